@@ -0,0 +1,121 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/bootstrap"
+)
+
+// RunBootstrapGen renders a gRPC xDS bootstrap configuration from the control plane settings,
+// i.e., the serving port, the xDS feature flags, and the authority name, and writes it to the
+// output file, or to stdout if the output file flag is empty. With the `-envoy` flag, it renders
+// an Envoy proxy static bootstrap configuration instead.
+func RunBootstrapGen(_ context.Context, flagset *flag.FlagSet, args []string) error {
+	logging.InitFlags(flagset)
+	var opts bootstrap.Options
+	var federationAuthorities, namespaceAuthorities, nodeMetadata, output string
+	var authoritiesOnly, envoy bool
+	flagset.StringVar(&opts.Authority, "authority", "", "authority name of the control plane, e.g., control-plane.xds.svc.cluster.local, defaults to the authority name of this Pod")
+	flagset.StringVar(&opts.ServerURI, "server-uri", "", "URI of the control plane management server, defaults to dns:///[authority]:[port]")
+	flagset.StringVar(&federationAuthorities, "federation-authorities", "", "comma-separated list of authority names of control planes in other clusters, used when enableFederation=true")
+	flagset.StringVar(&namespaceAuthorities, "namespace-authorities", "", "comma-separated list of additional authority names of this control plane, e.g., the authority names of namespaces in the informer configuration, used when enableFederation=true")
+	flagset.BoolVar(&authoritiesOnly, "authorities-only", false, "only render the authorities section of the bootstrap configuration")
+	flagset.StringVar(&opts.NodeID, "node-id", "", "xDS node ID, defaults to a random UUID")
+	flagset.StringVar(&opts.NodeCluster, "node-cluster", "", "xDS node cluster, e.g., the app name of the client")
+	flagset.StringVar(&opts.Zone, "zone", "", "xDS node locality zone")
+	flagset.StringVar(&opts.ClusterName, "cluster-name", "", "kubeconfig context name of the client's Kubernetes cluster, used when localityPriorityPolicy=clusterAndZone")
+	flagset.StringVar(&opts.CertificateFile, "certificate-file", "", "path of the client workload certificate chain file, defaults to /var/run/secrets/workload-spiffe-credentials/certificates.pem")
+	flagset.StringVar(&opts.PrivateKeyFile, "private-key-file", "", "path of the client workload private key file, defaults to /var/run/secrets/workload-spiffe-credentials/private_key.pem")
+	flagset.StringVar(&opts.CACertificateFile, "ca-certificate-file", "", "path of the CA certificates file, defaults to /var/run/secrets/workload-spiffe-credentials/ca_certificates.pem")
+	flagset.DurationVar(&opts.CertificateRefreshInterval, "certificate-refresh-interval", 0, "how often clients reload the certificate files, defaults to 10m")
+	flagset.BoolVar(&envoy, "envoy", false, "render an Envoy proxy static bootstrap configuration in YAML format, instead of a gRPC xDS bootstrap configuration")
+	flagset.StringVar(&nodeMetadata, "node-metadata", "", "comma-separated list of additional Envoy node metadata as key=value pairs, e.g., K8S_NAMESPACE=xds,K8S_POD=envoy-abc12")
+	flagset.IntVar(&opts.EnvoyAdminPort, "envoy-admin-port", 0, "port of the Envoy admin interface, defaults to 19000")
+	flagset.StringVar(&opts.ServerCertificateFile, "server-certificate-file", "", "path of the Envoy server certificate chain file, defaults to /etc/envoy-ssl/certificates.pem")
+	flagset.StringVar(&opts.ServerPrivateKeyFile, "server-private-key-file", "", "path of the Envoy server private key file, defaults to /etc/envoy-ssl/private_key.pem")
+	flagset.StringVar(&opts.ServerCACertificateFile, "server-ca-certificate-file", "", "path of the CA certificates file that Envoy uses to validate client certificates, defaults to /etc/envoy-ssl/ca_certificates.pem")
+	flagset.StringVar(&output, "output", "", "path of the bootstrap configuration file to write, defaults to stdout")
+	if err := flagset.Parse(args); err != nil {
+		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
+	}
+	logger := logging.NewLogger()
+	if federationAuthorities != "" {
+		opts.FederationAuthorities = strings.Split(federationAuthorities, ",")
+	}
+	if namespaceAuthorities != "" {
+		opts.NamespaceAuthorities = strings.Split(namespaceAuthorities, ",")
+	}
+	if nodeMetadata != "" {
+		opts.NodeMetadata = make(map[string]string)
+		for _, pair := range strings.Split(nodeMetadata, ",") {
+			key, value, found := strings.Cut(pair, "=")
+			if !found || key == "" {
+				return fmt.Errorf("invalid -node-metadata entry %q, expected key=value", pair)
+			}
+			opts.NodeMetadata[key] = value
+		}
+	}
+	var err error
+	if opts.Port, err = config.ServingPort(flagset); err != nil {
+		return fmt.Errorf("could not configure management server listening port: %w", err)
+	}
+	if opts.XDSFeatures, err = config.XDSFeatures(logger); err != nil {
+		return fmt.Errorf("could not initialize xDS feature flags: %w", err)
+	}
+	if opts.Authority == "" {
+		if opts.Authority, err = config.AuthorityName(logger); err != nil {
+			return fmt.Errorf("could not determine control plane authority name, set the -authority flag: %w", err)
+		}
+	}
+	if opts.NodeID == "" {
+		nodeID, err := uuid.NewRandom()
+		if err != nil {
+			return fmt.Errorf("could not generate xDS node ID: %w", err)
+		}
+		opts.NodeID = nodeID.String()
+	}
+	render := bootstrap.Render
+	description := "gRPC xDS bootstrap configuration"
+	switch {
+	case envoy:
+		render = bootstrap.RenderEnvoy
+		description = "Envoy bootstrap configuration"
+	case authoritiesOnly:
+		render = bootstrap.RenderAuthorities
+	}
+	data, err := render(opts)
+	if err != nil {
+		return err
+	}
+	logger.V(2).Info("Rendered "+description, "output", output)
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		return fmt.Errorf("could not write %s to file %s: %w", description, output, err)
+	}
+	return nil
+}
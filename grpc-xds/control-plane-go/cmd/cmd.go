@@ -16,8 +16,10 @@ package cmd
 
 import (
 	"context"
+	"errors"
 	"flag"
 	"fmt"
+	"io/fs"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/auth"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/config"
@@ -31,6 +33,7 @@ func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
 	ctx = signals.SetupSignalHandler(ctx)
 	logging.InitFlags(flagset)
 	informers.InitFlags(flagset)
+	config.InitServerFlags(flagset)
 	if err := flagset.Parse(args); err != nil {
 		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
 	}
@@ -38,16 +41,25 @@ func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
 	logging.SetGRPCLogger(logger)
 	ctx = logging.NewContext(ctx, logger)
 	auth.RegisterAll(ctx, logger)
-	servingPort, err := config.ServingPort()
+	serverConfig, err := config.ServerConfig(flagset)
 	if err != nil {
-		return fmt.Errorf("could not configure management server listening port: %w", err)
+		return fmt.Errorf("could not configure management server: %w", err)
 	}
-	healthPort, err := config.HealthPort()
+	fallbackApps, err := config.FallbackApplications(logger)
 	if err != nil {
-		return fmt.Errorf("could not configure management server health checking port: %w", err)
+		return fmt.Errorf("could not initialize fallback applications: %w", err)
 	}
-	kubecontexts, err := config.Kubecontexts(logger)
+	discoverySources, err := config.DiscoverySources(logger, flagset)
 	if err != nil {
+		return fmt.Errorf("could not configure discovery sources: %w", err)
+	}
+	kubecontexts, err := config.Kubecontexts(logger)
+	if errors.Is(err, fs.ErrNotExist) && (len(fallbackApps) > 0 || len(discoverySources) > 0) {
+		// Run without Kubernetes clusters, e.g., on a laptop with non-Kubernetes backends,
+		// or with synthetic applications.
+		logger.V(1).Info("No informer configuration, serving the fallback applications and the applications of other discovery sources", "apps", fallbackApps)
+		kubecontexts = nil
+	} else if err != nil {
 		return fmt.Errorf("could not initialize informer configuration: %w", err)
 	}
 	xdsFeatures, err := config.XDSFeatures(logger)
@@ -55,11 +67,66 @@ func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
 		return fmt.Errorf("could not initialize xDS feature flags: %w", err)
 	}
 	authority, err := config.AuthorityName(logger)
-	if err != nil {
+	if err != nil && kubecontexts == nil {
+		logger.V(1).Info("Could not determine control plane authority name, using the default", "authority", config.DefaultAuthorityName, "error", err.Error())
+		authority = config.DefaultAuthorityName
+	} else if err != nil {
 		return fmt.Errorf("could not determine control plane authority name: %w", err)
 	}
 	if xdsFeatures.EnableFederation {
 		logger.V(2).Info("Enabling xDS federation", "authority", authority)
 	}
-	return server.Run(ctx, servingPort, healthPort, kubecontexts, xdsFeatures, authority)
+	authorityServers, err := config.AuthorityServers(logger, serverConfig)
+	if err != nil {
+		return fmt.Errorf("could not configure authority servers: %w", err)
+	}
+	configReloadInterval, err := config.ConfigReloadInterval()
+	if err != nil {
+		return fmt.Errorf("could not configure config file reload interval: %w", err)
+	}
+	kubecontextHealth, err := config.KubecontextHealth()
+	if err != nil {
+		return fmt.Errorf("could not configure kubecontext health checking: %w", err)
+	}
+	nodeHashIdleTTL, err := config.NodeHashIdleTTL()
+	if err != nil {
+		return fmt.Errorf("could not configure node hash idle TTL: %w", err)
+	}
+	rbacPolicies, err := config.RBACPolicies(logger)
+	if err != nil {
+		return fmt.Errorf("could not initialize RBAC policies: %w", err)
+	}
+	routePolicies, err := config.RoutePolicies(logger)
+	if err != nil {
+		return fmt.Errorf("could not initialize route policies: %w", err)
+	}
+	jwtProviders, err := config.JWTProviders(logger)
+	if err != nil {
+		return fmt.Errorf("could not initialize JWT providers: %w", err)
+	}
+	externalBackends, err := config.ExternalBackends(logger)
+	if err != nil {
+		return fmt.Errorf("could not initialize external backends: %w", err)
+	}
+	canaryRollouts, err := config.CanaryRollouts(logger, xdsFeatures)
+	if err != nil {
+		return fmt.Errorf("could not initialize canary rollouts: %w", err)
+	}
+	return server.Run(ctx, server.Options{
+		Server:               serverConfig,
+		Kubecontexts:         kubecontexts,
+		DiscoverySources:     discoverySources,
+		XDSFeatures:          xdsFeatures,
+		Authority:            authority,
+		AuthorityServers:     authorityServers,
+		ConfigReloadInterval: configReloadInterval,
+		KubecontextHealth:    kubecontextHealth,
+		NodeHashIdleTTL:      nodeHashIdleTTL,
+		RBACPolicies:         rbacPolicies,
+		RoutePolicies:        routePolicies,
+		JWTProviders:         jwtProviders,
+		ExternalBackends:     externalBackends,
+		CanaryRollouts:       canaryRollouts,
+		FallbackApps:         fallbackApps,
+	})
 }
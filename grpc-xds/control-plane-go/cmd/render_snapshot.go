@@ -0,0 +1,258 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/go-logr/logr"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+var (
+	errNoEndpointsFile     = errors.New("the -endpoints flag is required")
+	errUnknownFormat       = errors.New("the -format flag must be one of json or yaml")
+	errInvalidNodeMetadata = errors.New("the -node-metadata flag must be a comma-separated list of key=value fields")
+)
+
+// RunRenderSnapshot renders the xDS resource snapshot that the control plane would serve to an
+// xDS client in the provided zone and cluster, and writes it to the output file, or to stdout if
+// the output file flag is empty.
+//
+// The applications and their endpoints are read from a static YAML file instead of from
+// Kubernetes clusters. Applications are included if the informer configuration selects them
+// by kubeconfig context, namespace, and service name. Label selectors are ignored.
+func RunRenderSnapshot(_ context.Context, flagset *flag.FlagSet, args []string) error {
+	logging.InitFlags(flagset)
+	var endpoints, zone, clusterName, nodeMetadata, serverListenerAddresses, authority, format, output string
+	flagset.StringVar(&endpoints, "endpoints", "", "path of the YAML file with the static list of applications and their endpoints")
+	flagset.StringVar(&zone, "zone", "", "xDS node locality zone")
+	flagset.StringVar(&clusterName, "cluster-name", "", "kubeconfig context name of the client's Kubernetes cluster, used when localityPriorityPolicy=clusterAndZone")
+	flagset.StringVar(&nodeMetadata, "node-metadata", "", "comma-separated list of key=value node metadata fields of the client, used by featureOverrides")
+	flagset.StringVar(&serverListenerAddresses, "server-listener-addresses", "", "comma-separated list of host:port addresses of xDS-enabled gRPC servers to render server Listeners for")
+	flagset.StringVar(&authority, "authority", "control-plane.xds.svc.cluster.local", "authority name of the control plane, used when enableFederation=true")
+	flagset.StringVar(&format, "format", "json", "output format, either json or yaml")
+	flagset.StringVar(&output, "output", "", "path of the snapshot file to write, defaults to stdout")
+	if err := flagset.Parse(args); err != nil {
+		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
+	}
+	logger := logging.NewLogger()
+	if endpoints == "" {
+		return errNoEndpointsFile
+	}
+	if format != "json" && format != "yaml" {
+		return fmt.Errorf("%w: format=%s", errUnknownFormat, format)
+	}
+	kubecontexts, err := config.Kubecontexts(logger)
+	if err != nil {
+		return fmt.Errorf("could not create informer configurations: %w", err)
+	}
+	xdsFeatures, err := config.XDSFeatures(logger)
+	if err != nil {
+		return fmt.Errorf("could not initialize xDS feature flags: %w", err)
+	}
+	rbacPolicies, err := config.RBACPolicies(logger)
+	if err != nil {
+		return fmt.Errorf("could not load RBAC policies: %w", err)
+	}
+	routePolicies, err := config.RoutePolicies(logger)
+	if err != nil {
+		return fmt.Errorf("could not load route policies: %w", err)
+	}
+	jwtProviders, err := config.JWTProviders(logger)
+	if err != nil {
+		return fmt.Errorf("could not load JWT providers: %w", err)
+	}
+	externalBackends, err := config.ExternalBackends(logger)
+	if err != nil {
+		return fmt.Errorf("could not load external backends: %w", err)
+	}
+	staticApps, err := config.StaticApplications(logger, endpoints)
+	if err != nil {
+		return fmt.Errorf("could not load static applications: %w", err)
+	}
+	apps, err := selectApplications(logger, kubecontexts, staticApps)
+	if err != nil {
+		return err
+	}
+	addresses, err := parseEndpointAddresses(serverListenerAddresses)
+	if err != nil {
+		return err
+	}
+	metadata, err := parseNodeMetadata(nodeMetadata)
+	if err != nil {
+		return err
+	}
+	nodeHashFn, localityPriorityMapper := xdsFeatures.NodeHash()
+	nodeHash := nodeHashFn.ID(newNode(zone, clusterName, metadata))
+	snapshotBuilder, err := xds.NewSnapshotBuilder(nodeHash, localityPriorityMapper, xdsFeatures, authority).
+		WithRoutePolicies(routePolicies).
+		AddGRPCApplications(apps)
+	if err != nil {
+		return fmt.Errorf("could not create xDS resource snapshot builder for nodeHash=%s: %w", nodeHash, err)
+	}
+	snapshotBuilder, err = snapshotBuilder.AddExternalBackends(externalBackends)
+	if err != nil {
+		return fmt.Errorf("could not add external backends to xDS resource snapshot builder for nodeHash=%s: %w", nodeHash, err)
+	}
+	snapshot, err := snapshotBuilder.
+		AddGRPCServerListenerAddresses(addresses).
+		AddRBACPolicies(rbacPolicies).
+		AddJWTProviders(jwtProviders).
+		Build()
+	if err != nil {
+		return fmt.Errorf("could not create xDS resource snapshot for nodeHash=%s: %w", nodeHash, err)
+	}
+	data, err := xds.MarshalSnapshotJSON(snapshot)
+	if err != nil {
+		return err
+	}
+	if format == "yaml" {
+		if data, err = jsonToYAML(data); err != nil {
+			return err
+		}
+	}
+	logger.V(2).Info("Rendered xDS resource snapshot", "nodeHash", nodeHash, "output", output)
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		return fmt.Errorf("could not write xDS resource snapshot to file %s: %w", output, err)
+	}
+	return nil
+}
+
+// selectApplications returns the static applications selected by the informer configurations.
+func selectApplications(logger logr.Logger, kubecontexts []informers.Kubecontext, staticApps []config.StaticApplication) ([]applications.Application, error) {
+	var apps []applications.Application
+	for _, staticApp := range staticApps {
+		if !selected(kubecontexts, staticApp) {
+			logger.V(4).Info("Skipping static application not selected by the informer configuration", "context", staticApp.Context, "namespace", staticApp.Namespace, "name", staticApp.Name)
+			continue
+		}
+		app, err := staticApp.Application()
+		if err != nil {
+			return nil, fmt.Errorf("could not convert static application %s/%s: %w", staticApp.Namespace, staticApp.Name, err)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+func selected(kubecontexts []informers.Kubecontext, staticApp config.StaticApplication) bool {
+	for _, kubecontext := range kubecontexts {
+		if kubecontext.Context != staticApp.Context {
+			continue
+		}
+		for _, informerConfig := range kubecontext.Informers {
+			if informerConfig.Namespace == staticApp.Namespace &&
+				(informerConfig.AllServices() || slices.Contains(informerConfig.Services, staticApp.Name)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// parseEndpointAddresses parses a comma-separated list of host:port addresses.
+func parseEndpointAddresses(addresses string) ([]xds.EndpointAddress, error) {
+	if addresses == "" {
+		return nil, nil
+	}
+	var endpointAddresses []xds.EndpointAddress
+	for _, address := range strings.Split(addresses, ",") {
+		host, portStr, err := net.SplitHostPort(address)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse server listener address %s: %w", address, err)
+		}
+		port, err := strconv.ParseUint(portStr, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse port of server listener address %s: %w", address, err)
+		}
+		endpointAddresses = append(endpointAddresses, xds.EndpointAddress{
+			Host: host,
+			Port: uint32(port),
+		})
+	}
+	return endpointAddresses, nil
+}
+
+// parseNodeMetadata parses a comma-separated list of key=value node metadata fields.
+func parseNodeMetadata(nodeMetadata string) (map[string]string, error) {
+	metadata := map[string]string{}
+	if nodeMetadata == "" {
+		return metadata, nil
+	}
+	for _, field := range strings.Split(nodeMetadata, ",") {
+		key, value, found := strings.Cut(field, "=")
+		if !found || key == "" {
+			return nil, fmt.Errorf("%w: %s", errInvalidNodeMetadata, field)
+		}
+		metadata[key] = value
+	}
+	return metadata, nil
+}
+
+// newNode returns an xDS node with the locality zone, and with the cluster name and other node
+// metadata used by the node hash functions.
+func newNode(zone string, clusterName string, metadata map[string]string) *corev3.Node {
+	node := &corev3.Node{
+		Locality: &corev3.Locality{
+			Zone: zone,
+		},
+	}
+	if clusterName != "" {
+		metadata[xds.NodeMetadataClusterName] = clusterName
+	}
+	if len(metadata) > 0 {
+		node.Metadata = &structpb.Struct{
+			Fields: map[string]*structpb.Value{},
+		}
+		for key, value := range metadata {
+			node.Metadata.Fields[key] = structpb.NewStringValue(value)
+		}
+	}
+	return node
+}
+
+func jsonToYAML(data []byte) ([]byte, error) {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return nil, fmt.Errorf("could not unmarshal xDS resource snapshot JSON: %w", err)
+	}
+	yamlBytes, err := yaml.Marshal(value)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal xDS resource snapshot to YAML: %w", err)
+	}
+	return yamlBytes, nil
+}
@@ -0,0 +1,359 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: admin/admin.proto
+
+package adminpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ControlPlaneAdmin_ListNodeHashes_FullMethodName      = "/controlplane.ControlPlaneAdmin/ListNodeHashes"
+	ControlPlaneAdmin_DumpSnapshot_FullMethodName        = "/controlplane.ControlPlaneAdmin/DumpSnapshot"
+	ControlPlaneAdmin_RegenerateSnapshots_FullMethodName = "/controlplane.ControlPlaneAdmin/RegenerateSnapshots"
+	ControlPlaneAdmin_SetFeatureFlag_FullMethodName      = "/controlplane.ControlPlaneAdmin/SetFeatureFlag"
+	ControlPlaneAdmin_SetMaintenanceMode_FullMethodName  = "/controlplane.ControlPlaneAdmin/SetMaintenanceMode"
+	ControlPlaneAdmin_SwitchBlueGreen_FullMethodName     = "/controlplane.ControlPlaneAdmin/SwitchBlueGreen"
+)
+
+// ControlPlaneAdminClient is the client API for ControlPlaneAdmin service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The control plane admin service definition.
+//
+// Lets workshop facilitators inspect and change the state of the control
+// plane at runtime, without editing the ConfigMap with the config files. The
+// control plane serves this service on the health port only.
+type ControlPlaneAdminClient interface {
+	// Returns the node hashes that have xDS resource snapshots in the cache.
+	ListNodeHashes(ctx context.Context, in *ListNodeHashesRequest, opts ...grpc.CallOption) (*ListNodeHashesResponse, error)
+	// Returns the xDS resources of the snapshot for a node hash, as JSON.
+	DumpSnapshot(ctx context.Context, in *DumpSnapshotRequest, opts ...grpc.CallOption) (*DumpSnapshotResponse, error)
+	// Generates new xDS resource snapshots for all node hashes.
+	RegenerateSnapshots(ctx context.Context, in *RegenerateSnapshotsRequest, opts ...grpc.CallOption) (*RegenerateSnapshotsResponse, error)
+	// Changes the value of an xDS feature flag, and generates new xDS resource
+	// snapshots. The change lasts until the next change to the xDS feature flags
+	// config file.
+	SetFeatureFlag(ctx context.Context, in *SetFeatureFlagRequest, opts ...grpc.CallOption) (*SetFeatureFlagResponse, error)
+	// Puts an application in maintenance mode, or removes it from maintenance
+	// mode, and generates new xDS resource snapshots.
+	SetMaintenanceMode(ctx context.Context, in *SetMaintenanceModeRequest, opts ...grpc.CallOption) (*SetMaintenanceModeResponse, error)
+	// Routes all requests to an application to a target application, e.g.,
+	// from a blue to a green deployment, and generates new xDS resource
+	// snapshots. The target application must have healthy endpoints.
+	SwitchBlueGreen(ctx context.Context, in *SwitchBlueGreenRequest, opts ...grpc.CallOption) (*SwitchBlueGreenResponse, error)
+}
+
+type controlPlaneAdminClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewControlPlaneAdminClient(cc grpc.ClientConnInterface) ControlPlaneAdminClient {
+	return &controlPlaneAdminClient{cc}
+}
+
+func (c *controlPlaneAdminClient) ListNodeHashes(ctx context.Context, in *ListNodeHashesRequest, opts ...grpc.CallOption) (*ListNodeHashesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ListNodeHashesResponse)
+	err := c.cc.Invoke(ctx, ControlPlaneAdmin_ListNodeHashes_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlPlaneAdminClient) DumpSnapshot(ctx context.Context, in *DumpSnapshotRequest, opts ...grpc.CallOption) (*DumpSnapshotResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DumpSnapshotResponse)
+	err := c.cc.Invoke(ctx, ControlPlaneAdmin_DumpSnapshot_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlPlaneAdminClient) RegenerateSnapshots(ctx context.Context, in *RegenerateSnapshotsRequest, opts ...grpc.CallOption) (*RegenerateSnapshotsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(RegenerateSnapshotsResponse)
+	err := c.cc.Invoke(ctx, ControlPlaneAdmin_RegenerateSnapshots_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlPlaneAdminClient) SetFeatureFlag(ctx context.Context, in *SetFeatureFlagRequest, opts ...grpc.CallOption) (*SetFeatureFlagResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetFeatureFlagResponse)
+	err := c.cc.Invoke(ctx, ControlPlaneAdmin_SetFeatureFlag_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlPlaneAdminClient) SetMaintenanceMode(ctx context.Context, in *SetMaintenanceModeRequest, opts ...grpc.CallOption) (*SetMaintenanceModeResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SetMaintenanceModeResponse)
+	err := c.cc.Invoke(ctx, ControlPlaneAdmin_SetMaintenanceMode_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *controlPlaneAdminClient) SwitchBlueGreen(ctx context.Context, in *SwitchBlueGreenRequest, opts ...grpc.CallOption) (*SwitchBlueGreenResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(SwitchBlueGreenResponse)
+	err := c.cc.Invoke(ctx, ControlPlaneAdmin_SwitchBlueGreen_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ControlPlaneAdminServer is the server API for ControlPlaneAdmin service.
+// All implementations must embed UnimplementedControlPlaneAdminServer
+// for forward compatibility.
+//
+// The control plane admin service definition.
+//
+// Lets workshop facilitators inspect and change the state of the control
+// plane at runtime, without editing the ConfigMap with the config files. The
+// control plane serves this service on the health port only.
+type ControlPlaneAdminServer interface {
+	// Returns the node hashes that have xDS resource snapshots in the cache.
+	ListNodeHashes(context.Context, *ListNodeHashesRequest) (*ListNodeHashesResponse, error)
+	// Returns the xDS resources of the snapshot for a node hash, as JSON.
+	DumpSnapshot(context.Context, *DumpSnapshotRequest) (*DumpSnapshotResponse, error)
+	// Generates new xDS resource snapshots for all node hashes.
+	RegenerateSnapshots(context.Context, *RegenerateSnapshotsRequest) (*RegenerateSnapshotsResponse, error)
+	// Changes the value of an xDS feature flag, and generates new xDS resource
+	// snapshots. The change lasts until the next change to the xDS feature flags
+	// config file.
+	SetFeatureFlag(context.Context, *SetFeatureFlagRequest) (*SetFeatureFlagResponse, error)
+	// Puts an application in maintenance mode, or removes it from maintenance
+	// mode, and generates new xDS resource snapshots.
+	SetMaintenanceMode(context.Context, *SetMaintenanceModeRequest) (*SetMaintenanceModeResponse, error)
+	// Routes all requests to an application to a target application, e.g.,
+	// from a blue to a green deployment, and generates new xDS resource
+	// snapshots. The target application must have healthy endpoints.
+	SwitchBlueGreen(context.Context, *SwitchBlueGreenRequest) (*SwitchBlueGreenResponse, error)
+	mustEmbedUnimplementedControlPlaneAdminServer()
+}
+
+// UnimplementedControlPlaneAdminServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedControlPlaneAdminServer struct{}
+
+func (UnimplementedControlPlaneAdminServer) ListNodeHashes(context.Context, *ListNodeHashesRequest) (*ListNodeHashesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListNodeHashes not implemented")
+}
+func (UnimplementedControlPlaneAdminServer) DumpSnapshot(context.Context, *DumpSnapshotRequest) (*DumpSnapshotResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DumpSnapshot not implemented")
+}
+func (UnimplementedControlPlaneAdminServer) RegenerateSnapshots(context.Context, *RegenerateSnapshotsRequest) (*RegenerateSnapshotsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RegenerateSnapshots not implemented")
+}
+func (UnimplementedControlPlaneAdminServer) SetFeatureFlag(context.Context, *SetFeatureFlagRequest) (*SetFeatureFlagResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetFeatureFlag not implemented")
+}
+func (UnimplementedControlPlaneAdminServer) SetMaintenanceMode(context.Context, *SetMaintenanceModeRequest) (*SetMaintenanceModeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetMaintenanceMode not implemented")
+}
+func (UnimplementedControlPlaneAdminServer) SwitchBlueGreen(context.Context, *SwitchBlueGreenRequest) (*SwitchBlueGreenResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SwitchBlueGreen not implemented")
+}
+func (UnimplementedControlPlaneAdminServer) mustEmbedUnimplementedControlPlaneAdminServer() {}
+func (UnimplementedControlPlaneAdminServer) testEmbeddedByValue()                           {}
+
+// UnsafeControlPlaneAdminServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ControlPlaneAdminServer will
+// result in compilation errors.
+type UnsafeControlPlaneAdminServer interface {
+	mustEmbedUnimplementedControlPlaneAdminServer()
+}
+
+func RegisterControlPlaneAdminServer(s grpc.ServiceRegistrar, srv ControlPlaneAdminServer) {
+	// If the following call pancis, it indicates UnimplementedControlPlaneAdminServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ControlPlaneAdmin_ServiceDesc, srv)
+}
+
+func _ControlPlaneAdmin_ListNodeHashes_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListNodeHashesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlPlaneAdminServer).ListNodeHashes(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlPlaneAdmin_ListNodeHashes_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlPlaneAdminServer).ListNodeHashes(ctx, req.(*ListNodeHashesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlPlaneAdmin_DumpSnapshot_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DumpSnapshotRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlPlaneAdminServer).DumpSnapshot(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlPlaneAdmin_DumpSnapshot_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlPlaneAdminServer).DumpSnapshot(ctx, req.(*DumpSnapshotRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlPlaneAdmin_RegenerateSnapshots_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RegenerateSnapshotsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlPlaneAdminServer).RegenerateSnapshots(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlPlaneAdmin_RegenerateSnapshots_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlPlaneAdminServer).RegenerateSnapshots(ctx, req.(*RegenerateSnapshotsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlPlaneAdmin_SetFeatureFlag_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetFeatureFlagRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlPlaneAdminServer).SetFeatureFlag(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlPlaneAdmin_SetFeatureFlag_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlPlaneAdminServer).SetFeatureFlag(ctx, req.(*SetFeatureFlagRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlPlaneAdmin_SetMaintenanceMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetMaintenanceModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlPlaneAdminServer).SetMaintenanceMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlPlaneAdmin_SetMaintenanceMode_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlPlaneAdminServer).SetMaintenanceMode(ctx, req.(*SetMaintenanceModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ControlPlaneAdmin_SwitchBlueGreen_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SwitchBlueGreenRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ControlPlaneAdminServer).SwitchBlueGreen(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ControlPlaneAdmin_SwitchBlueGreen_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ControlPlaneAdminServer).SwitchBlueGreen(ctx, req.(*SwitchBlueGreenRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ControlPlaneAdmin_ServiceDesc is the grpc.ServiceDesc for ControlPlaneAdmin service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ControlPlaneAdmin_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "controlplane.ControlPlaneAdmin",
+	HandlerType: (*ControlPlaneAdminServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "ListNodeHashes",
+			Handler:    _ControlPlaneAdmin_ListNodeHashes_Handler,
+		},
+		{
+			MethodName: "DumpSnapshot",
+			Handler:    _ControlPlaneAdmin_DumpSnapshot_Handler,
+		},
+		{
+			MethodName: "RegenerateSnapshots",
+			Handler:    _ControlPlaneAdmin_RegenerateSnapshots_Handler,
+		},
+		{
+			MethodName: "SetFeatureFlag",
+			Handler:    _ControlPlaneAdmin_SetFeatureFlag_Handler,
+		},
+		{
+			MethodName: "SetMaintenanceMode",
+			Handler:    _ControlPlaneAdmin_SetMaintenanceMode_Handler,
+		},
+		{
+			MethodName: "SwitchBlueGreen",
+			Handler:    _ControlPlaneAdmin_SwitchBlueGreen_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "admin/admin.proto",
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gateway contains the subset of the Kubernetes Gateway API GRPCRoute
+// resource that the control plane translates into xDS route configurations.
+//
+// The control plane reads GRPCRoute resources using the dynamic client, so this
+// package declares only the fields it uses, instead of depending on
+// `sigs.k8s.io/gateway-api`. See https://gateway-api.sigs.k8s.io/api-types/grpcroute/
+package gateway
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	MatchTypeExact             = "Exact"
+	MatchTypeRegularExpression = "RegularExpression"
+)
+
+// GRPCRouteGVR identifies the GRPCRoute resource for the dynamic client.
+var GRPCRouteGVR = schema.GroupVersionResource{
+	Group:    "gateway.networking.k8s.io",
+	Version:  "v1",
+	Resource: "grpcroutes",
+}
+
+type GRPCRoute struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              GRPCRouteSpec `json:"spec,omitempty"`
+}
+
+type GRPCRouteSpec struct {
+	// Hostnames are used as the xDS listener names, e.g., `greeter-leaf` for the
+	// channel target `xds:///greeter-leaf`.
+	Hostnames []string        `json:"hostnames,omitempty"`
+	Rules     []GRPCRouteRule `json:"rules,omitempty"`
+}
+
+type GRPCRouteRule struct {
+	Matches     []GRPCRouteMatch `json:"matches,omitempty"`
+	BackendRefs []GRPCBackendRef `json:"backendRefs,omitempty"`
+}
+
+type GRPCRouteMatch struct {
+	Method  *GRPCMethodMatch  `json:"method,omitempty"`
+	Headers []GRPCHeaderMatch `json:"headers,omitempty"`
+}
+
+type GRPCMethodMatch struct {
+	// Type is `Exact` (default) or `RegularExpression`.
+	Type    string `json:"type,omitempty"`
+	Service string `json:"service,omitempty"`
+	Method  string `json:"method,omitempty"`
+}
+
+type GRPCHeaderMatch struct {
+	// Type is `Exact` (default) or `RegularExpression`.
+	Type  string `json:"type,omitempty"`
+	Name  string `json:"name"`
+	Value string `json:"value"`
+}
+
+type GRPCBackendRef struct {
+	// Name of the Kubernetes Service.
+	Name string `json:"name"`
+	// Namespace of the Kubernetes Service, defaults to the Namespace of the GRPCRoute.
+	Namespace *string `json:"namespace,omitempty"`
+	// Port of the Kubernetes Service.
+	Port *int32 `json:"port,omitempty"`
+	// Weight defaults to 1.
+	Weight *int32 `json:"weight,omitempty"`
+}
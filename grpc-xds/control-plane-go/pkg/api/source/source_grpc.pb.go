@@ -0,0 +1,199 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: source/source.proto
+
+package sourcepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ApplicationSource_PutApplications_FullMethodName    = "/controlplane.ApplicationSource/PutApplications"
+	ApplicationSource_DeleteApplications_FullMethodName = "/controlplane.ApplicationSource/DeleteApplications"
+)
+
+// ApplicationSourceClient is the client API for ApplicationSource service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The application source service definition.
+//
+// Lets external programs push applications directly into the control plane,
+// as an alternative to the Kubernetes informers, e.g., to discover endpoints
+// from Consul, from static lists, or from CI tests. The control plane
+// generates xDS resources for these applications the same way as for
+// applications discovered from Kubernetes EndpointSlices. The control plane
+// serves this service on the health port only.
+type ApplicationSourceClient interface {
+	// Replaces the applications of a source in a namespace, and generates new
+	// xDS resource snapshots if the applications changed.
+	PutApplications(ctx context.Context, in *PutApplicationsRequest, opts ...grpc.CallOption) (*PutApplicationsResponse, error)
+	// Removes the applications of a source, either in one namespace, or in all
+	// namespaces, and generates new xDS resource snapshots.
+	DeleteApplications(ctx context.Context, in *DeleteApplicationsRequest, opts ...grpc.CallOption) (*DeleteApplicationsResponse, error)
+}
+
+type applicationSourceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewApplicationSourceClient(cc grpc.ClientConnInterface) ApplicationSourceClient {
+	return &applicationSourceClient{cc}
+}
+
+func (c *applicationSourceClient) PutApplications(ctx context.Context, in *PutApplicationsRequest, opts ...grpc.CallOption) (*PutApplicationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(PutApplicationsResponse)
+	err := c.cc.Invoke(ctx, ApplicationSource_PutApplications_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *applicationSourceClient) DeleteApplications(ctx context.Context, in *DeleteApplicationsRequest, opts ...grpc.CallOption) (*DeleteApplicationsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(DeleteApplicationsResponse)
+	err := c.cc.Invoke(ctx, ApplicationSource_DeleteApplications_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ApplicationSourceServer is the server API for ApplicationSource service.
+// All implementations must embed UnimplementedApplicationSourceServer
+// for forward compatibility.
+//
+// The application source service definition.
+//
+// Lets external programs push applications directly into the control plane,
+// as an alternative to the Kubernetes informers, e.g., to discover endpoints
+// from Consul, from static lists, or from CI tests. The control plane
+// generates xDS resources for these applications the same way as for
+// applications discovered from Kubernetes EndpointSlices. The control plane
+// serves this service on the health port only.
+type ApplicationSourceServer interface {
+	// Replaces the applications of a source in a namespace, and generates new
+	// xDS resource snapshots if the applications changed.
+	PutApplications(context.Context, *PutApplicationsRequest) (*PutApplicationsResponse, error)
+	// Removes the applications of a source, either in one namespace, or in all
+	// namespaces, and generates new xDS resource snapshots.
+	DeleteApplications(context.Context, *DeleteApplicationsRequest) (*DeleteApplicationsResponse, error)
+	mustEmbedUnimplementedApplicationSourceServer()
+}
+
+// UnimplementedApplicationSourceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedApplicationSourceServer struct{}
+
+func (UnimplementedApplicationSourceServer) PutApplications(context.Context, *PutApplicationsRequest) (*PutApplicationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PutApplications not implemented")
+}
+func (UnimplementedApplicationSourceServer) DeleteApplications(context.Context, *DeleteApplicationsRequest) (*DeleteApplicationsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteApplications not implemented")
+}
+func (UnimplementedApplicationSourceServer) mustEmbedUnimplementedApplicationSourceServer() {}
+func (UnimplementedApplicationSourceServer) testEmbeddedByValue()                           {}
+
+// UnsafeApplicationSourceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ApplicationSourceServer will
+// result in compilation errors.
+type UnsafeApplicationSourceServer interface {
+	mustEmbedUnimplementedApplicationSourceServer()
+}
+
+func RegisterApplicationSourceServer(s grpc.ServiceRegistrar, srv ApplicationSourceServer) {
+	// If the following call pancis, it indicates UnimplementedApplicationSourceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ApplicationSource_ServiceDesc, srv)
+}
+
+func _ApplicationSource_PutApplications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PutApplicationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationSourceServer).PutApplications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ApplicationSource_PutApplications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationSourceServer).PutApplications(ctx, req.(*PutApplicationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ApplicationSource_DeleteApplications_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteApplicationsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ApplicationSourceServer).DeleteApplications(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ApplicationSource_DeleteApplications_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ApplicationSourceServer).DeleteApplications(ctx, req.(*DeleteApplicationsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ApplicationSource_ServiceDesc is the grpc.ServiceDesc for ApplicationSource service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ApplicationSource_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "controlplane.ApplicationSource",
+	HandlerType: (*ApplicationSourceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "PutApplications",
+			Handler:    _ApplicationSource_PutApplications_Handler,
+		},
+		{
+			MethodName: "DeleteApplications",
+			Handler:    _ApplicationSource_DeleteApplications_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "source/source.proto",
+}
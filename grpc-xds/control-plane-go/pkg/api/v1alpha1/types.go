@@ -0,0 +1,105 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package v1alpha1 contains the types of the XDSApplication custom resource.
+//
+// The custom resource definition is in the directory `k8s/control-plane/components/xds-applications`.
+// The control plane reads XDSApplication resources using the dynamic client,
+// so the types do not implement `runtime.Object`, and there is no generated clientset.
+package v1alpha1
+
+import (
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	GroupName = "grpc-xds.solutions-workshops.example.com"
+	Version   = "v1alpha1"
+	Kind      = "XDSApplication"
+	Resource  = "xdsapplications"
+)
+
+// XDSApplicationGVR identifies the XDSApplication resource for the dynamic client.
+var XDSApplicationGVR = schema.GroupVersionResource{
+	Group:    GroupName,
+	Version:  Version,
+	Resource: Resource,
+}
+
+// XDSApplication declares the routing, TLS, and health checking settings of an
+// application that clients discover using xDS.
+type XDSApplication struct {
+	metav1.TypeMeta   `json:",inline"`
+	metav1.ObjectMeta `json:"metadata,omitempty"`
+	Spec              XDSApplicationSpec `json:"spec,omitempty"`
+}
+
+type XDSApplicationSpec struct {
+	// Service is the name of the Kubernetes Service that provides the endpoints.
+	// Defaults to the name of the XDSApplication.
+	Service     string          `json:"service,omitempty"`
+	Routing     RoutingSpec     `json:"routing,omitempty"`
+	TLS         TLSSpec         `json:"tls,omitempty"`
+	HealthCheck HealthCheckSpec `json:"healthCheck,omitempty"`
+}
+
+type RoutingSpec struct {
+	// PathPrefix of the route to the application. Defaults to matching all paths.
+	PathPrefix string `json:"pathPrefix,omitempty"`
+}
+
+type TLSSpec struct {
+	// ServiceAccountName of the application Pods, used to verify the server identity
+	// when data plane TLS is enabled. Defaults to the name of the XDSApplication.
+	ServiceAccountName string `json:"serviceAccountName,omitempty"`
+	// SubjectAltNames of the server certificates that clients accept, e.g., when the application
+	// Pods use certificates from another issuer. Defaults to the SPIFFE ID of the ServiceAccount.
+	SubjectAltNames []SubjectAltNameSpec `json:"subjectAltNames,omitempty"`
+}
+
+// SubjectAltNameSpec matches one subject alternative name. Set exactly one of the fields.
+type SubjectAltNameSpec struct {
+	// SPIFFEID is an exact SPIFFE ID, e.g., `spiffe://example.com/ns/xds/sa/greeter`.
+	SPIFFEID string `json:"spiffeId,omitempty"`
+	// DNS is an exact DNS name.
+	DNS string `json:"dns,omitempty"`
+	// Regex is an RE2 regular expression that matches the whole SAN.
+	Regex string `json:"regex,omitempty"`
+}
+
+type HealthCheckSpec struct {
+	// Port overrides the health check port discovered from the EndpointSlices.
+	Port uint32 `json:"port,omitempty"`
+	// Protocol overrides the health check protocol discovered from the EndpointSlices.
+	Protocol string `json:"protocol,omitempty"`
+	// GRPCService is the service name of gRPC health checks. Defaults to the overall health of the server.
+	GRPCService string `json:"grpcService,omitempty"`
+}
+
+// ServiceName returns the name of the Kubernetes Service that provides the endpoints.
+func (a *XDSApplication) ServiceName() string {
+	if a.Spec.Service != "" {
+		return a.Spec.Service
+	}
+	return a.Name
+}
+
+// ServiceAccountName returns the Kubernetes ServiceAccount name of the application Pods.
+func (a *XDSApplication) ServiceAccountName() string {
+	if a.Spec.TLS.ServiceAccountName != "" {
+		return a.Spec.TLS.ServiceAccountName
+	}
+	return a.Name
+}
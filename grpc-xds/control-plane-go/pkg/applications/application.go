@@ -17,19 +17,47 @@ package applications
 import (
 	"slices"
 	"strings"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
 )
 
 // Application represents an application, e.g., a gRPC server, that clients discover using xDS.
+//
+// Authority is the xDS federation authority name of the application. If empty, the application
+// uses the authority name of the control plane.
+//
+// HealthCheckGRPCService is the service name that gRPC health checks request the serving status
+// of. If empty, gRPC health checks request the overall health of the server.
 type Application struct {
-	Namespace           string
-	ServiceAccountName  string
+	Namespace          string
+	Authority          string
+	ServiceAccountName string
+	// SubjectAltNames match the SANs of the server certificates of the application. If empty,
+	// server certificates must have the SPIFFE ID of the Namespace and ServiceAccountName.
+	SubjectAltNames     []tls.SubjectAltNameMatcher
 	Name                string
 	PathPrefix          string
 	ServingPort         uint32
 	ServingProtocol     string
 	HealthCheckPort     uint32
 	HealthCheckProtocol string
-	Endpoints           []ApplicationEndpoints
+	// HealthCheckGRPCService is only used if HealthCheckProtocol is `grpc`.
+	HealthCheckGRPCService string
+	Endpoints              []ApplicationEndpoints
+}
+
+// httpProtocols are the serving protocols of applications that clients reach using HTTP or gRPC.
+// See `findProtocol()` in the `informers` package for how the protocol is determined.
+var httpProtocols = map[string]bool{
+	"grpc":              true,
+	"grpcs":             true,
+	"h2c":               true,
+	"http":              true,
+	"http2":             true,
+	"https":             true,
+	"kubernetes.io/h2c": true,
+	"kubernetes.io/ws":  true,
+	"kubernetes.io/wss": true,
 }
 
 // NewApplication is a convenience function that creates a Application where the
@@ -53,15 +81,36 @@ func NewApplication(namespace string, name string, servingPort uint32, servingPr
 	}
 }
 
+// ServesHTTP returns true if the serving protocol of the application is HTTP or gRPC, or not set.
+// Other protocols, e.g., `tcp`, require TCP proxy listeners.
+func (a Application) ServesHTTP() bool {
+	return a.ServingProtocol == "" || httpProtocols[strings.ToLower(a.ServingProtocol)]
+}
+
+// HealthCheckPathOrGRPCService returns the gRPC service name for gRPC health checks, and an
+// empty string for other health check protocols.
+func (a Application) HealthCheckPathOrGRPCService() string {
+	if !strings.EqualFold(a.HealthCheckProtocol, "grpc") {
+		return ""
+	}
+	return a.HealthCheckGRPCService
+}
+
 // Compare assumes that the list of endpoints is sorted,
 // as done in `NewApplication()`.
 func (a Application) Compare(b Application) int {
 	if a.Namespace != b.Namespace {
 		return strings.Compare(a.Namespace, b.Namespace)
 	}
+	if a.Authority != b.Authority {
+		return strings.Compare(a.Authority, b.Authority)
+	}
 	if a.ServiceAccountName != b.ServiceAccountName {
 		return strings.Compare(a.ServiceAccountName, b.ServiceAccountName)
 	}
+	if c := slices.CompareFunc(a.SubjectAltNames, b.SubjectAltNames, tls.SubjectAltNameMatcher.Compare); c != 0 {
+		return c
+	}
 	if a.Name != b.Name {
 		return strings.Compare(a.Name, b.Name)
 	}
@@ -80,6 +129,9 @@ func (a Application) Compare(b Application) int {
 	if a.HealthCheckProtocol != b.HealthCheckProtocol {
 		return strings.Compare(a.HealthCheckProtocol, b.HealthCheckProtocol)
 	}
+	if a.HealthCheckGRPCService != b.HealthCheckGRPCService {
+		return strings.Compare(a.HealthCheckGRPCService, b.HealthCheckGRPCService)
+	}
 	return slices.CompareFunc(a.Endpoints, b.Endpoints,
 		func(e ApplicationEndpoints, f ApplicationEndpoints) int {
 			return e.Compare(f)
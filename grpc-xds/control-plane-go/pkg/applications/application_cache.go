@@ -15,20 +15,19 @@
 package applications
 
 import (
-	"fmt"
 	"slices"
 	"sync"
 )
 
-// The ApplicationCache key is `<kubecontext>/<namespace>`.
+// The ApplicationCache stores applications by kubecontext and namespace.
 type ApplicationCache struct {
 	mu    sync.RWMutex
-	cache map[string][]Application
+	cache map[cacheKey][]Application
 }
 
 func NewApplicationCache() *ApplicationCache {
 	return &ApplicationCache{
-		cache: map[string][]Application{},
+		cache: map[cacheKey][]Application{},
 	}
 }
 
@@ -51,6 +50,35 @@ func (c *ApplicationCache) Put(kubecontextName string, namespace string, apps []
 	})
 }
 
+// Delete removes the applications of the namespace in the kubecontext.
+// Delete returns true iff the cache changed.
+func (c *ApplicationCache) Delete(kubecontextName string, namespace string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := key(kubecontextName, namespace)
+	apps, exists := c.cache[key]
+	if !exists {
+		return false
+	}
+	delete(c.cache, key)
+	return len(apps) > 0
+}
+
+// DeleteKubecontext removes the applications of all namespaces for the kubecontext.
+// DeleteKubecontext returns true iff the cache changed.
+func (c *ApplicationCache) DeleteKubecontext(kubecontextName string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := false
+	for key, apps := range c.cache {
+		if key.kubecontextName == kubecontextName {
+			changed = changed || len(apps) > 0
+			delete(c.cache, key)
+		}
+	}
+	return changed
+}
+
 func (c *ApplicationCache) Get(kubecontextName string, namespace string) []Application {
 	c.mu.RLock()
 	defer c.mu.RUnlock()
@@ -67,6 +95,13 @@ func (c *ApplicationCache) GetAll() []Application {
 	return apps
 }
 
-func key(kubecontextName string, namespace string) string {
-	return fmt.Sprintf("%s/%s", kubecontextName, namespace)
+// cacheKey is the key of the application and route caches. The kubecontext and namespace are
+// separate fields, because kubecontext names can contain slashes, e.g., the ARNs of EKS clusters.
+type cacheKey struct {
+	kubecontextName string
+	namespace       string
+}
+
+func key(kubecontextName string, namespace string) cacheKey {
+	return cacheKey{kubecontextName: kubecontextName, namespace: namespace}
 }
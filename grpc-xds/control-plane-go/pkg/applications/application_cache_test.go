@@ -0,0 +1,146 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applications
+
+import (
+	"slices"
+	"testing"
+)
+
+// eksKubecontext is a kubecontext name with a slash, as used for EKS clusters.
+const eksKubecontext = "arn:aws:eks:us-west-2:123456789012:cluster/prod"
+
+func TestApplicationCacheDeleteKubecontext(t *testing.T) {
+	tests := []struct {
+		name        string
+		kubecontext string
+		wantChanged bool
+		// wantApps are the names of the remaining applications.
+		wantApps []string
+	}{
+		{
+			name:        "kubecontext that is a prefix of another kubecontext",
+			kubecontext: "arn:aws:eks:us-west-2:123456789012:cluster",
+			wantChanged: true,
+			wantApps:    []string{"greeter-prod"},
+		},
+		{
+			name:        "kubecontext with slash",
+			kubecontext: eksKubecontext,
+			wantChanged: true,
+			wantApps:    []string{"greeter"},
+		},
+		{
+			name:        "unknown kubecontext",
+			kubecontext: "arn:aws:eks:us-west-2:123456789012",
+			wantChanged: false,
+			wantApps:    []string{"greeter", "greeter-prod"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewApplicationCache()
+			// Without separate key fields, the key of both would be `<prefix>/prod/xds`.
+			c.Put("arn:aws:eks:us-west-2:123456789012:cluster", "prod/xds", []Application{{Namespace: "prod/xds", Name: "greeter"}})
+			c.Put(eksKubecontext, "xds", []Application{{Namespace: "xds", Name: "greeter-prod"}})
+			if changed := c.DeleteKubecontext(test.kubecontext); changed != test.wantChanged {
+				t.Errorf("DeleteKubecontext(%s) = %t, want %t", test.kubecontext, changed, test.wantChanged)
+			}
+			var gotApps []string
+			for _, app := range c.GetAll() {
+				gotApps = append(gotApps, app.Name)
+			}
+			slices.Sort(gotApps)
+			if !slices.Equal(gotApps, test.wantApps) {
+				t.Errorf("applications after DeleteKubecontext(%s) = %v, want %v", test.kubecontext, gotApps, test.wantApps)
+			}
+		})
+	}
+}
+
+func TestApplicationCacheKeysDoNotCollide(t *testing.T) {
+	c := NewApplicationCache()
+	c.Put("a/b", "c", []Application{{Namespace: "c", Name: "greeter-1"}})
+	c.Put("a", "b/c", []Application{{Namespace: "b/c", Name: "greeter-2"}})
+	if apps := c.Get("a/b", "c"); len(apps) != 1 || apps[0].Name != "greeter-1" {
+		t.Errorf("Get(a/b, c) = %+v, want greeter-1", apps)
+	}
+	if apps := c.Get("a", "b/c"); len(apps) != 1 || apps[0].Name != "greeter-2" {
+		t.Errorf("Get(a, b/c) = %+v, want greeter-2", apps)
+	}
+}
+
+func TestApplicationCacheDelete(t *testing.T) {
+	tests := []struct {
+		name        string
+		kubecontext string
+		namespace   string
+		wantChanged bool
+		// wantApps are the names of the remaining applications.
+		wantApps []string
+	}{
+		{
+			name:        "namespace with applications",
+			kubecontext: "kubecontext-1",
+			namespace:   "xds",
+			wantChanged: true,
+			wantApps:    []string{"greeter-2", "greeter-3"},
+		},
+		{
+			name:        "namespace without applications",
+			kubecontext: "kubecontext-1",
+			namespace:   "empty",
+			wantChanged: false,
+			wantApps:    []string{"greeter-1", "greeter-2", "greeter-3"},
+		},
+		{
+			name:        "namespace of another kubecontext",
+			kubecontext: "kubecontext-2",
+			namespace:   "xds",
+			wantChanged: true,
+			wantApps:    []string{"greeter-1", "greeter-3"},
+		},
+		{
+			name:        "unknown namespace",
+			kubecontext: "kubecontext-1",
+			namespace:   "unknown",
+			wantChanged: false,
+			wantApps:    []string{"greeter-1", "greeter-2", "greeter-3"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewApplicationCache()
+			c.Put("kubecontext-1", "xds", []Application{{Namespace: "xds", Name: "greeter-1"}})
+			c.Put("kubecontext-1", "empty", nil)
+			c.Put("kubecontext-2", "xds", []Application{{Namespace: "xds", Name: "greeter-2"}})
+			c.Put("kubecontext-1", "other", []Application{{Namespace: "other", Name: "greeter-3"}})
+			if changed := c.Delete(test.kubecontext, test.namespace); changed != test.wantChanged {
+				t.Errorf("Delete(%s, %s) = %t, want %t", test.kubecontext, test.namespace, changed, test.wantChanged)
+			}
+			if apps := c.Get(test.kubecontext, test.namespace); len(apps) != 0 {
+				t.Errorf("Get(%s, %s) after Delete() = %+v, want none", test.kubecontext, test.namespace, apps)
+			}
+			var gotApps []string
+			for _, app := range c.GetAll() {
+				gotApps = append(gotApps, app.Name)
+			}
+			slices.Sort(gotApps)
+			if !slices.Equal(gotApps, test.wantApps) {
+				t.Errorf("applications after Delete(%s, %s) = %v, want %v", test.kubecontext, test.namespace, gotApps, test.wantApps)
+			}
+		})
+	}
+}
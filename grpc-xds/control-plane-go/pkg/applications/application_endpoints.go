@@ -15,42 +15,147 @@
 package applications
 
 import (
+	"cmp"
+	"maps"
+	"net"
 	"slices"
 	"strings"
 )
 
+// AddressType is the type of the addresses of endpoints, with the same values as the
+// `addressType` of Kubernetes EndpointSlices, see
+// https://kubernetes.io/docs/concepts/services-networking/endpoint-slices/#address-types
+type AddressType string
+
+const (
+	AddressTypeIPv4 AddressType = "IPv4"
+	AddressTypeIPv6 AddressType = "IPv6"
+	// AddressTypeFQDN addresses are fully qualified domain names, e.g., `greeter.example.com`.
+	AddressTypeFQDN AddressType = "FQDN"
+)
+
+// AddressTypeOf returns the address type of the provided address, e.g., for static applications
+// where the address type is not set.
+func AddressTypeOf(address string) AddressType {
+	ip := net.ParseIP(address)
+	switch {
+	case ip == nil:
+		return AddressTypeFQDN
+	case ip.To4() != nil && !strings.Contains(address, ":"):
+		return AddressTypeIPv4
+	default:
+		return AddressTypeIPv6
+	}
+}
+
 type ApplicationEndpoints struct {
-	Node           string
-	Zone           string
+	// Pod is the name of the Kubernetes Pod, if the endpoints are Pods.
+	Pod  string
+	Node string
+	Zone string
+	// Cluster is the name of the Kubernetes cluster, i.e., the kubeconfig context name.
+	Cluster        string
 	Addresses      []string
+	AddressType    AddressType
 	EndpointStatus EndpointStatus
+	// Weight is the load balancing weight of each of the addresses. Zero means the default weight.
+	Weight uint32
+	// Labels are Pod labels used for subset load balancing, e.g., `version`.
+	Labels map[string]string
 }
 
-func NewApplicationEndpoints(node string, zone string, addresses []string, endpointStatus EndpointStatus) ApplicationEndpoints {
+func NewApplicationEndpoints(pod string, node string, zone string, cluster string, addresses []string, addressType AddressType, endpointStatus EndpointStatus, labels map[string]string) ApplicationEndpoints {
 	addressesCopy := make([]string, len(addresses))
 	copy(addressesCopy, addresses)
 	slices.Sort(addressesCopy)
 	return ApplicationEndpoints{
+		Pod:            pod,
 		Node:           node,
 		Zone:           zone,
+		Cluster:        cluster,
 		Addresses:      addressesCopy,
+		AddressType:    addressType,
 		EndpointStatus: endpointStatus,
+		Labels:         maps.Clone(labels),
 	}
 }
 
 // Compare assumes that the list of addresses is sorted,
 // as done in `NewApplicationEndpoints()`.
 func (e ApplicationEndpoints) Compare(f ApplicationEndpoints) int {
+	if e.Pod != f.Pod {
+		return strings.Compare(e.Pod, f.Pod)
+	}
 	if e.Node != f.Node {
 		return strings.Compare(e.Node, f.Node)
 	}
 	if e.Zone != f.Zone {
 		return strings.Compare(e.Zone, f.Zone)
 	}
+	if e.Cluster != f.Cluster {
+		return strings.Compare(e.Cluster, f.Cluster)
+	}
+	if e.AddressType != f.AddressType {
+		return strings.Compare(string(e.AddressType), string(f.AddressType))
+	}
 	if e.EndpointStatus != f.EndpointStatus {
 		return strings.Compare(e.EndpointStatus.String(), f.EndpointStatus.String())
 	}
-	return slices.Compare(e.Addresses, f.Addresses)
+	if e.Weight != f.Weight {
+		return cmp.Compare(e.Weight, f.Weight)
+	}
+	if c := slices.Compare(e.Addresses, f.Addresses); c != 0 {
+		return c
+	}
+	return compareLabels(e.Labels, f.Labels)
+}
+
+// compareLabels compares the sorted keys, then the values of the sorted keys.
+func compareLabels(a map[string]string, b map[string]string) int {
+	aKeys := slices.Sorted(maps.Keys(a))
+	bKeys := slices.Sorted(maps.Keys(b))
+	if c := slices.Compare(aKeys, bKeys); c != 0 {
+		return c
+	}
+	for _, key := range aKeys {
+		if c := strings.Compare(a[key], b[key]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// FQDNHostname returns the first hostname of the endpoints, if all the endpoints have FQDN
+// addresses. Returns `false` as the second return value if there are no endpoints, or if
+// any of the endpoints have IP addresses.
+func FQDNHostname(endpoints []ApplicationEndpoints) (string, bool) {
+	var hostnames []string
+	for _, endpoint := range endpoints {
+		if endpoint.AddressType != AddressTypeFQDN {
+			return "", false
+		}
+		hostnames = append(hostnames, endpoint.Addresses...)
+	}
+	if len(hostnames) == 0 {
+		return "", false
+	}
+	return slices.Min(hostnames), true
+}
+
+// SubsetLabelValues returns the sorted distinct values of each label of the endpoints, by label key.
+func SubsetLabelValues(endpoints []ApplicationEndpoints) map[string][]string {
+	subsets := map[string][]string{}
+	for _, endpoint := range endpoints {
+		for key, value := range endpoint.Labels {
+			if !slices.Contains(subsets[key], value) {
+				subsets[key] = append(subsets[key], value)
+			}
+		}
+	}
+	for _, values := range subsets {
+		slices.Sort(values)
+	}
+	return subsets
 }
 
 // Equal assumes that the list of addresses is sorted,
@@ -0,0 +1,76 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applications
+
+import (
+	"reflect"
+	"strings"
+)
+
+// Route represents routing rules for requests to one or more listener names,
+// e.g., from a Kubernetes Gateway API GRPCRoute resource.
+type Route struct {
+	Namespace string
+	Name      string
+	// Hostnames are the listener names that use the routing rules.
+	Hostnames []string
+	Rules     []RouteRule
+}
+
+// RouteRule sends requests that match any of the Matches to the Backends.
+// A rule without Matches matches all requests.
+type RouteRule struct {
+	Matches  []RouteMatch
+	Backends []RouteBackend
+}
+
+// RouteMatch matches requests by gRPC service and method, and by request headers.
+// Empty Service and Method values match any service or method.
+type RouteMatch struct {
+	Service string
+	Method  string
+	// RegularExpression is true if Service and Method are regular expressions.
+	RegularExpression bool
+	Headers           []HeaderMatch
+}
+
+type HeaderMatch struct {
+	Name              string
+	Value             string
+	RegularExpression bool
+}
+
+// RouteBackend is an application, identified by name, and optionally by Namespace and serving
+// port, and a relative weight.
+type RouteBackend struct {
+	Name string
+	// Namespace of the application. Empty matches applications in any Namespace, and backends that
+	// aren't applications, e.g., external backends.
+	Namespace string
+	// Port is the serving port of the application. Zero matches any serving port.
+	Port   uint32
+	Weight uint32
+}
+
+func (r Route) Compare(s Route) int {
+	if r.Namespace != s.Namespace {
+		return strings.Compare(r.Namespace, s.Namespace)
+	}
+	return strings.Compare(r.Name, s.Name)
+}
+
+func (r Route) Equal(s Route) bool {
+	return reflect.DeepEqual(r, s)
+}
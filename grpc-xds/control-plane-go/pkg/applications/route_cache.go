@@ -0,0 +1,92 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applications
+
+import (
+	"slices"
+	"sync"
+)
+
+// The RouteCache stores routes by kubecontext and namespace.
+type RouteCache struct {
+	mu    sync.RWMutex
+	cache map[cacheKey][]Route
+}
+
+func NewRouteCache() *RouteCache {
+	return &RouteCache{
+		cache: map[cacheKey][]Route{},
+	}
+}
+
+// Put returns true iff the update changed the cache.
+func (c *RouteCache) Put(kubecontextName string, namespace string, routes []Route) bool {
+	if routes == nil {
+		routes = []Route{}
+	}
+	slices.SortFunc(routes, func(a Route, b Route) int {
+		return a.Compare(b)
+	})
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := key(kubecontextName, namespace)
+	oldRoutes := c.cache[key]
+	c.cache[key] = routes
+	return !slices.EqualFunc(oldRoutes, routes, func(a Route, b Route) bool {
+		return a.Equal(b)
+	})
+}
+
+// Delete removes the routes of the namespace in the kubecontext.
+// Delete returns true iff the cache changed.
+func (c *RouteCache) Delete(kubecontextName string, namespace string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := key(kubecontextName, namespace)
+	routes, exists := c.cache[key]
+	if !exists {
+		return false
+	}
+	delete(c.cache, key)
+	return len(routes) > 0
+}
+
+// DeleteKubecontext removes the routes of all namespaces for the kubecontext.
+// DeleteKubecontext returns true iff the cache changed.
+func (c *RouteCache) DeleteKubecontext(kubecontextName string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	changed := false
+	for key, routes := range c.cache {
+		if key.kubecontextName == kubecontextName {
+			changed = changed || len(routes) > 0
+			delete(c.cache, key)
+		}
+	}
+	return changed
+}
+
+func (c *RouteCache) GetAll() []Route {
+	routes := []Route{}
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for _, routesForKey := range c.cache {
+		routes = append(routes, routesForKey...)
+	}
+	slices.SortFunc(routes, func(a Route, b Route) int {
+		return a.Compare(b)
+	})
+	return routes
+}
@@ -0,0 +1,62 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package applications
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestRouteCacheDeleteKubecontext(t *testing.T) {
+	c := NewRouteCache()
+	c.Put("arn:aws:eks:us-west-2:123456789012:cluster", "prod/xds", []Route{{Namespace: "prod/xds", Name: "greeter"}})
+	c.Put(eksKubecontext, "xds", []Route{{Namespace: "xds", Name: "greeter-prod"}})
+	if !c.DeleteKubecontext("arn:aws:eks:us-west-2:123456789012:cluster") {
+		t.Errorf("DeleteKubecontext() = false, want true")
+	}
+	var gotRoutes []string
+	for _, route := range c.GetAll() {
+		gotRoutes = append(gotRoutes, route.Name)
+	}
+	if want := []string{"greeter-prod"}; !slices.Equal(gotRoutes, want) {
+		t.Errorf("routes after DeleteKubecontext() = %v, want %v", gotRoutes, want)
+	}
+	if c.DeleteKubecontext("arn:aws:eks:us-west-2:123456789012:cluster") {
+		t.Errorf("second DeleteKubecontext() = true, want false")
+	}
+}
+
+func TestRouteCacheDelete(t *testing.T) {
+	c := NewRouteCache()
+	c.Put("kubecontext-1", "xds", []Route{{Namespace: "xds", Name: "greeter-1"}})
+	c.Put("kubecontext-2", "xds", []Route{{Namespace: "xds", Name: "greeter-2"}})
+	c.Put("kubecontext-1", "empty", nil)
+	if !c.Delete("kubecontext-1", "xds") {
+		t.Errorf("Delete() = false, want true")
+	}
+	var gotRoutes []string
+	for _, route := range c.GetAll() {
+		gotRoutes = append(gotRoutes, route.Name)
+	}
+	if want := []string{"greeter-2"}; !slices.Equal(gotRoutes, want) {
+		t.Errorf("routes after Delete() = %v, want %v", gotRoutes, want)
+	}
+	if c.Delete("kubecontext-1", "xds") {
+		t.Errorf("second Delete() = true, want false")
+	}
+	if c.Delete("kubecontext-1", "empty") {
+		t.Errorf("Delete() of namespace without routes = true, want false")
+	}
+}
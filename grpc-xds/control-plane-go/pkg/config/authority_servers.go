@@ -0,0 +1,129 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	authorityServersConfigFile = "authority_servers.yaml"
+)
+
+var (
+	errNoAuthorityServerName        = errors.New("authority server name cannot be blank")
+	errDuplicateAuthorityServerName = errors.New("authority server name used more than once")
+	errDuplicateAuthorityServerPort = errors.New("authority server port must be different from the other server ports")
+)
+
+// AuthorityServer is an additional port of the xDS management server, associated with an
+// xDS federation authority name. All ports serve the same snapshot cache, and the snapshots
+// contain `xdstp://` resource names for the authority of each port, so that one control
+// plane can simulate several control planes for xDS federation.
+type AuthorityServer struct {
+	// Name is the authority name, e.g., `control-plane-west.xds.svc.cluster.local`.
+	Name string `yaml:"name"`
+	Port int    `yaml:"port"`
+	// TLS overrides the control plane TLS settings of the xDS feature flags and the server
+	// configuration for this port. If nil, the port uses the same settings as the serving port.
+	TLS *AuthorityServerTLS `yaml:"tls,omitempty"`
+}
+
+// AuthorityServerTLS is the TLS configuration of an authority server port.
+type AuthorityServerTLS struct {
+	// Enabled is false for insecure credentials.
+	Enabled            bool `yaml:"enabled"`
+	RequireClientCerts bool `yaml:"requireClientCerts"`
+	// CertFile, KeyFile, and CAFile default to the files of the serving port if empty.
+	CertFile string `yaml:"certFile,omitempty"`
+	KeyFile  string `yaml:"keyFile,omitempty"`
+	CAFile   string `yaml:"caFile,omitempty"`
+}
+
+// AuthorityServers returns the additional authority server ports of the xDS management server.
+// If the config file does not exist, AuthorityServers returns nil.
+func AuthorityServers(logger logr.Logger, serverConfig Server) ([]AuthorityServer, error) {
+	authorityServersConfigFilePath := configFilePath(authorityServersConfigFile)
+	logger.V(4).Info("Loading authority servers", "filepath", authorityServersConfigFilePath)
+	yamlBytes, err := os.ReadFile(authorityServersConfigFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.V(2).Info("No authority servers config file", "filepath", authorityServersConfigFilePath)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read authority servers from file %s: %w", authorityServersConfigFilePath, err)
+	}
+	var authorityServers []AuthorityServer
+	err = yaml.Unmarshal(yamlBytes, &authorityServers)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal authority servers YAML file contents [%s]: %w", yamlBytes, err)
+	}
+	if err := validateAuthorityServers(authorityServers, serverConfig); err != nil {
+		return nil, fmt.Errorf("authority servers validation failed: %w", err)
+	}
+	logger.V(2).Info("Authority servers", "authorityServers", authorityServers)
+	return authorityServers, nil
+}
+
+// ServerConfig returns the server configuration of the authority server port, with the TLS
+// files of the port, if set.
+func (a AuthorityServer) ServerConfig(serverConfig Server) Server {
+	serverConfig.ServingPort = a.Port
+	if a.TLS == nil {
+		return serverConfig
+	}
+	if a.TLS.CertFile != "" {
+		serverConfig.TLSCertFile = a.TLS.CertFile
+	}
+	if a.TLS.KeyFile != "" {
+		serverConfig.TLSKeyFile = a.TLS.KeyFile
+	}
+	if a.TLS.CAFile != "" {
+		serverConfig.TLSCAFile = a.TLS.CAFile
+	}
+	return serverConfig
+}
+
+func validateAuthorityServers(authorityServers []AuthorityServer, serverConfig Server) error {
+	names := map[string]bool{}
+	ports := map[int]bool{
+		serverConfig.ServingPort: true,
+		serverConfig.HealthPort:  true,
+		serverConfig.MetricsPort: true,
+	}
+	for _, authorityServer := range authorityServers {
+		if authorityServer.Name == "" {
+			return fmt.Errorf("%w: authorityServer=%+v", errNoAuthorityServerName, authorityServer)
+		}
+		if names[authorityServer.Name] {
+			return fmt.Errorf("%w: name=%s", errDuplicateAuthorityServerName, authorityServer.Name)
+		}
+		names[authorityServer.Name] = true
+		if authorityServer.Port < 1 || authorityServer.Port > 65535 {
+			return fmt.Errorf("%w: authority=%s port=%d", errInvalidPort, authorityServer.Name, authorityServer.Port)
+		}
+		if ports[authorityServer.Port] {
+			return fmt.Errorf("%w: authority=%s port=%d", errDuplicateAuthorityServerPort, authorityServer.Name, authorityServer.Port)
+		}
+		ports[authorityServer.Port] = true
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/rollout"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+const (
+	canaryRolloutsConfigFile = "canary_rollouts.yaml"
+)
+
+var (
+	errNoCanaryRolloutApplication        = errors.New("canary rollout application and canary cannot be blank")
+	errDuplicateCanaryRolloutApplication = errors.New("canary rollout application used more than once")
+	errInvalidCanaryRolloutSteps         = errors.New("canary rollout steps must be increasing percentages between 1 and 100")
+	errInvalidCanaryRolloutInterval      = errors.New("canary rollout interval must be positive")
+	errInvalidCanaryRolloutSuccessRatio  = errors.New("canary rollout minSuccessRatio must be between 0 and 1")
+	errCanaryRolloutNoLoadReports        = errors.New("canary rollout minSuccessRatio requires the loadReport locality weight policy")
+)
+
+// CanaryRollouts returns the canary rollouts to run at startup. If the config file does not
+// exist, CanaryRollouts returns nil.
+//
+// xDS clients only send load reports if the clusters include an LRS server, and the clusters
+// only include an LRS server with the `loadReport` locality weight policy. Without load reports,
+// `minSuccessRatio` has no effect, so CanaryRollouts rejects rollouts that set it.
+func CanaryRollouts(logger logr.Logger, xdsFeatures *xds.Features) ([]rollout.CanarySpec, error) {
+	canaryRolloutsConfigFilePath := configFilePath(canaryRolloutsConfigFile)
+	logger.V(4).Info("Loading canary rollouts", "filepath", canaryRolloutsConfigFilePath)
+	yamlBytes, err := os.ReadFile(canaryRolloutsConfigFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.V(2).Info("No canary rollouts config file", "filepath", canaryRolloutsConfigFilePath)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read canary rollouts from file %s: %w", canaryRolloutsConfigFilePath, err)
+	}
+	var specs []rollout.CanarySpec
+	err = yaml.Unmarshal(yamlBytes, &specs)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal canary rollouts YAML file contents [%s]: %w", yamlBytes, err)
+	}
+	if err := validateCanaryRollouts(specs, xdsFeatures.UsesLoadReports()); err != nil {
+		return nil, fmt.Errorf("canary rollouts validation failed: %w", err)
+	}
+	logger.V(2).Info("Canary rollouts", "rollouts", specs)
+	return specs, nil
+}
+
+func validateCanaryRollouts(specs []rollout.CanarySpec, usesLoadReports bool) error {
+	applications := map[string]bool{}
+	for _, spec := range specs {
+		if spec.Application == "" || spec.Canary == "" {
+			return fmt.Errorf("%w: rollout=%+v", errNoCanaryRolloutApplication, spec)
+		}
+		if applications[spec.Application] {
+			return fmt.Errorf("%w: application=%s", errDuplicateCanaryRolloutApplication, spec.Application)
+		}
+		applications[spec.Application] = true
+		if len(spec.Steps) == 0 {
+			return fmt.Errorf("%w: application=%s steps=%v", errInvalidCanaryRolloutSteps, spec.Application, spec.Steps)
+		}
+		var previous uint32
+		for _, percent := range spec.Steps {
+			if percent <= previous || percent > 100 {
+				return fmt.Errorf("%w: application=%s steps=%v", errInvalidCanaryRolloutSteps, spec.Application, spec.Steps)
+			}
+			previous = percent
+		}
+		if spec.Interval <= 0 {
+			return fmt.Errorf("%w: application=%s interval=%s", errInvalidCanaryRolloutInterval, spec.Application, spec.Interval)
+		}
+		if spec.MinSuccessRatio < 0 || spec.MinSuccessRatio > 1 {
+			return fmt.Errorf("%w: application=%s minSuccessRatio=%v", errInvalidCanaryRolloutSuccessRatio, spec.Application, spec.MinSuccessRatio)
+		}
+		if spec.MinSuccessRatio > 0 && !usesLoadReports {
+			return fmt.Errorf("%w: application=%s minSuccessRatio=%v", errCanaryRolloutNoLoadReports, spec.Application, spec.MinSuccessRatio)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/rollout"
+)
+
+func TestValidateCanaryRollouts(t *testing.T) {
+	valid := rollout.CanarySpec{
+		Application: "greeter-leaf",
+		Canary:      "greeter-leaf-canary",
+		Steps:       []uint32{10, 50, 100},
+		Interval:    time.Minute,
+	}
+	withSuccessRatio := valid
+	withSuccessRatio.MinSuccessRatio = 0.9
+	withDecreasingSteps := valid
+	withDecreasingSteps.Steps = []uint32{50, 10}
+	tests := []struct {
+		name            string
+		specs           []rollout.CanarySpec
+		usesLoadReports bool
+		wantErr         error
+	}{
+		{
+			name:  "valid without success ratio",
+			specs: []rollout.CanarySpec{valid},
+		},
+		{
+			name:            "success ratio with load reports",
+			specs:           []rollout.CanarySpec{withSuccessRatio},
+			usesLoadReports: true,
+		},
+		{
+			name:    "success ratio without load reports",
+			specs:   []rollout.CanarySpec{withSuccessRatio},
+			wantErr: errCanaryRolloutNoLoadReports,
+		},
+		{
+			name:    "duplicate application",
+			specs:   []rollout.CanarySpec{valid, valid},
+			wantErr: errDuplicateCanaryRolloutApplication,
+		},
+		{
+			name:    "decreasing steps",
+			specs:   []rollout.CanarySpec{withDecreasingSteps},
+			wantErr: errInvalidCanaryRolloutSteps,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateCanaryRollouts(test.specs, test.usesLoadReports)
+			if !errors.Is(err, test.wantErr) {
+				t.Errorf("validateCanaryRollouts() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
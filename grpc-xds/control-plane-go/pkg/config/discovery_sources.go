@@ -0,0 +1,223 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery/consul"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery/gce"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery/synthetic"
+)
+
+const (
+	consulSourceName       = "consul"
+	defaultConsulNamespace = "consul"
+	defaultConsulWaitTime  = 5 * time.Minute
+	// consulAddressEnvVar and consulTokenEnvVar are the environment variables used by the Consul CLI.
+	consulAddressEnvVar    = "CONSUL_HTTP_ADDR"
+	consulTokenEnvVar      = "CONSUL_HTTP_TOKEN"
+	consulDatacenterEnvVar = "CONSUL_DATACENTER"
+	consulNamespaceEnvVar  = "CONSUL_APPLICATION_NAMESPACE"
+	consulTagEnvVar        = "CONSUL_SERVICE_TAG"
+	consulWaitTimeEnvVar   = "CONSUL_WAIT_TIME"
+
+	gceSourceName             = "gce"
+	defaultGCENamespace       = "gce"
+	defaultGCENamedPort       = "grpc"
+	defaultGCEServingProtocol = "grpc"
+	defaultGCEPollInterval    = 30 * time.Second
+	gceInstanceGroupsEnvVar   = "GCE_INSTANCE_GROUPS"
+	gceNamespaceEnvVar        = "GCE_APPLICATION_NAMESPACE"
+	gceNamedPortEnvVar        = "GCE_NAMED_PORT"
+	gceServingProtocolEnvVar  = "GCE_SERVING_PROTOCOL"
+	gcePollIntervalEnvVar     = "GCE_POLL_INTERVAL"
+
+	syntheticSourceName   = "synthetic"
+	syntheticNamespace    = "synthetic"
+	defaultSyntheticZones = "us-central1-a,us-central1-b,us-central1-c"
+)
+
+var (
+	errNonPositiveConsulWaitTime      = errors.New("consul wait time must be positive")
+	errNonPositiveGCEPollInterval     = errors.New("compute engine poll interval must be positive")
+	errNegativeSyntheticApplications  = errors.New("number of synthetic applications must not be negative")
+	errNonPositiveSyntheticEndpoints  = errors.New("number of synthetic endpoints must be positive")
+	errNegativeSyntheticChurnInterval = errors.New("synthetic churn interval must not be negative")
+	errTooManySyntheticEndpoints      = errors.New("total number of synthetic endpoints must not exceed 8388608")
+)
+
+// The synthetic application generator settings are server settings, so they can be set using
+// either command line flags or environment variables, see `InitServerFlags()`.
+var (
+	syntheticApplicationsSetting  = serverSetting{"synthetic-applications", "SYNTHETIC_APPLICATIONS", "number of synthetic applications to generate for scale testing, 0 disables the generator"}
+	syntheticEndpointsSetting     = serverSetting{"synthetic-endpoints", "SYNTHETIC_ENDPOINTS", "number of endpoints of each synthetic application"}
+	syntheticZonesSetting         = serverSetting{"synthetic-zones", "SYNTHETIC_ZONES", "comma-separated zones of the synthetic endpoints"}
+	syntheticChurnIntervalSetting = serverSetting{"synthetic-churn-interval", "SYNTHETIC_CHURN_INTERVAL", "how often the endpoints of one synthetic application change, 0 disables the changes"}
+)
+
+// DiscoverySources returns the discovery sources that run alongside the Kubernetes informers.
+// The Consul catalog source is enabled if the `CONSUL_HTTP_ADDR` environment variable is set,
+// the managed instance group source is enabled if the `GCE_INSTANCE_GROUPS` environment
+// variable is set, and the synthetic application generator is enabled if the
+// `-synthetic-applications` flag of the flag set or the `SYNTHETIC_APPLICATIONS` environment
+// variable is positive.
+func DiscoverySources(logger logr.Logger, flagset *flag.FlagSet) ([]discovery.Source, error) {
+	var sources []discovery.Source
+	consulSource, err := consulDiscoverySource(logger)
+	if err != nil {
+		return nil, err
+	}
+	if consulSource != nil {
+		sources = append(sources, consulSource)
+	}
+	gceSource, err := gceDiscoverySource(logger)
+	if err != nil {
+		return nil, err
+	}
+	if gceSource != nil {
+		sources = append(sources, gceSource)
+	}
+	syntheticSource, err := syntheticDiscoverySource(logger, flagset)
+	if err != nil {
+		return nil, err
+	}
+	if syntheticSource != nil {
+		sources = append(sources, syntheticSource)
+	}
+	return sources, nil
+}
+
+func consulDiscoverySource(logger logr.Logger) (discovery.Source, error) {
+	address, exists := os.LookupEnv(consulAddressEnvVar)
+	if !exists || address == "" {
+		logger.V(4).Info("No Consul address, not watching the Consul catalog")
+		return nil, nil
+	}
+	if !strings.Contains(address, "://") {
+		// The Consul CLI accepts addresses without scheme, e.g., `127.0.0.1:8500`.
+		address = "http://" + address
+	}
+	waitTime, err := durationFromEnv(consulWaitTimeEnvVar, defaultConsulWaitTime)
+	if err != nil {
+		return nil, err
+	}
+	if waitTime <= 0 {
+		return nil, fmt.Errorf("%w: %s", errNonPositiveConsulWaitTime, waitTime)
+	}
+	namespace := envOrDefault(consulNamespaceEnvVar, defaultConsulNamespace)
+	consulConfig := consul.Config{
+		Name:       consulSourceName,
+		Address:    address,
+		Token:      os.Getenv(consulTokenEnvVar),
+		Datacenter: os.Getenv(consulDatacenterEnvVar),
+		Namespace:  namespace,
+		Tag:        os.Getenv(consulTagEnvVar),
+		WaitTime:   waitTime,
+	}
+	logger.V(2).Info("Consul catalog discovery source", "address", consulConfig.Address, "datacenter", consulConfig.Datacenter, "namespace", consulConfig.Namespace, "tag", consulConfig.Tag)
+	return consul.NewSource(consulConfig), nil
+}
+
+func gceDiscoverySource(logger logr.Logger) (discovery.Source, error) {
+	instanceGroups := os.Getenv(gceInstanceGroupsEnvVar)
+	if instanceGroups == "" {
+		logger.V(4).Info("No Compute Engine managed instance groups, not polling instance groups")
+		return nil, nil
+	}
+	pollInterval, err := durationFromEnv(gcePollIntervalEnvVar, defaultGCEPollInterval)
+	if err != nil {
+		return nil, err
+	}
+	if pollInterval <= 0 {
+		return nil, fmt.Errorf("%w: %s", errNonPositiveGCEPollInterval, pollInterval)
+	}
+	gceConfig := gce.Config{
+		Name:            gceSourceName,
+		Namespace:       envOrDefault(gceNamespaceEnvVar, defaultGCENamespace),
+		NamedPort:       envOrDefault(gceNamedPortEnvVar, defaultGCENamedPort),
+		ServingProtocol: envOrDefault(gceServingProtocolEnvVar, defaultGCEServingProtocol),
+		PollInterval:    pollInterval,
+	}
+	for _, instanceGroup := range strings.Split(instanceGroups, ",") {
+		if instanceGroup = strings.TrimSpace(instanceGroup); instanceGroup != "" {
+			gceConfig.InstanceGroups = append(gceConfig.InstanceGroups, instanceGroup)
+		}
+	}
+	logger.V(2).Info("Compute Engine managed instance group discovery source", "instanceGroups", gceConfig.InstanceGroups, "namespace", gceConfig.Namespace, "namedPort", gceConfig.NamedPort)
+	return gce.NewSource(gceConfig), nil
+}
+
+func syntheticDiscoverySource(logger logr.Logger, flagset *flag.FlagSet) (discovery.Source, error) {
+	apps, err := intSetting(flagset, syntheticApplicationsSetting, 0)
+	if err != nil {
+		return nil, err
+	}
+	if apps < 0 {
+		return nil, fmt.Errorf("%w: %d", errNegativeSyntheticApplications, apps)
+	}
+	if apps == 0 {
+		logger.V(4).Info("No synthetic applications, not generating applications")
+		return nil, nil
+	}
+	endpoints, err := intSetting(flagset, syntheticEndpointsSetting, 1)
+	if err != nil {
+		return nil, err
+	}
+	if endpoints <= 0 {
+		return nil, fmt.Errorf("%w: %d", errNonPositiveSyntheticEndpoints, endpoints)
+	}
+	if apps*endpoints > synthetic.MaxEndpoints {
+		return nil, fmt.Errorf("%w: %d applications with %d endpoints", errTooManySyntheticEndpoints, apps, endpoints)
+	}
+	churnInterval, err := durationSetting(flagset, syntheticChurnIntervalSetting, 0)
+	if err != nil {
+		return nil, err
+	}
+	if churnInterval < 0 {
+		return nil, fmt.Errorf("%w: %s", errNegativeSyntheticChurnInterval, churnInterval)
+	}
+	syntheticConfig := synthetic.Config{
+		Name:          syntheticSourceName,
+		Namespace:     syntheticNamespace,
+		Applications:  apps,
+		Endpoints:     endpoints,
+		ChurnInterval: churnInterval,
+	}
+	for _, zone := range strings.Split(stringSetting(flagset, syntheticZonesSetting, defaultSyntheticZones), ",") {
+		if zone = strings.TrimSpace(zone); zone != "" {
+			syntheticConfig.Zones = append(syntheticConfig.Zones, zone)
+		}
+	}
+	logger.V(2).Info("Synthetic application generator", "applications", apps, "endpoints", endpoints, "zones", syntheticConfig.Zones, "churnInterval", churnInterval)
+	return synthetic.NewSource(syntheticConfig), nil
+}
+
+// envOrDefault returns the value of the environment variable, or the default value if the
+// environment variable is not set or empty.
+func envOrDefault(envVar string, defaultValue string) string {
+	if value := os.Getenv(envVar); value != "" {
+		return value
+	}
+	return defaultValue
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+)
+
+const (
+	externalBackendsConfigFile = "external_backends.yaml"
+)
+
+var (
+	errNoBackendName        = errors.New("external backend name cannot be blank")
+	errDuplicateBackendName = errors.New("external backend name used more than once")
+	errNoBackendHostname    = errors.New("external backend hostname cannot be blank")
+	errNoBackendPort        = errors.New("external backend port must be set")
+	errUnknownDNSType       = errors.New("external backend dnsType must be one of logical or strict")
+)
+
+// ExternalBackends returns the gRPC backends outside the Kubernetes clusters, which clients
+// reach using DNS clusters. If the config file does not exist, ExternalBackends returns nil.
+func ExternalBackends(logger logr.Logger) ([]cds.ExternalBackend, error) {
+	externalBackendsConfigFilePath := configFilePath(externalBackendsConfigFile)
+	logger.V(4).Info("Loading external backends", "filepath", externalBackendsConfigFilePath)
+	yamlBytes, err := os.ReadFile(externalBackendsConfigFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.V(2).Info("No external backends config file", "filepath", externalBackendsConfigFilePath)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read external backends from file %s: %w", externalBackendsConfigFilePath, err)
+	}
+	var backends []cds.ExternalBackend
+	err = yaml.Unmarshal(yamlBytes, &backends)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal external backends YAML file contents [%s]: %w", yamlBytes, err)
+	}
+	if err := validateExternalBackends(backends); err != nil {
+		return nil, fmt.Errorf("external backends validation failed: %w", err)
+	}
+	logger.V(2).Info("External backends", "backends", backends)
+	return backends, nil
+}
+
+func validateExternalBackends(backends []cds.ExternalBackend) error {
+	names := map[string]bool{}
+	for _, backend := range backends {
+		if backend.Name == "" {
+			return fmt.Errorf("%w: backend=%+v", errNoBackendName, backend)
+		}
+		if names[backend.Name] {
+			return fmt.Errorf("%w: name=%s", errDuplicateBackendName, backend.Name)
+		}
+		names[backend.Name] = true
+		if backend.Hostname == "" {
+			return fmt.Errorf("%w: backend=%s", errNoBackendHostname, backend.Name)
+		}
+		if backend.Port == 0 {
+			return fmt.Errorf("%w: backend=%s", errNoBackendPort, backend.Name)
+		}
+		switch backend.DNSType {
+		case "", cds.DNSTypeLogical, cds.DNSTypeStrict:
+		default:
+			return fmt.Errorf("%w: backend=%s dnsType=%s", errUnknownDNSType, backend.Name, backend.DNSType)
+		}
+	}
+	return nil
+}
@@ -20,6 +20,10 @@ import (
 	"github.com/go-logr/logr"
 )
 
+// DefaultAuthorityName is the authority name used when the control plane does not run in a
+// Kubernetes Pod, e.g., when serving only fallback applications on a laptop.
+const DefaultAuthorityName = "control-plane.xds.svc.cluster.local"
+
 // AuthorityName returns the expected authority name of this control plane management server.
 // The authority name is used in xDS federation, where xDS clients can specify
 // the authority of an xDS resource.
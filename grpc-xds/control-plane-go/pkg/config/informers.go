@@ -19,9 +19,11 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
 
 	"github.com/go-logr/logr"
 	"gopkg.in/yaml.v3"
+	"k8s.io/apimachinery/pkg/labels"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
 )
@@ -34,17 +36,15 @@ const (
 var (
 	errNoConfig           = errors.New("no informer configurations provided")
 	errNoContext          = errors.New("no kubeconfig contexts provided")
-	errNoServices         = errors.New("no services listed in informer configuration")
+	errServicesNotAllowed = errors.New("services and labelSelector cannot be set in informer configuration with xdsApplications=true")
+	errInvalidWildcard    = errors.New("the `*` wildcard must be the only entry in the list of services")
 	errDuplicateContext   = errors.New("context name used more than once in the informer configuration")
 	errDuplicateNamespace = errors.New("namespace used more than once in the informer configuration")
+	errNegativeTTL        = errors.New("stalenessTtl cannot be negative")
 )
 
 func Kubecontexts(logger logr.Logger) ([]informers.Kubecontext, error) {
-	configDir, exists := os.LookupEnv("CONFIG_DIR")
-	if !exists {
-		configDir = defaultConfigDir
-	}
-	informersConfigFilePath := filepath.Join(configDir, informersConfigFile)
+	informersConfigFilePath := configFilePath(informersConfigFile)
 	logger.V(4).Info("Loading informer configuration", "filepath", informersConfigFilePath)
 	yamlBytes, err := os.ReadFile(informersConfigFilePath)
 	if err != nil {
@@ -62,6 +62,16 @@ func Kubecontexts(logger logr.Logger) ([]informers.Kubecontext, error) {
 	return kubecontexts, err
 }
 
+// configFilePath returns the path of the named file in the config directory.
+// The config directory is set by the `CONFIG_DIR` environment variable.
+func configFilePath(filename string) string {
+	configDir, exists := os.LookupEnv("CONFIG_DIR")
+	if !exists {
+		configDir = defaultConfigDir
+	}
+	return filepath.Join(configDir, filename)
+}
+
 func validateKubeContexts(contexts []informers.Kubecontext) error {
 	if len(contexts) == 0 {
 		return errNoContext
@@ -71,6 +81,9 @@ func validateKubeContexts(contexts []informers.Kubecontext) error {
 		if _, exists := contextNames[context.Context]; exists {
 			return fmt.Errorf("%w: context=%s", errDuplicateContext, context.Context)
 		}
+		if context.StalenessTTL != nil && *context.StalenessTTL < 0 {
+			return fmt.Errorf("%w: context=%s stalenessTtl=%s", errNegativeTTL, context.Context, *context.StalenessTTL)
+		}
 		if err := validateInformerConfigs(context.Informers); err != nil {
 			return fmt.Errorf("invalid informer config for context=%s: %w", context.Context, err)
 		}
@@ -85,8 +98,16 @@ func validateInformerConfigs(configs []informers.Config) error {
 	}
 	namespaces := map[string]bool{}
 	for _, config := range configs {
-		if config.Services == nil || len(config.Services) == 0 {
-			return fmt.Errorf("%w: config=%+v", errNoServices, config)
+		if config.XDSApplications && (len(config.Services) > 0 || config.LabelSelector != "") {
+			return fmt.Errorf("%w: config=%+v", errServicesNotAllowed, config)
+		}
+		if len(config.Services) > 1 && slices.Contains(config.Services, informers.AllServicesWildcard) {
+			return fmt.Errorf("%w: config=%+v", errInvalidWildcard, config)
+		}
+		if config.LabelSelector != "" {
+			if _, err := labels.Parse(config.LabelSelector); err != nil {
+				return fmt.Errorf("invalid labelSelector in config=%+v: %w", config, err)
+			}
 		}
 		if _, exists := namespaces[config.Namespace]; exists {
 			return fmt.Errorf("%w: namespace=%s", errDuplicateNamespace, config.Namespace)
@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
+)
+
+func TestValidateInformerConfigs(t *testing.T) {
+	tests := []struct {
+		name    string
+		configs []informers.Config
+		wantErr error
+	}{
+		{
+			name:    "wildcard",
+			configs: []informers.Config{{Namespace: "xds", Services: []string{informers.AllServicesWildcard}}},
+		},
+		{
+			name:    "service names",
+			configs: []informers.Config{{Namespace: "xds", Services: []string{"greeter-intermediary", "greeter-leaf"}}},
+		},
+		{
+			name:    "wildcard with service names",
+			configs: []informers.Config{{Namespace: "xds", Services: []string{informers.AllServicesWildcard, "greeter-leaf"}}},
+			wantErr: errInvalidWildcard,
+		},
+		{
+			name:    "services with xdsApplications",
+			configs: []informers.Config{{Namespace: "xds", Services: []string{"greeter-leaf"}, XDSApplications: true}},
+			wantErr: errServicesNotAllowed,
+		},
+		{
+			name:    "duplicate namespace",
+			configs: []informers.Config{{Namespace: "xds"}, {Namespace: "xds"}},
+			wantErr: errDuplicateNamespace,
+		},
+		{
+			name:    "no configs",
+			configs: nil,
+			wantErr: errNoConfig,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateInformerConfigs(test.configs)
+			if test.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+)
+
+const (
+	jwtAuthnConfigFile = "jwt_authn.yaml"
+)
+
+var (
+	errNoProviderName        = errors.New("JWT provider name cannot be blank")
+	errDuplicateProviderName = errors.New("JWT provider name used more than once")
+	errNoIssuer              = errors.New("JWT provider issuer cannot be blank")
+	errJWKSSource            = errors.New("JWT provider must specify exactly one of jwksUri and localJwks")
+	errNoJWKSCluster         = errors.New("JWT provider with jwksUri must also specify jwksCluster")
+	errInvalidJWKSURI        = errors.New("JWT provider jwksUri must be an http or https URL with a host")
+	errJWKSClusterConflict   = errors.New("JWT providers with the same jwksCluster must use the same scheme, host, and port in jwksUri")
+)
+
+// JWTProviders returns the JWT providers for the JWT authentication HTTP filter of gRPC server
+// Listeners. If the config file does not exist, JWTProviders returns nil.
+func JWTProviders(logger logr.Logger) ([]lds.JWTProvider, error) {
+	jwtAuthnConfigFilePath := configFilePath(jwtAuthnConfigFile)
+	logger.V(4).Info("Loading JWT providers", "filepath", jwtAuthnConfigFilePath)
+	yamlBytes, err := os.ReadFile(jwtAuthnConfigFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.V(2).Info("No JWT providers config file", "filepath", jwtAuthnConfigFilePath)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read JWT providers from file %s: %w", jwtAuthnConfigFilePath, err)
+	}
+	var providers []lds.JWTProvider
+	err = yaml.Unmarshal(yamlBytes, &providers)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal JWT providers YAML file contents [%s]: %w", yamlBytes, err)
+	}
+	if err := validateJWTProviders(providers); err != nil {
+		return nil, fmt.Errorf("JWT providers validation failed: %w", err)
+	}
+	logger.V(2).Info("JWT authentication", "providers", providers)
+	return providers, nil
+}
+
+// validateJWTProviders checks the JWT providers. The control plane generates a Cluster for each
+// `jwksCluster` from the `jwksUri`, so providers that share a `jwksCluster` must fetch their JWKS
+// from the same scheme, host, and port.
+func validateJWTProviders(providers []lds.JWTProvider) error {
+	names := map[string]bool{}
+	jwksHostsByCluster := map[string]string{}
+	for _, provider := range providers {
+		if provider.Name == "" {
+			return fmt.Errorf("%w: provider=%+v", errNoProviderName, provider)
+		}
+		if names[provider.Name] {
+			return fmt.Errorf("%w: name=%s", errDuplicateProviderName, provider.Name)
+		}
+		names[provider.Name] = true
+		if provider.Issuer == "" {
+			return fmt.Errorf("%w: provider=%s", errNoIssuer, provider.Name)
+		}
+		if (provider.JWKSURI == "") == (provider.LocalJWKS == "") {
+			return fmt.Errorf("%w: provider=%s", errJWKSSource, provider.Name)
+		}
+		if provider.JWKSURI == "" {
+			continue
+		}
+		if provider.JWKSCluster == "" {
+			return fmt.Errorf("%w: provider=%s", errNoJWKSCluster, provider.Name)
+		}
+		jwksURI, err := url.Parse(provider.JWKSURI)
+		if err != nil || (jwksURI.Scheme != "http" && jwksURI.Scheme != "https") || jwksURI.Hostname() == "" {
+			return fmt.Errorf("%w: provider=%s jwksUri=%s", errInvalidJWKSURI, provider.Name, provider.JWKSURI)
+		}
+		jwksHost := jwksURI.Scheme + "://" + jwksURI.Host
+		if existing, exists := jwksHostsByCluster[provider.JWKSCluster]; exists && existing != jwksHost {
+			return fmt.Errorf("%w: provider=%s jwksCluster=%s hosts=[%s %s]", errJWKSClusterConflict, provider.Name, provider.JWKSCluster, existing, jwksHost)
+		}
+		jwksHostsByCluster[provider.JWKSCluster] = jwksHost
+	}
+	return nil
+}
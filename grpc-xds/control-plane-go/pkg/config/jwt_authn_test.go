@@ -0,0 +1,115 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+)
+
+func TestValidateJWTProviders(t *testing.T) {
+	google := lds.JWTProvider{
+		Name:        "google",
+		Issuer:      "https://accounts.google.com",
+		JWKSURI:     "https://www.googleapis.com/oauth2/v3/certs",
+		JWKSCluster: "googleapis",
+	}
+	tests := []struct {
+		name      string
+		providers []lds.JWTProvider
+		wantErr   error
+	}{
+		{
+			name:      "remote JWKS",
+			providers: []lds.JWTProvider{google},
+		},
+		{
+			name:      "local JWKS",
+			providers: []lds.JWTProvider{{Name: "local", Issuer: "https://issuer.example.com", LocalJWKS: `{"keys":[]}`}},
+		},
+		{
+			name: "providers sharing a jwksCluster with the same host",
+			providers: []lds.JWTProvider{google, {
+				Name:        "firebase",
+				Issuer:      "https://securetoken.google.com/project",
+				JWKSURI:     "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com",
+				JWKSCluster: "googleapis",
+			}},
+		},
+		{
+			name:      "blank provider name",
+			providers: []lds.JWTProvider{{Issuer: "https://accounts.google.com", LocalJWKS: `{"keys":[]}`}},
+			wantErr:   errNoProviderName,
+		},
+		{
+			name:      "duplicate provider name",
+			providers: []lds.JWTProvider{google, google},
+			wantErr:   errDuplicateProviderName,
+		},
+		{
+			name:      "blank issuer",
+			providers: []lds.JWTProvider{{Name: "google", JWKSURI: google.JWKSURI, JWKSCluster: google.JWKSCluster}},
+			wantErr:   errNoIssuer,
+		},
+		{
+			name:      "no JWKS source",
+			providers: []lds.JWTProvider{{Name: "google", Issuer: google.Issuer}},
+			wantErr:   errJWKSSource,
+		},
+		{
+			name:      "both JWKS sources",
+			providers: []lds.JWTProvider{{Name: "google", Issuer: google.Issuer, JWKSURI: google.JWKSURI, JWKSCluster: google.JWKSCluster, LocalJWKS: `{"keys":[]}`}},
+			wantErr:   errJWKSSource,
+		},
+		{
+			name:      "jwksUri without jwksCluster",
+			providers: []lds.JWTProvider{{Name: "google", Issuer: google.Issuer, JWKSURI: google.JWKSURI}},
+			wantErr:   errNoJWKSCluster,
+		},
+		{
+			name:      "jwksUri without scheme",
+			providers: []lds.JWTProvider{{Name: "google", Issuer: google.Issuer, JWKSURI: "www.googleapis.com/oauth2/v3/certs", JWKSCluster: google.JWKSCluster}},
+			wantErr:   errInvalidJWKSURI,
+		},
+		{
+			name:      "jwksUri with unsupported scheme",
+			providers: []lds.JWTProvider{{Name: "google", Issuer: google.Issuer, JWKSURI: "file:///etc/jwks.json", JWKSCluster: google.JWKSCluster}},
+			wantErr:   errInvalidJWKSURI,
+		},
+		{
+			name: "providers sharing a jwksCluster with different hosts",
+			providers: []lds.JWTProvider{google, {
+				Name:        "example",
+				Issuer:      "https://issuer.example.com",
+				JWKSURI:     "https://issuer.example.com/jwks.json",
+				JWKSCluster: "googleapis",
+			}},
+			wantErr: errJWKSClusterConflict,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateJWTProviders(test.providers)
+			if test.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
+)
+
+const (
+	defaultKubecontextHealthCheckInterval = 10 * time.Second
+	defaultKubecontextStalenessTTL        = 60 * time.Second
+	kubecontextHealthCheckIntervalEnvVar  = "KUBECONTEXT_HEALTH_CHECK_INTERVAL"
+	kubecontextStalenessTTLEnvVar         = "KUBECONTEXT_STALENESS_TTL"
+)
+
+// KubecontextHealth returns the health checking configuration for the Kubernetes API
+// servers of the kubecontexts in the informer configuration.
+// A health check interval of 0 disables health checking, and a staleness TTL of 0
+// disables eviction of applications from unreachable kubecontexts.
+func KubecontextHealth() (informers.HealthConfig, error) {
+	interval, err := durationFromEnv(kubecontextHealthCheckIntervalEnvVar, defaultKubecontextHealthCheckInterval)
+	if err != nil {
+		return informers.HealthConfig{}, err
+	}
+	stalenessTTL, err := durationFromEnv(kubecontextStalenessTTLEnvVar, defaultKubecontextStalenessTTL)
+	if err != nil {
+		return informers.HealthConfig{}, err
+	}
+	return informers.HealthConfig{
+		Interval:     interval,
+		StalenessTTL: stalenessTTL,
+	}, nil
+}
+
+func durationFromEnv(envVar string, defaultValue time.Duration) (time.Duration, error) {
+	valueEnv, exists := os.LookupEnv(envVar)
+	if !exists {
+		return defaultValue, nil
+	}
+	value, err := time.ParseDuration(valueEnv)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert environment variable value %s=%s to duration: %w", envVar, valueEnv, err)
+	}
+	return value, nil
+}
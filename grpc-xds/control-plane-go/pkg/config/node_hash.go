@@ -0,0 +1,30 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"time"
+)
+
+const (
+	defaultNodeHashIdleTTL = 10 * time.Minute
+	nodeHashIdleTTLEnvVar  = "NODE_HASH_IDLE_TTL"
+)
+
+// NodeHashIdleTTL returns how long the snapshot cache keeps the snapshot and server listener
+// addresses of a node hash after its last xDS stream closes. A value of 0 disables eviction.
+func NodeHashIdleTTL() (time.Duration, error) {
+	return durationFromEnv(nodeHashIdleTTLEnvVar, defaultNodeHashIdleTTL)
+}
@@ -14,39 +14,27 @@
 
 package config
 
-import (
-	"fmt"
-	"os"
-	"strconv"
-)
+import "flag"
 
 const (
 	defaultServingPort = 50051
 	defaultHealthPort  = 50052
+	defaultMetricsPort = 50053
 	servingPortEnvVar  = "PORT"
 	healthPortEnvVar   = "HEALTH_PORT"
+	metricsPortEnvVar  = "METRICS_PORT"
 )
 
-func ServingPort() (int, error) {
-	port := defaultServingPort
-	if portEnv, exists := os.LookupEnv(servingPortEnvVar); exists {
-		var err error
-		port, err = strconv.Atoi(portEnv)
-		if err != nil {
-			return 0, fmt.Errorf("could not convert environment variable value %s=%s to integer: %w", servingPortEnvVar, portEnv, err)
-		}
-	}
-	return port, nil
+func ServingPort(flagset *flag.FlagSet) (int, error) {
+	return intSetting(flagset, servingPortSetting, defaultServingPort)
+}
+
+func HealthPort(flagset *flag.FlagSet) (int, error) {
+	return intSetting(flagset, healthPortSetting, defaultHealthPort)
 }
 
-func HealthPort() (int, error) {
-	port := defaultHealthPort
-	if portEnv, exists := os.LookupEnv(healthPortEnvVar); exists {
-		var err error
-		port, err = strconv.Atoi(portEnv)
-		if err != nil {
-			return 0, fmt.Errorf("could not convert environment variable value %s=%s to integer: %w", healthPortEnvVar, portEnv, err)
-		}
-	}
-	return port, nil
+// MetricsPort is the port of the HTTP server for Prometheus metrics and debug information.
+// A value of 0 disables the HTTP server.
+func MetricsPort(flagset *flag.FlagSet) (int, error) {
+	return intSetting(flagset, metricsPortSetting, defaultMetricsPort)
 }
@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+const (
+	rbacConfigFile = "rbac.yaml"
+)
+
+var (
+	errNoPolicyName        = errors.New("RBAC policy name cannot be blank")
+	errDuplicatePolicyName = errors.New("RBAC policy name used more than once")
+	errInvalidMethod       = errors.New("RBAC policy methods must start with `/`")
+	errNoHeaderName        = errors.New("RBAC policy header name cannot be blank")
+	errNoRBACApplication   = errors.New("RBAC policy application name cannot be blank")
+)
+
+// RBACPolicies returns the RBAC policies for gRPC server Listeners. If the config file does
+// not exist, RBACPolicies returns nil, and the control plane uses `rds.DefaultRBACPolicies()`.
+func RBACPolicies(logger logr.Logger) ([]rds.RBACPolicy, error) {
+	rbacConfigFilePath := configFilePath(rbacConfigFile)
+	logger.V(4).Info("Loading RBAC policies", "filepath", rbacConfigFilePath)
+	yamlBytes, err := os.ReadFile(rbacConfigFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.V(2).Info("No RBAC policies config file, using the default policies", "filepath", rbacConfigFilePath)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read RBAC policies from file %s: %w", rbacConfigFilePath, err)
+	}
+	var policies []rds.RBACPolicy
+	err = yaml.Unmarshal(yamlBytes, &policies)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal RBAC policies YAML file contents [%s]: %w", yamlBytes, err)
+	}
+	if err := validateRBACPolicies(policies); err != nil {
+		return nil, fmt.Errorf("RBAC policies validation failed: %w", err)
+	}
+	logger.V(2).Info("RBAC", "policies", policies)
+	return policies, nil
+}
+
+func validateRBACPolicies(policies []rds.RBACPolicy) error {
+	names := map[string]bool{}
+	for _, policy := range policies {
+		if policy.Name == "" {
+			return fmt.Errorf("%w: policy=%+v", errNoPolicyName, policy)
+		}
+		if names[policy.Name] {
+			return fmt.Errorf("%w: name=%s", errDuplicatePolicyName, policy.Name)
+		}
+		names[policy.Name] = true
+		for _, method := range policy.Methods {
+			if !strings.HasPrefix(method, "/") {
+				return fmt.Errorf("%w: policy=%s method=%s", errInvalidMethod, policy.Name, method)
+			}
+		}
+		for _, appName := range policy.Applications {
+			if appName == "" {
+				return fmt.Errorf("%w: policy=%s", errNoRBACApplication, policy.Name)
+			}
+		}
+		for _, header := range policy.Headers {
+			if header.Name == "" {
+				return fmt.Errorf("%w: policy=%s", errNoHeaderName, policy.Name)
+			}
+			if header.RegularExpression {
+				if _, err := regexp.Compile(header.Value); err != nil {
+					return fmt.Errorf("invalid regular expression for header=%s in policy=%s: %w", header.Name, policy.Name, err)
+				}
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+func TestValidateRBACPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []rds.RBACPolicy
+		wantErr  error
+	}{
+		{
+			name:     "valid",
+			policies: []rds.RBACPolicy{{Name: "a", Methods: []string{"/helloworld.Greeter/"}, Applications: []string{"greeter-leaf"}}},
+		},
+		{
+			name:     "blank policy name",
+			policies: []rds.RBACPolicy{{}},
+			wantErr:  errNoPolicyName,
+		},
+		{
+			name:     "duplicate policy name",
+			policies: []rds.RBACPolicy{{Name: "a"}, {Name: "a"}},
+			wantErr:  errDuplicatePolicyName,
+		},
+		{
+			name:     "method without leading slash",
+			policies: []rds.RBACPolicy{{Name: "a", Methods: []string{"helloworld.Greeter/SayHello"}}},
+			wantErr:  errInvalidMethod,
+		},
+		{
+			name:     "blank header name",
+			policies: []rds.RBACPolicy{{Name: "a", Headers: []rds.RBACHeaderMatch{{Value: "x"}}}},
+			wantErr:  errNoHeaderName,
+		},
+		{
+			name:     "blank application name",
+			policies: []rds.RBACPolicy{{Name: "a", Applications: []string{"greeter-leaf", ""}}},
+			wantErr:  errNoRBACApplication,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateRBACPolicies(test.policies)
+			if test.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
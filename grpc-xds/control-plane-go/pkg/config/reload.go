@@ -0,0 +1,180 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+const (
+	defaultConfigReloadInterval = 10 * time.Second
+	configReloadIntervalEnvVar  = "CONFIG_RELOAD_INTERVAL"
+)
+
+// ConfigReloadInterval returns how often to check the informer configuration and
+// xDS feature flags files for changes. A value of 0 disables reloading.
+func ConfigReloadInterval() (time.Duration, error) {
+	return durationFromEnv(configReloadIntervalEnvVar, defaultConfigReloadInterval)
+}
+
+// ReloadHandler applies configuration changes at runtime.
+type ReloadHandler interface {
+	ReloadKubecontexts(ctx context.Context, logger logr.Logger, kubecontexts []informers.Kubecontext) error
+	ReloadXDSFeatures(ctx context.Context, logger logr.Logger, xdsFeatures *xds.Features) error
+	ReloadRBACPolicies(ctx context.Context, logger logr.Logger, policies []rds.RBACPolicy) error
+	ReloadRoutePolicies(ctx context.Context, logger logr.Logger, policies []rds.RoutePolicy) error
+	ReloadJWTProviders(ctx context.Context, logger logr.Logger, providers []lds.JWTProvider) error
+	ReloadExternalBackends(ctx context.Context, logger logr.Logger, backends []cds.ExternalBackend) error
+	ReloadFallbackApplications(ctx context.Context, logger logr.Logger, apps []applications.Application) error
+}
+
+// WatchConfigFiles polls the informer configuration, xDS feature flags, RBAC policies, route policies, JWT providers, external backends, and fallback applications files
+// at the provided interval, until the context is done.
+//
+// When the contents of a file change, the file is parsed and validated, and the
+// new configuration is passed to the handler. If the new configuration is invalid,
+// the current configuration stays in effect.
+//
+// Polling the file contents, instead of watching for filesystem events, works with
+// Kubernetes ConfigMap volumes, where updates replace a symlink to a directory.
+func WatchConfigFiles(ctx context.Context, logger logr.Logger, interval time.Duration, handler ReloadHandler) {
+	if interval <= 0 {
+		logger.V(2).Info("Config file reloading disabled")
+		return
+	}
+	kubecontextsWatcher := newFileWatcher(configFilePath(informersConfigFile))
+	xdsFeaturesWatcher := newFileWatcher(configFilePath(xdsFeaturesConfigFile))
+	rbacPoliciesWatcher := newFileWatcher(configFilePath(rbacConfigFile))
+	routePoliciesWatcher := newFileWatcher(configFilePath(routePoliciesConfigFile))
+	jwtProvidersWatcher := newFileWatcher(configFilePath(jwtAuthnConfigFile))
+	externalBackendsWatcher := newFileWatcher(configFilePath(externalBackendsConfigFile))
+	fallbackApplicationsWatcher := newFileWatcher(configFilePath(fallbackApplicationsConfigFile))
+	logger.V(2).Info("Watching config files for changes", "interval", interval, "files", []string{kubecontextsWatcher.path, xdsFeaturesWatcher.path, rbacPoliciesWatcher.path, routePoliciesWatcher.path, jwtProvidersWatcher.path, externalBackendsWatcher.path, fallbackApplicationsWatcher.path})
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if kubecontextsWatcher.changed(logger) {
+				kubecontexts, err := Kubecontexts(logger)
+				if err == nil {
+					err = handler.ReloadKubecontexts(ctx, logger, kubecontexts)
+				}
+				if err != nil {
+					logger.Error(err, "Could not reload informer configuration, keeping the current informers")
+				}
+			}
+			if xdsFeaturesWatcher.changed(logger) {
+				xdsFeatures, err := XDSFeatures(logger)
+				if err == nil {
+					err = handler.ReloadXDSFeatures(ctx, logger, xdsFeatures)
+				}
+				if err != nil {
+					logger.Error(err, "Could not reload xDS feature flags, keeping the current flags")
+				}
+			}
+			if rbacPoliciesWatcher.changed(logger) {
+				policies, err := RBACPolicies(logger)
+				if err == nil {
+					err = handler.ReloadRBACPolicies(ctx, logger, policies)
+				}
+				if err != nil {
+					logger.Error(err, "Could not reload RBAC policies, keeping the current policies")
+				}
+			}
+			if routePoliciesWatcher.changed(logger) {
+				policies, err := RoutePolicies(logger)
+				if err == nil {
+					err = handler.ReloadRoutePolicies(ctx, logger, policies)
+				}
+				if err != nil {
+					logger.Error(err, "Could not reload route policies, keeping the current policies")
+				}
+			}
+			if jwtProvidersWatcher.changed(logger) {
+				providers, err := JWTProviders(logger)
+				if err == nil {
+					err = handler.ReloadJWTProviders(ctx, logger, providers)
+				}
+				if err != nil {
+					logger.Error(err, "Could not reload JWT providers, keeping the current providers")
+				}
+			}
+			if externalBackendsWatcher.changed(logger) {
+				backends, err := ExternalBackends(logger)
+				if err == nil {
+					err = handler.ReloadExternalBackends(ctx, logger, backends)
+				}
+				if err != nil {
+					logger.Error(err, "Could not reload external backends, keeping the current backends")
+				}
+			}
+			if fallbackApplicationsWatcher.changed(logger) {
+				apps, err := FallbackApplications(logger)
+				if err == nil {
+					err = handler.ReloadFallbackApplications(ctx, logger, apps)
+				}
+				if err != nil {
+					logger.Error(err, "Could not reload fallback applications, keeping the current applications")
+				}
+			}
+		}
+	}
+}
+
+// fileWatcher detects changes to the contents of a file.
+type fileWatcher struct {
+	path     string
+	contents []byte
+}
+
+// newFileWatcher records the current contents of the file, so that the first
+// call to `changed()` only reports changes made after startup.
+func newFileWatcher(path string) *fileWatcher {
+	contents, _ := os.ReadFile(path)
+	return &fileWatcher{
+		path:     path,
+		contents: contents,
+	}
+}
+
+// changed returns true iff the file contents differ from the previous call.
+func (w *fileWatcher) changed(logger logr.Logger) bool {
+	contents, err := os.ReadFile(w.path)
+	if err != nil {
+		logger.V(4).Info("Could not read config file, skipping reload", "filepath", w.path, "error", err.Error())
+		return false
+	}
+	if bytes.Equal(contents, w.contents) {
+		return false
+	}
+	logger.V(1).Info("Config file changed", "filepath", w.path)
+	w.contents = contents
+	return true
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+const (
+	routePoliciesConfigFile = "route_policies.yaml"
+)
+
+var (
+	errNoRoutePolicyApplication        = errors.New("route policy application cannot be blank")
+	errDuplicateRoutePolicyApplication = errors.New("route policy application used more than once")
+	errNoMethodRouteService            = errors.New("route policy service cannot be blank")
+	errUnknownRetryOn                  = errors.New("route policy retryOn must be one of cancelled, deadline-exceeded, internal, resource-exhausted, or unavailable")
+	errInvalidNumRetries               = fmt.Errorf("route policy retry numRetries must be between 1 and %d", rds.MaxNumRetries)
+	errInvalidRetryInterval            = errors.New("route policy retry intervals cannot be negative, and maxInterval cannot be less than baseInterval")
+	errInvalidFaultPercent             = errors.New("route policy fault percentages must be between 0 and 100")
+	errInvalidAbortCode                = errors.New("route policy fault abortCode must be a gRPC status code name other than OK")
+	errNoMirrorCluster                 = errors.New("route policy mirror cluster cannot be blank")
+	errInvalidMirrorPercent            = errors.New("route policy mirror percent must be between 1 and 100")
+)
+
+// retryOnGRPCStatusCodes are the `retry_on` values supported by gRPC clients, see gRFC A44.
+var retryOnGRPCStatusCodes = map[string]bool{
+	"cancelled":          true,
+	"deadline-exceeded":  true,
+	"internal":           true,
+	"resource-exhausted": true,
+	"unavailable":        true,
+}
+
+// RoutePolicies returns the per-method route policies of applications. If the config file does
+// not exist, RoutePolicies returns nil, and the RouteConfigurations of applications only have a
+// route for the path prefix of the application.
+func RoutePolicies(logger logr.Logger) ([]rds.RoutePolicy, error) {
+	routePoliciesConfigFilePath := configFilePath(routePoliciesConfigFile)
+	logger.V(4).Info("Loading route policies", "filepath", routePoliciesConfigFilePath)
+	yamlBytes, err := os.ReadFile(routePoliciesConfigFilePath)
+	if errors.Is(err, fs.ErrNotExist) {
+		logger.V(2).Info("No route policies config file", "filepath", routePoliciesConfigFilePath)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read route policies from file %s: %w", routePoliciesConfigFilePath, err)
+	}
+	var policies []rds.RoutePolicy
+	err = yaml.Unmarshal(yamlBytes, &policies)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal route policies YAML file contents [%s]: %w", yamlBytes, err)
+	}
+	if err := validateRoutePolicies(policies); err != nil {
+		return nil, fmt.Errorf("route policies validation failed: %w", err)
+	}
+	logger.V(2).Info("Route policies", "policies", policies)
+	return policies, nil
+}
+
+// validateRetryPolicy rejects retry policies that gRPC clients would reject (NACK).
+func validateRetryPolicy(retry rds.RetryPolicy) error {
+	if retry.NumRetries < 1 || retry.NumRetries > rds.MaxNumRetries {
+		return errInvalidNumRetries
+	}
+	for _, retryOn := range retry.RetryOn {
+		if !retryOnGRPCStatusCodes[retryOn] {
+			return fmt.Errorf("%w: retryOn=%s", errUnknownRetryOn, retryOn)
+		}
+	}
+	if retry.BaseInterval < 0 || retry.MaxInterval < 0 {
+		return errInvalidRetryInterval
+	}
+	baseInterval := cmp.Or(retry.BaseInterval, rds.DefaultRetryBaseInterval)
+	if retry.MaxInterval > 0 && retry.MaxInterval < baseInterval {
+		return errInvalidRetryInterval
+	}
+	return nil
+}
+
+func validateRoutePolicies(policies []rds.RoutePolicy) error {
+	applications := map[string]bool{}
+	for _, policy := range policies {
+		if policy.Application == "" {
+			return fmt.Errorf("%w: policy=%+v", errNoRoutePolicyApplication, policy)
+		}
+		if applications[policy.Application] {
+			return fmt.Errorf("%w: application=%s", errDuplicateRoutePolicyApplication, policy.Application)
+		}
+		applications[policy.Application] = true
+		for _, mirror := range policy.Mirrors {
+			if mirror.Cluster == "" {
+				return fmt.Errorf("%w: application=%s mirror=%+v", errNoMirrorCluster, policy.Application, mirror)
+			}
+			if mirror.Percent == 0 || mirror.Percent > 100 {
+				return fmt.Errorf("%w: application=%s mirror=%+v", errInvalidMirrorPercent, policy.Application, mirror)
+			}
+		}
+		for _, route := range policy.Routes {
+			if route.Service == "" {
+				return fmt.Errorf("%w: application=%s route=%+v", errNoMethodRouteService, policy.Application, route)
+			}
+			if route.Retry != nil {
+				if err := validateRetryPolicy(*route.Retry); err != nil {
+					return fmt.Errorf("%w: application=%s retry=%+v", err, policy.Application, *route.Retry)
+				}
+			}
+			if route.Fault != nil {
+				if route.Fault.DelayPercent > 100 || route.Fault.AbortPercent > 100 {
+					return fmt.Errorf("%w: application=%s fault=%+v", errInvalidFaultPercent, policy.Application, *route.Fault)
+				}
+				if route.Fault.AbortCode != "" {
+					code, err := route.Fault.ParseAbortCode()
+					if err != nil || code == codes.OK {
+						return fmt.Errorf("%w: application=%s abortCode=%s", errInvalidAbortCode, policy.Application, route.Fault.AbortCode)
+					}
+				}
+			}
+		}
+	}
+	return nil
+}
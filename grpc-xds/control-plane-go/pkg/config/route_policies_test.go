@@ -0,0 +1,86 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+func TestValidateRoutePoliciesRetry(t *testing.T) {
+	tests := []struct {
+		name    string
+		retry   rds.RetryPolicy
+		wantErr error
+	}{
+		{
+			name:  "valid",
+			retry: rds.RetryPolicy{NumRetries: 2, RetryOn: []string{"unavailable"}, BaseInterval: 50 * time.Millisecond, MaxInterval: 500 * time.Millisecond},
+		},
+		{
+			name:  "max interval equal to base interval",
+			retry: rds.RetryPolicy{NumRetries: 4, BaseInterval: 50 * time.Millisecond, MaxInterval: 50 * time.Millisecond},
+		},
+		{
+			name:    "numRetries omitted",
+			retry:   rds.RetryPolicy{RetryOn: []string{"unavailable"}},
+			wantErr: errInvalidNumRetries,
+		},
+		{
+			name:    "numRetries above gRPC maximum",
+			retry:   rds.RetryPolicy{NumRetries: 5},
+			wantErr: errInvalidNumRetries,
+		},
+		{
+			name:    "unknown retryOn",
+			retry:   rds.RetryPolicy{NumRetries: 1, RetryOn: []string{"5xx"}},
+			wantErr: errUnknownRetryOn,
+		},
+		{
+			name:    "max interval less than base interval",
+			retry:   rds.RetryPolicy{NumRetries: 1, BaseInterval: time.Second, MaxInterval: 100 * time.Millisecond},
+			wantErr: errInvalidRetryInterval,
+		},
+		{
+			name:    "max interval less than default base interval",
+			retry:   rds.RetryPolicy{NumRetries: 1, MaxInterval: 10 * time.Millisecond},
+			wantErr: errInvalidRetryInterval,
+		},
+		{
+			name:    "negative base interval",
+			retry:   rds.RetryPolicy{NumRetries: 1, BaseInterval: -time.Second},
+			wantErr: errInvalidRetryInterval,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			retry := test.retry
+			policies := []rds.RoutePolicy{{
+				Application: "greeter-leaf",
+				Routes:      []rds.MethodRoute{{Service: "helloworld.Greeter", Retry: &retry}},
+			}}
+			err := validateRoutePolicies(policies)
+			if test.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("got error %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,387 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	defaultGracefulShutdownTimeout = 5 * time.Second
+	defaultKeepaliveTime           = 30 * time.Second
+	defaultKeepaliveTimeout        = 5 * time.Second
+	defaultKeepaliveMinTime        = 30 * time.Second
+	defaultMaxConcurrentStreams    = 1000000
+	defaultTLSCertFile             = "/var/run/secrets/workload-spiffe-credentials/certificates.pem"
+	defaultTLSKeyFile              = "/var/run/secrets/workload-spiffe-credentials/private_key.pem"
+	defaultTLSCAFile               = "/var/run/secrets/workload-spiffe-credentials/ca_certificates.pem"
+	defaultTLSRefreshInterval      = 600 * time.Second
+	defaultTLSExpiryWarning        = 6 * time.Hour
+	// defaultMaxRecvMsgSize and defaultMaxSendMsgSize are the defaults of grpc-go.
+	defaultMaxRecvMsgSize = 4 * 1024 * 1024
+	defaultMaxSendMsgSize = math.MaxInt32
+
+	// CertificateSourceFiles reads the server certificate, private key, and CA certificates from PEM files.
+	CertificateSourceFiles = "files"
+	// CertificateSourceWorkloadAPI fetches X.509 SVIDs and trust bundles from the SPIFFE Workload API,
+	// e.g., from a SPIRE agent.
+	CertificateSourceWorkloadAPI = "spiffe-workload-api"
+
+	gracefulShutdownTimeoutEnvVar = "GRACEFUL_SHUTDOWN_TIMEOUT"
+	drainIntervalEnvVar           = "DRAIN_INTERVAL"
+	keepaliveTimeEnvVar           = "GRPC_KEEPALIVE_TIME"
+	keepaliveTimeoutEnvVar        = "GRPC_KEEPALIVE_TIMEOUT"
+	keepaliveMinTimeEnvVar        = "GRPC_KEEPALIVE_MIN_TIME"
+	maxConcurrentStreamsEnvVar    = "GRPC_MAX_CONCURRENT_STREAMS"
+	maxRecvMsgSizeEnvVar          = "GRPC_MAX_RECV_MSG_SIZE"
+	maxSendMsgSizeEnvVar          = "GRPC_MAX_SEND_MSG_SIZE"
+	tlsCertFileEnvVar             = "TLS_CERT_FILE"
+	tlsKeyFileEnvVar              = "TLS_KEY_FILE"
+	tlsCAFileEnvVar               = "TLS_CA_FILE"
+	tlsRefreshIntervalEnvVar      = "TLS_REFRESH_INTERVAL"
+	tlsExpiryWarningEnvVar        = "TLS_EXPIRY_WARNING_THRESHOLD"
+	certificateSourceEnvVar       = "TLS_CERTIFICATE_SOURCE"
+	enableApplicationSourceEnvVar = "ENABLE_APPLICATION_SOURCE"
+	enableAdminWritesEnvVar       = "ENABLE_ADMIN_WRITES"
+	// spiffeEndpointSocketEnvVar is the environment variable defined by the SPIFFE Workload Endpoint
+	// specification, see https://github.com/spiffe/spiffe/blob/main/standards/SPIFFE_Workload_Endpoint.md
+	spiffeEndpointSocketEnvVar = "SPIFFE_ENDPOINT_SOCKET"
+)
+
+var (
+	errInvalidPort                   = errors.New("port must be between 1 and 65535")
+	errInvalidMetricsPort            = errors.New("metrics port must be between 0 and 65535")
+	errDuplicatePort                 = errors.New("serving, health, and metrics ports must be different")
+	errNegativeGracefulShutdown      = errors.New("graceful shutdown timeout must not be negative")
+	errNegativeDrainInterval         = errors.New("drain interval must not be negative")
+	errNonPositiveKeepalive          = errors.New("keepalive durations must be positive")
+	errInvalidMaxConcurrentStreams   = errors.New("max concurrent streams must be between 1 and 4294967295")
+	errNonPositiveMaxMsgSize         = errors.New("max receive and send message sizes must be positive")
+	errEmptyTLSFile                  = errors.New("TLS certificate, private key, and CA certificate file paths must not be empty")
+	errNonPositiveTLSRefreshInterval = errors.New("TLS refresh interval must be positive")
+	errNegativeTLSExpiryWarning      = errors.New("TLS expiry warning threshold must not be negative")
+	errUnknownCertificateSource      = errors.New("TLS certificate source must be one of files or spiffe-workload-api")
+	errNoSPIFFEEndpointSocket        = errors.New("SPIFFE endpoint socket is required for the spiffe-workload-api certificate source")
+)
+
+// serverSetting is a server configuration value that can be set using either a command line
+// flag or an environment variable. The flag takes precedence.
+type serverSetting struct {
+	flag   string
+	envVar string
+	usage  string
+}
+
+var (
+	servingPortSetting             = serverSetting{"port", servingPortEnvVar, "port of the xDS management server"}
+	healthPortSetting              = serverSetting{"health-port", healthPortEnvVar, "port of the gRPC health checking server"}
+	metricsPortSetting             = serverSetting{"metrics-port", metricsPortEnvVar, "port of the HTTP server for metrics and debug information, 0 disables the server"}
+	gracefulShutdownTimeoutSetting = serverSetting{"graceful-shutdown-timeout", gracefulShutdownTimeoutEnvVar, "how long to wait for open streams to finish before stopping the servers"}
+	drainIntervalSetting           = serverSetting{"drain-interval", drainIntervalEnvVar, "how long to report NOT_SERVING health status before gracefully stopping the servers"}
+	keepaliveTimeSetting           = serverSetting{"keepalive-time", keepaliveTimeEnvVar, "gRPC server keepalive ping interval"}
+	keepaliveTimeoutSetting        = serverSetting{"keepalive-timeout", keepaliveTimeoutEnvVar, "gRPC server keepalive ping timeout"}
+	keepaliveMinTimeSetting        = serverSetting{"keepalive-min-time", keepaliveMinTimeEnvVar, "minimum interval between client keepalive pings"}
+	maxConcurrentStreamsSetting    = serverSetting{"max-concurrent-streams", maxConcurrentStreamsEnvVar, "maximum number of concurrent streams per client connection"}
+	maxRecvMsgSizeSetting          = serverSetting{"max-recv-msg-size", maxRecvMsgSizeEnvVar, "maximum size in bytes of messages received by the gRPC servers"}
+	maxSendMsgSizeSetting          = serverSetting{"max-send-msg-size", maxSendMsgSizeEnvVar, "maximum size in bytes of messages sent by the gRPC servers, e.g., xDS responses"}
+	tlsCertFileSetting             = serverSetting{"tls-cert-file", tlsCertFileEnvVar, "path of the server certificate chain file, used when enableControlPlaneTls=true"}
+	tlsKeyFileSetting              = serverSetting{"tls-key-file", tlsKeyFileEnvVar, "path of the server private key file, used when enableControlPlaneTls=true"}
+	tlsCAFileSetting               = serverSetting{"tls-ca-file", tlsCAFileEnvVar, "path of the CA certificates file, used when requireControlPlaneClientCerts=true"}
+	tlsRefreshIntervalSetting      = serverSetting{"tls-refresh-interval", tlsRefreshIntervalEnvVar, "how often to reload the TLS certificate files"}
+	tlsExpiryWarningSetting        = serverSetting{"tls-expiry-warning-threshold", tlsExpiryWarningEnvVar, "log warnings when a TLS certificate expires within this duration, 0 disables the warnings"}
+	certificateSourceSetting       = serverSetting{"tls-certificate-source", certificateSourceEnvVar, "source of the server certificates when enableControlPlaneTls=true, either files or spiffe-workload-api"}
+	spiffeEndpointSocketSetting    = serverSetting{"spiffe-endpoint-socket", spiffeEndpointSocketEnvVar, "address of the SPIFFE Workload API, e.g., unix:///run/spire/sockets/agent.sock"}
+	enableApplicationSourceSetting = serverSetting{"enable-application-source", enableApplicationSourceEnvVar, "accept PutApplications and DeleteApplications requests on the plaintext health port"}
+	enableAdminWritesSetting       = serverSetting{"enable-admin-writes", enableAdminWritesEnvVar, "accept admin requests that change the control plane state on the plaintext health and metrics ports"}
+
+	serverSettings = []serverSetting{
+		servingPortSetting,
+		healthPortSetting,
+		metricsPortSetting,
+		gracefulShutdownTimeoutSetting,
+		drainIntervalSetting,
+		keepaliveTimeSetting,
+		keepaliveTimeoutSetting,
+		keepaliveMinTimeSetting,
+		maxConcurrentStreamsSetting,
+		maxRecvMsgSizeSetting,
+		maxSendMsgSizeSetting,
+		tlsCertFileSetting,
+		tlsKeyFileSetting,
+		tlsCAFileSetting,
+		tlsRefreshIntervalSetting,
+		tlsExpiryWarningSetting,
+		certificateSourceSetting,
+		spiffeEndpointSocketSetting,
+		enableApplicationSourceSetting,
+		enableAdminWritesSetting,
+		syntheticApplicationsSetting,
+		syntheticEndpointsSetting,
+		syntheticZonesSetting,
+		syntheticChurnIntervalSetting,
+	}
+)
+
+// settingValue is the value of a server flag, as set on the command line. Unlike the flag
+// defaults, unset flags do not override the environment variables.
+type settingValue struct {
+	value string
+	set   bool
+}
+
+func (v *settingValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *settingValue) Set(value string) error {
+	v.value = value
+	v.set = true
+	return nil
+}
+
+// Server is the configuration of the xDS management server, the health checking server,
+// and the metrics HTTP server.
+type Server struct {
+	ServingPort int
+	HealthPort  int
+	// MetricsPort is the port of the HTTP server for Prometheus metrics and debug information.
+	// A value of 0 disables the HTTP server.
+	MetricsPort int
+	// GracefulShutdownTimeout is how long the server waits for open streams to finish on shutdown,
+	// before stopping immediately.
+	GracefulShutdownTimeout time.Duration
+	// DrainInterval is how long the server reports NOT_SERVING health status on shutdown before it
+	// starts the graceful shutdown, so that load balancers and clients can switch to other replicas.
+	DrainInterval        time.Duration
+	KeepaliveTime        time.Duration
+	KeepaliveTimeout     time.Duration
+	KeepaliveMinTime     time.Duration
+	MaxConcurrentStreams uint32
+	// MaxRecvMsgSize and MaxSendMsgSize are the maximum sizes of received and sent messages, in bytes.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+	// TLSCertFile, TLSKeyFile, and TLSCAFile are only used when `enableControlPlaneTls` is set
+	// in the xDS feature flags. TLSCAFile is only used when `requireControlPlaneClientCerts` is set.
+	TLSCertFile        string
+	TLSKeyFile         string
+	TLSCAFile          string
+	TLSRefreshInterval time.Duration
+	// TLSExpiryWarningThreshold is how long before expiry of the certificates in TLSCertFile and
+	// TLSCAFile the server starts logging warnings. A value of 0 disables the warnings.
+	TLSExpiryWarningThreshold time.Duration
+	// CertificateSource is either `CertificateSourceFiles` or `CertificateSourceWorkloadAPI`.
+	CertificateSource string
+	// SPIFFEEndpointSocket is the address of the SPIFFE Workload API, only used with `CertificateSourceWorkloadAPI`.
+	SPIFFEEndpointSocket string
+	// EnableApplicationSource allows the ApplicationSource service on the health port to change
+	// the applications. The health port is plaintext and does not authenticate callers, so this
+	// is disabled by default.
+	EnableApplicationSource bool
+	// EnableAdminWrites allows the requests of the ControlPlaneAdmin service on the health port, and
+	// of the `/debug/maintenance` handler on the metrics port, that change the state of the control
+	// plane, e.g., feature flags and maintenance mode. Read-only requests are always allowed. Disabled by default, for the same reason as EnableApplicationSource.
+	EnableAdminWrites bool
+}
+
+// InitServerFlags initializes flags for the server configuration. Each flag overrides the
+// corresponding environment variable.
+func InitServerFlags(flagset *flag.FlagSet) {
+	if flagset == nil {
+		flagset = flag.CommandLine
+	}
+	for _, setting := range serverSettings {
+		flagset.Var(&settingValue{}, setting.flag, fmt.Sprintf("%s (env %s)", setting.usage, setting.envVar))
+	}
+}
+
+// ServerConfig returns the server configuration from the command line flags of the flag set,
+// environment variables, and defaults, in that order of precedence. The flag set must be
+// initialized using `InitServerFlags()`. A nil flag set only uses environment variables and defaults.
+func ServerConfig(flagset *flag.FlagSet) (Server, error) {
+	var c Server
+	var err error
+	if c.ServingPort, err = intSetting(flagset, servingPortSetting, defaultServingPort); err != nil {
+		return Server{}, err
+	}
+	if c.HealthPort, err = intSetting(flagset, healthPortSetting, defaultHealthPort); err != nil {
+		return Server{}, err
+	}
+	if c.MetricsPort, err = intSetting(flagset, metricsPortSetting, defaultMetricsPort); err != nil {
+		return Server{}, err
+	}
+	if c.GracefulShutdownTimeout, err = durationSetting(flagset, gracefulShutdownTimeoutSetting, defaultGracefulShutdownTimeout); err != nil {
+		return Server{}, err
+	}
+	if c.DrainInterval, err = durationSetting(flagset, drainIntervalSetting, 0); err != nil {
+		return Server{}, err
+	}
+	if c.KeepaliveTime, err = durationSetting(flagset, keepaliveTimeSetting, defaultKeepaliveTime); err != nil {
+		return Server{}, err
+	}
+	if c.KeepaliveTimeout, err = durationSetting(flagset, keepaliveTimeoutSetting, defaultKeepaliveTimeout); err != nil {
+		return Server{}, err
+	}
+	if c.KeepaliveMinTime, err = durationSetting(flagset, keepaliveMinTimeSetting, defaultKeepaliveMinTime); err != nil {
+		return Server{}, err
+	}
+	maxConcurrentStreams, err := intSetting(flagset, maxConcurrentStreamsSetting, defaultMaxConcurrentStreams)
+	if err != nil {
+		return Server{}, err
+	}
+	if maxConcurrentStreams <= 0 || int64(maxConcurrentStreams) > int64(^uint32(0)) {
+		return Server{}, fmt.Errorf("%w: %d", errInvalidMaxConcurrentStreams, maxConcurrentStreams)
+	}
+	c.MaxConcurrentStreams = uint32(maxConcurrentStreams)
+	if c.MaxRecvMsgSize, err = intSetting(flagset, maxRecvMsgSizeSetting, defaultMaxRecvMsgSize); err != nil {
+		return Server{}, err
+	}
+	if c.MaxSendMsgSize, err = intSetting(flagset, maxSendMsgSizeSetting, defaultMaxSendMsgSize); err != nil {
+		return Server{}, err
+	}
+	c.TLSCertFile = stringSetting(flagset, tlsCertFileSetting, defaultTLSCertFile)
+	c.TLSKeyFile = stringSetting(flagset, tlsKeyFileSetting, defaultTLSKeyFile)
+	c.TLSCAFile = stringSetting(flagset, tlsCAFileSetting, defaultTLSCAFile)
+	if c.TLSRefreshInterval, err = durationSetting(flagset, tlsRefreshIntervalSetting, defaultTLSRefreshInterval); err != nil {
+		return Server{}, err
+	}
+	if c.TLSExpiryWarningThreshold, err = durationSetting(flagset, tlsExpiryWarningSetting, defaultTLSExpiryWarning); err != nil {
+		return Server{}, err
+	}
+	c.CertificateSource = stringSetting(flagset, certificateSourceSetting, CertificateSourceFiles)
+	c.SPIFFEEndpointSocket = stringSetting(flagset, spiffeEndpointSocketSetting, "")
+	if c.EnableApplicationSource, err = boolSetting(flagset, enableApplicationSourceSetting, false); err != nil {
+		return Server{}, err
+	}
+	if c.EnableAdminWrites, err = boolSetting(flagset, enableAdminWritesSetting, false); err != nil {
+		return Server{}, err
+	}
+	if err := c.validate(); err != nil {
+		return Server{}, fmt.Errorf("invalid server configuration %+v: %w", c, err)
+	}
+	return c, nil
+}
+
+func (c Server) validate() error {
+	for _, port := range []int{c.ServingPort, c.HealthPort} {
+		if port < 1 || port > 65535 {
+			return fmt.Errorf("%w: %d", errInvalidPort, port)
+		}
+	}
+	if c.MetricsPort < 0 || c.MetricsPort > 65535 {
+		return fmt.Errorf("%w: %d", errInvalidMetricsPort, c.MetricsPort)
+	}
+	if c.ServingPort == c.HealthPort || c.ServingPort == c.MetricsPort || c.HealthPort == c.MetricsPort {
+		return errDuplicatePort
+	}
+	if c.GracefulShutdownTimeout < 0 {
+		return errNegativeGracefulShutdown
+	}
+	if c.DrainInterval < 0 {
+		return errNegativeDrainInterval
+	}
+	if c.KeepaliveTime <= 0 || c.KeepaliveTimeout <= 0 || c.KeepaliveMinTime <= 0 {
+		return errNonPositiveKeepalive
+	}
+	if c.MaxRecvMsgSize <= 0 || c.MaxSendMsgSize <= 0 {
+		return errNonPositiveMaxMsgSize
+	}
+	if c.TLSCertFile == "" || c.TLSKeyFile == "" || c.TLSCAFile == "" {
+		return errEmptyTLSFile
+	}
+	if c.TLSRefreshInterval <= 0 {
+		return errNonPositiveTLSRefreshInterval
+	}
+	if c.TLSExpiryWarningThreshold < 0 {
+		return errNegativeTLSExpiryWarning
+	}
+	switch c.CertificateSource {
+	case CertificateSourceFiles:
+	case CertificateSourceWorkloadAPI:
+		if c.SPIFFEEndpointSocket == "" {
+			return errNoSPIFFEEndpointSocket
+		}
+	default:
+		return fmt.Errorf("%w: %s", errUnknownCertificateSource, c.CertificateSource)
+	}
+	return nil
+}
+
+// lookupSetting returns the value of the flag if it was set, otherwise the value of the
+// environment variable if it exists. The source describes where the value came from, for errors.
+func lookupSetting(flagset *flag.FlagSet, setting serverSetting) (value string, source string, exists bool) {
+	if flagset != nil {
+		if f := flagset.Lookup(setting.flag); f != nil {
+			if v, ok := f.Value.(*settingValue); ok && v.set {
+				return v.value, "flag -" + setting.flag, true
+			}
+		}
+	}
+	if value, exists := os.LookupEnv(setting.envVar); exists {
+		return value, "environment variable " + setting.envVar, true
+	}
+	return "", "", false
+}
+
+func intSetting(flagset *flag.FlagSet, setting serverSetting, defaultValue int) (int, error) {
+	valueString, source, exists := lookupSetting(flagset, setting)
+	if !exists {
+		return defaultValue, nil
+	}
+	value, err := strconv.Atoi(valueString)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert %s value %s to integer: %w", source, valueString, err)
+	}
+	return value, nil
+}
+
+func durationSetting(flagset *flag.FlagSet, setting serverSetting, defaultValue time.Duration) (time.Duration, error) {
+	valueString, source, exists := lookupSetting(flagset, setting)
+	if !exists {
+		return defaultValue, nil
+	}
+	value, err := time.ParseDuration(valueString)
+	if err != nil {
+		return 0, fmt.Errorf("could not convert %s value %s to duration: %w", source, valueString, err)
+	}
+	return value, nil
+}
+
+func boolSetting(flagset *flag.FlagSet, setting serverSetting, defaultValue bool) (bool, error) {
+	valueString, source, exists := lookupSetting(flagset, setting)
+	if !exists {
+		return defaultValue, nil
+	}
+	value, err := strconv.ParseBool(valueString)
+	if err != nil {
+		return false, fmt.Errorf("could not convert %s value %s to boolean: %w", source, valueString, err)
+	}
+	return value, nil
+}
+
+func stringSetting(flagset *flag.FlagSet, setting serverSetting, defaultValue string) string {
+	if value, _, exists := lookupSetting(flagset, setting); exists {
+		return value
+	}
+	return defaultValue
+}
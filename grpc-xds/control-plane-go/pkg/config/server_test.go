@@ -0,0 +1,165 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"flag"
+	"strconv"
+	"testing"
+	"time"
+)
+
+func TestServerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		args    []string
+		env     map[string]string
+		wantErr error
+		// check verifies the configuration if there is no error.
+		check func(t *testing.T, c Server)
+	}{
+		{
+			name: "defaults",
+			check: func(t *testing.T, c Server) {
+				if c.ServingPort != defaultServingPort || c.HealthPort != defaultHealthPort || c.MetricsPort != defaultMetricsPort {
+					t.Errorf("ports = %d, %d, %d, want %d, %d, %d", c.ServingPort, c.HealthPort, c.MetricsPort, defaultServingPort, defaultHealthPort, defaultMetricsPort)
+				}
+				if c.KeepaliveTime != defaultKeepaliveTime || c.GracefulShutdownTimeout != defaultGracefulShutdownTimeout {
+					t.Errorf("keepalive time = %s, graceful shutdown timeout = %s, want %s, %s", c.KeepaliveTime, c.GracefulShutdownTimeout, defaultKeepaliveTime, defaultGracefulShutdownTimeout)
+				}
+			},
+		},
+		{
+			name: "environment variables",
+			env:  map[string]string{servingPortEnvVar: "8080", drainIntervalEnvVar: "10s"},
+			check: func(t *testing.T, c Server) {
+				if c.ServingPort != 8080 || c.DrainInterval != 10*time.Second {
+					t.Errorf("serving port = %d, drain interval = %s, want 8080, 10s", c.ServingPort, c.DrainInterval)
+				}
+			},
+		},
+		{
+			name: "flags override environment variables",
+			args: []string{"-port=9090", "-drain-interval=20s"},
+			env:  map[string]string{servingPortEnvVar: "8080", drainIntervalEnvVar: "10s"},
+			check: func(t *testing.T, c Server) {
+				if c.ServingPort != 9090 || c.DrainInterval != 20*time.Second {
+					t.Errorf("serving port = %d, drain interval = %s, want 9090, 20s", c.ServingPort, c.DrainInterval)
+				}
+			},
+		},
+		{
+			name: "metrics port 0 disables the metrics server",
+			args: []string{"-metrics-port=0"},
+			check: func(t *testing.T, c Server) {
+				if c.MetricsPort != 0 {
+					t.Errorf("metrics port = %d, want 0", c.MetricsPort)
+				}
+			},
+		},
+		{
+			name:    "serving port 0",
+			args:    []string{"-port=0"},
+			wantErr: errInvalidPort,
+		},
+		{
+			name:    "health port above 65535",
+			args:    []string{"-health-port=65536"},
+			wantErr: errInvalidPort,
+		},
+		{
+			name:    "negative metrics port",
+			args:    []string{"-metrics-port=-1"},
+			wantErr: errInvalidMetricsPort,
+		},
+		{
+			name:    "duplicate ports",
+			args:    []string{"-port=50051", "-metrics-port=50051"},
+			wantErr: errDuplicatePort,
+		},
+		{
+			name:    "port is not an integer",
+			args:    []string{"-port=http"},
+			wantErr: strconv.ErrSyntax,
+		},
+		{
+			name:    "negative graceful shutdown timeout",
+			args:    []string{"-graceful-shutdown-timeout=-1s"},
+			wantErr: errNegativeGracefulShutdown,
+		},
+		{
+			name:    "negative drain interval",
+			env:     map[string]string{drainIntervalEnvVar: "-1s"},
+			wantErr: errNegativeDrainInterval,
+		},
+		{
+			name:    "zero keepalive time",
+			args:    []string{"-keepalive-time=0s"},
+			wantErr: errNonPositiveKeepalive,
+		},
+		{
+			name:    "zero max concurrent streams",
+			args:    []string{"-max-concurrent-streams=0"},
+			wantErr: errInvalidMaxConcurrentStreams,
+		},
+		{
+			name:    "max concurrent streams above max uint32",
+			args:    []string{"-max-concurrent-streams=4294967296"},
+			wantErr: errInvalidMaxConcurrentStreams,
+		},
+		{
+			name:    "zero TLS refresh interval",
+			args:    []string{"-tls-refresh-interval=0s"},
+			wantErr: errNonPositiveTLSRefreshInterval,
+		},
+		{
+			name:    "negative TLS expiry warning threshold",
+			args:    []string{"-tls-expiry-warning-threshold=-1h"},
+			wantErr: errNegativeTLSExpiryWarning,
+		},
+		{
+			name:    "workload API certificate source without socket",
+			args:    []string{"-tls-certificate-source=" + CertificateSourceWorkloadAPI},
+			env:     map[string]string{spiffeEndpointSocketEnvVar: ""},
+			wantErr: errNoSPIFFEEndpointSocket,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			flagset := flag.NewFlagSet("test", flag.ContinueOnError)
+			InitServerFlags(flagset)
+			if err := flagset.Parse(test.args); err != nil {
+				t.Fatalf("could not parse flags %v: %v", test.args, err)
+			}
+			c, err := ServerConfig(flagset)
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("ServerConfig() unexpected error: %v", err)
+			}
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("ServerConfig() error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if test.check != nil {
+				test.check(t, c)
+			}
+		})
+	}
+}
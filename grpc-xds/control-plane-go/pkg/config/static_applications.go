@@ -0,0 +1,177 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+
+	"github.com/go-logr/logr"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+const (
+	fallbackApplicationsConfigFile = "fallback.yaml"
+)
+
+var (
+	errNoApplicationName      = errors.New("static application namespace and name cannot be blank")
+	errNoServingPort          = errors.New("static application servingPort must be set")
+	errUnknownEndpointStatus  = errors.New("static application endpoint status must be one of Healthy, Unhealthy, or Draining")
+	errNoApplicationEndpoints = errors.New("static application endpoints must have at least one address")
+	errUnknownAddressType     = errors.New("static application endpoint addressType must be one of IPv4, IPv6, or FQDN")
+)
+
+// StaticApplication is an application with a fixed list of endpoints, used instead of
+// Kubernetes EndpointSlices, e.g., to render xDS resources without a Kubernetes cluster.
+type StaticApplication struct {
+	// Context is the kubeconfig context name of the cluster of the application.
+	Context   string `yaml:"context"`
+	Namespace string `yaml:"namespace"`
+	Name      string `yaml:"name"`
+	// Authority is an optional xDS federation authority name of the application.
+	Authority string `yaml:"authority"`
+	// PathPrefix is an optional path prefix of the route to the application, e.g., `/helloworld.Greeter/`.
+	PathPrefix          string `yaml:"pathPrefix"`
+	ServingPort         uint32 `yaml:"servingPort"`
+	ServingProtocol     string `yaml:"servingProtocol"`
+	HealthCheckPort     uint32 `yaml:"healthCheckPort"`
+	HealthCheckProtocol string `yaml:"healthCheckProtocol"`
+	// HealthCheckGRPCService is an optional service name for gRPC health checks.
+	HealthCheckGRPCService string `yaml:"healthCheckGrpcService"`
+	// ServiceAccountName is an optional Kubernetes ServiceAccount name of the application Pods,
+	// used to verify the server identity when data plane TLS is enabled. Defaults to Name.
+	ServiceAccountName string `yaml:"serviceAccountName"`
+	// SubjectAltNames optionally replace the SPIFFE ID of the ServiceAccount as the accepted
+	// subject alternative names of the server certificates.
+	SubjectAltNames []tls.SubjectAltNameMatcher `yaml:"subjectAltNames"`
+	Endpoints       []StaticApplicationEndpoint `yaml:"endpoints"`
+}
+
+// StaticApplicationEndpoint is a group of endpoint addresses on a node.
+type StaticApplicationEndpoint struct {
+	// Pod is an optional Pod name, added to the endpoint metadata.
+	Pod       string   `yaml:"pod"`
+	Node      string   `yaml:"node"`
+	Zone      string   `yaml:"zone"`
+	Addresses []string `yaml:"addresses"`
+	// AddressType is one of `IPv4`, `IPv6`, or `FQDN`. Default is the type of the first address.
+	AddressType string `yaml:"addressType"`
+	// Status is one of `Healthy` (default), `Unhealthy`, or `Draining`.
+	Status string `yaml:"status"`
+	// Labels are used for subset load balancing, as if they were the labels of the Pod.
+	Labels map[string]string `yaml:"labels"`
+}
+
+// StaticApplications reads static applications from the YAML file at the provided path.
+func StaticApplications(logger logr.Logger, path string) ([]StaticApplication, error) {
+	logger.V(4).Info("Loading static applications", "filepath", path)
+	yamlBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read static applications from file %s: %w", path, err)
+	}
+	var apps []StaticApplication
+	err = yaml.Unmarshal(yamlBytes, &apps)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal static applications YAML file contents [%s]: %w", yamlBytes, err)
+	}
+	for _, app := range apps {
+		if _, err := app.Application(); err != nil {
+			return nil, fmt.Errorf("static applications validation failed: %w", err)
+		}
+	}
+	logger.V(2).Info("Static", "applications", apps)
+	return apps, nil
+}
+
+// FallbackApplications returns the applications from the `fallback.yaml` file in the config directory.
+// The control plane serves these applications when the informers have not found any applications,
+// e.g., when running on a laptop without access to a Kubernetes cluster. If the config file does not
+// exist, FallbackApplications returns nil.
+func FallbackApplications(logger logr.Logger) ([]applications.Application, error) {
+	fallbackApplicationsConfigFilePath := configFilePath(fallbackApplicationsConfigFile)
+	if _, err := os.Stat(fallbackApplicationsConfigFilePath); errors.Is(err, fs.ErrNotExist) {
+		logger.V(2).Info("No fallback applications config file", "filepath", fallbackApplicationsConfigFilePath)
+		return nil, nil
+	}
+	staticApps, err := StaticApplications(logger, fallbackApplicationsConfigFilePath)
+	if err != nil {
+		return nil, fmt.Errorf("could not load fallback applications: %w", err)
+	}
+	apps := make([]applications.Application, 0, len(staticApps))
+	for _, staticApp := range staticApps {
+		app, err := staticApp.Application()
+		if err != nil {
+			return nil, fmt.Errorf("could not convert fallback application %s/%s: %w", staticApp.Namespace, staticApp.Name, err)
+		}
+		apps = append(apps, app)
+	}
+	return apps, nil
+}
+
+// Application converts the static application to the representation used by the xDS resource snapshot builder.
+func (a StaticApplication) Application() (applications.Application, error) {
+	if a.Namespace == "" || a.Name == "" {
+		return applications.Application{}, fmt.Errorf("%w: application=%+v", errNoApplicationName, a)
+	}
+	if a.ServingPort == 0 {
+		return applications.Application{}, fmt.Errorf("%w: application=%s/%s", errNoServingPort, a.Namespace, a.Name)
+	}
+	for _, subjectAltName := range a.SubjectAltNames {
+		if err := subjectAltName.Validate(); err != nil {
+			return applications.Application{}, fmt.Errorf("invalid subjectAltNames in static application %s/%s: %w", a.Namespace, a.Name, err)
+		}
+	}
+	var endpoints []applications.ApplicationEndpoints
+	for _, endpoint := range a.Endpoints {
+		if len(endpoint.Addresses) == 0 {
+			return applications.Application{}, fmt.Errorf("%w: application=%s/%s node=%s", errNoApplicationEndpoints, a.Namespace, a.Name, endpoint.Node)
+		}
+		var status applications.EndpointStatus
+		switch endpoint.Status {
+		case "", applications.Healthy.String():
+			status = applications.Healthy
+		case applications.Unhealthy.String():
+			status = applications.Unhealthy
+		case applications.Draining.String():
+			status = applications.Draining
+		default:
+			return applications.Application{}, fmt.Errorf("%w: application=%s/%s status=%s", errUnknownEndpointStatus, a.Namespace, a.Name, endpoint.Status)
+		}
+		addressType := applications.AddressType(endpoint.AddressType)
+		switch addressType {
+		case "":
+			addressType = applications.AddressTypeOf(endpoint.Addresses[0])
+		case applications.AddressTypeIPv4, applications.AddressTypeIPv6, applications.AddressTypeFQDN:
+		default:
+			return applications.Application{}, fmt.Errorf("%w: application=%s/%s addressType=%s", errUnknownAddressType, a.Namespace, a.Name, endpoint.AddressType)
+		}
+		endpoints = append(endpoints, applications.NewApplicationEndpoints(endpoint.Pod, endpoint.Node, endpoint.Zone, a.Context, endpoint.Addresses, addressType, status, endpoint.Labels))
+	}
+	app := applications.NewApplication(a.Namespace, a.Name, a.ServingPort, a.ServingProtocol, a.HealthCheckPort, a.HealthCheckProtocol, endpoints)
+	app.Authority = a.Authority
+	app.HealthCheckGRPCService = a.HealthCheckGRPCService
+	app.PathPrefix = a.PathPrefix
+	if a.ServiceAccountName != "" {
+		app.ServiceAccountName = a.ServiceAccountName
+	}
+	app.SubjectAltNames = a.SubjectAltNames
+	return app, nil
+}
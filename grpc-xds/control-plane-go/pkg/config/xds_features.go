@@ -17,13 +17,18 @@ package config
 import (
 	"errors"
 	"fmt"
+	"net/netip"
 	"os"
-	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/go-logr/logr"
 	"gopkg.in/yaml.v3"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
 )
 
 const (
@@ -34,14 +39,28 @@ var (
 	errEBACRequiresDataPlaneMTLS         = errors.New("enableRbac=true requires enableDataPlaneTls=true and requireDataPlaneClientCerts=true")
 	errControlPlaneClientCertsRequireTLS = errors.New("requireControlPlaneClientCerts=true requires enableControlPlaneTls=true")
 	errDataPlaneClientCertsRequireTLS    = errors.New("requireDataPlaneClientCerts=true requires enableDataPlaneTls=true")
+	errRBACAuditOnlyRequiresRBAC         = errors.New("rbacAuditOnly=true requires enableRbac=true")
+	errClientAllowListRequiresClientCert = errors.New("allowedControlPlaneClient* lists require requireControlPlaneClientCerts=true")
+	errUnknownLocalityPriorityPolicy     = errors.New("localityPriorityPolicy must be one of zone, clusterAndZone, or nodeAndZone")
+	errUnknownXDSStreamMode              = errors.New("xdsStreamMode must be one of all, ads, or separate")
+	errUnknownLocalityWeightPolicy       = errors.New("localityWeightPolicy must be one of endpointCount, static, or loadReport, or empty")
+	errUnknownLoadBalancingPolicy        = errors.New("loadBalancingPolicy must be one of round_robin, pick_first, least_request, or weighted_round_robin")
+	errWeightedRoundRobinConflict        = errors.New("enableWeightedRoundRobin=true cannot be combined with loadBalancingPolicy")
+	errUnknownAccessLog                  = errors.New("accessLog must be one of stdout or grpc, or empty")
+	errInvalidEndpointWeightTransition   = fmt.Errorf("endpointWeightTransition must be 0 or at least %s", xds.MinEndpointWeightTransition)
+	errUnknownListenerIPFamily           = errors.New("listenerIpFamily must be one of IPv4, IPv6, or DualStack, or empty")
+	errInvalidPermissiveSourcePrefix     = errors.New("permissiveSourcePrefixes must be IP address ranges in CIDR notation")
+	errInvalidTrustDomainName            = errors.New("trust domain name cannot be blank or contain `/`")
+	errDuplicateTrustDomainName          = errors.New("trust domain name used more than once")
+	errPartialTrustBundles               = errors.New("either all or none of the trust domains must specify trustBundleFile")
+	errInvalidMaintenanceStatusCode      = errors.New("maintenanceApplications status codes must be gRPC status code names other than OK, or empty")
+	errInvalidResourceTTL                = fmt.Errorf("resourceTtl must be 0 or at least %s", xds.MinResourceTTL)
+	errResourceTTLRequiresPartial        = errors.New("resourceTtl cannot be combined with requireAllResourceNames=true")
+	errInvalidFeatureOverride            = errors.New("featureOverrides must specify features, and cannot override flags that require a restart or apply to all clients")
 )
 
 func XDSFeatures(logger logr.Logger) (*xds.Features, error) {
-	configDir, exists := os.LookupEnv("CONFIG_DIR")
-	if !exists {
-		configDir = defaultConfigDir
-	}
-	xdsFeaturesConfigFilePath := filepath.Join(configDir, xdsFeaturesConfigFile)
+	xdsFeaturesConfigFilePath := configFilePath(xdsFeaturesConfigFile)
 	logger.V(4).Info("Loading xDS feature flags", "filepath", xdsFeaturesConfigFilePath)
 	yamlBytes, err := os.ReadFile(xdsFeaturesConfigFilePath)
 	if err != nil {
@@ -59,6 +78,23 @@ func XDSFeatures(logger logr.Logger) (*xds.Features, error) {
 	return &xdsFeatures, err
 }
 
+// SetXDSFeatureFlag returns a copy of the xDS feature flags, with the flag of the provided name, as
+// in the xDS feature flags config file, set to the provided YAML value.
+func SetXDSFeatureFlag(xdsFeatures xds.Features, name string, yamlValue string) (*xds.Features, error) {
+	var value interface{}
+	if err := yaml.Unmarshal([]byte(yamlValue), &value); err != nil {
+		return nil, fmt.Errorf("could not unmarshal value [%s] of xDS feature flag %s: %w", yamlValue, name, err)
+	}
+	updatedFeatures, err := xdsFeatures.WithOverrides(map[string]interface{}{name: value})
+	if err != nil {
+		return nil, fmt.Errorf("could not set xDS feature flag %s to value [%s]: %w", name, yamlValue, err)
+	}
+	if err := validateXDSFeatureFlags(*updatedFeatures); err != nil {
+		return nil, fmt.Errorf("xDS feature flags validation failed: %w", err)
+	}
+	return updatedFeatures, nil
+}
+
 func validateXDSFeatureFlags(xdsFeatures xds.Features) error {
 	if xdsFeatures.RequireControlPlaneClientCerts && !xdsFeatures.EnableControlPlaneTLS {
 		return errControlPlaneClientCertsRequireTLS
@@ -69,5 +105,133 @@ func validateXDSFeatureFlags(xdsFeatures xds.Features) error {
 	if xdsFeatures.EnableRBAC && (!xdsFeatures.EnableDataPlaneTLS || !xdsFeatures.RequireDataPlaneClientCerts) {
 		return errEBACRequiresDataPlaneMTLS
 	}
+	if xdsFeatures.RBACAuditOnly && !xdsFeatures.EnableRBAC {
+		return errRBACAuditOnlyRequiresRBAC
+	}
+	if (len(xdsFeatures.AllowedControlPlaneClientSPIFFEIDs) > 0 ||
+		len(xdsFeatures.AllowedControlPlaneClientNamespaces) > 0 ||
+		len(xdsFeatures.AllowedControlPlaneClientServiceAccounts) > 0) &&
+		!xdsFeatures.RequireControlPlaneClientCerts {
+		return errClientAllowListRequiresClientCert
+	}
+	switch xdsFeatures.LocalityPriorityPolicy {
+	case "", xds.LocalityPriorityPolicyZone, xds.LocalityPriorityPolicyClusterAndZone, xds.LocalityPriorityPolicyNodeAndZone:
+	default:
+		return fmt.Errorf("%w: localityPriorityPolicy=%s", errUnknownLocalityPriorityPolicy, xdsFeatures.LocalityPriorityPolicy)
+	}
+	switch xdsFeatures.LocalityWeightPolicy {
+	case "", xds.LocalityWeightPolicyEndpointCount, xds.LocalityWeightPolicyStatic, xds.LocalityWeightPolicyLoadReport:
+	default:
+		return fmt.Errorf("%w: localityWeightPolicy=%s", errUnknownLocalityWeightPolicy, xdsFeatures.LocalityWeightPolicy)
+	}
+	switch xdsFeatures.XDSStreamMode {
+	case "", xds.XDSStreamModeAll, xds.XDSStreamModeADS, xds.XDSStreamModeSeparate:
+	default:
+		return fmt.Errorf("%w: xdsStreamMode=%s", errUnknownXDSStreamMode, xdsFeatures.XDSStreamMode)
+	}
+	switch xdsFeatures.LoadBalancingPolicy {
+	case "", cds.LoadBalancingPolicyRoundRobin, cds.LoadBalancingPolicyPickFirst, cds.LoadBalancingPolicyLeastRequest, cds.LoadBalancingPolicyWeightedRoundRobin:
+	default:
+		return fmt.Errorf("%w: loadBalancingPolicy=%s", errUnknownLoadBalancingPolicy, xdsFeatures.LoadBalancingPolicy)
+	}
+	if xdsFeatures.EnableWeightedRoundRobin && xdsFeatures.LoadBalancingPolicy != "" {
+		return fmt.Errorf("%w: loadBalancingPolicy=%s", errWeightedRoundRobinConflict, xdsFeatures.LoadBalancingPolicy)
+	}
+	switch xdsFeatures.AccessLog {
+	case "", lds.AccessLogStdout, lds.AccessLogGRPC:
+	default:
+		return fmt.Errorf("%w: accessLog=%s", errUnknownAccessLog, xdsFeatures.AccessLog)
+	}
+	if xdsFeatures.EndpointWeightTransition < 0 ||
+		(xdsFeatures.EndpointWeightTransition > 0 && xdsFeatures.EndpointWeightTransition < xds.MinEndpointWeightTransition) {
+		return fmt.Errorf("%w: endpointWeightTransition=%s", errInvalidEndpointWeightTransition, xdsFeatures.EndpointWeightTransition)
+	}
+	switch xdsFeatures.ListenerIPFamily {
+	case "", lds.IPFamilyIPv4, lds.IPFamilyIPv6, lds.IPFamilyDualStack:
+	default:
+		return fmt.Errorf("%w: listenerIpFamily=%s", errUnknownListenerIPFamily, xdsFeatures.ListenerIPFamily)
+	}
+	for _, prefix := range xdsFeatures.PermissiveSourcePrefixes {
+		if _, err := netip.ParsePrefix(prefix); err != nil {
+			return fmt.Errorf("%w: %w", errInvalidPermissiveSourcePrefix, err)
+		}
+	}
+	for appName, statusCodeName := range xdsFeatures.MaintenanceApplications {
+		if _, err := xds.MaintenanceStatusCode(statusCodeName); err != nil {
+			return fmt.Errorf("%w: application=%s: %w", errInvalidMaintenanceStatusCode, appName, err)
+		}
+	}
+	if xdsFeatures.ResourceTTL != 0 && xdsFeatures.ResourceTTL < xds.MinResourceTTL {
+		return fmt.Errorf("%w: resourceTtl=%s", errInvalidResourceTTL, xdsFeatures.ResourceTTL)
+	}
+	// Heartbeats for resources with a TTL discard the watches of requests that do not name all
+	// the resources of a type, if the cache requires all resource names.
+	if xdsFeatures.ResourceTTL > 0 && xdsFeatures.RequireAllResourceNames {
+		return errResourceTTLRequiresPartial
+	}
+	if err := validateFeatureOverrides(xdsFeatures); err != nil {
+		return err
+	}
+	return validateTrustDomains(xdsFeatures.TrustDomains)
+}
+
+// startupOnlyXDSFeatureFlags are the xDS feature flags that feature overrides cannot change,
+// because they configure the management server or the node hash function of the cache, or
+// state that all node hashes share, such as the endpoint weight transitions.
+var startupOnlyXDSFeatureFlags = []string{
+	"enableControlPlaneTls",
+	"requireControlPlaneClientCerts",
+	"allowedControlPlaneClientSpiffeIds",
+	"allowedControlPlaneClientNamespaces",
+	"allowedControlPlaneClientServiceAccounts",
+	"requireAllResourceNames",
+	"xdsStreamMode",
+	"localityPriorityPolicy",
+	"endpointWeightTransition",
+	"featureOverrides",
+}
+
+// validateFeatureOverrides checks that each feature override can be applied on its own, and
+// results in valid feature flags.
+func validateFeatureOverrides(xdsFeatures xds.Features) error {
+	for i, override := range xdsFeatures.FeatureOverrides {
+		if len(override.Features) == 0 {
+			return fmt.Errorf("%w: featureOverrides[%d] has no features", errInvalidFeatureOverride, i)
+		}
+		for name := range override.Features {
+			if slices.Contains(startupOnlyXDSFeatureFlags, name) {
+				return fmt.Errorf("%w: featureOverrides[%d] overrides %s", errInvalidFeatureOverride, i, name)
+			}
+		}
+		overridden, err := xdsFeatures.WithOverrides(override.Features)
+		if err != nil {
+			return fmt.Errorf("%w: featureOverrides[%d]: %w", errInvalidFeatureOverride, i, err)
+		}
+		overridden.FeatureOverrides = nil
+		if err := validateXDSFeatureFlags(*overridden); err != nil {
+			return fmt.Errorf("featureOverrides[%d] results in invalid xDS feature flags: %w", i, err)
+		}
+	}
+	return nil
+}
+
+func validateTrustDomains(trustDomains []tls.TrustDomain) error {
+	names := map[string]bool{}
+	trustBundles := 0
+	for _, trustDomain := range trustDomains {
+		if trustDomain.Name == "" || strings.Contains(trustDomain.Name, "/") {
+			return fmt.Errorf("%w: name=%s", errInvalidTrustDomainName, trustDomain.Name)
+		}
+		if names[trustDomain.Name] {
+			return fmt.Errorf("%w: name=%s", errDuplicateTrustDomainName, trustDomain.Name)
+		}
+		names[trustDomain.Name] = true
+		if trustDomain.TrustBundleFile != "" {
+			trustBundles++
+		}
+	}
+	if trustBundles > 0 && trustBundles < len(trustDomains) {
+		return errPartialTrustBundles
+	}
 	return nil
 }
@@ -0,0 +1,273 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package consul discovers applications from the service catalog of a HashiCorp Consul
+// cluster, using the Consul HTTP API, so that xDS clients can reach services outside
+// Kubernetes the same way as services discovered by the Kubernetes informers.
+package consul
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+)
+
+const (
+	// consulServiceName is the service that Consul servers register for themselves.
+	consulServiceName = "consul"
+	// indexHeader is the response header with the index for blocking queries, see
+	// https://developer.hashicorp.com/consul/api-docs/features/blocking
+	indexHeader = "X-Consul-Index"
+	// tokenHeader is the request header for the ACL token.
+	tokenHeader = "X-Consul-Token"
+	// zoneMetaKey is the node or service metadata key for the zone of the endpoints.
+	zoneMetaKey = "zone"
+	// protocolMetaKey is the service metadata key for the serving protocol, e.g., `grpc`.
+	protocolMetaKey = "protocol"
+	// retryInterval is how long to wait before retrying after a failed request.
+	retryInterval = 5 * time.Second
+)
+
+var errUnexpectedStatus = errors.New("unexpected HTTP response status from Consul")
+
+// Config is the configuration of the Consul catalog source.
+type Config struct {
+	// Name of the source, used as the cluster name of the endpoints.
+	Name string
+	// Address is the URL of the Consul HTTP API, e.g., `http://consul-server.consul:8500`.
+	Address string
+	// Token is an optional ACL token.
+	Token string
+	// Datacenter is optional. The default is the datacenter of the Consul agent.
+	Datacenter string
+	// Namespace is the namespace of the applications in the xDS resources, used by clients
+	// in the same way as the Kubernetes namespaces of applications from the informers.
+	Namespace string
+	// Tag is optional. If set, only services with this tag are discovered.
+	Tag string
+	// WaitTime is the maximum duration of each blocking query. Health check changes are
+	// discovered at the latest after this duration.
+	WaitTime time.Duration
+}
+
+// Source watches the Consul catalog, and passes an application for each service to the sink.
+type Source struct {
+	*discovery.Loop
+	config     Config
+	httpClient *http.Client
+}
+
+var _ discovery.Source = &Source{}
+
+// NewSource creates a source for the Consul catalog.
+func NewSource(config Config) *Source {
+	s := &Source{
+		config: config,
+		httpClient: &http.Client{
+			// Allow for the wait time of blocking queries, plus the jitter added by Consul.
+			Timeout: config.WaitTime + config.WaitTime/16 + 10*time.Second,
+		},
+	}
+	s.Loop = discovery.NewLoop(config.Name, s.Run)
+	return s
+}
+
+// Run watches the catalog using blocking queries until the context is done. After each change,
+// and at least every wait time, Run fetches the health of the instances of all services.
+func (s *Source) Run(ctx context.Context, logger logr.Logger, sink discovery.Sink) error {
+	logger = logger.WithValues("source", s.config.Name, "address", s.config.Address)
+	logger.V(1).Info("Watching the Consul catalog", "namespace", s.config.Namespace, "tag", s.config.Tag)
+	var index uint64
+	var previous []applications.Application
+	for {
+		services, nextIndex, err := s.catalogServices(ctx, index)
+		if err == nil {
+			var apps []applications.Application
+			apps, err = s.applications(ctx, logger, services)
+			if err == nil && !slices.EqualFunc(apps, previous, applications.Application.Equal) {
+				logger.V(2).Info("Consul catalog update", "apps", apps)
+				err = sink.UpdateResources(ctx, logger, s.config.Name, s.config.Namespace, apps)
+				previous = apps
+			}
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			logger.Error(err, "Could not discover applications from the Consul catalog, retrying", "retryInterval", retryInterval)
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(retryInterval):
+			}
+			continue
+		}
+		// Reset the index if it goes backwards, see https://developer.hashicorp.com/consul/api-docs/features/blocking#implementation-details
+		if nextIndex < index {
+			nextIndex = 0
+		}
+		index = nextIndex
+	}
+}
+
+// catalogServices returns the tags of each service in the catalog, and the index for the next blocking query.
+func (s *Source) catalogServices(ctx context.Context, index uint64) (map[string][]string, uint64, error) {
+	query := url.Values{}
+	if index > 0 {
+		query.Set("index", strconv.FormatUint(index, 10))
+		query.Set("wait", fmt.Sprintf("%ds", int(s.config.WaitTime.Seconds())))
+	}
+	var services map[string][]string
+	resp, err := s.get(ctx, "/v1/catalog/services", query, &services)
+	if err != nil {
+		return nil, 0, err
+	}
+	nextIndex, err := strconv.ParseUint(resp.Header.Get(indexHeader), 10, 64)
+	if err != nil {
+		return nil, 0, fmt.Errorf("could not parse %s header value %q: %w", indexHeader, resp.Header.Get(indexHeader), err)
+	}
+	return services, nextIndex, nil
+}
+
+// applications fetches the instances of each service with their health checks, and converts
+// them to applications. Services without instances are skipped.
+func (s *Source) applications(ctx context.Context, logger logr.Logger, services map[string][]string) ([]applications.Application, error) {
+	var apps []applications.Application
+	for name, tags := range services {
+		if name == consulServiceName || (s.config.Tag != "" && !slices.Contains(tags, s.config.Tag)) {
+			continue
+		}
+		query := url.Values{}
+		if s.config.Tag != "" {
+			query.Set("tag", s.config.Tag)
+		}
+		var entries []serviceEntry
+		if _, err := s.get(ctx, "/v1/health/service/"+url.PathEscape(name), query, &entries); err != nil {
+			return nil, err
+		}
+		if app, ok := s.application(logger, name, entries); ok {
+			apps = append(apps, app)
+		}
+	}
+	slices.SortFunc(apps, applications.Application.Compare)
+	return apps, nil
+}
+
+// application converts the instances of a service to an application. The serving port of the
+// application is the port of the first instance, and instances with other ports are skipped,
+// since all endpoints of an application share the same port.
+func (s *Source) application(logger logr.Logger, name string, entries []serviceEntry) (applications.Application, bool) {
+	if len(entries) == 0 {
+		return applications.Application{}, false
+	}
+	servingPort := entries[0].Service.Port
+	protocol := entries[0].Service.Meta[protocolMetaKey]
+	var endpoints []applications.ApplicationEndpoints
+	for _, entry := range entries {
+		if entry.Service.Port != servingPort {
+			logger.V(2).Info("Skipping Consul service instance with a different port", "service", name, "instance", entry.Service.ID, "port", entry.Service.Port, "servingPort", servingPort)
+			continue
+		}
+		address := entry.Service.Address
+		if address == "" {
+			address = entry.Node.Address
+		}
+		zone := entry.Service.Meta[zoneMetaKey]
+		if zone == "" {
+			zone = entry.Node.Meta[zoneMetaKey]
+		}
+		endpoints = append(endpoints, applications.NewApplicationEndpoints(
+			"", entry.Node.Node, zone, s.config.Name,
+			[]string{address}, applications.AddressTypeOf(address),
+			entry.endpointStatus(), entry.Service.Meta))
+	}
+	return applications.NewApplication(s.config.Namespace, name, uint32(servingPort), protocol, uint32(servingPort), protocol, endpoints), true
+}
+
+// get sends a GET request to the Consul HTTP API, and decodes the JSON response body into v.
+func (s *Source) get(ctx context.Context, path string, query url.Values, v interface{}) (*http.Response, error) {
+	if s.config.Datacenter != "" {
+		query.Set("dc", s.config.Datacenter)
+	}
+	requestURL := strings.TrimSuffix(s.config.Address, "/") + path
+	if len(query) > 0 {
+		requestURL += "?" + query.Encode()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, requestURL, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create Consul request for %s: %w", requestURL, err)
+	}
+	if s.config.Token != "" {
+		req.Header.Set(tokenHeader, s.config.Token)
+	}
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send Consul request for %s: %w", requestURL, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w: %s for %s", errUnexpectedStatus, resp.Status, requestURL)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, fmt.Errorf("could not decode Consul response for %s: %w", requestURL, err)
+	}
+	return resp, nil
+}
+
+// serviceEntry is an instance of a service, with its node and health checks, as returned by the
+// `/v1/health/service/:service` endpoint of the Consul HTTP API.
+type serviceEntry struct {
+	Node struct {
+		Node    string            `json:"Node"`
+		Address string            `json:"Address"`
+		Meta    map[string]string `json:"Meta"`
+	} `json:"Node"`
+	Service struct {
+		ID      string            `json:"ID"`
+		Address string            `json:"Address"`
+		Port    int               `json:"Port"`
+		Meta    map[string]string `json:"Meta"`
+	} `json:"Service"`
+	Checks []struct {
+		CheckID string `json:"CheckID"`
+		Status  string `json:"Status"`
+	} `json:"Checks"`
+}
+
+// endpointStatus returns `Draining` if the node or the service instance is in maintenance mode,
+// `Unhealthy` if any check is critical, and `Healthy` otherwise.
+func (e serviceEntry) endpointStatus() applications.EndpointStatus {
+	status := applications.Healthy
+	for _, check := range e.Checks {
+		if check.CheckID == "_node_maintenance" || strings.HasPrefix(check.CheckID, "_service_maintenance:") {
+			return applications.Draining
+		}
+		if check.Status == "critical" {
+			status = applications.Unhealthy
+		}
+	}
+	return status
+}
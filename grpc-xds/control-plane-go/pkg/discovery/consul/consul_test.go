@@ -0,0 +1,82 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package consul
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+func TestApplication(t *testing.T) {
+	entriesJSON := `[
+  {
+    "Node": {"Node": "node-a", "Address": "10.0.0.1", "Meta": {"zone": "us-central1-a"}},
+    "Service": {"ID": "greeter-1", "Address": "10.1.0.1", "Port": 50051, "Meta": {"protocol": "grpc"}},
+    "Checks": [{"CheckID": "serfHealth", "Status": "passing"}]
+  },
+  {
+    "Node": {"Node": "node-b", "Address": "10.0.0.2", "Meta": {"zone": "us-central1-b"}},
+    "Service": {"ID": "greeter-2", "Address": "", "Port": 50051, "Meta": {"zone": "us-central1-c"}},
+    "Checks": [{"CheckID": "service:greeter-2", "Status": "critical"}]
+  },
+  {
+    "Node": {"Node": "node-c", "Address": "10.0.0.3"},
+    "Service": {"ID": "greeter-3", "Address": "10.1.0.3", "Port": 50051},
+    "Checks": [{"CheckID": "_service_maintenance:greeter-3", "Status": "critical"}]
+  },
+  {
+    "Node": {"Node": "node-d", "Address": "10.0.0.4"},
+    "Service": {"ID": "greeter-4", "Address": "10.1.0.4", "Port": 8080}
+  }
+]`
+	var entries []serviceEntry
+	if err := json.Unmarshal([]byte(entriesJSON), &entries); err != nil {
+		t.Fatalf("could not unmarshal service entries: %v", err)
+	}
+	source := NewSource(Config{Name: "consul", Namespace: "hybrid"})
+	app, ok := source.application(logr.Discard(), "greeter", entries)
+	if !ok {
+		t.Fatalf("no application for service entries")
+	}
+	if app.Namespace != "hybrid" || app.Name != "greeter" || app.ServingPort != 50051 || app.ServingProtocol != "grpc" {
+		t.Errorf("application = %s/%s port=%d protocol=%s, want hybrid/greeter port=50051 protocol=grpc", app.Namespace, app.Name, app.ServingPort, app.ServingProtocol)
+	}
+	want := map[string]struct {
+		zone    string
+		address string
+		status  applications.EndpointStatus
+	}{
+		"node-a": {zone: "us-central1-a", address: "10.1.0.1", status: applications.Healthy},
+		"node-b": {zone: "us-central1-c", address: "10.0.0.2", status: applications.Unhealthy},
+		"node-c": {zone: "", address: "10.1.0.3", status: applications.Draining},
+	}
+	if len(app.Endpoints) != len(want) {
+		t.Fatalf("endpoints = %+v, want endpoints on nodes %v", app.Endpoints, want)
+	}
+	for _, endpoint := range app.Endpoints {
+		wantEndpoint, exists := want[endpoint.Node]
+		if !exists {
+			t.Errorf("unexpected endpoint on node %s", endpoint.Node)
+			continue
+		}
+		if endpoint.Zone != wantEndpoint.zone || endpoint.Addresses[0] != wantEndpoint.address || endpoint.EndpointStatus != wantEndpoint.status || endpoint.Cluster != "consul" {
+			t.Errorf("endpoint on node %s = %+v, want %+v", endpoint.Node, endpoint, wantEndpoint)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package discovery defines how discovery sources, such as the Kubernetes informers and the
+// Consul catalog watcher, are started and stopped, and how they pass the applications they
+// find to the xDS resource cache.
+package discovery
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+// Sink receives the applications and routes of discovery sources. The source name is the
+// kubecontext name for the Kubernetes informers. Sources must use distinct names, since the
+// applications of a source and namespace replace the previous applications of that source
+// and namespace.
+//
+// `xds.SnapshotCache` implements Sink.
+type Sink interface {
+	// UpdateResources replaces the applications of the source in the namespace.
+	UpdateResources(ctx context.Context, logger logr.Logger, source string, namespace string, apps []applications.Application) error
+	// UpdateRoutes replaces the routes of the source in the namespace.
+	UpdateRoutes(ctx context.Context, logger logr.Logger, source string, namespace string, routes []applications.Route) error
+	// DeleteNamespace removes the applications and routes of the source in the namespace.
+	DeleteNamespace(ctx context.Context, logger logr.Logger, source string, namespace string) error
+	// EvictKubecontext removes all applications and routes of the source.
+	EvictKubecontext(ctx context.Context, logger logr.Logger, source string) error
+}
+
+// Key identifies the applications of a source in a namespace.
+type Key struct {
+	Source    string
+	Namespace string
+}
+
+// Snapshot is the most recent applications of a source, keyed by source and namespace.
+type Snapshot map[Key][]applications.Application
+
+// Source discovers applications, e.g., from the EndpointSlices of a Kubernetes cluster, or
+// from a service catalog, and passes them to a sink. The informer manager of each kubecontext,
+// the Consul catalog watcher, and the Compute Engine managed instance group poller are sources.
+//
+// Sources can be tested without an xDS resource cache, by starting them with a `Recorder` sink,
+// and comparing their snapshots to the expected applications.
+type Source interface {
+	// Name is the source name used with the sink.
+	Name() string
+	// Start starts discovering applications, and returns without waiting for the first
+	// applications. The source stops when the context is done, or when Stop is called.
+	Start(ctx context.Context, logger logr.Logger, sink Sink) error
+	// Stop stops discovering applications. Stop does not remove the applications of the
+	// source from the sink.
+	Stop()
+	// Snapshot returns the applications that the source most recently passed to the sink.
+	Snapshot() Snapshot
+}
@@ -0,0 +1,280 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package gce discovers applications from Compute Engine managed instance groups (MIGs),
+// using the Compute Engine API, so that VM-based backends can participate in the same
+// xDS mesh as Pods discovered by the Kubernetes informers.
+package gce
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"golang.org/x/oauth2/google"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+)
+
+const (
+	computeEndpoint = "https://compute.googleapis.com/compute/v1/"
+	computeScope    = "https://www.googleapis.com/auth/compute.readonly"
+	// healthCheckNamedPort is the named port of the instance group used for health checks,
+	// following the same convention as the Kubernetes Service port names.
+	healthCheckNamedPort = "health"
+)
+
+var (
+	errUnexpectedStatus   = errors.New("unexpected HTTP response status from the Compute Engine API")
+	errNoNamedPort        = errors.New("instance group has no named port")
+	errInvalidGroupName   = errors.New("instance group must be a relative resource name, e.g., projects/PROJECT/zones/ZONE/instanceGroupManagers/NAME")
+	errNoNetworkInterface = errors.New("instance has no network interfaces")
+)
+
+// Config is the configuration of the managed instance group source.
+type Config struct {
+	// Name of the source, used as the cluster name of the endpoints.
+	Name string
+	// InstanceGroups are the relative resource names of the zonal or regional managed instance
+	// groups, e.g., `projects/my-project/zones/us-central1-a/instanceGroupManagers/greeter`.
+	// The application name is the name of the instance group.
+	InstanceGroups []string
+	// Namespace is the namespace of the applications in the xDS resources.
+	Namespace string
+	// NamedPort is the name of the named port of the instance groups for the serving port, e.g., `grpc`.
+	NamedPort string
+	// ServingProtocol is the protocol of the serving port, e.g., `grpc`.
+	ServingProtocol string
+	// PollInterval is how often to list the instances of the instance groups.
+	PollInterval time.Duration
+}
+
+// Source polls managed instance groups, and passes an application for each group to the sink.
+type Source struct {
+	*discovery.Loop
+	config Config
+	// endpoint is the base URL of the Compute Engine API, replaced in tests.
+	endpoint string
+}
+
+var _ discovery.Source = &Source{}
+
+// NewSource creates a source for Compute Engine managed instance groups.
+func NewSource(config Config) *Source {
+	s := &Source{
+		config:   config,
+		endpoint: computeEndpoint,
+	}
+	s.Loop = discovery.NewLoop(config.Name, s.Run)
+	return s
+}
+
+// Run lists the instances of the instance groups at the poll interval, until the context is done.
+// The source uses Application Default Credentials, e.g., the Kubernetes service account of the
+// control plane with Workload Identity Federation for GKE.
+func (s *Source) Run(ctx context.Context, logger logr.Logger, sink discovery.Sink) error {
+	logger = logger.WithValues("source", s.config.Name)
+	httpClient, err := google.DefaultClient(ctx, computeScope)
+	if err != nil {
+		return fmt.Errorf("could not create Compute Engine API client: %w", err)
+	}
+	logger.V(1).Info("Polling managed instance groups", "instanceGroups", s.config.InstanceGroups, "namespace", s.config.Namespace, "interval", s.config.PollInterval)
+	ticker := time.NewTicker(s.config.PollInterval)
+	defer ticker.Stop()
+	var previous []applications.Application
+	for {
+		apps, err := s.applications(ctx, httpClient)
+		if err != nil {
+			logger.Error(err, "Could not discover applications from managed instance groups")
+		} else if !slices.EqualFunc(apps, previous, applications.Application.Equal) {
+			logger.V(2).Info("Managed instance group update", "apps", apps)
+			if err := sink.UpdateResources(ctx, logger, s.config.Name, s.config.Namespace, apps); err != nil {
+				logger.Error(err, "Could not update the xDS resource cache with managed instance group applications", "apps", apps)
+			} else {
+				previous = apps
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+	}
+}
+
+// applications returns an application for each instance group, with an endpoint for each instance.
+func (s *Source) applications(ctx context.Context, httpClient *http.Client) ([]applications.Application, error) {
+	apps := make([]applications.Application, 0, len(s.config.InstanceGroups))
+	for _, instanceGroup := range s.config.InstanceGroups {
+		app, err := s.application(ctx, httpClient, instanceGroup)
+		if err != nil {
+			return nil, err
+		}
+		apps = append(apps, app)
+	}
+	slices.SortFunc(apps, applications.Application.Compare)
+	return apps, nil
+}
+
+func (s *Source) application(ctx context.Context, httpClient *http.Client, instanceGroup string) (applications.Application, error) {
+	segments := strings.Split(instanceGroup, "/")
+	if len(segments) != 6 || segments[0] != "projects" || (segments[2] != "zones" && segments[2] != "regions") || segments[4] != "instanceGroupManagers" {
+		return applications.Application{}, fmt.Errorf("%w: %s", errInvalidGroupName, instanceGroup)
+	}
+	var manager instanceGroupManager
+	if err := s.call(ctx, httpClient, http.MethodGet, s.endpoint+instanceGroup, &manager); err != nil {
+		return applications.Application{}, err
+	}
+	servingPort, exists := manager.namedPort(s.config.NamedPort)
+	if !exists {
+		return applications.Application{}, fmt.Errorf("%w: instanceGroup=%s namedPort=%s", errNoNamedPort, instanceGroup, s.config.NamedPort)
+	}
+	healthCheckPort, exists := manager.namedPort(healthCheckNamedPort)
+	if !exists {
+		healthCheckPort = servingPort
+	}
+	var endpoints []applications.ApplicationEndpoints
+	pageToken := ""
+	for {
+		listURL := s.endpoint + instanceGroup + "/listManagedInstances"
+		if pageToken != "" {
+			listURL += "?pageToken=" + pageToken
+		}
+		var list listManagedInstancesResponse
+		if err := s.call(ctx, httpClient, http.MethodPost, listURL, &list); err != nil {
+			return applications.Application{}, err
+		}
+		for _, managedInstance := range list.ManagedInstances {
+			if managedInstance.InstanceStatus != "RUNNING" {
+				continue
+			}
+			var instance instance
+			if err := s.call(ctx, httpClient, http.MethodGet, managedInstance.Instance, &instance); err != nil {
+				return applications.Application{}, err
+			}
+			if len(instance.NetworkInterfaces) == 0 {
+				return applications.Application{}, fmt.Errorf("%w: %s", errNoNetworkInterface, managedInstance.Instance)
+			}
+			address := instance.NetworkInterfaces[0].NetworkIP
+			endpoints = append(endpoints, applications.NewApplicationEndpoints(
+				"", instance.Name, path.Base(instance.Zone), s.config.Name,
+				[]string{address}, applications.AddressTypeOf(address),
+				managedInstance.endpointStatus(), instance.Labels))
+		}
+		if list.NextPageToken == "" {
+			break
+		}
+		pageToken = list.NextPageToken
+	}
+	return applications.NewApplication(s.config.Namespace, segments[5], servingPort, s.config.ServingProtocol, healthCheckPort, s.config.ServingProtocol, endpoints), nil
+}
+
+// call sends a request to the Compute Engine API, and decodes the JSON response body into v.
+func (s *Source) call(ctx context.Context, httpClient *http.Client, method string, url string, v interface{}) error {
+	var body io.Reader
+	if method == http.MethodPost {
+		body = bytes.NewReader([]byte("{}"))
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, body)
+	if err != nil {
+		return fmt.Errorf("could not create Compute Engine API request for %s: %w", url, err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send Compute Engine API request for %s: %w", url, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: %s for %s", errUnexpectedStatus, resp.Status, url)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return fmt.Errorf("could not decode Compute Engine API response for %s: %w", url, err)
+	}
+	return nil
+}
+
+// instanceGroupManager is the subset of the Compute Engine InstanceGroupManager resource used by the source.
+type instanceGroupManager struct {
+	NamedPorts []struct {
+		Name string `json:"name"`
+		Port uint32 `json:"port"`
+	} `json:"namedPorts"`
+}
+
+func (m instanceGroupManager) namedPort(name string) (uint32, bool) {
+	for _, namedPort := range m.NamedPorts {
+		if namedPort.Name == name {
+			return namedPort.Port, true
+		}
+	}
+	return 0, false
+}
+
+type listManagedInstancesResponse struct {
+	ManagedInstances []managedInstance `json:"managedInstances"`
+	NextPageToken    string            `json:"nextPageToken"`
+}
+
+type managedInstance struct {
+	// Instance is the URL of the instance.
+	Instance       string `json:"instance"`
+	InstanceStatus string `json:"instanceStatus"`
+	CurrentAction  string `json:"currentAction"`
+	InstanceHealth []struct {
+		DetailedHealthState string `json:"detailedHealthState"`
+	} `json:"instanceHealth"`
+}
+
+// endpointStatus returns `Draining` if the instance group is deleting or abandoning the instance,
+// `Unhealthy` if the instance group is changing the instance in another way, or if the
+// autohealing health check reports the instance as unhealthy, and `Healthy` otherwise.
+func (i managedInstance) endpointStatus() applications.EndpointStatus {
+	switch i.CurrentAction {
+	case "", "NONE":
+	case "DELETING", "ABANDONING":
+		return applications.Draining
+	default:
+		return applications.Unhealthy
+	}
+	for _, health := range i.InstanceHealth {
+		if health.DetailedHealthState != "HEALTHY" && health.DetailedHealthState != "" {
+			return applications.Unhealthy
+		}
+	}
+	return applications.Healthy
+}
+
+// instance is the subset of the Compute Engine Instance resource used by the source.
+type instance struct {
+	Name string `json:"name"`
+	// Zone is the URL of the zone of the instance.
+	Zone              string            `json:"zone"`
+	Labels            map[string]string `json:"labels"`
+	NetworkInterfaces []struct {
+		NetworkIP string `json:"networkIP"`
+	} `json:"networkInterfaces"`
+}
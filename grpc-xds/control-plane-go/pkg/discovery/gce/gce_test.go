@@ -0,0 +1,75 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package gce
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+func TestApplication(t *testing.T) {
+	const instanceGroup = "projects/my-project/regions/us-central1/instanceGroupManagers/greeter-vm"
+	mux := http.NewServeMux()
+	server := httptest.NewServer(mux)
+	defer server.Close()
+	mux.HandleFunc("GET /"+instanceGroup, func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprint(w, `{"namedPorts": [{"name": "grpc", "port": 50051}, {"name": "health", "port": 50052}]}`)
+	})
+	mux.HandleFunc("POST /"+instanceGroup+"/listManagedInstances", func(w http.ResponseWriter, _ *http.Request) {
+		fmt.Fprintf(w, `{"managedInstances": [
+  {"instance": "%[1]s/vm-a", "instanceStatus": "RUNNING", "currentAction": "NONE", "instanceHealth": [{"detailedHealthState": "HEALTHY"}]},
+  {"instance": "%[1]s/vm-b", "instanceStatus": "RUNNING", "currentAction": "DELETING"},
+  {"instance": "%[1]s/vm-c", "instanceStatus": "RUNNING", "currentAction": "NONE", "instanceHealth": [{"detailedHealthState": "UNHEALTHY"}]},
+  {"instance": "%[1]s/vm-d", "instanceStatus": "STAGING", "currentAction": "CREATING"}
+]}`, server.URL)
+	})
+	for i, name := range []string{"vm-a", "vm-b", "vm-c"} {
+		mux.HandleFunc("GET /"+name, func(w http.ResponseWriter, _ *http.Request) {
+			fmt.Fprintf(w, `{"name": "%s", "zone": "https://www.googleapis.com/compute/v1/projects/my-project/zones/us-central1-%c", "networkInterfaces": [{"networkIP": "10.128.0.%d"}]}`, name, 'a'+i, i+10)
+		})
+	}
+	source := NewSource(Config{Name: "gce", Namespace: "vms", NamedPort: "grpc", ServingProtocol: "grpc"})
+	source.endpoint = server.URL + "/"
+	app, err := source.application(context.Background(), server.Client(), instanceGroup)
+	if err != nil {
+		t.Fatalf("could not get application for instance group: %v", err)
+	}
+	if app.Namespace != "vms" || app.Name != "greeter-vm" || app.ServingPort != 50051 || app.HealthCheckPort != 50052 {
+		t.Errorf("application = %s/%s servingPort=%d healthCheckPort=%d, want vms/greeter-vm servingPort=50051 healthCheckPort=50052", app.Namespace, app.Name, app.ServingPort, app.HealthCheckPort)
+	}
+	want := []struct {
+		node    string
+		zone    string
+		address string
+		status  applications.EndpointStatus
+	}{
+		{node: "vm-a", zone: "us-central1-a", address: "10.128.0.10", status: applications.Healthy},
+		{node: "vm-b", zone: "us-central1-b", address: "10.128.0.11", status: applications.Draining},
+		{node: "vm-c", zone: "us-central1-c", address: "10.128.0.12", status: applications.Unhealthy},
+	}
+	if len(app.Endpoints) != len(want) {
+		t.Fatalf("endpoints = %+v, want %+v", app.Endpoints, want)
+	}
+	for i, endpoint := range app.Endpoints {
+		if endpoint.Node != want[i].node || endpoint.Zone != want[i].zone || endpoint.Addresses[0] != want[i].address || endpoint.EndpointStatus != want[i].status {
+			t.Errorf("endpoint %d = %+v, want %+v", i, endpoint, want[i])
+		}
+	}
+}
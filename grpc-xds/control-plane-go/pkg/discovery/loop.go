@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+var errAlreadyStarted = errors.New("discovery source already started")
+
+// RunFunc discovers applications and passes them to the sink until the context is done.
+type RunFunc func(ctx context.Context, logger logr.Logger, sink Sink) error
+
+// Loop implements `Source` for sources that discover applications in a blocking loop, such as
+// the Consul catalog watcher. Sources embed a Loop created with their run function.
+type Loop struct {
+	name string
+	run  RunFunc
+	// mu guards recorder, cancel, and done.
+	mu       sync.Mutex
+	recorder *Recorder
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewLoop creates a source that calls the run function in a goroutine when started.
+func NewLoop(name string, run RunFunc) *Loop {
+	return &Loop{
+		name:     name,
+		run:      run,
+		recorder: NewRecorder(nil),
+	}
+}
+
+func (l *Loop) Name() string {
+	return l.name
+}
+
+// Start runs the loop in a goroutine. Loops can be started again after they are stopped.
+func (l *Loop) Start(ctx context.Context, logger logr.Logger, sink Sink) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.cancel != nil {
+		return fmt.Errorf("%w: %s", errAlreadyStarted, l.name)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	recorder := NewRecorder(sink)
+	done := make(chan struct{})
+	l.recorder = recorder
+	l.cancel = cancel
+	l.done = done
+	go func() {
+		defer close(done)
+		if err := l.run(ctx, logger, recorder); err != nil && ctx.Err() == nil {
+			logger.Error(err, "Discovery source stopped", "source", l.name)
+		}
+	}()
+	return nil
+}
+
+// Stop cancels the loop, and waits for the run function to return.
+func (l *Loop) Stop() {
+	l.mu.Lock()
+	cancel, done := l.cancel, l.done
+	l.cancel = nil
+	l.done = nil
+	l.mu.Unlock()
+	if cancel == nil {
+		return
+	}
+	cancel()
+	<-done
+}
+
+func (l *Loop) Snapshot() Snapshot {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.recorder.Snapshot()
+}
@@ -0,0 +1,64 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+// TestLoop starts a loop source with a recording sink, and checks that the snapshots of the
+// source and the sink follow the updates of the run function, and that Stop waits for it to return.
+func TestLoop(t *testing.T) {
+	updated := make(chan struct{})
+	returned := false
+	loop := NewLoop("test", func(ctx context.Context, logger logr.Logger, sink Sink) error {
+		apps := []applications.Application{{Namespace: "ns", Name: "app-1"}}
+		if err := sink.UpdateResources(ctx, logger, "test", "ns", apps); err != nil {
+			return err
+		}
+		if err := sink.UpdateResources(ctx, logger, "test", "other", apps); err != nil {
+			return err
+		}
+		if err := sink.DeleteNamespace(ctx, logger, "test", "other"); err != nil {
+			return err
+		}
+		close(updated)
+		<-ctx.Done()
+		returned = true
+		return nil
+	})
+	sink := NewRecorder(nil)
+	if err := loop.Start(context.Background(), logr.Discard(), sink); err != nil {
+		t.Fatalf("could not start loop: %v", err)
+	}
+	if err := loop.Start(context.Background(), logr.Discard(), sink); err == nil {
+		t.Errorf("expected error when starting a running loop")
+	}
+	<-updated
+	for name, snapshot := range map[string]Snapshot{"source": loop.Snapshot(), "sink": sink.Snapshot()} {
+		if len(snapshot) != 1 || len(snapshot[Key{Source: "test", Namespace: "ns"}]) != 1 {
+			t.Errorf("%s snapshot = %+v, want one application in namespace ns", name, snapshot)
+		}
+	}
+	loop.Stop()
+	if !returned {
+		t.Errorf("Stop returned before the run function")
+	}
+}
@@ -0,0 +1,98 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package discovery
+
+import (
+	"context"
+	"slices"
+	"sync"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+// Recorder is a sink that records the applications passed to it, and forwards all calls to
+// another sink. Sources use a Recorder to implement `Source.Snapshot()`.
+type Recorder struct {
+	// sink receives the calls after they are recorded. Nil sinks only record, e.g., in tests.
+	sink Sink
+	// mu guards apps.
+	mu   sync.RWMutex
+	apps Snapshot
+}
+
+var _ Sink = &Recorder{}
+
+// NewRecorder creates a sink that records applications before forwarding them to the provided sink.
+// The provided sink can be nil.
+func NewRecorder(sink Sink) *Recorder {
+	return &Recorder{
+		sink: sink,
+		apps: Snapshot{},
+	}
+}
+
+func (r *Recorder) UpdateResources(ctx context.Context, logger logr.Logger, source string, namespace string, apps []applications.Application) error {
+	r.mu.Lock()
+	r.apps[Key{Source: source, Namespace: namespace}] = slices.Clone(apps)
+	r.mu.Unlock()
+	if r.sink == nil {
+		return nil
+	}
+	return r.sink.UpdateResources(ctx, logger, source, namespace, apps)
+}
+
+func (r *Recorder) UpdateRoutes(ctx context.Context, logger logr.Logger, source string, namespace string, routes []applications.Route) error {
+	if r.sink == nil {
+		return nil
+	}
+	return r.sink.UpdateRoutes(ctx, logger, source, namespace, routes)
+}
+
+func (r *Recorder) DeleteNamespace(ctx context.Context, logger logr.Logger, source string, namespace string) error {
+	r.mu.Lock()
+	delete(r.apps, Key{Source: source, Namespace: namespace})
+	r.mu.Unlock()
+	if r.sink == nil {
+		return nil
+	}
+	return r.sink.DeleteNamespace(ctx, logger, source, namespace)
+}
+
+func (r *Recorder) EvictKubecontext(ctx context.Context, logger logr.Logger, source string) error {
+	r.mu.Lock()
+	for key := range r.apps {
+		if key.Source == source {
+			delete(r.apps, key)
+		}
+	}
+	r.mu.Unlock()
+	if r.sink == nil {
+		return nil
+	}
+	return r.sink.EvictKubecontext(ctx, logger, source)
+}
+
+// Snapshot returns a copy of the recorded applications.
+func (r *Recorder) Snapshot() Snapshot {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	snapshot := make(Snapshot, len(r.apps))
+	for key, apps := range r.apps {
+		snapshot[key] = slices.Clone(apps)
+	}
+	return snapshot
+}
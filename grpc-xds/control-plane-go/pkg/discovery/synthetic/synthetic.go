@@ -0,0 +1,130 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package synthetic provides a discovery source that generates applications and endpoints,
+// to observe the behavior and resource consumption of the control plane at scale without
+// Kubernetes clusters or other service registries.
+package synthetic
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+)
+
+const (
+	servingPort     = 50051
+	healthCheckPort = 50052
+	protocol        = "grpc"
+	// MaxEndpoints is the maximum total number of endpoints of all applications. This is half
+	// the number of addresses in `10.0.0.0/8`, since each endpoint alternates between two addresses.
+	MaxEndpoints = 1 << 23
+)
+
+// Config is the configuration of the synthetic application generator.
+type Config struct {
+	// Name is the source name used with the sink.
+	Name string
+	// Namespace is the namespace of the applications.
+	Namespace string
+	// Applications is the number of applications, named `synthetic-0`, `synthetic-1`, and so on.
+	Applications int
+	// Endpoints is the number of endpoints of each application.
+	Endpoints int
+	// Zones are assigned to the endpoints of each application in turn.
+	Zones []string
+	// ChurnInterval is how often the endpoint addresses of one of the applications change,
+	// taking turns between the applications. A value of 0 disables the changes.
+	ChurnInterval time.Duration
+}
+
+// Source passes the synthetic applications to the sink, and changes the endpoints of one
+// application at each churn interval.
+type Source struct {
+	*discovery.Loop
+	config Config
+}
+
+var _ discovery.Source = &Source{}
+
+// NewSource creates a synthetic application generator.
+func NewSource(config Config) *Source {
+	s := &Source{
+		config: config,
+	}
+	s.Loop = discovery.NewLoop(config.Name, s.Run)
+	return s
+}
+
+// Run passes the applications to the sink, and then changes the endpoints of one application
+// at each churn interval, until the context is done.
+func (s *Source) Run(ctx context.Context, logger logr.Logger, sink discovery.Sink) error {
+	logger = logger.WithValues("source", s.config.Name)
+	logger.V(1).Info("Generating synthetic applications", "namespace", s.config.Namespace, "applications", s.config.Applications, "endpoints", s.config.Endpoints, "churnInterval", s.config.ChurnInterval)
+	// versions counts the endpoint changes of each application.
+	versions := make([]int, s.config.Applications)
+	if err := sink.UpdateResources(ctx, logger, s.config.Name, s.config.Namespace, s.applications(versions)); err != nil {
+		return fmt.Errorf("could not pass synthetic applications to the sink: %w", err)
+	}
+	if s.config.ChurnInterval <= 0 || s.config.Applications == 0 {
+		<-ctx.Done()
+		return nil
+	}
+	ticker := time.NewTicker(s.config.ChurnInterval)
+	defer ticker.Stop()
+	for churn := 0; ; churn++ {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+		}
+		versions[churn%len(versions)]++
+		logger.V(2).Info("Changing endpoints of synthetic application", "app", appName(churn%len(versions)))
+		if err := sink.UpdateResources(ctx, logger, s.config.Name, s.config.Namespace, s.applications(versions)); err != nil {
+			logger.Error(err, "Could not pass synthetic applications to the sink")
+		}
+	}
+}
+
+// applications generates the applications. The endpoint addresses are unique within the
+// `10.0.0.0/8` range for up to `MaxEndpoints` endpoints. Each endpoint alternates between
+// two addresses when the version of the application changes, so the addresses never wrap
+// around into the addresses of other endpoints.
+func (s *Source) applications(versions []int) []applications.Application {
+	total := s.config.Applications * s.config.Endpoints
+	apps := make([]applications.Application, s.config.Applications)
+	for i := range apps {
+		endpoints := make([]applications.ApplicationEndpoints, s.config.Endpoints)
+		for j := range endpoints {
+			index := (versions[i]%2)*total + i*s.config.Endpoints + j
+			address := fmt.Sprintf("10.%d.%d.%d", (index>>16)&0xff, (index>>8)&0xff, index&0xff)
+			zone := ""
+			if len(s.config.Zones) > 0 {
+				zone = s.config.Zones[j%len(s.config.Zones)]
+			}
+			endpoints[j] = applications.NewApplicationEndpoints("", fmt.Sprintf("synthetic-node-%d", j), zone, "", []string{address}, applications.AddressTypeIPv4, applications.Healthy, nil)
+		}
+		apps[i] = applications.NewApplication(s.config.Namespace, appName(i), servingPort, protocol, healthCheckPort, protocol, endpoints)
+	}
+	return apps
+}
+
+func appName(i int) string {
+	return fmt.Sprintf("synthetic-%d", i)
+}
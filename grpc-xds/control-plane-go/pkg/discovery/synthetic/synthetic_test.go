@@ -0,0 +1,66 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package synthetic
+
+import (
+	"testing"
+)
+
+func TestApplications(t *testing.T) {
+	source := NewSource(Config{Name: "synthetic", Namespace: "synthetic", Applications: 3, Endpoints: 4, Zones: []string{"us-central1-a", "us-central1-b"}})
+	versions := []int{0, 0, 0}
+	before := source.applications(versions)
+	versions[1]++
+	after := source.applications(versions)
+
+	addresses := map[string]bool{}
+	for i, app := range before {
+		if len(app.Endpoints) != 4 {
+			t.Fatalf("app %s has %d endpoints, want 4", app.Name, len(app.Endpoints))
+		}
+		for _, endpoint := range app.Endpoints {
+			if addresses[endpoint.Addresses[0]] {
+				t.Errorf("duplicate address %s", endpoint.Addresses[0])
+			}
+			addresses[endpoint.Addresses[0]] = true
+		}
+		if changed := !app.Equal(after[i]); changed != (i == 1) {
+			t.Errorf("app %s changed=%t after changing the version of app %s", app.Name, changed, appName(1))
+		}
+	}
+	for _, endpoint := range after[1].Endpoints {
+		if addresses[endpoint.Addresses[0]] {
+			t.Errorf("changed endpoint address %s is in use by another endpoint", endpoint.Addresses[0])
+		}
+	}
+}
+
+func TestApplicationsAddressesAlternate(t *testing.T) {
+	source := NewSource(Config{Name: "synthetic", Namespace: "synthetic", Applications: 2, Endpoints: 3})
+	initial := source.applications([]int{0, 0})
+	for version := 1; version <= 4; version++ {
+		changed := source.applications([]int{version, 0})
+		if got, want := changed[0].Equal(initial[0]), version%2 == 0; got != want {
+			t.Errorf("version=%d: app %s unchanged=%t, want %t", version, initial[0].Name, got, want)
+		}
+		for _, endpoint := range changed[0].Endpoints {
+			for _, other := range initial[1].Endpoints {
+				if endpoint.Addresses[0] == other.Addresses[0] {
+					t.Errorf("version=%d: address %s of app %s is in use by app %s", version, endpoint.Addresses[0], changed[0].Name, initial[1].Name)
+				}
+			}
+		}
+	}
+}
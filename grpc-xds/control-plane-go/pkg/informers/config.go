@@ -14,15 +14,56 @@
 
 package informers
 
+import (
+	"time"
+)
+
+// AllServicesWildcard in the list of services of a `Config` selects all services in the namespace.
+const AllServicesWildcard = "*"
+
 // Config represents a collection of Kubernetes services in a namespace.
+//
+// If Services is empty, or contains only AllServicesWildcard (`*`), the informer watches
+// the EndpointSlices of all services in the namespace. If LabelSelector is set,
+// the informer only watches the EndpointSlices of services with matching labels,
+// e.g., `xds-discovery=enabled`. The EndpointSlice controller copies the labels
+// of services to their EndpointSlices.
+//
+// If XDSApplications is true, the applications are declared by XDSApplication
+// custom resources in the namespace, instead of by the list of services.
+// If GRPCRoutes is true, Kubernetes Gateway API GRPCRoute resources in the
+// namespace provide routing rules for the applications.
+//
+// SubsetLabels are Pod label keys, e.g., `version`. The informer copies the values
+// of these labels from the Pods to the endpoint metadata, for subset load balancing.
+//
+// Authority is the xDS federation authority name of the applications in the namespace,
+// e.g., `team-a.xds.example.com`. With xDS federation enabled, the control plane creates
+// the `xdstp://` resources of these applications with this authority name, instead of the
+// authority name of the control plane. Clients must map the authority name to this control
+// plane in the `authorities` section of their bootstrap configuration.
 type Config struct {
-	Namespace string   `yaml:"namespace"`
-	Services  []string `yaml:"services"`
+	Namespace       string   `yaml:"namespace"`
+	Services        []string `yaml:"services"`
+	LabelSelector   string   `yaml:"labelSelector"`
+	XDSApplications bool     `yaml:"xdsApplications"`
+	GRPCRoutes      bool     `yaml:"grpcRoutes"`
+	SubsetLabels    []string `yaml:"subsetLabels"`
+	Authority       string   `yaml:"authority"`
 }
 
 // Kubecontext represents a kubeconfig context,
 // containing a list of `informer.Config`s.
+//
+// StalenessTTL overrides the default staleness TTL of `HealthConfig` for this kubecontext.
 type Kubecontext struct {
-	Context   string   `yaml:"context"`
-	Informers []Config `yaml:"informers"`
+	Context      string         `yaml:"context"`
+	Informers    []Config       `yaml:"informers"`
+	StalenessTTL *time.Duration `yaml:"stalenessTtl"`
+}
+
+// AllServices returns true if the config selects the services in the namespace
+// without a list of service names.
+func (c Config) AllServices() bool {
+	return len(c.Services) == 0 || (len(c.Services) == 1 && c.Services[0] == AllServicesWildcard)
 }
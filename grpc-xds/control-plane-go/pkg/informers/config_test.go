@@ -0,0 +1,56 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"testing"
+)
+
+func TestConfigAllServices(t *testing.T) {
+	tests := []struct {
+		name     string
+		services []string
+		want     bool
+	}{
+		{
+			name:     "no services",
+			services: nil,
+			want:     true,
+		},
+		{
+			name:     "wildcard",
+			services: []string{AllServicesWildcard},
+			want:     true,
+		},
+		{
+			name:     "service names",
+			services: []string{"greeter-intermediary", "greeter-leaf"},
+			want:     false,
+		},
+		{
+			name:     "wildcard with service names",
+			services: []string{AllServicesWildcard, "greeter-leaf"},
+			want:     false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := Config{Namespace: "xds", Services: test.services}
+			if got := config.AllServices(); got != test.want {
+				t.Errorf("AllServices() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
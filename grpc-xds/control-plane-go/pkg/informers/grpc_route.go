@@ -0,0 +1,161 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	informercache "k8s.io/client-go/tools/cache"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/gateway"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+// AddGRPCRouteInformer watches Kubernetes Gateway API GRPCRoute resources in the namespace,
+// and updates the routing rules in the xDS resource cache.
+func (m *Manager) AddGRPCRouteInformer(ctx context.Context, logger logr.Logger, config Config) error {
+	ctx, err := m.informerContext(ctx)
+	if err != nil {
+		return err
+	}
+	logger = logger.WithValues("kubecontext", m.kubecontext, "namespace", config.Namespace)
+	logger.V(2).Info("Creating informer for GRPCRoutes")
+
+	stop := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		logger.V(1).Info("Stopping informer for GRPCRoutes")
+		close(stop)
+	}()
+
+	indexers := informercache.Indexers{informercache.NamespaceIndex: informercache.MetaNamespaceIndexFunc}
+	informer := dynamicinformer.NewFilteredDynamicInformer(m.dynamicClient, gateway.GRPCRouteGVR, config.Namespace, 0, indexers, nil).Informer()
+
+	handleEvent := func(event string) {
+		logger := logger.WithValues("event", event)
+		routes := getRoutesForInformer(logger, informer)
+		if ctx.Err() != nil {
+			logger.V(2).Info("Ignoring resource update from stopped informer", "routes", routes)
+			return
+		}
+		logger.V(2).Info("Informer resource update", "routes", routes)
+		if err := m.updates.updateRoutes(ctx, logger, config.Namespace, routes); err != nil {
+			logger.Error(err, "Could not update the xDS resource cache with routes", "routes", routes)
+		}
+	}
+	_, err = informer.AddEventHandler(informercache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) {
+			handleEvent("add")
+		},
+		UpdateFunc: func(_, _ interface{}) {
+			handleEvent("update")
+		},
+		DeleteFunc: func(_ interface{}) {
+			handleEvent("delete")
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not add GRPCRoute informer event handler for kubecontext=%s namespace=%s: %w", m.kubecontext, config.Namespace, err)
+	}
+	m.addRefresher(ctx, func() {
+		handleEvent("refresh")
+	})
+	go func() {
+		logger.V(2).Info("Starting GRPCRoute informer")
+		informer.Run(stop)
+	}()
+	return nil
+}
+
+func getRoutesForInformer(logger logr.Logger, informer informercache.SharedIndexInformer) []applications.Route {
+	var routes []applications.Route
+	for _, obj := range informer.GetIndexer().List() {
+		grpcRoute, err := toGRPCRoute(obj)
+		if err != nil {
+			logger.Error(err, "Skipping GRPCRoute")
+			continue
+		}
+		routes = append(routes, newRoute(grpcRoute))
+	}
+	return routes
+}
+
+func toGRPCRoute(obj interface{}) (*gateway.GRPCRoute, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected *unstructured.Unstructured, got %T", errUnexpectedType, obj)
+	}
+	var grpcRoute gateway.GRPCRoute
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &grpcRoute); err != nil {
+		return nil, fmt.Errorf("could not convert unstructured object %s/%s to GRPCRoute: %w", u.GetNamespace(), u.GetName(), err)
+	}
+	return &grpcRoute, nil
+}
+
+// newRoute translates a GRPCRoute to routing rules, using the Gateway API defaults
+// of `Exact` match types, backend weights of 1, and backends in the Namespace of the GRPCRoute.
+func newRoute(grpcRoute *gateway.GRPCRoute) applications.Route {
+	route := applications.Route{
+		Namespace: grpcRoute.Namespace,
+		Name:      grpcRoute.Name,
+		Hostnames: grpcRoute.Spec.Hostnames,
+	}
+	for _, grpcRouteRule := range grpcRoute.Spec.Rules {
+		var rule applications.RouteRule
+		for _, grpcRouteMatch := range grpcRouteRule.Matches {
+			var match applications.RouteMatch
+			if grpcRouteMatch.Method != nil {
+				match.Service = grpcRouteMatch.Method.Service
+				match.Method = grpcRouteMatch.Method.Method
+				match.RegularExpression = grpcRouteMatch.Method.Type == gateway.MatchTypeRegularExpression
+			}
+			for _, header := range grpcRouteMatch.Headers {
+				match.Headers = append(match.Headers, applications.HeaderMatch{
+					Name:              header.Name,
+					Value:             header.Value,
+					RegularExpression: header.Type == gateway.MatchTypeRegularExpression,
+				})
+			}
+			rule.Matches = append(rule.Matches, match)
+		}
+		for _, backendRef := range grpcRouteRule.BackendRefs {
+			weight := uint32(1)
+			if backendRef.Weight != nil && *backendRef.Weight >= 0 {
+				weight = uint32(*backendRef.Weight)
+			}
+			namespace := grpcRoute.Namespace
+			if backendRef.Namespace != nil && *backendRef.Namespace != "" {
+				namespace = *backendRef.Namespace
+			}
+			var port uint32
+			if backendRef.Port != nil && *backendRef.Port > 0 {
+				port = uint32(*backendRef.Port)
+			}
+			rule.Backends = append(rule.Backends, applications.RouteBackend{
+				Name:      backendRef.Name,
+				Namespace: namespace,
+				Port:      port,
+				Weight:    weight,
+			})
+		}
+		route.Rules = append(route.Rules, rule)
+	}
+	return route
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+)
+
+// HealthConfig configures health checking of the Kubernetes API servers of kubecontexts.
+type HealthConfig struct {
+	// Interval between health checks.
+	Interval time.Duration
+	// StalenessTTL is how long a kubecontext can be unreachable before its applications
+	// and routes are evicted from the xDS resource cache. A value of 0 disables eviction.
+	// Kubecontexts can override this value in the informer configuration.
+	StalenessTTL time.Duration
+}
+
+// refresher pushes the current state of an informer to the xDS resource cache.
+type refresher struct {
+	ctx     context.Context
+	refresh func()
+}
+
+// addRefresher registers a function that pushes the current state of an informer
+// to the xDS resource cache, after the kubecontext recovers from an eviction.
+// The function is discarded when the informer context is done.
+func (m *Manager) addRefresher(ctx context.Context, refresh func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.refreshers = append(m.refreshers, refresher{ctx: ctx, refresh: refresh})
+}
+
+// MonitorHealth checks the readiness endpoint of the Kubernetes API server at the configured interval,
+// until the context is done.
+//
+// If the API server is unreachable for longer than the staleness TTL, the applications and routes
+// of the kubecontext are evicted from the xDS resource cache, so that clients stop sending requests
+// to endpoints that may no longer exist. When the API server is reachable again, the informers
+// push their current state to the xDS resource cache.
+func (m *Manager) MonitorHealth(ctx context.Context, logger logr.Logger, interval time.Duration, stalenessTTL time.Duration) {
+	logger = logger.WithValues("kubecontext", m.kubecontext)
+	if interval <= 0 {
+		logger.V(2).Info("Kubecontext health checking disabled")
+		return
+	}
+	logger.V(2).Info("Starting kubecontext health checking", "interval", interval, "stalenessTTL", stalenessTTL)
+	lastHealthy := time.Now()
+	evicted := false
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			logger.V(2).Info("Stopping kubecontext health checking")
+			return
+		case <-ticker.C:
+			err := m.checkHealth(ctx, interval)
+			if ctx.Err() != nil {
+				return
+			}
+			if err == nil {
+				lastHealthy = time.Now()
+				if evicted {
+					logger.Info("Kubernetes API server is reachable again, restoring applications and routes")
+					evicted = false
+					m.refresh()
+				}
+				continue
+			}
+			unhealthyFor := time.Since(lastHealthy)
+			logger.Error(err, "Kubernetes API server health check failed", "unhealthyFor", unhealthyFor.Round(time.Second))
+			if !evicted && stalenessTTL > 0 && unhealthyFor > stalenessTTL {
+				logger.Info("Evicting applications and routes of unreachable kubecontext", "stalenessTTL", stalenessTTL)
+				if err := m.updates.evictKubecontext(ctx, logger); err != nil {
+					logger.Error(err, "Could not evict applications and routes of unreachable kubecontext")
+					continue
+				}
+				evicted = true
+			}
+		}
+	}
+}
+
+// checkHealth calls the `/readyz` endpoint of the Kubernetes API server.
+// All clients, including unauthenticated clients, are allowed to call this endpoint.
+func (m *Manager) checkHealth(ctx context.Context, timeout time.Duration) error {
+	return m.clientset.Discovery().RESTClient().Get().AbsPath("/readyz").Timeout(timeout).Do(ctx).Error()
+}
+
+// refresh pushes the current state of all running informers to the xDS resource cache.
+func (m *Manager) refresh() {
+	m.mu.Lock()
+	var refreshers []refresher
+	for _, r := range m.refreshers {
+		if r.ctx.Err() == nil {
+			refreshers = append(refreshers, r)
+		}
+	}
+	m.refreshers = refreshers
+	m.mu.Unlock()
+	for _, r := range refreshers {
+		r.refresh()
+	}
+}
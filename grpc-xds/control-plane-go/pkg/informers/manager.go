@@ -19,28 +19,35 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"slices"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/go-logr/logr"
 	discoveryv1 "k8s.io/api/discovery/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/informers"
 	discoveryinformers "k8s.io/client-go/informers/discovery/v1"
 	"k8s.io/client-go/kubernetes"
 	informercache "k8s.io/client-go/tools/cache"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
-	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
 )
 
 var (
+	errManagerAlreadyStarted  = errors.New("informer manager already started")
+	errManagerNotStarted      = errors.New("informer manager not started")
 	errMissingLabel           = errors.New("missing service label")
 	errMissingMetadata        = errors.New("missing metadata")
 	errNilEndpointSlice       = errors.New("nil EndpointSlice")
 	errNoPortsInEndpointSlice = errors.New("no ports in EndpointSlice")
 	errUnexpectedType         = errors.New("unexpected type")
 	// Kubernetes Service ports with one of these names will be considered health check ports (case-sensitive match). This is a port naming convention invented in this sample xDS control plane implementation.
+	// The `HealthCheckPortAnnotation` on the Service takes precedence.
 	healthCheckPortNames = map[string]bool{
 		"health":      true,
 		"healthz":     true,
@@ -49,33 +56,117 @@ var (
 	}
 )
 
-// Manager manages a collection of informers.
+// Manager manages a collection of informers for one kubecontext. Manager is the discovery source
+// of the kubecontext, and the source name is the kubecontext name.
+//
+// Informers can only be added after the manager is started, and they stop when the manager stops.
 type Manager struct {
 	kubecontext string
-	clientset   *kubernetes.Clientset
-	xdsCache    *xds.SnapshotCache
+	clientset   kubernetes.Interface
+	// dynamicClient reads custom resources, such as XDSApplications.
+	dynamicClient dynamic.Interface
+	// xdsCache is the recorder for the sink provided to `Start()`.
+	xdsCache *discovery.Recorder
+	// updates passes application updates from the informers to xdsCache.
+	updates *updateQueue
+	// includeTerminating includes terminating endpoints that are still serving as draining
+	// endpoints, see `SetIncludeTerminatingEndpoints()`.
+	includeTerminating atomic.Bool
+	// mu guards ctx, cancel, xdsCache, updates, and refreshers.
+	mu         sync.Mutex
+	ctx        context.Context
+	cancel     context.CancelFunc
+	refreshers []refresher
 }
 
+var _ discovery.Source = &Manager{}
+
 // NewManager creates an instance that manages a collection of informers
 // for one kubecontext.
-func NewManager(ctx context.Context, kubecontextName string, xdsCache *xds.SnapshotCache) (*Manager, error) {
+func NewManager(ctx context.Context, kubecontextName string) (*Manager, error) {
 	clientset, err := NewClientSet(ctx, kubecontextName)
 	if err != nil {
 		return nil, err
 	}
+	dynamicClient, err := NewDynamicClient(ctx, kubecontextName)
+	if err != nil {
+		return nil, err
+	}
+	return NewManagerForClients(kubecontextName, clientset, dynamicClient), nil
+}
+
+// NewManagerForClients creates an instance that manages a collection of informers
+// for one kubecontext, using the provided clients, e.g., fake clients in tests.
+func NewManagerForClients(kubecontextName string, clientset kubernetes.Interface, dynamicClient dynamic.Interface) *Manager {
 	return &Manager{
-		kubecontext: kubecontextName,
-		clientset:   clientset,
-		xdsCache:    xdsCache,
-	}, nil
+		kubecontext:   kubecontextName,
+		clientset:     clientset,
+		dynamicClient: dynamicClient,
+	}
+}
+
+func (m *Manager) Name() string {
+	return m.kubecontext
+}
+
+// Start records the sink for the informers, and starts an informer for namespace deletions.
+func (m *Manager) Start(ctx context.Context, logger logr.Logger, sink discovery.Sink) error {
+	m.mu.Lock()
+	if m.cancel != nil {
+		m.mu.Unlock()
+		return fmt.Errorf("%w: kubecontext=%s", errManagerAlreadyStarted, m.kubecontext)
+	}
+	m.ctx, m.cancel = context.WithCancel(ctx)
+	m.xdsCache = discovery.NewRecorder(sink)
+	m.updates = newUpdateQueue(m.ctx, logger, m.kubecontext, m.xdsCache)
+	m.mu.Unlock()
+	if err := m.addNamespaceInformer(ctx, logger); err != nil {
+		m.Stop()
+		return err
+	}
+	return nil
+}
+
+// Stop stops all informers of the manager.
+func (m *Manager) Stop() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.cancel != nil {
+		m.cancel()
+	}
+}
+
+// Snapshot returns the applications that the informers most recently passed to the sink.
+func (m *Manager) Snapshot() discovery.Snapshot {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.xdsCache == nil {
+		return discovery.Snapshot{}
+	}
+	return m.xdsCache.Snapshot()
+}
+
+// informerContext returns a context for an informer that is done when the provided context
+// is done, or when the manager stops.
+func (m *Manager) informerContext(ctx context.Context) (context.Context, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.ctx == nil {
+		return nil, fmt.Errorf("%w: kubecontext=%s", errManagerNotStarted, m.kubecontext)
+	}
+	informerCtx, cancel := context.WithCancel(ctx)
+	stopAfterManager := context.AfterFunc(m.ctx, cancel)
+	context.AfterFunc(informerCtx, func() { stopAfterManager() })
+	return informerCtx, nil
 }
 
 func (m *Manager) AddEndpointSliceInformer(ctx context.Context, logger logr.Logger, config Config) error {
-	logger = logger.WithValues("kubecontext", m.kubecontext, "namespace", config.Namespace)
-	if config.Services == nil {
-		config.Services = make([]string, 0)
+	ctx, err := m.informerContext(ctx)
+	if err != nil {
+		return err
 	}
-	labelSelector := fmt.Sprintf("%s in (%s)", discoveryv1.LabelServiceName, strings.Join(config.Services, ", "))
+	logger = logger.WithValues("kubecontext", m.kubecontext, "namespace", config.Namespace)
+	labelSelector := endpointSliceLabelSelector(config)
 	logger.V(2).Info("Creating informer for EndpointSlices", "labelSelector", labelSelector)
 
 	stop := make(chan struct{})
@@ -92,37 +183,94 @@ func (m *Manager) AddEndpointSliceInformer(ctx context.Context, logger logr.Logg
 			listOptions.LabelSelector = labelSelector
 		})
 	})
+	serviceInformer := newServiceInformer(m.clientset, config.Namespace)
+	pods, podInformer := newPodLabels(m.clientset, config)
 
-	_, err := informer.AddEventHandler(informercache.ResourceEventHandlerFuncs{
+	_, err = informer.AddEventHandler(informercache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
 			logger := logger.WithValues("event", "add")
 			logEndpointSlice(logger, obj)
-			apps := getAppsForInformer(logger, informer)
-			m.handleEndpointSliceEvent(ctx, logger, config.Namespace, apps)
+			apps := getAppsForInformer(logger, informer, serviceInformer.GetIndexer(), pods, m.kubecontext)
+			m.handleEndpointSliceEvent(ctx, logger, config, apps)
 		},
 		UpdateFunc: func(_, obj interface{}) {
 			logger := logger.WithValues("event", "update")
 			logEndpointSlice(logger, obj)
-			apps := getAppsForInformer(logger, informer)
-			m.handleEndpointSliceEvent(ctx, logger, config.Namespace, apps)
+			apps := getAppsForInformer(logger, informer, serviceInformer.GetIndexer(), pods, m.kubecontext)
+			m.handleEndpointSliceEvent(ctx, logger, config, apps)
 		},
 		DeleteFunc: func(obj interface{}) {
 			logger := logger.WithValues("event", "delete")
 			logEndpointSlice(logger, obj)
-			apps := getAppsForInformer(logger, informer)
-			m.handleEndpointSliceEvent(ctx, logger, config.Namespace, apps)
+			apps := getAppsForInformer(logger, informer, serviceInformer.GetIndexer(), pods, m.kubecontext)
+			m.handleEndpointSliceEvent(ctx, logger, config, apps)
 		},
 	})
 	if err != nil {
 		return fmt.Errorf("could not add informer event handler for kubecontext=%s namespace=%s services=%+v: %w", m.kubecontext, config.Namespace, config.Services, err)
 	}
+	// Service annotations can change the health check port and protocol of the applications,
+	// and Pod labels can change the subsets of the endpoints.
+	handleServiceEvent := func(event string) {
+		logger := logger.WithValues("event", event)
+		apps := getAppsForInformer(logger, informer, serviceInformer.GetIndexer(), pods, m.kubecontext)
+		m.handleEndpointSliceEvent(ctx, logger, config, apps)
+	}
+	_, err = serviceInformer.AddEventHandler(informercache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) {
+			handleServiceEvent("add")
+		},
+		UpdateFunc: func(_, _ interface{}) {
+			handleServiceEvent("update")
+		},
+		DeleteFunc: func(_ interface{}) {
+			handleServiceEvent("delete")
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not add Service informer event handler for kubecontext=%s namespace=%s: %w", m.kubecontext, config.Namespace, err)
+	}
+	if podInformer != nil {
+		// Pod labels change the endpoint metadata used for subset load balancing.
+		if err := pods.addEventHandler(podInformer, handleServiceEvent); err != nil {
+			return fmt.Errorf("could not add Pod informer event handler for kubecontext=%s namespace=%s: %w", m.kubecontext, config.Namespace, err)
+		}
+	}
+	m.addRefresher(ctx, func() {
+		logger := logger.WithValues("event", "refresh")
+		apps := getAppsForInformer(logger, informer, serviceInformer.GetIndexer(), pods, m.kubecontext)
+		m.handleEndpointSliceEvent(ctx, logger, config, apps)
+	})
 	go func() {
 		logger.V(2).Info("Starting informer", "services", config.Services)
 		informer.Run(stop)
 	}()
+	go func() {
+		logger.V(2).Info("Starting Service informer")
+		serviceInformer.Run(stop)
+	}()
+	if podInformer != nil {
+		go func() {
+			logger.V(2).Info("Starting Pod informer", "subsetLabels", config.SubsetLabels)
+			podInformer.Run(stop)
+		}()
+	}
 	return nil
 }
 
+// endpointSliceLabelSelector selects EndpointSlices that are owned by services,
+// either by service names, or by the labels copied from the services.
+func endpointSliceLabelSelector(config Config) string {
+	labelSelector := discoveryv1.LabelServiceName
+	if !config.AllServices() {
+		labelSelector = fmt.Sprintf("%s in (%s)", discoveryv1.LabelServiceName, strings.Join(config.Services, ", "))
+	}
+	if config.LabelSelector != "" {
+		labelSelector = labelSelector + "," + config.LabelSelector
+	}
+	return labelSelector
+}
+
 func logEndpointSlice(logger logr.Logger, obj interface{}) {
 	if logger.V(4).Enabled() {
 		jsonBytes, err := json.MarshalIndent(obj, "", "  ")
@@ -133,15 +281,41 @@ func logEndpointSlice(logger logr.Logger, obj interface{}) {
 	}
 }
 
-func (m *Manager) handleEndpointSliceEvent(ctx context.Context, logger logr.Logger, namespace string, apps []applications.Application) {
-	logger.V(2).Info("Informer resource update", "apps", apps)
-	if err := m.xdsCache.UpdateResources(ctx, logger, m.kubecontext, namespace, apps); err != nil {
-		// Can't propagate this error, and we probably shouldn't end the goroutine anyway.
-		logger.Error(err, "Could not update the xDS resource cache with gRPC application configuration", "apps", apps)
+// SetIncludeTerminatingEndpoints determines if the applications include terminating endpoints that
+// are still serving, as draining endpoints, for the `endpointWeightTransition` feature flag.
+// Changes apply to the next EndpointSlice event of each informer.
+func (m *Manager) SetIncludeTerminatingEndpoints(include bool) {
+	m.includeTerminating.Store(include)
+}
+
+// handleEndpointSliceEvent sets the authority name of the informer configuration on the applications,
+// removes draining endpoints unless terminating endpoints are included, and queues an update of the
+// xDS resource cache.
+func (m *Manager) handleEndpointSliceEvent(ctx context.Context, logger logr.Logger, config Config, apps []applications.Application) {
+	if ctx.Err() != nil {
+		// The informer has been stopped, e.g., because it was removed from the informer configuration.
+		logger.V(2).Info("Ignoring resource update from stopped informer", "apps", apps)
+		return
 	}
+	includeTerminating := m.includeTerminating.Load()
+	for i := range apps {
+		apps[i].Authority = config.Authority
+		if !includeTerminating {
+			// Endpoints are only draining if they are terminating, see `applications.EndpointStatusFromConditions()`.
+			apps[i].Endpoints = slices.DeleteFunc(apps[i].Endpoints, func(endpoints applications.ApplicationEndpoints) bool {
+				return endpoints.EndpointStatus == applications.Draining
+			})
+		}
+	}
+	logger.V(2).Info("Informer resource update", "apps", apps)
+	m.updates.enqueue(ctx, logger, config.Namespace, apps)
 }
 
-func getAppsForInformer(logger logr.Logger, informer informercache.SharedIndexInformer) []applications.Application {
+// getAppsForInformer creates an application for each EndpointSlice in the informer. The annotations of the
+// Kubernetes Services in the services indexer can override the health check port and protocol, and set the
+// service name of gRPC health checks and the path prefix of the route. The endpoints have the values of the
+// subset labels of their Pods.
+func getAppsForInformer(logger logr.Logger, informer informercache.SharedIndexInformer, services informercache.Indexer, pods podLabels, cluster string) []applications.Application {
 	var apps []applications.Application
 	for _, eps := range informer.GetIndexer().List() {
 		endpointSlice, err := validateEndpointSlice(eps)
@@ -151,16 +325,23 @@ func getAppsForInformer(logger logr.Logger, informer informercache.SharedIndexIn
 		}
 		k8sServiceName := endpointSlice.GetObjectMeta().GetLabels()[discoveryv1.LabelServiceName]
 		namespace := endpointSlice.GetObjectMeta().GetNamespace()
-		servingPort := findServingPort(endpointSlice)
-		healthCheckPort, exists := findHealthCheckPort(endpointSlice)
+		annotations := getServiceAnnotations(services, namespace, k8sServiceName)
+		isHealthCheckPort := healthCheckPortMatcher(logger, endpointSlice, annotations)
+		servingPort := findServingPort(endpointSlice, isHealthCheckPort)
+		healthCheckPort, exists := findHealthCheckPort(endpointSlice, isHealthCheckPort)
 		if !exists {
 			// Default to using the serving port for health checks.
 			healthCheckPort = servingPort
 		}
 		servingProtocol := findProtocol(servingPort)
 		healthCheckProtocol := findProtocol(healthCheckPort)
-		appEndpoints := getApplicationEndpoints(endpointSlice)
+		if protocol, exists := healthCheckProtocolOverride(annotations); exists {
+			healthCheckProtocol = protocol
+		}
+		appEndpoints := getApplicationEndpoints(endpointSlice, pods, cluster)
 		app := applications.NewApplication(namespace, k8sServiceName, uint32(*servingPort.Port), servingProtocol, uint32(*healthCheckPort.Port), healthCheckProtocol, appEndpoints)
+		app.HealthCheckGRPCService = healthCheckGRPCService(annotations)
+		app.PathPrefix = pathPrefix(logger, annotations)
 		apps = append(apps, app)
 	}
 	return apps
@@ -181,23 +362,23 @@ func findProtocol(port discoveryv1.EndpointPort) string {
 	return "tcp"
 }
 
-// findServingPort returns the first port that isn't named to identify as a health check port.
+// findServingPort returns the first port that isn't identified as a health check port.
 // If there is only port on the EndpointSlice, return it regardless of name.
-func findServingPort(endpointSlice *discoveryv1.EndpointSlice) discoveryv1.EndpointPort {
+func findServingPort(endpointSlice *discoveryv1.EndpointSlice, isHealthCheckPort func(discoveryv1.EndpointPort) bool) discoveryv1.EndpointPort {
 	for _, endpointPort := range endpointSlice.Ports {
-		if endpointPort.Port != nil && (endpointPort.Name == nil || !healthCheckPortNames[*endpointPort.Name]) {
+		if endpointPort.Port != nil && !isHealthCheckPort(endpointPort) {
 			return endpointPort
 		}
 	}
-	// If all ports are named as health check ports, use the first one, regardless of name.
+	// If all ports are identified as health check ports, use the first one, regardless of name.
 	return endpointSlice.Ports[0]
 }
 
-// findHealthCheckPort returns the first port that is named to identify as a health check port.
-// Returns `false` as the second return value if no ports are named to identify as health check ports.
-func findHealthCheckPort(endpointSlice *discoveryv1.EndpointSlice) (discoveryv1.EndpointPort, bool) {
+// findHealthCheckPort returns the first port that is identified as a health check port.
+// Returns `false` as the second return value if no ports are identified as health check ports.
+func findHealthCheckPort(endpointSlice *discoveryv1.EndpointSlice, isHealthCheckPort func(discoveryv1.EndpointPort) bool) (discoveryv1.EndpointPort, bool) {
 	for _, endpointPort := range endpointSlice.Ports {
-		if endpointPort.Name != nil && healthCheckPortNames[*endpointPort.Name] {
+		if endpointPort.Port != nil && isHealthCheckPort(endpointPort) {
 			return endpointPort, true
 		}
 	}
@@ -205,23 +386,31 @@ func findHealthCheckPort(endpointSlice *discoveryv1.EndpointSlice) (discoveryv1.
 }
 
 // getApplicationEndpoints returns the endpoints as `GRPCApplicationEndpoints`.
-func getApplicationEndpoints(endpointSlice *discoveryv1.EndpointSlice) []applications.ApplicationEndpoints {
+func getApplicationEndpoints(endpointSlice *discoveryv1.EndpointSlice, pods podLabels, cluster string) []applications.ApplicationEndpoints {
 	var appEndpoints []applications.ApplicationEndpoints
 	for _, endpoint := range endpointSlice.Endpoints {
-		if endpoint.Conditions.Ready != nil && *endpoint.Conditions.Ready {
-			var k8sNode, zone string
+		// Include terminating endpoints that are still serving, as draining endpoints.
+		if isTrue(endpoint.Conditions.Ready) || (isTrue(endpoint.Conditions.Terminating) && isTrue(endpoint.Conditions.Serving)) {
+			var pod, k8sNode, zone string
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Kind == "Pod" {
+				pod = endpoint.TargetRef.Name
+			}
 			if endpoint.NodeName != nil {
 				k8sNode = *endpoint.NodeName
 			}
 			if endpoint.Zone != nil {
 				zone = *endpoint.Zone
 			}
-			appEndpoints = append(appEndpoints, applications.NewApplicationEndpoints(k8sNode, zone, endpoint.Addresses, applications.EndpointStatusFromConditions(endpoint.Conditions)))
+			appEndpoints = append(appEndpoints, applications.NewApplicationEndpoints(pod, k8sNode, zone, cluster, endpoint.Addresses, applications.AddressType(endpointSlice.AddressType), applications.EndpointStatusFromConditions(endpoint.Conditions), pods.lookup(endpointSlice.GetNamespace(), endpoint.TargetRef)))
 		}
 	}
 	return appEndpoints
 }
 
+func isTrue(b *bool) bool {
+	return b != nil && *b
+}
+
 // validateEndpointSlice ensures that the EndpointSlice contains the fields
 // required to turn it into a `xds.GRPCApplication` instance.
 func validateEndpointSlice(eps interface{}) (*discoveryv1.EndpointSlice, error) {
@@ -0,0 +1,410 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	streamv3 "github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+const (
+	testNamespace   = "xds"
+	testServiceName = "greeter-leaf"
+	testZone        = "us-central1-a"
+	// testTimeout is how long to wait for informer events to reach the snapshot cache.
+	testTimeout = 10 * time.Second
+)
+
+var testNode = &corev3.Node{
+	Id:            "test-node",
+	UserAgentName: "envoy",
+	Locality: &corev3.Locality{
+		Zone: testZone,
+	},
+}
+
+// TestEndpointSliceInformer adds, updates, and deletes an EndpointSlice using a fake clientset,
+// and checks that the ClusterLoadAssignment in the snapshot cache follows the changes.
+func TestEndpointSliceInformer(t *testing.T) {
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+	xdsCache := newTestSnapshotCache(ctx, t)
+	clientset := fake.NewSimpleClientset()
+	manager := NewManagerForClients("", clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if err := manager.Start(ctx, logr.Discard(), xdsCache); err != nil {
+		t.Fatalf("could not start informer manager: %v", err)
+	}
+	if err := manager.AddEndpointSliceInformer(ctx, logr.Discard(), Config{
+		Namespace: testNamespace,
+		Services:  []string{testServiceName},
+	}); err != nil {
+		t.Fatalf("could not add EndpointSlice informer: %v", err)
+	}
+	endpointSlices := clientset.DiscoveryV1().EndpointSlices(testNamespace)
+
+	endpointSlice := newEndpointSlice(testServiceName, "10.0.0.20")
+	if _, err := endpointSlices.Create(ctx, endpointSlice, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("could not create EndpointSlice: %v", err)
+	}
+	waitForAddresses(ctx, t, xdsCache, testServiceName, []string{"10.0.0.20"})
+
+	endpointSlice = newEndpointSlice(testServiceName, "10.0.0.20", "10.0.0.21")
+	if _, err := endpointSlices.Update(ctx, endpointSlice, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("could not update EndpointSlice: %v", err)
+	}
+	waitForAddresses(ctx, t, xdsCache, testServiceName, []string{"10.0.0.20", "10.0.0.21"})
+	snapshot := manager.Snapshot()
+	if apps := snapshot[discovery.Key{Namespace: testNamespace}]; len(apps) != 1 || apps[0].Name != testServiceName {
+		t.Errorf("manager snapshot = %+v, want application %s in namespace %s", snapshot, testServiceName, testNamespace)
+	}
+
+	if err := endpointSlices.Delete(ctx, endpointSlice.Name, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("could not delete EndpointSlice: %v", err)
+	}
+	waitForAddresses(ctx, t, xdsCache, testServiceName, nil)
+}
+
+// TestHealthCheckPortAnnotation checks that the health check port annotation on the Service
+// overrides the port naming convention, by port name and by port number.
+func TestHealthCheckPortAnnotation(t *testing.T) {
+	servingPortName, healthPortName, adminPortName := "grpc", "health", "admin"
+	servingPort, healthPort, adminPort := int32(50051), int32(50052), int32(50053)
+	endpointSlice := newEndpointSlice(testServiceName, "10.0.0.20")
+	endpointSlice.Ports = []discoveryv1.EndpointPort{
+		{Name: &servingPortName, Port: &servingPort},
+		{Name: &healthPortName, Port: &healthPort},
+		{Name: &adminPortName, Port: &adminPort},
+	}
+	tests := []struct {
+		name            string
+		annotations     map[string]string
+		wantServingPort int32
+		wantHealthPort  int32
+	}{
+		{
+			name:            "no annotation",
+			wantServingPort: servingPort,
+			wantHealthPort:  healthPort,
+		},
+		{
+			name:            "port name",
+			annotations:     map[string]string{HealthCheckPortAnnotation: adminPortName},
+			wantServingPort: servingPort,
+			wantHealthPort:  adminPort,
+		},
+		{
+			name:            "port number",
+			annotations:     map[string]string{HealthCheckPortAnnotation: "50053"},
+			wantServingPort: servingPort,
+			wantHealthPort:  adminPort,
+		},
+		{
+			name:            "unknown port",
+			annotations:     map[string]string{HealthCheckPortAnnotation: "metrics"},
+			wantServingPort: servingPort,
+			wantHealthPort:  healthPort,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isHealthCheckPort := healthCheckPortMatcher(logr.Discard(), endpointSlice, tt.annotations)
+			if got := *findServingPort(endpointSlice, isHealthCheckPort).Port; got != tt.wantServingPort {
+				t.Errorf("serving port = %d, want %d", got, tt.wantServingPort)
+			}
+			healthCheckPort, exists := findHealthCheckPort(endpointSlice, isHealthCheckPort)
+			if !exists {
+				t.Fatalf("no health check port, want %d", tt.wantHealthPort)
+			}
+			if got := *healthCheckPort.Port; got != tt.wantHealthPort {
+				t.Errorf("health check port = %d, want %d", got, tt.wantHealthPort)
+			}
+		})
+	}
+}
+
+// TestEndpointSliceAddressType checks that the application endpoints have the address type
+// of the EndpointSlice.
+func TestEndpointSliceAddressType(t *testing.T) {
+	tests := []struct {
+		name        string
+		addressType discoveryv1.AddressType
+		address     string
+		want        applications.AddressType
+	}{
+		{
+			name:        "IPv4",
+			addressType: discoveryv1.AddressTypeIPv4,
+			address:     "10.0.0.20",
+			want:        applications.AddressTypeIPv4,
+		},
+		{
+			name:        "IPv6",
+			addressType: discoveryv1.AddressTypeIPv6,
+			address:     "fd00:10::20",
+			want:        applications.AddressTypeIPv6,
+		},
+		{
+			name:        "FQDN",
+			addressType: discoveryv1.AddressTypeFQDN,
+			address:     "greeter.example.com",
+			want:        applications.AddressTypeFQDN,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpointSlice := newEndpointSlice(testServiceName, tt.address)
+			endpointSlice.AddressType = tt.addressType
+			appEndpoints := getApplicationEndpoints(endpointSlice, podLabels{}, "")
+			if len(appEndpoints) != 1 {
+				t.Fatalf("got %d application endpoints, want 1", len(appEndpoints))
+			}
+			if got := appEndpoints[0].AddressType; got != tt.want {
+				t.Errorf("address type = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestTerminatingEndpoints checks that terminating endpoints are only included if they are
+// still serving, and then as draining endpoints.
+func TestTerminatingEndpoints(t *testing.T) {
+	yes, no := true, false
+	tests := []struct {
+		name       string
+		conditions discoveryv1.EndpointConditions
+		want       []applications.EndpointStatus
+	}{
+		{
+			name:       "ready",
+			conditions: discoveryv1.EndpointConditions{Ready: &yes, Serving: &yes, Terminating: &no},
+			want:       []applications.EndpointStatus{applications.Healthy},
+		},
+		{
+			name:       "terminating and serving",
+			conditions: discoveryv1.EndpointConditions{Ready: &no, Serving: &yes, Terminating: &yes},
+			want:       []applications.EndpointStatus{applications.Draining},
+		},
+		{
+			name:       "terminating and not serving",
+			conditions: discoveryv1.EndpointConditions{Ready: &no, Serving: &no, Terminating: &yes},
+		},
+		{
+			name:       "not ready",
+			conditions: discoveryv1.EndpointConditions{Ready: &no, Serving: &no, Terminating: &no},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			endpointSlice := newEndpointSlice(testServiceName, "10.0.0.20")
+			endpointSlice.Endpoints[0].Conditions = tt.conditions
+			var got []applications.EndpointStatus
+			for _, appEndpoints := range getApplicationEndpoints(endpointSlice, podLabels{}, "") {
+				got = append(got, appEndpoints.EndpointStatus)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("endpoint statuses = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestIncludeTerminatingEndpoints checks that the manager only passes terminating endpoints that
+// are still serving to the xDS resource cache if they are included, for the
+// `endpointWeightTransition` feature flag.
+func TestIncludeTerminatingEndpoints(t *testing.T) {
+	for _, include := range []bool{false, true} {
+		t.Run(fmt.Sprintf("include=%t", include), func(t *testing.T) {
+			ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+			defer cancel()
+			xdsCache := newTestSnapshotCache(ctx, t)
+			clientset := fake.NewSimpleClientset()
+			manager := NewManagerForClients("", clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+			manager.SetIncludeTerminatingEndpoints(include)
+			if err := manager.Start(ctx, logr.Discard(), xdsCache); err != nil {
+				t.Fatalf("could not start informer manager: %v", err)
+			}
+			if err := manager.AddEndpointSliceInformer(ctx, logr.Discard(), Config{
+				Namespace: testNamespace,
+				Services:  []string{testServiceName},
+			}); err != nil {
+				t.Fatalf("could not add EndpointSlice informer: %v", err)
+			}
+			endpointSlice := newEndpointSlice(testServiceName, "10.0.0.20", "10.0.0.21")
+			yes, no := true, false
+			endpointSlice.Endpoints[1].Conditions = discoveryv1.EndpointConditions{Ready: &no, Serving: &yes, Terminating: &yes}
+			if _, err := clientset.DiscoveryV1().EndpointSlices(testNamespace).Create(ctx, endpointSlice, metav1.CreateOptions{}); err != nil {
+				t.Fatalf("could not create EndpointSlice: %v", err)
+			}
+			want := []string{"10.0.0.20"}
+			if include {
+				want = append(want, "10.0.0.21")
+			}
+			waitForAddresses(ctx, t, xdsCache, testServiceName, want)
+		})
+	}
+}
+
+// TestPathPrefixAnnotation checks that the path prefix annotation on the Service sets the path
+// prefix of the route, unless the value is not a path.
+func TestPathPrefixAnnotation(t *testing.T) {
+	tests := []struct {
+		name        string
+		annotations map[string]string
+		want        string
+	}{
+		{
+			name: "no annotation",
+			want: "",
+		},
+		{
+			name:        "service prefix",
+			annotations: map[string]string{PathPrefixAnnotation: "/helloworld.Greeter/"},
+			want:        "/helloworld.Greeter/",
+		},
+		{
+			name:        "not a path",
+			annotations: map[string]string{PathPrefixAnnotation: "helloworld.Greeter"},
+			want:        "",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := pathPrefix(logr.Discard(), tt.annotations); got != tt.want {
+				t.Errorf("path prefix = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// newTestSnapshotCache returns a snapshot cache with an open Listener watch for the test node,
+// so that the cache creates new snapshots for the node when applications change.
+func newTestSnapshotCache(ctx context.Context, t *testing.T) *xds.SnapshotCache {
+	t.Helper()
+	xdsCache := xds.NewSnapshotCache(ctx, xds.ZoneHash{}, eds.LocalityPriorityByZone{}, &xds.Features{}, "")
+	responses := make(chan cachev3.Response, 1)
+	cancelWatch := xdsCache.CreateWatch(&cachev3.Request{
+		Node:    testNode,
+		TypeUrl: resource.ListenerType,
+	}, streamv3.NewStreamState(false, nil), responses)
+	t.Cleanup(cancelWatch)
+	return xdsCache
+}
+
+func newEndpointSlice(serviceName string, addresses ...string) *discoveryv1.EndpointSlice {
+	portName := "grpc"
+	port := int32(50051)
+	protocol := corev1.ProtocolTCP
+	ready := true
+	zone := testZone
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName + "-abcde",
+			Namespace: testNamespace,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: serviceName,
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports: []discoveryv1.EndpointPort{
+			{
+				Name:     &portName,
+				Port:     &port,
+				Protocol: &protocol,
+			},
+		},
+	}
+	for _, address := range addresses {
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{
+			Addresses: []string{address},
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:   &ready,
+				Serving: &ready,
+			},
+			Zone: &zone,
+		})
+	}
+	return endpointSlice
+}
+
+// waitForAddresses waits until the ClusterLoadAssignment of the service in the snapshot cache
+// contains the expected endpoint addresses. Nil addresses means no ClusterLoadAssignment.
+func waitForAddresses(ctx context.Context, t *testing.T, xdsCache *xds.SnapshotCache, serviceName string, want []string) {
+	t.Helper()
+	deadline := time.Now().Add(testTimeout)
+	var got []string
+	for time.Now().Before(deadline) {
+		got = fetchAddresses(ctx, t, xdsCache, serviceName)
+		if slices.Equal(got, want) {
+			return
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for addresses of %s, got %v, want %v", serviceName, got, want)
+}
+
+// fetchAddresses returns the sorted endpoint addresses of the ClusterLoadAssignment of the service.
+func fetchAddresses(ctx context.Context, t *testing.T, xdsCache *xds.SnapshotCache, serviceName string) []string {
+	t.Helper()
+	response, err := xdsCache.Fetch(ctx, &cachev3.Request{
+		Node:    testNode,
+		TypeUrl: resource.EndpointType,
+	})
+	if err != nil {
+		return nil
+	}
+	discoveryResponse, err := response.GetDiscoveryResponse()
+	if err != nil {
+		t.Fatalf("could not get discovery response: %v", err)
+	}
+	var addresses []string
+	for _, anyResource := range discoveryResponse.GetResources() {
+		var cla endpointv3.ClusterLoadAssignment
+		if err := anyResource.UnmarshalTo(&cla); err != nil {
+			t.Fatalf("could not unmarshal ClusterLoadAssignment: %v", err)
+		}
+		if cla.GetClusterName() != serviceName {
+			continue
+		}
+		for _, localityLbEndpoints := range cla.GetEndpoints() {
+			for _, lbEndpoint := range localityLbEndpoints.GetLbEndpoints() {
+				addresses = append(addresses, lbEndpoint.GetEndpoint().GetAddress().GetSocketAddress().GetAddress())
+			}
+		}
+	}
+	slices.Sort(addresses)
+	return addresses
+}
@@ -0,0 +1,73 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	informercache "k8s.io/client-go/tools/cache"
+)
+
+// addNamespaceInformer watches for namespace deletions in the kubecontext, and removes the
+// applications and routes of deleted namespaces from the xDS resource cache.
+func (m *Manager) addNamespaceInformer(ctx context.Context, logger logr.Logger) error {
+	ctx, err := m.informerContext(ctx)
+	if err != nil {
+		return err
+	}
+	logger = logger.WithValues("kubecontext", m.kubecontext)
+	logger.V(2).Info("Creating informer for namespace deletions")
+
+	stop := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		logger.V(1).Info("Stopping informer for namespace deletions")
+		close(stop)
+	}()
+
+	informer := coreinformers.NewNamespaceInformer(m.clientset, 0, informercache.Indexers{})
+	_, err = informer.AddEventHandler(informercache.ResourceEventHandlerFuncs{
+		DeleteFunc: func(obj interface{}) {
+			if tombstone, ok := obj.(informercache.DeletedFinalStateUnknown); ok {
+				obj = tombstone.Obj
+			}
+			namespace, ok := obj.(*corev1.Namespace)
+			if !ok {
+				logger.Error(fmt.Errorf("%w: expected *corev1.Namespace, got %T", errUnexpectedType, obj), "Skipping namespace deletion")
+				return
+			}
+			if ctx.Err() != nil {
+				return
+			}
+			logger := logger.WithValues("event", "delete", "namespace", namespace.Name)
+			logger.V(2).Info("Namespace deleted")
+			if err := m.updates.deleteNamespace(ctx, logger, namespace.Name); err != nil {
+				logger.Error(err, "Could not remove the applications and routes of the deleted namespace from the xDS resource cache")
+			}
+		},
+	})
+	if err != nil {
+		return fmt.Errorf("could not add namespace informer event handler for kubecontext=%s: %w", m.kubecontext, err)
+	}
+	go func() {
+		logger.V(2).Info("Starting informer for namespace deletions")
+		informer.Run(stop)
+	}()
+	return nil
+}
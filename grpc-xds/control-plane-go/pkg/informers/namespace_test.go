@@ -0,0 +1,94 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+// TestNamespaceDeletion deletes a namespace using a fake clientset, and checks that the
+// applications and routes of the namespace are removed from the snapshot cache.
+func TestNamespaceDeletion(t *testing.T) {
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+	xdsCache := newTestSnapshotCache(ctx, t)
+	namespace := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: testNamespace}}
+	clientset := fake.NewSimpleClientset(namespace)
+	manager := NewManagerForClients("", clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if err := manager.Start(ctx, logr.Discard(), xdsCache); err != nil {
+		t.Fatalf("could not start informer manager: %v", err)
+	}
+	if err := manager.AddEndpointSliceInformer(ctx, logr.Discard(), Config{
+		Namespace: testNamespace,
+		Services:  []string{testServiceName},
+	}); err != nil {
+		t.Fatalf("could not add EndpointSlice informer: %v", err)
+	}
+	if _, err := clientset.DiscoveryV1().EndpointSlices(testNamespace).Create(ctx, newEndpointSlice(testServiceName, "10.0.0.20"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("could not create EndpointSlice: %v", err)
+	}
+	waitForAddresses(ctx, t, xdsCache, testServiceName, []string{"10.0.0.20"})
+	routeHostname := testServiceName + "-route"
+	if err := xdsCache.UpdateRoutes(ctx, logr.Discard(), "", testNamespace, []applications.Route{{
+		Namespace: testNamespace,
+		Name:      routeHostname,
+		Hostnames: []string{routeHostname},
+		Rules:     []applications.RouteRule{{Backends: []applications.RouteBackend{{Name: testServiceName, Weight: 100}}}},
+	}}); err != nil {
+		t.Fatalf("could not update routes: %v", err)
+	}
+	if !hasListener(t, xdsCache, routeHostname) {
+		t.Fatalf("no Listener for route hostname=%s before deleting the namespace", routeHostname)
+	}
+
+	if err := clientset.CoreV1().Namespaces().Delete(ctx, testNamespace, metav1.DeleteOptions{}); err != nil {
+		t.Fatalf("could not delete namespace: %v", err)
+	}
+	// The fake clientset does not delete the EndpointSlices of the namespace, so only the
+	// namespace informer removes the application.
+	waitForAddresses(ctx, t, xdsCache, testServiceName, nil)
+	deadline := time.Now().Add(testTimeout)
+	for hasListener(t, xdsCache, routeHostname) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for the Listener for route hostname=%s to be removed", routeHostname)
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
+
+// hasListener determines if the snapshot of the test node has a Listener with the name.
+func hasListener(t *testing.T, xdsCache *xds.SnapshotCache, name string) bool {
+	t.Helper()
+	snapshot, err := xdsCache.Snapshot(testZone)
+	if err != nil {
+		t.Fatalf("could not get snapshot for nodeHash=%s: %v", testZone, err)
+	}
+	_, exists := snapshot.GetResources(resource.ListenerType)[name]
+	return exists
+}
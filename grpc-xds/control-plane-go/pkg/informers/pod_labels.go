@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"maps"
+
+	corev1 "k8s.io/api/core/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	informercache "k8s.io/client-go/tools/cache"
+)
+
+// podLabels looks up the values of the subset labels of the Pods behind EndpointSlice endpoints.
+// The zero value has no Pods, and returns no labels.
+type podLabels struct {
+	pods informercache.Indexer
+	keys []string
+}
+
+// newPodLabels returns a Pod informer for the namespace, and the lookup of the subset labels
+// of the informer configuration. If there are no subset labels, the informer is nil, since
+// watching all Pods in the namespace is only necessary for subset load balancing.
+func newPodLabels(clientset kubernetes.Interface, config Config) (podLabels, informercache.SharedIndexInformer) {
+	if len(config.SubsetLabels) == 0 {
+		return podLabels{}, nil
+	}
+	indexers := informercache.Indexers{informercache.NamespaceIndex: informercache.MetaNamespaceIndexFunc}
+	informer := coreinformers.NewPodInformer(clientset, config.Namespace, 0, indexers)
+	return podLabels{
+		pods: informer.GetIndexer(),
+		keys: config.SubsetLabels,
+	}, informer
+}
+
+// addEventHandler calls handleEvent when Pods are added or deleted, and when the values of
+// the subset labels of a Pod change. Other Pod updates, e.g., status changes, are ignored.
+func (p podLabels) addEventHandler(informer informercache.SharedIndexInformer, handleEvent func(event string)) error {
+	_, err := informer.AddEventHandler(informercache.ResourceEventHandlerFuncs{
+		AddFunc: func(_ interface{}) {
+			handleEvent("add")
+		},
+		UpdateFunc: func(oldObj, newObj interface{}) {
+			oldPod, oldOK := oldObj.(*corev1.Pod)
+			newPod, newOK := newObj.(*corev1.Pod)
+			if oldOK && newOK && maps.Equal(subsetLabelValues(oldPod.GetLabels(), p.keys), subsetLabelValues(newPod.GetLabels(), p.keys)) {
+				return
+			}
+			handleEvent("update")
+		},
+		DeleteFunc: func(_ interface{}) {
+			handleEvent("delete")
+		},
+	})
+	return err
+}
+
+// lookup returns the values of the subset labels of the Pod referenced by the endpoint, or nil if
+// the endpoint is not a Pod, or if the Pod has none of the labels.
+func (p podLabels) lookup(namespace string, targetRef *corev1.ObjectReference) map[string]string {
+	if p.pods == nil || len(p.keys) == 0 || targetRef == nil || targetRef.Kind != "Pod" {
+		return nil
+	}
+	obj, exists, err := p.pods.GetByKey(namespace + "/" + targetRef.Name)
+	if err != nil || !exists {
+		return nil
+	}
+	pod, ok := obj.(*corev1.Pod)
+	if !ok {
+		return nil
+	}
+	return subsetLabelValues(pod.GetLabels(), p.keys)
+}
+
+// subsetLabelValues returns the labels with the provided keys, or nil if there are none.
+func subsetLabelValues(labels map[string]string, keys []string) map[string]string {
+	var values map[string]string
+	for _, key := range keys {
+		if value, exists := labels[key]; exists {
+			if values == nil {
+				values = map[string]string{}
+			}
+			values[key] = value
+		}
+	}
+	return values
+}
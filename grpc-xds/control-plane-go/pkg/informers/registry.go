@@ -0,0 +1,284 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+)
+
+// Registry keeps track of the running informers, so that informers can be
+// added and removed when the informer configuration changes at runtime.
+type Registry struct {
+	mu           sync.Mutex
+	xdsCache     discovery.Sink
+	healthConfig HealthConfig
+	// managers has one informer manager per kubecontext name.
+	managers map[string]*managedKubecontext
+	// informers has one running informer per `<kubecontext>/<namespace>` key.
+	informers map[string]*runningInformer
+	// newManager creates informer managers, it is replaced in tests.
+	newManager func(ctx context.Context, kubecontextName string) (*Manager, error)
+	// includeTerminating is passed to the informer managers, see `SetIncludeTerminatingEndpoints()`.
+	includeTerminating bool
+}
+
+type managedKubecontext struct {
+	manager       *Manager
+	stalenessTTL  time.Duration
+	cancelMonitor context.CancelFunc
+}
+
+type runningInformer struct {
+	kubecontext string
+	config      Config
+	cancel      context.CancelFunc
+}
+
+func NewRegistry(xdsCache discovery.Sink, healthConfig HealthConfig) *Registry {
+	return &Registry{
+		xdsCache:     xdsCache,
+		healthConfig: healthConfig,
+		managers:     map[string]*managedKubecontext{},
+		informers:    map[string]*runningInformer{},
+		newManager:   NewManager,
+	}
+}
+
+// Apply starts and stops informers so that the running informers match the provided configuration.
+//
+// Informers with unchanged configuration keep running. Informers that are no longer
+// configured, or that have a changed selection of services or sources, are stopped, and their
+// applications are removed from the xDS resource cache.
+// Informers run until the provided context is done, or until a later call to
+// `Apply()` stops them.
+//
+// If an informer manager or an informer cannot be created, Apply stops the informers that it
+// started, restarts the informers that it stopped, and leaves the registry unchanged.
+//
+// Apply also monitors the health of each kubecontext, see `Manager.MonitorHealth()`.
+func (r *Registry) Apply(ctx context.Context, logger logr.Logger, kubecontexts []Kubecontext) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	desired := map[string]*runningInformer{}
+	for _, kubecontext := range kubecontexts {
+		for _, config := range kubecontext.Informers {
+			desired[informerKey(kubecontext.Context, config.Namespace)] = &runningInformer{
+				kubecontext: kubecontext.Context,
+				config:      config,
+			}
+		}
+	}
+	stale := map[string]*runningInformer{}
+	for key, running := range r.informers {
+		if wanted, exists := desired[key]; !exists || !sameInformerConfig(wanted.config, running.config) {
+			stale[key] = running
+		}
+	}
+	added := map[string]*runningInformer{}
+	for key, wanted := range desired {
+		if _, running := r.informers[key]; !running || stale[key] != nil {
+			added[key] = wanted
+		}
+	}
+
+	// Create the managers for new kubecontexts before stopping any informers.
+	newManagers := map[string]*Manager{}
+	for _, wanted := range added {
+		if _, exists := r.managers[wanted.kubecontext]; exists || newManagers[wanted.kubecontext] != nil {
+			continue
+		}
+		manager, err := r.startManager(ctx, logger, wanted.kubecontext)
+		if err != nil {
+			stopManagers(newManagers)
+			return err
+		}
+		newManagers[wanted.kubecontext] = manager
+	}
+	managerFor := func(kubecontextName string) *Manager {
+		if managed, exists := r.managers[kubecontextName]; exists {
+			return managed.manager
+		}
+		return newManagers[kubecontextName]
+	}
+
+	// Stop the stale informers before starting their replacements, so that the removal of their
+	// applications from the xDS resource cache does not race with the replacement informers.
+	var errs []error
+	for _, running := range stale {
+		logger.V(1).Info("Removing informer", "kubecontext", running.kubecontext, "namespace", running.config.Namespace, "services", running.config.Services)
+		running.cancel()
+		if err := r.xdsCache.DeleteNamespace(ctx, logger, running.kubecontext, running.config.Namespace); err != nil {
+			errs = append(errs, fmt.Errorf("could not remove applications and routes of stopped informer for context=%s namespace=%s from the xDS resource cache: %w", running.kubecontext, running.config.Namespace, err))
+		}
+	}
+	started := map[string]*runningInformer{}
+	for key, wanted := range added {
+		cancel, err := startInformer(ctx, logger, managerFor(wanted.kubecontext), wanted)
+		if err != nil {
+			for _, informer := range started {
+				informer.cancel()
+			}
+			stopManagers(newManagers)
+			errs = append([]error{err}, errs...)
+			return errors.Join(append(errs, r.restart(ctx, logger, stale)...)...)
+		}
+		started[key] = &runningInformer{
+			kubecontext: wanted.kubecontext,
+			config:      wanted.config,
+			cancel:      cancel,
+		}
+	}
+
+	// Swap in the new state.
+	informers := maps.Clone(r.informers)
+	for key := range stale {
+		delete(informers, key)
+	}
+	maps.Copy(informers, started)
+	r.informers = informers
+	for kubecontextName, manager := range newManagers {
+		r.managers[kubecontextName] = &managedKubecontext{
+			manager: manager,
+		}
+	}
+	r.monitorHealth(ctx, logger, kubecontexts)
+	return errors.Join(errs...)
+}
+
+// restart starts the provided informers again with their previous configuration, after a failed
+// `Apply()`. Informers that cannot be restarted are removed from the registry.
+func (r *Registry) restart(ctx context.Context, logger logr.Logger, informers map[string]*runningInformer) []error {
+	var errs []error
+	for key, informer := range informers {
+		logger.V(1).Info("Restarting informer", "kubecontext", informer.kubecontext, "namespace", informer.config.Namespace, "services", informer.config.Services)
+		cancel, err := startInformer(ctx, logger, r.managers[informer.kubecontext].manager, informer)
+		if err != nil {
+			errs = append(errs, err)
+			delete(r.informers, key)
+			continue
+		}
+		informer.cancel = cancel
+	}
+	return errs
+}
+
+// startInformer adds the informers for the configuration to the manager, and returns the
+// function that stops them.
+func startInformer(ctx context.Context, logger logr.Logger, manager *Manager, informer *runningInformer) (context.CancelFunc, error) {
+	informerCtx, cancel := context.WithCancel(ctx)
+	addInformer := manager.AddEndpointSliceInformer
+	if informer.config.XDSApplications {
+		addInformer = manager.AddXDSApplicationInformer
+	}
+	if err := addInformer(informerCtx, logger, informer.config); err != nil {
+		cancel()
+		return nil, fmt.Errorf("could not create Kubernetes informer for context=%s for %+v: %w", informer.kubecontext, informer.config, err)
+	}
+	if informer.config.GRPCRoutes {
+		if err := manager.AddGRPCRouteInformer(informerCtx, logger, informer.config); err != nil {
+			cancel()
+			return nil, fmt.Errorf("could not create GRPCRoute informer for context=%s for %+v: %w", informer.kubecontext, informer.config, err)
+		}
+	}
+	return cancel, nil
+}
+
+// sameInformerConfig returns true if the informers for the configurations select the same
+// services and sources.
+func sameInformerConfig(a Config, b Config) bool {
+	return a.XDSApplications == b.XDSApplications &&
+		a.GRPCRoutes == b.GRPCRoutes &&
+		a.LabelSelector == b.LabelSelector &&
+		a.Authority == b.Authority &&
+		slices.Equal(a.Services, b.Services) &&
+		slices.Equal(a.SubsetLabels, b.SubsetLabels)
+}
+
+func stopManagers(managers map[string]*Manager) {
+	for _, manager := range managers {
+		manager.Stop()
+	}
+}
+
+// monitorHealth starts health monitoring for new kubecontexts, restarts it for kubecontexts with
+// a changed staleness TTL, and stops it for kubecontexts that are no longer configured.
+func (r *Registry) monitorHealth(ctx context.Context, logger logr.Logger, kubecontexts []Kubecontext) {
+	stalenessTTLs := map[string]time.Duration{}
+	for _, kubecontext := range kubecontexts {
+		stalenessTTL := r.healthConfig.StalenessTTL
+		if kubecontext.StalenessTTL != nil {
+			stalenessTTL = *kubecontext.StalenessTTL
+		}
+		stalenessTTLs[kubecontext.Context] = stalenessTTL
+	}
+	for kubecontextName, managed := range r.managers {
+		stalenessTTL, exists := stalenessTTLs[kubecontextName]
+		if exists && managed.cancelMonitor != nil && stalenessTTL == managed.stalenessTTL {
+			continue
+		}
+		if managed.cancelMonitor != nil {
+			managed.cancelMonitor()
+			managed.cancelMonitor = nil
+		}
+		if !exists {
+			managed.manager.Stop()
+			delete(r.managers, kubecontextName)
+			continue
+		}
+		monitorCtx, cancel := context.WithCancel(ctx)
+		managed.stalenessTTL = stalenessTTL
+		managed.cancelMonitor = cancel
+		go managed.manager.MonitorHealth(monitorCtx, logger, r.healthConfig.Interval, stalenessTTL)
+	}
+}
+
+// startManager creates and starts the informer manager for the kubecontext,
+// see `Manager.Start()`.
+// SetIncludeTerminatingEndpoints determines if the informers of all current and future managers
+// include terminating endpoints that are still serving, see `Manager.SetIncludeTerminatingEndpoints()`.
+func (r *Registry) SetIncludeTerminatingEndpoints(include bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.includeTerminating = include
+	for _, managed := range r.managers {
+		managed.manager.SetIncludeTerminatingEndpoints(include)
+	}
+}
+
+func (r *Registry) startManager(ctx context.Context, logger logr.Logger, kubecontextName string) (*Manager, error) {
+	manager, err := r.newManager(ctx, kubecontextName)
+	if err != nil {
+		return nil, fmt.Errorf("could not create Kubernetes informer manager for context=%s: %w", kubecontextName, err)
+	}
+	manager.SetIncludeTerminatingEndpoints(r.includeTerminating)
+	if err := manager.Start(ctx, logger, r.xdsCache); err != nil {
+		return nil, fmt.Errorf("could not start Kubernetes informer manager for context=%s: %w", kubecontextName, err)
+	}
+	return manager, nil
+}
+
+func informerKey(kubecontextName string, namespace string) string {
+	return fmt.Sprintf("%s/%s", kubecontextName, namespace)
+}
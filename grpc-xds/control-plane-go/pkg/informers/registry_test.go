@@ -0,0 +1,91 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+)
+
+var errTestKubecontext = errors.New("test kubecontext is unreachable")
+
+// TestRegistryApplyFailureLeavesRegistryUnchanged checks that an Apply that fails to create an
+// informer manager does not stop or replace the running informers.
+func TestRegistryApplyFailureLeavesRegistryUnchanged(t *testing.T) {
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+	xdsCache := newTestSnapshotCache(ctx, t)
+	clientset := fake.NewSimpleClientset()
+	registry := NewRegistry(xdsCache, HealthConfig{Interval: time.Hour})
+	registry.newManager = func(_ context.Context, kubecontextName string) (*Manager, error) {
+		if kubecontextName == "unreachable" {
+			return nil, errTestKubecontext
+		}
+		return NewManagerForClients(kubecontextName, clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())), nil
+	}
+	initial := []Kubecontext{{
+		Context:   "local",
+		Informers: []Config{{Namespace: testNamespace, Services: []string{testServiceName}}},
+	}}
+	if err := registry.Apply(ctx, logr.Discard(), initial); err != nil {
+		t.Fatalf("Apply initial configuration: %v", err)
+	}
+	if _, err := clientset.DiscoveryV1().EndpointSlices(testNamespace).Create(ctx, newEndpointSlice(testServiceName, "10.0.0.30"), metav1.CreateOptions{}); err != nil {
+		t.Fatalf("could not create EndpointSlice: %v", err)
+	}
+	waitForAddresses(ctx, t, xdsCache, testServiceName, []string{"10.0.0.30"})
+
+	// The changed informer for the local kubecontext would replace the running informer,
+	// but the manager for the unreachable kubecontext cannot be created.
+	updated := []Kubecontext{
+		{
+			Context:   "local",
+			Informers: []Config{{Namespace: testNamespace, Services: []string{testServiceName, "greeter-intermediary"}}},
+		},
+		{
+			Context:   "unreachable",
+			Informers: []Config{{Namespace: testNamespace}},
+		},
+	}
+	if err := registry.Apply(ctx, logr.Discard(), updated); !errors.Is(err, errTestKubecontext) {
+		t.Fatalf("Apply updated configuration: got error %v, want %v", err, errTestKubecontext)
+	}
+	running, exists := registry.informers[informerKey("local", testNamespace)]
+	if !exists || !slices.Equal(running.config.Services, []string{testServiceName}) {
+		t.Errorf("running informer = %+v, want the initial informer for services [%s]", running, testServiceName)
+	}
+	if len(registry.informers) != 1 {
+		t.Errorf("got %d running informers, want 1", len(registry.informers))
+	}
+	if _, exists := registry.managers["unreachable"]; exists {
+		t.Error("registry has a manager for the unreachable kubecontext")
+	}
+	// The running informer still passes EndpointSlice updates to the xDS resource cache.
+	if _, err := clientset.DiscoveryV1().EndpointSlices(testNamespace).Update(ctx, newEndpointSlice(testServiceName, "10.0.0.30", "10.0.0.31"), metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("could not update EndpointSlice: %v", err)
+	}
+	waitForAddresses(ctx, t, xdsCache, testServiceName, []string{"10.0.0.30", "10.0.0.31"})
+}
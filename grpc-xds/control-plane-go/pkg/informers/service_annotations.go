@@ -0,0 +1,121 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/go-logr/logr"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	coreinformers "k8s.io/client-go/informers/core/v1"
+	"k8s.io/client-go/kubernetes"
+	informercache "k8s.io/client-go/tools/cache"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/v1alpha1"
+)
+
+const (
+	// HealthCheckPortAnnotation on a Kubernetes Service identifies the health check port of the
+	// endpoints, by port name or port number. It overrides the `healthCheckPortNames` convention.
+	HealthCheckPortAnnotation = v1alpha1.GroupName + "/healthcheck-port"
+	// HealthCheckProtocolAnnotation on a Kubernetes Service overrides the health check protocol,
+	// which otherwise comes from the `appProtocol` or `protocol` of the health check port.
+	HealthCheckProtocolAnnotation = v1alpha1.GroupName + "/healthcheck-protocol"
+	// HealthCheckGRPCServiceAnnotation on a Kubernetes Service sets the service name of gRPC health
+	// checks, e.g., `helloworld.Greeter`. By default, gRPC health checks request the overall health
+	// of the server.
+	HealthCheckGRPCServiceAnnotation = v1alpha1.GroupName + "/healthcheck-grpc-service"
+	// PathPrefixAnnotation on a Kubernetes Service sets the path prefix of the route to the application,
+	// e.g., `/helloworld.Greeter/`, so that the route only matches requests for that gRPC service.
+	// By default, the route matches all paths.
+	PathPrefixAnnotation = v1alpha1.GroupName + "/path-prefix"
+)
+
+// newServiceInformer watches the Kubernetes Services in the namespace, to read their annotations.
+func newServiceInformer(clientset kubernetes.Interface, namespace string) informercache.SharedIndexInformer {
+	indexers := informercache.Indexers{informercache.NamespaceIndex: informercache.MetaNamespaceIndexFunc}
+	return coreinformers.NewServiceInformer(clientset, namespace, 0, indexers)
+}
+
+// getServiceAnnotations returns the annotations of the Kubernetes Service, or nil if the Service is not in the indexer.
+func getServiceAnnotations(services informercache.Indexer, namespace string, name string) map[string]string {
+	if services == nil {
+		return nil
+	}
+	obj, exists, err := services.GetByKey(namespace + "/" + name)
+	if err != nil || !exists {
+		return nil
+	}
+	service, ok := obj.(*corev1.Service)
+	if !ok {
+		return nil
+	}
+	return service.GetAnnotations()
+}
+
+// healthCheckPortMatcher returns a function that identifies the health check port of the EndpointSlice.
+// If the Service has a health check port annotation that matches one of the ports of the EndpointSlice,
+// the function matches that port. Otherwise, the function matches ports named as health check ports.
+func healthCheckPortMatcher(logger logr.Logger, endpointSlice *discoveryv1.EndpointSlice, annotations map[string]string) func(discoveryv1.EndpointPort) bool {
+	annotatedPort, exists := annotations[HealthCheckPortAnnotation]
+	if !exists || annotatedPort == "" {
+		return isNamedHealthCheckPort
+	}
+	isAnnotatedPort := func(endpointPort discoveryv1.EndpointPort) bool {
+		if endpointPort.Name != nil && *endpointPort.Name == annotatedPort {
+			return true
+		}
+		return endpointPort.Port != nil && strconv.Itoa(int(*endpointPort.Port)) == annotatedPort
+	}
+	for _, endpointPort := range endpointSlice.Ports {
+		if isAnnotatedPort(endpointPort) {
+			return isAnnotatedPort
+		}
+	}
+	logger.V(1).Info("Health check port annotation does not match any EndpointSlice port, using port names instead", "endpointSlice", endpointSlice.GetName(), "annotation", HealthCheckPortAnnotation, "value", annotatedPort)
+	return isNamedHealthCheckPort
+}
+
+func isNamedHealthCheckPort(endpointPort discoveryv1.EndpointPort) bool {
+	return endpointPort.Name != nil && healthCheckPortNames[*endpointPort.Name]
+}
+
+// healthCheckProtocolOverride returns the health check protocol from the Service annotation, in all lowercase.
+func healthCheckProtocolOverride(annotations map[string]string) (string, bool) {
+	protocol, exists := annotations[HealthCheckProtocolAnnotation]
+	if !exists || protocol == "" {
+		return "", false
+	}
+	return strings.ToLower(protocol), true
+}
+
+// healthCheckGRPCService returns the service name of gRPC health checks from the Service annotation,
+// or an empty string if the Service is not annotated.
+func healthCheckGRPCService(annotations map[string]string) string {
+	return annotations[HealthCheckGRPCServiceAnnotation]
+}
+
+// pathPrefix returns the path prefix of the route from the Service annotation, or an empty string
+// if the Service is not annotated, or if the annotation value does not start with `/`.
+func pathPrefix(logger logr.Logger, annotations map[string]string) string {
+	prefix := annotations[PathPrefixAnnotation]
+	if prefix != "" && !strings.HasPrefix(prefix, "/") {
+		logger.V(1).Info("Ignoring path prefix annotation that does not start with /", "annotation", PathPrefixAnnotation, "value", prefix)
+		return ""
+	}
+	return prefix
+}
@@ -0,0 +1,191 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"golang.org/x/time/rate"
+	"k8s.io/client-go/util/workqueue"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+)
+
+const (
+	meterName = "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
+	// updateQueueQPS is the sustained rate of application updates passed to the xDS resource cache per kubecontext.
+	updateQueueQPS = 10
+	// updateQueueBurst is the number of application updates passed to the xDS resource cache without delay.
+	updateQueueBurst = 100
+)
+
+// updateQueue passes application updates from informer event handlers to the xDS resource cache
+// in a separate goroutine, so that slow snapshot generation does not block informer processing.
+//
+// The queue is keyed by namespace, and only the most recent applications of a namespace are
+// passed to the xDS resource cache, so bursts of informer events for the same namespace result
+// in one update. The rate of updates is limited by a token bucket.
+//
+// Route updates, namespace deletions, and kubecontext evictions are passed to the sink directly,
+// but they are serialized with the application updates, and deletions and evictions discard the
+// pending application updates that they replace. Otherwise, an application update that was
+// queued before a namespace deletion could add the applications of the namespace back.
+type updateQueue struct {
+	kubecontext string
+	sink        discovery.Sink
+	queue       workqueue.TypedRateLimitingInterface[string]
+	// sinkMu serializes the calls to the sink.
+	sinkMu sync.Mutex
+	// mu guards pending.
+	mu sync.Mutex
+	// pending has the most recent applications for each namespace in the queue.
+	pending map[string]pendingUpdate
+}
+
+type pendingUpdate struct {
+	// ctx is the context of the informer. Updates from stopped informers are discarded.
+	ctx    context.Context
+	logger logr.Logger
+	apps   []applications.Application
+}
+
+// newUpdateQueue creates a queue for the kubecontext, and starts passing updates to the sink
+// until the context is done.
+func newUpdateQueue(ctx context.Context, logger logr.Logger, kubecontext string, sink discovery.Sink) *updateQueue {
+	q := &updateQueue{
+		kubecontext: kubecontext,
+		sink:        sink,
+		queue: workqueue.NewTypedRateLimitingQueueWithConfig[string](
+			&workqueue.TypedBucketRateLimiter[string]{Limiter: rate.NewLimiter(rate.Limit(updateQueueQPS), updateQueueBurst)},
+			workqueue.TypedRateLimitingQueueConfig[string]{}),
+		pending: map[string]pendingUpdate{},
+	}
+	registration, err := q.registerDepthGauge()
+	if err != nil {
+		logger.Error(err, "Could not create gauge for the depth of the informer update queue", "kubecontext", kubecontext)
+	}
+	go func() {
+		<-ctx.Done()
+		q.queue.ShutDown()
+		if registration != nil {
+			if err := registration.Unregister(); err != nil {
+				logger.Error(err, "Could not unregister gauge for the depth of the informer update queue", "kubecontext", kubecontext)
+			}
+		}
+	}()
+	go q.run()
+	return q
+}
+
+// registerDepthGauge reports the number of namespaces with pending updates, using the global MeterProvider.
+func (q *updateQueue) registerDepthGauge() (metric.Registration, error) {
+	meter := otel.Meter(meterName)
+	depth, err := meter.Int64ObservableGauge("xds.informers.update_queue.depth",
+		metric.WithDescription("Number of namespaces with application updates waiting to be passed to the xDS resource cache, by kubecontext."),
+		metric.WithUnit("{namespace}"))
+	if err != nil {
+		return nil, err
+	}
+	attributes := metric.WithAttributes(attribute.String("kubecontext", q.kubecontext))
+	return meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		q.mu.Lock()
+		defer q.mu.Unlock()
+		observer.ObserveInt64(depth, int64(len(q.pending)), attributes)
+		return nil
+	}, depth)
+}
+
+// enqueue replaces the pending applications of the namespace, and adds the namespace to the queue.
+func (q *updateQueue) enqueue(ctx context.Context, logger logr.Logger, namespace string, apps []applications.Application) {
+	q.mu.Lock()
+	if _, exists := q.pending[namespace]; exists {
+		logger.V(4).Info("Replacing pending application update", "namespace", namespace)
+	}
+	q.pending[namespace] = pendingUpdate{
+		ctx:    ctx,
+		logger: logger,
+		apps:   apps,
+	}
+	q.mu.Unlock()
+	q.queue.AddRateLimited(namespace)
+}
+
+// run passes the pending updates to the sink until the queue shuts down.
+func (q *updateQueue) run() {
+	for {
+		namespace, shutdown := q.queue.Get()
+		if shutdown {
+			return
+		}
+		q.process(namespace)
+		q.queue.Done(namespace)
+	}
+}
+
+func (q *updateQueue) process(namespace string) {
+	q.sinkMu.Lock()
+	defer q.sinkMu.Unlock()
+	q.mu.Lock()
+	update, exists := q.pending[namespace]
+	delete(q.pending, namespace)
+	q.mu.Unlock()
+	if !exists {
+		return
+	}
+	if update.ctx.Err() != nil {
+		update.logger.V(2).Info("Ignoring resource update from stopped informer", "apps", update.apps)
+		return
+	}
+	if err := q.sink.UpdateResources(update.ctx, update.logger, q.kubecontext, namespace, update.apps); err != nil {
+		// Can't propagate this error, and we probably shouldn't stop the queue anyway.
+		update.logger.Error(err, "Could not update the xDS resource cache with gRPC application configuration", "apps", update.apps)
+	}
+}
+
+// updateRoutes replaces the routes of the namespace in the sink, after any application update
+// that is in progress.
+func (q *updateQueue) updateRoutes(ctx context.Context, logger logr.Logger, namespace string, routes []applications.Route) error {
+	q.sinkMu.Lock()
+	defer q.sinkMu.Unlock()
+	return q.sink.UpdateRoutes(ctx, logger, q.kubecontext, namespace, routes)
+}
+
+// deleteNamespace discards the pending application update of the namespace, and removes the
+// applications and routes of the namespace from the sink.
+func (q *updateQueue) deleteNamespace(ctx context.Context, logger logr.Logger, namespace string) error {
+	q.sinkMu.Lock()
+	defer q.sinkMu.Unlock()
+	q.mu.Lock()
+	delete(q.pending, namespace)
+	q.mu.Unlock()
+	return q.sink.DeleteNamespace(ctx, logger, q.kubecontext, namespace)
+}
+
+// evictKubecontext discards the pending application updates of all namespaces, and removes all
+// applications and routes of the kubecontext from the sink.
+func (q *updateQueue) evictKubecontext(ctx context.Context, logger logr.Logger) error {
+	q.sinkMu.Lock()
+	defer q.sinkMu.Unlock()
+	q.mu.Lock()
+	clear(q.pending)
+	q.mu.Unlock()
+	return q.sink.EvictKubecontext(ctx, logger, q.kubecontext)
+}
@@ -0,0 +1,188 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
+)
+
+// blockingSink passes the applications of each UpdateResources call to a channel, and then
+// blocks until the release channel is closed.
+type blockingSink struct {
+	discovery.Sink
+	updates chan []applications.Application
+	release chan struct{}
+}
+
+func (s *blockingSink) UpdateResources(_ context.Context, _ logr.Logger, _ string, _ string, apps []applications.Application) error {
+	s.updates <- apps
+	<-s.release
+	return nil
+}
+
+// TestUpdateQueueCoalescesUpdates checks that updates of a namespace that arrive while the sink
+// is busy are replaced by the most recent update.
+func TestUpdateQueueCoalescesUpdates(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	sink := &blockingSink{
+		updates: make(chan []applications.Application),
+		release: make(chan struct{}),
+	}
+	queue := newUpdateQueue(ctx, logr.Discard(), "test", sink)
+	apps := func(name string) []applications.Application {
+		return []applications.Application{{Namespace: testNamespace, Name: name}}
+	}
+
+	queue.enqueue(ctx, logr.Discard(), testNamespace, apps("first"))
+	if got := <-sink.updates; len(got) != 1 || got[0].Name != "first" {
+		t.Errorf("update = %+v, want application first", got)
+	}
+	// The sink is busy with the first update.
+	queue.enqueue(ctx, logr.Discard(), testNamespace, apps("second"))
+	queue.enqueue(ctx, logr.Discard(), testNamespace, apps("third"))
+	close(sink.release)
+	if got := <-sink.updates; len(got) != 1 || got[0].Name != "third" {
+		t.Errorf("update = %+v, want application third", got)
+	}
+	select {
+	case got := <-sink.updates:
+		t.Errorf("unexpected update %+v", got)
+	default:
+	}
+}
+
+// orderedSink records the sink calls in order. UpdateResources blocks until the release channel
+// is closed, after signaling the started channel.
+type orderedSink struct {
+	discovery.Sink
+	mu      sync.Mutex
+	calls   []string
+	started chan struct{}
+	release chan struct{}
+}
+
+func (s *orderedSink) record(call string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.calls = append(s.calls, call)
+}
+
+func (s *orderedSink) UpdateResources(_ context.Context, _ logr.Logger, _ string, namespace string, apps []applications.Application) error {
+	s.started <- struct{}{}
+	<-s.release
+	s.record(fmt.Sprintf("update %s %s", namespace, apps[0].Name))
+	return nil
+}
+
+func (s *orderedSink) UpdateRoutes(_ context.Context, _ logr.Logger, _ string, namespace string, _ []applications.Route) error {
+	s.record("routes " + namespace)
+	return nil
+}
+
+func (s *orderedSink) DeleteNamespace(_ context.Context, _ logr.Logger, _ string, namespace string) error {
+	s.record("delete " + namespace)
+	return nil
+}
+
+func (s *orderedSink) EvictKubecontext(_ context.Context, _ logr.Logger, kubecontext string) error {
+	s.record("evict " + kubecontext)
+	return nil
+}
+
+// TestUpdateQueueSerializesSinkCalls checks that route updates, namespace deletions, and
+// kubecontext evictions wait for the application update in progress, and that deletions and
+// evictions discard the pending updates, so that the applications of deleted namespaces are not
+// added back.
+func TestUpdateQueueSerializesSinkCalls(t *testing.T) {
+	tests := []struct {
+		name string
+		// call deletes the namespace, evicts the kubecontext, or updates the routes.
+		call     func(ctx context.Context, queue *updateQueue) error
+		wantCall string
+		// wantLast is true if no application updates may follow the call.
+		wantLast bool
+	}{
+		{
+			name: "delete namespace",
+			call: func(ctx context.Context, queue *updateQueue) error {
+				return queue.deleteNamespace(ctx, logr.Discard(), testNamespace)
+			},
+			wantCall: "delete " + testNamespace,
+			wantLast: true,
+		},
+		{
+			name: "evict kubecontext",
+			call: func(ctx context.Context, queue *updateQueue) error {
+				return queue.evictKubecontext(ctx, logr.Discard())
+			},
+			wantCall: "evict test",
+			wantLast: true,
+		},
+		{
+			name: "update routes",
+			call: func(ctx context.Context, queue *updateQueue) error {
+				return queue.updateRoutes(ctx, logr.Discard(), testNamespace, nil)
+			},
+			wantCall: "routes " + testNamespace,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			sink := &orderedSink{
+				started: make(chan struct{}, 2),
+				release: make(chan struct{}),
+			}
+			queue := newUpdateQueue(ctx, logr.Discard(), "test", sink)
+			apps := func(name string) []applications.Application {
+				return []applications.Application{{Namespace: testNamespace, Name: name}}
+			}
+			queue.enqueue(ctx, logr.Discard(), testNamespace, apps("first"))
+			<-sink.started
+			// The sink is busy with the first update, so the second update is pending.
+			queue.enqueue(ctx, logr.Discard(), testNamespace, apps("second"))
+			done := make(chan error)
+			go func() {
+				done <- test.call(ctx, queue)
+			}()
+			close(sink.release)
+			if err := <-done; err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			// Wait for the queue to process any remaining update.
+			queue.queue.ShutDownWithDrain()
+			sink.mu.Lock()
+			defer sink.mu.Unlock()
+			index := slices.Index(sink.calls, test.wantCall)
+			if index < 1 || sink.calls[0] != "update "+testNamespace+" first" {
+				t.Errorf("sink calls = %v, want %s after the first update", sink.calls, test.wantCall)
+			}
+			if test.wantLast && index != len(sink.calls)-1 {
+				t.Errorf("sink calls = %v, want no updates after %s", sink.calls, test.wantCall)
+			}
+		})
+	}
+}
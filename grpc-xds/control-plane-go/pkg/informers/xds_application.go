@@ -0,0 +1,195 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-logr/logr"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	discoveryinformers "k8s.io/client-go/informers/discovery/v1"
+	informercache "k8s.io/client-go/tools/cache"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/v1alpha1"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+// AddXDSApplicationInformer watches XDSApplication resources and EndpointSlices in the namespace.
+// Each XDSApplication becomes an application, with endpoints from the EndpointSlices of the
+// Kubernetes Service named in the XDSApplication, and with the routing, TLS, and health checking
+// settings declared in the XDSApplication.
+func (m *Manager) AddXDSApplicationInformer(ctx context.Context, logger logr.Logger, config Config) error {
+	ctx, err := m.informerContext(ctx)
+	if err != nil {
+		return err
+	}
+	logger = logger.WithValues("kubecontext", m.kubecontext, "namespace", config.Namespace)
+	logger.V(2).Info("Creating informers for XDSApplications, EndpointSlices, and Services")
+
+	stop := make(chan struct{})
+	go func() {
+		<-ctx.Done()
+		logger.V(1).Info("Stopping informers for XDSApplications, EndpointSlices, and Services")
+		close(stop)
+	}()
+
+	indexers := informercache.Indexers{informercache.NamespaceIndex: informercache.MetaNamespaceIndexFunc}
+	xdsApplicationInformer := dynamicinformer.NewFilteredDynamicInformer(m.dynamicClient, v1alpha1.XDSApplicationGVR, config.Namespace, 0, indexers, nil).Informer()
+	// Watch the EndpointSlices of all Services in the namespace, since any Service can be named in an XDSApplication.
+	endpointSliceInformer := discoveryinformers.NewFilteredEndpointSliceInformer(m.clientset, config.Namespace, 0, indexers, func(listOptions *metav1.ListOptions) {
+		listOptions.LabelSelector = discoveryv1.LabelServiceName
+	})
+	serviceInformer := newServiceInformer(m.clientset, config.Namespace)
+	pods, podInformer := newPodLabels(m.clientset, config)
+
+	handleEvent := func(event string, obj interface{}) {
+		logger := logger.WithValues("event", event)
+		logEndpointSlice(logger, obj)
+		apps := getAppsForXDSApplications(logger, xdsApplicationInformer, endpointSliceInformer, serviceInformer.GetIndexer(), pods, m.kubecontext)
+		m.handleEndpointSliceEvent(ctx, logger, config, apps)
+	}
+	eventHandler := informercache.ResourceEventHandlerFuncs{
+		AddFunc: func(obj interface{}) {
+			handleEvent("add", obj)
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			handleEvent("update", obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			handleEvent("delete", obj)
+		},
+	}
+	if _, err := xdsApplicationInformer.AddEventHandler(eventHandler); err != nil {
+		return fmt.Errorf("could not add XDSApplication informer event handler for kubecontext=%s namespace=%s: %w", m.kubecontext, config.Namespace, err)
+	}
+	if _, err := endpointSliceInformer.AddEventHandler(eventHandler); err != nil {
+		return fmt.Errorf("could not add EndpointSlice informer event handler for kubecontext=%s namespace=%s: %w", m.kubecontext, config.Namespace, err)
+	}
+	if _, err := serviceInformer.AddEventHandler(eventHandler); err != nil {
+		return fmt.Errorf("could not add Service informer event handler for kubecontext=%s namespace=%s: %w", m.kubecontext, config.Namespace, err)
+	}
+	if podInformer != nil {
+		if err := pods.addEventHandler(podInformer, func(event string) {
+			handleEvent(event, nil)
+		}); err != nil {
+			return fmt.Errorf("could not add Pod informer event handler for kubecontext=%s namespace=%s: %w", m.kubecontext, config.Namespace, err)
+		}
+	}
+	m.addRefresher(ctx, func() {
+		handleEvent("refresh", nil)
+	})
+	go func() {
+		logger.V(2).Info("Starting XDSApplication informer")
+		xdsApplicationInformer.Run(stop)
+	}()
+	go func() {
+		logger.V(2).Info("Starting EndpointSlice informer for XDSApplications")
+		endpointSliceInformer.Run(stop)
+	}()
+	go func() {
+		logger.V(2).Info("Starting Service informer for XDSApplications")
+		serviceInformer.Run(stop)
+	}()
+	if podInformer != nil {
+		go func() {
+			logger.V(2).Info("Starting Pod informer for XDSApplications", "subsetLabels", config.SubsetLabels)
+			podInformer.Run(stop)
+		}()
+	}
+	return nil
+}
+
+// getAppsForXDSApplications creates an application for each XDSApplication that
+// has a Kubernetes Service with EndpointSlices. The health checking settings of the
+// XDSApplication take precedence over the annotations of the Service.
+func getAppsForXDSApplications(logger logr.Logger, xdsApplicationInformer informercache.SharedIndexInformer, endpointSliceInformer informercache.SharedIndexInformer, services informercache.Indexer, pods podLabels, cluster string) []applications.Application {
+	appsByServiceName := map[string][]applications.Application{}
+	for _, app := range getAppsForInformer(logger, endpointSliceInformer, services, pods, cluster) {
+		appsByServiceName[app.Name] = append(appsByServiceName[app.Name], app)
+	}
+	var apps []applications.Application
+	for _, obj := range xdsApplicationInformer.GetIndexer().List() {
+		xdsApplication, err := toXDSApplication(obj)
+		if err != nil {
+			logger.Error(err, "Skipping XDSApplication")
+			continue
+		}
+		serviceApps, exists := appsByServiceName[xdsApplication.ServiceName()]
+		if !exists {
+			logger.V(2).Info("No EndpointSlices for XDSApplication", "name", xdsApplication.Name, "service", xdsApplication.ServiceName())
+			continue
+		}
+		subjectAltNames, err := subjectAltNameMatchers(xdsApplication)
+		if err != nil {
+			logger.Error(err, "Skipping XDSApplication")
+			continue
+		}
+		for _, app := range serviceApps {
+			app.Name = xdsApplication.Name
+			app.ServiceAccountName = xdsApplication.ServiceAccountName()
+			app.SubjectAltNames = subjectAltNames
+			if xdsApplication.Spec.Routing.PathPrefix != "" {
+				app.PathPrefix = xdsApplication.Spec.Routing.PathPrefix
+			}
+			if xdsApplication.Spec.HealthCheck.Port != 0 {
+				app.HealthCheckPort = xdsApplication.Spec.HealthCheck.Port
+			}
+			if xdsApplication.Spec.HealthCheck.Protocol != "" {
+				app.HealthCheckProtocol = xdsApplication.Spec.HealthCheck.Protocol
+			}
+			if xdsApplication.Spec.HealthCheck.GRPCService != "" {
+				app.HealthCheckGRPCService = xdsApplication.Spec.HealthCheck.GRPCService
+			}
+			apps = append(apps, app)
+		}
+	}
+	return apps
+}
+
+// subjectAltNameMatchers converts the SAN matchers of the XDSApplication. Returns an error if any
+// matcher is invalid, since skipping matchers would change the server certificates that clients accept.
+func subjectAltNameMatchers(xdsApplication *v1alpha1.XDSApplication) ([]tls.SubjectAltNameMatcher, error) {
+	var matchers []tls.SubjectAltNameMatcher
+	for _, san := range xdsApplication.Spec.TLS.SubjectAltNames {
+		matcher := tls.SubjectAltNameMatcher{
+			SPIFFEID: san.SPIFFEID,
+			DNS:      san.DNS,
+			Regex:    san.Regex,
+		}
+		if err := matcher.Validate(); err != nil {
+			return nil, fmt.Errorf("invalid subject alternative name matcher of XDSApplication %s/%s: %w", xdsApplication.Namespace, xdsApplication.Name, err)
+		}
+		matchers = append(matchers, matcher)
+	}
+	return matchers, nil
+}
+
+func toXDSApplication(obj interface{}) (*v1alpha1.XDSApplication, error) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return nil, fmt.Errorf("%w: expected *unstructured.Unstructured, got %T", errUnexpectedType, obj)
+	}
+	var xdsApplication v1alpha1.XDSApplication
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(u.UnstructuredContent(), &xdsApplication); err != nil {
+		return nil, fmt.Errorf("could not convert unstructured object %s/%s to XDSApplication: %w", u.GetNamespace(), u.GetName(), err)
+	}
+	return &xdsApplication, nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package informers
+
+import (
+	"testing"
+
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/v1alpha1"
+)
+
+func TestSubjectAltNameMatchers(t *testing.T) {
+	tests := []struct {
+		name            string
+		subjectAltNames []v1alpha1.SubjectAltNameSpec
+		wantMatchers    int
+		wantErr         bool
+	}{
+		{
+			name:            "valid matchers",
+			subjectAltNames: []v1alpha1.SubjectAltNameSpec{{DNS: "greeter-leaf.xds.svc.cluster.local"}, {SPIFFEID: "spiffe://example.org/ns/xds/sa/greeter"}},
+			wantMatchers:    2,
+		},
+		{
+			name:            "rejects the XDSApplication if any matcher is invalid",
+			subjectAltNames: []v1alpha1.SubjectAltNameSpec{{DNS: "greeter-leaf.xds.svc.cluster.local"}, {Regex: "greeter-(.+"}},
+			wantErr:         true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			xdsApplication := &v1alpha1.XDSApplication{
+				ObjectMeta: metav1.ObjectMeta{Namespace: "xds", Name: "greeter-leaf"},
+				Spec: v1alpha1.XDSApplicationSpec{
+					TLS: v1alpha1.TLSSpec{SubjectAltNames: test.subjectAltNames},
+				},
+			}
+			matchers, err := subjectAltNameMatchers(xdsApplication)
+			if (err != nil) != test.wantErr {
+				t.Fatalf("subjectAltNameMatchers() error = %v, wantErr %t", err, test.wantErr)
+			}
+			if len(matchers) != test.wantMatchers {
+				t.Errorf("subjectAltNameMatchers() returned %d matchers, want %d", len(matchers), test.wantMatchers)
+			}
+		})
+	}
+}
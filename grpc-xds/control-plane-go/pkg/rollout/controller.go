@@ -0,0 +1,232 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package rollout shifts traffic from applications to canary applications in steps, by updating
+// the route weights in the xDS resource snapshots, and rolls back if xDS clients reject (NACK)
+// the updated resources, or if the load reports of the canary show too many errors.
+package rollout
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+// routesKubecontextName is the kubecontext name for the routes of canary rollouts in the route
+// cache. Each rollout uses the application name as the namespace, so that rollouts don't replace
+// the routes of other rollouts.
+const routesKubecontextName = "rollout"
+
+// Phase is the state of a canary rollout.
+type Phase string
+
+const (
+	// PhaseProgressing means that the canary receives the percentage of the current step.
+	PhaseProgressing Phase = "Progressing"
+	// PhasePromoted means that the canary received the percentage of the last step for the
+	// duration of the interval without any rollback signals.
+	PhasePromoted Phase = "Promoted"
+	// PhaseRolledBack means that all requests go to the application again.
+	PhaseRolledBack Phase = "RolledBack"
+)
+
+// CanarySpec describes the rollout of a canary for an application.
+type CanarySpec struct {
+	// Application is the name of the application, and of the listener that the xDS clients use.
+	Application string `yaml:"application"`
+	// Canary is the name of the canary application, i.e., the cluster that receives a percentage
+	// of the requests to the application.
+	Canary string `yaml:"canary"`
+	// Steps are the percentages of requests for the canary, in increasing order, e.g.,
+	// `[10, 50, 100]`.
+	Steps []uint32 `yaml:"steps"`
+	// Interval is the duration of each step.
+	Interval time.Duration `yaml:"interval"`
+	// MinSuccessRatio is the minimum ratio of successful requests to the canary in the load
+	// reports of xDS clients, see gRFC A27. Zero disables the check.
+	MinSuccessRatio float64 `yaml:"minSuccessRatio"`
+}
+
+// RouteUpdater updates the routes in the xDS resource snapshots, e.g., `xds.SnapshotCache`.
+type RouteUpdater interface {
+	UpdateRoutes(ctx context.Context, logger logr.Logger, kubecontextName string, namespace string, routes []applications.Route) error
+}
+
+// EDSServiceNamer returns the EDS service names that xDS clients use in load reports for the
+// ClusterLoadAssignment of an application, e.g., `xds.SnapshotCache`.
+type EDSServiceNamer interface {
+	EDSServiceNames(name string) []string
+}
+
+// Controller runs canary rollouts. Each step replaces the RouteConfiguration of the application
+// listener with weighted clusters for the application and the canary, so the method routes and
+// maintenance mode of the application do not apply during the rollout.
+type Controller struct {
+	logger      logr.Logger
+	routes      RouteUpdater
+	loadReports *eds.LoadReports
+	namer       EDSServiceNamer
+	specs       []CanarySpec
+	// nacks counts the NACKs from all xDS clients, see `ObserveNACK()`.
+	nacks atomic.Int64
+}
+
+// NewController creates a controller for the canary rollouts. The load reports provide the
+// success ratios of the canaries, under the EDS service names from the namer.
+func NewController(logger logr.Logger, routes RouteUpdater, loadReports *eds.LoadReports, namer EDSServiceNamer, specs []CanarySpec) *Controller {
+	return &Controller{
+		logger:      logger,
+		routes:      routes,
+		loadReports: loadReports,
+		namer:       namer,
+		specs:       specs,
+	}
+}
+
+// ObserveNACK records that an xDS client rejected (NACKed) a response. Clients don't identify the
+// rejected resource, so a NACK of any resource type during a step rolls back all rollouts in
+// progress. A nil Controller ignores NACKs.
+func (c *Controller) ObserveNACK(typeURL string) {
+	if c == nil {
+		return
+	}
+	c.logger.V(2).Info("Observed NACK for canary rollouts", "typeUrl", typeURL)
+	c.nacks.Add(1)
+}
+
+// Run runs the canary rollouts until they are promoted or rolled back, or until the context is
+// done. Run blocks, so call it in a goroutine.
+func (c *Controller) Run(ctx context.Context) {
+	done := make(chan struct{}, len(c.specs))
+	for _, spec := range c.specs {
+		go func(spec CanarySpec) {
+			defer func() { done <- struct{}{} }()
+			c.run(ctx, spec)
+		}(spec)
+	}
+	for range c.specs {
+		<-done
+	}
+}
+
+func (c *Controller) run(ctx context.Context, spec CanarySpec) {
+	r := &rollout{controller: c, spec: spec}
+	if err := r.start(ctx); err != nil {
+		c.logger.Error(err, "Could not start canary rollout", "application", spec.Application, "canary", spec.Canary)
+		return
+	}
+	ticker := time.NewTicker(spec.Interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			phase, err := r.advance(ctx)
+			if err != nil {
+				c.logger.Error(err, "Could not advance canary rollout", "application", spec.Application, "canary", spec.Canary)
+			}
+			if phase != PhaseProgressing {
+				return
+			}
+		}
+	}
+}
+
+// rollout is the state of the rollout of one canary.
+type rollout struct {
+	controller *Controller
+	spec       CanarySpec
+	// step is the index of the current step.
+	step int
+	// nacksAtStep is the NACK count when the current step started.
+	nacksAtStep int64
+}
+
+// start sends the percentage of the first step to the canary.
+func (r *rollout) start(ctx context.Context) error {
+	r.step = 0
+	return r.applyStep(ctx)
+}
+
+// advance checks the rollback signals of the current step, and then either rolls back, moves on
+// to the next step, or promotes the canary after the last step.
+func (r *rollout) advance(ctx context.Context) (Phase, error) {
+	logger := r.controller.logger.WithValues("application", r.spec.Application, "canary", r.spec.Canary, "percent", r.spec.Steps[r.step])
+	if nacks := r.controller.nacks.Load() - r.nacksAtStep; nacks > 0 {
+		logger.Info("Rolling back canary, xDS clients rejected resources", "nacks", nacks)
+		return r.rollback(ctx)
+	}
+	edsServiceNames := r.controller.namer.EDSServiceNames(r.spec.Canary)
+	successRatio, exists := r.controller.loadReports.ServiceSuccessRatio(edsServiceNames...)
+	if exists && successRatio < r.spec.MinSuccessRatio {
+		logger.Info("Rolling back canary, success ratio below minimum", "successRatio", successRatio, "minSuccessRatio", r.spec.MinSuccessRatio)
+		return r.rollback(ctx)
+	}
+	if !exists && r.spec.MinSuccessRatio > 0 {
+		// E.g., if a feature override or a config reload turned off the `loadReport` locality weight policy.
+		logger.Info("Warning: no load reports for the canary, skipping the success ratio check", "edsServiceNames", edsServiceNames, "minSuccessRatio", r.spec.MinSuccessRatio)
+	}
+	if r.step == len(r.spec.Steps)-1 {
+		logger.Info("Promoted canary")
+		return PhasePromoted, nil
+	}
+	r.step++
+	if err := r.applyStep(ctx); err != nil {
+		return PhaseProgressing, err
+	}
+	return PhaseProgressing, nil
+}
+
+// applyStep updates the route weights for the percentage of the current step.
+func (r *rollout) applyStep(ctx context.Context) error {
+	percent := r.spec.Steps[r.step]
+	r.controller.logger.V(2).Info("Canary rollout step", "application", r.spec.Application, "canary", r.spec.Canary, "step", r.step, "percent", percent)
+	r.nacksAtStep = r.controller.nacks.Load()
+	route := applications.Route{
+		Namespace: r.spec.Application,
+		Name:      r.spec.Application + "-canary",
+		Hostnames: []string{r.spec.Application},
+		Rules: []applications.RouteRule{
+			{
+				Backends: []applications.RouteBackend{
+					{Name: r.spec.Application, Weight: 100 - percent},
+					{Name: r.spec.Canary, Weight: percent},
+				},
+			},
+		},
+	}
+	if percent == 100 {
+		route.Rules[0].Backends = route.Rules[0].Backends[1:]
+	}
+	if err := r.controller.routes.UpdateRoutes(ctx, r.controller.logger, routesKubecontextName, r.spec.Application, []applications.Route{route}); err != nil {
+		return fmt.Errorf("could not update routes for canary=%s of application=%s at percent=%d: %w", r.spec.Canary, r.spec.Application, percent, err)
+	}
+	return nil
+}
+
+// rollback removes the route of the rollout, so that the application uses its own
+// RouteConfiguration again.
+func (r *rollout) rollback(ctx context.Context) (Phase, error) {
+	if err := r.controller.routes.UpdateRoutes(ctx, r.controller.logger, routesKubecontextName, r.spec.Application, nil); err != nil {
+		return PhaseRolledBack, fmt.Errorf("could not remove routes for canary=%s of application=%s: %w", r.spec.Canary, r.spec.Application, err)
+	}
+	return PhaseRolledBack, nil
+}
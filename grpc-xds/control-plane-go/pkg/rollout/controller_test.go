@@ -0,0 +1,136 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rollout
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+const testAuthority = "xds-authority.example.com"
+
+// fakeRouteUpdater records the canary weights of the most recent route update.
+type fakeRouteUpdater struct {
+	weights map[string]uint32
+}
+
+func (f *fakeRouteUpdater) UpdateRoutes(_ context.Context, _ logr.Logger, _ string, _ string, routes []applications.Route) error {
+	f.weights = map[string]uint32{}
+	for _, route := range routes {
+		for _, backend := range route.Rules[0].Backends {
+			f.weights[backend.Name] = backend.Weight
+		}
+	}
+	return nil
+}
+
+func TestRolloutAdvance(t *testing.T) {
+	spec := CanarySpec{
+		Application:     "greeter-leaf",
+		Canary:          "greeter-leaf-canary",
+		Steps:           []uint32{10, 50, 100},
+		Interval:        time.Minute,
+		MinSuccessRatio: 0.9,
+	}
+	tests := []struct {
+		name string
+		// signal is called after the first step is applied.
+		signal      func(c *Controller, loadReports *eds.LoadReports)
+		wantPhases  []Phase
+		wantWeights map[string]uint32
+	}{
+		{
+			name:        "promotes canary without rollback signals",
+			signal:      func(_ *Controller, _ *eds.LoadReports) {},
+			wantPhases:  []Phase{PhaseProgressing, PhaseProgressing, PhasePromoted},
+			wantWeights: map[string]uint32{"greeter-leaf-canary": 100},
+		},
+		{
+			name: "rolls back on NACK",
+			signal: func(c *Controller, _ *eds.LoadReports) {
+				c.ObserveNACK("type.googleapis.com/envoy.config.route.v3.RouteConfiguration")
+			},
+			wantPhases:  []Phase{PhaseRolledBack},
+			wantWeights: map[string]uint32{},
+		},
+		{
+			name: "rolls back on low success ratio",
+			signal: func(_ *Controller, loadReports *eds.LoadReports) {
+				loadReports.Add("greeter-leaf-canary", eds.Locality{Zone: "us-central1-a"}, 80, 20)
+			},
+			wantPhases:  []Phase{PhaseRolledBack},
+			wantWeights: map[string]uint32{},
+		},
+		{
+			name: "rolls back on low success ratio with xDS federation",
+			signal: func(_ *Controller, loadReports *eds.LoadReports) {
+				// gRPC clients using xDS federation report loads under the `xdstp://` name.
+				loadReports.Add("xdstp://"+testAuthority+"/envoy.config.endpoint.v3.ClusterLoadAssignment/greeter-leaf-canary", eds.Locality{Zone: "us-central1-a"}, 80, 20)
+			},
+			wantPhases:  []Phase{PhaseRolledBack},
+			wantWeights: map[string]uint32{},
+		},
+		{
+			name: "ignores errors of other clusters",
+			signal: func(_ *Controller, loadReports *eds.LoadReports) {
+				loadReports.Add("greeter-leaf", eds.Locality{Zone: "us-central1-a"}, 0, 100)
+				loadReports.Add("greeter-leaf-canary", eds.Locality{Zone: "us-central1-a"}, 95, 5)
+			},
+			wantPhases:  []Phase{PhaseProgressing, PhaseProgressing, PhasePromoted},
+			wantWeights: map[string]uint32{"greeter-leaf-canary": 100},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := context.Background()
+			routes := &fakeRouteUpdater{}
+			loadReports := eds.NewLoadReports()
+			namer := xds.NewSnapshotCache(ctx, xds.ZoneHash{}, eds.LocalityPriorityByZone{}, &xds.Features{EnableFederation: true}, testAuthority)
+			c := NewController(logr.Discard(), routes, loadReports, namer, []CanarySpec{spec})
+			r := &rollout{controller: c, spec: spec}
+			if err := r.start(ctx); err != nil {
+				t.Fatalf("could not start rollout: %v", err)
+			}
+			if routes.weights["greeter-leaf"] != 90 || routes.weights["greeter-leaf-canary"] != 10 {
+				t.Errorf("first step weights = %v, want 90 and 10", routes.weights)
+			}
+			test.signal(c, loadReports)
+			for i, wantPhase := range test.wantPhases {
+				phase, err := r.advance(ctx)
+				if err != nil {
+					t.Fatalf("could not advance rollout: %v", err)
+				}
+				if phase != wantPhase {
+					t.Errorf("phase after advance %d = %s, want %s", i, phase, wantPhase)
+				}
+			}
+			if len(routes.weights) != len(test.wantWeights) {
+				t.Errorf("weights = %v, want %v", routes.weights, test.wantWeights)
+			}
+			for name, weight := range test.wantWeights {
+				if routes.weights[name] != weight {
+					t.Errorf("weights = %v, want %v", routes.weights, test.wantWeights)
+				}
+			}
+		})
+	}
+}
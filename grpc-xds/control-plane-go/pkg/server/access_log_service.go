@@ -0,0 +1,101 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	datav3 "github.com/envoyproxy/go-control-plane/envoy/data/accesslog/v3"
+	accesslogv3 "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v3"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// accessLogService receives access logs from Envoy proxies, using the gRPC Access Log Service (ALS).
+// The service logs each HTTP access log entry, and counts the entries by node ID, log name, and
+// response code, as an OpenTelemetry counter.
+type accessLogService struct {
+	accesslogv3.UnimplementedAccessLogServiceServer
+	logger  logr.Logger
+	entries metric.Int64Counter
+}
+
+var _ accesslogv3.AccessLogServiceServer = &accessLogService{}
+
+// newAccessLogService creates the access log entry counter, using the global MeterProvider.
+func newAccessLogService(logger logr.Logger) (*accessLogService, error) {
+	entries, err := otel.Meter(meterName).Int64Counter("envoy.access_logs",
+		metric.WithDescription("Number of HTTP access log entries received from Envoy proxies, by node ID, log name, and response code."),
+		metric.WithUnit("{entry}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create access log entries counter: %w", err)
+	}
+	return &accessLogService{
+		logger:  logger.WithName("accesslog"),
+		entries: entries,
+	}, nil
+}
+
+// StreamAccessLogs receives access log entries until the Envoy proxy closes the stream.
+// Only the first message on a stream includes the identifier of the node and the log name.
+func (s *accessLogService) StreamAccessLogs(stream accesslogv3.AccessLogService_StreamAccessLogsServer) error {
+	var nodeID, logName string
+	for {
+		message, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return stream.SendAndClose(&accesslogv3.StreamAccessLogsResponse{})
+		}
+		if err != nil {
+			return fmt.Errorf("could not receive access log message: %w", err)
+		}
+		if identifier := message.GetIdentifier(); identifier != nil {
+			nodeID = identifier.GetNode().GetId()
+			logName = identifier.GetLogName()
+		}
+		for _, entry := range message.GetHttpLogs().GetLogEntry() {
+			s.record(stream, nodeID, logName, entry)
+		}
+		if tcpLogs := message.GetTcpLogs().GetLogEntry(); len(tcpLogs) > 0 {
+			s.logger.V(4).Info("Ignoring TCP access log entries", "nodeId", nodeID, "logName", logName, "entries", len(tcpLogs))
+		}
+	}
+}
+
+func (s *accessLogService) record(stream accesslogv3.AccessLogService_StreamAccessLogsServer, nodeID string, logName string, entry *datav3.HTTPAccessLogEntry) {
+	common := entry.GetCommonProperties()
+	request := entry.GetRequest()
+	responseCode := entry.GetResponse().GetResponseCode().GetValue()
+	s.logger.Info("AccessLog",
+		"nodeId", nodeID,
+		"logName", logName,
+		"startTime", common.GetStartTime().AsTime(),
+		"method", request.GetRequestMethod().String(),
+		"authority", request.GetAuthority(),
+		"path", request.GetPath(),
+		"responseCode", responseCode,
+		"duration", common.GetDuration().AsDuration(),
+		"downstreamRemoteAddress", common.GetDownstreamRemoteAddress().GetSocketAddress().GetAddress(),
+		"upstreamCluster", common.GetUpstreamCluster(),
+		"upstreamRemoteAddress", common.GetUpstreamRemoteAddress().GetSocketAddress().GetAddress())
+	s.entries.Add(stream.Context(), 1, metric.WithAttributes(
+		attribute.String("node_id", nodeID),
+		attribute.String("log_name", logName),
+		attribute.String("response_code", strconv.FormatUint(uint64(responseCode), 10))))
+}
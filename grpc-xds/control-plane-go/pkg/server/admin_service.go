@@ -0,0 +1,171 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"errors"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gopkg.in/yaml.v3"
+
+	adminpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/admin"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+// adminService implements the ControlPlaneAdmin gRPC service, which lets workshop facilitators
+// inspect and change the state of the control plane at runtime. Register this service on the
+// health port only, since the serving port is reachable by all xDS clients. The health port is
+// plaintext and does not authenticate callers, so the requests that change the state of the
+// control plane are rejected unless writes are enabled, see `config.Server.EnableAdminWrites`.
+type adminService struct {
+	adminpb.UnimplementedControlPlaneAdminServer
+	logger   logr.Logger
+	xdsCache *xds.SnapshotCache
+	// reloader applies feature flag changes the same way as changes to the config file, so that
+	// flags that require a restart keep their values from startup.
+	reloader      *configReloader
+	writesEnabled bool
+}
+
+var _ adminpb.ControlPlaneAdminServer = &adminService{}
+
+func newAdminService(logger logr.Logger, xdsCache *xds.SnapshotCache, reloader *configReloader, writesEnabled bool) *adminService {
+	return &adminService{
+		logger:        logger.WithName("admin"),
+		xdsCache:      xdsCache,
+		reloader:      reloader,
+		writesEnabled: writesEnabled,
+	}
+}
+
+// errAdminWritesDisabled is returned for requests that change the state of the control plane,
+// unless writes are enabled.
+var errAdminWritesDisabled = status.Error(codes.PermissionDenied, "admin requests that change the control plane are disabled, enable them with -enable-admin-writes (env ENABLE_ADMIN_WRITES)")
+
+func (s *adminService) ListNodeHashes(_ context.Context, _ *adminpb.ListNodeHashesRequest) (*adminpb.ListNodeHashesResponse, error) {
+	return &adminpb.ListNodeHashesResponse{
+		NodeHashes: s.xdsCache.NodeHashes(),
+	}, nil
+}
+
+func (s *adminService) DumpSnapshot(_ context.Context, request *adminpb.DumpSnapshotRequest) (*adminpb.DumpSnapshotResponse, error) {
+	snapshot, err := s.xdsCache.Snapshot(request.GetNodeHash())
+	if err != nil {
+		return nil, status.Errorf(codes.NotFound, "no snapshot for node hash %s: %v", request.GetNodeHash(), err)
+	}
+	resourcesJSON, err := xds.MarshalSnapshotJSON(snapshot)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not marshal snapshot for node hash %s: %v", request.GetNodeHash(), err)
+	}
+	return &adminpb.DumpSnapshotResponse{
+		Version:       snapshot.GetVersion(""),
+		ResourcesJson: string(resourcesJSON),
+	}, nil
+}
+
+func (s *adminService) RegenerateSnapshots(ctx context.Context, _ *adminpb.RegenerateSnapshotsRequest) (*adminpb.RegenerateSnapshotsResponse, error) {
+	if !s.writesEnabled {
+		return nil, errAdminWritesDisabled
+	}
+	s.logger.Info("Regenerating xDS resource snapshots")
+	if err := s.xdsCache.RegenerateSnapshots(ctx, s.logger); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not regenerate snapshots: %v", err)
+	}
+	return &adminpb.RegenerateSnapshotsResponse{
+		NodeHashes: s.xdsCache.NodeHashes(),
+	}, nil
+}
+
+func (s *adminService) SetFeatureFlag(ctx context.Context, request *adminpb.SetFeatureFlagRequest) (*adminpb.SetFeatureFlagResponse, error) {
+	if !s.writesEnabled {
+		return nil, errAdminWritesDisabled
+	}
+	xdsFeatures, err := config.SetXDSFeatureFlag(s.xdsCache.Features(), request.GetName(), request.GetValue())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "could not set xDS feature flag %s: %v", request.GetName(), err)
+	}
+	s.logger.Info("Setting xDS feature flag", "name", request.GetName(), "value", request.GetValue())
+	if err := s.reloader.ReloadXDSFeatures(ctx, s.logger, xdsFeatures); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not apply xDS feature flag %s: %v", request.GetName(), err)
+	}
+	featuresYAML, err := yaml.Marshal(s.xdsCache.Features())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not marshal xDS feature flags: %v", err)
+	}
+	return &adminpb.SetFeatureFlagResponse{
+		FeaturesYaml: string(featuresYAML),
+	}, nil
+}
+
+func (s *adminService) SetMaintenanceMode(ctx context.Context, request *adminpb.SetMaintenanceModeRequest) (*adminpb.SetMaintenanceModeResponse, error) {
+	if !s.writesEnabled {
+		return nil, errAdminWritesDisabled
+	}
+	appName := request.GetApplication()
+	if appName == "" {
+		return nil, status.Error(codes.InvalidArgument, "application is required")
+	}
+	if request.GetEnabled() {
+		if _, err := xds.MaintenanceStatusCode(request.GetStatusCode()); err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid status code %s: %v", request.GetStatusCode(), err)
+		}
+		s.logger.Info("Setting maintenance mode", "app", appName, "statusCode", request.GetStatusCode())
+		if err := s.xdsCache.SetMaintenanceMode(ctx, s.logger, appName, request.GetStatusCode()); err != nil {
+			return nil, status.Errorf(codes.Internal, "could not set maintenance mode for application %s: %v", appName, err)
+		}
+	} else {
+		s.logger.Info("Clearing maintenance mode", "app", appName)
+		if err := s.xdsCache.ClearMaintenanceMode(ctx, s.logger, appName); err != nil {
+			return nil, status.Errorf(codes.Internal, "could not clear maintenance mode for application %s: %v", appName, err)
+		}
+	}
+	return &adminpb.SetMaintenanceModeResponse{
+		MaintenanceApplications: s.xdsCache.MaintenanceApplications(),
+	}, nil
+}
+
+// SwitchBlueGreen switches the application to the target application, and logs an audit log entry
+// with the peer address of the caller for each successful switchover.
+func (s *adminService) SwitchBlueGreen(ctx context.Context, request *adminpb.SwitchBlueGreenRequest) (*adminpb.SwitchBlueGreenResponse, error) {
+	if !s.writesEnabled {
+		return nil, errAdminWritesDisabled
+	}
+	appName := request.GetApplication()
+	target := request.GetTarget()
+	if appName == "" || target == "" {
+		return nil, status.Error(codes.InvalidArgument, "application and target are required")
+	}
+	previousTarget, err := s.xdsCache.SwitchBlueGreen(ctx, s.logger, appName, target)
+	if errors.Is(err, xds.ErrInvalidBlueGreenTarget) {
+		return nil, status.Error(codes.FailedPrecondition, err.Error())
+	}
+	if err != nil {
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+	var peerAddress string
+	if p, ok := peer.FromContext(ctx); ok {
+		peerAddress = p.Addr.String()
+	}
+	s.logger.Info("Audit: blue/green switchover", "app", appName, "previousTarget", previousTarget, "target", target, "peer", peerAddress)
+	return &adminpb.SwitchBlueGreenResponse{
+		PreviousTarget: previousTarget,
+		Targets:        s.xdsCache.BlueGreenTargets(),
+	}, nil
+}
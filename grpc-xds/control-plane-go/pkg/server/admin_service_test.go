@@ -0,0 +1,87 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	adminpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/admin"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+func TestAdminServiceWritesEnabled(t *testing.T) {
+	// writes calls the requests that change the control plane, and returns the status code of each.
+	writes := func(ctx context.Context, s *adminService) map[string]codes.Code {
+		_, regenerateErr := s.RegenerateSnapshots(ctx, &adminpb.RegenerateSnapshotsRequest{})
+		_, maintenanceErr := s.SetMaintenanceMode(ctx, &adminpb.SetMaintenanceModeRequest{Application: "greeter-leaf", Enabled: true})
+		_, switchErr := s.SwitchBlueGreen(ctx, &adminpb.SwitchBlueGreenRequest{Application: "greeter-leaf", Target: "greeter-leaf-green"})
+		_, featureFlagErr := s.SetFeatureFlag(ctx, &adminpb.SetFeatureFlagRequest{Name: "unknownFlag", Value: "true"})
+		return map[string]codes.Code{
+			"RegenerateSnapshots": status.Code(regenerateErr),
+			"SetMaintenanceMode":  status.Code(maintenanceErr),
+			"SwitchBlueGreen":     status.Code(switchErr),
+			"SetFeatureFlag":      status.Code(featureFlagErr),
+		}
+	}
+	tests := []struct {
+		name          string
+		writesEnabled bool
+		want          map[string]codes.Code
+	}{
+		{
+			name:          "writes disabled by default",
+			writesEnabled: false,
+			want: map[string]codes.Code{
+				"RegenerateSnapshots": codes.PermissionDenied,
+				"SetMaintenanceMode":  codes.PermissionDenied,
+				"SwitchBlueGreen":     codes.PermissionDenied,
+				"SetFeatureFlag":      codes.PermissionDenied,
+			},
+		},
+		{
+			name:          "writes enabled",
+			writesEnabled: true,
+			want: map[string]codes.Code{
+				"RegenerateSnapshots": codes.OK,
+				"SetMaintenanceMode":  codes.OK,
+				"SwitchBlueGreen":     codes.FailedPrecondition,
+				"SetFeatureFlag":      codes.InvalidArgument,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := logging.NewContext(context.Background(), logr.Discard())
+			xdsCache := xds.NewSnapshotCache(ctx, xds.ZoneHash{}, eds.LocalityPriorityByZone{}, &xds.Features{}, "xds-authority.example.com")
+			s := newAdminService(logr.Discard(), xdsCache, nil, test.writesEnabled)
+			got := writes(ctx, s)
+			for method, want := range test.want {
+				if got[method] != want {
+					t.Errorf("%s() code = %s, want %s", method, got[method], want)
+				}
+			}
+			if _, err := s.ListNodeHashes(ctx, &adminpb.ListNodeHashesRequest{}); err != nil {
+				t.Errorf("ListNodeHashes() error = %v, want nil", err)
+			}
+		})
+	}
+}
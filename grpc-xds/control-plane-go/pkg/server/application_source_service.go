@@ -0,0 +1,129 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	sourcepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/source"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+// sourceKubecontextPrefix is prepended to source names in the application cache, so that the
+// applications of a source cannot replace or evict the applications of a kubecontext.
+const sourceKubecontextPrefix = "source:"
+
+// applicationSourceService implements the ApplicationSource gRPC service, which lets external
+// programs push applications into the control plane, bypassing the Kubernetes informers.
+// Register this service on the health port only, since the serving port is reachable by all
+// xDS clients. The health port is plaintext and does not authenticate callers, so the service
+// rejects all requests unless it is enabled, see `config.Server.EnableApplicationSource`.
+type applicationSourceService struct {
+	sourcepb.UnimplementedApplicationSourceServer
+	logger   logr.Logger
+	xdsCache *xds.SnapshotCache
+	enabled  bool
+}
+
+var _ sourcepb.ApplicationSourceServer = &applicationSourceService{}
+
+func newApplicationSourceService(logger logr.Logger, xdsCache *xds.SnapshotCache, enabled bool) *applicationSourceService {
+	return &applicationSourceService{
+		logger:   logger.WithName("source"),
+		xdsCache: xdsCache,
+		enabled:  enabled,
+	}
+}
+
+// errApplicationSourceDisabled is returned for all requests unless the service is enabled.
+var errApplicationSourceDisabled = status.Error(codes.PermissionDenied, "the application source service is disabled, enable it with -enable-application-source (env ENABLE_APPLICATION_SOURCE)")
+
+func (s *applicationSourceService) PutApplications(ctx context.Context, request *sourcepb.PutApplicationsRequest) (*sourcepb.PutApplicationsResponse, error) {
+	if !s.enabled {
+		return nil, errApplicationSourceDisabled
+	}
+	if request.GetSource() == "" || request.GetNamespace() == "" {
+		return nil, status.Error(codes.InvalidArgument, "source and namespace are required")
+	}
+	apps := make([]applications.Application, 0, len(request.GetApplications()))
+	for _, app := range request.GetApplications() {
+		staticApp := staticApplication(request.GetSource(), request.GetNamespace(), app)
+		converted, err := staticApp.Application()
+		if err != nil {
+			return nil, status.Errorf(codes.InvalidArgument, "invalid application %s: %v", app.GetName(), err)
+		}
+		apps = append(apps, converted)
+	}
+	s.logger.V(2).Info("Putting applications", "source", request.GetSource(), "namespace", request.GetNamespace(), "apps", apps)
+	if err := s.xdsCache.UpdateResources(ctx, s.logger, sourceKubecontextPrefix+request.GetSource(), request.GetNamespace(), apps); err != nil {
+		return nil, status.Errorf(codes.Internal, "could not put applications of source %s in namespace %s: %v", request.GetSource(), request.GetNamespace(), err)
+	}
+	return &sourcepb.PutApplicationsResponse{}, nil
+}
+
+func (s *applicationSourceService) DeleteApplications(ctx context.Context, request *sourcepb.DeleteApplicationsRequest) (*sourcepb.DeleteApplicationsResponse, error) {
+	if !s.enabled {
+		return nil, errApplicationSourceDisabled
+	}
+	if request.GetSource() == "" {
+		return nil, status.Error(codes.InvalidArgument, "source is required")
+	}
+	s.logger.V(2).Info("Deleting applications", "source", request.GetSource(), "namespace", request.GetNamespace())
+	var err error
+	if request.GetNamespace() == "" {
+		err = s.xdsCache.EvictKubecontext(ctx, s.logger, sourceKubecontextPrefix+request.GetSource())
+	} else {
+		err = s.xdsCache.DeleteNamespace(ctx, s.logger, sourceKubecontextPrefix+request.GetSource(), request.GetNamespace())
+	}
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "could not delete applications of source %s: %v", request.GetSource(), err)
+	}
+	return &sourcepb.DeleteApplicationsResponse{}, nil
+}
+
+// staticApplication converts the application message to a static application, to use the same
+// validation and conversion as the applications in the `fallback.yaml` config file.
+func staticApplication(source string, namespace string, app *sourcepb.Application) config.StaticApplication {
+	staticApp := config.StaticApplication{
+		Context:                source,
+		Namespace:              namespace,
+		Name:                   app.GetName(),
+		Authority:              app.GetAuthority(),
+		PathPrefix:             app.GetPathPrefix(),
+		ServingPort:            app.GetServingPort(),
+		ServingProtocol:        app.GetServingProtocol(),
+		HealthCheckPort:        app.GetHealthCheckPort(),
+		HealthCheckProtocol:    app.GetHealthCheckProtocol(),
+		HealthCheckGRPCService: app.GetHealthCheckGrpcService(),
+	}
+	for _, endpoint := range app.GetEndpoints() {
+		staticApp.Endpoints = append(staticApp.Endpoints, config.StaticApplicationEndpoint{
+			Pod:         endpoint.GetPod(),
+			Node:        endpoint.GetNode(),
+			Zone:        endpoint.GetZone(),
+			Addresses:   endpoint.GetAddresses(),
+			AddressType: endpoint.GetAddressType(),
+			Status:      endpoint.GetStatus(),
+			Labels:      endpoint.GetLabels(),
+		})
+	}
+	return staticApp
+}
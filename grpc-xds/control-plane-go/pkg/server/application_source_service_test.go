@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	sourcepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/source"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+func TestApplicationSourceServiceEnabled(t *testing.T) {
+	putRequest := &sourcepb.PutApplicationsRequest{
+		Source:    "ci",
+		Namespace: "xds",
+		Applications: []*sourcepb.Application{
+			{
+				Name:        "greeter-static",
+				ServingPort: 50051,
+				Endpoints:   []*sourcepb.Endpoint{{Zone: "us-central1-a", Addresses: []string{"10.0.0.10"}}},
+			},
+		},
+	}
+	deleteRequest := &sourcepb.DeleteApplicationsRequest{Source: "ci"}
+	tests := []struct {
+		name     string
+		enabled  bool
+		wantCode codes.Code
+	}{
+		{
+			name:     "disabled by default",
+			enabled:  false,
+			wantCode: codes.PermissionDenied,
+		},
+		{
+			name:     "enabled",
+			enabled:  true,
+			wantCode: codes.OK,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := logging.NewContext(context.Background(), logr.Discard())
+			xdsCache := xds.NewSnapshotCache(ctx, xds.ZoneHash{}, eds.LocalityPriorityByZone{}, &xds.Features{}, "xds-authority.example.com")
+			s := newApplicationSourceService(logr.Discard(), xdsCache, test.enabled)
+			if _, err := s.PutApplications(ctx, putRequest); status.Code(err) != test.wantCode {
+				t.Errorf("PutApplications() code = %s, want %s (err=%v)", status.Code(err), test.wantCode, err)
+			}
+			if _, err := s.DeleteApplications(ctx, deleteRequest); status.Code(err) != test.wantCode {
+				t.Errorf("DeleteApplications() code = %s, want %s (err=%v)", status.Code(err), test.wantCode, err)
+			}
+			// Validation only happens for enabled services.
+			if _, err := s.PutApplications(ctx, &sourcepb.PutApplicationsRequest{}); test.enabled && status.Code(err) != codes.InvalidArgument {
+				t.Errorf("PutApplications() without source code = %s, want %s", status.Code(err), codes.InvalidArgument)
+			}
+		})
+	}
+}
@@ -0,0 +1,181 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr/funcr"
+)
+
+func TestEarliestCertificateExpiry(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	tests := []struct {
+		name string
+		// notAfters are the expiry times of the certificates, with subjects `CN=cert-<index>`.
+		notAfters []time.Time
+		// withPrivateKey adds a private key PEM block, which is not a certificate.
+		withPrivateKey bool
+		want           certificateExpiry
+		wantErr        error
+	}{
+		{
+			name:      "one certificate",
+			notAfters: []time.Time{now.Add(time.Hour)},
+			want:      certificateExpiry{Subject: "CN=cert-0", NotAfter: now.Add(time.Hour)},
+		},
+		{
+			name:           "certificate chain and private key",
+			notAfters:      []time.Time{now.Add(24 * time.Hour), now.Add(time.Hour), now.Add(48 * time.Hour)},
+			withPrivateKey: true,
+			want:           certificateExpiry{Subject: "CN=cert-1", NotAfter: now.Add(time.Hour)},
+		},
+		{
+			name:      "expired certificate",
+			notAfters: []time.Time{now.Add(time.Hour), now.Add(-time.Hour)},
+			want:      certificateExpiry{Subject: "CN=cert-1", NotAfter: now.Add(-time.Hour)},
+		},
+		{
+			name:           "no certificates",
+			withPrivateKey: true,
+			wantErr:        errNoCertificates,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := writeCertificateFile(t, test.notAfters, test.withPrivateKey)
+			got, err := earliestCertificateExpiry(file)
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("earliestCertificateExpiry() unexpected error: %v", err)
+			}
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("earliestCertificateExpiry() error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if got.Subject != test.want.Subject || !got.NotAfter.Equal(test.want.NotAfter) {
+				t.Errorf("earliestCertificateExpiry() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCertificateExpiryMonitorPoll(t *testing.T) {
+	now := time.Now()
+	tests := []struct {
+		name      string
+		notAfter  time.Time
+		threshold time.Duration
+		wantWarn  bool
+	}{
+		{
+			name:      "expires after the threshold",
+			notAfter:  now.Add(48 * time.Hour),
+			threshold: 24 * time.Hour,
+			wantWarn:  false,
+		},
+		{
+			name:      "expires within the threshold",
+			notAfter:  now.Add(time.Hour),
+			threshold: 24 * time.Hour,
+			wantWarn:  true,
+		},
+		{
+			name:      "zero threshold disables warnings",
+			notAfter:  now.Add(time.Hour),
+			threshold: 0,
+			wantWarn:  false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var logs []string
+			logger := funcr.New(func(_, args string) { logs = append(logs, args) }, funcr.Options{})
+			file := writeCertificateFile(t, []time.Time{test.notAfter}, false)
+			missingFile := filepath.Join(t.TempDir(), "missing.pem")
+			m := &certificateExpiryMonitor{
+				logger:    logger,
+				files:     []string{file, missingFile},
+				threshold: test.threshold,
+				expiries:  map[string]certificateExpiry{},
+			}
+			m.poll()
+			expiries := m.snapshot()
+			if len(expiries) != 1 {
+				t.Fatalf("expiries = %+v, want only the expiry of %s", expiries, file)
+			}
+			if expiry := expiries[file]; !expiry.NotAfter.Equal(test.notAfter.Truncate(time.Second)) {
+				t.Errorf("expiry of %s = %s, want %s", file, expiry.NotAfter, test.notAfter.Truncate(time.Second))
+			}
+			warned := false
+			for _, log := range logs {
+				warned = warned || strings.Contains(log, "Warning: certificate expires soon")
+			}
+			if warned != test.wantWarn {
+				t.Errorf("warned = %t, want %t, logs: %v", warned, test.wantWarn, logs)
+			}
+		})
+	}
+}
+
+// writeCertificateFile writes self-signed certificates with the expiry times, and optionally
+// a private key, to a PEM file in a temporary directory, and returns the file path.
+func writeCertificateFile(t *testing.T, notAfters []time.Time, withPrivateKey bool) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate private key: %v", err)
+	}
+	var pemBytes []byte
+	for i, notAfter := range notAfters {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 1)),
+			Subject:      pkix.Name{CommonName: "cert-" + strconv.Itoa(i)},
+			NotBefore:    notAfter.Add(-48 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("could not create certificate: %v", err)
+		}
+		pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	if withPrivateKey {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("could not marshal private key: %v", err)
+		}
+		pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})...)
+	}
+	file := filepath.Join(t.TempDir(), "certificates.pem")
+	if err := os.WriteFile(file, pemBytes, 0o600); err != nil {
+		t.Fatalf("could not write PEM file: %v", err)
+	}
+	return file
+}
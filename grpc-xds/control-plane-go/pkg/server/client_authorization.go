@@ -0,0 +1,140 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/security/advancedtls"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+var (
+	errNoSPIFFEID            = errors.New("client TLS certificate does not contain exactly one URI SAN")
+	errNotSPIFFEID           = errors.New("client URI SAN is not a SPIFFE ID")
+	errInvalidSPIFFEID       = errors.New("client SPIFFE ID is not a Kubernetes workload identity")
+	errClientNotAllowed      = errors.New("client SPIFFE ID is not in the allow-list")
+	errTrustDomainNotAllowed = errors.New("client SPIFFE ID is not in an allowed trust domain")
+)
+
+// clientAuthorizer allows xDS clients to connect to the control plane based on the
+// SPIFFE ID in the URI SAN of their TLS certificate.
+// The allow-lists are fixed at startup, but the trust domains follow config reloads.
+type clientAuthorizer struct {
+	logger          logr.Logger
+	spiffeIDs       []string
+	namespaces      []string
+	serviceAccounts []string
+	mu              sync.RWMutex
+	trustDomains    []string
+}
+
+func newClientAuthorizer(logger logr.Logger, xdsFeatures *xds.Features) *clientAuthorizer {
+	authorizer := &clientAuthorizer{
+		logger:          logger,
+		spiffeIDs:       xdsFeatures.AllowedControlPlaneClientSPIFFEIDs,
+		namespaces:      xdsFeatures.AllowedControlPlaneClientNamespaces,
+		serviceAccounts: xdsFeatures.AllowedControlPlaneClientServiceAccounts,
+	}
+	authorizer.setTrustDomains(xdsFeatures.TrustDomains)
+	return authorizer
+}
+
+// setTrustDomains replaces the trust domains that client SPIFFE IDs must be in.
+func (a *clientAuthorizer) setTrustDomains(trustDomains []tls.TrustDomain) {
+	names := make([]string, 0, len(trustDomains))
+	for _, trustDomain := range trustDomains {
+		names = append(names, trustDomain.Name)
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.trustDomains = names
+}
+
+// allowedTrustDomain returns true if there are no trust domains, or if the trust domain is one of them.
+func (a *clientAuthorizer) allowedTrustDomain(trustDomain string) bool {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	return len(a.trustDomains) == 0 || slices.Contains(a.trustDomains, trustDomain)
+}
+
+// enabled returns true iff there is an allow-list.
+func (a *clientAuthorizer) enabled() bool {
+	return len(a.spiffeIDs) > 0 || len(a.namespaces) > 0 || len(a.serviceAccounts) > 0
+}
+
+// verifyPeer is used as `AdditionalPeerVerification` in the server-side `advancedtls.Options`.
+// If there is no allow-list, all clients are allowed, and the client SPIFFE ID is only logged.
+func (a *clientAuthorizer) verifyPeer(params *advancedtls.HandshakeVerificationInfo) (*advancedtls.PostHandshakeVerificationResults, error) {
+	var spiffeID *url.URL
+	// SPIFFE certificates must have exactly one URI SAN.
+	if params.Leaf != nil && len(params.Leaf.URIs) == 1 && params.Leaf.URIs[0] != nil {
+		spiffeID = params.Leaf.URIs[0]
+		a.logger.V(2).Info("Client TLS certificate", "spiffeID", *spiffeID)
+	}
+	if !a.enabled() {
+		return &advancedtls.PostHandshakeVerificationResults{}, nil
+	}
+	if err := a.authorize(spiffeID); err != nil {
+		a.logger.Info("Denied xDS client", "spiffeID", spiffeID, "reason", err.Error())
+		return nil, err
+	}
+	return &advancedtls.PostHandshakeVerificationResults{}, nil
+}
+
+// authorize allows the SPIFFE ID if it is in the list of allowed SPIFFE IDs, or if it is a
+// Kubernetes workload identity in one of the allowed Namespaces and ServiceAccounts.
+// Empty lists of Namespaces or ServiceAccounts allow any, but not both.
+//
+// The checks go from the certificate to the allow-lists, so that the error names the first
+// reason for denying the client, e.g., a URI SAN that isn't a SPIFFE ID is not reported as
+// being in the wrong trust domain.
+func (a *clientAuthorizer) authorize(spiffeID *url.URL) error {
+	if spiffeID == nil {
+		return errNoSPIFFEID
+	}
+	if spiffeID.Scheme != "spiffe" || spiffeID.Host == "" {
+		return fmt.Errorf("%w: uri=%s", errNotSPIFFEID, spiffeID)
+	}
+	if !a.allowedTrustDomain(spiffeID.Host) {
+		return fmt.Errorf("%w: trustDomain=%s", errTrustDomainNotAllowed, spiffeID.Host)
+	}
+	if slices.Contains(a.spiffeIDs, spiffeID.String()) {
+		return nil
+	}
+	if len(a.namespaces) == 0 && len(a.serviceAccounts) == 0 {
+		return errClientNotAllowed
+	}
+	// Kubernetes workload identities have the path `/ns/[namespace]/sa/[serviceAccount]`.
+	segments := strings.Split(strings.TrimPrefix(spiffeID.Path, "/"), "/")
+	if len(segments) != 4 || segments[0] != "ns" || segments[2] != "sa" {
+		return fmt.Errorf("%w: spiffeID=%s", errInvalidSPIFFEID, spiffeID)
+	}
+	if len(a.namespaces) > 0 && !slices.Contains(a.namespaces, segments[1]) {
+		return fmt.Errorf("%w: namespace=%s", errClientNotAllowed, segments[1])
+	}
+	if len(a.serviceAccounts) > 0 && !slices.Contains(a.serviceAccounts, segments[3]) {
+		return fmt.Errorf("%w: serviceAccount=%s", errClientNotAllowed, segments[3])
+	}
+	return nil
+}
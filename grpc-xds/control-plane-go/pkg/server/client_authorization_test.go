@@ -0,0 +1,124 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"crypto/x509"
+	"errors"
+	"net/url"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/security/advancedtls"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+func TestClientAuthorizerSetTrustDomains(t *testing.T) {
+	authorizer := newClientAuthorizer(logr.Discard(), &xds.Features{
+		AllowedControlPlaneClientNamespaces: []string{"xds"},
+		TrustDomains:                        []tls.TrustDomain{{Name: "a.svc.id.goog"}},
+	})
+	spiffeID := &url.URL{Scheme: "spiffe", Host: "b.svc.id.goog", Path: "/ns/xds/sa/default"}
+	if err := authorizer.authorize(spiffeID); !errors.Is(err, errTrustDomainNotAllowed) {
+		t.Fatalf("authorize(%s) before reload: got err=%v, want %v", spiffeID, err, errTrustDomainNotAllowed)
+	}
+
+	authorizer.setTrustDomains([]tls.TrustDomain{{Name: "a.svc.id.goog"}, {Name: "b.svc.id.goog"}})
+	if err := authorizer.authorize(spiffeID); err != nil {
+		t.Errorf("authorize(%s) after reload: got err=%v, want nil", spiffeID, err)
+	}
+
+	authorizer.setTrustDomains(nil)
+	if err := authorizer.authorize(spiffeID); err != nil {
+		t.Errorf("authorize(%s) without trust domains: got err=%v, want nil", spiffeID, err)
+	}
+}
+
+func TestClientAuthorizerAuthorize(t *testing.T) {
+	features := &xds.Features{
+		AllowedControlPlaneClientSPIFFEIDs:  []string{"spiffe://example.svc.id.goog/greeter-cli"},
+		AllowedControlPlaneClientNamespaces: []string{"xds"},
+		TrustDomains:                        []tls.TrustDomain{{Name: "example.svc.id.goog"}},
+	}
+	tests := []struct {
+		name string
+		// uris are the URI SANs of the client certificate. Nil means no client certificate.
+		uris    []string
+		wantErr error
+	}{
+		{
+			name: "allowed SPIFFE ID",
+			uris: []string{"spiffe://example.svc.id.goog/greeter-cli"},
+		},
+		{
+			name: "allowed Namespace",
+			uris: []string{"spiffe://example.svc.id.goog/ns/xds/sa/greeter-leaf"},
+		},
+		{
+			name:    "unknown SPIFFE ID",
+			uris:    []string{"spiffe://example.svc.id.goog/ns/other/sa/greeter-leaf"},
+			wantErr: errClientNotAllowed,
+		},
+		{
+			name:    "unknown SPIFFE ID that is not a workload identity",
+			uris:    []string{"spiffe://example.svc.id.goog/other-cli"},
+			wantErr: errInvalidSPIFFEID,
+		},
+		{
+			name:    "wrong trust domain",
+			uris:    []string{"spiffe://other.svc.id.goog/ns/xds/sa/greeter-leaf"},
+			wantErr: errTrustDomainNotAllowed,
+		},
+		{
+			name:    "URI SAN that is not a SPIFFE ID",
+			uris:    []string{"https://example.svc.id.goog/ns/xds/sa/greeter-leaf"},
+			wantErr: errNotSPIFFEID,
+		},
+		{
+			name:    "several URI SANs",
+			uris:    []string{"spiffe://example.svc.id.goog/greeter-cli", "spiffe://example.svc.id.goog/ns/xds/sa/greeter-leaf"},
+			wantErr: errNoSPIFFEID,
+		},
+		{
+			name:    "no client certificate",
+			uris:    nil,
+			wantErr: errNoSPIFFEID,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			params := &advancedtls.HandshakeVerificationInfo{}
+			if test.uris != nil {
+				params.Leaf = &x509.Certificate{}
+				for _, uri := range test.uris {
+					parsed, err := url.Parse(uri)
+					if err != nil {
+						t.Fatalf("could not parse URI %s: %v", uri, err)
+					}
+					params.Leaf.URIs = append(params.Leaf.URIs, parsed)
+				}
+			}
+			_, err := newClientAuthorizer(logr.Discard(), features).verifyPeer(params)
+			if test.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Errorf("error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,121 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"slices"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+// configReloader applies changes to the informer configuration, xDS feature flags, RBAC policies, JWT providers,
+// external backends, and fallback applications at runtime.
+type configReloader struct {
+	informerRegistry *informers.Registry
+	xdsCache         *xds.SnapshotCache
+	clientAuthorizer *clientAuthorizer
+	// startupFeatures are the xDS feature flags read at startup. The control plane
+	// TLS flags configure the management server transport credentials, and the
+	// locality priority policy determines the node hash function of the cache, so
+	// changes to those flags only take effect after a restart.
+	startupFeatures xds.Features
+}
+
+var _ config.ReloadHandler = &configReloader{}
+
+func (r *configReloader) ReloadKubecontexts(ctx context.Context, logger logr.Logger, kubecontexts []informers.Kubecontext) error {
+	logger.V(1).Info("Reloading informer configuration", "configurations", kubecontexts)
+	return r.informerRegistry.Apply(ctx, logger, kubecontexts)
+}
+
+func (r *configReloader) ReloadXDSFeatures(ctx context.Context, logger logr.Logger, xdsFeatures *xds.Features) error {
+	if xdsFeatures.EnableControlPlaneTLS != r.startupFeatures.EnableControlPlaneTLS ||
+		xdsFeatures.RequireControlPlaneClientCerts != r.startupFeatures.RequireControlPlaneClientCerts {
+		logger.Info("Changes to enableControlPlaneTls and requireControlPlaneClientCerts require a restart of the control plane, keeping the values from startup",
+			"enableControlPlaneTls", r.startupFeatures.EnableControlPlaneTLS,
+			"requireControlPlaneClientCerts", r.startupFeatures.RequireControlPlaneClientCerts)
+		xdsFeatures.EnableControlPlaneTLS = r.startupFeatures.EnableControlPlaneTLS
+		xdsFeatures.RequireControlPlaneClientCerts = r.startupFeatures.RequireControlPlaneClientCerts
+	}
+	if !slices.Equal(xdsFeatures.AllowedControlPlaneClientSPIFFEIDs, r.startupFeatures.AllowedControlPlaneClientSPIFFEIDs) ||
+		!slices.Equal(xdsFeatures.AllowedControlPlaneClientNamespaces, r.startupFeatures.AllowedControlPlaneClientNamespaces) ||
+		!slices.Equal(xdsFeatures.AllowedControlPlaneClientServiceAccounts, r.startupFeatures.AllowedControlPlaneClientServiceAccounts) {
+		logger.Info("Changes to the allowed control plane clients require a restart of the control plane, keeping the values from startup",
+			"allowedControlPlaneClientSpiffeIds", r.startupFeatures.AllowedControlPlaneClientSPIFFEIDs,
+			"allowedControlPlaneClientNamespaces", r.startupFeatures.AllowedControlPlaneClientNamespaces,
+			"allowedControlPlaneClientServiceAccounts", r.startupFeatures.AllowedControlPlaneClientServiceAccounts)
+		xdsFeatures.AllowedControlPlaneClientSPIFFEIDs = r.startupFeatures.AllowedControlPlaneClientSPIFFEIDs
+		xdsFeatures.AllowedControlPlaneClientNamespaces = r.startupFeatures.AllowedControlPlaneClientNamespaces
+		xdsFeatures.AllowedControlPlaneClientServiceAccounts = r.startupFeatures.AllowedControlPlaneClientServiceAccounts
+	}
+	if xdsFeatures.LocalityPriorityPolicy != r.startupFeatures.LocalityPriorityPolicy {
+		logger.Info("Changes to localityPriorityPolicy require a restart of the control plane, keeping the value from startup",
+			"localityPriorityPolicy", r.startupFeatures.LocalityPriorityPolicy)
+		xdsFeatures.LocalityPriorityPolicy = r.startupFeatures.LocalityPriorityPolicy
+	}
+	if xdsFeatures.RequireAllResourceNames != r.startupFeatures.RequireAllResourceNames ||
+		xdsFeatures.XDSStreamMode != r.startupFeatures.XDSStreamMode {
+		logger.Info("Changes to requireAllResourceNames and xdsStreamMode require a restart of the control plane, keeping the values from startup",
+			"requireAllResourceNames", r.startupFeatures.RequireAllResourceNames,
+			"xdsStreamMode", r.startupFeatures.XDSStreamMode)
+		xdsFeatures.RequireAllResourceNames = r.startupFeatures.RequireAllResourceNames
+		xdsFeatures.XDSStreamMode = r.startupFeatures.XDSStreamMode
+	}
+	if !slices.Equal(xdsFeatures.FeatureOverrideMetadataKeys(), r.startupFeatures.FeatureOverrideMetadataKeys()) {
+		logger.Info("Feature overrides with new node metadata keys require a restart of the control plane, only node metadata keys from startup are matched",
+			"nodeMetadataKeys", r.startupFeatures.FeatureOverrideMetadataKeys())
+	}
+	logger.V(1).Info("Reloading xDS feature flags", "flags", xdsFeatures)
+	if err := r.xdsCache.UpdateFeatures(ctx, logger, xdsFeatures); err != nil {
+		return err
+	}
+	r.informerRegistry.SetIncludeTerminatingEndpoints(xdsFeatures.EndpointWeightTransition > 0)
+	r.clientAuthorizer.setTrustDomains(xdsFeatures.TrustDomains)
+	return nil
+}
+
+func (r *configReloader) ReloadRBACPolicies(ctx context.Context, logger logr.Logger, policies []rds.RBACPolicy) error {
+	logger.V(1).Info("Reloading RBAC policies", "policies", policies)
+	return r.xdsCache.UpdateRBACPolicies(ctx, logger, policies)
+}
+
+func (r *configReloader) ReloadRoutePolicies(ctx context.Context, logger logr.Logger, policies []rds.RoutePolicy) error {
+	logger.V(1).Info("Reloading route policies", "policies", policies)
+	return r.xdsCache.UpdateRoutePolicies(ctx, logger, policies)
+}
+
+func (r *configReloader) ReloadJWTProviders(ctx context.Context, logger logr.Logger, providers []lds.JWTProvider) error {
+	logger.V(1).Info("Reloading JWT providers", "providers", providers)
+	return r.xdsCache.UpdateJWTProviders(ctx, logger, providers)
+}
+
+func (r *configReloader) ReloadExternalBackends(ctx context.Context, logger logr.Logger, backends []cds.ExternalBackend) error {
+	logger.V(1).Info("Reloading external backends", "backends", backends)
+	return r.xdsCache.UpdateExternalBackends(ctx, logger, backends)
+}
+
+func (r *configReloader) ReloadFallbackApplications(ctx context.Context, logger logr.Logger, apps []applications.Application) error {
+	logger.V(1).Info("Reloading fallback applications", "apps", apps)
+	return r.xdsCache.UpdateFallbackApplications(ctx, logger, apps)
+}
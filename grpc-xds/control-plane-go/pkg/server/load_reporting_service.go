@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	loadstatsv3 "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v3"
+	"github.com/go-logr/logr"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+// loadReportingInterval is how often xDS clients send load reports, and how often the control
+// plane refreshes locality weights from the load reports.
+const loadReportingInterval = 10 * time.Second
+
+// loadReportingService receives load reports from gRPC clients, using the Load Reporting Service
+// (LRS), and records the successful and failed requests by EDS service and locality, for the
+// `loadReport` locality weight policy. gRPC clients only send load reports for Clusters with an
+// `lrs_server`, see `cds.AddLoadReportingServer()`.
+type loadReportingService struct {
+	loadstatsv3.UnimplementedLoadReportingServiceServer
+	logger      logr.Logger
+	loadReports *eds.LoadReports
+}
+
+var _ loadstatsv3.LoadReportingServiceServer = &loadReportingService{}
+
+func newLoadReportingService(logger logr.Logger, loadReports *eds.LoadReports) *loadReportingService {
+	return &loadReportingService{
+		logger:      logger.WithName("lrs"),
+		loadReports: loadReports,
+	}
+}
+
+// StreamLoadStats asks the client to report load for all Clusters, and records the load reports
+// until the client closes the stream. Only the first request on a stream includes the node.
+func (s *loadReportingService) StreamLoadStats(stream loadstatsv3.LoadReportingService_StreamLoadStatsServer) error {
+	request, err := stream.Recv()
+	if err != nil {
+		return fmt.Errorf("could not receive initial load stats request: %w", err)
+	}
+	nodeID := request.GetNode().GetId()
+	s.logger.V(2).Info("Load reporting stream opened", "nodeId", nodeID)
+	if err := stream.Send(&loadstatsv3.LoadStatsResponse{
+		SendAllClusters:       true,
+		LoadReportingInterval: durationpb.New(loadReportingInterval),
+	}); err != nil {
+		return fmt.Errorf("could not send load stats response to nodeId=%s: %w", nodeID, err)
+	}
+	for {
+		s.record(nodeID, request)
+		request, err = stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("could not receive load stats request from nodeId=%s: %w", nodeID, err)
+		}
+	}
+}
+
+func (s *loadReportingService) record(nodeID string, request *loadstatsv3.LoadStatsRequest) {
+	for _, clusterStats := range request.GetClusterStats() {
+		// gRPC clients report the EDS service name separately if it differs from the Cluster name.
+		edsServiceName := cmp.Or(clusterStats.GetClusterServiceName(), clusterStats.GetClusterName())
+		for _, localityStats := range clusterStats.GetUpstreamLocalityStats() {
+			locality := eds.LocalityFromSubZone(localityStats.GetLocality().GetZone(), localityStats.GetLocality().GetSubZone())
+			s.logger.V(4).Info("LoadReport",
+				"nodeId", nodeID,
+				"edsServiceName", edsServiceName,
+				"locality", locality,
+				"successful", localityStats.GetTotalSuccessfulRequests(),
+				"errors", localityStats.GetTotalErrorRequests())
+			s.loadReports.Add(edsServiceName, locality, localityStats.GetTotalSuccessfulRequests(), localityStats.GetTotalErrorRequests())
+		}
+	}
+}
@@ -0,0 +1,89 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+// maintenanceHandler puts applications in maintenance mode, and removes them from maintenance mode,
+// without changing the xDS feature flags config file.
+//
+// `POST` with the query parameters `app` and, optionally, `status` (a gRPC status code name,
+// default `UNAVAILABLE`) puts the application in maintenance mode. `POST` with the query parameters
+// `app` and `enabled=false` removes the application from maintenance mode. `GET` and `POST` respond
+// with the status code names of the applications in maintenance mode, as JSON.
+//
+// The metrics port is plaintext and does not authenticate callers, so `POST` requests fail with
+// status 403 unless writes are enabled, see `config.Server.EnableAdminWrites`.
+func maintenanceHandler(logger logr.Logger, xdsCache *xds.SnapshotCache, writesEnabled bool) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPost:
+			if !writesEnabled {
+				http.Error(w, "changes to maintenance mode are disabled, enable them with -enable-admin-writes (env ENABLE_ADMIN_WRITES)", http.StatusForbidden)
+				return
+			}
+			appName := r.URL.Query().Get("app")
+			if appName == "" {
+				http.Error(w, "query parameter app is required", http.StatusBadRequest)
+				return
+			}
+			enabled := true
+			if enabledString := r.URL.Query().Get("enabled"); enabledString != "" {
+				var err error
+				if enabled, err = strconv.ParseBool(enabledString); err != nil {
+					http.Error(w, "query parameter enabled must be true or false", http.StatusBadRequest)
+					return
+				}
+			}
+			if !enabled {
+				if err := xdsCache.ClearMaintenanceMode(r.Context(), logger, appName); err != nil {
+					http.Error(w, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				logger.Info("Cleared maintenance mode", "app", appName, "remoteAddr", r.RemoteAddr)
+				break
+			}
+			statusCodeName := r.URL.Query().Get("status")
+			if _, err := xds.MaintenanceStatusCode(statusCodeName); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := xdsCache.SetMaintenanceMode(r.Context(), logger, appName, statusCodeName); err != nil {
+				http.Error(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			logger.Info("Set maintenance mode", "app", appName, "statusCode", statusCodeName, "remoteAddr", r.RemoteAddr)
+		default:
+			w.Header().Set("Allow", "GET, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		encoder := json.NewEncoder(w)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(xdsCache.MaintenanceApplications()); err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+		}
+	})
+}
@@ -0,0 +1,120 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+func TestMaintenanceHandler(t *testing.T) {
+	tests := []struct {
+		name          string
+		writesEnabled bool
+		method        string
+		query         string
+		wantStatus    int
+		// wantMaintenance is true if the application should be in maintenance mode after the request.
+		wantMaintenance bool
+	}{
+		{
+			name:          "get",
+			writesEnabled: false,
+			method:        http.MethodGet,
+			wantStatus:    http.StatusOK,
+		},
+		{
+			name:          "post with writes disabled",
+			writesEnabled: false,
+			method:        http.MethodPost,
+			query:         "app=greeter-leaf",
+			wantStatus:    http.StatusForbidden,
+		},
+		{
+			name:            "post with writes enabled",
+			writesEnabled:   true,
+			method:          http.MethodPost,
+			query:           "app=greeter-leaf&status=UNAVAILABLE",
+			wantStatus:      http.StatusOK,
+			wantMaintenance: true,
+		},
+		{
+			name:          "post without app",
+			writesEnabled: true,
+			method:        http.MethodPost,
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "post with invalid enabled",
+			writesEnabled: true,
+			method:        http.MethodPost,
+			query:         "app=greeter-leaf&enabled=maybe",
+			wantStatus:    http.StatusBadRequest,
+		},
+		{
+			name:          "put is not allowed",
+			writesEnabled: true,
+			method:        http.MethodPut,
+			query:         "app=greeter-leaf",
+			wantStatus:    http.StatusMethodNotAllowed,
+		},
+		{
+			name:          "delete is not allowed",
+			writesEnabled: true,
+			method:        http.MethodDelete,
+			query:         "app=greeter-leaf",
+			wantStatus:    http.StatusMethodNotAllowed,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ctx := logging.NewContext(context.Background(), logr.Discard())
+			xdsCache := xds.NewSnapshotCache(ctx, xds.ZoneHash{}, eds.LocalityPriorityByZone{}, &xds.Features{}, "xds-authority.example.com")
+			handler := maintenanceHandler(logr.Discard(), xdsCache, test.writesEnabled)
+			recorder := httptest.NewRecorder()
+			handler.ServeHTTP(recorder, httptest.NewRequest(test.method, "/debug/maintenance?"+test.query, nil))
+			if recorder.Code != test.wantStatus {
+				t.Errorf("status = %d, want %d, body: %s", recorder.Code, test.wantStatus, recorder.Body.String())
+			}
+			if _, exists := xdsCache.MaintenanceApplications()["greeter-leaf"]; exists != test.wantMaintenance {
+				t.Errorf("greeter-leaf in maintenance mode = %t, want %t", exists, test.wantMaintenance)
+			}
+		})
+	}
+}
+
+func TestMaintenanceHandlerClear(t *testing.T) {
+	ctx := logging.NewContext(context.Background(), logr.Discard())
+	xdsCache := xds.NewSnapshotCache(ctx, xds.ZoneHash{}, eds.LocalityPriorityByZone{}, &xds.Features{}, "xds-authority.example.com")
+	handler := maintenanceHandler(logr.Discard(), xdsCache, true)
+	for _, query := range []string{"app=greeter-leaf", "app=greeter-leaf&enabled=false"} {
+		recorder := httptest.NewRecorder()
+		handler.ServeHTTP(recorder, httptest.NewRequest(http.MethodPost, "/debug/maintenance?"+query, nil))
+		if recorder.Code != http.StatusOK {
+			t.Fatalf("POST %s status = %d, want %d, body: %s", query, recorder.Code, http.StatusOK, recorder.Body.String())
+		}
+	}
+	if len(xdsCache.MaintenanceApplications()) != 0 {
+		t.Errorf("applications in maintenance mode = %v, want none", xdsCache.MaintenanceApplications())
+	}
+}
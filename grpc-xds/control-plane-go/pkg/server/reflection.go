@@ -0,0 +1,49 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	// Register the descriptors of xDS resource types and extensions that gRPC clients and servers
+	// support, but that the control plane does not otherwise use. The reflection service serves
+	// these descriptors, so that grpcurl can decode the `Any` resources in DiscoveryResponses
+	// from other control planes, and in CSDS responses.
+	_ "github.com/cncf/xds/go/udpa/type/v1"
+	_ "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/clusters/aggregate/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/gcp_authn/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/stateful_session/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/http/stateful_session/cookie/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/load_balancing_policies/ring_hash/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/extensions/rbac/audit_loggers/stream/v3"
+	_ "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/reflection"
+)
+
+// registerReflection registers the gRPC server reflection service on the servers.
+//
+// The reflection service serves the descriptors of all the protobuf files linked into the
+// control plane. This includes the xDS services and DiscoveryRequest messages, the xDS
+// resource types, and the Envoy extensions used in the resources. With reflection, grpcurl
+// users can compose DiscoveryRequests and decode DiscoveryResponses without local copies of
+// the Envoy and xDS proto files, e.g.:
+//
+//	grpcurl -plaintext -d '{"node": {"id": "test"}, "type_url": "type.googleapis.com/envoy.config.listener.v3.Listener"}' \
+//	  localhost:50051 envoy.service.discovery.v3.AggregatedDiscoveryService/StreamAggregatedResources
+func registerReflection(servers ...*grpc.Server) {
+	for _, server := range servers {
+		reflection.Register(server)
+	}
+}
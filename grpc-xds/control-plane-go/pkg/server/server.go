@@ -18,19 +18,28 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
+	"sync"
 	"time"
 
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	accesslogv3 "github.com/envoyproxy/go-control-plane/envoy/service/accesslog/v3"
 	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/service/cluster/v3"
 	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
 	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/service/endpoint/v3"
 	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/service/listener/v3"
+	loadstatsv3 "github.com/envoyproxy/go-control-plane/envoy/service/load_stats/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/service/route/v3"
 	runtimev3 "github.com/envoyproxy/go-control-plane/envoy/service/runtime/v3"
 	secretv3 "github.com/envoyproxy/go-control-plane/envoy/service/secret/v3"
 	serverv3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/admin"
+	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/credentials"
 	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/credentials/tls/certprovider"
@@ -38,23 +47,23 @@ import (
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
-	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/security/advancedtls"
 	"google.golang.org/protobuf/encoding/protojson"
 
+	adminpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/admin"
+	sourcepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/api/source"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/interceptors"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/rollout"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/telemetry"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
-	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
-)
-
-// gRPC configuration based on https://github.com/envoyproxy/go-control-plane/blob/v0.11.1/internal/example/server.go
-const (
-	grpcKeepaliveTime        = 30 * time.Second
-	grpcKeepaliveTimeout     = 5 * time.Second
-	grpcKeepaliveMinTime     = 30 * time.Second
-	grpcMaxConcurrentStreams = 1000000
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
 )
 
 type transportCredentials struct {
@@ -71,21 +80,57 @@ func (c *transportCredentials) Close() {
 	}
 }
 
-func Run(ctx context.Context, servingPort int, healthPort int, kubecontexts []informers.Kubecontext, xdsFeatures *xds.Features, authority string) error {
+// Options configures the xDS control plane management server.
+type Options struct {
+	// Server configures the ports, keepalive, and TLS settings of the gRPC servers.
+	Server       config.Server
+	Kubecontexts []informers.Kubecontext
+	// DiscoverySources provide applications in addition to the Kubernetes informers.
+	DiscoverySources []discovery.Source
+	XDSFeatures      *xds.Features
+	// Authority is the xDS federation authority name of the control plane.
+	Authority string
+	// AuthorityServers serve additional xDS federation authorities on separate ports.
+	AuthorityServers []config.AuthorityServer
+	// ConfigReloadInterval is how often to check the config files for changes.
+	ConfigReloadInterval time.Duration
+	KubecontextHealth    informers.HealthConfig
+	// NodeHashIdleTTL is how long the snapshots of node hashes without open xDS streams are kept.
+	NodeHashIdleTTL  time.Duration
+	RBACPolicies     []rds.RBACPolicy
+	RoutePolicies    []rds.RoutePolicy
+	JWTProviders     []lds.JWTProvider
+	ExternalBackends []cds.ExternalBackend
+	CanaryRollouts   []rollout.CanarySpec
+	// FallbackApps are served when the informers and discovery sources have not found any applications.
+	FallbackApps []applications.Application
+}
+
+func Run(ctx context.Context, opts Options) error {
 	logger := logging.FromContext(ctx)
-	serverCredentials, err := createServerCredentials(logger, xdsFeatures)
+	// The client authorizer is shared by all servers, so that config reloads can update its trust domains.
+	authorizer := newClientAuthorizer(logger, opts.XDSFeatures)
+	serverCredentials, err := createServerCredentials(ctx, logger, opts.Server, opts.XDSFeatures, authorizer)
 	if err != nil {
 		return fmt.Errorf("could not create server-side transport credentials: %w", err)
 	}
 	defer serverCredentials.Close()
 
-	grpcOptions := serverOptions(logger, serverCredentials)
+	grpcOptions := serverOptions(logger, opts.Server, serverCredentials)
 	server := grpc.NewServer(grpcOptions...)
+	authorityGRPCServers, cleanupAuthorityServers, err := createAuthorityGRPCServers(ctx, logger, opts.Server, opts.XDSFeatures, authorizer, opts.AuthorityServers)
+	if err != nil {
+		return err
+	}
+	defer cleanupAuthorityServers()
+	servingGRPCServers := append([]*grpc.Server{server}, authorityGRPCServers...)
 	healthGRPCServer := grpc.NewServer()
 	healthServer := health.NewServer()
-	addServerStopBehavior(ctx, logger, server, healthGRPCServer, healthServer)
+	addServerStopBehavior(ctx, logger, opts.Server.DrainInterval, opts.Server.GracefulShutdownTimeout, servingGRPCServers, healthGRPCServer, healthServer)
 	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
-	healthpb.RegisterHealthServer(server, healthServer)
+	for _, servingGRPCServer := range servingGRPCServers {
+		healthpb.RegisterHealthServer(servingGRPCServer, healthServer)
+	}
 	healthpb.RegisterHealthServer(healthGRPCServer, healthServer)
 
 	cleanup, err := registerAdminServers(server, healthGRPCServer)
@@ -94,36 +139,154 @@ func Run(ctx context.Context, servingPort int, healthPort int, kubecontexts []in
 	}
 	defer cleanup()
 
-	reflection.Register(server)
-	reflection.Register(healthGRPCServer)
+	registerReflection(server, healthGRPCServer)
 
-	xdsCache := xds.NewSnapshotCache(ctx, true, xds.ZoneHash{}, eds.LocalityPriorityByZone{}, xdsFeatures, authority)
-	xdsServer := serverv3.NewServer(ctx, xdsCache, xdsServerCallbackFuncs(logger))
+	nodeHash, localityPriorityMapper := opts.XDSFeatures.NodeHash()
+	additionalAuthorities := make([]string, 0, len(opts.AuthorityServers))
+	for _, authorityServer := range opts.AuthorityServers {
+		additionalAuthorities = append(additionalAuthorities, authorityServer.Name)
+	}
+	xdsCache := xds.NewSnapshotCache(ctx, nodeHash, localityPriorityMapper, opts.XDSFeatures, opts.Authority, additionalAuthorities...)
+	if err := xdsCache.UpdateRBACPolicies(ctx, logger, opts.RBACPolicies); err != nil {
+		return fmt.Errorf("could not set RBAC policies: %w", err)
+	}
+	if err := xdsCache.UpdateRoutePolicies(ctx, logger, opts.RoutePolicies); err != nil {
+		return fmt.Errorf("could not set route policies: %w", err)
+	}
+	if err := xdsCache.UpdateJWTProviders(ctx, logger, opts.JWTProviders); err != nil {
+		return fmt.Errorf("could not set JWT providers: %w", err)
+	}
+	if err := xdsCache.UpdateExternalBackends(ctx, logger, opts.ExternalBackends); err != nil {
+		return fmt.Errorf("could not set external backends: %w", err)
+	}
+	if err := xdsCache.UpdateFallbackApplications(ctx, logger, opts.FallbackApps); err != nil {
+		return fmt.Errorf("could not set fallback applications: %w", err)
+	}
+	shutdownMeterProvider, err := telemetry.InitMeterProvider()
+	if err != nil {
+		return fmt.Errorf("could not initialize metrics: %w", err)
+	}
+	defer shutdownMeterProvider(context.Background())
+	if opts.XDSFeatures.EnableControlPlaneTLS && opts.Server.CertificateSource == config.CertificateSourceFiles {
+		certificateFiles := []string{opts.Server.TLSCertFile}
+		if opts.XDSFeatures.RequireControlPlaneClientCerts {
+			certificateFiles = append(certificateFiles, opts.Server.TLSCAFile)
+		}
+		certificateExpiry, err := newCertificateExpiryMonitor(logger, certificateFiles, opts.Server.TLSExpiryWarningThreshold)
+		if err != nil {
+			return fmt.Errorf("could not create certificate expiry monitor: %w", err)
+		}
+		go certificateExpiry.run(ctx, opts.Server.TLSRefreshInterval)
+	}
+	rollouts := rollout.NewController(logger, xdsCache, xdsCache.LoadReports(), xdsCache, opts.CanaryRollouts)
+	callbackFuncs, err := xdsServerCallbackFuncs(logger, xdsCache, rollouts)
+	if err != nil {
+		return fmt.Errorf("could not create xDS server callbacks: %w", err)
+	}
+	xdsStreams, err := newXDSStreamMetrics(callbackFuncs)
+	if err != nil {
+		return fmt.Errorf("could not create xDS stream metrics: %w", err)
+	}
+	xdsServer := serverv3.NewServer(ctx, xdsCache, xdsStreams)
+	go xdsCache.EvictIdleNodeHashes(ctx, logger, opts.NodeHashIdleTTL)
+	go xdsCache.RefreshLoadReportWeights(ctx, logger, loadReportingInterval)
+	go xdsCache.RefreshEndpointWeights(ctx, logger, xds.EndpointWeightRefreshInterval)
+	go rollouts.Run(ctx)
 
-	registerXDSServices(server, xdsServer)
+	accessLogService, err := newAccessLogService(logger)
+	if err != nil {
+		return fmt.Errorf("could not create gRPC access log service: %w", err)
+	}
+	loadReportingService := newLoadReportingService(logger, xdsCache.LoadReports())
+	for _, servingGRPCServer := range servingGRPCServers {
+		registerXDSServices(servingGRPCServer, xdsServer, opts.XDSFeatures)
+		accesslogv3.RegisterAccessLogServiceServer(servingGRPCServer, accessLogService)
+		loadstatsv3.RegisterLoadReportingServiceServer(servingGRPCServer, loadReportingService)
+	}
 
-	if err := createInformers(ctx, logger, kubecontexts, xdsCache); err != nil {
+	informerRegistry := informers.NewRegistry(xdsCache, opts.KubecontextHealth)
+	informerRegistry.SetIncludeTerminatingEndpoints(opts.XDSFeatures.EndpointWeightTransition > 0)
+	if err := informerRegistry.Apply(ctx, logger, opts.Kubecontexts); err != nil {
 		return fmt.Errorf("could not create Kubernetes informer managers: %w", err)
 	}
+	for _, source := range opts.DiscoverySources {
+		if err := source.Start(ctx, logger, xdsCache); err != nil {
+			return fmt.Errorf("could not start discovery source %s: %w", source.Name(), err)
+		}
+	}
+	reloader := &configReloader{
+		informerRegistry: informerRegistry,
+		xdsCache:         xdsCache,
+		clientAuthorizer: authorizer,
+		startupFeatures:  *opts.XDSFeatures,
+	}
+	go config.WatchConfigFiles(ctx, logger, opts.ConfigReloadInterval, reloader)
+	// Only serve the admin and application source services on the health port, not on the serving
+	// ports for xDS clients. The health port is plaintext and reachable by the kubelet and other
+	// Pods, so both services reject changes unless they are enabled in the server configuration.
+	adminpb.RegisterControlPlaneAdminServer(healthGRPCServer, newAdminService(logger, xdsCache, reloader, opts.Server.EnableAdminWrites))
+	sourcepb.RegisterApplicationSourceServer(healthGRPCServer, newApplicationSourceService(logger, xdsCache, opts.Server.EnableApplicationSource))
 
-	tcpListener, err := net.Listen("tcp", fmt.Sprintf(":%d", servingPort))
+	tcpListener, err := net.Listen("tcp", fmt.Sprintf(":%d", opts.Server.ServingPort))
 	if err != nil {
-		return fmt.Errorf("could not create TCP listener on port=%d: %w", servingPort, err)
+		return fmt.Errorf("could not create TCP listener on port=%d: %w", opts.Server.ServingPort, err)
 	}
-	healthTCPListener, err := net.Listen("tcp", fmt.Sprintf(":%d", healthPort))
+	healthTCPListener, err := net.Listen("tcp", fmt.Sprintf(":%d", opts.Server.HealthPort))
 	if err != nil {
-		return fmt.Errorf("could not create TCP listener on port=%d: %w", healthPort, err)
+		return fmt.Errorf("could not create TCP listener on port=%d: %w", opts.Server.HealthPort, err)
 	}
-	logger.V(1).Info("xDS control plane management server listening", "port", servingPort, "healthPort", healthPort)
+	if opts.Server.MetricsPort != 0 {
+		metricsTCPListener, err := net.Listen("tcp", fmt.Sprintf(":%d", opts.Server.MetricsPort))
+		if err != nil {
+			return fmt.Errorf("could not create TCP listener on port=%d: %w", opts.Server.MetricsPort, err)
+		}
+		go func() {
+			if err := listenHTTPMetrics(logger, metricsTCPListener, xdsStreams, xdsCache, opts.Server.EnableAdminWrites); err != nil {
+				logger.Error(err, "Metrics HTTP server stopped", "metricsPort", opts.Server.MetricsPort)
+			}
+		}()
+	}
+	authorityTCPListeners := make([]net.Listener, 0, len(opts.AuthorityServers))
+	for _, authorityServer := range opts.AuthorityServers {
+		authorityTCPListener, err := net.Listen("tcp", fmt.Sprintf(":%d", authorityServer.Port))
+		if err != nil {
+			return fmt.Errorf("could not create TCP listener on port=%d for authority=%s: %w", authorityServer.Port, authorityServer.Name, err)
+		}
+		authorityTCPListeners = append(authorityTCPListeners, authorityTCPListener)
+	}
+	logger.V(1).Info("xDS control plane management server listening", "port", opts.Server.ServingPort, "healthPort", opts.Server.HealthPort, "metricsPort", opts.Server.MetricsPort, "authorityServers", opts.AuthorityServers)
 	go func() {
 		err := server.Serve(tcpListener)
 		if err != nil {
 			healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
 		}
 	}()
+	for i, authorityGRPCServer := range authorityGRPCServers {
+		go func(authorityServer config.AuthorityServer, authorityGRPCServer *grpc.Server, listener net.Listener) {
+			if err := authorityGRPCServer.Serve(listener); err != nil {
+				logger.Error(err, "Authority server stopped", "authority", authorityServer.Name, "port", authorityServer.Port)
+			}
+		}(opts.AuthorityServers[i], authorityGRPCServer, authorityTCPListeners[i])
+	}
 	return healthGRPCServer.Serve(healthTCPListener)
 }
 
+// listenHTTPMetrics serves Prometheus metrics on `/metrics`, the open xDS streams on `/debug/xds-streams`,
+// the log verbosity on `/debug/verbosity`, and the applications in maintenance mode on `/debug/maintenance`.
+// Changes to maintenance mode require `adminWritesEnabled`.
+func listenHTTPMetrics(logger logr.Logger, listener net.Listener, xdsStreams *xdsStreamMetrics, xdsCache *xds.SnapshotCache, adminWritesEnabled bool) error {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", telemetry.MetricsHandler())
+	mux.Handle("/debug/xds-streams", xdsStreams)
+	mux.Handle("/debug/verbosity", logging.VerbosityHandler(logger))
+	mux.Handle("/debug/maintenance", maintenanceHandler(logger, xdsCache, adminWritesEnabled))
+	httpServer := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 10 * time.Second,
+	}
+	return httpServer.Serve(listener)
+}
+
 func registerAdminServers(servingGRPCServer *grpc.Server, healthGRPCServer *grpc.Server) (func(), error) {
 	cleanupServing, err := admin.Register(servingGRPCServer)
 	if err != nil {
@@ -139,13 +302,72 @@ func registerAdminServers(servingGRPCServer *grpc.Server, healthGRPCServer *grpc
 	}, nil
 }
 
-func xdsServerCallbackFuncs(logger logr.Logger) *serverv3.CallbackFuncs {
+// xdsServerCallbackFuncs logs xDS requests and responses, and tracks the open streams of
+// each node hash, so that the cache can evict idle node hashes, and remove the server listener
+// addresses requested on closed streams.
+//
+// Requests are logged as new subscriptions, ACKs, NACKs, or stale requests, by comparing the
+// response nonce of each request to the nonce of the most recent response on the stream.
+// NACKs are also counted by type URL, as an OpenTelemetry counter, and can roll back canary rollouts.
+// Delta requests carry the error detail of a NACK directly, so they don't need the nonce tracking.
+func xdsServerCallbackFuncs(logger logr.Logger, xdsCache *xds.SnapshotCache, rollouts *rollout.Controller) (*serverv3.CallbackFuncs, error) {
+	requests := newXDSRequestTracker()
+	nacks, err := otel.Meter(meterName).Int64Counter("xds.requests.nacks",
+		metric.WithDescription("Number of xDS requests that reject (NACK) a response, by resource type URL."),
+		metric.WithUnit("{request}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create xDS NACK counter: %w", err)
+	}
 	return &serverv3.CallbackFuncs{
+		StreamClosedFunc: func(streamID int64, _ *corev3.Node) {
+			requests.streamClosed(streamID)
+			xdsCache.StreamClosed(xds.StreamKey{ID: streamID})
+			xdsCache.StreamListenerClosed(xds.StreamKey{ID: streamID})
+		},
+		DeltaStreamClosedFunc: func(streamID int64, _ *corev3.Node) {
+			xdsCache.StreamClosed(xds.StreamKey{ID: streamID, Delta: true})
+			xdsCache.StreamListenerClosed(xds.StreamKey{ID: streamID, Delta: true})
+		},
+		StreamDeltaRequestFunc: func(streamID int64, request *discoveryv3.DeltaDiscoveryRequest) error {
+			logger.V(2).Info("StreamDeltaRequest", "streamID", streamID, "type", request.GetTypeUrl(), "subscribe", request.GetResourceNamesSubscribe(), "unsubscribe", request.GetResourceNamesUnsubscribe())
+			if request.GetErrorDetail() != nil {
+				logger.Info("StreamDeltaRequest NACK", "streamID", streamID, "type", request.GetTypeUrl(), "responseNonce", request.GetResponseNonce(),
+					"errorCode", codes.Code(request.GetErrorDetail().GetCode()).String(),
+					"errorMessage", request.GetErrorDetail().GetMessage())
+				nacks.Add(context.Background(), 1, metric.WithAttributes(attribute.String("type_url", request.GetTypeUrl())))
+				rollouts.ObserveNACK(request.GetTypeUrl())
+			}
+			xdsCache.StreamRequest(xds.StreamKey{ID: streamID, Delta: true}, request.GetNode())
+			xdsCache.StreamListenerRequest(xds.StreamKey{ID: streamID, Delta: true}, request.GetNode(), request.GetTypeUrl(), request.GetResourceNamesSubscribe(), request.GetResourceNamesUnsubscribe())
+			return nil
+		},
 		StreamRequestFunc: func(streamID int64, request *discoveryv3.DiscoveryRequest) error {
-			logger.Info("StreamRequest", "streamID", streamID, "type", request.GetTypeUrl(), "resourceNames", request.ResourceNames)
+			kind, subscriptionChanged := requests.request(streamID, request)
+			keysAndValues := []interface{}{
+				"streamID", streamID,
+				"kind", kind,
+				"type", request.GetTypeUrl(),
+				"versionInfo", request.GetVersionInfo(),
+				"responseNonce", request.GetResponseNonce(),
+				"resourceNames", request.ResourceNames,
+				"subscriptionChanged", subscriptionChanged,
+			}
+			if kind == requestKindNACK {
+				keysAndValues = append(keysAndValues,
+					"errorCode", codes.Code(request.GetErrorDetail().GetCode()).String(),
+					"errorMessage", request.GetErrorDetail().GetMessage())
+				// Clients only send the node in the first request on a stream, so NACKs are not counted by node.
+				nacks.Add(context.Background(), 1, metric.WithAttributes(attribute.String("type_url", request.GetTypeUrl())))
+				rollouts.ObserveNACK(request.GetTypeUrl())
+			}
+			logger.Info("StreamRequest", keysAndValues...)
+			xdsCache.StreamRequest(xds.StreamKey{ID: streamID}, request.GetNode())
+			xdsCache.StreamListenerRequest(xds.StreamKey{ID: streamID}, request.GetNode(), request.GetTypeUrl(), request.GetResourceNames(), nil)
 			return nil
 		},
 		StreamResponseFunc: func(_ context.Context, streamID int64, _ *discoveryv3.DiscoveryRequest, response *discoveryv3.DiscoveryResponse) {
+			requests.response(streamID, response)
+			logger.V(2).Info("StreamResponse sent", "streamID", streamID, "type", response.GetTypeUrl(), "versionInfo", response.GetVersionInfo(), "nonce", response.GetNonce(), "resources", len(response.GetResources()))
 			protoMarshalOptions := protojson.MarshalOptions{
 				Multiline:    true,
 				Indent:       "  ",
@@ -170,11 +392,18 @@ func xdsServerCallbackFuncs(logger logr.Logger) *serverv3.CallbackFuncs {
 				logger.Info("StreamResponse", "streamID", streamID, "type", response.GetTypeUrl(), "resource", string(jsonResourceBytes))
 			}
 		},
-	}
+	}, nil
 }
 
-func registerXDSServices(grpcServer *grpc.Server, xdsServer serverv3.Server) {
-	discoveryv3.RegisterAggregatedDiscoveryServiceServer(grpcServer, xdsServer)
+// registerXDSServices registers the Aggregated Discovery Service (ADS), the separate discovery
+// services for each resource type, or both, depending on the xDS stream mode feature flag.
+func registerXDSServices(grpcServer *grpc.Server, xdsServer serverv3.Server, xdsFeatures *xds.Features) {
+	if xdsFeatures.ServesADS() {
+		discoveryv3.RegisterAggregatedDiscoveryServiceServer(grpcServer, xdsServer)
+	}
+	if !xdsFeatures.ServesSeparateStreams() {
+		return
+	}
 	endpointv3.RegisterEndpointDiscoveryServiceServer(grpcServer, xdsServer)
 	clusterv3.RegisterClusterDiscoveryServiceServer(grpcServer, xdsServer)
 	routev3.RegisterRouteDiscoveryServiceServer(grpcServer, xdsServer)
@@ -183,22 +412,8 @@ func registerXDSServices(grpcServer *grpc.Server, xdsServer serverv3.Server) {
 	runtimev3.RegisterRuntimeDiscoveryServiceServer(grpcServer, xdsServer)
 }
 
-func createInformers(ctx context.Context, logger logr.Logger, kubecontexts []informers.Kubecontext, xdsCache *xds.SnapshotCache) error {
-	for _, kubecontext := range kubecontexts {
-		informerManager, err := informers.NewManager(ctx, kubecontext.Context, xdsCache)
-		if err != nil {
-			return fmt.Errorf("could not create Kubernetes informer manager for context=%s: %w", kubecontext.Context, err)
-		}
-		for _, informer := range kubecontext.Informers {
-			if err := informerManager.AddEndpointSliceInformer(ctx, logger, informer); err != nil {
-				return fmt.Errorf("could not create Kubernetes informer for context=%s for %+v: %w", kubecontext.Context, informer, err)
-			}
-		}
-	}
-	return nil
-}
-
-// serverOptions sets gRPC server options.
+// serverOptions sets gRPC server options. The keepalive parameters and the maximum number of
+// concurrent streams come from the server configuration.
 //
 // gRPC golang library sets a very small upper bound for the number gRPC/h2
 // streams over a single TCP connection. If a proxy multiplexes requests over
@@ -206,68 +421,87 @@ func createInformers(ctx context.Context, logger logr.Logger, kubecontexts []inf
 // availability problems.
 // Keepalive timeouts based on connection_keepalive parameter https://www.envoyproxy.io/docs/envoy/latest/configuration/overview/examples#dynamic
 // Source: https://github.com/envoyproxy/go-control-plane/blob/v0.11.1/internal/example/server.go#L67
-func serverOptions(logger logr.Logger, transportCredentials credentials.TransportCredentials) []grpc.ServerOption {
+func serverOptions(logger logr.Logger, serverConfig config.Server, transportCredentials credentials.TransportCredentials) []grpc.ServerOption {
 	return []grpc.ServerOption{
 		grpc.ChainStreamInterceptor(interceptors.StreamServerLogging(logger)),
 		grpc.ChainUnaryInterceptor(interceptors.UnaryServerLogging(logger)),
 		grpc.Creds(transportCredentials),
 		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
-			MinTime:             grpcKeepaliveMinTime,
+			MinTime:             serverConfig.KeepaliveMinTime,
 			PermitWithoutStream: true,
 		}),
 		grpc.KeepaliveParams(keepalive.ServerParameters{
-			Time:    grpcKeepaliveTime,
-			Timeout: grpcKeepaliveTimeout,
+			Time:    serverConfig.KeepaliveTime,
+			Timeout: serverConfig.KeepaliveTimeout,
 		}),
-		grpc.MaxConcurrentStreams(grpcMaxConcurrentStreams),
+		grpc.MaxConcurrentStreams(serverConfig.MaxConcurrentStreams),
+		grpc.MaxRecvMsgSize(serverConfig.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(serverConfig.MaxSendMsgSize),
 	}
 }
 
-func createServerCredentials(logger logr.Logger, xdsFeatures *xds.Features) (*transportCredentials, error) {
+// createAuthorityGRPCServers creates a gRPC server for each authority server port, with the TLS
+// settings of the port. The returned function cleans up the transport credentials of the servers.
+func createAuthorityGRPCServers(ctx context.Context, logger logr.Logger, serverConfig config.Server, xdsFeatures *xds.Features, authorizer *clientAuthorizer, authorityServers []config.AuthorityServer) ([]*grpc.Server, func(), error) {
+	grpcServers := make([]*grpc.Server, 0, len(authorityServers))
+	credentialsList := make([]*transportCredentials, 0, len(authorityServers))
+	cleanup := func() {
+		for _, creds := range credentialsList {
+			creds.Close()
+		}
+	}
+	for _, authorityServer := range authorityServers {
+		authorityServerConfig := authorityServer.ServerConfig(serverConfig)
+		authorityFeatures := *xdsFeatures
+		if authorityServer.TLS != nil {
+			authorityFeatures.EnableControlPlaneTLS = authorityServer.TLS.Enabled
+			authorityFeatures.RequireControlPlaneClientCerts = authorityServer.TLS.Enabled && authorityServer.TLS.RequireClientCerts
+		}
+		creds, err := createServerCredentials(ctx, logger, authorityServerConfig, &authorityFeatures, authorizer)
+		if err != nil {
+			cleanup()
+			return nil, func() {}, fmt.Errorf("could not create server-side transport credentials for authority=%s port=%d: %w", authorityServer.Name, authorityServer.Port, err)
+		}
+		credentialsList = append(credentialsList, creds)
+		grpcServers = append(grpcServers, grpc.NewServer(serverOptions(logger, authorityServerConfig, creds)...))
+	}
+	return grpcServers, cleanup, nil
+}
+
+// createServerCredentials returns insecure credentials, or TLS credentials with certificates
+// from either PEM files or the SPIFFE Workload API, depending on the server configuration.
+// The authorizer verifies the SPIFFE IDs of clients during TLS handshakes.
+func createServerCredentials(ctx context.Context, logger logr.Logger, serverConfig config.Server, xdsFeatures *xds.Features, authorizer *clientAuthorizer) (*transportCredentials, error) {
 	if !xdsFeatures.EnableControlPlaneTLS {
 		logger.V(2).Info("using insecure credentials for the control plane server")
 		return &transportCredentials{
 			TransportCredentials: insecure.NewCredentials(),
 		}, nil
 	}
-	logger.V(2).Info("using mTLS with automatic certificate reloading for the control plane server")
-	identityOptions := pemfile.Options{
-		CertFile:        "/var/run/secrets/workload-spiffe-credentials/certificates.pem",
-		KeyFile:         "/var/run/secrets/workload-spiffe-credentials/private_key.pem",
-		RefreshDuration: 600 * time.Second,
+	if serverConfig.CertificateSource == config.CertificateSourceWorkloadAPI {
+		logger.V(2).Info("using mTLS with certificates from the SPIFFE Workload API for the control plane server", "socket", serverConfig.SPIFFEEndpointSocket)
+	} else {
+		logger.V(2).Info("using mTLS with automatic certificate reloading for the control plane server")
 	}
-	identityProvider, err := pemfile.NewProvider(identityOptions)
+	identityProvider, rootProvider, err := createCertificateProviders(ctx, serverConfig, xdsFeatures)
 	if err != nil {
-		return nil, fmt.Errorf("could not create a new certificate provider for identityOptions=%+v: %w", identityOptions, err)
+		return nil, err
 	}
 	providers := []certprovider.Provider{identityProvider}
+	if rootProvider != nil && rootProvider != identityProvider {
+		providers = append(providers, rootProvider)
+	}
 
 	options := &advancedtls.Options{
 		IdentityOptions: advancedtls.IdentityCertificateOptions{
 			IdentityProvider: identityProvider,
 		},
-		AdditionalPeerVerification: func(params *advancedtls.HandshakeVerificationInfo) (*advancedtls.PostHandshakeVerificationResults, error) {
-			// Not actually checking anything, just logging the client's SPIFFE ID.
-			// SPIFFE certificates must have exactly one URI SAN.
-			if len(params.Leaf.URIs) == 1 && params.Leaf.URIs[0] != nil {
-				logger.V(2).Info("Client TLS certificate", "spiffeID", *params.Leaf.URIs[0])
-			}
-			return &advancedtls.PostHandshakeVerificationResults{}, nil
-		},
-		RequireClientCert: false,
-		VerificationType:  advancedtls.CertVerification,
+		AdditionalPeerVerification: authorizer.verifyPeer,
+		RequireClientCert:          false,
+		VerificationType:           advancedtls.CertVerification,
 	}
 
 	if xdsFeatures.RequireControlPlaneClientCerts {
-		rootOptions := pemfile.Options{
-			RootFile:        "/var/run/secrets/workload-spiffe-credentials/ca_certificates.pem",
-			RefreshDuration: 600 * time.Second,
-		}
-		rootProvider, err := pemfile.NewProvider(rootOptions)
-		if err != nil {
-			return nil, fmt.Errorf("could not create a new certificate provider for rootOptions=%+v: %w", rootOptions, err)
-		}
-		providers = append(providers, rootProvider)
 		options.RootOptions = advancedtls.RootCertificateOptions{
 			RootProvider: rootProvider,
 		}
@@ -284,21 +518,81 @@ func createServerCredentials(logger logr.Logger, xdsFeatures *xds.Features) (*tr
 	}, err
 }
 
-func addServerStopBehavior(ctx context.Context, logger logr.Logger, servingGRPCServer *grpc.Server, healthGRPCServer *grpc.Server, healthServer *health.Server) {
+// createCertificateProviders returns the certificate providers for the server certificate and for the
+// root certificates used to verify client certificates. With the SPIFFE Workload API certificate source,
+// both are the same provider. The root provider is nil if client certificates are not required.
+func createCertificateProviders(ctx context.Context, serverConfig config.Server, xdsFeatures *xds.Features) (certprovider.Provider, certprovider.Provider, error) {
+	if serverConfig.CertificateSource == config.CertificateSourceWorkloadAPI {
+		var trustDomainNames []string
+		for _, trustDomain := range xdsFeatures.TrustDomains {
+			trustDomainNames = append(trustDomainNames, trustDomain.Name)
+		}
+		provider, err := newWorkloadAPIProvider(ctx, serverConfig.SPIFFEEndpointSocket, trustDomainNames)
+		if err != nil {
+			return nil, nil, err
+		}
+		if !xdsFeatures.RequireControlPlaneClientCerts {
+			return provider, nil, nil
+		}
+		return provider, provider, nil
+	}
+	identityOptions := pemfile.Options{
+		CertFile:        serverConfig.TLSCertFile,
+		KeyFile:         serverConfig.TLSKeyFile,
+		RefreshDuration: serverConfig.TLSRefreshInterval,
+	}
+	identityProvider, err := pemfile.NewProvider(identityOptions)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not create a new certificate provider for identityOptions=%+v: %w", identityOptions, err)
+	}
+	if !xdsFeatures.RequireControlPlaneClientCerts {
+		return identityProvider, nil, nil
+	}
+	rootOptions := pemfile.Options{
+		RootFile:        serverConfig.TLSCAFile,
+		RefreshDuration: serverConfig.TLSRefreshInterval,
+	}
+	rootProvider, err := pemfile.NewProvider(rootOptions)
+	if err != nil {
+		identityProvider.Close()
+		return nil, nil, fmt.Errorf("could not create a new certificate provider for rootOptions=%+v: %w", rootOptions, err)
+	}
+	return identityProvider, rootProvider, nil
+}
+
+// addServerStopBehavior stops the servers when the context is done. The health status changes
+// to NOT_SERVING first, and the servers keep serving for the drain interval, so that clients can
+// switch to other replicas. GracefulStop then sends HTTP/2 GOAWAY frames to all clients of the
+// serving servers and waits for open streams to finish, until the graceful shutdown timeout expires.
+func addServerStopBehavior(ctx context.Context, logger logr.Logger, drainInterval time.Duration, gracefulShutdownTimeout time.Duration, servingGRPCServers []*grpc.Server, healthGRPCServer *grpc.Server, healthServer *health.Server) {
 	go func() {
 		<-ctx.Done()
 		healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+		if drainInterval > 0 {
+			logger.Info("Draining the xDS management server before stopping", "drainInterval", drainInterval)
+			time.Sleep(drainInterval)
+		}
 		stopped := make(chan struct{})
 		go func() {
 			logger.Info("Attempting to gracefully stop the xDS management server")
-			servingGRPCServer.GracefulStop()
+			var wg sync.WaitGroup
+			for _, servingGRPCServer := range servingGRPCServers {
+				wg.Add(1)
+				go func(s *grpc.Server) {
+					defer wg.Done()
+					s.GracefulStop()
+				}(servingGRPCServer)
+			}
+			wg.Wait()
 			close(stopped)
 		}()
-		t := time.NewTimer(5 * time.Second)
+		t := time.NewTimer(gracefulShutdownTimeout)
 		select {
 		case <-t.C:
 			logger.Info("Stopping the xDS management server immediately")
-			servingGRPCServer.Stop()
+			for _, servingGRPCServer := range servingGRPCServers {
+				servingGRPCServer.Stop()
+			}
 			healthGRPCServer.Stop()
 		case <-stopped:
 			t.Stop()
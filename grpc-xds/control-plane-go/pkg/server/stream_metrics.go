@@ -0,0 +1,236 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"cmp"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"sync"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	serverv3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+const (
+	meterName = "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/server"
+	// adsStreamType is used as the stream type of Aggregated Discovery Service (ADS) streams.
+	adsStreamType = "ADS"
+)
+
+// xdsStreamMetrics tracks the open xDS streams and the resources that each stream watches.
+// The stream counts and watch counts per node and type URL are exported as OpenTelemetry
+// gauges, and the streams are served as JSON on a debug HTTP endpoint.
+//
+// xdsStreamMetrics implements `serverv3.Callbacks`, and passes all callbacks on to `next`.
+type xdsStreamMetrics struct {
+	next    serverv3.Callbacks
+	mu      sync.Mutex
+	streams map[int64]*xdsStream
+}
+
+// xdsStream is the state of an open xDS stream.
+type xdsStream struct {
+	ID     int64  `json:"id"`
+	NodeID string `json:"nodeId"`
+	// Type is the type URL for single resource type streams, or `ADS` for aggregated streams.
+	Type  string `json:"type"`
+	Delta bool   `json:"delta"`
+	// Watches are the requested resource names per type URL.
+	// An empty list of resource names is a wildcard subscription.
+	Watches map[string][]string `json:"watches"`
+}
+
+var _ serverv3.Callbacks = &xdsStreamMetrics{}
+
+// newXDSStreamMetrics creates the gauges for open streams and watches, using the global MeterProvider.
+func newXDSStreamMetrics(next serverv3.Callbacks) (*xdsStreamMetrics, error) {
+	m := &xdsStreamMetrics{
+		next:    next,
+		streams: map[int64]*xdsStream{},
+	}
+	meter := otel.Meter(meterName)
+	openStreams, err := meter.Int64ObservableGauge("xds.streams.open",
+		metric.WithDescription("Number of open xDS streams, by node ID and stream type."),
+		metric.WithUnit("{stream}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create open xDS streams gauge: %w", err)
+	}
+	watches, err := meter.Int64ObservableGauge("xds.watches",
+		metric.WithDescription("Number of xDS streams watching resources, by node ID and resource type URL."),
+		metric.WithUnit("{watch}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create xDS watches gauge: %w", err)
+	}
+	_, err = meter.RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		openStreamCounts := map[[2]string]int64{}
+		watchCounts := map[[2]string]int64{}
+		for _, stream := range m.snapshot() {
+			openStreamCounts[[2]string{stream.NodeID, stream.Type}]++
+			for typeURL := range stream.Watches {
+				watchCounts[[2]string{stream.NodeID, typeURL}]++
+			}
+		}
+		for key, count := range openStreamCounts {
+			observer.ObserveInt64(openStreams, count, metric.WithAttributes(
+				attribute.String("node_id", key[0]),
+				attribute.String("stream_type", key[1])))
+		}
+		for key, count := range watchCounts {
+			observer.ObserveInt64(watches, count, metric.WithAttributes(
+				attribute.String("node_id", key[0]),
+				attribute.String("type_url", key[1])))
+		}
+		return nil
+	}, openStreams, watches)
+	if err != nil {
+		return nil, fmt.Errorf("could not register callback for xDS stream gauges: %w", err)
+	}
+	return m, nil
+}
+
+// snapshot returns copies of the open streams, sorted by stream ID.
+func (m *xdsStreamMetrics) snapshot() []xdsStream {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	streams := make([]xdsStream, 0, len(m.streams))
+	for _, stream := range m.streams {
+		streamCopy := *stream
+		streamCopy.Watches = make(map[string][]string, len(stream.Watches))
+		for typeURL, resourceNames := range stream.Watches {
+			streamCopy.Watches[typeURL] = slices.Clone(resourceNames)
+		}
+		streams = append(streams, streamCopy)
+	}
+	slices.SortFunc(streams, func(a, b xdsStream) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return streams
+}
+
+// ServeHTTP serves the open streams as JSON.
+func (m *xdsStreamMetrics) ServeHTTP(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(m.snapshot()); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+	}
+}
+
+func (m *xdsStreamMetrics) openStream(streamID int64, typeURL string, delta bool) {
+	streamType := typeURL
+	if typeURL == resourcev3.AnyType {
+		streamType = adsStreamType
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.streams[streamID] = &xdsStream{
+		ID:      streamID,
+		Type:    streamType,
+		Delta:   delta,
+		Watches: map[string][]string{},
+	}
+}
+
+func (m *xdsStreamMetrics) closeStream(streamID int64) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.streams, streamID)
+}
+
+// updateWatches records the node ID, which is only included in the first request on a stream,
+// and replaces or updates the watched resource names.
+func (m *xdsStreamMetrics) updateWatches(streamID int64, node *corev3.Node, typeURL string, update func(resourceNames []string) []string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	stream, exists := m.streams[streamID]
+	if !exists {
+		return
+	}
+	if node.GetId() != "" {
+		stream.NodeID = node.GetId()
+	}
+	if typeURL == "" {
+		return
+	}
+	stream.Watches[typeURL] = update(stream.Watches[typeURL])
+}
+
+func (m *xdsStreamMetrics) OnStreamOpen(ctx context.Context, streamID int64, typeURL string) error {
+	m.openStream(streamID, typeURL, false)
+	return m.next.OnStreamOpen(ctx, streamID, typeURL)
+}
+
+func (m *xdsStreamMetrics) OnStreamClosed(streamID int64, node *corev3.Node) {
+	m.closeStream(streamID)
+	m.next.OnStreamClosed(streamID, node)
+}
+
+func (m *xdsStreamMetrics) OnStreamRequest(streamID int64, request *discoveryv3.DiscoveryRequest) error {
+	m.updateWatches(streamID, request.GetNode(), request.GetTypeUrl(), func(_ []string) []string {
+		return slices.Clone(request.GetResourceNames())
+	})
+	return m.next.OnStreamRequest(streamID, request)
+}
+
+func (m *xdsStreamMetrics) OnStreamResponse(ctx context.Context, streamID int64, request *discoveryv3.DiscoveryRequest, response *discoveryv3.DiscoveryResponse) {
+	m.next.OnStreamResponse(ctx, streamID, request, response)
+}
+
+func (m *xdsStreamMetrics) OnDeltaStreamOpen(ctx context.Context, streamID int64, typeURL string) error {
+	m.openStream(streamID, typeURL, true)
+	return m.next.OnDeltaStreamOpen(ctx, streamID, typeURL)
+}
+
+func (m *xdsStreamMetrics) OnDeltaStreamClosed(streamID int64, node *corev3.Node) {
+	m.closeStream(streamID)
+	m.next.OnDeltaStreamClosed(streamID, node)
+}
+
+func (m *xdsStreamMetrics) OnStreamDeltaRequest(streamID int64, request *discoveryv3.DeltaDiscoveryRequest) error {
+	m.updateWatches(streamID, request.GetNode(), request.GetTypeUrl(), func(resourceNames []string) []string {
+		resourceNames = slices.DeleteFunc(resourceNames, func(name string) bool {
+			return slices.Contains(request.GetResourceNamesUnsubscribe(), name)
+		})
+		for _, name := range request.GetResourceNamesSubscribe() {
+			if !slices.Contains(resourceNames, name) {
+				resourceNames = append(resourceNames, name)
+			}
+		}
+		return resourceNames
+	})
+	return m.next.OnStreamDeltaRequest(streamID, request)
+}
+
+func (m *xdsStreamMetrics) OnStreamDeltaResponse(streamID int64, request *discoveryv3.DeltaDiscoveryRequest, response *discoveryv3.DeltaDiscoveryResponse) {
+	m.next.OnStreamDeltaResponse(streamID, request, response)
+}
+
+func (m *xdsStreamMetrics) OnFetchRequest(ctx context.Context, request *discoveryv3.DiscoveryRequest) error {
+	return m.next.OnFetchRequest(ctx, request)
+}
+
+func (m *xdsStreamMetrics) OnFetchResponse(request *discoveryv3.DiscoveryRequest, response *discoveryv3.DiscoveryResponse) {
+	m.next.OnFetchResponse(request, response)
+}
@@ -0,0 +1,116 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"slices"
+	"sync"
+
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+)
+
+// xdsRequestKind classifies a DiscoveryRequest on a stream, see `xdsRequestTracker.request()`.
+type xdsRequestKind string
+
+const (
+	// requestKindSubscribe is the first request for a type URL on the stream.
+	requestKindSubscribe xdsRequestKind = "subscribe"
+	// requestKindACK accepts the most recent response for the type URL.
+	requestKindACK xdsRequestKind = "ACK"
+	// requestKindNACK rejects the most recent response for the type URL, with error detail.
+	requestKindNACK xdsRequestKind = "NACK"
+	// requestKindStale has a response nonce that does not match the most recent response for the
+	// type URL, e.g., because the control plane sent another response in the meantime.
+	requestKindStale xdsRequestKind = "stale"
+)
+
+// xdsRequestTracker records the most recent response nonce and the requested resource names of each
+// type URL on each xDS stream, so that requests can be logged as new subscriptions, ACKs, or NACKs.
+//
+// See [xDS protocol ACK/NACK and resource type instance version]: https://www.envoyproxy.io/docs/envoy/latest/api-docs/xds_protocol#ack-and-nack-semantics
+type xdsRequestTracker struct {
+	mu      sync.Mutex
+	streams map[int64]map[string]*xdsTypeState
+}
+
+// xdsTypeState is the state of one type URL on an xDS stream.
+type xdsTypeState struct {
+	// nonce of the most recent response sent on the stream for the type URL.
+	nonce string
+	// resourceNames of the most recent request on the stream for the type URL.
+	resourceNames []string
+}
+
+func newXDSRequestTracker() *xdsRequestTracker {
+	return &xdsRequestTracker{
+		streams: map[int64]map[string]*xdsTypeState{},
+	}
+}
+
+// request classifies the request, and returns true if the requested resource names differ from
+// the previous request for the same type URL on the stream.
+func (t *xdsRequestTracker) request(streamID int64, request *discoveryv3.DiscoveryRequest) (xdsRequestKind, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	types, exists := t.streams[streamID]
+	if !exists {
+		types = map[string]*xdsTypeState{}
+		t.streams[streamID] = types
+	}
+	resourceNames := slices.Clone(request.GetResourceNames())
+	slices.Sort(resourceNames)
+	state, exists := types[request.GetTypeUrl()]
+	if !exists {
+		types[request.GetTypeUrl()] = &xdsTypeState{resourceNames: resourceNames}
+		return requestKindSubscribe, true
+	}
+	subscriptionChanged := !slices.Equal(state.resourceNames, resourceNames)
+	state.resourceNames = resourceNames
+	switch {
+	case request.GetResponseNonce() == "":
+		// E.g., a client that reconnected to the control plane without closing the stream.
+		return requestKindSubscribe, subscriptionChanged
+	case request.GetResponseNonce() != state.nonce:
+		return requestKindStale, subscriptionChanged
+	case request.GetErrorDetail() != nil:
+		return requestKindNACK, subscriptionChanged
+	default:
+		return requestKindACK, subscriptionChanged
+	}
+}
+
+// response records the nonce of a response sent on the stream.
+func (t *xdsRequestTracker) response(streamID int64, response *discoveryv3.DiscoveryResponse) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	types, exists := t.streams[streamID]
+	if !exists {
+		types = map[string]*xdsTypeState{}
+		t.streams[streamID] = types
+	}
+	state, exists := types[response.GetTypeUrl()]
+	if !exists {
+		state = &xdsTypeState{}
+		types[response.GetTypeUrl()] = state
+	}
+	state.nonce = response.GetNonce()
+}
+
+// streamClosed removes the state of the stream.
+func (t *xdsRequestTracker) streamClosed(streamID int64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.streams, streamID)
+}
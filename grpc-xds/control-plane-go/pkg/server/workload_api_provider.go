@@ -0,0 +1,99 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"time"
+
+	"github.com/spiffe/go-spiffe/v2/spiffeid"
+	"github.com/spiffe/go-spiffe/v2/workloadapi"
+	"google.golang.org/grpc/credentials/tls/certprovider"
+)
+
+// workloadAPIFetchTimeout is how long to wait for the first X.509 SVID from the SPIFFE Workload API on startup.
+const workloadAPIFetchTimeout = 30 * time.Second
+
+// workloadAPIProvider is a certificate provider that returns the X.509 SVID and trust bundles from the
+// SPIFFE Workload API, e.g., from a SPIRE agent, instead of reading PEM files. The X509Source keeps
+// the SVID and bundles up to date by watching the Workload API.
+type workloadAPIProvider struct {
+	source *workloadapi.X509Source
+	// trustDomains are the additional trust domains, other than the trust domain of the SVID,
+	// whose bundles are included in the root certificates.
+	trustDomains []spiffeid.TrustDomain
+}
+
+var _ certprovider.Provider = &workloadAPIProvider{}
+
+func newWorkloadAPIProvider(ctx context.Context, socket string, trustDomainNames []string) (*workloadAPIProvider, error) {
+	var trustDomains []spiffeid.TrustDomain
+	for _, name := range trustDomainNames {
+		trustDomain, err := spiffeid.TrustDomainFromString(name)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse trust domain name %s: %w", name, err)
+		}
+		trustDomains = append(trustDomains, trustDomain)
+	}
+	ctx, cancel := context.WithTimeout(ctx, workloadAPIFetchTimeout)
+	defer cancel()
+	source, err := workloadapi.NewX509Source(ctx, workloadapi.WithClientOptions(workloadapi.WithAddr(socket)))
+	if err != nil {
+		return nil, fmt.Errorf("could not fetch X.509 SVID from the SPIFFE Workload API at %s: %w", socket, err)
+	}
+	return &workloadAPIProvider{
+		source:       source,
+		trustDomains: trustDomains,
+	}, nil
+}
+
+// KeyMaterial returns the current X.509 SVID as the identity certificate, and the trust bundles
+// of the trust domain of the SVID and of the additional trust domains as the root certificates.
+func (p *workloadAPIProvider) KeyMaterial(_ context.Context) (*certprovider.KeyMaterial, error) {
+	svid, err := p.source.GetX509SVID()
+	if err != nil {
+		return nil, fmt.Errorf("could not get X.509 SVID: %w", err)
+	}
+	certificate := tls.Certificate{
+		PrivateKey: svid.PrivateKey,
+		Leaf:       svid.Certificates[0],
+	}
+	for _, cert := range svid.Certificates {
+		certificate.Certificate = append(certificate.Certificate, cert.Raw)
+	}
+	roots := x509.NewCertPool()
+	for _, trustDomain := range append([]spiffeid.TrustDomain{svid.ID.TrustDomain()}, p.trustDomains...) {
+		bundle, err := p.source.GetX509BundleForTrustDomain(trustDomain)
+		if err != nil {
+			// The Workload API only returns bundles of federated trust domains.
+			continue
+		}
+		for _, authority := range bundle.X509Authorities() {
+			roots.AddCert(authority)
+		}
+	}
+	return &certprovider.KeyMaterial{
+		Certs: []tls.Certificate{certificate},
+		Roots: roots,
+	}, nil
+}
+
+// Close stops watching the SPIFFE Workload API.
+func (p *workloadAPIProvider) Close() {
+	_ = p.source.Close()
+}
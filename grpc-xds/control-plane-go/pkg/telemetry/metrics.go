@@ -0,0 +1,46 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package telemetry exports metrics of the control plane.
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/otel"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+)
+
+// InitMeterProvider creates an OpenTelemetry MeterProvider that exports metrics
+// in the Prometheus exposition format, and sets it as the global MeterProvider.
+//
+// The returned function shuts down the MeterProvider.
+func InitMeterProvider() (func(context.Context) error, error) {
+	exporter, err := otelprometheus.New()
+	if err != nil {
+		return nil, fmt.Errorf("could not create OpenTelemetry Prometheus exporter: %w", err)
+	}
+	meterProvider := sdkmetric.NewMeterProvider(sdkmetric.WithReader(exporter))
+	otel.SetMeterProvider(meterProvider)
+	return meterProvider.Shutdown, nil
+}
+
+// MetricsHandler serves the metrics in the Prometheus exposition format.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
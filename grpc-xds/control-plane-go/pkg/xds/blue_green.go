@@ -0,0 +1,181 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+// blueGreenKubecontextName is the kubecontext name for the routes of blue/green switchovers in the
+// route cache. Each switchover uses the application name as the namespace.
+const blueGreenKubecontextName = "blue-green"
+
+var (
+	// ErrInvalidBlueGreenTarget means that the application or the target application of a
+	// blue/green switchover does not exist, that the target has no healthy endpoints, or that
+	// other routes, e.g., from a canary rollout or a GRPCRoute, use the listener of the application.
+	ErrInvalidBlueGreenTarget       = errors.New("invalid blue/green target")
+	errBlueGreenApplicationNotFound = fmt.Errorf("%w: application not found", ErrInvalidBlueGreenTarget)
+	errBlueGreenTargetNotFound      = fmt.Errorf("%w: target application not found", ErrInvalidBlueGreenTarget)
+	errBlueGreenNoHealthyEndpoints  = fmt.Errorf("%w: target application has no healthy endpoints", ErrInvalidBlueGreenTarget)
+	errBlueGreenConflictingRoutes   = fmt.Errorf("%w: other routes use the listener of the application", ErrInvalidBlueGreenTarget)
+)
+
+// BlueGreenTargets returns the target applications of blue/green switchovers, by application name.
+func (c *SnapshotCache) BlueGreenTargets() map[string]string {
+	c.blueGreenMu.Lock()
+	defer c.blueGreenMu.Unlock()
+	return maps.Clone(c.blueGreenTargets)
+}
+
+// SwitchBlueGreen routes all requests to the listener of the application to the target
+// application, e.g., from the Cluster `greeter-leaf-blue` to the Cluster `greeter-leaf-green`,
+// and generates new snapshots for all node hashes. Each snapshot switches all routes at once.
+// Switching to the application itself removes the switchover.
+//
+// The application must exist, the target application must have at least one healthy endpoint,
+// and no other routes, such as the routes of canary rollouts or GRPCRoutes, may use the listener
+// of the application, otherwise SwitchBlueGreen returns an error that wraps
+// `ErrInvalidBlueGreenTarget`. Routes added for the listener after the switchover are ignored
+// until the switchover is removed, see `blueGreenPrecedence()`. SwitchBlueGreen returns the previous
+// target, which is the application itself if there was no switchover. If the new snapshots
+// cannot be created, the previous target remains in place.
+func (c *SnapshotCache) SwitchBlueGreen(ctx context.Context, logger logr.Logger, appName string, target string) (string, error) {
+	c.blueGreenMu.Lock()
+	defer c.blueGreenMu.Unlock()
+	previousTarget, exists := c.blueGreenTargets[appName]
+	if !exists {
+		previousTarget = appName
+	}
+	if target != appName {
+		if err := c.validateBlueGreenApplication(appName); err != nil {
+			return previousTarget, fmt.Errorf("could not switch application %s to %s: %w", appName, target, err)
+		}
+		if err := c.validateBlueGreenTarget(target); err != nil {
+			return previousTarget, fmt.Errorf("could not switch application %s to %s: %w", appName, target, err)
+		}
+	}
+	logger.V(2).Info("Switching blue/green target", "app", appName, "previousTarget", previousTarget, "target", target)
+	if err := c.UpdateRoutes(ctx, logger, blueGreenKubecontextName, appName, blueGreenRoutes(appName, target)); err != nil {
+		// Restore the routes of the previous target, so that later snapshots don't pick up the
+		// switchover that failed.
+		c.routesCache.Put(blueGreenKubecontextName, appName, blueGreenRoutes(appName, previousTarget))
+		return previousTarget, fmt.Errorf("could not switch application %s to %s: %w", appName, target, err)
+	}
+	if target == appName {
+		delete(c.blueGreenTargets, appName)
+	} else {
+		c.blueGreenTargets[appName] = target
+	}
+	return previousTarget, nil
+}
+
+// blueGreenRoutes returns the route that sends all requests to the listener of the application
+// to the target application, or no routes if the target is the application itself.
+func blueGreenRoutes(appName string, target string) []applications.Route {
+	if target == appName {
+		return nil
+	}
+	return []applications.Route{
+		{
+			Namespace: appName,
+			Name:      blueGreenRouteName(appName),
+			Hostnames: []string{appName},
+			Rules: []applications.RouteRule{
+				{Backends: []applications.RouteBackend{{Name: target, Weight: 1}}},
+			},
+		},
+	}
+}
+
+// validateBlueGreenTarget returns an error unless the target application has a healthy endpoint.
+func (c *SnapshotCache) validateBlueGreenTarget(target string) error {
+	found := false
+	for _, app := range c.apps() {
+		if app.Name != target {
+			continue
+		}
+		found = true
+		for _, endpoints := range app.Endpoints {
+			if endpoints.EndpointStatus == applications.Healthy && len(endpoints.Addresses) > 0 {
+				return nil
+			}
+		}
+	}
+	if !found {
+		return fmt.Errorf("%w: %s", errBlueGreenTargetNotFound, target)
+	}
+	return fmt.Errorf("%w: %s", errBlueGreenNoHealthyEndpoints, target)
+}
+
+// blueGreenRouteName returns the name of the route of the blue/green switchover of the application.
+func blueGreenRouteName(appName string) string {
+	return appName + "-blue-green"
+}
+
+// isBlueGreenRoute returns true if the route is the route of a blue/green switchover.
+func isBlueGreenRoute(route applications.Route) bool {
+	return route.Name == blueGreenRouteName(route.Namespace)
+}
+
+// validateBlueGreenApplication returns an error unless the application exists, and no routes other
+// than the route of its blue/green switchover use its listener. Routes for the same listener are
+// merged, ordered by route name, so other routes could shadow the switchover.
+func (c *SnapshotCache) validateBlueGreenApplication(appName string) error {
+	if !slices.ContainsFunc(c.apps(), func(app applications.Application) bool { return app.Name == appName }) {
+		return fmt.Errorf("%w: %s", errBlueGreenApplicationNotFound, appName)
+	}
+	for _, route := range c.routesCache.GetAll() {
+		if !isBlueGreenRoute(route) && slices.Contains(route.Hostnames, appName) {
+			return fmt.Errorf("%w: application=%s route=%s/%s", errBlueGreenConflictingRoutes, appName, route.Namespace, route.Name)
+		}
+	}
+	return nil
+}
+
+// blueGreenPrecedence removes the listeners of applications with a blue/green switchover from the
+// hostnames of other routes, so that routes added after the switchover, e.g., from GRPCRoutes,
+// don't shadow it. Routes without remaining hostnames are removed.
+func blueGreenPrecedence(routes []applications.Route) []applications.Route {
+	switched := map[string]bool{}
+	for _, route := range routes {
+		if isBlueGreenRoute(route) {
+			switched[route.Namespace] = true
+		}
+	}
+	if len(switched) == 0 {
+		return routes
+	}
+	var result []applications.Route
+	for _, route := range routes {
+		if !isBlueGreenRoute(route) {
+			// Clone the hostnames, because the routes share them with the route cache.
+			route.Hostnames = slices.DeleteFunc(slices.Clone(route.Hostnames), func(hostname string) bool { return switched[hostname] })
+			if len(route.Hostnames) == 0 {
+				continue
+			}
+		}
+		result = append(result, route)
+	}
+	return result
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+func TestSwitchBlueGreen(t *testing.T) {
+	ctx := logging.NewContext(context.Background(), logr.Discard())
+	xdsCache := NewSnapshotCache(ctx, ZoneHash{}, eds.LocalityPriorityByZone{}, &Features{}, goldenAuthority)
+	apps := []applications.Application{
+		applications.NewApplication("xds", "greeter-leaf", 50051, "grpc", 50052, "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("greeter-leaf-abcde", "node-a", goldenZone, "", []string{"10.0.0.29"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+		}),
+		applications.NewApplication("xds", "greeter-leaf-green", 50051, "grpc", 50052, "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("greeter-leaf-green-abcde", "node-a", goldenZone, "", []string{"10.0.0.30"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+		}),
+		applications.NewApplication("xds", "greeter-leaf-draining", 50051, "grpc", 50052, "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("greeter-leaf-draining-abcde", "node-a", goldenZone, "", []string{"10.0.0.31"}, applications.AddressTypeIPv4, applications.Draining, nil),
+		}),
+	}
+	if err := xdsCache.UpdateResources(ctx, logr.Discard(), goldenCluster, "xds", apps); err != nil {
+		t.Fatalf("could not update resources: %v", err)
+	}
+
+	if _, err := xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-missing", "greeter-leaf-green"); !errors.Is(err, errBlueGreenApplicationNotFound) {
+		t.Errorf("expected %v for missing application, got %v", errBlueGreenApplicationNotFound, err)
+	}
+	if _, err := xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-leaf", "greeter-leaf-missing"); !errors.Is(err, errBlueGreenTargetNotFound) {
+		t.Errorf("expected %v for missing target, got %v", errBlueGreenTargetNotFound, err)
+	}
+	if _, err := xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-leaf", "greeter-leaf-draining"); !errors.Is(err, errBlueGreenNoHealthyEndpoints) {
+		t.Errorf("expected %v for target without healthy endpoints, got %v", errBlueGreenNoHealthyEndpoints, err)
+	}
+	previousTarget, err := xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-leaf", "greeter-leaf-green")
+	if err != nil {
+		t.Fatalf("could not switch to green: %v", err)
+	}
+	if previousTarget != "greeter-leaf" {
+		t.Errorf("previous target = %s, want greeter-leaf", previousTarget)
+	}
+	if target := xdsCache.BlueGreenTargets()["greeter-leaf"]; target != "greeter-leaf-green" {
+		t.Errorf("target = %s, want greeter-leaf-green", target)
+	}
+	if _, err := xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-leaf", "greeter-leaf-draining"); !errors.Is(err, ErrInvalidBlueGreenTarget) {
+		t.Errorf("expected %v for invalid target, got %v", ErrInvalidBlueGreenTarget, err)
+	}
+	if target := xdsCache.BlueGreenTargets()["greeter-leaf"]; target != "greeter-leaf-green" {
+		t.Errorf("target after failed switch = %s, want greeter-leaf-green", target)
+	}
+	previousTarget, err = xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-leaf", "greeter-leaf")
+	if err != nil {
+		t.Fatalf("could not remove switchover: %v", err)
+	}
+	if previousTarget != "greeter-leaf-green" {
+		t.Errorf("previous target = %s, want greeter-leaf-green", previousTarget)
+	}
+	if len(xdsCache.BlueGreenTargets()) != 0 {
+		t.Errorf("expected no switchovers, got %v", xdsCache.BlueGreenTargets())
+	}
+}
+
+func TestSwitchBlueGreenConflictingRoutes(t *testing.T) {
+	ctx := logging.NewContext(context.Background(), logr.Discard())
+	xdsCache := NewSnapshotCache(ctx, ZoneHash{}, eds.LocalityPriorityByZone{}, &Features{}, goldenAuthority)
+	apps := []applications.Application{
+		applications.NewApplication("xds", "greeter-leaf", 50051, "grpc", 50052, "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("greeter-leaf-abcde", "node-a", goldenZone, "", []string{"10.0.0.29"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+		}),
+		applications.NewApplication("xds", "greeter-leaf-green", 50051, "grpc", 50052, "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("greeter-leaf-green-abcde", "node-a", goldenZone, "", []string{"10.0.0.30"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+		}),
+	}
+	if err := xdsCache.UpdateResources(ctx, logr.Discard(), goldenCluster, "xds", apps); err != nil {
+		t.Fatalf("could not update resources: %v", err)
+	}
+	canaryRoutes := []applications.Route{
+		{
+			Namespace: "greeter-leaf",
+			Name:      "greeter-leaf-canary",
+			Hostnames: []string{"greeter-leaf"},
+			Rules:     []applications.RouteRule{{Backends: []applications.RouteBackend{{Name: "greeter-leaf-green", Weight: 10}}}},
+		},
+	}
+	if err := xdsCache.UpdateRoutes(ctx, logr.Discard(), "canary-rollouts", "greeter-leaf", canaryRoutes); err != nil {
+		t.Fatalf("could not update routes: %v", err)
+	}
+	if _, err := xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-leaf", "greeter-leaf-green"); !errors.Is(err, errBlueGreenConflictingRoutes) {
+		t.Errorf("expected %v with canary route, got %v", errBlueGreenConflictingRoutes, err)
+	}
+	if err := xdsCache.UpdateRoutes(ctx, logr.Discard(), "canary-rollouts", "greeter-leaf", nil); err != nil {
+		t.Fatalf("could not remove routes: %v", err)
+	}
+	if _, err := xdsCache.SwitchBlueGreen(ctx, logr.Discard(), "greeter-leaf", "greeter-leaf-green"); err != nil {
+		t.Fatalf("could not switch to green: %v", err)
+	}
+}
+
+func TestBlueGreenPrecedence(t *testing.T) {
+	blueGreen := blueGreenRoutes("greeter-leaf", "greeter-leaf-green")[0]
+	grpcRoute := applications.Route{
+		Namespace: "xds",
+		Name:      "greeter",
+		Hostnames: []string{"greeter-intermediary", "greeter-leaf"},
+	}
+	otherRoute := applications.Route{
+		Namespace: "xds",
+		Name:      "greeter-leaf-only",
+		Hostnames: []string{"greeter-leaf"},
+	}
+	got := blueGreenPrecedence([]applications.Route{blueGreen, grpcRoute, otherRoute})
+	if len(got) != 2 {
+		t.Fatalf("blueGreenPrecedence() returned %d routes, want 2: %+v", len(got), got)
+	}
+	if !got[0].Equal(blueGreen) {
+		t.Errorf("blueGreenPrecedence()[0] = %+v, want %+v", got[0], blueGreen)
+	}
+	if got[1].Name != "greeter" || len(got[1].Hostnames) != 1 || got[1].Hostnames[0] != "greeter-intermediary" {
+		t.Errorf("blueGreenPrecedence()[1] = %+v, want route greeter for greeter-intermediary only", got[1])
+	}
+	if len(grpcRoute.Hostnames) != 2 {
+		t.Errorf("blueGreenPrecedence() modified the hostnames of the input route: %v", grpcRoute.Hostnames)
+	}
+}
@@ -0,0 +1,264 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bootstrap renders gRPC xDS bootstrap configurations for clients of this control plane,
+// and static bootstrap configurations for Envoy proxies.
+//
+// See [gRFC A27: xDS-Based Global Load Balancing]: https://github.com/grpc/proposal/blob/master/A27-xds-global-load-balancing.md#xdsclient-and-bootstrap-file
+// and [gRFC A47: xDS Federation]: https://github.com/grpc/proposal/blob/master/A47-xds-federation.md#bootstrap-config-changes.
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strconv"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+)
+
+const (
+	workloadCredentialsDir            = "/var/run/secrets/workload-spiffe-credentials"
+	defaultCertificateFile            = workloadCredentialsDir + "/certificates.pem"
+	defaultPrivateKeyFile             = workloadCredentialsDir + "/private_key.pem"
+	defaultCACertificateFile          = workloadCredentialsDir + "/ca_certificates.pem"
+	defaultCertificateRefreshInterval = 600 * time.Second
+)
+
+// Options for rendering a gRPC xDS bootstrap configuration.
+type Options struct {
+	// Authority is the xDS federation authority name of this control plane, see `config.AuthorityName()`.
+	Authority string
+	// Port is the serving port of the control plane management servers.
+	Port int
+	// ServerURI of the default xDS management server. Defaults to `dns:///[Authority]:[Port]`.
+	ServerURI string
+	// FederationAuthorities are the authority names of other control planes, e.g.,
+	// control planes in other Kubernetes clusters.
+	FederationAuthorities []string
+	// NamespaceAuthorities are additional authority names of this control plane, e.g., the
+	// `authority` names of namespaces in the informer configuration. Clients use the default
+	// xDS management server for these authorities.
+	NamespaceAuthorities []string
+	NodeID               string
+	NodeCluster          string
+	Zone                 string
+	// ClusterName is added to the node metadata for the `clusterAndZone` locality priority policy.
+	ClusterName string
+	// XDSFeatures determine the channel credentials, the certificate provider instance name, and the use of xDS federation.
+	XDSFeatures *xds.Features
+	// CertificateFile, PrivateKeyFile, and CACertificateFile are the paths of the workload certificate files
+	// of the client, e.g., files mounted by the cert-manager CSI driver or written by a SPIRE agent.
+	// Default to the files in `/var/run/secrets/workload-spiffe-credentials`.
+	CertificateFile   string
+	PrivateKeyFile    string
+	CACertificateFile string
+	// CertificateRefreshInterval is how often the client reloads the certificate files. Defaults to 10 minutes.
+	CertificateRefreshInterval time.Duration
+	// NodeMetadata is additional node metadata for Envoy proxies, e.g., `K8S_NAMESPACE` and `K8S_POD`.
+	NodeMetadata map[string]string
+	// EnvoyAdminPort is the port of the Envoy admin interface. Defaults to 19000.
+	EnvoyAdminPort int
+	// ServerCertificateFile, ServerPrivateKeyFile, and ServerCACertificateFile are the paths of the
+	// certificate files that Envoy proxies use for the Listener receiving gRPC requests.
+	// Default to the files in `/etc/envoy-ssl`.
+	ServerCertificateFile   string
+	ServerPrivateKeyFile    string
+	ServerCACertificateFile string
+}
+
+// Render returns the gRPC xDS bootstrap configuration in JSON format.
+func Render(opts Options) ([]byte, error) {
+	serverURI := defaultServerURI(opts)
+	setCertificateFileDefaults(&opts)
+	channelCreds := newChannelCreds(opts)
+	metadata := map[string]string{
+		"XDS_STREAM_TYPE": "ADS",
+	}
+	if opts.ClusterName != "" {
+		metadata[xds.NodeMetadataClusterName] = opts.ClusterName
+	}
+	config := bootstrapJSON{
+		XDSServers: []xdsServerJSON{newXDSServer(serverURI, channelCreds)},
+		Node: nodeJSON{
+			ID:       opts.NodeID,
+			Cluster:  opts.NodeCluster,
+			Metadata: metadata,
+		},
+		CertificateProviders: map[string]certificateProviderJSON{
+			opts.XDSFeatures.CertificateProvider().InstanceName: {
+				PluginName: "file_watcher",
+				Config:     newCertificateFiles(opts, true),
+			},
+		},
+		ServerListenerResourceNameTemplate: lds.GRPCServerListenerResourceNameTemplate,
+	}
+	if opts.Zone != "" {
+		config.Node.Locality = &localityJSON{Zone: opts.Zone}
+	}
+	if opts.XDSFeatures.EnableFederation {
+		config.Authorities = newAuthorities(opts, serverURI, channelCreds)
+		config.ClientDefaultListenerResourceNameTemplate = "%s"
+	}
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal gRPC xDS bootstrap configuration: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// RenderAuthorities returns the `authorities` section of the gRPC xDS bootstrap configuration in
+// JSON format, e.g., to merge into the bootstrap configuration files of existing clients.
+func RenderAuthorities(opts Options) ([]byte, error) {
+	serverURI := defaultServerURI(opts)
+	setCertificateFileDefaults(&opts)
+	authorities := map[string]map[string]authorityJSON{
+		"authorities": newAuthorities(opts, serverURI, newChannelCreds(opts)),
+	}
+	data, err := json.MarshalIndent(authorities, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal gRPC xDS bootstrap authorities: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// defaultServerURI returns the URI of the default xDS management server.
+func defaultServerURI(opts Options) string {
+	if opts.ServerURI != "" {
+		return opts.ServerURI
+	}
+	return "dns:///" + net.JoinHostPort(opts.Authority, strconv.Itoa(opts.Port))
+}
+
+// newAuthorities returns the authorities of this control plane, including the namespace
+// authorities, and of the control planes in other clusters.
+func newAuthorities(opts Options, serverURI string, channelCreds channelCredsJSON) map[string]authorityJSON {
+	authorities := map[string]authorityJSON{
+		opts.Authority: newAuthority(opts.Authority, serverURI, channelCreds),
+	}
+	for _, authority := range opts.NamespaceAuthorities {
+		authorities[authority] = newAuthority(authority, serverURI, channelCreds)
+	}
+	for _, authority := range opts.FederationAuthorities {
+		authorityServerURI := "dns:///" + net.JoinHostPort(authority, strconv.Itoa(opts.Port))
+		authorities[authority] = newAuthority(authority, authorityServerURI, channelCreds)
+	}
+	return authorities
+}
+
+// newChannelCreds returns the credentials for connecting to the control plane management servers,
+// see [gRFC A65: mTLS Credentials in xDS Bootstrap File]: https://github.com/grpc/proposal/blob/master/A65-xds-mtls-creds-in-bootstrap.md
+func newChannelCreds(opts Options) channelCredsJSON {
+	if !opts.XDSFeatures.EnableControlPlaneTLS {
+		return channelCredsJSON{Type: "insecure"}
+	}
+	files := newCertificateFiles(opts, opts.XDSFeatures.RequireControlPlaneClientCerts)
+	return channelCredsJSON{
+		Type:   "tls",
+		Config: &files,
+	}
+}
+
+func setCertificateFileDefaults(opts *Options) {
+	if opts.CertificateFile == "" {
+		opts.CertificateFile = defaultCertificateFile
+	}
+	if opts.PrivateKeyFile == "" {
+		opts.PrivateKeyFile = defaultPrivateKeyFile
+	}
+	if opts.CACertificateFile == "" {
+		opts.CACertificateFile = defaultCACertificateFile
+	}
+	if opts.CertificateRefreshInterval <= 0 {
+		opts.CertificateRefreshInterval = defaultCertificateRefreshInterval
+	}
+}
+
+func newCertificateFiles(opts Options, includeCertificate bool) certificateFilesJSON {
+	files := certificateFilesJSON{
+		CACertificateFile: opts.CACertificateFile,
+		// JSON representation of a protobuf Duration, e.g., `600s`.
+		RefreshInterval: strconv.FormatFloat(opts.CertificateRefreshInterval.Seconds(), 'f', -1, 64) + "s",
+	}
+	if includeCertificate {
+		files.CertificateFile = opts.CertificateFile
+		files.PrivateKeyFile = opts.PrivateKeyFile
+	}
+	return files
+}
+
+func newXDSServer(serverURI string, channelCreds channelCredsJSON) xdsServerJSON {
+	return xdsServerJSON{
+		ServerURI:      serverURI,
+		ChannelCreds:   []channelCredsJSON{channelCreds},
+		ServerFeatures: []string{"xds_v3", "ignore_resource_deletion"},
+	}
+}
+
+func newAuthority(authority string, serverURI string, channelCreds channelCredsJSON) authorityJSON {
+	return authorityJSON{
+		XDSServers:                         []xdsServerJSON{newXDSServer(serverURI, channelCreds)},
+		ClientListenerResourceNameTemplate: fmt.Sprintf("xdstp://%s/envoy.config.listener.v3.Listener/%%s", authority),
+	}
+}
+
+type bootstrapJSON struct {
+	XDSServers                                []xdsServerJSON                    `json:"xds_servers"`
+	Authorities                               map[string]authorityJSON           `json:"authorities,omitempty"`
+	Node                                      nodeJSON                           `json:"node"`
+	CertificateProviders                      map[string]certificateProviderJSON `json:"certificate_providers"`
+	ServerListenerResourceNameTemplate        string                             `json:"server_listener_resource_name_template"`
+	ClientDefaultListenerResourceNameTemplate string                             `json:"client_default_listener_resource_name_template,omitempty"`
+}
+
+type xdsServerJSON struct {
+	ServerURI      string             `json:"server_uri"`
+	ChannelCreds   []channelCredsJSON `json:"channel_creds"`
+	ServerFeatures []string           `json:"server_features"`
+}
+
+type channelCredsJSON struct {
+	Type   string                `json:"type"`
+	Config *certificateFilesJSON `json:"config,omitempty"`
+}
+
+type authorityJSON struct {
+	XDSServers                         []xdsServerJSON `json:"xds_servers"`
+	ClientListenerResourceNameTemplate string          `json:"client_listener_resource_name_template"`
+}
+
+type nodeJSON struct {
+	ID       string            `json:"id"`
+	Cluster  string            `json:"cluster,omitempty"`
+	Metadata map[string]string `json:"metadata"`
+	Locality *localityJSON     `json:"locality,omitempty"`
+}
+
+type localityJSON struct {
+	Zone string `json:"zone"`
+}
+
+type certificateProviderJSON struct {
+	PluginName string               `json:"plugin_name"`
+	Config     certificateFilesJSON `json:"config"`
+}
+
+type certificateFilesJSON struct {
+	CACertificateFile string `json:"ca_certificate_file"`
+	CertificateFile   string `json:"certificate_file,omitempty"`
+	PrivateKeyFile    string `json:"private_key_file,omitempty"`
+	RefreshInterval   string `json:"refresh_interval"`
+}
@@ -0,0 +1,251 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+// renderBootstrap renders the gRPC xDS bootstrap configuration, and unmarshals it.
+func renderBootstrap(t *testing.T, opts Options) bootstrapJSON {
+	t.Helper()
+	data, err := Render(opts)
+	if err != nil {
+		t.Fatalf("Render() error: %v", err)
+	}
+	var config bootstrapJSON
+	if err := json.Unmarshal(data, &config); err != nil {
+		t.Fatalf("could not unmarshal gRPC xDS bootstrap configuration: %v\n%s", err, data)
+	}
+	return config
+}
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name             string
+		opts             Options
+		wantServerURI    string
+		wantChannelCreds channelCredsJSON
+		// wantAuthorities are the authority names, sorted.
+		wantAuthorities []string
+	}{
+		{
+			name: "plaintext",
+			opts: Options{
+				Authority:   "control-plane.xds.svc.cluster.local",
+				Port:        50051,
+				XDSFeatures: &xds.Features{},
+			},
+			wantServerURI:    "dns:///control-plane.xds.svc.cluster.local:50051",
+			wantChannelCreds: channelCredsJSON{Type: "insecure"},
+		},
+		{
+			name: "server URI",
+			opts: Options{
+				Authority:   "control-plane.xds.svc.cluster.local",
+				Port:        50051,
+				ServerURI:   "xds-server.example.com:443",
+				XDSFeatures: &xds.Features{},
+			},
+			wantServerURI:    "xds-server.example.com:443",
+			wantChannelCreds: channelCredsJSON{Type: "insecure"},
+		},
+		{
+			name: "control plane TLS without client certificates",
+			opts: Options{
+				Authority:         "control-plane.xds.svc.cluster.local",
+				Port:              50051,
+				CACertificateFile: "/certs/ca.pem",
+				XDSFeatures:       &xds.Features{EnableControlPlaneTLS: true},
+			},
+			wantServerURI: "dns:///control-plane.xds.svc.cluster.local:50051",
+			wantChannelCreds: channelCredsJSON{
+				Type: "tls",
+				Config: &certificateFilesJSON{
+					CACertificateFile: "/certs/ca.pem",
+					RefreshInterval:   "600s",
+				},
+			},
+		},
+		{
+			name: "control plane mTLS",
+			opts: Options{
+				Authority:                  "control-plane.xds.svc.cluster.local",
+				Port:                       50051,
+				CertificateRefreshInterval: 90 * time.Second,
+				XDSFeatures:                &xds.Features{EnableControlPlaneTLS: true, RequireControlPlaneClientCerts: true},
+			},
+			wantServerURI: "dns:///control-plane.xds.svc.cluster.local:50051",
+			wantChannelCreds: channelCredsJSON{
+				Type: "tls",
+				Config: &certificateFilesJSON{
+					CACertificateFile: defaultCACertificateFile,
+					CertificateFile:   defaultCertificateFile,
+					PrivateKeyFile:    defaultPrivateKeyFile,
+					RefreshInterval:   "90s",
+				},
+			},
+		},
+		{
+			name: "federation",
+			opts: Options{
+				Authority:             "control-plane.xds.svc.cluster.local",
+				Port:                  50051,
+				FederationAuthorities: []string{"control-plane.xds.svc.cluster2.example.com"},
+				NamespaceAuthorities:  []string{"team-a.xds.example.com"},
+				XDSFeatures:           &xds.Features{EnableFederation: true},
+			},
+			wantServerURI:    "dns:///control-plane.xds.svc.cluster.local:50051",
+			wantChannelCreds: channelCredsJSON{Type: "insecure"},
+			wantAuthorities:  []string{"control-plane.xds.svc.cluster.local", "control-plane.xds.svc.cluster2.example.com", "team-a.xds.example.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			config := renderBootstrap(t, test.opts)
+			if len(config.XDSServers) != 1 {
+				t.Fatalf("got %d xDS servers, want 1", len(config.XDSServers))
+			}
+			server := config.XDSServers[0]
+			if server.ServerURI != test.wantServerURI {
+				t.Errorf("server_uri = %s, want %s", server.ServerURI, test.wantServerURI)
+			}
+			if len(server.ChannelCreds) != 1 {
+				t.Fatalf("got %d channel_creds, want 1", len(server.ChannelCreds))
+			}
+			gotCreds, _ := json.Marshal(server.ChannelCreds[0])
+			wantCreds, _ := json.Marshal(test.wantChannelCreds)
+			if string(gotCreds) != string(wantCreds) {
+				t.Errorf("channel_creds = %s, want %s", gotCreds, wantCreds)
+			}
+			var gotAuthorities []string
+			for authority := range config.Authorities {
+				gotAuthorities = append(gotAuthorities, authority)
+			}
+			slices.Sort(gotAuthorities)
+			if !slices.Equal(gotAuthorities, test.wantAuthorities) {
+				t.Errorf("authorities = %v, want %v", gotAuthorities, test.wantAuthorities)
+			}
+			wantTemplate := ""
+			if test.opts.XDSFeatures.EnableFederation {
+				wantTemplate = "%s"
+			}
+			if config.ClientDefaultListenerResourceNameTemplate != wantTemplate {
+				t.Errorf("client_default_listener_resource_name_template = %q, want %q", config.ClientDefaultListenerResourceNameTemplate, wantTemplate)
+			}
+		})
+	}
+}
+
+func TestRenderFederationAuthorities(t *testing.T) {
+	config := renderBootstrap(t, Options{
+		Authority:             "control-plane.xds.svc.cluster.local",
+		Port:                  50051,
+		FederationAuthorities: []string{"control-plane.xds.svc.cluster2.example.com"},
+		NamespaceAuthorities:  []string{"team-a.xds.example.com"},
+		XDSFeatures:           &xds.Features{EnableFederation: true},
+	})
+	tests := []struct {
+		authority     string
+		wantServerURI string
+	}{
+		{
+			authority:     "control-plane.xds.svc.cluster.local",
+			wantServerURI: "dns:///control-plane.xds.svc.cluster.local:50051",
+		},
+		{
+			// Namespace authorities use the management server of this control plane.
+			authority:     "team-a.xds.example.com",
+			wantServerURI: "dns:///control-plane.xds.svc.cluster.local:50051",
+		},
+		{
+			authority:     "control-plane.xds.svc.cluster2.example.com",
+			wantServerURI: "dns:///control-plane.xds.svc.cluster2.example.com:50051",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.authority, func(t *testing.T) {
+			authority, exists := config.Authorities[test.authority]
+			if !exists {
+				t.Fatalf("missing authority %s", test.authority)
+			}
+			if got := authority.XDSServers[0].ServerURI; got != test.wantServerURI {
+				t.Errorf("server_uri = %s, want %s", got, test.wantServerURI)
+			}
+			wantTemplate := "xdstp://" + test.authority + "/envoy.config.listener.v3.Listener/%s"
+			if authority.ClientListenerResourceNameTemplate != wantTemplate {
+				t.Errorf("client_listener_resource_name_template = %s, want %s", authority.ClientListenerResourceNameTemplate, wantTemplate)
+			}
+		})
+	}
+}
+
+func TestRenderNode(t *testing.T) {
+	config := renderBootstrap(t, Options{
+		Authority:   "control-plane.xds.svc.cluster.local",
+		Port:        50051,
+		NodeID:      "node-a",
+		NodeCluster: "greeter-intermediary",
+		Zone:        "us-central1-a",
+		ClusterName: "grpc-xds-2",
+		XDSFeatures: &xds.Features{},
+	})
+	if config.Node.ID != "node-a" || config.Node.Cluster != "greeter-intermediary" {
+		t.Errorf("node = %+v, want id=node-a and cluster=greeter-intermediary", config.Node)
+	}
+	if config.Node.Locality == nil || config.Node.Locality.Zone != "us-central1-a" {
+		t.Errorf("node locality = %+v, want zone=us-central1-a", config.Node.Locality)
+	}
+	if got := config.Node.Metadata[xds.NodeMetadataClusterName]; got != "grpc-xds-2" {
+		t.Errorf("node metadata %s = %q, want grpc-xds-2", xds.NodeMetadataClusterName, got)
+	}
+	if got := config.Node.Metadata["XDS_STREAM_TYPE"]; got != "ADS" {
+		t.Errorf("node metadata XDS_STREAM_TYPE = %q, want ADS", got)
+	}
+	provider, exists := config.CertificateProviders[(&xds.Features{}).CertificateProvider().InstanceName]
+	if !exists {
+		t.Fatalf("missing certificate provider, got %+v", config.CertificateProviders)
+	}
+	if provider.PluginName != "file_watcher" || provider.Config.CertificateFile != defaultCertificateFile {
+		t.Errorf("certificate provider = %+v, want file_watcher with the default certificate files", provider)
+	}
+	if config.ServerListenerResourceNameTemplate == "" {
+		t.Errorf("missing server_listener_resource_name_template")
+	}
+}
+
+func TestRenderAuthorities(t *testing.T) {
+	data, err := RenderAuthorities(Options{
+		Authority:             "control-plane.xds.svc.cluster.local",
+		Port:                  50051,
+		FederationAuthorities: []string{"control-plane.xds.svc.cluster2.example.com"},
+		XDSFeatures:           &xds.Features{},
+	})
+	if err != nil {
+		t.Fatalf("RenderAuthorities() error: %v", err)
+	}
+	var got map[string]map[string]authorityJSON
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("could not unmarshal authorities: %v\n%s", err, data)
+	}
+	if len(got) != 1 || len(got["authorities"]) != 2 {
+		t.Errorf("got %s, want one `authorities` section with two authorities", data)
+	}
+}
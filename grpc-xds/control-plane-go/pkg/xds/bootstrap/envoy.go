@@ -0,0 +1,342 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+
+	bootstrapv3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+var errUnknownAccessLogServiceCluster = errors.New("the access log service cluster must be the ADS cluster of the Envoy bootstrap configuration")
+
+const (
+	defaultEnvoyAdminPort          = 19000
+	envoyServerCredentialsDir      = "/etc/envoy-ssl"
+	defaultServerCertificateFile   = envoyServerCredentialsDir + "/certificates.pem"
+	defaultServerPrivateKeyFile    = envoyServerCredentialsDir + "/private_key.pem"
+	defaultServerCACertificateFile = envoyServerCredentialsDir + "/ca_certificates.pem"
+	envoyXDSClusterConnectTimeout  = 10
+	envoyHTTPProtocolOptionsName   = "envoy.extensions.upstreams.http.v3.HttpProtocolOptions"
+)
+
+// RenderEnvoy returns the static bootstrap configuration of Envoy proxies in YAML format.
+//
+// The feature flags determine the transport socket of the ADS cluster (`enableControlPlaneTls`
+// and `requireControlPlaneClientCerts`) and the static secrets that the TLS contexts of the xDS
+// resources refer to (`enableDataPlaneTls` and `requireDataPlaneClientCerts`). The secret names
+// are the names in the `tls` package.
+//
+// The bootstrap configuration only contains the static ADS cluster, so if `accessLog` is `grpc`,
+// the access log service cluster must be the ADS cluster, see `xds.EnvoyADSCluster`.
+func RenderEnvoy(opts Options) ([]byte, error) {
+	setCertificateFileDefaults(&opts)
+	setEnvoyDefaults(&opts)
+	if err := validateEnvoyAccessLogServiceCluster(opts.XDSFeatures); err != nil {
+		return nil, err
+	}
+	host, port, err := envoyServerAddress(opts)
+	if err != nil {
+		return nil, err
+	}
+	node, err := newEnvoyNode(opts)
+	if err != nil {
+		return nil, err
+	}
+	xdsCluster, err := newEnvoyXDSCluster(opts, host, port)
+	if err != nil {
+		return nil, err
+	}
+	adsConfigSource := &corev3.ConfigSource{
+		ResourceApiVersion: corev3.ApiVersion_V3,
+		ConfigSourceSpecifier: &corev3.ConfigSource_Ads{
+			Ads: &corev3.AggregatedConfigSource{},
+		},
+	}
+	config := &bootstrapv3.Bootstrap{
+		Node: node,
+		DynamicResources: &bootstrapv3.Bootstrap_DynamicResources{
+			AdsConfig: &corev3.ApiConfigSource{
+				ApiType:             corev3.ApiConfigSource_GRPC,
+				TransportApiVersion: corev3.ApiVersion_V3,
+				GrpcServices: []*corev3.GrpcService{
+					{
+						TargetSpecifier: &corev3.GrpcService_EnvoyGrpc_{
+							EnvoyGrpc: &corev3.GrpcService_EnvoyGrpc{
+								ClusterName: xds.EnvoyADSCluster,
+							},
+						},
+					},
+				},
+			},
+			CdsConfig: adsConfigSource,
+			LdsConfig: adsConfigSource,
+		},
+		StaticResources: &bootstrapv3.Bootstrap_StaticResources{
+			Clusters: []*clusterv3.Cluster{xdsCluster},
+			Secrets:  newEnvoySecrets(opts),
+		},
+		Admin: &bootstrapv3.Admin{
+			Address: &corev3.Address{
+				Address: &corev3.Address_SocketAddress{
+					SocketAddress: &corev3.SocketAddress{
+						Address: "0.0.0.0",
+						PortSpecifier: &corev3.SocketAddress_PortValue{
+							PortValue: uint32(opts.EnvoyAdminPort),
+						},
+					},
+				},
+			},
+		},
+	}
+	if err := config.ValidateAll(); err != nil {
+		return nil, fmt.Errorf("invalid Envoy bootstrap configuration: %w", err)
+	}
+	jsonData, err := protojson.MarshalOptions{UseProtoNames: true}.Marshal(config)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal Envoy bootstrap configuration to JSON: %w", err)
+	}
+	// JSON is valid YAML, so decode the JSON as YAML and encode it again for readability.
+	var yamlData interface{}
+	if err := yaml.Unmarshal(jsonData, &yamlData); err != nil {
+		return nil, fmt.Errorf("could not convert Envoy bootstrap configuration to YAML: %w", err)
+	}
+	var buf strings.Builder
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(yamlData); err != nil {
+		return nil, fmt.Errorf("could not marshal Envoy bootstrap configuration to YAML: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("could not marshal Envoy bootstrap configuration to YAML: %w", err)
+	}
+	return []byte(buf.String()), nil
+}
+
+// validateEnvoyAccessLogServiceCluster returns an error if Envoy proxies would send access logs to
+// a cluster that is not in the bootstrap configuration.
+func validateEnvoyAccessLogServiceCluster(xdsFeatures *xds.Features) error {
+	accessLog := xdsFeatures.AccessLogConfig()
+	if accessLog.Type != lds.AccessLogGRPC || accessLog.GRPCServiceCluster == xds.EnvoyADSCluster {
+		return nil
+	}
+	return fmt.Errorf("%w: accessLogServiceCluster=%s, want %s", errUnknownAccessLogServiceCluster, accessLog.GRPCServiceCluster, xds.EnvoyADSCluster)
+}
+
+func setEnvoyDefaults(opts *Options) {
+	if opts.EnvoyAdminPort <= 0 {
+		opts.EnvoyAdminPort = defaultEnvoyAdminPort
+	}
+	if opts.ServerCertificateFile == "" {
+		opts.ServerCertificateFile = defaultServerCertificateFile
+	}
+	if opts.ServerPrivateKeyFile == "" {
+		opts.ServerPrivateKeyFile = defaultServerPrivateKeyFile
+	}
+	if opts.ServerCACertificateFile == "" {
+		opts.ServerCACertificateFile = defaultServerCACertificateFile
+	}
+}
+
+// envoyServerAddress returns the host and port of the default xDS management server. Envoy
+// proxies resolve the host using a STRICT_DNS cluster, so the server URI must use the `dns`
+// scheme, or no scheme.
+func envoyServerAddress(opts Options) (string, int, error) {
+	serverURI := defaultServerURI(opts)
+	address := strings.TrimPrefix(serverURI, "dns:///")
+	host, portStr, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", 0, fmt.Errorf("could not parse host and port of xDS management server URI %s for Envoy: %w", serverURI, err)
+	}
+	port, err := strconv.Atoi(portStr)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid port in xDS management server URI %s for Envoy: %w", serverURI, err)
+	}
+	return host, port, nil
+}
+
+func newEnvoyNode(opts Options) (*corev3.Node, error) {
+	metadata := map[string]interface{}{
+		"XDS_STREAM_TYPE": "ADS",
+	}
+	if opts.ClusterName != "" {
+		metadata[xds.NodeMetadataClusterName] = opts.ClusterName
+	}
+	for key, value := range opts.NodeMetadata {
+		metadata[key] = value
+	}
+	metadataStruct, err := structpb.NewStruct(metadata)
+	if err != nil {
+		return nil, fmt.Errorf("could not create Envoy node metadata: %w", err)
+	}
+	node := &corev3.Node{
+		Id:       opts.NodeID,
+		Cluster:  opts.NodeCluster,
+		Metadata: metadataStruct,
+	}
+	if opts.Zone != "" {
+		node.Locality = &corev3.Locality{Zone: opts.Zone}
+	}
+	return node, nil
+}
+
+// newEnvoyXDSCluster returns the static cluster for the xDS management server, using TLS if
+// `enableControlPlaneTls` is true.
+func newEnvoyXDSCluster(opts Options, host string, port int) (*clusterv3.Cluster, error) {
+	anyWrappedHTTPProtocolOptions, err := anypb.New(&httpv3.HttpProtocolOptions{
+		UpstreamProtocolOptions: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+					Http2ProtocolOptions: &corev3.Http2ProtocolOptions{},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall HttpProtocolOptions into Any instance: %w", err)
+	}
+	clusterName := xds.EnvoyADSCluster
+	cluster := &clusterv3.Cluster{
+		Name: clusterName,
+		ClusterDiscoveryType: &clusterv3.Cluster_Type{
+			Type: clusterv3.Cluster_STRICT_DNS,
+		},
+		TypedExtensionProtocolOptions: map[string]*anypb.Any{
+			envoyHTTPProtocolOptionsName: anyWrappedHTTPProtocolOptions,
+		},
+		ConnectTimeout: &durationpb.Duration{
+			Seconds: envoyXDSClusterConnectTimeout,
+		},
+		LoadAssignment: &endpointv3.ClusterLoadAssignment{
+			ClusterName: clusterName,
+			Endpoints: []*endpointv3.LocalityLbEndpoints{
+				{
+					LbEndpoints: []*endpointv3.LbEndpoint{
+						{
+							HostIdentifier: &endpointv3.LbEndpoint_Endpoint{
+								Endpoint: &endpointv3.Endpoint{
+									Address: &corev3.Address{
+										Address: &corev3.Address_SocketAddress{
+											SocketAddress: &corev3.SocketAddress{
+												Address: host,
+												PortSpecifier: &corev3.SocketAddress_PortValue{
+													PortValue: uint32(port),
+												},
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	if opts.XDSFeatures.EnableControlPlaneTLS {
+		upstreamTLSContext := &tlsv3.UpstreamTlsContext{
+			Sni: host,
+			CommonTlsContext: &tlsv3.CommonTlsContext{
+				AlpnProtocols: []string{"h2"},
+				ValidationContextType: &tlsv3.CommonTlsContext_ValidationContextSdsSecretConfig{
+					ValidationContextSdsSecretConfig: &tlsv3.SdsSecretConfig{
+						Name: tls.UpstreamValidationSecretName,
+					},
+				},
+			},
+		}
+		if opts.XDSFeatures.RequireControlPlaneClientCerts {
+			upstreamTLSContext.CommonTlsContext.TlsCertificateSdsSecretConfigs = []*tlsv3.SdsSecretConfig{
+				{
+					Name: tls.UpstreamCertificateSecretName,
+				},
+			}
+		}
+		transportSocket, err := tls.CreateTransportSocket(upstreamTLSContext)
+		if err != nil {
+			return nil, fmt.Errorf("could not create TLS transport socket for Envoy xDS cluster: %w", err)
+		}
+		cluster.TransportSocket = transportSocket
+	}
+	return cluster, nil
+}
+
+// newEnvoySecrets returns the static secrets that the ADS cluster and the TLS contexts of the
+// xDS resources refer to. The Listener for Envoy proxies receiving gRPC requests always uses TLS.
+func newEnvoySecrets(opts Options) []*tlsv3.Secret {
+	features := opts.XDSFeatures
+	var secrets []*tlsv3.Secret
+	if (features.EnableControlPlaneTLS && features.RequireControlPlaneClientCerts) ||
+		(features.EnableDataPlaneTLS && features.RequireDataPlaneClientCerts) {
+		secrets = append(secrets, newTLSCertificateSecret(tls.UpstreamCertificateSecretName, opts.CertificateFile, opts.PrivateKeyFile))
+	}
+	if features.EnableControlPlaneTLS || features.EnableDataPlaneTLS {
+		secrets = append(secrets, newValidationContextSecret(tls.UpstreamValidationSecretName, opts.CACertificateFile))
+	}
+	secrets = append(secrets, newTLSCertificateSecret(tls.DownstreamCertificateSecretName, opts.ServerCertificateFile, opts.ServerPrivateKeyFile))
+	if features.RequireDataPlaneClientCerts {
+		secrets = append(secrets, newValidationContextSecret(tls.DownstreamValidationSecretName, opts.ServerCACertificateFile))
+	}
+	return secrets
+}
+
+func newTLSCertificateSecret(name string, certificateFile string, privateKeyFile string) *tlsv3.Secret {
+	return &tlsv3.Secret{
+		Name: name,
+		Type: &tlsv3.Secret_TlsCertificate{
+			TlsCertificate: &tlsv3.TlsCertificate{
+				CertificateChain: newDataSourceFile(certificateFile),
+				PrivateKey:       newDataSourceFile(privateKeyFile),
+			},
+		},
+	}
+}
+
+func newValidationContextSecret(name string, caCertificateFile string) *tlsv3.Secret {
+	return &tlsv3.Secret{
+		Name: name,
+		Type: &tlsv3.Secret_ValidationContext{
+			ValidationContext: &tlsv3.CertificateValidationContext{
+				TrustedCa: newDataSourceFile(caCertificateFile),
+			},
+		},
+	}
+}
+
+func newDataSourceFile(filename string) *corev3.DataSource {
+	return &corev3.DataSource{
+		Specifier: &corev3.DataSource_Filename{
+			Filename: filename,
+		},
+	}
+}
@@ -0,0 +1,118 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	bootstrapv3 "github.com/envoyproxy/go-control-plane/envoy/config/bootstrap/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+)
+
+// parseEnvoyBootstrap converts the rendered YAML to JSON, and unmarshals it.
+func parseEnvoyBootstrap(t *testing.T, yamlData []byte) *bootstrapv3.Bootstrap {
+	t.Helper()
+	var data interface{}
+	if err := yaml.Unmarshal(yamlData, &data); err != nil {
+		t.Fatalf("could not unmarshal YAML: %v", err)
+	}
+	jsonData, err := json.Marshal(data)
+	if err != nil {
+		t.Fatalf("could not marshal JSON: %v", err)
+	}
+	var config bootstrapv3.Bootstrap
+	if err := protojson.Unmarshal(jsonData, &config); err != nil {
+		t.Fatalf("could not unmarshal Envoy bootstrap configuration: %v", err)
+	}
+	return &config
+}
+
+func TestRenderEnvoy(t *testing.T) {
+	tests := []struct {
+		name        string
+		xdsFeatures *xds.Features
+		wantErr     error
+		// wantTLS is true if the ADS cluster should use TLS.
+		wantTLS bool
+	}{
+		{
+			name:        "plaintext",
+			xdsFeatures: &xds.Features{},
+		},
+		{
+			name:        "control plane TLS",
+			xdsFeatures: &xds.Features{EnableControlPlaneTLS: true, RequireControlPlaneClientCerts: true},
+			wantTLS:     true,
+		},
+		{
+			name:        "gRPC access logs to the ADS cluster",
+			xdsFeatures: &xds.Features{AccessLog: "grpc"},
+		},
+		{
+			name:        "gRPC access logs to the ADS cluster by name",
+			xdsFeatures: &xds.Features{AccessLog: "grpc", AccessLogServiceCluster: xds.EnvoyADSCluster},
+		},
+		{
+			name:        "gRPC access logs to another cluster",
+			xdsFeatures: &xds.Features{AccessLog: "grpc", AccessLogServiceCluster: "als_cluster"},
+			wantErr:     errUnknownAccessLogServiceCluster,
+		},
+		{
+			name:        "stdout access logs ignore the cluster",
+			xdsFeatures: &xds.Features{AccessLog: "stdout", AccessLogServiceCluster: "als_cluster"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			yamlData, err := RenderEnvoy(Options{
+				Authority:   "control-plane.xds.svc.cluster.local",
+				Port:        50051,
+				NodeID:      "envoy-1",
+				Zone:        "us-central1-a",
+				XDSFeatures: test.xdsFeatures,
+			})
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("RenderEnvoy() error = %v, want %v", err, test.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			config := parseEnvoyBootstrap(t, yamlData)
+			adsClusterName := config.GetDynamicResources().GetAdsConfig().GetGrpcServices()[0].GetEnvoyGrpc().GetClusterName()
+			if adsClusterName != xds.EnvoyADSCluster {
+				t.Errorf("ADS cluster name = %s, want %s", adsClusterName, xds.EnvoyADSCluster)
+			}
+			clusters := config.GetStaticResources().GetClusters()
+			if len(clusters) != 1 || clusters[0].GetName() != xds.EnvoyADSCluster {
+				t.Fatalf("static clusters = %v, want one cluster %s", clusters, xds.EnvoyADSCluster)
+			}
+			socketAddress := clusters[0].GetLoadAssignment().GetEndpoints()[0].GetLbEndpoints()[0].GetEndpoint().GetAddress().GetSocketAddress()
+			if socketAddress.GetAddress() != "control-plane.xds.svc.cluster.local" || socketAddress.GetPortValue() != 50051 {
+				t.Errorf("ADS cluster address = %s:%d, want control-plane.xds.svc.cluster.local:50051", socketAddress.GetAddress(), socketAddress.GetPortValue())
+			}
+			if gotTLS := clusters[0].GetTransportSocket() != nil; gotTLS != test.wantTLS {
+				t.Errorf("ADS cluster uses TLS = %t, want %t", gotTLS, test.wantTLS)
+			}
+			if config.GetNode().GetId() != "envoy-1" || config.GetNode().GetLocality().GetZone() != "us-central1-a" {
+				t.Errorf("node = %v, want ID envoy-1 in zone us-central1-a", config.GetNode())
+			}
+		})
+	}
+}
@@ -58,12 +58,20 @@ var (
 //
 // To disable client-side health checking, set `healthCheckProtocol` to an empty string.
 //
+// With TLS enabled, server certificates must have a SAN that matches one of `subjectAltNames`,
+// or the SPIFFE ID of the namespace and `serviceAccountName` if `subjectAltNames` is empty.
+//
+// To deliver a load balancing policy using the `load_balancing_policy` field, set
+// `loadBalancingPolicy` to one of the `LoadBalancingPolicy*` constants, e.g.,
+// `LoadBalancingPolicyWeightedRoundRobin` to balance load based on backend metrics reported by
+// the upstream servers. Otherwise, the Cluster uses round-robin load balancing.
+//
 // Client-side active health checks are supported by Envoy proxy, but not by gRPC clients.
 // See https://www.envoyproxy.io/docs/envoy/latest/intro/arch_overview/upstream/service_discovery#on-eventually-consistent-service-discovery
 // and https://github.com/grpc/grpc/issues/34581
 //
 // TODO: Clean up too many parameters.
-func CreateCluster(name string, edsServiceName string, namespace string, serviceAccountName string, healthCheckPort uint32, healthCheckProtocol string, healthCheckPathOrGRPCService string, enableTLS bool, requireClientCerts bool) (*clusterv3.Cluster, error) {
+func CreateCluster(name string, edsServiceName string, namespace string, serviceAccountName string, subjectAltNames []tls.SubjectAltNameMatcher, healthCheckPort uint32, healthCheckProtocol string, healthCheckPathOrGRPCService string, enableTLS bool, certificateProvider tls.CertificateProvider, requireClientCerts bool, loadBalancingPolicy string, trustDomains []tls.TrustDomain) (*clusterv3.Cluster, error) {
 	anyWrappedHTTPProtocolOptions, err := anypb.New(&httpv3.HttpProtocolOptions{
 		UpstreamProtocolOptions: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_{
 			ExplicitHttpConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig{
@@ -111,8 +119,16 @@ func CreateCluster(name string, edsServiceName string, namespace string, service
 		}
 	}
 
+	if loadBalancingPolicy != "" {
+		clusterLoadBalancingPolicy, err := createLoadBalancingPolicy(loadBalancingPolicy)
+		if err != nil {
+			return nil, err
+		}
+		cluster.LoadBalancingPolicy = clusterLoadBalancingPolicy
+	}
+
 	if enableTLS {
-		upstreamTLSContext := tls.CreateUpstreamTLSContext(namespace, serviceAccountName, requireClientCerts)
+		upstreamTLSContext := tls.CreateUpstreamTLSContext(certificateProvider, namespace, serviceAccountName, subjectAltNames, requireClientCerts, trustDomains)
 		transportSocket, err := tls.CreateTransportSocket(upstreamTLSContext)
 		if err != nil {
 			return nil, err
@@ -123,6 +139,18 @@ func CreateCluster(name string, edsServiceName string, namespace string, service
 	return &cluster, nil
 }
 
+// InvalidateCluster removes the EDS config source of the Cluster, so that xDS clients reject (NACK)
+// the Cluster. The Cluster still names its EDS service, but gRPC clients and Envoy proxies require
+// EDS Clusters to fetch endpoints using ADS or a config source.
+//
+// A Cluster that names an EDS service that is missing from the snapshot would not cause a NACK,
+// since xDS clients treat missing resources as not existing, instead of invalid.
+func InvalidateCluster(cluster *clusterv3.Cluster) {
+	if cluster.GetEdsClusterConfig() != nil {
+		cluster.EdsClusterConfig.EdsConfig = nil
+	}
+}
+
 func createHealthCheck(protocol string, port uint32, pathOrGRPCService string) *corev3.HealthCheck {
 	healthCheck := &corev3.HealthCheck{
 		AltPort:            wrapperspb.UInt32(port),
@@ -0,0 +1,141 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cds
+
+import (
+	"fmt"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	httpv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/upstreams/http/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	// DNSTypeLogical resolves the hostname and connects to the first address, see
+	// https://www.envoyproxy.io/docs/envoy/latest/intro/arch_overview/upstream/service_discovery#logical-dns
+	DNSTypeLogical = "logical"
+	// DNSTypeStrict resolves the hostname and load balances across all the addresses.
+	// gRPC clients do not support strict DNS clusters, only Envoy proxies do.
+	DNSTypeStrict = "strict"
+)
+
+// ExternalBackend is a gRPC backend outside the Kubernetes clusters, e.g., a greeter service
+// on a VM, that clients reach using a DNS hostname.
+type ExternalBackend struct {
+	// Name of the Listener, RouteConfiguration, and Cluster for the backend.
+	Name string `yaml:"name"`
+	// Hostname is the DNS name of the backend.
+	Hostname string `yaml:"hostname"`
+	// Port is the serving port of the backend.
+	Port uint32 `yaml:"port"`
+	// PathPrefix is the route prefix in the RouteConfiguration, e.g., `/helloworld.Greeter/`.
+	// Empty matches all requests.
+	PathPrefix string `yaml:"pathPrefix"`
+	// DNSType is either `logical` (default) or `strict`.
+	DNSType string `yaml:"dnsType"`
+}
+
+// CreateDNSCluster returns a Cluster that resolves the hostname of the external backend
+// using DNS, instead of fetching endpoints using EDS.
+//
+// gRPC clients support LOGICAL_DNS clusters, see
+// [gRFC A37]: https://github.com/grpc/proposal/blob/master/A37-xds-aggregate-and-logical-dns-clusters.md
+func CreateDNSCluster(name string, backend ExternalBackend, loadBalancingPolicy string) (*clusterv3.Cluster, error) {
+	anyWrappedHTTPProtocolOptions, err := anypb.New(&httpv3.HttpProtocolOptions{
+		UpstreamProtocolOptions: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_{
+			ExplicitHttpConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig{
+				ProtocolConfig: &httpv3.HttpProtocolOptions_ExplicitHttpConfig_Http2ProtocolOptions{
+					Http2ProtocolOptions: &corev3.Http2ProtocolOptions{},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall HttpProtocolOptions into Any instance: %w", err)
+	}
+	discoveryType := clusterv3.Cluster_LOGICAL_DNS
+	if backend.DNSType == DNSTypeStrict {
+		discoveryType = clusterv3.Cluster_STRICT_DNS
+	}
+	cluster := clusterv3.Cluster{
+		Name: name,
+		ClusterDiscoveryType: &clusterv3.Cluster_Type{
+			Type: discoveryType,
+		},
+		DnsLookupFamily: clusterv3.Cluster_V4_PREFERRED,
+		LoadAssignment:  createDNSLoadAssignment(name, backend.Hostname, backend.Port),
+		ConnectTimeout: &durationpb.Duration{
+			Seconds: 3, // default is 5s
+		},
+		TypedExtensionProtocolOptions: map[string]*anypb.Any{
+			envoyExtensionsUpstreamsHTTPProtocolOptions: anyWrappedHTTPProtocolOptions,
+		},
+		LbPolicy: clusterv3.Cluster_ROUND_ROBIN,
+	}
+	if loadBalancingPolicy != "" {
+		clusterLoadBalancingPolicy, err := createLoadBalancingPolicy(loadBalancingPolicy)
+		if err != nil {
+			return nil, err
+		}
+		cluster.LoadBalancingPolicy = clusterLoadBalancingPolicy
+	}
+	return &cluster, nil
+}
+
+// UseLogicalDNS changes an EDS Cluster to a LOGICAL_DNS Cluster that resolves the hostname,
+// e.g., for applications with FQDN endpoints. xDS clients require IP addresses in EDS
+// ClusterLoadAssignments, and LOGICAL_DNS Clusters have exactly one hostname.
+func UseLogicalDNS(cluster *clusterv3.Cluster, hostname string, port uint32) {
+	cluster.ClusterDiscoveryType = &clusterv3.Cluster_Type{
+		Type: clusterv3.Cluster_LOGICAL_DNS,
+	}
+	cluster.EdsClusterConfig = nil
+	cluster.DnsLookupFamily = clusterv3.Cluster_V4_PREFERRED
+	cluster.LoadAssignment = createDNSLoadAssignment(cluster.GetName(), hostname, port)
+}
+
+// createDNSLoadAssignment returns the inline load assignment of a DNS Cluster, with one endpoint
+// for the hostname.
+func createDNSLoadAssignment(clusterName string, hostname string, port uint32) *endpointv3.ClusterLoadAssignment {
+	return &endpointv3.ClusterLoadAssignment{
+		ClusterName: clusterName,
+		Endpoints: []*endpointv3.LocalityLbEndpoints{
+			{
+				LbEndpoints: []*endpointv3.LbEndpoint{
+					{
+						HostIdentifier: &endpointv3.LbEndpoint_Endpoint{
+							Endpoint: &endpointv3.Endpoint{
+								Address: &corev3.Address{
+									Address: &corev3.Address_SocketAddress{
+										SocketAddress: &corev3.SocketAddress{
+											Protocol: corev3.SocketAddress_TCP,
+											Address:  hostname,
+											PortSpecifier: &corev3.SocketAddress_PortValue{
+												PortValue: port,
+											},
+										},
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,80 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cds
+
+import (
+	"fmt"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	dynamic_forward_proxy_clusterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/clusters/dynamic_forward_proxy/v3"
+	dynamic_forward_proxyv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/dynamic_forward_proxy/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	// DynamicForwardProxyClusterName is the name of the Cluster that the dynamic forward proxy
+	// Listener of Envoy proxies routes requests to.
+	DynamicForwardProxyClusterName      = "dynamic-forward-proxy"
+	envoyClusterDynamicForwardProxyName = "envoy.clusters.dynamic_forward_proxy"
+	// dynamicForwardProxyDNSCacheName must be the same for the Cluster and the HTTP filter, so
+	// that they share the DNS cache.
+	dynamicForwardProxyDNSCacheName     = "dynamic_forward_proxy_cache"
+	dynamicForwardProxyDNSCacheHostTTL  = 5 * time.Minute
+	dynamicForwardProxyDNSCacheMaxHosts = 1024
+)
+
+// CreateDynamicForwardProxyDNSCacheConfig returns the DNS cache configuration shared by the
+// dynamic forward proxy Cluster and HTTP filter. Envoy proxies resolve the hostname from the
+// `:authority` header of each request, and cache the addresses.
+func CreateDynamicForwardProxyDNSCacheConfig() *dynamic_forward_proxyv3.DnsCacheConfig {
+	return &dynamic_forward_proxyv3.DnsCacheConfig{
+		Name:            dynamicForwardProxyDNSCacheName,
+		DnsLookupFamily: clusterv3.Cluster_V4_PREFERRED,
+		HostTtl:         durationpb.New(dynamicForwardProxyDNSCacheHostTTL),
+		MaxHosts:        wrapperspb.UInt32(dynamicForwardProxyDNSCacheMaxHosts),
+	}
+}
+
+// CreateDynamicForwardProxyCluster returns a Cluster that connects to the hosts resolved by the
+// dynamic forward proxy HTTP filter, see
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/dynamic_forward_proxy_filter
+//
+// gRPC does not support dynamic forward proxy clusters, so only Envoy proxies accept the Cluster.
+func CreateDynamicForwardProxyCluster(dnsCacheConfig *dynamic_forward_proxyv3.DnsCacheConfig) (*clusterv3.Cluster, error) {
+	clusterConfig, err := anypb.New(&dynamic_forward_proxy_clusterv3.ClusterConfig{
+		ClusterImplementationSpecifier: &dynamic_forward_proxy_clusterv3.ClusterConfig_DnsCacheConfig{
+			DnsCacheConfig: dnsCacheConfig,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall dynamic forward proxy ClusterConfig into Any instance: %w", err)
+	}
+	return &clusterv3.Cluster{
+		Name: DynamicForwardProxyClusterName,
+		ClusterDiscoveryType: &clusterv3.Cluster_ClusterType{
+			ClusterType: &clusterv3.Cluster_CustomClusterType{
+				Name:        envoyClusterDynamicForwardProxyName,
+				TypedConfig: clusterConfig,
+			},
+		},
+		ConnectTimeout: &durationpb.Duration{
+			Seconds: 3, // default is 5s
+		},
+		LbPolicy: clusterv3.Cluster_CLUSTER_PROVIDED,
+	}, nil
+}
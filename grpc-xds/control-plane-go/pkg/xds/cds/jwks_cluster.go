@@ -0,0 +1,83 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cds
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+var errJWKSURI = errors.New("JWKS URI must be an http or https URL with a host")
+
+// CreateJWKSCluster returns a LOGICAL_DNS Cluster for Envoy proxies to fetch the JSON Web Key Set
+// (JWKS) of a JWT provider from the host of the JWKS URI. For `https` URIs, the Cluster uses TLS,
+// with the host as SNI, and validates the server certificate using the system root certificates.
+//
+// gRPC servers ignore the JWT authentication HTTP filter, so only Envoy proxies use the Cluster.
+func CreateJWKSCluster(name string, jwksURI string) (*clusterv3.Cluster, error) {
+	u, err := url.Parse(jwksURI)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse JWKS URI %s: %w", jwksURI, err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Hostname() == "" {
+		return nil, fmt.Errorf("%w: uri=%s", errJWKSURI, jwksURI)
+	}
+	port := uint64(443)
+	if u.Scheme == "http" {
+		port = 80
+	}
+	if u.Port() != "" {
+		if port, err = strconv.ParseUint(u.Port(), 10, 16); err != nil {
+			return nil, fmt.Errorf("could not parse port of JWKS URI %s: %w", jwksURI, err)
+		}
+	}
+	cluster := &clusterv3.Cluster{
+		Name: name,
+		ClusterDiscoveryType: &clusterv3.Cluster_Type{
+			Type: clusterv3.Cluster_LOGICAL_DNS,
+		},
+		DnsLookupFamily: clusterv3.Cluster_V4_PREFERRED,
+		LoadAssignment:  createDNSLoadAssignment(name, u.Hostname(), uint32(port)),
+		ConnectTimeout: &durationpb.Duration{
+			Seconds: 3, // default is 5s
+		},
+		LbPolicy: clusterv3.Cluster_ROUND_ROBIN,
+	}
+	if u.Scheme == "https" {
+		transportSocket, err := tls.CreateTransportSocket(&tlsv3.UpstreamTlsContext{
+			Sni: u.Hostname(),
+			CommonTlsContext: &tlsv3.CommonTlsContext{
+				ValidationContextType: &tlsv3.CommonTlsContext_ValidationContext{
+					ValidationContext: &tlsv3.CertificateValidationContext{
+						SystemRootCerts: &tlsv3.CertificateValidationContext_SystemRootCerts{},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create TLS transport socket for JWKS Cluster %s: %w", name, err)
+		}
+		cluster.TransportSocket = transportSocket
+	}
+	return cluster, nil
+}
@@ -0,0 +1,187 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cds
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	wrrv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/load_balancing_policies/client_side_weighted_round_robin/v3"
+	leastrequestv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/load_balancing_policies/least_request/v3"
+	pickfirstv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/load_balancing_policies/pick_first/v3"
+	roundrobinv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/load_balancing_policies/round_robin/v3"
+	wrrlocalityv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/load_balancing_policies/wrr_locality/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+const (
+	// LoadBalancingPolicyRoundRobin picks endpoints in round-robin order, within the locality
+	// selected by `wrr_locality`.
+	LoadBalancingPolicyRoundRobin = "round_robin"
+	// LoadBalancingPolicyPickFirst sends all requests to the first endpoint that the client can
+	// connect to, after shuffling the endpoints, see [gRFC A62].
+	//
+	// [gRFC A62]: https://github.com/grpc/proposal/blob/master/A62-pick-first.md
+	LoadBalancingPolicyPickFirst = "pick_first"
+	// LoadBalancingPolicyLeastRequest picks the endpoint with the fewest outstanding requests
+	// out of two random endpoints, within the locality selected by `wrr_locality`, see [gRFC A48].
+	//
+	// [gRFC A48]: https://github.com/grpc/proposal/blob/master/A48-xds-least-request-lb-policy.md
+	LoadBalancingPolicyLeastRequest = "least_request"
+	// LoadBalancingPolicyWeightedRoundRobin weights endpoints using backend metrics, see
+	// `createWeightedRoundRobinLoadBalancingPolicy()`.
+	LoadBalancingPolicyWeightedRoundRobin = "weighted_round_robin"
+
+	envoyLoadBalancingPoliciesClientSideWeightedRoundRobin = "envoy.load_balancing_policies.client_side_weighted_round_robin"
+	envoyLoadBalancingPoliciesLeastRequest                 = "envoy.load_balancing_policies.least_request"
+	envoyLoadBalancingPoliciesPickFirst                    = "envoy.load_balancing_policies.pick_first"
+	envoyLoadBalancingPoliciesRoundRobin                   = "envoy.load_balancing_policies.round_robin"
+	envoyLoadBalancingPoliciesWrrLocality                  = "envoy.load_balancing_policies.wrr_locality"
+)
+
+var errUnknownLoadBalancingPolicy = errors.New("unknown load balancing policy")
+
+// The weighted round-robin periods are the defaults of gRFC A58, set explicitly so that the
+// Clusters show the values that clients use. They are not configurable, because the upstream
+// servers can enforce a longer out-of-band reporting period than clients request, see
+// `orcaMinReportingInterval` in greeter-go.
+var (
+	oobReportingPeriod = durationpb.New(10 * time.Second)
+	blackoutPeriod     = durationpb.New(10 * time.Second)
+)
+
+// createLoadBalancingPolicy returns the load balancing policy with the provided name, delivered
+// using the `load_balancing_policy` field of the Cluster, see [gRFC A52].
+//
+// [gRFC A52]: https://github.com/grpc/proposal/blob/master/A52-xds-custom-lb-policies.md
+func createLoadBalancingPolicy(name string) (*clusterv3.LoadBalancingPolicy, error) {
+	switch name {
+	case LoadBalancingPolicyRoundRobin:
+		return createLocalityLoadBalancingPolicy(envoyLoadBalancingPoliciesRoundRobin, &roundrobinv3.RoundRobin{})
+	case LoadBalancingPolicyPickFirst:
+		return createPickFirstLoadBalancingPolicy()
+	case LoadBalancingPolicyLeastRequest:
+		return createLocalityLoadBalancingPolicy(envoyLoadBalancingPoliciesLeastRequest, &leastrequestv3.LeastRequest{
+			ChoiceCount: wrapperspb.UInt32(2),
+		})
+	case LoadBalancingPolicyWeightedRoundRobin:
+		return createWeightedRoundRobinLoadBalancingPolicy()
+	default:
+		return nil, fmt.Errorf("%w: %s", errUnknownLoadBalancingPolicy, name)
+	}
+}
+
+// createLocalityLoadBalancingPolicy returns a load balancing policy that uses the provided
+// endpoint picking policy within localities.
+//
+// Clients select the first policy in the list that they support:
+//
+//  1. gRPC clients select `wrr_locality` with the endpoint picking policy, as gRPC
+//     clients require a locality picking policy at the top level.
+//  2. Envoy proxy selects the endpoint picking policy.
+//  3. `round_robin` is the fallback for clients that support neither.
+func createLocalityLoadBalancingPolicy(endpointPickingPolicyName string, endpointPickingPolicyTypedConfig proto.Message) (*clusterv3.LoadBalancingPolicy, error) {
+	endpointPickingPolicy, err := createLoadBalancingPolicyPolicy(endpointPickingPolicyName, endpointPickingPolicyTypedConfig)
+	if err != nil {
+		return nil, err
+	}
+	wrrLocalityPolicy, err := createLoadBalancingPolicyPolicy(envoyLoadBalancingPoliciesWrrLocality, &wrrlocalityv3.WrrLocality{
+		EndpointPickingPolicy: &clusterv3.LoadBalancingPolicy{
+			Policies: []*clusterv3.LoadBalancingPolicy_Policy{endpointPickingPolicy},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	policies := []*clusterv3.LoadBalancingPolicy_Policy{wrrLocalityPolicy, endpointPickingPolicy}
+	if endpointPickingPolicyName != envoyLoadBalancingPoliciesRoundRobin {
+		roundRobinPolicy, err := createLoadBalancingPolicyPolicy(envoyLoadBalancingPoliciesRoundRobin, &roundrobinv3.RoundRobin{})
+		if err != nil {
+			return nil, err
+		}
+		policies = append(policies, roundRobinPolicy)
+	}
+	return &clusterv3.LoadBalancingPolicy{
+		Policies: policies,
+	}, nil
+}
+
+// createPickFirstLoadBalancingPolicy returns a load balancing policy that sends all requests to
+// one endpoint. Clients shuffle the endpoints first, to spread the load across clients.
+// `pick_first` is not locality-aware, so it is not wrapped in `wrr_locality`.
+// `round_robin` is the fallback for clients that do not support `pick_first`.
+func createPickFirstLoadBalancingPolicy() (*clusterv3.LoadBalancingPolicy, error) {
+	pickFirstPolicy, err := createLoadBalancingPolicyPolicy(envoyLoadBalancingPoliciesPickFirst, &pickfirstv3.PickFirst{
+		ShuffleAddressList: true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	roundRobinPolicy, err := createLoadBalancingPolicyPolicy(envoyLoadBalancingPoliciesRoundRobin, &roundrobinv3.RoundRobin{})
+	if err != nil {
+		return nil, err
+	}
+	return &clusterv3.LoadBalancingPolicy{
+		Policies: []*clusterv3.LoadBalancingPolicy_Policy{
+			pickFirstPolicy,
+			roundRobinPolicy,
+		},
+	}, nil
+}
+
+// createWeightedRoundRobinLoadBalancingPolicy returns a load balancing policy that
+// weights endpoints using the backend metrics reported by the upstream servers via
+// [ORCA], as described in [gRFC A58].
+//
+// Clients select the first policy in the list that they support:
+//
+//  1. gRPC clients select `wrr_locality` with `client_side_weighted_round_robin`
+//     as the endpoint picking policy, see [gRFC A52].
+//  2. Envoy proxy selects `client_side_weighted_round_robin`.
+//  3. `round_robin` is the fallback for clients that support neither.
+//
+// The upstream servers report backend metrics out-of-band, so clients do not need to
+// rely on per-request metrics in trailers.
+//
+// [ORCA]: https://github.com/grpc/proposal/blob/master/A51-custom-backend-metrics.md
+// [gRFC A52]: https://github.com/grpc/proposal/blob/master/A52-xds-custom-lb-policies.md
+// [gRFC A58]: https://github.com/grpc/proposal/blob/master/A58-client-side-weighted-round-robin-lb-policy.md
+func createWeightedRoundRobinLoadBalancingPolicy() (*clusterv3.LoadBalancingPolicy, error) {
+	weightedRoundRobin := &wrrv3.ClientSideWeightedRoundRobin{
+		EnableOobLoadReport: wrapperspb.Bool(true),
+		OobReportingPeriod:  oobReportingPeriod,
+		BlackoutPeriod:      blackoutPeriod,
+	}
+	return createLocalityLoadBalancingPolicy(envoyLoadBalancingPoliciesClientSideWeightedRoundRobin, weightedRoundRobin)
+}
+
+func createLoadBalancingPolicyPolicy(name string, typedConfig proto.Message) (*clusterv3.LoadBalancingPolicy_Policy, error) {
+	anyWrappedTypedConfig, err := anypb.New(typedConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall load balancing policy %s into Any instance: %w", name, err)
+	}
+	return &clusterv3.LoadBalancingPolicy_Policy{
+		TypedExtensionConfig: &corev3.TypedExtensionConfig{
+			Name:        name,
+			TypedConfig: anyWrappedTypedConfig,
+		},
+	}, nil
+}
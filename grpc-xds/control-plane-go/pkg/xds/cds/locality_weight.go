@@ -0,0 +1,47 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cds
+
+import (
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+)
+
+// AddLocalityWeightedLbConfig makes Envoy proxies pick localities using the `load_balancing_weight`
+// of each locality in the ClusterLoadAssignment, see [Envoy locality weighted load balancing].
+// Without this configuration, Envoy proxies ignore the locality weights. gRPC clients always pick
+// localities by weight, and ignore this configuration.
+//
+// [Envoy locality weighted load balancing]: https://www.envoyproxy.io/docs/envoy/latest/intro/arch_overview/upstream/load_balancing/locality_weight
+func AddLocalityWeightedLbConfig(cluster *clusterv3.Cluster) {
+	if cluster.CommonLbConfig == nil {
+		cluster.CommonLbConfig = &clusterv3.Cluster_CommonLbConfig{}
+	}
+	cluster.CommonLbConfig.LocalityConfigSpecifier = &clusterv3.Cluster_CommonLbConfig_LocalityWeightedLbConfig_{
+		LocalityWeightedLbConfig: &clusterv3.Cluster_CommonLbConfig_LocalityWeightedLbConfig{},
+	}
+}
+
+// AddLoadReportingServer makes gRPC clients send load reports for the Cluster to the control plane,
+// using the Load Reporting Service (LRS) on the same server as the Cluster resource.
+// Envoy proxies only send load reports if configured in their bootstrap configuration.
+func AddLoadReportingServer(cluster *clusterv3.Cluster) {
+	cluster.LrsServer = &corev3.ConfigSource{
+		ResourceApiVersion: corev3.ApiVersion_V3,
+		ConfigSourceSpecifier: &corev3.ConfigSource_Self{
+			Self: &corev3.SelfConfigSource{},
+		},
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cds
+
+import (
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+)
+
+// AddSubsetConfig adds a subset load balancing configuration to the Cluster, with one subset
+// selector for each of the label keys. Envoy proxies use the subsets to route requests to
+// endpoints with matching `envoy.lb` metadata, based on the `metadata_match` of the route.
+// Requests without a matching subset go to any endpoint. gRPC clients ignore the subset
+// configuration.
+//
+// Envoy only uses the subset configuration with the `lb_policy` field, so the Cluster is not
+// changed if it uses the `load_balancing_policy` field.
+func AddSubsetConfig(cluster *clusterv3.Cluster, labelKeys []string) {
+	if len(labelKeys) == 0 || cluster.GetLoadBalancingPolicy() != nil {
+		return
+	}
+	subsetConfig := &clusterv3.Cluster_LbSubsetConfig{
+		FallbackPolicy: clusterv3.Cluster_LbSubsetConfig_ANY_ENDPOINT,
+	}
+	for _, key := range labelKeys {
+		subsetConfig.SubsetSelectors = append(subsetConfig.SubsetSelectors, &clusterv3.Cluster_LbSubsetConfig_LbSubsetSelector{
+			Keys: []string{key},
+		})
+	}
+	cluster.LbSubsetConfig = subsetConfig
+}
@@ -0,0 +1,414 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+// defaultResourceGenerators returns the generators that SnapshotBuilder.Build always runs,
+// before any registered generators.
+func (b *SnapshotBuilder) defaultResourceGenerators() []ResourceGenerator {
+	return []ResourceGenerator{
+		&applicationGenerator{b: b},
+		&federationGenerator{b: b},
+		&grpcServerListenerGenerator{b: b},
+		&envoyGRPCListenerGenerator{b: b},
+		&dynamicForwardProxyGenerator{},
+		&placeholderGenerator{b: b},
+	}
+}
+
+// applicationGenerator adds the API Listener, RouteConfiguration, Cluster, and
+// ClusterLoadAssignment of each application added using `AddGRPCApplications()`, and TCP proxy
+// Listeners for Envoy proxies. The first application with a name wins, so applications don't
+// replace the resources of external backends and routes with the same name.
+type applicationGenerator struct {
+	BaseResourceGenerator
+	b *SnapshotBuilder
+}
+
+func (g *applicationGenerator) GenerateListeners(input GeneratorInput, resources *Resources) error {
+	for _, app := range g.b.apps {
+		if resources.Listeners[app.Name] == nil {
+			apiListener, err := g.b.createAPIListener(app.Name, app.Name)
+			if err != nil {
+				return fmt.Errorf("could not create LDS API listener for gRPC application %+v: %w", app, err)
+			}
+			resources.Listeners[apiListener.Name] = apiListener
+		}
+		// Envoy proxies can only have one Listener per port, so the first application wins if several
+		// applications that don't serve HTTP or gRPC use the same serving port.
+		if input.Features.EnableTCPProxy && !app.ServesHTTP() && app.ServingPort != envoyGRPCListenerPort &&
+			!(input.Features.EnableDynamicForwardProxy && app.ServingPort == envoyDynamicForwardProxyListenerPort) &&
+			resources.Listeners[lds.EnvoyTCPProxyListenerName(app.ServingPort)] == nil {
+			tcpProxyListener, err := lds.CreateEnvoyTCPProxyListener(app.ServingPort, app.Name)
+			if err != nil {
+				return fmt.Errorf("could not create LDS TCP proxy Listener for application %+v: %w", app, err)
+			}
+			lds.SetIPFamily(tcpProxyListener, input.Features.ListenerIPFamily)
+			resources.Listeners[tcpProxyListener.Name] = tcpProxyListener
+		}
+	}
+	return nil
+}
+
+func (g *applicationGenerator) GenerateRoutes(_ GeneratorInput, resources *Resources) error {
+	for _, app := range g.b.apps {
+		if resources.RouteConfigurations[app.Name] != nil {
+			continue
+		}
+		routeConfiguration := rds.CreateRouteConfigurationForAPIListener(app.Name, app.Name, app.PathPrefix, app.Name)
+		if err := rds.AddMethodRoutes(routeConfiguration, g.b.routePolicies[app.Name].Routes, app.Name); err != nil {
+			return fmt.Errorf("could not add method routes for gRPC application %+v: %w", app, err)
+		}
+		if err := g.b.setMaintenanceMode(app.Name, routeConfiguration.GetVirtualHosts()...); err != nil {
+			return err
+		}
+		resources.RouteConfigurations[routeConfiguration.Name] = routeConfiguration
+	}
+	return nil
+}
+
+// GenerateClusters adds the Clusters of the applications. Applications with FQDN endpoints get
+// LOGICAL_DNS Clusters, since xDS clients require IP addresses in EDS, and Clusters of
+// applications with subset labels on their endpoints get a subset load balancing configuration.
+func (g *applicationGenerator) GenerateClusters(input GeneratorInput, resources *Resources) error {
+	for _, app := range g.b.apps {
+		if resources.Clusters[app.Name] != nil {
+			continue
+		}
+		cluster, err := g.b.createCluster(app.Name, app.Name, app)
+		if err != nil {
+			return fmt.Errorf("could not create CDS Cluster for gRPC application %+v: %w", app, err)
+		}
+		g.b.addLocalityWeightConfig(cluster)
+		if input.Features.SimulatesNACK(app.Name) {
+			cds.InvalidateCluster(cluster)
+		}
+		if hostname, exists := applications.FQDNHostname(g.b.endpointsByCluster[appEndpointsKey(app)]); exists {
+			cds.UseLogicalDNS(cluster, hostname, app.ServingPort)
+		} else if subsets := g.b.subsetsByCluster[app.Name]; len(subsets) > 0 {
+			cds.AddSubsetConfig(cluster, slices.Sorted(maps.Keys(subsets)))
+		}
+		resources.Clusters[cluster.Name] = cluster
+	}
+	return nil
+}
+
+// GenerateEndpoints adds the ClusterLoadAssignments of the applications, with the merged
+// endpoints of the applications with the same name and serving port. Applications with FQDN
+// endpoints have no ClusterLoadAssignment.
+func (g *applicationGenerator) GenerateEndpoints(input GeneratorInput, resources *Resources) error {
+	localityPriorityMapper := input.Features.LocalityPriorityMapper(g.b.localityPriorityMapper)
+	localityWeighter := input.Features.LocalityWeighter(g.b.loadReports)
+	for _, app := range g.b.apps {
+		endpoints := g.b.endpointsByCluster[appEndpointsKey(app)]
+		if _, exists := applications.FQDNHostname(endpoints); exists {
+			continue
+		}
+		clusterLoadAssignment := eds.CreateClusterLoadAssignment(app.Name, app.ServingPort, input.NodeHash, localityPriorityMapper, localityWeighter, endpoints)
+		resources.ClusterLoadAssignments[clusterLoadAssignment.ClusterName] = clusterLoadAssignment
+	}
+	return nil
+}
+
+// federationGenerator adds copies of the Listeners, RouteConfigurations, Clusters, and
+// ClusterLoadAssignments of applications, external backends, and routes, renamed with
+// `xdstp://` names for each requested authority, see `SnapshotBuilder.federationAuthorities()`.
+// The resources are only created once, with plain names, so the copies have identical payloads,
+// apart from the names. The generator runs after `applicationGenerator` in each phase.
+type federationGenerator struct {
+	BaseResourceGenerator
+	b *SnapshotBuilder
+}
+
+func (g *federationGenerator) GenerateListeners(_ GeneratorInput, resources *Resources) error {
+	for name := range g.b.authoritiesByName {
+		if resources.Listeners[name] == nil {
+			continue
+		}
+		for _, namer := range g.b.federationNamers(name) {
+			listener, err := g.b.createAPIListener(namer.Listener(name), namer.RouteConfiguration(name))
+			if err != nil {
+				return fmt.Errorf("could not create federation LDS API listener for authority=%s and name=%s: %w", namer.authority, name, err)
+			}
+			resources.Listeners[listener.Name] = listener
+		}
+	}
+	return nil
+}
+
+func (g *federationGenerator) GenerateRoutes(_ GeneratorInput, resources *Resources) error {
+	for name := range g.b.authoritiesByName {
+		if routeConfiguration, ok := resources.RouteConfigurations[name].(*routev3.RouteConfiguration); ok {
+			for _, namer := range g.b.federationNamers(name) {
+				renamed := renameRouteConfiguration(namer, routeConfiguration)
+				resources.RouteConfigurations[renamed.Name] = renamed
+			}
+		}
+	}
+	return nil
+}
+
+func (g *federationGenerator) GenerateClusters(_ GeneratorInput, resources *Resources) error {
+	for name := range g.b.authoritiesByName {
+		if cluster, ok := resources.Clusters[name].(*clusterv3.Cluster); ok {
+			for _, namer := range g.b.federationNamers(name) {
+				renamed := renameCluster(namer, cluster)
+				resources.Clusters[renamed.Name] = renamed
+			}
+		}
+	}
+	return nil
+}
+
+func (g *federationGenerator) GenerateEndpoints(_ GeneratorInput, resources *Resources) error {
+	for name := range g.b.authoritiesByName {
+		if clusterLoadAssignment, ok := resources.ClusterLoadAssignments[name].(*endpointv3.ClusterLoadAssignment); ok {
+			for _, namer := range g.b.federationNamers(name) {
+				renamed := renameClusterLoadAssignment(namer, clusterLoadAssignment)
+				resources.ClusterLoadAssignments[renamed.ClusterName] = renamed
+			}
+		}
+	}
+	return nil
+}
+
+// grpcServerListenerGenerator adds the gRPC server Listeners for the server listener addresses,
+// and the RouteConfiguration for these Listeners. With JWT authentication, it also adds a Cluster
+// for each `jwksCluster` of the JWT providers that is not already in the snapshot, e.g., as an
+// external backend.
+// The JWKS Clusters are added in the clusters phase, so that the RouteConfiguration for the Envoy
+// gRPC Listener does not include virtual hosts for these Clusters.
+type grpcServerListenerGenerator struct {
+	BaseResourceGenerator
+	b *SnapshotBuilder
+}
+
+func (g *grpcServerListenerGenerator) routeConfiguration(features *Features) (*routev3.RouteConfiguration, error) {
+	routeConfiguration, err := rds.CreateRouteConfigurationForGRPCServerListener(features.EnableRBAC, features.RBACAuditOnly, g.b.rbacPolicies, features.TrustDomains)
+	if err != nil {
+		return nil, fmt.Errorf("could not create RDS RouteConfiguration for LDS server Listener: %w", err)
+	}
+	return routeConfiguration, nil
+}
+
+func (g *grpcServerListenerGenerator) GenerateListeners(input GeneratorInput, resources *Resources) error {
+	if len(g.b.grpcServerListenerAddresses) == 0 {
+		return nil
+	}
+	var inlineRouteConfiguration *routev3.RouteConfiguration
+	if !input.Features.ServerListenerUsesRDS {
+		var err error
+		if inlineRouteConfiguration, err = g.routeConfiguration(input.Features); err != nil {
+			return err
+		}
+	}
+	var jwtProviders []lds.JWTProvider
+	if input.Features.EnableJWTAuthn {
+		jwtProviders = g.b.jwtProviders
+	}
+	for address := range g.b.grpcServerListenerAddresses {
+		serverListener, err := lds.CreateGRPCServerListener(address.Host, address.Port, input.Features.EnableDataPlaneTLS, input.Features.CertificateProvider(), input.Features.RequireDataPlaneClientCerts, input.Features.EnableRBAC, jwtProviders, input.Features.AccessLogConfig(), inlineRouteConfiguration, input.Features.PermissiveSourcePrefixes)
+		if err != nil {
+			return fmt.Errorf("could not create LDS server Listener for address %s:%d: %w", address.Host, address.Port, err)
+		}
+		resources.Listeners[serverListener.Name] = serverListener
+	}
+	return nil
+}
+
+func (g *grpcServerListenerGenerator) GenerateRoutes(input GeneratorInput, resources *Resources) error {
+	if len(g.b.grpcServerListenerAddresses) == 0 || !input.Features.ServerListenerUsesRDS {
+		return nil
+	}
+	routeConfiguration, err := g.routeConfiguration(input.Features)
+	if err != nil {
+		return err
+	}
+	resources.RouteConfigurations[routeConfiguration.Name] = routeConfiguration
+	return nil
+}
+
+func (g *grpcServerListenerGenerator) GenerateClusters(input GeneratorInput, resources *Resources) error {
+	if len(g.b.grpcServerListenerAddresses) == 0 || !input.Features.EnableJWTAuthn {
+		return nil
+	}
+	for _, provider := range g.b.jwtProviders {
+		if provider.JWKSURI == "" || resources.Clusters[provider.JWKSCluster] != nil {
+			continue
+		}
+		jwksCluster, err := cds.CreateJWKSCluster(provider.JWKSCluster, provider.JWKSURI)
+		if err != nil {
+			return fmt.Errorf("could not create CDS JWKS Cluster for JWT provider %s: %w", provider.Name, err)
+		}
+		resources.Clusters[jwksCluster.Name] = jwksCluster
+	}
+	return nil
+}
+
+// envoyGRPCListenerGenerator adds the Listener for Envoy proxies receiving gRPC requests, and the
+// RouteConfiguration for this Listener, with one virtual host per Cluster.
+//
+// Envoy proxies will not accept the gRPC server Listeners, because all the routes in their RouteConfigurations
+// specify `NonForwardingAction` as the action.
+// Envoy proxies will also not accept the API Listeners created for gRPC clients, because Envoy proxies can only
+// have at most one API Listener defined, and that API Listener must be a static resource (not fetched via xDS).
+// TODO: Add gRPC-JSON transcoding and gRPC HTTP/1.1 bridge.
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/grpc_json_transcoder_filter
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/grpc_http1_bridge_filter
+type envoyGRPCListenerGenerator struct {
+	BaseResourceGenerator
+	b *SnapshotBuilder
+}
+
+func (g *envoyGRPCListenerGenerator) GenerateListeners(input GeneratorInput, resources *Resources) error {
+	envoyGRPCListener, err := lds.CreateEnvoyGRPCListener(envoyGRPCListenerPort, true, input.Features.CertificateProvider(), input.Features.TrustDomains, input.Features.AccessLogConfig(), input.Features.PermissiveSourcePrefixes)
+	if err != nil {
+		return fmt.Errorf("could not create LDS Listener for Envoy proxy receiving gRPC requests: %w", err)
+	}
+	lds.SetIPFamily(envoyGRPCListener, input.Features.ListenerIPFamily)
+	resources.Listeners[envoyGRPCListener.Name] = envoyGRPCListener
+	return nil
+}
+
+func (g *envoyGRPCListenerGenerator) GenerateRoutes(_ GeneratorInput, resources *Resources) error {
+	var clusterNames []string
+	for clusterName := range resources.Clusters {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	// The Clusters of applications are only added in the clusters phase.
+	for clusterName := range g.b.applicationsByCluster {
+		clusterNames = append(clusterNames, clusterName)
+	}
+	// Sort the names, so that the RouteConfiguration does not change unless the clusters change.
+	slices.Sort(clusterNames)
+	clusterNames = slices.Compact(clusterNames)
+	routeConfiguration, err := rds.CreateRouteConfigurationForEnvoyGRPCListener(clusterNames, g.b.subsetsByCluster, g.b.mirrorsWithClusters())
+	if err != nil {
+		return fmt.Errorf("could not create RDS RouteConfiguration for Envoy proxy gRPC LDS Listener: %w", err)
+	}
+	for _, virtualHost := range routeConfiguration.GetVirtualHosts() {
+		// The virtual hosts are named after their clusters.
+		if err := g.b.setMaintenanceMode(virtualHost.GetName(), virtualHost); err != nil {
+			return err
+		}
+	}
+	resources.RouteConfigurations[routeConfiguration.Name] = routeConfiguration
+	return nil
+}
+
+// dynamicForwardProxyGenerator adds the dynamic forward proxy Listener, RouteConfiguration, and
+// Cluster for Envoy proxies, if enabled.
+// The Cluster is added in the clusters phase, after creating the RouteConfiguration for the Envoy
+// gRPC Listener, so that the RouteConfiguration does not include a virtual host for this Cluster.
+type dynamicForwardProxyGenerator struct {
+	BaseResourceGenerator
+}
+
+func (g *dynamicForwardProxyGenerator) GenerateListeners(input GeneratorInput, resources *Resources) error {
+	if !input.Features.EnableDynamicForwardProxy {
+		return nil
+	}
+	dynamicForwardProxyListener, err := lds.CreateEnvoyDynamicForwardProxyListener(envoyDynamicForwardProxyListenerPort, cds.CreateDynamicForwardProxyDNSCacheConfig(), input.Features.AccessLogConfig())
+	if err != nil {
+		return fmt.Errorf("could not create LDS dynamic forward proxy Listener for Envoy proxy: %w", err)
+	}
+	lds.SetIPFamily(dynamicForwardProxyListener, input.Features.ListenerIPFamily)
+	resources.Listeners[dynamicForwardProxyListener.Name] = dynamicForwardProxyListener
+	return nil
+}
+
+func (g *dynamicForwardProxyGenerator) GenerateRoutes(input GeneratorInput, resources *Resources) error {
+	if !input.Features.EnableDynamicForwardProxy {
+		return nil
+	}
+	routeConfiguration := rds.CreateRouteConfigurationForEnvoyDynamicForwardProxyListener(cds.DynamicForwardProxyClusterName)
+	resources.RouteConfigurations[routeConfiguration.Name] = routeConfiguration
+	return nil
+}
+
+func (g *dynamicForwardProxyGenerator) GenerateClusters(input GeneratorInput, resources *Resources) error {
+	if !input.Features.EnableDynamicForwardProxy {
+		return nil
+	}
+	dynamicForwardProxyCluster, err := cds.CreateDynamicForwardProxyCluster(cds.CreateDynamicForwardProxyDNSCacheConfig())
+	if err != nil {
+		return fmt.Errorf("could not create CDS dynamic forward proxy Cluster for Envoy proxy: %w", err)
+	}
+	resources.Clusters[dynamicForwardProxyCluster.Name] = dynamicForwardProxyCluster
+	return nil
+}
+
+// placeholderGenerator adds EDS Clusters and empty ClusterLoadAssignments for the placeholder
+// names that are not in the snapshot, if synthesizing unknown resources is enabled.
+// The Clusters are added in the clusters phase, so that the RouteConfiguration for the Envoy gRPC
+// Listener does not include virtual hosts for placeholder Clusters.
+type placeholderGenerator struct {
+	BaseResourceGenerator
+	b *SnapshotBuilder
+	// clusterLoadAssignmentNames are the placeholder ClusterLoadAssignment names, including the
+	// names of the placeholder Clusters that do not have a ClusterLoadAssignment.
+	clusterLoadAssignmentNames []string
+}
+
+func (g *placeholderGenerator) GenerateClusters(input GeneratorInput, resources *Resources) error {
+	if !input.Features.SynthesizeUnknownResources {
+		return nil
+	}
+	g.clusterLoadAssignmentNames = slices.Clone(g.b.placeholderClusterLoadAssignmentNames)
+	for _, clusterName := range g.b.placeholderClusterNames {
+		if _, exists := resources.Clusters[clusterName]; exists {
+			continue
+		}
+		cluster, err := cds.CreateCluster(clusterName, clusterName, "", "", nil, 0, "", "", false, input.Features.CertificateProvider(), false, "", nil)
+		if err != nil {
+			return fmt.Errorf("could not create CDS placeholder Cluster %s: %w", clusterName, err)
+		}
+		resources.Clusters[clusterName] = cluster
+		if _, exists := resources.ClusterLoadAssignments[clusterName]; !exists {
+			g.clusterLoadAssignmentNames = append(g.clusterLoadAssignmentNames, clusterName)
+		}
+	}
+	return nil
+}
+
+func (g *placeholderGenerator) GenerateEndpoints(input GeneratorInput, resources *Resources) error {
+	if !input.Features.SynthesizeUnknownResources {
+		return nil
+	}
+	localityPriorityMapper := input.Features.LocalityPriorityMapper(g.b.localityPriorityMapper)
+	localityWeighter := input.Features.LocalityWeighter(g.b.loadReports)
+	for _, clusterLoadAssignmentName := range g.clusterLoadAssignmentNames {
+		if _, exists := resources.ClusterLoadAssignments[clusterLoadAssignmentName]; exists {
+			continue
+		}
+		resources.ClusterLoadAssignments[clusterLoadAssignmentName] = eds.CreateClusterLoadAssignment(clusterLoadAssignmentName, 0, input.NodeHash, localityPriorityMapper, localityWeighter, nil)
+	}
+	return nil
+}
@@ -15,28 +15,54 @@
 package eds
 
 import (
+	"cmp"
+	"net"
+	"slices"
+
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	"google.golang.org/protobuf/types/known/structpb"
 	"google.golang.org/protobuf/types/known/wrapperspb"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
 )
 
+const (
+	// endpointMetadataNamespace is the filter metadata namespace used by Envoy for subset load balancing.
+	endpointMetadataNamespace   = "envoy.lb"
+	endpointMetadataPod         = "pod"
+	endpointMetadataNode        = "node"
+	endpointMetadataKubecontext = "kubecontext"
+)
+
 // CreateClusterLoadAssignment for EDS.
 // `edsServiceName` must match the `ServiceName` in the `EDSClusterConfig` in the CDS Cluster resource.
 // [gRFC A27]: https://github.com/grpc/proposal/blob/972b69ab1f0f7f6079af81a8c2b8a01a15ce3bec/A27-xds-global-load-balancing.md#clusterloadassignment-proto
-func CreateClusterLoadAssignment(edsServiceName string, servingPort uint32, nodeHash string, localityPriorityMapper LocalityPriorityMapper, endpoints []applications.ApplicationEndpoints) *endpointv3.ClusterLoadAssignment {
-	endpointsByZone := map[string][]applications.ApplicationEndpoints{}
+func CreateClusterLoadAssignment(edsServiceName string, servingPort uint32, nodeHash string, localityPriorityMapper LocalityPriorityMapper, localityWeighter LocalityWeighter, endpoints []applications.ApplicationEndpoints) *endpointv3.ClusterLoadAssignment {
+	_, byNode := localityPriorityMapper.(nodeLocalities)
+	endpointsByLocality := map[Locality][]applications.ApplicationEndpoints{}
 	for _, endpoint := range endpoints {
-		endpointsByZone[endpoint.Zone] = append(endpointsByZone[endpoint.Zone], endpoint)
+		locality := Locality{
+			Zone:    endpoint.Zone,
+			Cluster: endpoint.Cluster,
+		}
+		if byNode {
+			locality.Node = endpoint.Node
+		}
+		endpointsByLocality[locality] = append(endpointsByLocality[locality], endpoint)
 	}
-	zones := make([]string, len(endpointsByZone))
+	localities := make([]Locality, len(endpointsByLocality))
 	i := 0
-	for zone := range endpointsByZone {
-		zones[i] = zone
+	for locality := range endpointsByLocality {
+		localities[i] = locality
 		i++
 	}
-	zonePriorities := localityPriorityMapper.BuildPriorityMap(nodeHash, zones)
+	// Sort the localities, so that the ClusterLoadAssignment does not change unless the endpoints change.
+	slices.SortFunc(localities, func(a, b Locality) int {
+		return cmp.Or(cmp.Compare(a.Cluster, b.Cluster), cmp.Compare(a.Zone, b.Zone), cmp.Compare(a.Node, b.Node))
+	})
+	localityPriorities := localityPriorityMapper.BuildPriorityMap(nodeHash, localities)
+	localityWeights := localityWeighter.BuildWeightMap(edsServiceName, endpointsByLocality)
 	cla := &endpointv3.ClusterLoadAssignment{
 		ClusterName: edsServiceName,
 		Endpoints:   []*endpointv3.LocalityLbEndpoints{},
@@ -49,27 +75,40 @@ func CreateClusterLoadAssignment(edsServiceName string, servingPort uint32, node
 			OverprovisioningFactor: wrapperspb.UInt32(100),
 		},
 	}
-	for zone, endpoints := range endpointsByZone {
+	for _, locality := range localities {
+		endpoints := endpointsByLocality[locality]
 		localityLbEndpoints := &endpointv3.LocalityLbEndpoints{
 			// LbEndpoints is mandatory.
 			LbEndpoints: []*endpointv3.LbEndpoint{},
 			// Weight is effectively mandatory, read the javadoc carefully :-)
-			// Use number of endpoints in locality as weight, so assume all endpoints can handle
-			// the same load.
-			LoadBalancingWeight: wrapperspb.UInt32(uint32(len(endpoints))),
+			// Envoy proxies only use the weight if the Cluster has a locality weighted LB config.
+			LoadBalancingWeight: wrapperspb.UInt32(localityWeights[locality]),
 			// Locality must be unique for a given priority.
 			Locality: &corev3.Locality{
-				Zone: zone,
+				Zone:    locality.Zone,
+				SubZone: locality.SubZone(),
 			},
 			// Priority is optional and defaults to 0. If provided, must start from 0 and have no gaps.
 			// Priority 0 is the highest priority.
-			Priority: zonePriorities[zone],
+			Priority: localityPriorities[locality],
 		}
 		for _, endpoint := range endpoints {
+			// xDS clients require IP addresses in ClusterLoadAssignments, so FQDN endpoints are
+			// skipped. Clusters of applications with only FQDN endpoints use DNS instead of EDS,
+			// see `cds.UseLogicalDNS()`.
+			if endpoint.AddressType == applications.AddressTypeFQDN {
+				continue
+			}
+			var loadBalancingWeight *wrapperspb.UInt32Value
+			if endpoint.Weight > 0 {
+				loadBalancingWeight = wrapperspb.UInt32(endpoint.Weight)
+			}
 			for _, address := range endpoint.Addresses {
 				localityLbEndpoints.LbEndpoints = append(localityLbEndpoints.LbEndpoints,
 					&endpointv3.LbEndpoint{
-						HealthStatus: endpoint.EndpointStatus.HealthStatus(),
+						HealthStatus:        endpoint.EndpointStatus.HealthStatus(),
+						LoadBalancingWeight: loadBalancingWeight,
+						Metadata:            createEndpointMetadata(endpoint),
 						HostIdentifier: &endpointv3.LbEndpoint_Endpoint{
 							// Endpoint is mandatory.
 							Endpoint: &endpointv3.Endpoint{
@@ -78,7 +117,7 @@ func CreateClusterLoadAssignment(edsServiceName string, servingPort uint32, node
 									Address: &corev3.Address_SocketAddress{
 										SocketAddress: &corev3.SocketAddress{
 											Protocol: corev3.SocketAddress_TCP,
-											Address:  address, // mandatory, IPv4 or IPv6
+											Address:  endpointIPAddress(address), // mandatory, IPv4 or IPv6
 											PortSpecifier: &corev3.SocketAddress_PortValue{
 												PortValue: servingPort, // mandatory
 											},
@@ -94,3 +133,43 @@ func CreateClusterLoadAssignment(edsServiceName string, servingPort uint32, node
 	}
 	return cla
 }
+
+// endpointIPAddress returns IPv4-mapped IPv6 addresses, e.g., `::ffff:10.0.0.1`, as IPv4 addresses.
+// The `ipv4_compat` field of socket addresses only applies to addresses that Envoy proxies bind to,
+// such as Listener addresses, and not to upstream endpoints.
+func endpointIPAddress(address string) string {
+	if ip := net.ParseIP(address); ip != nil && ip.To4() != nil {
+		return ip.To4().String()
+	}
+	return address
+}
+
+// createEndpointMetadata returns `envoy.lb` filter metadata that identifies the Pod, node, and kubecontext
+// of the endpoint, for debugging with CSDS and the Envoy admin interface. The metadata also includes the
+// subset labels of the endpoint, for subset load balancing.
+// gRPC clients ignore endpoint metadata. Returns nil if none of the fields are known.
+func createEndpointMetadata(endpoint applications.ApplicationEndpoints) *corev3.Metadata {
+	fields := map[string]*structpb.Value{}
+	if endpoint.Pod != "" {
+		fields[endpointMetadataPod] = structpb.NewStringValue(endpoint.Pod)
+	}
+	if endpoint.Node != "" {
+		fields[endpointMetadataNode] = structpb.NewStringValue(endpoint.Node)
+	}
+	if endpoint.Cluster != "" {
+		fields[endpointMetadataKubecontext] = structpb.NewStringValue(endpoint.Cluster)
+	}
+	for key, value := range endpoint.Labels {
+		fields[key] = structpb.NewStringValue(value)
+	}
+	if len(fields) == 0 {
+		return nil
+	}
+	return &corev3.Metadata{
+		FilterMetadata: map[string]*structpb.Struct{
+			endpointMetadataNamespace: {
+				Fields: fields,
+			},
+		},
+	}
+}
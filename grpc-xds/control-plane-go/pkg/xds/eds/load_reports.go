@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eds
+
+import (
+	"sync"
+)
+
+// LoadReports stores request counts from the load reports of xDS clients, by EDS service name and
+// locality, see [gRFC A27 load reporting]. The counts decay by half on each new report for the same
+// EDS service and locality, so that recent reports matter most.
+//
+// [gRFC A27 load reporting]: https://github.com/grpc/proposal/blob/master/A27-xds-global-load-balancing.md#load-reporting
+type LoadReports struct {
+	mu     sync.RWMutex
+	counts map[string]map[Locality]*requestCounts
+}
+
+type requestCounts struct {
+	successful float64
+	errors     float64
+}
+
+func NewLoadReports() *LoadReports {
+	return &LoadReports{
+		counts: map[string]map[Locality]*requestCounts{},
+	}
+}
+
+// Add records the successful and failed requests from a load report.
+func (r *LoadReports) Add(edsServiceName string, locality Locality, successful uint64, errors uint64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	localities, exists := r.counts[edsServiceName]
+	if !exists {
+		localities = map[Locality]*requestCounts{}
+		r.counts[edsServiceName] = localities
+	}
+	counts, exists := localities[locality]
+	if !exists {
+		counts = &requestCounts{}
+		localities[locality] = counts
+	}
+	counts.successful = counts.successful/2 + float64(successful)
+	counts.errors = counts.errors/2 + float64(errors)
+}
+
+// SuccessRatio returns the ratio of successful requests to all completed requests, and false if
+// there are no load reports with completed requests for the EDS service and locality.
+// A nil `LoadReports` has no load reports.
+func (r *LoadReports) SuccessRatio(edsServiceName string, locality Locality) (float64, bool) {
+	if r == nil {
+		return 0, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	counts, exists := r.counts[edsServiceName][locality]
+	if !exists || counts.successful+counts.errors == 0 {
+		return 0, false
+	}
+	return counts.successful / (counts.successful + counts.errors), true
+}
+
+// ServiceSuccessRatio returns the ratio of successful requests to all completed requests across
+// all localities of the EDS service names, e.g., the plain and `xdstp://` names of the same
+// ClusterLoadAssignment, and false if there are no load reports with completed requests for
+// the EDS service names. A nil `LoadReports` has no load reports.
+func (r *LoadReports) ServiceSuccessRatio(edsServiceNames ...string) (float64, bool) {
+	if r == nil {
+		return 0, false
+	}
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var successful, errors float64
+	for _, edsServiceName := range edsServiceNames {
+		for _, counts := range r.counts[edsServiceName] {
+			successful += counts.successful
+			errors += counts.errors
+		}
+	}
+	if successful+errors == 0 {
+		return 0, false
+	}
+	return successful / (successful + errors), true
+}
@@ -0,0 +1,58 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eds
+
+import (
+	"strings"
+)
+
+// NodeHashSeparator separates the zone and the cluster name in node hashes
+// used with `LocalityPriorityByClusterAndZone`, e.g., `us-central1-f/grpc-xds-2`.
+const NodeHashSeparator = "/"
+
+// LocalityPriorityByClusterAndZone determines EDS ClusterLoadAssignment locality priorities,
+// based on the Kubernetes cluster and the zone of the requesting node.
+//
+// Localities in the same Kubernetes cluster as the requesting node get the highest priorities,
+// ordered by zone as done by `LocalityPriorityByZone`. Localities in other Kubernetes clusters
+// get the next priorities, also ordered by zone. This means that clients fail over to endpoints
+// in remote clusters only when no healthy endpoints are available in their own cluster.
+type LocalityPriorityByClusterAndZone struct{}
+
+// BuildPriorityMap constructs the priority map for the provided localities.
+// Assumption: The nodeHash value (the first argument) is `[zone]/[cluster]`, as
+// created by `xds.ZoneClusterHash`.
+func (l LocalityPriorityByClusterAndZone) BuildPriorityMap(nodeHash string, localities []Locality) map[Locality]uint32 {
+	nodeZone, nodeCluster, _ := strings.Cut(nodeHash, NodeHashSeparator)
+	var localLocalities, remoteLocalities []Locality
+	for _, locality := range localities {
+		if locality.Cluster == nodeCluster {
+			localLocalities = append(localLocalities, locality)
+		} else {
+			remoteLocalities = append(remoteLocalities, locality)
+		}
+	}
+	localityPriorities := LocalityPriorityByZone{}.BuildPriorityMap(nodeZone, localLocalities)
+	var remotePriorityOffset uint32
+	for _, priority := range localityPriorities {
+		remotePriorityOffset = max(remotePriorityOffset, priority+1)
+	}
+	for locality, priority := range (LocalityPriorityByZone{}).BuildPriorityMap(nodeZone, remoteLocalities) {
+		localityPriorities[locality] = remotePriorityOffset + priority
+	}
+	return localityPriorities
+}
+
+var _ LocalityPriorityMapper = &LocalityPriorityByClusterAndZone{}
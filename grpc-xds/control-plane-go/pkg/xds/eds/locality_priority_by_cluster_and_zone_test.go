@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eds
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestLocalityPriorityByClusterAndZone(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodeHash   string
+		localities []Locality
+		want       map[Locality]uint32
+	}{
+		{
+			name:       "no localities",
+			nodeHash:   "us-central1-a/grpc-xds-1",
+			localities: nil,
+			want:       map[Locality]uint32{},
+		},
+		{
+			name:     "local cluster before remote clusters",
+			nodeHash: "us-central1-a/grpc-xds-1",
+			localities: []Locality{
+				{Zone: "us-central1-a", Cluster: "grpc-xds-2"},
+				{Zone: "us-central1-b", Cluster: "grpc-xds-1"},
+				{Zone: "us-central1-a", Cluster: "grpc-xds-1"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-central1-a", Cluster: "grpc-xds-1"}: 0,
+				{Zone: "us-central1-b", Cluster: "grpc-xds-1"}: 1,
+				{Zone: "us-central1-a", Cluster: "grpc-xds-2"}: 2,
+			},
+		},
+		{
+			name:     "remote clusters ordered by zone",
+			nodeHash: "us-west1-a/grpc-xds-1",
+			localities: []Locality{
+				{Zone: "us-west1-a", Cluster: "grpc-xds-1"},
+				{Zone: "europe-west1-b", Cluster: "grpc-xds-3"},
+				{Zone: "us-west1-b", Cluster: "grpc-xds-2"},
+				{Zone: "us-west1-a", Cluster: "grpc-xds-2"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-west1-a", Cluster: "grpc-xds-1"}:     0,
+				{Zone: "us-west1-a", Cluster: "grpc-xds-2"}:     1,
+				{Zone: "us-west1-b", Cluster: "grpc-xds-2"}:     2,
+				{Zone: "europe-west1-b", Cluster: "grpc-xds-3"}: 3,
+			},
+		},
+		{
+			name:     "no localities in the local cluster",
+			nodeHash: "us-central1-a/grpc-xds-1",
+			localities: []Locality{
+				{Zone: "us-central1-b", Cluster: "grpc-xds-2"},
+				{Zone: "us-central1-a", Cluster: "grpc-xds-2"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-central1-a", Cluster: "grpc-xds-2"}: 0,
+				{Zone: "us-central1-b", Cluster: "grpc-xds-2"}: 1,
+			},
+		},
+		{
+			name:     "no remote clusters",
+			nodeHash: "us-central1-a/grpc-xds-1",
+			localities: []Locality{
+				{Zone: "us-central1-b", Cluster: "grpc-xds-1"},
+				{Zone: "us-central1-a", Cluster: "grpc-xds-1"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-central1-a", Cluster: "grpc-xds-1"}: 0,
+				{Zone: "us-central1-b", Cluster: "grpc-xds-1"}: 1,
+			},
+		},
+		{
+			name:     "node hash without cluster matches localities without cluster",
+			nodeHash: "us-central1-a",
+			localities: []Locality{
+				{Zone: "us-central1-a", Cluster: "grpc-xds-1"},
+				{Zone: "us-central1-b"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-central1-b"}:                        0,
+				{Zone: "us-central1-a", Cluster: "grpc-xds-1"}: 1,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := LocalityPriorityByClusterAndZone{}.BuildPriorityMap(test.nodeHash, test.localities)
+			if !maps.Equal(got, test.want) {
+				t.Errorf("BuildPriorityMap() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
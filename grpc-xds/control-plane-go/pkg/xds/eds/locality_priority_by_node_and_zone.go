@@ -0,0 +1,60 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eds
+
+import (
+	"strings"
+)
+
+// LocalityPriorityByNodeAndZone determines EDS ClusterLoadAssignment locality priorities,
+// based on the Kubernetes node and the zone of the requesting node.
+//
+// Each Kubernetes node with endpoints is a separate locality, with the node name in the
+// `sub_zone`. The localities on the same node as the requesting node get the highest priority,
+// and the other localities get the next priorities, ordered by zone as done by
+// `LocalityPriorityByZone`. This means that clients send requests to endpoints on their own
+// node, and fail over to endpoints on other nodes only when no healthy endpoints are available
+// on their own node.
+type LocalityPriorityByNodeAndZone struct{}
+
+// BuildPriorityMap constructs the priority map for the provided localities.
+// Assumption: The nodeHash value (the first argument) is `[zone]/[node]`, as
+// created by `xds.ZoneNodeHash`.
+func (l LocalityPriorityByNodeAndZone) BuildPriorityMap(nodeHash string, localities []Locality) map[Locality]uint32 {
+	nodeZone, nodeName, _ := strings.Cut(nodeHash, NodeHashSeparator)
+	localityPriorities := map[Locality]uint32{}
+	var remotePriorityOffset uint32
+	var remoteLocalities []Locality
+	for _, locality := range localities {
+		if nodeName != "" && locality.Node == nodeName {
+			localityPriorities[locality] = 0
+			remotePriorityOffset = 1
+		} else {
+			remoteLocalities = append(remoteLocalities, locality)
+		}
+	}
+	for locality, priority := range (LocalityPriorityByZone{}).BuildPriorityMap(nodeZone, remoteLocalities) {
+		localityPriorities[locality] = remotePriorityOffset + priority
+	}
+	return localityPriorities
+}
+
+// nodeLocalities makes `CreateClusterLoadAssignment()` create one locality per Kubernetes node.
+func (l LocalityPriorityByNodeAndZone) nodeLocalities() {}
+
+var (
+	_ LocalityPriorityMapper = &LocalityPriorityByNodeAndZone{}
+	_ nodeLocalities         = &LocalityPriorityByNodeAndZone{}
+)
@@ -16,6 +16,7 @@ package eds
 
 import (
 	"regexp"
+	"slices"
 )
 
 // LocalityPriorityByZone determines EDS ClusterLoadAssignment locality priorites,
@@ -47,9 +48,25 @@ import (
 // e.g., `europe-west1`.
 type LocalityPriorityByZone struct{}
 
-// BuildPriorityMap constructs the priority map for the provided zones, based on the zone of the requesting node.
+// BuildPriorityMap constructs the priority map for the provided localities, based on the zone of the requesting node.
 // Assumption: The nodeHash value (the first argument) is the zone name of the requesting node.
-func (l LocalityPriorityByZone) BuildPriorityMap(nodeZone string, zonesToPrioritize []string) map[string]uint32 {
+func (l LocalityPriorityByZone) BuildPriorityMap(nodeZone string, localities []Locality) map[Locality]uint32 {
+	zones := make([]string, 0, len(localities))
+	for _, locality := range localities {
+		if !slices.Contains(zones, locality.Zone) {
+			zones = append(zones, locality.Zone)
+		}
+	}
+	zonePriorities := buildZonePriorityMap(nodeZone, zones)
+	localityPriorities := map[Locality]uint32{}
+	for _, locality := range localities {
+		localityPriorities[locality] = zonePriorities[locality.Zone]
+	}
+	return localityPriorities
+}
+
+// buildZonePriorityMap constructs the priority map for the provided zones, based on the zone of the requesting node.
+func buildZonePriorityMap(nodeZone string, zonesToPrioritize []string) map[string]uint32 {
 	region := regionRegexp.FindString(nodeZone)
 	superRegion := superRegionRegexp.FindString(nodeZone)
 	multiRegion := multiRegionRegexp.FindString(nodeZone)
@@ -0,0 +1,100 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eds
+
+import (
+	"maps"
+	"testing"
+)
+
+func TestLocalityPriorityByZone(t *testing.T) {
+	tests := []struct {
+		name       string
+		nodeZone   string
+		localities []Locality
+		want       map[Locality]uint32
+	}{
+		{
+			name:       "no localities",
+			nodeZone:   "us-central1-a",
+			localities: nil,
+			want:       map[Locality]uint32{},
+		},
+		{
+			name:     "zone, region, super-region, multi-region, and other",
+			nodeZone: "us-west1-a",
+			localities: []Locality{
+				{Zone: "europe-west1-b"},
+				{Zone: "us-east1-b"},
+				{Zone: "us-west2-a"},
+				{Zone: "us-west1-b"},
+				{Zone: "us-west1-a"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-west1-a"}:     0,
+				{Zone: "us-west1-b"}:     1,
+				{Zone: "us-west2-a"}:     2,
+				{Zone: "us-east1-b"}:     3,
+				{Zone: "europe-west1-b"}: 4,
+			},
+		},
+		{
+			name:     "priorities have no gaps",
+			nodeZone: "us-west1-a",
+			localities: []Locality{
+				{Zone: "us-west1-a"},
+				{Zone: "europe-west1-b"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-west1-a"}:     0,
+				{Zone: "europe-west1-b"}: 1,
+			},
+		},
+		{
+			name:     "localities in the same zone share the priority",
+			nodeZone: "us-central1-a",
+			localities: []Locality{
+				{Zone: "us-central1-a", Node: "node-a"},
+				{Zone: "us-central1-a", Node: "node-b"},
+				{Zone: "us-central1-b", Node: "node-c"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-central1-a", Node: "node-a"}: 0,
+				{Zone: "us-central1-a", Node: "node-b"}: 0,
+				{Zone: "us-central1-b", Node: "node-c"}: 1,
+			},
+		},
+		{
+			name:     "node zone without localities",
+			nodeZone: "us-central1-a",
+			localities: []Locality{
+				{Zone: "us-central1-b"},
+				{Zone: "us-east1-b"},
+			},
+			want: map[Locality]uint32{
+				{Zone: "us-central1-b"}: 0,
+				{Zone: "us-east1-b"}:    1,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := LocalityPriorityByZone{}.BuildPriorityMap(test.nodeZone, test.localities)
+			if !maps.Equal(got, test.want) {
+				t.Errorf("BuildPriorityMap() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
@@ -14,18 +14,63 @@
 
 package eds
 
+import (
+	"strings"
+)
+
+// subZoneSeparator separates the Kubernetes cluster and node names in the `sub_zone` of
+// xDS localities, if the localities include the node.
+const subZoneSeparator = "/"
+
+// Locality of endpoints in EDS ClusterLoadAssignments. Cluster is the name of the
+// Kubernetes cluster, and it is used as the `sub_zone` of the xDS locality.
+//
+// Node is the name of the Kubernetes node, and it is only set if the locality priority mapper
+// prioritizes endpoints on the node of the client, see `LocalityPriorityByNodeAndZone`.
+// The `sub_zone` is then `[cluster]/[node]`.
+type Locality struct {
+	Zone    string
+	Cluster string
+	Node    string
+}
+
+// SubZone returns the `sub_zone` of the xDS locality.
+func (l Locality) SubZone() string {
+	if l.Node == "" {
+		return l.Cluster
+	}
+	return l.Cluster + subZoneSeparator + l.Node
+}
+
+// LocalityFromSubZone returns the locality for the `zone` and `sub_zone` of an xDS locality,
+// e.g., from load reports. This is the inverse of `SubZone()`.
+func LocalityFromSubZone(zone string, subZone string) Locality {
+	cluster, node, _ := strings.Cut(subZone, subZoneSeparator)
+	return Locality{
+		Zone:    zone,
+		Cluster: cluster,
+		Node:    node,
+	}
+}
+
 // LocalityPriorityMapper determines EDS ClusterLoadAssignment locality priorites.
 type LocalityPriorityMapper interface {
-	// BuildPriorityMap constructs the priority map for the provided zones, based on the node hash.
-	BuildPriorityMap(nodeHash string, zones []string) map[string]uint32
+	// BuildPriorityMap constructs the priority map for the provided localities, based on the node hash.
+	BuildPriorityMap(nodeHash string, localities []Locality) map[Locality]uint32
+}
+
+// nodeLocalities is implemented by locality priority mappers that require one locality per
+// Kubernetes node.
+type nodeLocalities interface {
+	nodeLocalities()
 }
 
 // FixedLocalityPriority returns an empty map.
 // Lookups in the map will always return 0 as the value, so all localities can be assigned the highest priority.
 type FixedLocalityPriority struct{}
 
-func (f FixedLocalityPriority) BuildPriorityMap(_ string, _ []string) map[string]uint32 {
-	return make(map[string]uint32)
+func (f FixedLocalityPriority) BuildPriorityMap(_ string, _ []Locality) map[Locality]uint32 {
+	return make(map[Locality]uint32)
 }
 
 var _ LocalityPriorityMapper = &FixedLocalityPriority{}
@@ -0,0 +1,101 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package eds
+
+import (
+	"math"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+// loadReportWeightScale multiplies the endpoint count weights of localities by their success ratio
+// from load reports, so that the weights can express fractions of the endpoint count.
+const loadReportWeightScale = 100
+
+// LocalityWeighter determines EDS ClusterLoadAssignment locality load balancing weights.
+type LocalityWeighter interface {
+	// BuildWeightMap returns the load balancing weight of each locality of the EDS service.
+	// Weights must be greater than 0.
+	BuildWeightMap(edsServiceName string, endpointsByLocality map[Locality][]applications.ApplicationEndpoints) map[Locality]uint32
+}
+
+// LocalityWeightByEndpointCount uses the number of endpoints in each locality as the weight, so it
+// assumes that all endpoints can handle the same load.
+type LocalityWeightByEndpointCount struct{}
+
+var _ LocalityWeighter = &LocalityWeightByEndpointCount{}
+
+func (l LocalityWeightByEndpointCount) BuildWeightMap(_ string, endpointsByLocality map[Locality][]applications.ApplicationEndpoints) map[Locality]uint32 {
+	weights := make(map[Locality]uint32, len(endpointsByLocality))
+	for locality, endpoints := range endpointsByLocality {
+		weights[locality] = uint32(len(endpoints))
+	}
+	return weights
+}
+
+// StaticLocalityWeights uses fixed weights by zone, e.g., from a config file. Localities in
+// zones without a fixed weight use the number of endpoints as the weight.
+type StaticLocalityWeights struct {
+	weightsByZone map[string]uint32
+}
+
+var _ LocalityWeighter = &StaticLocalityWeights{}
+
+// NewStaticLocalityWeights ignores weights of 0, since localities must have a positive weight.
+func NewStaticLocalityWeights(weightsByZone map[string]uint32) *StaticLocalityWeights {
+	return &StaticLocalityWeights{
+		weightsByZone: weightsByZone,
+	}
+}
+
+func (s *StaticLocalityWeights) BuildWeightMap(edsServiceName string, endpointsByLocality map[Locality][]applications.ApplicationEndpoints) map[Locality]uint32 {
+	weights := LocalityWeightByEndpointCount{}.BuildWeightMap(edsServiceName, endpointsByLocality)
+	for locality := range weights {
+		if weight := s.weightsByZone[locality.Zone]; weight > 0 {
+			weights[locality] = weight
+		}
+	}
+	return weights
+}
+
+// LocalityWeightByLoadReport scales the number of endpoints in each locality by the ratio of
+// successful requests in the load reports from xDS clients, see `LoadReports`, so that localities
+// with failing requests receive less traffic. Localities without load reports use the number of
+// endpoints, scaled as if all requests were successful.
+type LocalityWeightByLoadReport struct {
+	loadReports *LoadReports
+}
+
+var _ LocalityWeighter = &LocalityWeightByLoadReport{}
+
+// NewLocalityWeightByLoadReport uses the endpoint counts only, if `loadReports` is nil.
+func NewLocalityWeightByLoadReport(loadReports *LoadReports) *LocalityWeightByLoadReport {
+	return &LocalityWeightByLoadReport{
+		loadReports: loadReports,
+	}
+}
+
+func (l *LocalityWeightByLoadReport) BuildWeightMap(edsServiceName string, endpointsByLocality map[Locality][]applications.ApplicationEndpoints) map[Locality]uint32 {
+	weights := make(map[Locality]uint32, len(endpointsByLocality))
+	for locality, endpoints := range endpointsByLocality {
+		successRatio, exists := l.loadReports.SuccessRatio(edsServiceName, locality)
+		if !exists {
+			successRatio = 1
+		}
+		weight := math.Round(float64(len(endpoints)*loadReportWeightScale) * successRatio)
+		weights[locality] = uint32(max(weight, 1))
+	}
+	return weights
+}
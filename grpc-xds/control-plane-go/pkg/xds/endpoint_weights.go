@@ -0,0 +1,190 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+const (
+	// EndpointWeightRefreshInterval is how often snapshots are created while endpoint weights
+	// ramp up or decay.
+	EndpointWeightRefreshInterval = 5 * time.Second
+	// MinEndpointWeightTransition is the shortest period for endpoint weights to ramp up or
+	// decay, so that the weights change in several steps.
+	MinEndpointWeightTransition = 2 * EndpointWeightRefreshInterval
+	// endpointWeightFull is the load balancing weight of endpoints that are not in transition.
+	endpointWeightFull = 100
+)
+
+// endpointTransitions tracks when endpoints were added, and when endpoints started draining,
+// to compute load balancing weights that ramp up for new endpoints and decay for draining
+// endpoints, see `apply()`. One instance is shared by all node hashes, so the transition
+// period is a global feature flag, and feature overrides cannot change it.
+type endpointTransitions struct {
+	mu sync.Mutex
+	// started is false until the first observation that includes endpoints, so that the
+	// endpoints that exist when the control plane starts, or when the feature is enabled,
+	// do not ramp up.
+	started       bool
+	period        time.Duration
+	addedAt       map[string]time.Time
+	drainingSince map[string]time.Time
+}
+
+func newEndpointTransitions() *endpointTransitions {
+	return &endpointTransitions{
+		addedAt:       map[string]time.Time{},
+		drainingSince: map[string]time.Time{},
+	}
+}
+
+// endpointTransitionKey identifies endpoints across application updates.
+func endpointTransitionKey(app applications.Application, endpoint applications.ApplicationEndpoints) string {
+	return app.Namespace + "/" + app.Name + "/" + strings.Join(endpoint.Addresses, ",")
+}
+
+// apply observes the endpoints of the applications, and returns copies of the applications where
+// the endpoint weights ramp up from 1 to `endpointWeightFull` over the transition period after the
+// endpoints are added. Draining endpoints stay healthy, with a weight that decays over the period,
+// so that clients shift traffic away gradually. Draining endpoints are reported as draining after
+// the period. A period of 0 returns the applications unchanged, and resets the observations.
+func (t *endpointTransitions) apply(apps []applications.Application, now time.Time, period time.Duration) []applications.Application {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if period <= 0 {
+		t.reset()
+		return apps
+	}
+	t.observe(apps, now)
+	t.period = period
+	weightedApps := make([]applications.Application, len(apps))
+	for i, app := range apps {
+		app.Endpoints = slices.Clone(app.Endpoints)
+		for j, endpoint := range app.Endpoints {
+			key := endpointTransitionKey(app, endpoint)
+			if drainingSince, exists := t.drainingSince[key]; exists {
+				if remaining := period - now.Sub(drainingSince); remaining > 0 {
+					app.Endpoints[j].EndpointStatus = applications.Healthy
+					app.Endpoints[j].Weight = transitionWeight(remaining, period)
+				}
+				continue
+			}
+			app.Endpoints[j].Weight = transitionWeight(now.Sub(t.addedAt[key]), period)
+		}
+		weightedApps[i] = app
+	}
+	return weightedApps
+}
+
+// observe records the endpoints that are new or started draining, and forgets endpoints that
+// no longer exist.
+func (t *endpointTransitions) observe(apps []applications.Application, now time.Time) {
+	seen := map[string]bool{}
+	observedEndpoints := false
+	for _, app := range apps {
+		for _, endpoint := range app.Endpoints {
+			key := endpointTransitionKey(app, endpoint)
+			seen[key] = true
+			observedEndpoints = true
+			if _, exists := t.addedAt[key]; !exists {
+				addedAt := now
+				if !t.started {
+					addedAt = time.Time{}
+				}
+				t.addedAt[key] = addedAt
+			}
+			if endpoint.EndpointStatus != applications.Draining {
+				delete(t.drainingSince, key)
+			} else if _, exists := t.drainingSince[key]; !exists {
+				t.drainingSince[key] = now
+			}
+		}
+	}
+	for key := range t.addedAt {
+		if !seen[key] {
+			delete(t.addedAt, key)
+			delete(t.drainingSince, key)
+		}
+	}
+	t.started = t.started || observedEndpoints
+}
+
+// reset forgets all observations.
+func (t *endpointTransitions) reset() {
+	t.started = false
+	t.period = 0
+	clear(t.addedAt)
+	clear(t.drainingSince)
+}
+
+// inTransition returns true if any endpoints are ramping up or draining.
+func (t *endpointTransitions) inTransition(now time.Time) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	for _, addedAt := range t.addedAt {
+		if now.Sub(addedAt) < t.period {
+			return true
+		}
+	}
+	for _, drainingSince := range t.drainingSince {
+		if now.Sub(drainingSince) < t.period {
+			return true
+		}
+	}
+	return false
+}
+
+// transitionWeight returns the weight for the elapsed part of the transition period,
+// between 1 and `endpointWeightFull`.
+func transitionWeight(elapsed time.Duration, period time.Duration) uint32 {
+	if elapsed >= period {
+		return endpointWeightFull
+	}
+	return max(1, uint32(endpointWeightFull*elapsed/period))
+}
+
+// RefreshEndpointWeights creates new snapshots for all node hashes on every interval, while
+// endpoint weights ramp up or decay, see `endpointWeightTransition` in the feature flags.
+// Runs until the context is done. An interval of 0 disables refreshing.
+func (c *SnapshotCache) RefreshEndpointWeights(ctx context.Context, logger logr.Logger, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			if !c.endpointTransitions.inTransition(now) {
+				continue
+			}
+			logger.V(2).Info("Refreshing endpoint weights, generating new xDS resource snapshots")
+			if err := c.createNewSnapshots(c.apps()); err != nil {
+				logger.Error(err, "Could not create new xDS resource snapshots with endpoint weights")
+			}
+		}
+	}
+}
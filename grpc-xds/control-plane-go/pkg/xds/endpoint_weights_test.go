@@ -0,0 +1,184 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+const testTransitionPeriod = 60 * time.Second
+
+// transitionTestApp returns an application with one endpoint for each address, using the
+// statuses by address, and `Healthy` for addresses without a status.
+func transitionTestApp(statuses map[string]applications.EndpointStatus, addresses ...string) applications.Application {
+	var endpoints []applications.ApplicationEndpoints
+	for _, address := range addresses {
+		status, exists := statuses[address]
+		if !exists {
+			status = applications.Healthy
+		}
+		endpoints = append(endpoints, applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{address}, applications.AddressTypeIPv4, status, nil))
+	}
+	return applications.NewApplication("xds", "greeter-leaf", 50051, "grpc", 50051, "grpc", endpoints)
+}
+
+func TestEndpointTransitions(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	draining := map[string]applications.EndpointStatus{"10.0.0.20": applications.Draining}
+	// step applies the applications at `start` plus `at`, and compares the weights of the endpoints.
+	type step struct {
+		at   time.Duration
+		apps []applications.Application
+		// want has the weight of each endpoint address.
+		want map[string]uint32
+		// wantStatus has the status of the addresses that are not healthy.
+		wantStatus map[string]applications.EndpointStatus
+	}
+	tests := []struct {
+		name  string
+		steps []step
+	}{
+		{
+			name: "endpoints at startup have full weight",
+			steps: []step{
+				{at: 0, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull}},
+			},
+		},
+		{
+			name: "empty first sync does not start the transitions",
+			steps: []step{
+				{at: 0, apps: nil},
+				{at: time.Second, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull}},
+			},
+		},
+		{
+			name: "new endpoints ramp up",
+			steps: []step{
+				{at: 0, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull}},
+				{at: time.Minute, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull, "10.0.0.21": 1}},
+				{at: time.Minute + testTransitionPeriod/4, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull, "10.0.0.21": 25}},
+				{at: time.Minute + testTransitionPeriod, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull, "10.0.0.21": endpointWeightFull}},
+			},
+		},
+		{
+			name: "draining endpoints stay healthy while their weight decays",
+			steps: []step{
+				{at: 0, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull, "10.0.0.21": endpointWeightFull}},
+				{at: time.Minute, apps: []applications.Application{transitionTestApp(draining, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull, "10.0.0.21": endpointWeightFull}},
+				{at: time.Minute + testTransitionPeriod/4, apps: []applications.Application{transitionTestApp(draining, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": 75, "10.0.0.21": endpointWeightFull}},
+				{
+					at:         time.Minute + testTransitionPeriod,
+					apps:       []applications.Application{transitionTestApp(draining, "10.0.0.20", "10.0.0.21")},
+					want:       map[string]uint32{"10.0.0.20": 0, "10.0.0.21": endpointWeightFull},
+					wantStatus: map[string]applications.EndpointStatus{"10.0.0.20": applications.Draining},
+				},
+			},
+		},
+		{
+			name: "removed endpoints ramp up again when added back",
+			steps: []step{
+				{at: 0, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull, "10.0.0.21": endpointWeightFull}},
+				{at: time.Minute, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull}},
+				{at: time.Minute + testTransitionPeriod/2, apps: []applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}, want: map[string]uint32{"10.0.0.20": endpointWeightFull, "10.0.0.21": 1}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			transitions := newEndpointTransitions()
+			for i, step := range test.steps {
+				got := transitions.apply(step.apps, start.Add(step.at), testTransitionPeriod)
+				if len(step.apps) == 0 {
+					continue
+				}
+				for _, endpoints := range got[0].Endpoints {
+					address := endpoints.Addresses[0]
+					if want := step.want[address]; endpoints.Weight != want {
+						t.Errorf("step %d: weight of %s = %d, want %d", i, address, endpoints.Weight, want)
+					}
+					wantStatus, exists := step.wantStatus[address]
+					if !exists {
+						wantStatus = applications.Healthy
+					}
+					if endpoints.EndpointStatus != wantStatus {
+						t.Errorf("step %d: status of %s = %s, want %s", i, address, endpoints.EndpointStatus, wantStatus)
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestEndpointTransitionsDisabled(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	transitions := newEndpointTransitions()
+	transitions.apply([]applications.Application{transitionTestApp(nil, "10.0.0.20")}, start, testTransitionPeriod)
+	apps := []applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}
+	got := transitions.apply(apps, start.Add(time.Second), 0)
+	for _, endpoints := range got[0].Endpoints {
+		if endpoints.Weight != 0 {
+			t.Errorf("weight of %s = %d, want 0 without transitions", endpoints.Addresses[0], endpoints.Weight)
+		}
+	}
+	if transitions.inTransition(start.Add(time.Second)) {
+		t.Errorf("inTransition after disabling = true, want false")
+	}
+	// Enabling the transitions again treats the current endpoints as startup endpoints.
+	got = transitions.apply(apps, start.Add(2*time.Second), testTransitionPeriod)
+	for _, endpoints := range got[0].Endpoints {
+		if endpoints.Weight != endpointWeightFull {
+			t.Errorf("weight of %s = %d, want %d after enabling", endpoints.Addresses[0], endpoints.Weight, endpointWeightFull)
+		}
+	}
+}
+
+func TestEndpointTransitionsInTransition(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	transitions := newEndpointTransitions()
+	transitions.apply([]applications.Application{transitionTestApp(nil, "10.0.0.20")}, start, testTransitionPeriod)
+	if transitions.inTransition(start) {
+		t.Errorf("inTransition with startup endpoints = true, want false")
+	}
+	added := start.Add(time.Minute)
+	transitions.apply([]applications.Application{transitionTestApp(nil, "10.0.0.20", "10.0.0.21")}, added, testTransitionPeriod)
+	if !transitions.inTransition(added.Add(testTransitionPeriod / 2)) {
+		t.Errorf("inTransition during ramp up = false, want true")
+	}
+	if transitions.inTransition(added.Add(testTransitionPeriod)) {
+		t.Errorf("inTransition after the period = true, want false")
+	}
+}
+
+func TestTransitionWeight(t *testing.T) {
+	tests := []struct {
+		elapsed time.Duration
+		want    uint32
+	}{
+		{elapsed: 0, want: 1},
+		{elapsed: testTransitionPeriod / 200, want: 1},
+		{elapsed: testTransitionPeriod / 2, want: 50},
+		{elapsed: testTransitionPeriod - time.Millisecond, want: 99},
+		{elapsed: testTransitionPeriod, want: endpointWeightFull},
+		{elapsed: 2 * testTransitionPeriod, want: endpointWeightFull},
+	}
+	for _, test := range tests {
+		if got := transitionWeight(test.elapsed, testTransitionPeriod); got != test.want {
+			t.Errorf("transitionWeight(%s, %s) = %d, want %d", test.elapsed, testTransitionPeriod, got, test.want)
+		}
+	}
+}
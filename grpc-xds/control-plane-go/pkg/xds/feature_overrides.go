@@ -0,0 +1,161 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"gopkg.in/yaml.v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+const (
+	// nodeHashMetadataSeparator separates the node hash of the wrapped node hash function from the
+	// node metadata fields appended by `NodeMetadataHash`.
+	nodeHashMetadataSeparator = ";"
+	// nodeHashMetadataKeyValueSeparator separates the key and the value of node metadata fields
+	// appended by `NodeMetadataHash`.
+	nodeHashMetadataKeyValueSeparator = "="
+)
+
+var errUnknownFeatureFlag = errors.New("unknown xDS feature flag")
+
+// FeatureOverride changes xDS feature flags for the xDS clients in a zone, or with node metadata
+// values, e.g., to enable RBAC for the clients in one namespace as a staged rollout.
+type FeatureOverride struct {
+	// Zone matches xDS clients in the zone. Empty matches all zones.
+	Zone string `yaml:"zone"`
+	// NodeMetadata matches xDS clients with all of these node metadata fields, e.g.,
+	// `K8S_NAMESPACE: xds`. Empty matches all clients.
+	NodeMetadata map[string]string `yaml:"nodeMetadata"`
+	// Features are the feature flags to change, using the names from the xDS feature flags config file.
+	Features map[string]interface{} `yaml:"features"`
+}
+
+// matches returns true if the override applies to xDS clients in the zone, with the node metadata.
+func (o FeatureOverride) matches(zone string, nodeMetadata map[string]string) bool {
+	if o.Zone != "" && o.Zone != zone {
+		return false
+	}
+	for key, value := range o.NodeMetadata {
+		if nodeMetadata[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// WithOverrides returns a copy of the feature flags, with the flags of the provided names, as in
+// the xDS feature flags config file, set to the provided values.
+func (f *Features) WithOverrides(overrides map[string]interface{}) (*Features, error) {
+	yamlBytes, err := yaml.Marshal(f)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal xDS feature flags to YAML: %w", err)
+	}
+	flags := map[string]interface{}{}
+	if err := yaml.Unmarshal(yamlBytes, &flags); err != nil {
+		return nil, fmt.Errorf("could not unmarshal xDS feature flags YAML [%s]: %w", yamlBytes, err)
+	}
+	for name, value := range overrides {
+		if _, exists := flags[name]; !exists {
+			return nil, fmt.Errorf("%w: name=%s", errUnknownFeatureFlag, name)
+		}
+		flags[name] = value
+	}
+	yamlBytes, err = yaml.Marshal(flags)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal xDS feature flags to YAML: %w", err)
+	}
+	var features Features
+	if err := yaml.Unmarshal(yamlBytes, &features); err != nil {
+		return nil, fmt.Errorf("could not apply xDS feature flag overrides %+v: %w", overrides, err)
+	}
+	return &features, nil
+}
+
+// ForNodeHash returns the feature flags for the xDS clients with the node hash, after applying the
+// matching feature overrides in order. Overrides that cannot be applied are skipped, since the
+// config file validation rejects them, see `config.XDSFeatures()`.
+func (f *Features) ForNodeHash(nodeHash string) *Features {
+	if len(f.FeatureOverrides) == 0 {
+		return f
+	}
+	baseNodeHash, nodeMetadata := splitNodeHash(nodeHash)
+	zone, _, _ := strings.Cut(baseNodeHash, eds.NodeHashSeparator)
+	features := f
+	for _, override := range f.FeatureOverrides {
+		if !override.matches(zone, nodeMetadata) {
+			continue
+		}
+		if overridden, err := features.WithOverrides(override.Features); err == nil {
+			features = overridden
+		}
+	}
+	return features
+}
+
+// FeatureOverrideMetadataKeys returns the sorted node metadata keys used by the feature overrides.
+func (f *Features) FeatureOverrideMetadataKeys() []string {
+	keys := map[string]bool{}
+	for _, override := range f.FeatureOverrides {
+		for key := range override.NodeMetadata {
+			keys[key] = true
+		}
+	}
+	return slices.Sorted(maps.Keys(keys))
+}
+
+// NodeMetadataHash appends the values of node metadata fields to the node hash of the wrapped node
+// hash function, as `[nodeHash];[key]=[value]`, so that xDS clients with different values access
+// different cache snapshots, e.g., for feature overrides by node metadata.
+type NodeMetadataHash struct {
+	nodeHash     cachev3.NodeHash
+	metadataKeys []string
+}
+
+var _ cachev3.NodeHash = &NodeMetadataHash{}
+
+func (h NodeMetadataHash) ID(node *corev3.Node) string {
+	var nodeHash strings.Builder
+	nodeHash.WriteString(h.nodeHash.ID(node))
+	for _, key := range h.metadataKeys {
+		if value := node.GetMetadata().GetFields()[key].GetStringValue(); value != "" {
+			nodeHash.WriteString(nodeHashMetadataSeparator + key + nodeHashMetadataKeyValueSeparator + value)
+		}
+	}
+	return nodeHash.String()
+}
+
+// splitNodeHash returns the node hash of the wrapped node hash function, and the node metadata
+// fields appended by `NodeMetadataHash`.
+func splitNodeHash(nodeHash string) (string, map[string]string) {
+	baseNodeHash, metadataFields, found := strings.Cut(nodeHash, nodeHashMetadataSeparator)
+	if !found {
+		return nodeHash, nil
+	}
+	nodeMetadata := map[string]string{}
+	for _, field := range strings.Split(metadataFields, nodeHashMetadataSeparator) {
+		key, value, _ := strings.Cut(field, nodeHashMetadataKeyValueSeparator)
+		nodeMetadata[key] = value
+	}
+	return baseNodeHash, nodeMetadata
+}
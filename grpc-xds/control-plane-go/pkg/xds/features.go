@@ -14,6 +14,56 @@
 
 package xds
 
+import (
+	"cmp"
+	"slices"
+	"time"
+
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+const (
+	// LocalityPriorityPolicyZone prioritizes endpoints by zone, see `eds.LocalityPriorityByZone`.
+	LocalityPriorityPolicyZone = "zone"
+	// LocalityPriorityPolicyClusterAndZone prioritizes endpoints in the same Kubernetes cluster,
+	// and then by zone, see `eds.LocalityPriorityByClusterAndZone`.
+	LocalityPriorityPolicyClusterAndZone = "clusterAndZone"
+	// LocalityPriorityPolicyNodeAndZone prioritizes endpoints on the same Kubernetes node,
+	// and then by zone, see `eds.LocalityPriorityByNodeAndZone`.
+	LocalityPriorityPolicyNodeAndZone = "nodeAndZone"
+
+	// XDSStreamModeAll serves both the Aggregated Discovery Service (ADS) and the separate
+	// discovery services for each resource type.
+	XDSStreamModeAll = "all"
+	// XDSStreamModeADS only serves the Aggregated Discovery Service (ADS).
+	XDSStreamModeADS = "ads"
+	// XDSStreamModeSeparate only serves the separate discovery services for each resource type.
+	// gRPC clients require ADS, so only Envoy proxies can use this mode.
+	XDSStreamModeSeparate = "separate"
+
+	// LocalityWeightPolicyEndpointCount weighs localities by their number of endpoints,
+	// see `eds.LocalityWeightByEndpointCount`.
+	LocalityWeightPolicyEndpointCount = "endpointCount"
+	// LocalityWeightPolicyStatic weighs localities using fixed weights by zone from the
+	// `localityWeights` feature flag, see `eds.StaticLocalityWeights`.
+	LocalityWeightPolicyStatic = "static"
+	// LocalityWeightPolicyLoadReport weighs localities by their number of endpoints and the ratio
+	// of successful requests in load reports from gRPC clients, see `eds.LocalityWeightByLoadReport`.
+	LocalityWeightPolicyLoadReport = "loadReport"
+
+	// EnvoyADSCluster is the name of the static Envoy cluster for the control plane in the Envoy
+	// bootstrap configuration, used for the Aggregated Discovery Service (ADS).
+	EnvoyADSCluster = "xds_cluster"
+	// DefaultAccessLogServiceCluster is the Envoy cluster for the gRPC Access Log Service (ALS).
+	// The control plane serves ALS on the same port as ADS, so the default is the ADS cluster.
+	DefaultAccessLogServiceCluster = EnvoyADSCluster
+)
+
 // Features of the xDS control plane that can be enabled and disabled via a config file.
 type Features struct {
 	EnableControlPlaneTLS          bool `yaml:"enableControlPlaneTls"`
@@ -22,4 +72,206 @@ type Features struct {
 	RequireDataPlaneClientCerts    bool `yaml:"requireDataPlaneClientCerts"`
 	EnableRBAC                     bool `yaml:"enableRbac"`
 	EnableFederation               bool `yaml:"enableFederation"`
+	EnableWeightedRoundRobin       bool `yaml:"enableWeightedRoundRobin"`
+	// LoadBalancingPolicy is `round_robin`, `pick_first`, `least_request`, or `weighted_round_robin`,
+	// and is sent to clients using the `load_balancing_policy` field of Clusters, see gRFC A52.
+	// Empty means round-robin, using the `lb_policy` field. Cannot be combined with
+	// `enableWeightedRoundRobin`, which is the same as `weighted_round_robin`.
+	LoadBalancingPolicy string `yaml:"loadBalancingPolicy"`
+	// RBACAuditOnly evaluates and logs RBAC policies as shadow rules, without enforcing them.
+	RBACAuditOnly bool `yaml:"rbacAuditOnly"`
+	// EnableJWTAuthn adds a JWT authentication HTTP filter to server Listeners, using the JWT providers
+	// from the `jwt_authn.yaml` config file. Only Envoy proxies verify JWTs, gRPC servers ignore the filter.
+	EnableJWTAuthn bool `yaml:"enableJwtAuthn"`
+	// AccessLog is `stdout` for JSON access logs on standard output, `grpc` to send access logs to the
+	// gRPC Access Log Service (ALS) of the control plane, or empty to disable access logs. Only Envoy
+	// proxies write access logs, gRPC servers ignore the configuration.
+	AccessLog string `yaml:"accessLog"`
+	// AccessLogServiceCluster is the Envoy cluster that Envoy proxies use to send access logs if
+	// `accessLog` is `grpc`. Default is `DefaultAccessLogServiceCluster`. The Envoy bootstrap
+	// configuration from `bootstrap.RenderEnvoy()` only contains the ADS cluster.
+	AccessLogServiceCluster string `yaml:"accessLogServiceCluster"`
+	// EnableTCPProxy adds a TCP proxy Listener for Envoy proxies for each application that does not serve
+	// HTTP or gRPC, on the serving port of the application. gRPC clients and servers ignore these Listeners.
+	EnableTCPProxy bool `yaml:"enableTcpProxy"`
+	// EnableDynamicForwardProxy adds a Listener and Cluster for Envoy proxies that forward egress HTTP
+	// requests to any host, resolving the hostnames using a DNS cache. gRPC clients ignore these resources.
+	EnableDynamicForwardProxy bool `yaml:"enableDynamicForwardProxy"`
+	// PermissiveSourcePrefixes are IP address ranges in CIDR notation, e.g., of health checkers. Server
+	// Listeners and Envoy Listeners get an additional filter chain for connections from these ranges,
+	// that does not require client certificates, and does not enforce RBAC policies or verify JWTs.
+	PermissiveSourcePrefixes []string `yaml:"permissiveSourcePrefixes"`
+	// ServerListenerUsesRDS makes gRPC server Listeners refer to their RouteConfiguration by name,
+	// so that xDS clients fetch it using RDS. Otherwise, the RouteConfiguration is inline in the Listener.
+	ServerListenerUsesRDS bool `yaml:"serverListenerUsesRds"`
+	// TrustDomains are the SPIFFE trust domains of workloads that gRPC clients and servers accept
+	// certificates from. Empty means any trust domain. Envoy proxies use the trust bundles, if set,
+	// to validate client certificates from workloads in other trust domains.
+	TrustDomains []tls.TrustDomain `yaml:"trustDomains"`
+	// AllowedControlPlaneClientSPIFFEIDs, AllowedControlPlaneClientNamespaces, and
+	// AllowedControlPlaneClientServiceAccounts restrict which xDS clients can connect to the control plane,
+	// based on the SPIFFE ID in their TLS certificate. Clients are allowed if their SPIFFE ID is in the list
+	// of SPIFFE IDs, or if their Namespace and ServiceAccount are in the lists. Empty lists allow any client.
+	// Requires requireControlPlaneClientCerts=true.
+	AllowedControlPlaneClientSPIFFEIDs       []string `yaml:"allowedControlPlaneClientSpiffeIds"`
+	AllowedControlPlaneClientNamespaces      []string `yaml:"allowedControlPlaneClientNamespaces"`
+	AllowedControlPlaneClientServiceAccounts []string `yaml:"allowedControlPlaneClientServiceAccounts"`
+	// RequireAllResourceNames makes the control plane respond to a request for a resource type only
+	// if the request names all the resources of that type in the snapshot. By default, the control
+	// plane responds to partial requests, see `AllowPartialRequests()`.
+	RequireAllResourceNames bool `yaml:"requireAllResourceNames"`
+	// XDSStreamMode is `all` (default), `ads`, or `separate`, and determines the discovery services
+	// that the control plane serves.
+	XDSStreamMode string `yaml:"xdsStreamMode"`
+	// LocalityPriorityPolicy is `zone` (default), `clusterAndZone`, or `nodeAndZone`.
+	LocalityPriorityPolicy string `yaml:"localityPriorityPolicy"`
+	// DisableLocalityPriority assigns priority 0 to all localities, and weighs localities by their
+	// number of endpoints, regardless of `localityWeightPolicy`, so that clients spread requests
+	// evenly across all endpoints in all zones. Use this to compare with zone-aware routing.
+	DisableLocalityPriority bool `yaml:"disableLocalityPriority"`
+	// LocalityWeightPolicy is `endpointCount`, `static`, or `loadReport`, and determines the load
+	// balancing weights of localities in ClusterLoadAssignments. Any of these values also adds a
+	// locality weighted LB config to Clusters, so that Envoy proxies pick localities by weight.
+	// Empty means endpoint count weights, without the locality weighted LB config.
+	LocalityWeightPolicy string `yaml:"localityWeightPolicy"`
+	// LocalityWeights are the fixed locality weights by zone if `localityWeightPolicy` is `static`.
+	LocalityWeights map[string]uint32 `yaml:"localityWeights"`
+	// CertificateProviderInstanceName must match a `certificate_providers` key in the gRPC xDS
+	// bootstrap configuration of clients and servers. Default is `tls.DefaultCertificateProviderInstanceName`.
+	CertificateProviderInstanceName string `yaml:"certificateProviderInstanceName"`
+	// IdentityCertificateName is passed to the certificate provider when requesting workload certificates.
+	IdentityCertificateName string `yaml:"identityCertificateName"`
+	// RootCertificateName is passed to the certificate provider when requesting CA certificates.
+	RootCertificateName string `yaml:"rootCertificateName"`
+	// NACKSimulationApplications are names of applications whose Clusters are deliberately invalid,
+	// so that xDS clients reject (NACK) the CDS responses. Use this to observe NACK handling in clients
+	// and in the control plane, and remove the application names to recover.
+	NACKSimulationApplications []string `yaml:"nackSimulationApplications"`
+	// MaintenanceApplications are names of applications in maintenance mode, mapped to the name of
+	// the gRPC status code, e.g., `UNAVAILABLE`, that requests to the application fail with. Empty
+	// means `DefaultMaintenanceStatusCode`. The routes to these applications respond directly,
+	// without forwarding requests, see `rds.SetMaintenanceMode()`.
+	MaintenanceApplications map[string]string `yaml:"maintenanceApplications"`
+	// ResourceTTL is the TTL of the RouteConfiguration for Envoy proxies, e.g., `30s`. The control
+	// plane sends heartbeats to refresh the TTL, and Envoy proxies remove the RouteConfiguration if
+	// the TTL expires, e.g., when the control plane is unavailable. Zero means no TTL.
+	ResourceTTL time.Duration `yaml:"resourceTtl"`
+	// SynthesizeUnknownResources adds placeholder Clusters and ClusterLoadAssignments without
+	// endpoints for names that xDS clients request, but that are not in the snapshot, e.g., due to
+	// configuration skew. Clients then fail requests to these clusters fast with `UNAVAILABLE`.
+	SynthesizeUnknownResources bool `yaml:"synthesizeUnknownResources"`
+	// ListenerIPFamily is the IP family of the Listeners for Envoy proxies, one of `IPv4` (default),
+	// `IPv6`, or `DualStack`, see `lds.SetIPFamily()`. Listeners for gRPC servers use the listening
+	// addresses of the gRPC servers.
+	ListenerIPFamily string `yaml:"listenerIpFamily"`
+	// EndpointWeightTransition is the period over which the load balancing weights of new endpoints
+	// ramp up, and the weights of terminating endpoints decay, e.g., `60s`, to smooth traffic shifts
+	// during rollouts. Zero disables the weights. gRPC clients only use endpoint weights with some
+	// load balancing policies, e.g., `ring_hash`, while Envoy proxies use them for round robin.
+	// Feature overrides cannot change this flag. Kubernetes informers only include terminating
+	// endpoints that are still serving while this flag is set.
+	EndpointWeightTransition time.Duration `yaml:"endpointWeightTransition"`
+	// FeatureOverrides change feature flags for the xDS clients in a zone, or with node metadata
+	// values, and are applied in order, see `ForNodeHash()`. Node metadata keys in the overrides
+	// are added to the node hash at startup, so new keys require a restart.
+	FeatureOverrides []FeatureOverride `yaml:"featureOverrides"`
+}
+
+// NodeHash returns the node hash function of the snapshot cache, and the matching locality
+// priority mapper, for the locality priority policy. If feature overrides match node metadata,
+// the node hash function also adds the node metadata values, see `NodeMetadataHash`.
+func (f *Features) NodeHash() (cachev3.NodeHash, eds.LocalityPriorityMapper) {
+	var nodeHash cachev3.NodeHash = ZoneHash{}
+	var localityPriorityMapper eds.LocalityPriorityMapper = eds.LocalityPriorityByZone{}
+	switch f.LocalityPriorityPolicy {
+	case LocalityPriorityPolicyClusterAndZone:
+		nodeHash, localityPriorityMapper = ZoneClusterHash{}, eds.LocalityPriorityByClusterAndZone{}
+	case LocalityPriorityPolicyNodeAndZone:
+		nodeHash, localityPriorityMapper = ZoneNodeHash{}, eds.LocalityPriorityByNodeAndZone{}
+	}
+	if metadataKeys := f.FeatureOverrideMetadataKeys(); len(metadataKeys) > 0 {
+		nodeHash = NodeMetadataHash{
+			nodeHash:     nodeHash,
+			metadataKeys: metadataKeys,
+		}
+	}
+	return nodeHash, localityPriorityMapper
+}
+
+// LocalityPriorityMapper returns the provided mapper, or `eds.FixedLocalityPriority` if locality
+// priority is disabled. Unlike `NodeHash()`, this can change when the feature flags are reloaded.
+func (f *Features) LocalityPriorityMapper(localityPriorityMapper eds.LocalityPriorityMapper) eds.LocalityPriorityMapper {
+	if f.DisableLocalityPriority {
+		return eds.FixedLocalityPriority{}
+	}
+	return localityPriorityMapper
+}
+
+// LocalityWeighter returns the locality weighter for the locality weight policy. The load reports
+// are only used by the `loadReport` policy.
+func (f *Features) LocalityWeighter(loadReports *eds.LoadReports) eds.LocalityWeighter {
+	if f.DisableLocalityPriority {
+		return eds.LocalityWeightByEndpointCount{}
+	}
+	switch f.LocalityWeightPolicy {
+	case LocalityWeightPolicyStatic:
+		return eds.NewStaticLocalityWeights(f.LocalityWeights)
+	case LocalityWeightPolicyLoadReport:
+		return eds.NewLocalityWeightByLoadReport(loadReports)
+	default:
+		return eds.LocalityWeightByEndpointCount{}
+	}
+}
+
+// UsesLoadReports returns true if Clusters should ask gRPC clients to send load reports.
+func (f *Features) UsesLoadReports() bool {
+	return f.LocalityWeightPolicy == LocalityWeightPolicyLoadReport
+}
+
+// AllowPartialRequests returns true if the control plane responds to requests for a resource type
+// even if the request does not name all the resources of that type in the snapshot.
+func (f *Features) AllowPartialRequests() bool {
+	return !f.RequireAllResourceNames
+}
+
+// ServesADS returns true if the control plane serves the Aggregated Discovery Service (ADS).
+func (f *Features) ServesADS() bool {
+	return f.XDSStreamMode != XDSStreamModeSeparate
+}
+
+// ServesSeparateStreams returns true if the control plane serves the separate discovery
+// services for each resource type.
+func (f *Features) ServesSeparateStreams() bool {
+	return f.XDSStreamMode != XDSStreamModeADS
+}
+
+// ClusterLoadBalancingPolicy returns the name of the load balancing policy of Clusters, see
+// `cds.CreateCluster()`.
+func (f *Features) ClusterLoadBalancingPolicy() string {
+	if f.EnableWeightedRoundRobin {
+		return cds.LoadBalancingPolicyWeightedRoundRobin
+	}
+	return f.LoadBalancingPolicy
+}
+
+// AccessLogConfig returns the access log configuration for server Listeners and Envoy Listeners.
+func (f *Features) AccessLogConfig() lds.AccessLog {
+	if f.AccessLog == "" {
+		return lds.AccessLog{}
+	}
+	return lds.AccessLog{
+		Type:               f.AccessLog,
+		GRPCServiceCluster: cmp.Or(f.AccessLogServiceCluster, DefaultAccessLogServiceCluster),
+	}
+}
+
+// CertificateProvider returns the certificate provider instance and certificate names to use in TLS contexts.
+func (f *Features) CertificateProvider() tls.CertificateProvider {
+	return tls.NewCertificateProvider(f.CertificateProviderInstanceName, f.IdentityCertificateName, f.RootCertificateName)
+}
+
+// SimulatesNACK returns true if the Clusters of the application should be invalid, see
+// `NACKSimulationApplications`.
+func (f *Features) SimulatesNACK(appName string) bool {
+	return slices.Contains(f.NACKSimulationApplications, appName)
 }
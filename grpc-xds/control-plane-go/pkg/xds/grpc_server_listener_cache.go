@@ -18,14 +18,32 @@ import (
 	"sync"
 )
 
+// GRPCServerListenerCache stores the gRPC server listener addresses for each node hash, and the
+// xDS streams that requested each address, so that addresses are removed when the last stream
+// that requested them closes, e.g., when a Deployment of xDS-enabled gRPC servers scales down.
 type GRPCServerListenerCache struct {
 	mu    sync.RWMutex
 	cache map[string]map[EndpointAddress]bool
+	// streams are the server listener addresses requested on each open xDS stream.
+	streams map[StreamKey]*grpcServerListenerStream
+}
+
+// StreamKey identifies an xDS stream. State-of-the-world and delta (incremental) xDS streams
+// have separate stream ID sequences.
+type StreamKey struct {
+	ID    int64
+	Delta bool
+}
+
+type grpcServerListenerStream struct {
+	nodeHash  string
+	addresses map[EndpointAddress]bool
 }
 
 func NewGRPCServerListenerCache() *GRPCServerListenerCache {
 	return &GRPCServerListenerCache{
-		cache: map[string]map[EndpointAddress]bool{},
+		cache:   map[string]map[EndpointAddress]bool{},
+		streams: map[StreamKey]*grpcServerListenerStream{},
 	}
 }
 
@@ -60,3 +78,94 @@ func (c *GRPCServerListenerCache) Get(nodeHash string) []EndpointAddress {
 	}
 	return addresses
 }
+
+// Remove deletes the gRPC server listener addresses for the provided `nodeHash` cache key.
+func (c *GRPCServerListenerCache) Remove(nodeHash string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.cache, nodeHash)
+	for stream, streamAddresses := range c.streams {
+		if streamAddresses.nodeHash == nodeHash {
+			delete(c.streams, stream)
+		}
+	}
+}
+
+// SubscribeStream records that the stream requested the gRPC server listener addresses.
+// With `replace`, the addresses replace the addresses previously requested on the stream, since
+// each state-of-the-world request lists all the resource names that the client is interested in.
+//
+// The addresses are not added to the cache for the node hash, see `Add()`.
+func (c *GRPCServerListenerCache) SubscribeStream(stream StreamKey, nodeHash string, addresses []EndpointAddress, replace bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	streamAddresses, exists := c.streams[stream]
+	if !exists || replace || streamAddresses.nodeHash != nodeHash {
+		streamAddresses = &grpcServerListenerStream{
+			nodeHash:  nodeHash,
+			addresses: make(map[EndpointAddress]bool, len(addresses)),
+		}
+		c.streams[stream] = streamAddresses
+	}
+	for _, address := range addresses {
+		streamAddresses.addresses[address] = true
+	}
+}
+
+// UnsubscribeStream records that the delta stream is no longer interested in the gRPC server
+// listener addresses.
+func (c *GRPCServerListenerCache) UnsubscribeStream(stream StreamKey, addresses []EndpointAddress) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	streamAddresses, exists := c.streams[stream]
+	if !exists {
+		return
+	}
+	for _, address := range addresses {
+		delete(streamAddresses.addresses, address)
+	}
+}
+
+// NodeHash returns the node hash of a stream that requested gRPC server listener addresses.
+func (c *GRPCServerListenerCache) NodeHash(stream StreamKey) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	streamAddresses, exists := c.streams[stream]
+	if !exists {
+		return "", false
+	}
+	return streamAddresses.nodeHash, true
+}
+
+// RemoveStream forgets the stream, and removes the gRPC server listener addresses that the stream
+// requested from the cache, unless other open streams for the same node hash also requested them.
+// Returns the node hash of the stream, and true if any addresses were removed.
+func (c *GRPCServerListenerCache) RemoveStream(stream StreamKey) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	closedStream, exists := c.streams[stream]
+	if !exists {
+		return "", false
+	}
+	delete(c.streams, stream)
+	addresses := c.cache[closedStream.nodeHash]
+	removed := false
+	for address := range closedStream.addresses {
+		if addresses[address] && !c.requestedByOpenStream(closedStream.nodeHash, address) {
+			delete(addresses, address)
+			removed = true
+		}
+	}
+	return closedStream.nodeHash, removed
+}
+
+// requestedByOpenStream returns true if any open stream for the node hash requested the address.
+// The caller must hold the lock.
+func (c *GRPCServerListenerCache) requestedByOpenStream(nodeHash string, address EndpointAddress) bool {
+	for _, streamAddresses := range c.streams {
+		if streamAddresses.nodeHash == nodeHash && streamAddresses.addresses[address] {
+			return true
+		}
+	}
+	return false
+}
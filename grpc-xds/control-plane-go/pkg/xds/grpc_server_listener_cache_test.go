@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestGRPCServerListenerCacheRemoveStream(t *testing.T) {
+	nodeHash := goldenZone
+	addressA := EndpointAddress{Host: "10.0.0.20", Port: 50051}
+	addressB := EndpointAddress{Host: "10.0.0.21", Port: 50051}
+	streamA := StreamKey{ID: 1}
+	// Delta stream IDs are a separate sequence, so the same ID is a different stream.
+	streamB := StreamKey{ID: 1, Delta: true}
+	tests := []struct {
+		name        string
+		streamB     []EndpointAddress
+		unsubscribe []EndpointAddress
+		wantRemoved bool
+		want        []EndpointAddress
+	}{
+		{
+			name:        "removes addresses only requested by the closed stream",
+			streamB:     []EndpointAddress{addressB},
+			wantRemoved: true,
+			want:        []EndpointAddress{addressB},
+		},
+		{
+			name:        "keeps addresses requested by other open streams",
+			streamB:     []EndpointAddress{addressA, addressB},
+			wantRemoved: false,
+			want:        []EndpointAddress{addressA, addressB},
+		},
+		{
+			name:        "removes addresses unsubscribed by other open streams",
+			streamB:     []EndpointAddress{addressA, addressB},
+			unsubscribe: []EndpointAddress{addressA},
+			wantRemoved: true,
+			want:        []EndpointAddress{addressB},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := NewGRPCServerListenerCache()
+			c.SubscribeStream(streamA, nodeHash, []EndpointAddress{addressA}, true)
+			c.SubscribeStream(streamB, nodeHash, test.streamB, false)
+			c.UnsubscribeStream(streamB, test.unsubscribe)
+			c.Add(nodeHash, []EndpointAddress{addressA, addressB})
+			gotNodeHash, gotRemoved := c.RemoveStream(streamA)
+			if gotNodeHash != nodeHash || gotRemoved != test.wantRemoved {
+				t.Errorf("RemoveStream() = (%q, %t), want (%q, %t)", gotNodeHash, gotRemoved, nodeHash, test.wantRemoved)
+			}
+			got := c.Get(nodeHash)
+			slices.SortFunc(got, func(a EndpointAddress, b EndpointAddress) int {
+				return strings.Compare(a.Host, b.Host)
+			})
+			if !slices.Equal(got, test.want) {
+				t.Errorf("Get() = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
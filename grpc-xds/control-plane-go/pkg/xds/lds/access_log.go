@@ -0,0 +1,125 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lds
+
+import (
+	"fmt"
+
+	accesslogv3 "github.com/envoyproxy/go-control-plane/envoy/config/accesslog/v3"
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	grpcaccesslogv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/grpc/v3"
+	streamaccesslogv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/access_loggers/stream/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+const (
+	// AccessLogStdout writes access logs in JSON format to standard output.
+	AccessLogStdout = "stdout"
+	// AccessLogGRPC sends access logs to a gRPC Access Log Service (ALS).
+	AccessLogGRPC = "grpc"
+
+	envoyAccessLoggerStdoutName   = "envoy.access_loggers.stdout"
+	envoyAccessLoggerHTTPGRPCName = "envoy.access_loggers.http_grpc"
+)
+
+// AccessLog configures the access logs of the HttpConnectionManager of socket Listeners.
+// The zero value disables access logs.
+//
+// gRPC does not support access logs. xDS-enabled gRPC servers ignore the configuration,
+// and only Envoy proxies write access logs.
+type AccessLog struct {
+	// Type is either `AccessLogStdout`, `AccessLogGRPC`, or empty to disable access logs.
+	Type string
+	// GRPCServiceCluster is the name of the Envoy cluster of the gRPC Access Log Service.
+	// Only used if Type is `AccessLogGRPC`.
+	GRPCServiceCluster string
+}
+
+// accessLogJSONFormat is the format of stdout access logs, using Envoy command operators, see
+// https://www.envoyproxy.io/docs/envoy/latest/configuration/observability/access_log/usage#command-operators
+var accessLogJSONFormat = map[string]interface{}{
+	"startTime":            "%START_TIME%",
+	"method":               "%REQ(:METHOD)%",
+	"path":                 "%REQ(X-ENVOY-ORIGINAL-PATH?:PATH)%",
+	"protocol":             "%PROTOCOL%",
+	"responseCode":         "%RESPONSE_CODE%",
+	"grpcStatus":           "%GRPC_STATUS%",
+	"responseFlags":        "%RESPONSE_FLAGS%",
+	"duration":             "%DURATION%",
+	"downstreamRemoteAddr": "%DOWNSTREAM_REMOTE_ADDRESS%",
+	"downstreamPeerUriSan": "%DOWNSTREAM_PEER_URI_SAN%",
+	"upstreamHost":         "%UPSTREAM_HOST%",
+	"upstreamCluster":      "%UPSTREAM_CLUSTER%",
+}
+
+// createAccessLogs returns the access log configuration for a HttpConnectionManager, or nil
+// if access logs are disabled. The `logName` identifies the listener in gRPC ALS streams.
+func createAccessLogs(accessLog AccessLog, logName string) ([]*accesslogv3.AccessLog, error) {
+	switch accessLog.Type {
+	case AccessLogStdout:
+		jsonFormat, err := structpb.NewStruct(accessLogJSONFormat)
+		if err != nil {
+			return nil, fmt.Errorf("could not create JSON format for stdout access logs: %w", err)
+		}
+		typedConfig, err := anypb.New(&streamaccesslogv3.StdoutAccessLog{
+			AccessLogFormat: &streamaccesslogv3.StdoutAccessLog_LogFormat{
+				LogFormat: &corev3.SubstitutionFormatString{
+					Format: &corev3.SubstitutionFormatString_JsonFormat{
+						JsonFormat: jsonFormat,
+					},
+				},
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not marshall stdout access log typedConfig into Any instance: %w", err)
+		}
+		return []*accesslogv3.AccessLog{
+			{
+				Name: envoyAccessLoggerStdoutName,
+				ConfigType: &accesslogv3.AccessLog_TypedConfig{
+					TypedConfig: typedConfig,
+				},
+			},
+		}, nil
+	case AccessLogGRPC:
+		typedConfig, err := anypb.New(&grpcaccesslogv3.HttpGrpcAccessLogConfig{
+			CommonConfig: &grpcaccesslogv3.CommonGrpcAccessLogConfig{
+				LogName: logName,
+				GrpcService: &corev3.GrpcService{
+					TargetSpecifier: &corev3.GrpcService_EnvoyGrpc_{
+						EnvoyGrpc: &corev3.GrpcService_EnvoyGrpc{
+							ClusterName: accessLog.GRPCServiceCluster,
+						},
+					},
+				},
+				TransportApiVersion: corev3.ApiVersion_V3,
+			},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not marshall gRPC access log typedConfig into Any instance: %w", err)
+		}
+		return []*accesslogv3.AccessLog{
+			{
+				Name: envoyAccessLoggerHTTPGRPCName,
+				ConfigType: &accesslogv3.AccessLog_TypedConfig{
+					TypedConfig: typedConfig,
+				},
+			},
+		}, nil
+	default:
+		return nil, nil
+	}
+}
@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lds
+
+import (
+	"fmt"
+
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	dynamic_forward_proxyv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/common/dynamic_forward_proxy/v3"
+	dynamic_forward_proxyfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/dynamic_forward_proxy/v3"
+	http_connection_managerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+const (
+	envoyDynamicForwardProxyListenerNamePrefix = "envoy-dynamic-forward-proxy-listener"
+	// EnvoyDynamicForwardProxyRouteConfigurationName is used for the RouteConfiguration pointed to
+	// by the dynamic forward proxy Listener.
+	EnvoyDynamicForwardProxyRouteConfigurationName = "envoy-dynamic-forward-proxy-route-configuration"
+	envoyFilterHTTPDynamicForwardProxyName         = "envoy.filters.http.dynamic_forward_proxy"
+)
+
+// CreateEnvoyDynamicForwardProxyListener returns a listener for Envoy proxies that forwards
+// plaintext HTTP requests to the host in the `:authority` header of each request, using the
+// dynamic forward proxy HTTP filter. Use this listener for egress traffic to hosts that are not
+// known to the control plane.
+//
+// The `dnsCacheConfig` must be the same as the DNS cache configuration of the dynamic forward
+// proxy Cluster. gRPC does not support the filter, so only Envoy proxies accept the listener.
+func CreateEnvoyDynamicForwardProxyListener(port uint32, dnsCacheConfig *dynamic_forward_proxyv3.DnsCacheConfig, accessLog AccessLog) (*listenerv3.Listener, error) {
+	listenerName := fmt.Sprintf("%s-%d", envoyDynamicForwardProxyListenerNamePrefix, port)
+	httpConnectionManager, err := createHTTPConnectionManagerForSocketListener(EnvoyDynamicForwardProxyRouteConfigurationName, listenerName, false, nil, accessLog)
+	if err != nil {
+		return nil, fmt.Errorf("could not create HttpConnectionManager for Envoy dynamic forward proxy LDS Listener: %w", err)
+	}
+	dynamicForwardProxyFilterTypedConfig, err := anypb.New(&dynamic_forward_proxyfilterv3.FilterConfig{
+		ImplementationSpecifier: &dynamic_forward_proxyfilterv3.FilterConfig_DnsCacheConfig{
+			DnsCacheConfig: dnsCacheConfig,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall dynamic forward proxy HTTP filter typedConfig into Any instance: %w", err)
+	}
+	// Prepend dynamic forward proxy HTTP filter. Not append, as Router must be the last HTTP filter.
+	httpConnectionManager.HttpFilters = append([]*http_connection_managerv3.HttpFilter{
+		{
+			Name: envoyFilterHTTPDynamicForwardProxyName,
+			ConfigType: &http_connection_managerv3.HttpFilter_TypedConfig{
+				TypedConfig: dynamicForwardProxyFilterTypedConfig,
+			},
+		},
+	}, httpConnectionManager.HttpFilters...)
+	envoyDynamicForwardProxyListener, err := createSocketListener(listenerName, envoyListenerSocketAddress, port, httpConnectionManager, false, tls.CertificateProvider{}, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create LDS dynamic forward proxy Listener for Envoy proxy: %w", err)
+	}
+	return envoyDynamicForwardProxyListener, nil
+}
@@ -18,6 +18,8 @@ import (
 	"fmt"
 
 	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
 )
 
 const (
@@ -27,15 +29,28 @@ const (
 )
 
 // CreateEnvoyGRPCListener returns a GRPC listener for Envoy front proxies.
-func CreateEnvoyGRPCListener(port uint32, enableTLS bool) (*listenerv3.Listener, error) {
+// If all the `trustDomains` have trust bundles, the listener requires client certificates from
+// workloads in any of the trust domains, see `tls.CreateDownstreamTLSContext()`.
+// Connections from `permissiveSourcePrefixes` use a separate filter chain that does not validate
+// client certificates.
+func CreateEnvoyGRPCListener(port uint32, enableTLS bool, certificateProvider tls.CertificateProvider, trustDomains []tls.TrustDomain, accessLog AccessLog, permissiveSourcePrefixes []string) (*listenerv3.Listener, error) {
 	listenerName := fmt.Sprintf("%s-%d", envoyGRPCListenerNamePrefix, port)
-	httpConnectionManager, err := createHTTPConnectionManagerForSocketListener(EnvoyGRPCListenerRouteConfigurationName, listenerName, false)
+	httpConnectionManager, err := createHTTPConnectionManagerForSocketListener(EnvoyGRPCListenerRouteConfigurationName, listenerName, false, nil, accessLog)
 	if err != nil {
 		return nil, fmt.Errorf("could not create HttpConnectionManager for Envoy gRPC LDS Listener: %w", err)
 	}
-	envoyGRPCListener, err := createSocketListener(listenerName, envoyListenerSocketAddress, port, httpConnectionManager, enableTLS, false)
+	envoyGRPCListener, err := createSocketListener(listenerName, envoyListenerSocketAddress, port, httpConnectionManager, enableTLS, certificateProvider, false, trustDomains)
 	if err != nil {
 		return nil, fmt.Errorf("could not create LDS Listener for Envoy proxy: %w", err)
 	}
+	if len(permissiveSourcePrefixes) > 0 {
+		permissiveHTTPConnectionManager, err := createHTTPConnectionManagerForSocketListener(EnvoyGRPCListenerRouteConfigurationName, listenerName+permissiveStatPrefixSuffix, false, nil, accessLog)
+		if err != nil {
+			return nil, fmt.Errorf("could not create permissive HttpConnectionManager for Envoy gRPC LDS Listener: %w", err)
+		}
+		if err := addPermissiveFilterChain(envoyGRPCListener, permissiveSourcePrefixes, permissiveHTTPConnectionManager, enableTLS, certificateProvider); err != nil {
+			return nil, fmt.Errorf("could not add permissive filter chain to LDS Listener for Envoy proxy: %w", err)
+		}
+	}
 	return envoyGRPCListener, nil
 }
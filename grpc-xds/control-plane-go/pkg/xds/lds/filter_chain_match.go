@@ -0,0 +1,76 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lds
+
+import (
+	"fmt"
+	"net/netip"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	http_connection_managerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+// permissiveStatPrefixSuffix is appended to the stat prefix of the HttpConnectionManager of
+// permissive filter chains, to separate their stats from the default filter chain.
+const permissiveStatPrefixSuffix = "_permissive"
+
+// addPermissiveFilterChain adds a filter chain to the listener that matches connections from the
+// source prefixes, e.g., the IP address ranges of health checkers. The filter chain does not
+// require client certificates, and `httpConnectionManager` should not include the RBAC HTTP filter.
+//
+// Both Envoy proxies and gRPC servers select the filter chain with the most specific match, so
+// connections from other sources use the existing, default, filter chain. See
+// [gRFC A36: xDS-Enabled Servers]: https://github.com/grpc/proposal/blob/fd10c1a86562b712c2c5fa23178992654c47a072/A36-xds-for-servers.md#filterchainmatch
+func addPermissiveFilterChain(listener *listenerv3.Listener, sourcePrefixes []string, httpConnectionManager *http_connection_managerv3.HttpConnectionManager, enableTLS bool, certificateProvider tls.CertificateProvider) error {
+	sourcePrefixRanges, err := createCIDRRanges(sourcePrefixes)
+	if err != nil {
+		return err
+	}
+	filter, err := createHTTPConnectionManagerFilter(httpConnectionManager)
+	if err != nil {
+		return err
+	}
+	filterChain, err := createFilterChain(filter, enableTLS, certificateProvider, false, nil)
+	if err != nil {
+		return fmt.Errorf("could not create permissive filter chain for listener %s: %w", listener.Name, err)
+	}
+	filterChain.Name = listener.Name + permissiveStatPrefixSuffix
+	filterChain.FilterChainMatch = &listenerv3.FilterChainMatch{
+		SourcePrefixRanges: sourcePrefixRanges,
+	}
+	listener.FilterChains = append(listener.FilterChains, filterChain)
+	return nil
+}
+
+// createCIDRRanges parses prefixes in CIDR notation, e.g., `10.0.0.0/8` or `2001:db8::/32`.
+func createCIDRRanges(prefixes []string) ([]*corev3.CidrRange, error) {
+	cidrRanges := make([]*corev3.CidrRange, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		parsedPrefix, err := netip.ParsePrefix(prefix)
+		if err != nil {
+			return nil, fmt.Errorf("could not parse source prefix %s: %w", prefix, err)
+		}
+		parsedPrefix = parsedPrefix.Masked()
+		cidrRanges = append(cidrRanges, &corev3.CidrRange{
+			AddressPrefix: parsedPrefix.Addr().String(),
+			PrefixLen:     wrapperspb.UInt32(uint32(parsedPrefix.Bits())),
+		})
+	}
+	return cidrRanges, nil
+}
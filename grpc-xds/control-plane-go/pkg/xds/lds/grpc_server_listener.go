@@ -20,6 +20,10 @@ import (
 	"strconv"
 
 	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	http_connection_managerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
 )
 
 const (
@@ -32,22 +36,48 @@ const (
 )
 
 // CreateGRPCServerListener returns a downstream listener for xDS-enabled gRPC servers.
-func CreateGRPCServerListener(host string, port uint32, enableTLS bool, requireClientCerts bool, enableRBAC bool) (*listenerv3.Listener, error) {
+//
+// If `inlineRouteConfiguration` is nil, the listener refers to the RouteConfiguration
+// named `GRPCServerListenerRouteConfigurationName`, and xDS clients fetch it using RDS.
+// Otherwise, the listener includes the provided RouteConfiguration.
+//
+// If `jwtProviders` is not empty, the HttpConnectionManager includes a JWT authentication HTTP filter.
+//
+// If `permissiveSourcePrefixes` is not empty, the listener has an additional filter chain for
+// connections from these source prefixes. This filter chain does not require client certificates,
+// and does not enforce RBAC policies or verify JWTs.
+func CreateGRPCServerListener(host string, port uint32, enableTLS bool, certificateProvider tls.CertificateProvider, requireClientCerts bool, enableRBAC bool, jwtProviders []JWTProvider, accessLog AccessLog, inlineRouteConfiguration *routev3.RouteConfiguration, permissiveSourcePrefixes []string) (*listenerv3.Listener, error) {
 	statPrefix := GRPCServerListenerRouteConfigurationName
-	httpConnectionManager, err := createHTTPConnectionManagerForSocketListener(GRPCServerListenerRouteConfigurationName, statPrefix, enableRBAC)
+	httpConnectionManager, err := createHTTPConnectionManagerForSocketListener(GRPCServerListenerRouteConfigurationName, statPrefix, enableRBAC, jwtProviders, accessLog)
 	if err != nil {
 		return nil, fmt.Errorf("could not create HTTPConnectionManager for server LDS listener: %w", err)
 	}
+	if inlineRouteConfiguration != nil {
+		httpConnectionManager.RouteSpecifier = &http_connection_managerv3.HttpConnectionManager_RouteConfig{
+			RouteConfig: inlineRouteConfiguration,
+		}
+	}
 
 	// Relying on `serverListenerResourceNameTemplate` being an exact match of
 	// `server_listener_resource_name_template` from the gRPC xDS bootstrap configuration. See
 	// [gRFC A36: xDS-Enabled Servers]: https://github.com/grpc/proposal/blob/fd10c1a86562b712c2c5fa23178992654c47a072/A36-xds-for-servers.md#xds-protocol
 	listenerName := fmt.Sprintf(GRPCServerListenerResourceNameTemplate, net.JoinHostPort(host, strconv.Itoa(int(port))))
 
-	grpcServerListener, err := createSocketListener(listenerName, host, port, httpConnectionManager, enableTLS, requireClientCerts)
+	grpcServerListener, err := createSocketListener(listenerName, host, port, httpConnectionManager, enableTLS, certificateProvider, requireClientCerts, nil)
 	if err != nil {
 		return nil, fmt.Errorf("could not create LDS Listener for gRPC servers: %w", err)
 	}
 
+	if len(permissiveSourcePrefixes) > 0 {
+		permissiveHTTPConnectionManager, err := createHTTPConnectionManagerForSocketListener(GRPCServerListenerRouteConfigurationName, statPrefix+permissiveStatPrefixSuffix, false, nil, accessLog)
+		if err != nil {
+			return nil, fmt.Errorf("could not create permissive HTTPConnectionManager for server LDS listener: %w", err)
+		}
+		permissiveHTTPConnectionManager.RouteSpecifier = httpConnectionManager.RouteSpecifier
+		if err := addPermissiveFilterChain(grpcServerListener, permissiveSourcePrefixes, permissiveHTTPConnectionManager, enableTLS, certificateProvider); err != nil {
+			return nil, fmt.Errorf("could not add permissive filter chain to LDS Listener for gRPC servers: %w", err)
+		}
+	}
+
 	return grpcServerListener, nil
 }
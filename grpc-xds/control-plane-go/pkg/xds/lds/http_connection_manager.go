@@ -28,14 +28,19 @@ import (
 )
 
 const (
-	EnvoyFilterHTTPRBACName   = "envoy.filters.http.rbac"
-	envoyFilterHTTPFaultName  = "envoy.filters.http.fault"
-	envoyFilterHTTPRouterName = "envoy.filters.http.router"
+	EnvoyFilterHTTPRBACName     = "envoy.filters.http.rbac"
+	EnvoyFilterHTTPFaultName    = "envoy.filters.http.fault"
+	envoyFilterHTTPJWTAuthnName = "envoy.filters.http.jwt_authn"
+	envoyFilterHTTPRouterName   = "envoy.filters.http.router"
 )
 
 // createHTTPConnectionManagerForSocketListener returns a HttpConnectionManager to be
 // used with LDS Listeners for gRPC servers and Envoy proxy instances.
-func createHTTPConnectionManagerForSocketListener(routeConfigurationName string, statPrefix string, enableRBAC bool) (*http_connection_managerv3.HttpConnectionManager, error) {
+func createHTTPConnectionManagerForSocketListener(routeConfigurationName string, statPrefix string, enableRBAC bool, jwtProviders []JWTProvider, accessLog AccessLog) (*http_connection_managerv3.HttpConnectionManager, error) {
+	accessLogs, err := createAccessLogs(accessLog, statPrefix)
+	if err != nil {
+		return nil, err
+	}
 	routerFilterConfig, err := anypb.New(&routerv3.Router{})
 	if err != nil {
 		return nil, fmt.Errorf("could not marshall Router HTTP filter into Any instance: %w", err)
@@ -74,6 +79,7 @@ func createHTTPConnectionManagerForSocketListener(routeConfigurationName string,
 				UpgradeType: "websocket",
 			},
 		},
+		AccessLog: accessLogs,
 	}
 
 	if enableRBAC {
@@ -94,6 +100,15 @@ func createHTTPConnectionManagerForSocketListener(routeConfigurationName string,
 		}, httpConnectionManager.HttpFilters...)
 	}
 
+	if len(jwtProviders) > 0 {
+		jwtAuthnFilter, err := createJWTAuthnFilter(jwtProviders)
+		if err != nil {
+			return nil, err
+		}
+		// Prepend JWT authentication HTTP filter, to verify JWTs before authorization (RBAC) and routing.
+		httpConnectionManager.HttpFilters = append([]*http_connection_managerv3.HttpFilter{jwtAuthnFilter}, httpConnectionManager.HttpFilters...)
+	}
+
 	return &httpConnectionManager, nil
 }
 
@@ -114,7 +129,7 @@ func createHTTPConnectionManagerForAPIListener(routeConfigurationName string, st
 		HttpFilters: []*http_connection_managerv3.HttpFilter{
 			{
 				// Enable client-side fault injection.
-				Name: envoyFilterHTTPFaultName,
+				Name: EnvoyFilterHTTPFaultName,
 				ConfigType: &http_connection_managerv3.HttpFilter_TypedConfig{
 					TypedConfig: httpFaultFilterConfig,
 				},
@@ -0,0 +1,63 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lds
+
+import (
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+)
+
+const (
+	// IPFamilyIPv4 binds Envoy proxy Listeners to `0.0.0.0`. This is the default.
+	IPFamilyIPv4 = "IPv4"
+	// IPFamilyIPv6 binds Envoy proxy Listeners to `::`, with IPv4 compatibility, for IPv6-only
+	// clusters, and for dual-stack clusters where the nodes accept IPv4-mapped IPv6 addresses.
+	IPFamilyIPv6 = "IPv6"
+	// IPFamilyDualStack binds Envoy proxy Listeners to `0.0.0.0`, with `::` as an additional address.
+	IPFamilyDualStack = "DualStack"
+
+	envoyListenerIPv6SocketAddress = "::"
+)
+
+// SetIPFamily changes the addresses of an Envoy proxy Listener bound to `0.0.0.0` to the
+// provided IP family. Do not use this for Listeners for gRPC servers, since gRPC servers match
+// the Listener address to their own listening address, and do not support additional addresses.
+func SetIPFamily(listener *listenerv3.Listener, ipFamily string) {
+	socketAddress := listener.GetAddress().GetSocketAddress()
+	if socketAddress == nil || socketAddress.GetAddress() != envoyListenerSocketAddress {
+		return
+	}
+	switch ipFamily {
+	case IPFamilyIPv6:
+		socketAddress.Address = envoyListenerIPv6SocketAddress
+		socketAddress.Ipv4Compat = true
+	case IPFamilyDualStack:
+		// Without `ipv4_compat`, Envoy proxies bind the IPv6 address with `IPV6_V6ONLY`,
+		// so the IPv4 and IPv6 sockets do not conflict.
+		listener.AdditionalAddresses = []*listenerv3.AdditionalAddress{
+			{
+				Address: &corev3.Address{
+					Address: &corev3.Address_SocketAddress{
+						SocketAddress: &corev3.SocketAddress{
+							Protocol:      corev3.SocketAddress_TCP,
+							Address:       envoyListenerIPv6SocketAddress,
+							PortSpecifier: socketAddress.GetPortSpecifier(),
+						},
+					},
+				},
+			},
+		}
+	}
+}
@@ -0,0 +1,145 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lds
+
+import (
+	"fmt"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	jwt_authnv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/jwt_authn/v3"
+	http_connection_managerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+)
+
+const (
+	jwksFetchTimeout  = 5 * time.Second
+	jwksCacheDuration = 5 * time.Minute
+)
+
+// JWTProvider configures how to verify JSON Web Tokens (JWTs) from an issuer.
+// Set either JWKSURI and JWKSCluster, or LocalJWKS.
+type JWTProvider struct {
+	// Name of the provider.
+	Name string `yaml:"name"`
+	// Issuer must match the `iss` claim of the JWTs.
+	Issuer string `yaml:"issuer"`
+	// Audiences, if not empty, must contain the `aud` claim of the JWTs.
+	Audiences []string `yaml:"audiences"`
+	// JWKSURI is the URI to fetch the JSON Web Key Set (JWKS) from, e.g.,
+	// `https://www.googleapis.com/oauth2/v3/certs`.
+	JWKSURI string `yaml:"jwksUri"`
+	// JWKSCluster is the name of the Envoy cluster used to fetch the JWKS. The control plane
+	// generates this cluster from the JWKSURI, unless the snapshot already has a cluster with
+	// this name, see `cds.CreateJWKSCluster()`.
+	JWKSCluster string `yaml:"jwksCluster"`
+	// LocalJWKS is an inline JWKS, as a JSON string.
+	LocalJWKS string `yaml:"localJwks"`
+	// AllowMissing allows requests without a JWT. Requests with invalid JWTs are still rejected.
+	AllowMissing bool `yaml:"allowMissing"`
+}
+
+// createJWTAuthnFilter returns a JWT authentication HTTP filter that requires requests to
+// include a valid JWT from one of the providers.
+//
+// The filter is optional, because gRPC does not support JWT authentication. xDS-enabled gRPC
+// servers ignore the filter, and only Envoy proxies verify JWTs.
+func createJWTAuthnFilter(providers []JWTProvider) (*http_connection_managerv3.HttpFilter, error) {
+	jwtAuthentication := jwt_authnv3.JwtAuthentication{
+		Providers: make(map[string]*jwt_authnv3.JwtProvider, len(providers)),
+	}
+	var requirements []*jwt_authnv3.JwtRequirement
+	allowMissing := false
+	for _, provider := range providers {
+		jwtAuthentication.Providers[provider.Name] = createJWTProvider(provider)
+		requirements = append(requirements, &jwt_authnv3.JwtRequirement{
+			RequiresType: &jwt_authnv3.JwtRequirement_ProviderName{
+				ProviderName: provider.Name,
+			},
+		})
+		allowMissing = allowMissing || provider.AllowMissing
+	}
+	if allowMissing {
+		requirements = append(requirements, &jwt_authnv3.JwtRequirement{
+			RequiresType: &jwt_authnv3.JwtRequirement_AllowMissing{},
+		})
+	}
+	requirement := requirements[0]
+	if len(requirements) > 1 {
+		requirement = &jwt_authnv3.JwtRequirement{
+			RequiresType: &jwt_authnv3.JwtRequirement_RequiresAny{
+				RequiresAny: &jwt_authnv3.JwtRequirementOrList{
+					Requirements: requirements,
+				},
+			},
+		}
+	}
+	jwtAuthentication.Rules = []*jwt_authnv3.RequirementRule{
+		{
+			Match: &routev3.RouteMatch{
+				PathSpecifier: &routev3.RouteMatch_Prefix{
+					Prefix: "/",
+				},
+			},
+			RequirementType: &jwt_authnv3.RequirementRule_Requires{
+				Requires: requirement,
+			},
+		},
+	}
+	jwtAuthnFilterTypedConfig, err := anypb.New(&jwtAuthentication)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall JWT authentication HTTP filter typedConfig into Any instance: %w", err)
+	}
+	return &http_connection_managerv3.HttpFilter{
+		Name: envoyFilterHTTPJWTAuthnName,
+		ConfigType: &http_connection_managerv3.HttpFilter_TypedConfig{
+			TypedConfig: jwtAuthnFilterTypedConfig,
+		},
+		IsOptional: true,
+	}, nil
+}
+
+func createJWTProvider(provider JWTProvider) *jwt_authnv3.JwtProvider {
+	jwtProvider := &jwt_authnv3.JwtProvider{
+		Issuer:    provider.Issuer,
+		Audiences: provider.Audiences,
+		Forward:   true,
+	}
+	if provider.LocalJWKS != "" {
+		jwtProvider.JwksSourceSpecifier = &jwt_authnv3.JwtProvider_LocalJwks{
+			LocalJwks: &corev3.DataSource{
+				Specifier: &corev3.DataSource_InlineString{
+					InlineString: provider.LocalJWKS,
+				},
+			},
+		}
+		return jwtProvider
+	}
+	jwtProvider.JwksSourceSpecifier = &jwt_authnv3.JwtProvider_RemoteJwks{
+		RemoteJwks: &jwt_authnv3.RemoteJwks{
+			HttpUri: &corev3.HttpUri{
+				Uri: provider.JWKSURI,
+				HttpUpstreamType: &corev3.HttpUri_Cluster{
+					Cluster: provider.JWKSCluster,
+				},
+				Timeout: durationpb.New(jwksFetchTimeout),
+			},
+			CacheDuration: durationpb.New(jwksCacheDuration),
+		},
+	}
+	return jwtProvider
+}
@@ -16,6 +16,7 @@ package lds
 
 import (
 	"fmt"
+	"net"
 	"strings"
 
 	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
@@ -33,15 +34,26 @@ const (
 
 // createSocketListener returns an LDS Listener that can be used for
 // gRPC servers and Envoy proxy instances.
-func createSocketListener(listenerName string, host string, port uint32, httpConnectionManager *http_connection_managerv3.HttpConnectionManager, enableTLS bool, requireClientCerts bool) (*listenerv3.Listener, error) {
-	anyWrappedHTTPConnectionManager, err := anypb.New(httpConnectionManager)
+// `spiffeTrustDomains` must be nil for gRPC servers, see `tls.CreateDownstreamTLSContext()`.
+func createSocketListener(listenerName string, host string, port uint32, httpConnectionManager *http_connection_managerv3.HttpConnectionManager, enableTLS bool, certificateProvider tls.CertificateProvider, requireClientCerts bool, spiffeTrustDomains []tls.TrustDomain) (*listenerv3.Listener, error) {
+	filter, err := createHTTPConnectionManagerFilter(httpConnectionManager)
 	if err != nil {
-		return nil, fmt.Errorf("could not marshall HttpConnectionManager +%v into Any instance: %w", httpConnectionManager, err)
+		return nil, err
 	}
+	return createSocketListenerWithFilter(listenerName, host, port, filter, enableTLS, certificateProvider, requireClientCerts, spiffeTrustDomains)
+}
 
-	isIPv6 := strings.Count(host, ":") >= 2
+// createSocketListenerWithFilter returns an LDS Listener with one filter chain, where the provided
+// network filter is the only filter, e.g., HttpConnectionManager or TcpProxy.
+func createSocketListenerWithFilter(listenerName string, host string, port uint32, filter *listenerv3.Filter, enableTLS bool, certificateProvider tls.CertificateProvider, requireClientCerts bool, spiffeTrustDomains []tls.TrustDomain) (*listenerv3.Listener, error) {
+	filterChain, err := createFilterChain(filter, enableTLS, certificateProvider, requireClientCerts, spiffeTrustDomains)
+	if err != nil {
+		return nil, err
+	}
+
+	host, isIPv6 := canonicalHost(host)
 
-	serverListener := listenerv3.Listener{
+	return &listenerv3.Listener{
 		Name: listenerName,
 		Address: &corev3.Address{
 			Address: &corev3.Address_SocketAddress{
@@ -55,30 +67,58 @@ func createSocketListener(listenerName string, host string, port uint32, httpCon
 				},
 			},
 		},
-		FilterChains: []*listenerv3.FilterChain{
-			{
-				Filters: []*listenerv3.Filter{
-					{
-						Name: envoyHTTPConnectionManagerName, // must be the last filter
-						ConfigType: &listenerv3.Filter_TypedConfig{
-							TypedConfig: anyWrappedHTTPConnectionManager,
-						},
-					},
-				},
-			},
-		},
+		FilterChains:     []*listenerv3.FilterChain{filterChain},
 		TrafficDirection: corev3.TrafficDirection_INBOUND,
 		EnableReusePort:  wrapperspb.Bool(true),
+	}, nil
+}
+
+// canonicalHost returns IPv6 addresses in their canonical representation, e.g., `::` instead of
+// `0:0:0:0:0:0:0:0` from gRPC Java servers, and reports whether the host is an IPv6 address.
+// gRPC Go servers compare the Listener address to their listening address in the canonical
+// representation. IPv4 addresses and hostnames are returned unchanged.
+func canonicalHost(host string) (string, bool) {
+	ipAddress, zone, _ := strings.Cut(host, "%")
+	ip := net.ParseIP(ipAddress)
+	if ip == nil || !strings.Contains(ipAddress, ":") {
+		return host, false
+	}
+	if zone != "" {
+		return ip.String() + "%" + zone, true
 	}
+	return ip.String(), true
+}
 
+// createFilterChain returns a filter chain where the provided network filter is the only filter,
+// with a downstream TLS transport socket if `enableTLS` is true.
+func createFilterChain(filter *listenerv3.Filter, enableTLS bool, certificateProvider tls.CertificateProvider, requireClientCerts bool, spiffeTrustDomains []tls.TrustDomain) (*listenerv3.FilterChain, error) {
+	filterChain := listenerv3.FilterChain{
+		Filters: []*listenerv3.Filter{filter},
+	}
 	if enableTLS {
-		downstreamTLSContext := tls.CreateDownstreamTLSContext(requireClientCerts)
+		downstreamTLSContext, err := tls.CreateDownstreamTLSContext(certificateProvider, requireClientCerts, spiffeTrustDomains)
+		if err != nil {
+			return nil, err
+		}
 		transportSocket, err := tls.CreateTransportSocket(downstreamTLSContext)
 		if err != nil {
 			return nil, err
 		}
-		// Assume that HttpConnectionManager is the first (and only) filter in the Listener's filter chain:
-		serverListener.FilterChains[0].TransportSocket = transportSocket
+		filterChain.TransportSocket = transportSocket
+	}
+	return &filterChain, nil
+}
+
+// createHTTPConnectionManagerFilter wraps the HttpConnectionManager in a network filter.
+func createHTTPConnectionManagerFilter(httpConnectionManager *http_connection_managerv3.HttpConnectionManager) (*listenerv3.Filter, error) {
+	anyWrappedHTTPConnectionManager, err := anypb.New(httpConnectionManager)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall HttpConnectionManager +%v into Any instance: %w", httpConnectionManager, err)
 	}
-	return &serverListener, nil
+	return &listenerv3.Filter{
+		Name: envoyHTTPConnectionManagerName, // must be the last filter
+		ConfigType: &listenerv3.Filter_TypedConfig{
+			TypedConfig: anyWrappedHTTPConnectionManager,
+		},
+	}, nil
 }
@@ -0,0 +1,65 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package lds
+
+import (
+	"fmt"
+
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	tcp_proxyv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+const (
+	envoyTCPListenerNamePrefix = "envoy-tcp-listener"
+	envoyTCPProxyName          = "envoy.filters.network.tcp_proxy"
+)
+
+// CreateEnvoyTCPProxyListener returns a listener for Envoy front proxies that forwards TCP
+// connections on the port to the cluster, using the `tcp_proxy` network filter instead of
+// HttpConnectionManager. Use this listener for applications that do not serve HTTP or gRPC.
+//
+// The listener does not terminate TLS, since the protocol of the connections is unknown.
+// gRPC does not support `tcp_proxy`, so only Envoy proxies accept the listener.
+func CreateEnvoyTCPProxyListener(port uint32, clusterName string) (*listenerv3.Listener, error) {
+	listenerName := EnvoyTCPProxyListenerName(port)
+	tcpProxyTypedConfig, err := anypb.New(&tcp_proxyv3.TcpProxy{
+		StatPrefix: listenerName,
+		ClusterSpecifier: &tcp_proxyv3.TcpProxy_Cluster{
+			Cluster: clusterName,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall TcpProxy network filter typedConfig into Any instance: %w", err)
+	}
+	filter := &listenerv3.Filter{
+		Name: envoyTCPProxyName,
+		ConfigType: &listenerv3.Filter_TypedConfig{
+			TypedConfig: tcpProxyTypedConfig,
+		},
+	}
+	envoyTCPListener, err := createSocketListenerWithFilter(listenerName, envoyListenerSocketAddress, port, filter, false, tls.CertificateProvider{}, false, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create LDS TCP proxy Listener for Envoy proxy: %w", err)
+	}
+	return envoyTCPListener, nil
+}
+
+// EnvoyTCPProxyListenerName returns the name of the Envoy TCP proxy listener for the port.
+func EnvoyTCPProxyListenerName(port uint32) string {
+	return fmt.Sprintf("%s-%d", envoyTCPListenerNamePrefix, port)
+}
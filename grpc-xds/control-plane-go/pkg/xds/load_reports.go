@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+// LoadReports returns the store for load reports from gRPC clients, e.g., for the
+// Load Reporting Service (LRS).
+func (c *SnapshotCache) LoadReports() *eds.LoadReports {
+	return c.loadReports
+}
+
+// EDSServiceNames returns the names that xDS clients can use for the ClusterLoadAssignment of the
+// application in load reports: the plain name, and the `xdstp://` names for the authority of the
+// control plane and the additional authorities, which clients use with xDS federation.
+func (c *SnapshotCache) EDSServiceNames(name string) []string {
+	names := make([]string, 0, len(c.additionalAuthorities)+2)
+	names = append(names, name)
+	for _, authority := range append([]string{c.authority}, c.additionalAuthorities...) {
+		names = append(names, xdstpResourceNamer{authority: authority}.ClusterLoadAssignment(name))
+	}
+	return names
+}
+
+// RefreshLoadReportWeights creates new snapshots for all node hashes on every interval, if the
+// locality weight policy is `loadReport`, so that locality weights follow the load reports.
+// Runs until the context is done. An interval of 0 disables refreshing.
+func (c *SnapshotCache) RefreshLoadReportWeights(ctx context.Context, logger logr.Logger, interval time.Duration) {
+	if interval <= 0 {
+		return
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.featuresMu.RLock()
+			usesLoadReports := c.features.UsesLoadReports()
+			c.featuresMu.RUnlock()
+			if !usesLoadReports {
+				continue
+			}
+			logger.V(2).Info("Refreshing locality weights from load reports, generating new xDS resource snapshots")
+			if err := c.createNewSnapshots(c.apps()); err != nil {
+				logger.Error(err, "Could not create new xDS resource snapshots with locality weights from load reports")
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+// DefaultMaintenanceStatusCode is the gRPC status code for applications in maintenance mode,
+// if no status code is specified.
+const DefaultMaintenanceStatusCode = "UNAVAILABLE"
+
+var errMaintenanceStatusCodeOK = errors.New("maintenance mode gRPC status code cannot be OK")
+
+// MaintenanceStatusCode returns the gRPC status code with the provided name, for an application
+// in maintenance mode. An empty name means `DefaultMaintenanceStatusCode`.
+func MaintenanceStatusCode(name string) (codes.Code, error) {
+	code, err := rds.ParseStatusCode(cmp.Or(name, DefaultMaintenanceStatusCode))
+	if err != nil {
+		return codes.OK, err
+	}
+	if code == codes.OK {
+		return codes.OK, errMaintenanceStatusCodeOK
+	}
+	return code, nil
+}
+
+// MaintenanceApplications returns the gRPC status code names of applications in maintenance mode,
+// from both the `maintenanceApplications` feature flag and `SetMaintenanceMode()`.
+func (c *SnapshotCache) MaintenanceApplications() map[string]string {
+	c.featuresMu.RLock()
+	defer c.featuresMu.RUnlock()
+	maintenanceApplications := maps.Clone(c.features.MaintenanceApplications)
+	if maintenanceApplications == nil {
+		maintenanceApplications = map[string]string{}
+	}
+	maps.Copy(maintenanceApplications, c.maintenanceApplications)
+	return maintenanceApplications
+}
+
+// SetMaintenanceMode puts the application in maintenance mode, so that requests to the application
+// fail with the gRPC status code with the provided name, and generates new snapshots for all node
+// hashes. This overrides the status code from the `maintenanceApplications` feature flag.
+func (c *SnapshotCache) SetMaintenanceMode(_ context.Context, logger logr.Logger, appName string, statusCodeName string) error {
+	if _, err := MaintenanceStatusCode(statusCodeName); err != nil {
+		return fmt.Errorf("could not set maintenance mode for application %s: %w", appName, err)
+	}
+	c.featuresMu.Lock()
+	c.maintenanceApplications[appName] = statusCodeName
+	c.featuresMu.Unlock()
+	apps := c.apps()
+	logger.V(2).Info("Maintenance mode set, generating new xDS resource snapshots", "app", appName, "statusCode", statusCodeName)
+	return c.createNewSnapshots(apps)
+}
+
+// ClearMaintenanceMode removes the application from maintenance mode, unless the application is
+// listed in the `maintenanceApplications` feature flag, and generates new snapshots for all node hashes.
+func (c *SnapshotCache) ClearMaintenanceMode(_ context.Context, logger logr.Logger, appName string) error {
+	c.featuresMu.Lock()
+	delete(c.maintenanceApplications, appName)
+	c.featuresMu.Unlock()
+	apps := c.apps()
+	logger.V(2).Info("Maintenance mode cleared, generating new xDS resource snapshots", "app", appName)
+	return c.createNewSnapshots(apps)
+}
@@ -0,0 +1,124 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/go-logr/logr"
+)
+
+// maxNodeHashGCInterval is the longest time between checks for idle node hashes.
+const maxNodeHashGCInterval = time.Minute
+
+// nodeHashStreams counts the open xDS streams per node hash, so that snapshots and server
+// listener addresses of node hashes without streams can be evicted after an idle TTL.
+type nodeHashStreams struct {
+	mu sync.Mutex
+	// nodeHashByStream is the node hash of each open SotW or delta stream that has sent a
+	// request with a node.
+	nodeHashByStream map[StreamKey]string
+	// streamCount is the number of open streams per node hash.
+	streamCount map[string]int
+	// idleSince is when the last stream of a node hash closed.
+	idleSince map[string]time.Time
+}
+
+func newNodeHashStreams() *nodeHashStreams {
+	return &nodeHashStreams{
+		nodeHashByStream: map[StreamKey]string{},
+		streamCount:      map[string]int{},
+		idleSince:        map[string]time.Time{},
+	}
+}
+
+// StreamRequest records the node hash of the stream on the first request that includes a node.
+// Call this method from the `OnStreamRequest` and `OnStreamDeltaRequest` callbacks of the xDS
+// server, since SotW and delta stream IDs are separate sequences.
+func (c *SnapshotCache) StreamRequest(stream StreamKey, node *corev3.Node) {
+	if node == nil {
+		return
+	}
+	s := c.nodeHashStreams
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, exists := s.nodeHashByStream[stream]; exists {
+		return
+	}
+	nodeHash := c.hash.ID(node)
+	s.nodeHashByStream[stream] = nodeHash
+	s.streamCount[nodeHash]++
+	delete(s.idleSince, nodeHash)
+}
+
+// StreamClosed marks the node hash of the stream as idle if it was the last open stream for
+// the node hash. Call this method from the `OnStreamClosed` and `OnDeltaStreamClosed` callbacks
+// of the xDS server.
+func (c *SnapshotCache) StreamClosed(stream StreamKey) {
+	s := c.nodeHashStreams
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	nodeHash, exists := s.nodeHashByStream[stream]
+	if !exists {
+		return
+	}
+	delete(s.nodeHashByStream, stream)
+	s.streamCount[nodeHash]--
+	if s.streamCount[nodeHash] <= 0 {
+		delete(s.streamCount, nodeHash)
+		s.idleSince[nodeHash] = time.Now()
+	}
+}
+
+// EvictIdleNodeHashes removes the snapshots and server listener addresses of node hashes that
+// have had no open xDS streams for longer than `idleTTL`, until the context is done.
+// An `idleTTL` of 0 disables eviction.
+func (c *SnapshotCache) EvictIdleNodeHashes(ctx context.Context, logger logr.Logger, idleTTL time.Duration) {
+	if idleTTL <= 0 {
+		logger.V(2).Info("Eviction of idle node hashes is disabled")
+		return
+	}
+	interval := min(idleTTL, maxNodeHashGCInterval)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.evictIdleNodeHashes(logger, time.Now().Add(-idleTTL))
+		}
+	}
+}
+
+func (c *SnapshotCache) evictIdleNodeHashes(logger logr.Logger, idleBefore time.Time) {
+	s := c.nodeHashStreams
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for nodeHash, idleSince := range s.idleSince {
+		if idleSince.After(idleBefore) {
+			continue
+		}
+		logger.V(2).Info("Evicting idle node hash", "nodeHash", nodeHash, "idleSince", idleSince)
+		c.delegate.ClearSnapshot(nodeHash)
+		c.grpcServerListenerCache.Remove(nodeHash)
+		c.unknownResourceNames.remove(nodeHash)
+		c.requestedResourceNaming.remove(nodeHash)
+		delete(s.idleSince, nodeHash)
+	}
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+// newNodeHashGCTestCache returns a cache with an empty snapshot for the node hash `goldenZone`.
+func newNodeHashGCTestCache(t *testing.T) *SnapshotCache {
+	t.Helper()
+	ctx := logging.NewContext(context.Background(), logr.Discard())
+	c := NewSnapshotCache(ctx, ZoneHash{}, eds.LocalityPriorityByZone{}, &Features{}, goldenAuthority)
+	snapshot, err := cachev3.NewSnapshot("1", nil)
+	if err != nil {
+		t.Fatalf("could not create snapshot: %v", err)
+	}
+	if err := c.delegate.SetSnapshot(ctx, goldenZone, snapshot); err != nil {
+		t.Fatalf("could not set snapshot: %v", err)
+	}
+	return c
+}
+
+func hasSnapshot(c *SnapshotCache, nodeHash string) bool {
+	_, err := c.delegate.GetSnapshot(nodeHash)
+	return err == nil
+}
+
+func TestEvictIdleNodeHashes(t *testing.T) {
+	node := &corev3.Node{Id: "node-a", Locality: &corev3.Locality{Zone: goldenZone}}
+	tests := []struct {
+		name    string
+		streams []StreamKey
+		// closed are the streams that close before eviction.
+		closed    []StreamKey
+		idleFor   time.Duration
+		wantEvict bool
+	}{
+		{
+			name:      "evicts after the idle TTL",
+			streams:   []StreamKey{{ID: 1}},
+			closed:    []StreamKey{{ID: 1}},
+			idleFor:   time.Hour,
+			wantEvict: true,
+		},
+		{
+			name:      "keeps node hashes idle for less than the TTL",
+			streams:   []StreamKey{{ID: 1}},
+			closed:    []StreamKey{{ID: 1}},
+			idleFor:   -time.Hour,
+			wantEvict: false,
+		},
+		{
+			name:      "evicts node hashes with only delta streams",
+			streams:   []StreamKey{{ID: 1, Delta: true}},
+			closed:    []StreamKey{{ID: 1, Delta: true}},
+			idleFor:   time.Hour,
+			wantEvict: true,
+		},
+		{
+			name:      "keeps node hashes with open delta streams after SotW streams close",
+			streams:   []StreamKey{{ID: 1}, {ID: 1, Delta: true}},
+			closed:    []StreamKey{{ID: 1}},
+			idleFor:   time.Hour,
+			wantEvict: false,
+		},
+		{
+			name:      "evicts node hashes after SotW and delta streams close",
+			streams:   []StreamKey{{ID: 1}, {ID: 1, Delta: true}},
+			closed:    []StreamKey{{ID: 1, Delta: true}, {ID: 1}},
+			idleFor:   time.Hour,
+			wantEvict: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			c := newNodeHashGCTestCache(t)
+			for _, stream := range test.streams {
+				c.StreamRequest(stream, node)
+				// Later requests on the same stream don't count as new streams.
+				c.StreamRequest(stream, node)
+			}
+			for _, stream := range test.closed {
+				c.StreamClosed(stream)
+			}
+			c.evictIdleNodeHashes(logr.Discard(), time.Now().Add(test.idleFor))
+			if got := !hasSnapshot(c, goldenZone); got != test.wantEvict {
+				t.Errorf("evicted = %t, want %t", got, test.wantEvict)
+			}
+		})
+	}
+}
@@ -0,0 +1,242 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rds
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	rbacv3 "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	rbacfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+// rbacAuditStatPrefix is the prefix of the Envoy stats for shadow rules in audit-only mode.
+const rbacAuditStatPrefix = "rbac_audit_"
+
+// RBACPolicy allows workloads with an X.509 SVID for the specified Kubernetes Namespaces and
+// ServiceAccounts to call the specified gRPC methods, optionally only if the requests include
+// the specified headers.
+type RBACPolicy struct {
+	// Name of the policy, e.g., the name of the application.
+	Name string `yaml:"name"`
+	// Namespaces of the allowed clients. Empty means all Namespaces.
+	Namespaces []string `yaml:"namespaces"`
+	// ServiceAccounts of the allowed clients. Empty means all ServiceAccounts.
+	ServiceAccounts []string `yaml:"serviceAccounts"`
+	// Methods are gRPC method paths. Paths ending with `/` match all methods of a gRPC service,
+	// e.g., `/helloworld.Greeter/`, other paths match a single method, e.g.,
+	// `/helloworld.Greeter/SayHello`. Empty means all methods.
+	Methods []string `yaml:"methods"`
+	// Headers that requests must include, in addition to matching one of the methods.
+	Headers []RBACHeaderMatch `yaml:"headers"`
+	// Applications that the policy applies to, by application name. Empty means all applications.
+	Applications []string `yaml:"applications"`
+}
+
+// RBACHeaderMatch matches a request header value, either exactly, or as a regular expression.
+type RBACHeaderMatch struct {
+	Name              string `yaml:"name"`
+	Value             string `yaml:"value"`
+	RegularExpression bool   `yaml:"regularExpression"`
+}
+
+// DefaultRBACPolicies returns the policy used when no RBAC policies are configured. The policy
+// permits workloads in the `xds` and `host-certs` Namespaces to call the greeter and echo services.
+func DefaultRBACPolicies() []RBACPolicy {
+	return []RBACPolicy{
+		{
+			Name:       "greeter-clients",
+			Namespaces: []string{"xds", "host-certs"},
+			Methods:    []string{"/helloworld.Greeter/", "/helloworld.StreamingGreeter/", "/helloworld.TracingGreeter/", "/echo.Echo/"},
+		},
+	}
+}
+
+// RBACPoliciesForApplication returns the policies that apply to requests for the application,
+// i.e., the policies without applications, and the policies scoped to the application.
+// An empty application name only returns the policies without applications.
+func RBACPoliciesForApplication(policies []RBACPolicy, appName string) []RBACPolicy {
+	var applicable []RBACPolicy
+	for _, policy := range policies {
+		if len(policy.Applications) == 0 || (appName != "" && slices.Contains(policy.Applications, appName)) {
+			applicable = append(applicable, policy)
+		}
+	}
+	return applicable
+}
+
+// createRBACPerRouteConfig returns an RBACPerRoute config with one ALLOW policy per RBACPolicy.
+// Requests that don't match any of the policies are denied.
+//
+// In audit-only mode, the policies are shadow rules. Envoy proxies evaluate shadow rules and
+// record the decisions in stats and dynamic metadata for access logs, without enforcing them.
+// gRPC ignores shadow rules, see
+// [gRFC A41]: https://github.com/grpc/proposal/blob/c83f0cb8ed534c4192e0e5d7a4550a1f5a76ef65/A41-xds-rbac.md
+func createRBACPerRouteConfig(policies []RBACPolicy, auditOnly bool, trustDomains []tls.TrustDomain) (*anypb.Any, error) {
+	rbacPolicies := make(map[string]*rbacv3.Policy, len(policies))
+	for _, policy := range policies {
+		rbacPolicies[policy.Name] = &rbacv3.Policy{
+			Permissions: createPermissions(policy),
+			Principals: []*rbacv3.Principal{
+				{
+					Identifier: &rbacv3.Principal_Authenticated_{
+						Authenticated: &rbacv3.Principal_Authenticated{
+							PrincipalName: &matcherv3.StringMatcher{
+								MatchPattern: &matcherv3.StringMatcher_SafeRegex{
+									SafeRegex: &matcherv3.RegexMatcher{
+										// Matches against URI SANs, then DNS SANs, then Subject DN.
+										Regex: tls.SPIFFEIDRegex(trustDomains, "("+alternatives(policy.Namespaces)+")", "("+alternatives(policy.ServiceAccounts)+")"),
+									},
+								},
+							},
+						},
+					},
+				},
+			},
+		}
+	}
+	rules := &rbacv3.RBAC{
+		Action:   rbacv3.RBAC_ALLOW,
+		Policies: rbacPolicies,
+	}
+	if auditOnly {
+		return anypb.New(&rbacfilterv3.RBACPerRoute{
+			Rbac: &rbacfilterv3.RBAC{
+				ShadowRules:           rules,
+				ShadowRulesStatPrefix: rbacAuditStatPrefix,
+			},
+		})
+	}
+	return anypb.New(&rbacfilterv3.RBACPerRoute{
+		Rbac: &rbacfilterv3.RBAC{
+			Rules: rules,
+		},
+	})
+}
+
+// createPermissions returns permissions that match any of the methods of the policy,
+// and all of the headers.
+func createPermissions(policy RBACPolicy) []*rbacv3.Permission {
+	var methodPermissions []*rbacv3.Permission
+	for _, method := range policy.Methods {
+		methodPermissions = append(methodPermissions, createMethodPermission(method))
+	}
+	if len(methodPermissions) == 0 {
+		methodPermissions = []*rbacv3.Permission{
+			{
+				Rule: &rbacv3.Permission_Any{
+					Any: true,
+				},
+			},
+		}
+	}
+	if len(policy.Headers) == 0 {
+		return methodPermissions
+	}
+	rules := []*rbacv3.Permission{
+		{
+			Rule: &rbacv3.Permission_OrRules{
+				OrRules: &rbacv3.Permission_Set{
+					Rules: methodPermissions,
+				},
+			},
+		},
+	}
+	for _, header := range policy.Headers {
+		rules = append(rules, &rbacv3.Permission{
+			Rule: &rbacv3.Permission_Header{
+				Header: createHeaderMatcher(header.Name, header.Value, header.RegularExpression),
+			},
+		})
+	}
+	return []*rbacv3.Permission{
+		{
+			Rule: &rbacv3.Permission_AndRules{
+				AndRules: &rbacv3.Permission_Set{
+					Rules: rules,
+				},
+			},
+		},
+	}
+}
+
+func createMethodPermission(method string) *rbacv3.Permission {
+	pathMatcher := &matcherv3.StringMatcher{
+		MatchPattern: &matcherv3.StringMatcher_Exact{
+			Exact: method,
+		},
+	}
+	if strings.HasSuffix(method, "/") {
+		pathMatcher = &matcherv3.StringMatcher{
+			MatchPattern: &matcherv3.StringMatcher_Prefix{
+				Prefix: method,
+			},
+			IgnoreCase: true,
+		}
+	}
+	return &rbacv3.Permission{
+		// Permissions can match URL path, headers/metadata, and more.
+		Rule: &rbacv3.Permission_UrlPath{
+			UrlPath: &matcherv3.PathMatcher{
+				Rule: &matcherv3.PathMatcher_Path{
+					Path: pathMatcher,
+				},
+			},
+		},
+	}
+}
+
+// createHeaderMatcher returns a header matcher for either an exact value or a regular expression.
+// Used for both route matches and RBAC permissions.
+func createHeaderMatcher(name string, value string, regularExpression bool) *routev3.HeaderMatcher {
+	stringMatcher := &matcherv3.StringMatcher{
+		MatchPattern: &matcherv3.StringMatcher_Exact{
+			Exact: value,
+		},
+	}
+	if regularExpression {
+		stringMatcher = &matcherv3.StringMatcher{
+			MatchPattern: &matcherv3.StringMatcher_SafeRegex{
+				SafeRegex: &matcherv3.RegexMatcher{
+					Regex: value,
+				},
+			},
+		}
+	}
+	return &routev3.HeaderMatcher{
+		Name: name,
+		HeaderMatchSpecifier: &routev3.HeaderMatcher_StringMatch{
+			StringMatch: stringMatcher,
+		},
+	}
+}
+
+// alternatives returns a regular expression that matches any of the names, or any name if there are none.
+func alternatives(names []string) string {
+	if len(names) == 0 {
+		return ".+"
+	}
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, regexp.QuoteMeta(name))
+	}
+	return strings.Join(quoted, "|")
+}
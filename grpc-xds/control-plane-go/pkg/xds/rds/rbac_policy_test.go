@@ -0,0 +1,154 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rds
+
+import (
+	"slices"
+	"testing"
+
+	rbacfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+)
+
+func TestRBACPoliciesForApplication(t *testing.T) {
+	policies := []RBACPolicy{
+		{Name: "all-apps"},
+		{Name: "leaf-only", Applications: []string{"greeter-leaf"}},
+		{Name: "leaf-and-intermediary", Applications: []string{"greeter-intermediary", "greeter-leaf"}},
+	}
+	tests := []struct {
+		name    string
+		appName string
+		want    []string
+	}{
+		{
+			name:    "no application only returns policies without applications",
+			appName: "",
+			want:    []string{"all-apps"},
+		},
+		{
+			name:    "application in one policy",
+			appName: "greeter-intermediary",
+			want:    []string{"all-apps", "leaf-and-intermediary"},
+		},
+		{
+			name:    "application in several policies",
+			appName: "greeter-leaf",
+			want:    []string{"all-apps", "leaf-only", "leaf-and-intermediary"},
+		},
+		{
+			name:    "application without scoped policies",
+			appName: "echo",
+			want:    []string{"all-apps"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			for _, policy := range RBACPoliciesForApplication(policies, test.appName) {
+				got = append(got, policy.Name)
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("policies = %v, want %v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestCreateRouteConfigurationForGRPCServerListenerScopesRBACPolicies(t *testing.T) {
+	tests := []struct {
+		name     string
+		policies []RBACPolicy
+		// wantPolicies are the policy names by VirtualHost name.
+		wantPolicies map[string][]string
+	}{
+		{
+			name:     "default policies",
+			policies: nil,
+			wantPolicies: map[string][]string{
+				"default_inbound_config": {"greeter-clients"},
+			},
+		},
+		{
+			name:     "policies without applications",
+			policies: []RBACPolicy{{Name: "a"}, {Name: "b"}},
+			wantPolicies: map[string][]string{
+				"default_inbound_config": {"a", "b"},
+			},
+		},
+		{
+			name: "policies scoped to applications",
+			policies: []RBACPolicy{
+				{Name: "all-apps"},
+				{Name: "leaf-only", Applications: []string{"greeter-leaf"}},
+				{Name: "echo-only", Applications: []string{"echo"}},
+			},
+			wantPolicies: map[string][]string{
+				"default_inbound_config":              {"all-apps"},
+				"default_inbound_config/echo":         {"all-apps", "echo-only"},
+				"default_inbound_config/greeter-leaf": {"all-apps", "leaf-only"},
+			},
+		},
+		{
+			name: "only scoped policies deny requests for other applications",
+			policies: []RBACPolicy{
+				{Name: "leaf-only", Applications: []string{"greeter-leaf"}},
+			},
+			wantPolicies: map[string][]string{
+				"default_inbound_config":              nil,
+				"default_inbound_config/greeter-leaf": {"leaf-only"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			routeConfiguration, err := CreateRouteConfigurationForGRPCServerListener(true, false, test.policies, nil)
+			if err != nil {
+				t.Fatalf("CreateRouteConfigurationForGRPCServerListener() error: %v", err)
+			}
+			if got := len(routeConfiguration.GetVirtualHosts()); got != len(test.wantPolicies) {
+				t.Fatalf("got %d virtual hosts, want %d", got, len(test.wantPolicies))
+			}
+			for _, virtualHost := range routeConfiguration.GetVirtualHosts() {
+				wantPolicies, exists := test.wantPolicies[virtualHost.GetName()]
+				if !exists {
+					t.Errorf("unexpected virtual host name=%s", virtualHost.GetName())
+					continue
+				}
+				var rbacPerRoute rbacfilterv3.RBACPerRoute
+				if err := virtualHost.GetRoutes()[0].GetTypedPerFilterConfig()[lds.EnvoyFilterHTTPRBACName].UnmarshalTo(&rbacPerRoute); err != nil {
+					t.Fatalf("could not unmarshal RBACPerRoute for virtual host name=%s: %v", virtualHost.GetName(), err)
+				}
+				var gotPolicies []string
+				for policyName := range rbacPerRoute.GetRbac().GetRules().GetPolicies() {
+					gotPolicies = append(gotPolicies, policyName)
+				}
+				slices.Sort(gotPolicies)
+				slices.Sort(wantPolicies)
+				if !slices.Equal(gotPolicies, wantPolicies) {
+					t.Errorf("virtual host name=%s policies = %v, want %v", virtualHost.GetName(), gotPolicies, wantPolicies)
+				}
+			}
+		})
+	}
+}
+
+func TestApplicationDomains(t *testing.T) {
+	want := []string{"greeter-leaf", "greeter-leaf:*", "*/greeter-leaf"}
+	if got := applicationDomains("greeter-leaf"); !slices.Equal(got, want) {
+		t.Errorf("applicationDomains() = %v, want %v", got, want)
+	}
+}
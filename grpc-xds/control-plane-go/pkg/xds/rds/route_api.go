@@ -15,17 +15,21 @@
 package rds
 
 import (
+	"net/http"
+
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 )
 
 // CreateRouteConfigurationForAPIListener returns an RDS route configuration for a gRPC
-// client with one virtual host and one route for that virtual host.
+// client with one virtual host and one route for that virtual host. Use `AddMethodRoutes()`
+// to add routes with per-method policies.
 //
 // The virtual host Name is not used for routing.
 // The request `:authority` must match one of the virtual host Domains.
-// Te routePrefix parameter can be an empty string.
+// The routePrefix parameter can be an empty string, to match all paths. Otherwise, a default-deny
+// route follows the route for the prefix, see `createDenyRoute()`.
 func CreateRouteConfigurationForAPIListener(name string, virtualHostName string, routePrefix string, clusterName string) *routev3.RouteConfiguration {
-	return &routev3.RouteConfiguration{
+	routeConfiguration := &routev3.RouteConfiguration{
 		Name: name,
 		VirtualHosts: []*routev3.VirtualHost{
 			{
@@ -50,4 +54,28 @@ func CreateRouteConfigurationForAPIListener(name string, virtualHostName string,
 			},
 		},
 	}
+	if routePrefix != "" {
+		virtualHost := routeConfiguration.VirtualHosts[0]
+		virtualHost.Routes = append(virtualHost.Routes, createDenyRoute())
+	}
+	return routeConfiguration
+}
+
+// createDenyRoute returns a route that matches all paths, and responds with HTTP status 403.
+// gRPC clients fail requests that match routes with a direct response action, with status
+// UNAVAILABLE, instead of rejecting the RouteConfiguration.
+func createDenyRoute() *routev3.Route {
+	return &routev3.Route{
+		Name: "default-deny",
+		Match: &routev3.RouteMatch{
+			PathSpecifier: &routev3.RouteMatch_Prefix{
+				Prefix: "",
+			},
+		},
+		Action: &routev3.Route_DirectResponse{
+			DirectResponse: &routev3.DirectResponseAction{
+				Status: http.StatusForbidden,
+			},
+		},
+	}
 }
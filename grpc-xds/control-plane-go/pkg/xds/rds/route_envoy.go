@@ -15,41 +15,61 @@
 package rds
 
 import (
+	"fmt"
+	"maps"
+	"slices"
 	"strings"
 
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/types/known/structpb"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
 )
 
+// subsetHeaderPrefix is the prefix of the request headers that select an endpoint subset, e.g.,
+// requests with the header `x-subset-version: v2` go to endpoints with the label `version=v2`.
+const subsetHeaderPrefix = "x-subset-"
+
 // CreateRouteConfigurationForEnvoyGRPCListener returns an RDS route configuration for an Envoy
 // proxy Listener that listens for gRPC requests.
-func CreateRouteConfigurationForEnvoyGRPCListener(clusterNames []string) (*routev3.RouteConfiguration, error) {
+//
+// subsetsByCluster contains the subset label values of the endpoints of each cluster, by label
+// key. For each label value, the virtual host of the cluster has a route that matches requests
+// with the subset header for the label, and routes them to the endpoints with the label value.
+//
+// mirrorsByCluster contains the mirror policies of each cluster. All routes of the virtual host
+// of the cluster mirror requests to the clusters of the mirror policies.
+func CreateRouteConfigurationForEnvoyGRPCListener(clusterNames []string, subsetsByCluster map[string]map[string][]string, mirrorsByCluster map[string][]MirrorPolicy) (*routev3.RouteConfiguration, error) {
 	var virtualHosts []*routev3.VirtualHost
 	for _, clusterName := range clusterNames {
 		if strings.HasPrefix(clusterName, "xdstp://") {
 			continue // skip clusters added for xDS federation
 		}
-		virtualHosts = append(virtualHosts, &routev3.VirtualHost{
+		virtualHost := &routev3.VirtualHost{
 			Name:    clusterName,
 			Domains: []string{clusterName, clusterName + ".example.com", clusterName + ".xds.example.com"},
-			Routes: []*routev3.Route{
-				{
-					Match: &routev3.RouteMatch{
-						PathSpecifier: &routev3.RouteMatch_Prefix{
-							Prefix: "",
-						},
+			Routes: append(createSubsetRoutes(clusterName, subsetsByCluster[clusterName]), &routev3.Route{
+				Match: &routev3.RouteMatch{
+					PathSpecifier: &routev3.RouteMatch_Prefix{
+						Prefix: "",
 					},
-					Action: &routev3.Route_Route{
-						Route: &routev3.RouteAction{
-							ClusterSpecifier: &routev3.RouteAction_Cluster{
-								Cluster: clusterName,
-							},
+				},
+				Action: &routev3.Route_Route{
+					Route: &routev3.RouteAction{
+						ClusterSpecifier: &routev3.RouteAction_Cluster{
+							Cluster: clusterName,
 						},
 					},
 				},
-			},
-		})
+			}),
+		}
+		if requestMirrorPolicies := createRequestMirrorPolicies(mirrorsByCluster[clusterName]); len(requestMirrorPolicies) > 0 {
+			for _, route := range virtualHost.Routes {
+				route.GetRoute().RequestMirrorPolicies = requestMirrorPolicies
+			}
+		}
+		virtualHosts = append(virtualHosts, virtualHost)
 	}
 	routeConfiguration := routev3.RouteConfiguration{
 		Name:         lds.EnvoyGRPCListenerRouteConfigurationName,
@@ -57,3 +77,88 @@ func CreateRouteConfigurationForEnvoyGRPCListener(clusterNames []string) (*route
 	}
 	return &routeConfiguration, nil
 }
+
+// createSubsetRoutes returns one route per subset label value, sorted by label key and value.
+// The routes use `metadata_match` to select the endpoints with matching `envoy.lb` metadata.
+// Requests for subsets without healthy endpoints fall back to any endpoint of the cluster.
+func createSubsetRoutes(clusterName string, subsets map[string][]string) []*routev3.Route {
+	var routes []*routev3.Route
+	for _, key := range slices.Sorted(maps.Keys(subsets)) {
+		for _, value := range subsets[key] {
+			routes = append(routes, &routev3.Route{
+				Name: fmt.Sprintf("%s-%s-%s", clusterName, subsetHeaderName(key), value),
+				Match: &routev3.RouteMatch{
+					PathSpecifier: &routev3.RouteMatch_Prefix{
+						Prefix: "",
+					},
+					Headers: []*routev3.HeaderMatcher{
+						createHeaderMatcher(subsetHeaderName(key), value, false),
+					},
+				},
+				Action: &routev3.Route_Route{
+					Route: &routev3.RouteAction{
+						ClusterSpecifier: &routev3.RouteAction_Cluster{
+							Cluster: clusterName,
+						},
+						MetadataMatch: &corev3.Metadata{
+							FilterMetadata: map[string]*structpb.Struct{
+								"envoy.lb": {
+									Fields: map[string]*structpb.Value{
+										key: structpb.NewStringValue(value),
+									},
+								},
+							},
+						},
+					},
+				},
+			})
+		}
+	}
+	return routes
+}
+
+// subsetHeaderName returns the name of the request header that selects a subset by the label key.
+// Characters that are not valid in header names, e.g., `/` in `app.kubernetes.io/version`, are
+// replaced by `-`.
+func subsetHeaderName(labelKey string) string {
+	return subsetHeaderPrefix + strings.Map(func(r rune) rune {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			return r
+		}
+		if r >= 'A' && r <= 'Z' {
+			return r + ('a' - 'A')
+		}
+		return '-'
+	}, labelKey)
+}
+
+// CreateRouteConfigurationForEnvoyDynamicForwardProxyListener returns an RDS route configuration
+// for the Envoy proxy Listener with the dynamic forward proxy HTTP filter. All requests, for any
+// domain, are routed to the dynamic forward proxy Cluster.
+func CreateRouteConfigurationForEnvoyDynamicForwardProxyListener(clusterName string) *routev3.RouteConfiguration {
+	return &routev3.RouteConfiguration{
+		Name: lds.EnvoyDynamicForwardProxyRouteConfigurationName,
+		VirtualHosts: []*routev3.VirtualHost{
+			{
+				Name:    clusterName,
+				Domains: []string{"*"},
+				Routes: []*routev3.Route{
+					{
+						Match: &routev3.RouteMatch{
+							PathSpecifier: &routev3.RouteMatch_Prefix{
+								Prefix: "/",
+							},
+						},
+						Action: &routev3.Route_Route{
+							Route: &routev3.RouteAction{
+								ClusterSpecifier: &routev3.RouteAction_Cluster{
+									Cluster: clusterName,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
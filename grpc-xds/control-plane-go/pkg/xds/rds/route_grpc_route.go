@@ -0,0 +1,131 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rds
+
+import (
+	"regexp"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+)
+
+// CreateRouteConfigurationForRouteRules returns an RDS route configuration for a gRPC
+// client with one virtual host, and one route for each match of each rule, in order.
+//
+// The clusterName function maps backend application names to cluster names.
+// The caller must remove backends without clusters, and rules without backends.
+func CreateRouteConfigurationForRouteRules(name string, virtualHostName string, rules []applications.RouteRule, clusterName func(appName string) string) *routev3.RouteConfiguration {
+	var routes []*routev3.Route
+	for _, rule := range rules {
+		action := createRouteAction(rule.Backends, clusterName)
+		matches := rule.Matches
+		if len(matches) == 0 {
+			// A rule without matches matches all requests.
+			matches = []applications.RouteMatch{{}}
+		}
+		for _, match := range matches {
+			routes = append(routes, &routev3.Route{
+				Match:  createRouteMatch(match),
+				Action: action,
+			})
+		}
+	}
+	return &routev3.RouteConfiguration{
+		Name: name,
+		VirtualHosts: []*routev3.VirtualHost{
+			{
+				Name:    virtualHostName,
+				Domains: []string{"*"},
+				Routes:  routes,
+			},
+		},
+	}
+}
+
+// createRouteMatch translates a gRPC service and method match to a path match,
+// since the path of gRPC requests is `/[service]/[method]`.
+func createRouteMatch(match applications.RouteMatch) *routev3.RouteMatch {
+	routeMatch := &routev3.RouteMatch{}
+	switch {
+	case match.RegularExpression:
+		service, method := match.Service, match.Method
+		if service == "" {
+			service = "[^/]+"
+		}
+		if method == "" {
+			method = "[^/]+"
+		}
+		routeMatch.PathSpecifier = &routev3.RouteMatch_SafeRegex{
+			SafeRegex: &matcherv3.RegexMatcher{
+				Regex: "/" + service + "/" + method,
+			},
+		}
+	case match.Service != "" && match.Method != "":
+		routeMatch.PathSpecifier = &routev3.RouteMatch_Path{
+			Path: "/" + match.Service + "/" + match.Method,
+		}
+	case match.Service != "":
+		routeMatch.PathSpecifier = &routev3.RouteMatch_Prefix{
+			Prefix: "/" + match.Service + "/",
+		}
+	case match.Method != "":
+		routeMatch.PathSpecifier = &routev3.RouteMatch_SafeRegex{
+			SafeRegex: &matcherv3.RegexMatcher{
+				Regex: "/[^/]+/" + regexp.QuoteMeta(match.Method),
+			},
+		}
+	default:
+		routeMatch.PathSpecifier = &routev3.RouteMatch_Prefix{
+			Prefix: "",
+		}
+	}
+	for _, header := range match.Headers {
+		routeMatch.Headers = append(routeMatch.Headers, createHeaderMatcher(header.Name, header.Value, header.RegularExpression))
+	}
+	return routeMatch
+}
+
+// createRouteAction routes to a single cluster if there is only one backend,
+// otherwise it splits traffic across weighted clusters.
+func createRouteAction(backends []applications.RouteBackend, clusterName func(appName string) string) *routev3.Route_Route {
+	if len(backends) == 1 {
+		return &routev3.Route_Route{
+			Route: &routev3.RouteAction{
+				ClusterSpecifier: &routev3.RouteAction_Cluster{
+					Cluster: clusterName(backends[0].Name),
+				},
+			},
+		}
+	}
+	var weightedClusters []*routev3.WeightedCluster_ClusterWeight
+	for _, backend := range backends {
+		weightedClusters = append(weightedClusters, &routev3.WeightedCluster_ClusterWeight{
+			Name:   clusterName(backend.Name),
+			Weight: wrapperspb.UInt32(backend.Weight),
+		})
+	}
+	return &routev3.Route_Route{
+		Route: &routev3.RouteAction{
+			ClusterSpecifier: &routev3.RouteAction_WeightedClusters{
+				WeightedClusters: &routev3.WeightedCluster{
+					Clusters: weightedClusters,
+				},
+			},
+		},
+	}
+}
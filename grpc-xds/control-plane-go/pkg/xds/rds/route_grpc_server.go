@@ -16,116 +16,100 @@ package rds
 
 import (
 	"fmt"
-	"strings"
+	"slices"
 
-	rbacv3 "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
 	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
-	rbacfilterv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/rbac/v3"
-	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 	"google.golang.org/protobuf/types/known/anypb"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
 )
 
 // CreateRouteConfigurationForGRPCServerListener returns an RDS route configuration for a gRPC server Listener.
-func CreateRouteConfigurationForGRPCServerListener(enableRBAC bool) (*routev3.RouteConfiguration, error) {
+// If RBAC is enabled, the route uses the provided RBAC policies, or `DefaultRBACPolicies()` if there are none.
+// If `rbacAuditOnly` is true, the RBAC policies are evaluated and logged, but not enforced.
+// If `trustDomains` is not empty, the RBAC policies only allow clients with SPIFFE IDs in those trust domains.
+//
+// Policies that are scoped to applications get a VirtualHost per application, matching the
+// `:authority` of requests to that application, see `applicationDomains()`. The VirtualHost of
+// an application uses the policies scoped to the application and the policies without scope.
+// The default VirtualHost only uses the policies without scope.
+func CreateRouteConfigurationForGRPCServerListener(enableRBAC bool, rbacAuditOnly bool, rbacPolicies []RBACPolicy, trustDomains []tls.TrustDomain) (*routev3.RouteConfiguration, error) {
 	name := lds.GRPCServerListenerRouteConfigurationName
 	routeConfiguration := routev3.RouteConfiguration{
 		Name: name,
-		VirtualHosts: []*routev3.VirtualHost{
+		// The VirtualHost name _doesn't_ have to match the RouteConfiguration name.
+		VirtualHosts: []*routev3.VirtualHost{createServerVirtualHost(name, []string{"*"})},
+	}
+	if !enableRBAC {
+		return &routeConfiguration, nil
+	}
+	if len(rbacPolicies) == 0 {
+		rbacPolicies = DefaultRBACPolicies()
+	}
+	if err := setRBACPerRouteConfig(routeConfiguration.VirtualHosts[0], RBACPoliciesForApplication(rbacPolicies, ""), rbacAuditOnly, trustDomains); err != nil {
+		return nil, err
+	}
+	for _, appName := range rbacPolicyApplications(rbacPolicies) {
+		virtualHost := createServerVirtualHost(name+"/"+appName, applicationDomains(appName))
+		if err := setRBACPerRouteConfig(virtualHost, RBACPoliciesForApplication(rbacPolicies, appName), rbacAuditOnly, trustDomains); err != nil {
+			return nil, err
+		}
+		routeConfiguration.VirtualHosts = append(routeConfiguration.VirtualHosts, virtualHost)
+	}
+	return &routeConfiguration, nil
+}
+
+// createServerVirtualHost returns a VirtualHost with a single route that accepts all requests.
+func createServerVirtualHost(name string, domains []string) *routev3.VirtualHost {
+	return &routev3.VirtualHost{
+		Name:    name,
+		Domains: domains,
+		Routes: []*routev3.Route{
 			{
-				// The VirtualHost name _doesn't_ have to match the RouteConfiguration name.
-				Name:    name,
-				Domains: []string{"*"},
-				Routes: []*routev3.Route{
-					{
-						Match: &routev3.RouteMatch{
-							PathSpecifier: &routev3.RouteMatch_Prefix{
-								Prefix: "/",
-							},
-						},
-						Action: &routev3.Route_NonForwardingAction{
-							NonForwardingAction: &routev3.NonForwardingAction{},
-						},
-						Decorator: &routev3.Decorator{
-							Operation: name + "/*",
-						},
+				Match: &routev3.RouteMatch{
+					PathSpecifier: &routev3.RouteMatch_Prefix{
+						Prefix: "/",
 					},
 				},
+				Action: &routev3.Route_NonForwardingAction{
+					NonForwardingAction: &routev3.NonForwardingAction{},
+				},
+				Decorator: &routev3.Decorator{
+					Operation: name + "/*",
+				},
 			},
 		},
 	}
-	if enableRBAC {
-		rbacPerRouteConfig, err := createRBACPerRouteConfig("xds", "host-certs")
-		if err != nil {
-			return nil, fmt.Errorf("could not marshall RBACPerRoute typedConfig into Any instance: %w", err)
-		}
-		for _, virtualHost := range routeConfiguration.VirtualHosts {
-			for _, route := range virtualHost.Routes {
-				route.TypedPerFilterConfig = map[string]*anypb.Any{
-					lds.EnvoyFilterHTTPRBACName: rbacPerRouteConfig,
-				}
-			}
+}
+
+// setRBACPerRouteConfig adds the RBAC policies to all routes of the VirtualHost.
+func setRBACPerRouteConfig(virtualHost *routev3.VirtualHost, rbacPolicies []RBACPolicy, rbacAuditOnly bool, trustDomains []tls.TrustDomain) error {
+	rbacPerRouteConfig, err := createRBACPerRouteConfig(rbacPolicies, rbacAuditOnly, trustDomains)
+	if err != nil {
+		return fmt.Errorf("could not marshall RBACPerRoute typedConfig into Any instance: %w", err)
+	}
+	for _, route := range virtualHost.Routes {
+		route.TypedPerFilterConfig = map[string]*anypb.Any{
+			lds.EnvoyFilterHTTPRBACName: rbacPerRouteConfig,
 		}
 	}
-	return &routeConfiguration, nil
+	return nil
 }
 
-// createRBACPerRouteConfig returns an RBACPerRoute config with a single policy called
-// `greeter-clients`. The policy applies to the base URL path of the `helloworld.Greeter` gRPC
-// service, and it permits workloads with an X.509 SVID for any Kubernetes ServiceAccount in the
-// specified Kubernetes Namespaces. If no allowed Namespaces are provided, this function defaults
-// to allowing all ServiceAccounts in all Namespaces.
-func createRBACPerRouteConfig(allowNamespaces ...string) (*anypb.Any, error) {
-	if len(allowNamespaces) == 0 {
-		allowNamespaces = []string{".+"}
+// applicationDomains returns the VirtualHost domains that match the `:authority` of requests
+// from xDS clients to the application, i.e., the Listener name, with or without a port, and the
+// last path segment of `xdstp://` Listener names.
+func applicationDomains(appName string) []string {
+	return []string{appName, appName + ":*", "*/" + appName}
+}
+
+// rbacPolicyApplications returns the sorted names of the applications that policies are scoped to.
+func rbacPolicyApplications(policies []RBACPolicy) []string {
+	var appNames []string
+	for _, policy := range policies {
+		appNames = append(appNames, policy.Applications...)
 	}
-	pipedNamespaces := strings.Join(allowNamespaces, "|")
-	return anypb.New(&rbacfilterv3.RBACPerRoute{
-		Rbac: &rbacfilterv3.RBAC{
-			Rules: &rbacv3.RBAC{
-				Action: rbacv3.RBAC_ALLOW,
-				Policies: map[string]*rbacv3.Policy{
-					"greeter-clients": {
-						Permissions: []*rbacv3.Permission{
-							{
-								// Permissions can match URL path, headers/metadata, and more.
-								Rule: &rbacv3.Permission_UrlPath{
-									UrlPath: &matcherv3.PathMatcher{
-										Rule: &matcherv3.PathMatcher_Path{
-											Path: &matcherv3.StringMatcher{
-												MatchPattern: &matcherv3.StringMatcher_Prefix{
-													Prefix: "/helloworld.Greeter/",
-												},
-												IgnoreCase: true,
-											},
-										},
-									},
-								},
-								// Rule: &rbacv3.Permission_Any{
-								// 	Any: true,
-								// },
-							},
-						},
-						Principals: []*rbacv3.Principal{
-							{
-								Identifier: &rbacv3.Principal_Authenticated_{
-									Authenticated: &rbacv3.Principal_Authenticated{
-										PrincipalName: &matcherv3.StringMatcher{
-											MatchPattern: &matcherv3.StringMatcher_SafeRegex{
-												SafeRegex: &matcherv3.RegexMatcher{
-													// Matches against URI SANs, then DNS SANs, then Subject DN.
-													Regex: fmt.Sprintf("spiffe://[^/]+/ns/(%s)/sa/.+", pipedNamespaces),
-												},
-											},
-										},
-									},
-								},
-							},
-						},
-					},
-				},
-			},
-		},
-	})
+	slices.Sort(appNames)
+	return slices.Compact(appNames)
 }
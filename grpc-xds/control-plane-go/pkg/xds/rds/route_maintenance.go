@@ -0,0 +1,76 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rds
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/grpc/codes"
+)
+
+const maintenanceMessage = "application is in maintenance mode"
+
+// ParseStatusCode returns the gRPC status code with the provided name, e.g., `UNAVAILABLE`.
+func ParseStatusCode(name string) (codes.Code, error) {
+	var code codes.Code
+	if err := code.UnmarshalJSON([]byte(`"` + name + `"`)); err != nil {
+		return codes.OK, fmt.Errorf("could not parse gRPC status code %s: %w", name, err)
+	}
+	return code, nil
+}
+
+// SetMaintenanceMode replaces the routes of the virtual host with a single route that matches all
+// paths, and responds directly with the provided gRPC status code, without forwarding requests.
+//
+// Envoy proxies respond with HTTP status 200 and the gRPC status in the response headers, which
+// gRPC clients of the proxies see as a trailers-only response. gRPC clients that use the
+// RouteConfiguration fail requests that match routes with a direct response action, with status
+// UNAVAILABLE, regardless of the provided status code.
+func SetMaintenanceMode(virtualHost *routev3.VirtualHost, code codes.Code) {
+	virtualHost.Routes = []*routev3.Route{
+		{
+			Name: "maintenance",
+			Match: &routev3.RouteMatch{
+				PathSpecifier: &routev3.RouteMatch_Prefix{
+					Prefix: "",
+				},
+			},
+			Action: &routev3.Route_DirectResponse{
+				DirectResponse: &routev3.DirectResponseAction{
+					Status: http.StatusOK,
+				},
+			},
+			ResponseHeadersToAdd: []*corev3.HeaderValueOption{
+				responseHeader("content-type", "application/grpc"),
+				responseHeader("grpc-status", strconv.Itoa(int(code))),
+				responseHeader("grpc-message", maintenanceMessage),
+			},
+		},
+	}
+}
+
+func responseHeader(key string, value string) *corev3.HeaderValueOption {
+	return &corev3.HeaderValueOption{
+		Header: &corev3.HeaderValue{
+			Key:   key,
+			Value: value,
+		},
+		AppendAction: corev3.HeaderValueOption_OVERWRITE_IF_EXISTS_OR_ADD,
+	}
+}
@@ -0,0 +1,239 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rds
+
+import (
+	"cmp"
+	"fmt"
+	"strings"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	commonfaultv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/common/fault/v3"
+	faultv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/http/fault/v3"
+	typev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+)
+
+const (
+	// MaxNumRetries is the highest number of retries that gRPC clients allow in a retry policy.
+	MaxNumRetries = 4
+	// DefaultRetryBaseInterval is the base interval of the backoff between retries, if the retry
+	// policy does not set one, see gRFC A44.
+	DefaultRetryBaseInterval = 25 * time.Millisecond
+)
+
+// RoutePolicy adds routes with per-method policies to the RouteConfiguration of an application,
+// ahead of the route for the path prefix of the application.
+type RoutePolicy struct {
+	// Application is the name of the application, which is also the name of its RouteConfiguration.
+	Application string        `yaml:"application"`
+	Routes      []MethodRoute `yaml:"routes"`
+	// Mirrors copy a percentage of the requests to the application to other clusters, e.g., to a
+	// canary, without affecting the responses. Only Envoy proxies mirror requests, gRPC clients
+	// ignore mirror policies.
+	Mirrors []MirrorPolicy `yaml:"mirrors"`
+}
+
+// MirrorPolicy sends copies of a percentage of requests to a cluster, and ignores the responses.
+type MirrorPolicy struct {
+	// Cluster is the name of the application or external backend that receives the copies.
+	Cluster string `yaml:"cluster"`
+	// Percent of the requests to mirror, between 1 and 100.
+	Percent uint32 `yaml:"percent"`
+}
+
+// MethodRoute matches requests for a gRPC service and method, and applies a timeout, a retry
+// policy, and fault injection to the matching requests.
+type MethodRoute struct {
+	// Service is the fully qualified gRPC service name, e.g., `helloworld.Greeter`.
+	Service string `yaml:"service"`
+	// Method is the gRPC method name, e.g., `SayHello`. Empty means all methods of the service.
+	Method string `yaml:"method"`
+	// Timeout is the maximum duration of requests, including retries. Zero means no timeout.
+	Timeout time.Duration `yaml:"timeout"`
+	Retry   *RetryPolicy  `yaml:"retry"`
+	Fault   *FaultPolicy  `yaml:"fault"`
+}
+
+// RetryPolicy for gRPC requests, see [gRFC A44].
+//
+// [gRFC A44]: https://github.com/grpc/proposal/blob/master/A44-xds-retry.md
+type RetryPolicy struct {
+	// NumRetries is the maximum number of retries, between 1 and 4. gRPC clients reject (NACK)
+	// retry policies without retries, and allow at most 4 retries.
+	NumRetries uint32 `yaml:"numRetries"`
+	// RetryOn are gRPC status codes in lowercase with dashes, e.g., `unavailable` or
+	// `resource-exhausted`. gRPC clients support `cancelled`, `deadline-exceeded`, `internal`,
+	// `resource-exhausted`, and `unavailable`.
+	RetryOn []string `yaml:"retryOn"`
+	// BaseInterval and MaxInterval of the exponential backoff between retries. Default to 25ms and
+	// 10 times the base interval. MaxInterval cannot be less than the base interval.
+	BaseInterval time.Duration `yaml:"baseInterval"`
+	MaxInterval  time.Duration `yaml:"maxInterval"`
+}
+
+// FaultPolicy delays and aborts a percentage of requests, see [gRFC A33].
+//
+// [gRFC A33]: https://github.com/grpc/proposal/blob/master/A33-Fault-Injection.md
+type FaultPolicy struct {
+	Delay        time.Duration `yaml:"delay"`
+	DelayPercent uint32        `yaml:"delayPercent"`
+	// AbortCode is a gRPC status code name, e.g., `UNAVAILABLE`.
+	AbortCode    string `yaml:"abortCode"`
+	AbortPercent uint32 `yaml:"abortPercent"`
+}
+
+// ParseAbortCode returns the gRPC status code of the abort code name.
+func (f FaultPolicy) ParseAbortCode() (codes.Code, error) {
+	return ParseStatusCode(f.AbortCode)
+}
+
+// AddMethodRoutes inserts a route for each method route ahead of the existing routes of the
+// RouteConfiguration for an API Listener, see `CreateRouteConfigurationForAPIListener()`. The
+// method routes send requests to the provided cluster.
+func AddMethodRoutes(routeConfiguration *routev3.RouteConfiguration, methodRoutes []MethodRoute, clusterName string) error {
+	if len(methodRoutes) == 0 || len(routeConfiguration.GetVirtualHosts()) == 0 {
+		return nil
+	}
+	routes, err := createMethodRoutes(methodRoutes, clusterName)
+	if err != nil {
+		return fmt.Errorf("could not create method routes for RouteConfiguration %s: %w", routeConfiguration.GetName(), err)
+	}
+	virtualHost := routeConfiguration.VirtualHosts[0]
+	virtualHost.Routes = append(routes, virtualHost.Routes...)
+	return nil
+}
+
+// createMethodRoutes returns a route for each method route, that sends requests to the cluster.
+func createMethodRoutes(methodRoutes []MethodRoute, clusterName string) ([]*routev3.Route, error) {
+	routes := make([]*routev3.Route, 0, len(methodRoutes))
+	for _, methodRoute := range methodRoutes {
+		routeAction := &routev3.RouteAction{
+			ClusterSpecifier: &routev3.RouteAction_Cluster{
+				Cluster: clusterName,
+			},
+		}
+		if methodRoute.Timeout > 0 {
+			// Envoy proxies use the timeout, and gRPC clients use the max stream duration, see gRFC A31.
+			routeAction.Timeout = durationpb.New(methodRoute.Timeout)
+			routeAction.MaxStreamDuration = &routev3.RouteAction_MaxStreamDuration{
+				MaxStreamDuration: durationpb.New(methodRoute.Timeout),
+			}
+		}
+		if methodRoute.Retry != nil {
+			routeAction.RetryPolicy = createRetryPolicy(*methodRoute.Retry)
+		}
+		route := &routev3.Route{
+			Name: strings.TrimSuffix(methodRoute.Service+"/"+methodRoute.Method, "/"),
+			Match: createRouteMatch(applications.RouteMatch{
+				Service: methodRoute.Service,
+				Method:  methodRoute.Method,
+			}),
+			Action: &routev3.Route_Route{
+				Route: routeAction,
+			},
+		}
+		if methodRoute.Fault != nil {
+			faultConfig, err := createFaultPerRouteConfig(*methodRoute.Fault)
+			if err != nil {
+				return nil, err
+			}
+			route.TypedPerFilterConfig = map[string]*anypb.Any{
+				lds.EnvoyFilterHTTPFaultName: faultConfig,
+			}
+		}
+		routes = append(routes, route)
+	}
+	return routes, nil
+}
+
+// createRequestMirrorPolicies returns a request mirror policy for each mirror policy, or nil if
+// there are none.
+func createRequestMirrorPolicies(mirrors []MirrorPolicy) []*routev3.RouteAction_RequestMirrorPolicy {
+	var requestMirrorPolicies []*routev3.RouteAction_RequestMirrorPolicy
+	for _, mirror := range mirrors {
+		requestMirrorPolicies = append(requestMirrorPolicies, &routev3.RouteAction_RequestMirrorPolicy{
+			Cluster: mirror.Cluster,
+			RuntimeFraction: &corev3.RuntimeFractionalPercent{
+				DefaultValue: percentage(mirror.Percent),
+			},
+		})
+	}
+	return requestMirrorPolicies
+}
+
+// createRetryPolicy returns the route retry policy. The retry backoff is only set if the policy
+// sets the base or max interval, using the default base interval if only the max interval is set.
+func createRetryPolicy(retry RetryPolicy) *routev3.RetryPolicy {
+	retryPolicy := &routev3.RetryPolicy{
+		RetryOn:    strings.Join(retry.RetryOn, ","),
+		NumRetries: wrapperspb.UInt32(retry.NumRetries),
+	}
+	if retry.BaseInterval > 0 || retry.MaxInterval > 0 {
+		retryPolicy.RetryBackOff = &routev3.RetryPolicy_RetryBackOff{
+			BaseInterval: durationpb.New(cmp.Or(retry.BaseInterval, DefaultRetryBaseInterval)),
+		}
+		if retry.MaxInterval > 0 {
+			retryPolicy.RetryBackOff.MaxInterval = durationpb.New(retry.MaxInterval)
+		}
+	}
+	return retryPolicy
+}
+
+// createFaultPerRouteConfig returns an HTTPFault config that overrides the empty fault filter
+// config of API Listeners for the route.
+func createFaultPerRouteConfig(fault FaultPolicy) (*anypb.Any, error) {
+	httpFault := &faultv3.HTTPFault{}
+	if fault.Delay > 0 && fault.DelayPercent > 0 {
+		httpFault.Delay = &commonfaultv3.FaultDelay{
+			FaultDelaySecifier: &commonfaultv3.FaultDelay_FixedDelay{
+				FixedDelay: durationpb.New(fault.Delay),
+			},
+			Percentage: percentage(fault.DelayPercent),
+		}
+	}
+	if fault.AbortCode != "" && fault.AbortPercent > 0 {
+		code, err := fault.ParseAbortCode()
+		if err != nil {
+			return nil, err
+		}
+		httpFault.Abort = &faultv3.FaultAbort{
+			ErrorType: &faultv3.FaultAbort_GrpcStatus{
+				GrpcStatus: uint32(code),
+			},
+			Percentage: percentage(fault.AbortPercent),
+		}
+	}
+	faultConfig, err := anypb.New(httpFault)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall HTTPFault per-route config into Any instance: %w", err)
+	}
+	return faultConfig, nil
+}
+
+func percentage(percent uint32) *typev3.FractionalPercent {
+	return &typev3.FractionalPercent{
+		Numerator:   percent,
+		Denominator: typev3.FractionalPercent_HUNDRED,
+	}
+}
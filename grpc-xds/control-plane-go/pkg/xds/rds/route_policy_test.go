@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package rds
+
+import (
+	"testing"
+	"time"
+
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+)
+
+func TestCreateRetryPolicy(t *testing.T) {
+	tests := []struct {
+		name  string
+		retry RetryPolicy
+		want  *routev3.RetryPolicy
+	}{
+		{
+			name:  "without backoff",
+			retry: RetryPolicy{NumRetries: 2, RetryOn: []string{"unavailable", "resource-exhausted"}},
+			want: &routev3.RetryPolicy{
+				RetryOn:    "unavailable,resource-exhausted",
+				NumRetries: wrapperspb.UInt32(2),
+			},
+		},
+		{
+			name:  "with base interval",
+			retry: RetryPolicy{NumRetries: 1, RetryOn: []string{"unavailable"}, BaseInterval: 50 * time.Millisecond},
+			want: &routev3.RetryPolicy{
+				RetryOn:    "unavailable",
+				NumRetries: wrapperspb.UInt32(1),
+				RetryBackOff: &routev3.RetryPolicy_RetryBackOff{
+					BaseInterval: durationpb.New(50 * time.Millisecond),
+				},
+			},
+		},
+		{
+			name:  "with base and max interval",
+			retry: RetryPolicy{NumRetries: 4, RetryOn: []string{"unavailable"}, BaseInterval: 50 * time.Millisecond, MaxInterval: time.Second},
+			want: &routev3.RetryPolicy{
+				RetryOn:    "unavailable",
+				NumRetries: wrapperspb.UInt32(4),
+				RetryBackOff: &routev3.RetryPolicy_RetryBackOff{
+					BaseInterval: durationpb.New(50 * time.Millisecond),
+					MaxInterval:  durationpb.New(time.Second),
+				},
+			},
+		},
+		{
+			name:  "with max interval uses default base interval",
+			retry: RetryPolicy{NumRetries: 3, RetryOn: []string{"unavailable"}, MaxInterval: time.Second},
+			want: &routev3.RetryPolicy{
+				RetryOn:    "unavailable",
+				NumRetries: wrapperspb.UInt32(3),
+				RetryBackOff: &routev3.RetryPolicy_RetryBackOff{
+					BaseInterval: durationpb.New(DefaultRetryBaseInterval),
+					MaxInterval:  durationpb.New(time.Second),
+				},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := createRetryPolicy(test.retry); !proto.Equal(got, test.want) {
+				t.Errorf("createRetryPolicy(%+v) = %v, want %v", test.retry, got, test.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"sync"
+
+	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
+)
+
+var (
+	registeredResourceGeneratorsMu sync.RWMutex
+	registeredResourceGenerators   []ResourceGenerator
+)
+
+// Resources contains the xDS resources of a snapshot while it is being built, keyed by resource name.
+// Generators add, replace, or remove entries in the maps.
+type Resources struct {
+	Listeners              map[string]types.Resource
+	RouteConfigurations    map[string]types.Resource
+	Clusters               map[string]types.Resource
+	ClusterLoadAssignments map[string]types.Resource
+}
+
+// GeneratorInput is the read-only input that SnapshotBuilder provides to resource generators.
+type GeneratorInput struct {
+	// NodeHash is the node hash of the snapshot, without node metadata.
+	NodeHash string
+	// Features are the feature flags for the node hash, after applying feature overrides.
+	Features *Features
+}
+
+// ResourceGenerator adds xDS resources to a snapshot.
+//
+// SnapshotBuilder.Build runs the generation phases in order: listeners, routes, clusters, and
+// endpoints. Each phase runs the default generators first, then the generators registered using
+// RegisterResourceGenerator, and finally the generators added using
+// SnapshotBuilder.WithResourceGenerators. The generators for each phase see the resources added
+// by all generators in earlier phases.
+//
+// Note that the RouteConfiguration for the Envoy proxy gRPC Listener is created in the routes
+// phase, so it does not include virtual hosts for Clusters added in the clusters phase, apart
+// from the Clusters of applications.
+type ResourceGenerator interface {
+	GenerateListeners(input GeneratorInput, resources *Resources) error
+	GenerateRoutes(input GeneratorInput, resources *Resources) error
+	GenerateClusters(input GeneratorInput, resources *Resources) error
+	GenerateEndpoints(input GeneratorInput, resources *Resources) error
+}
+
+// BaseResourceGenerator implements all ResourceGenerator methods as no-ops.
+// Embed it in generators that only implement some of the phases.
+type BaseResourceGenerator struct{}
+
+func (BaseResourceGenerator) GenerateListeners(_ GeneratorInput, _ *Resources) error {
+	return nil
+}
+
+func (BaseResourceGenerator) GenerateRoutes(_ GeneratorInput, _ *Resources) error {
+	return nil
+}
+
+func (BaseResourceGenerator) GenerateClusters(_ GeneratorInput, _ *Resources) error {
+	return nil
+}
+
+func (BaseResourceGenerator) GenerateEndpoints(_ GeneratorInput, _ *Resources) error {
+	return nil
+}
+
+// RegisterResourceGenerator adds a generator that runs for every snapshot built after registration.
+// Extensions typically call this function from an `init()` function.
+func RegisterResourceGenerator(generator ResourceGenerator) {
+	registeredResourceGeneratorsMu.Lock()
+	defer registeredResourceGeneratorsMu.Unlock()
+	registeredResourceGenerators = append(registeredResourceGenerators, generator)
+}
+
+// resourceGenerators returns a copy of the registered generators.
+func resourceGenerators() []ResourceGenerator {
+	registeredResourceGeneratorsMu.RLock()
+	defer registeredResourceGeneratorsMu.RUnlock()
+	return append([]ResourceGenerator(nil), registeredResourceGenerators...)
+}
+
+// generatorPhase selects the ResourceGenerator method for a generation phase.
+type generatorPhase struct {
+	name     string
+	generate func(generator ResourceGenerator, input GeneratorInput, resources *Resources) error
+}
+
+// generatorPhases lists the generation phases in the order that SnapshotBuilder.Build runs them.
+var generatorPhases = []generatorPhase{
+	{name: "listeners", generate: ResourceGenerator.GenerateListeners},
+	{name: "routes", generate: ResourceGenerator.GenerateRoutes},
+	{name: "clusters", generate: ResourceGenerator.GenerateClusters},
+	{name: "endpoints", generate: ResourceGenerator.GenerateEndpoints},
+}
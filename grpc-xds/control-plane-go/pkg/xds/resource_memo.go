@@ -0,0 +1,92 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+	"sync"
+
+	"google.golang.org/protobuf/proto"
+)
+
+// resourceMemoGenerationSize is the number of resources in the current generation of the memo.
+// When the current generation is full, the memo starts a new generation, and drops the resources
+// that were not used since the previous generation started.
+const resourceMemoGenerationSize = 4096
+
+// resourceMemo reuses resources created with the same inputs across snapshots and node hashes,
+// so that unchanged Listeners and Clusters are not created and marshaled into `Any` fields again
+// for every snapshot.
+//
+// The memo returns clones, since the snapshot builder and resource generators modify resources
+// after they are created. Cloning copies the marshaled bytes of `Any` fields.
+type resourceMemo struct {
+	mu       sync.Mutex
+	current  map[string]proto.Message
+	previous map[string]proto.Message
+}
+
+func newResourceMemo() *resourceMemo {
+	return &resourceMemo{
+		current:  map[string]proto.Message{},
+		previous: map[string]proto.Message{},
+	}
+}
+
+// memoize returns a clone of the resource memoized for the kind and inputs, or creates the
+// resource and memoizes it. The inputs must only contain values, since they are formatted
+// with `%#v` to create the key. A nil memo always creates the resource.
+func memoize[T proto.Message](m *resourceMemo, kind string, inputs interface{}, create func() (T, error)) (T, error) {
+	if m == nil {
+		return create()
+	}
+	key := fmt.Sprintf("%s/%#v", kind, inputs)
+	if resource, exists := m.get(key); exists {
+		return proto.Clone(resource).(T), nil
+	}
+	resource, err := create()
+	if err != nil {
+		return resource, err
+	}
+	m.put(key, proto.Clone(resource))
+	return resource, nil
+}
+
+func (m *resourceMemo) get(key string) (proto.Message, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if resource, exists := m.current[key]; exists {
+		return resource, true
+	}
+	resource, exists := m.previous[key]
+	if exists {
+		m.putLocked(key, resource)
+	}
+	return resource, exists
+}
+
+func (m *resourceMemo) put(key string, resource proto.Message) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.putLocked(key, resource)
+}
+
+func (m *resourceMemo) putLocked(key string, resource proto.Message) {
+	if len(m.current) >= resourceMemoGenerationSize {
+		m.previous = m.current
+		m.current = map[string]proto.Message{}
+	}
+	m.current[key] = resource
+}
@@ -0,0 +1,107 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"fmt"
+
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+	"google.golang.org/protobuf/proto"
+)
+
+// ResourceNamer names the Listener, RouteConfiguration, Cluster, and ClusterLoadAssignment of an
+// application, external backend, or route hostname, for one resource naming style. The snapshot
+// builder creates these resources once, with plain names, and renames copies of them for each
+// ResourceNamer, see `SnapshotBuilder.Build()`.
+type ResourceNamer interface {
+	Listener(name string) string
+	RouteConfiguration(name string) string
+	Cluster(name string) string
+	ClusterLoadAssignment(name string) string
+}
+
+// xdstpResourceNamer names resources using `xdstp://` names with the authority, for xDS federation.
+type xdstpResourceNamer struct {
+	authority string
+}
+
+var _ ResourceNamer = xdstpResourceNamer{}
+
+func (n xdstpResourceNamer) Listener(name string) string {
+	return fmt.Sprintf("xdstp://%s/envoy.config.listener.v3.Listener/%s", n.authority, name)
+}
+
+func (n xdstpResourceNamer) RouteConfiguration(name string) string {
+	return fmt.Sprintf("xdstp://%s/envoy.config.route.v3.RouteConfiguration/%s", n.authority, name)
+}
+
+func (n xdstpResourceNamer) Cluster(name string) string {
+	return fmt.Sprintf("xdstp://%s/envoy.config.cluster.v3.Cluster/%s", n.authority, name)
+}
+
+func (n xdstpResourceNamer) ClusterLoadAssignment(name string) string {
+	return fmt.Sprintf("xdstp://%s/envoy.config.endpoint.v3.ClusterLoadAssignment/%s", n.authority, name)
+}
+
+// renameRouteConfiguration returns a copy of the RouteConfiguration, with the RouteConfiguration
+// name and the names of the Clusters in route actions renamed by the namer.
+func renameRouteConfiguration(namer ResourceNamer, routeConfiguration *routev3.RouteConfiguration) *routev3.RouteConfiguration {
+	renamed := proto.Clone(routeConfiguration).(*routev3.RouteConfiguration)
+	renamed.Name = namer.RouteConfiguration(routeConfiguration.GetName())
+	for _, virtualHost := range renamed.GetVirtualHosts() {
+		for _, route := range virtualHost.GetRoutes() {
+			routeAction := route.GetRoute()
+			if routeAction == nil {
+				continue
+			}
+			switch clusterSpecifier := routeAction.GetClusterSpecifier().(type) {
+			case *routev3.RouteAction_Cluster:
+				clusterSpecifier.Cluster = namer.Cluster(clusterSpecifier.Cluster)
+			case *routev3.RouteAction_WeightedClusters:
+				for _, weightedCluster := range clusterSpecifier.WeightedClusters.GetClusters() {
+					weightedCluster.Name = namer.Cluster(weightedCluster.GetName())
+				}
+			}
+			for _, requestMirrorPolicy := range routeAction.GetRequestMirrorPolicies() {
+				requestMirrorPolicy.Cluster = namer.Cluster(requestMirrorPolicy.GetCluster())
+			}
+		}
+	}
+	return renamed
+}
+
+// renameCluster returns a copy of the Cluster, with the Cluster name, the EDS service name, and the
+// cluster name of the inline load assignment renamed by the namer.
+func renameCluster(namer ResourceNamer, cluster *clusterv3.Cluster) *clusterv3.Cluster {
+	renamed := proto.Clone(cluster).(*clusterv3.Cluster)
+	renamed.Name = namer.Cluster(cluster.GetName())
+	if edsClusterConfig := renamed.GetEdsClusterConfig(); edsClusterConfig.GetServiceName() != "" {
+		edsClusterConfig.ServiceName = namer.ClusterLoadAssignment(edsClusterConfig.GetServiceName())
+	}
+	if loadAssignment := renamed.GetLoadAssignment(); loadAssignment != nil {
+		loadAssignment.ClusterName = renamed.Name
+	}
+	return renamed
+}
+
+// renameClusterLoadAssignment returns a copy of the ClusterLoadAssignment, with the cluster name
+// renamed by the namer.
+func renameClusterLoadAssignment(namer ResourceNamer, clusterLoadAssignment *endpointv3.ClusterLoadAssignment) *endpointv3.ClusterLoadAssignment {
+	renamed := proto.Clone(clusterLoadAssignment).(*endpointv3.ClusterLoadAssignment)
+	renamed.ClusterName = namer.ClusterLoadAssignment(clusterLoadAssignment.GetClusterName())
+	return renamed
+}
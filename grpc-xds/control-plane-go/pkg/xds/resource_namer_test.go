@@ -0,0 +1,79 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+// TestResourceNamerIdenticalPayloads checks that renamed copies of resources are identical to
+// resources created directly with `xdstp://` names.
+func TestResourceNamerIdenticalPayloads(t *testing.T) {
+	namer := xdstpResourceNamer{authority: goldenAuthority}
+	const name = "greeter-leaf"
+
+	t.Run("RouteConfiguration", func(t *testing.T) {
+		rules := []applications.RouteRule{
+			{Backends: []applications.RouteBackend{{Name: "greeter-leaf"}}},
+			{Backends: []applications.RouteBackend{{Name: "greeter-leaf", Weight: 80}, {Name: "greeter-intermediary", Weight: 20}}},
+		}
+		plain := rds.CreateRouteConfigurationForRouteRules(name, name, rules, func(appName string) string {
+			return appName
+		})
+		want := rds.CreateRouteConfigurationForRouteRules(namer.RouteConfiguration(name), name, rules, namer.Cluster)
+		if got := renameRouteConfiguration(namer, plain); !proto.Equal(got, want) {
+			t.Errorf("renamed RouteConfiguration\n%v\nwant\n%v", got, want)
+		}
+	})
+
+	t.Run("Cluster", func(t *testing.T) {
+		plain, err := cds.CreateCluster(name, name, "xds", "greeter-leaf", nil, 50052, "grpc", "", true, tls.CertificateProvider{}, true, "", nil)
+		if err != nil {
+			t.Fatalf("could not create Cluster: %v", err)
+		}
+		want, err := cds.CreateCluster(namer.Cluster(name), namer.ClusterLoadAssignment(name), "xds", "greeter-leaf", nil, 50052, "grpc", "", true, tls.CertificateProvider{}, true, "", nil)
+		if err != nil {
+			t.Fatalf("could not create Cluster: %v", err)
+		}
+		if got := renameCluster(namer, plain); !proto.Equal(got, want) {
+			t.Errorf("renamed Cluster\n%v\nwant\n%v", got, want)
+		}
+		if plain.GetName() != name {
+			t.Errorf("renaming changed the original Cluster name to %s", plain.GetName())
+		}
+	})
+
+	t.Run("DNS Cluster", func(t *testing.T) {
+		backend := cds.ExternalBackend{Name: name, Hostname: "greeter.example.com", Port: 50051}
+		plain, err := cds.CreateDNSCluster(name, backend, "")
+		if err != nil {
+			t.Fatalf("could not create DNS Cluster: %v", err)
+		}
+		want, err := cds.CreateDNSCluster(namer.Cluster(name), backend, "")
+		if err != nil {
+			t.Fatalf("could not create DNS Cluster: %v", err)
+		}
+		if got := renameCluster(namer, plain); !proto.Equal(got, want) {
+			t.Errorf("renamed DNS Cluster\n%v\nwant\n%v", got, want)
+		}
+	})
+}
@@ -0,0 +1,150 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"maps"
+	"strings"
+	"sync"
+
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+)
+
+const xdstpScheme = "xdstp://"
+
+// requestedNaming describes the resource naming styles that xDS clients of a node hash
+// requested: plain names, e.g., `greeter-leaf`, and `xdstp://` names by authority, e.g.,
+// `xdstp://control-plane.xds.svc.cluster.local/envoy.config.listener.v3.Listener/greeter-leaf`.
+//
+// The zero value means that all naming styles are requested, e.g., before any xDS client
+// of the node hash has requested resources other than server Listeners.
+type requestedNaming struct {
+	// known is true once a naming style has been recorded for the node hash.
+	known bool
+	// all is true if a client sent a wildcard request, e.g., an Envoy proxy.
+	all         bool
+	plain       bool
+	authorities map[string]bool
+}
+
+// includesPlain returns true if snapshots should contain resources with plain names.
+func (n requestedNaming) includesPlain() bool {
+	return !n.known || n.all || n.plain
+}
+
+// includesAuthority returns true if snapshots should contain resources with `xdstp://` names
+// of the authority.
+func (n requestedNaming) includesAuthority(authority string) bool {
+	return !n.known || n.all || n.authorities[authority]
+}
+
+// requestedResourceNaming records the naming styles of the resource names requested by the
+// xDS clients of each node hash, so that snapshots only contain both plain and `xdstp://`
+// resource names if clients requested both, see `CreateWatch()`. Snapshots are per node hash,
+// so the naming styles of all streams of a node hash are combined. Naming styles are kept
+// until the node hash is evicted.
+type requestedResourceNaming struct {
+	mu               sync.Mutex
+	namingByNodeHash map[string]requestedNaming
+}
+
+func newRequestedResourceNaming() *requestedResourceNaming {
+	return &requestedResourceNaming{
+		namingByNodeHash: map[string]requestedNaming{},
+	}
+}
+
+// add records the naming styles of the requested names for the node hash, and returns true
+// if any of the naming styles are new. Server Listener names don't count as plain names,
+// since the snapshots always contain the requested server Listeners. Requests without names
+// that are not wildcard requests, e.g., when a client unsubscribes, don't change anything.
+func (r *requestedResourceNaming) add(nodeHash string, names []string, wildcard bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	naming := r.namingByNodeHash[nodeHash]
+	if naming.all {
+		return false
+	}
+	if wildcard {
+		naming.known = true
+		naming.all = true
+		r.namingByNodeHash[nodeHash] = naming
+		return true
+	}
+	changes := false
+	for _, name := range names {
+		authority, plainName, isXDSTP := parseResourceName(name)
+		switch {
+		case isXDSTP:
+			if !naming.authorities[authority] {
+				naming.authorities = maps.Clone(naming.authorities)
+				if naming.authorities == nil {
+					naming.authorities = map[string]bool{}
+				}
+				naming.authorities[authority] = true
+				changes = true
+			}
+		case strings.HasPrefix(plainName, serverListenerNamePrefix):
+		case !naming.plain:
+			naming.plain = true
+			changes = true
+		}
+	}
+	if changes {
+		naming.known = true
+		r.namingByNodeHash[nodeHash] = naming
+	}
+	return changes
+}
+
+// get returns the naming styles requested by the xDS clients of the node hash.
+func (r *requestedResourceNaming) get(nodeHash string) requestedNaming {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.namingByNodeHash[nodeHash]
+}
+
+// remove deletes the naming styles for the node hash, e.g., when the node hash is evicted.
+func (r *requestedResourceNaming) remove(nodeHash string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.namingByNodeHash, nodeHash)
+}
+
+// parseResourceName normalizes a requested resource name. For `xdstp://` names, it returns the
+// authority and the resource name after the resource type, e.g., `greeter-leaf` for
+// `xdstp://control-plane.xds.svc.cluster.local/envoy.config.listener.v3.Listener/greeter-leaf`.
+// Other names are returned unchanged.
+func parseResourceName(name string) (authority string, plainName string, isXDSTP bool) {
+	rest, isXDSTP := strings.CutPrefix(name, xdstpScheme)
+	if !isXDSTP {
+		return "", name, false
+	}
+	authority, rest, _ = strings.Cut(rest, "/")
+	_, plainName, _ = strings.Cut(rest, "/")
+	return authority, plainName, true
+}
+
+// isWildcardRequest returns true if the requested names of a state-of-the-world request
+// subscribe to all resources of the type. Only Listener and Cluster requests can be wildcard
+// requests without names.
+func isWildcardRequest(typeURL string, names []string) bool {
+	for _, name := range names {
+		if name == "*" {
+			return true
+		}
+	}
+	return len(names) == 0 && (typeURL == resourcev3.ListenerType || typeURL == resourcev3.ClusterType)
+}
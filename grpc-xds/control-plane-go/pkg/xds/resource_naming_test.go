@@ -0,0 +1,91 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"testing"
+)
+
+func TestRequestedResourceNamingAdd(t *testing.T) {
+	const (
+		nodeHash   = goldenZone
+		plainName  = "greeter-leaf"
+		xdstpName  = "xdstp://" + goldenAuthority + "/envoy.config.listener.v3.Listener/greeter-leaf"
+		serverName = "grpc/server?xds.resource.listening_address=10.0.0.20:50051"
+	)
+	r := newRequestedResourceNaming()
+	if naming := r.get(nodeHash); !naming.includesPlain() || !naming.includesAuthority(goldenAuthority) {
+		t.Errorf("expected all naming styles before any requests, got %+v", naming)
+	}
+	if r.add(nodeHash, []string{serverName}, false) {
+		t.Errorf("expected no changes for server Listener names")
+	}
+	if !r.add(nodeHash, []string{xdstpName}, false) {
+		t.Errorf("expected changes for the first xdstp:// name")
+	}
+	if r.add(nodeHash, []string{xdstpName, serverName}, false) {
+		t.Errorf("expected no changes for an authority that was already requested")
+	}
+	naming := r.get(nodeHash)
+	if naming.includesPlain() || !naming.includesAuthority(goldenAuthority) || naming.includesAuthority("other.example.com") {
+		t.Errorf("expected only the requested authority, got %+v", naming)
+	}
+	if !r.add(nodeHash, []string{plainName}, false) {
+		t.Errorf("expected changes for the first plain name")
+	}
+	if !r.get(nodeHash).includesPlain() {
+		t.Errorf("expected plain names after requesting a plain name")
+	}
+	if !r.add(nodeHash, nil, true) || r.add(nodeHash, nil, true) {
+		t.Errorf("expected changes only for the first wildcard request")
+	}
+	if !r.get(nodeHash).includesAuthority("other.example.com") {
+		t.Errorf("expected all authorities after a wildcard request")
+	}
+}
+
+func TestParseResourceName(t *testing.T) {
+	tests := []struct {
+		name          string
+		wantAuthority string
+		wantPlainName string
+		wantXDSTP     bool
+	}{
+		{
+			name:          "greeter-leaf",
+			wantPlainName: "greeter-leaf",
+		},
+		{
+			name:          "xdstp://" + goldenAuthority + "/envoy.config.cluster.v3.Cluster/greeter-leaf",
+			wantAuthority: goldenAuthority,
+			wantPlainName: "greeter-leaf",
+			wantXDSTP:     true,
+		},
+		{
+			name:          "xdstp://" + goldenAuthority + "/envoy.config.listener.v3.Listener/grpc/server?xds.resource.listening_address=10.0.0.20:50051",
+			wantAuthority: goldenAuthority,
+			wantPlainName: "grpc/server?xds.resource.listening_address=10.0.0.20:50051",
+			wantXDSTP:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			authority, plainName, isXDSTP := parseResourceName(test.name)
+			if authority != test.wantAuthority || plainName != test.wantPlainName || isXDSTP != test.wantXDSTP {
+				t.Errorf("parseResourceName(%q) = (%q, %q, %t), want (%q, %q, %t)", test.name, authority, plainName, isXDSTP, test.wantAuthority, test.wantPlainName, test.wantXDSTP)
+			}
+		})
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"time"
+
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+)
+
+const (
+	// resourceTTLHeartbeatInterval is how often the cache sends heartbeats for resources with a TTL,
+	// so that xDS clients do not remove the resources while the control plane is available.
+	resourceTTLHeartbeatInterval = 5 * time.Second
+	// MinResourceTTL is the shortest resource TTL, so that clients receive several heartbeats
+	// before the resources expire.
+	MinResourceTTL = 3 * resourceTTLHeartbeatInterval
+)
+
+// setResourceTTL sets the TTL of the resource with the provided type URL and name in the
+// snapshot, if the snapshot contains the resource. xDS clients remove the resource if they
+// do not receive an update or a heartbeat for the resource before the TTL expires.
+func setResourceTTL(snapshot *cachev3.Snapshot, typeURL resource.Type, name string, ttl time.Duration) {
+	items := snapshot.Resources[cachev3.GetResponseType(typeURL)].Items
+	if resourceWithTTL, exists := items[name]; exists {
+		resourceWithTTL.TTL = &ttl
+		items[name] = resourceWithTTL
+	}
+}
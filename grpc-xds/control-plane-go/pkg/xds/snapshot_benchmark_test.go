@@ -0,0 +1,135 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	streamv3 "github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+)
+
+// benchmarkSizes are the numbers of applications, endpoints per application, and node hashes
+// used in the snapshot generation benchmarks. Run the benchmarks with profiles using `make bench`.
+var benchmarkSizes = []struct {
+	apps       int
+	endpoints  int
+	nodeHashes int
+}{
+	{apps: 10, endpoints: 10, nodeHashes: 1},
+	{apps: 10, endpoints: 10, nodeHashes: 10},
+	{apps: 100, endpoints: 10, nodeHashes: 10},
+	{apps: 100, endpoints: 100, nodeHashes: 10},
+	{apps: 1000, endpoints: 10, nodeHashes: 3},
+}
+
+// BenchmarkSnapshotBuilder measures building one snapshot for a node hash, with and without
+// the resource memo of the snapshot cache. The number of node hashes does not apply.
+func BenchmarkSnapshotBuilder(b *testing.B) {
+	features := &Features{EnableDataPlaneTLS: true, EnableFederation: true}
+	seen := map[[2]int]bool{}
+	for _, size := range benchmarkSizes {
+		if seen[[2]int{size.apps, size.endpoints}] {
+			continue
+		}
+		seen[[2]int{size.apps, size.endpoints}] = true
+		apps := benchmarkApplications(size.apps, size.endpoints, 0)
+		for _, useMemo := range []bool{false, true} {
+			b.Run(fmt.Sprintf("apps=%d/endpoints=%d/memo=%t", size.apps, size.endpoints, useMemo), func(b *testing.B) {
+				var memo *resourceMemo
+				if useMemo {
+					memo = newResourceMemo()
+				}
+				b.ReportAllocs()
+				b.ResetTimer()
+				for range b.N {
+					snapshotBuilder, err := NewSnapshotBuilder(benchmarkZone(0), eds.LocalityPriorityByZone{}, features, goldenAuthority).
+						withResourceMemo(memo).
+						AddGRPCApplications(apps)
+					if err != nil {
+						b.Fatalf("could not add applications to snapshot builder: %v", err)
+					}
+					if _, err := snapshotBuilder.
+						AddGRPCServerListenerAddresses([]EndpointAddress{{Host: "10.0.0.20", Port: 50051}}).
+						AddRBACPolicies(rds.DefaultRBACPolicies()).
+						Build(); err != nil {
+						b.Fatalf("could not build snapshot: %v", err)
+					}
+				}
+			})
+		}
+	}
+}
+
+// BenchmarkSnapshotCacheUpdateResources measures creating new snapshots for all node hashes in
+// the cache after an endpoint change, i.e., the work done for each informer update.
+func BenchmarkSnapshotCacheUpdateResources(b *testing.B) {
+	for _, size := range benchmarkSizes {
+		b.Run(fmt.Sprintf("apps=%d/endpoints=%d/nodeHashes=%d", size.apps, size.endpoints, size.nodeHashes), func(b *testing.B) {
+			ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+			defer cancel()
+			xdsCache := NewSnapshotCache(ctx, ZoneHash{}, eds.LocalityPriorityByZone{}, &Features{EnableDataPlaneTLS: true}, goldenAuthority)
+			for i := range size.nodeHashes {
+				// The zone hash creates one node hash per zone.
+				cancelWatch := xdsCache.CreateWatch(&cachev3.Request{
+					Node:    &corev3.Node{Id: fmt.Sprintf("node-%d", i), Locality: &corev3.Locality{Zone: benchmarkZone(i)}},
+					TypeUrl: resource.ListenerType,
+				}, streamv3.NewStreamState(false, nil), make(chan cachev3.Response, 1))
+				defer cancelWatch()
+			}
+			// Alternate between two sets of endpoints, so that every update changes the applications.
+			updates := [][]applications.Application{
+				benchmarkApplications(size.apps, size.endpoints, 0),
+				benchmarkApplications(size.apps, size.endpoints, 1),
+			}
+			b.ReportAllocs()
+			b.ResetTimer()
+			for i := range b.N {
+				if err := xdsCache.UpdateResources(ctx, logr.Discard(), "benchmark", "xds", updates[i%2]); err != nil {
+					b.Fatalf("could not update resources: %v", err)
+				}
+			}
+		})
+	}
+}
+
+// benchmarkApplications creates applications with endpoints spread over three zones. The offset
+// changes the last octet of the endpoint addresses.
+func benchmarkApplications(apps int, endpoints int, offset int) []applications.Application {
+	result := make([]applications.Application, apps)
+	for i := range apps {
+		appEndpoints := make([]applications.ApplicationEndpoints, endpoints)
+		for j := range endpoints {
+			address := fmt.Sprintf("10.%d.%d.%d", i/256, i%256, (j+offset)%256)
+			appEndpoints[j] = applications.NewApplicationEndpoints("", fmt.Sprintf("node-%d", j), benchmarkZone(j), "", []string{address}, applications.AddressTypeIPv4, applications.Healthy, nil)
+		}
+		result[i] = applications.NewApplication("xds", fmt.Sprintf("app-%d", i), 50051, "grpc", 50052, "grpc", appEndpoints)
+	}
+	return result
+}
+
+func benchmarkZone(i int) string {
+	return fmt.Sprintf("us-central1-%c", 'a'+rune(i%26))
+}
@@ -16,9 +16,13 @@ package xds
 
 import (
 	"fmt"
+	"slices"
 	"strconv"
 	"time"
 
+	clusterv3 "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
@@ -28,129 +32,451 @@ import (
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+const (
+	// envoyGRPCListenerPort is the port of the Listener for Envoy proxies receiving gRPC requests.
+	envoyGRPCListenerPort = 50051
+	// envoyDynamicForwardProxyListenerPort is the port of the Listener for Envoy proxies forwarding
+	// egress HTTP requests to any host.
+	envoyDynamicForwardProxyListenerPort = 10000
 )
 
 // SnapshotBuilder builds xDS resource snapshots for the cache.
 type SnapshotBuilder struct {
-	listeners                   map[string]types.Resource
-	routeConfigurations         map[string]types.Resource
-	clusters                    map[string]types.Resource
-	clusterLoadAssignments      map[string]types.Resource
-	endpointsByCluster          map[string][]applications.ApplicationEndpoints
-	grpcServerListenerAddresses map[EndpointAddress]bool
-	nodeHash                    string
-	localityPriorityMapper      eds.LocalityPriorityMapper
-	features                    *Features
-	authority                   string
-}
-
-// NewSnapshotBuilder initializes the builder.
+	listeners                             map[string]types.Resource
+	routeConfigurations                   map[string]types.Resource
+	clusters                              map[string]types.Resource
+	clusterLoadAssignments                map[string]types.Resource
+	apps                                  []applications.Application
+	endpointsByCluster                    map[string][]applications.ApplicationEndpoints
+	applicationsByCluster                 map[string][]applicationRef
+	subsetsByCluster                      map[string]map[string][]string
+	grpcServerListenerAddresses           map[EndpointAddress]bool
+	rbacPolicies                          []rds.RBACPolicy
+	routePolicies                         map[string]rds.RoutePolicy
+	maintenanceApplications               map[string]string
+	placeholderClusterNames               []string
+	placeholderClusterLoadAssignmentNames []string
+	resourceGenerators                    []ResourceGenerator
+	jwtProviders                          []lds.JWTProvider
+	nodeHash                              string
+	localityPriorityMapper                eds.LocalityPriorityMapper
+	loadReports                           *eds.LoadReports
+	features                              *Features
+	authority                             string
+	additionalAuthorities                 []string
+	// naming restricts the resource naming styles in the snapshot, see `withRequestedNaming()`.
+	naming requestedNaming
+	// authoritiesByName are the authority names for the `xdstp://` names of the Listeners,
+	// RouteConfigurations, Clusters, and ClusterLoadAssignments of applications, external
+	// backends, and routes, by their plain names, see `federationGenerator`.
+	authoritiesByName map[string][]string
+	// memo reuses Listeners and Clusters from previous snapshots, see `withResourceMemo()`.
+	memo *resourceMemo
+}
+
+// applicationRef identifies an application of a Cluster, for resolving route backends, see
+// `routeRulesWithClusters()`.
+type applicationRef struct {
+	namespace   string
+	servingPort uint32
+}
+
+// apiListenerInputs are the inputs of `lds.CreateAPIListener()`, used as the memo key.
+type apiListenerInputs struct {
+	Name                   string
+	RouteConfigurationName string
+}
+
+// clusterInputs are the inputs of `cds.CreateCluster()`, used as the memo key.
+type clusterInputs struct {
+	Name                         string
+	EDSServiceName               string
+	Namespace                    string
+	ServiceAccountName           string
+	SubjectAltNames              []tls.SubjectAltNameMatcher
+	HealthCheckPort              uint32
+	HealthCheckProtocol          string
+	HealthCheckPathOrGRPCService string
+	EnableTLS                    bool
+	CertificateProvider          tls.CertificateProvider
+	RequireClientCerts           bool
+	LoadBalancingPolicy          string
+	TrustDomains                 []tls.TrustDomain
+}
+
+// NewSnapshotBuilder initializes the builder, using the feature flags after applying the feature
+// overrides that match the node hash.
 func NewSnapshotBuilder(nodeHash string, localityPriorityMapper eds.LocalityPriorityMapper, features *Features, authority string) *SnapshotBuilder {
+	// The locality priority mappers expect the node hash without node metadata.
+	baseNodeHash, _ := splitNodeHash(nodeHash)
 	return &SnapshotBuilder{
 		listeners:                   make(map[string]types.Resource),
 		routeConfigurations:         make(map[string]types.Resource),
 		clusters:                    make(map[string]types.Resource),
 		clusterLoadAssignments:      make(map[string]types.Resource),
 		endpointsByCluster:          make(map[string][]applications.ApplicationEndpoints),
+		applicationsByCluster:       make(map[string][]applicationRef),
+		subsetsByCluster:            make(map[string]map[string][]string),
 		grpcServerListenerAddresses: make(map[EndpointAddress]bool),
-		nodeHash:                    nodeHash,
+		authoritiesByName:           make(map[string][]string),
+		nodeHash:                    baseNodeHash,
 		localityPriorityMapper:      localityPriorityMapper,
-		features:                    features,
+		features:                    features.ForNodeHash(nodeHash),
 		authority:                   authority,
 	}
 }
 
+// WithLoadReports sets the load reports used for locality weights, if the locality weight policy
+// is `loadReport`. Call this method before `AddGRPCApplications()`.
+func (b *SnapshotBuilder) WithLoadReports(loadReports *eds.LoadReports) *SnapshotBuilder {
+	b.loadReports = loadReports
+	return b
+}
+
+// WithRoutePolicies sets the per-method routes of the RouteConfigurations of applications, and
+// the request mirror policies for Envoy proxies. Call this method before `AddGRPCApplications()`.
+func (b *SnapshotBuilder) WithRoutePolicies(policies []rds.RoutePolicy) *SnapshotBuilder {
+	b.routePolicies = make(map[string]rds.RoutePolicy, len(policies))
+	for _, policy := range policies {
+		b.routePolicies[policy.Application] = policy
+	}
+	return b
+}
+
+// WithMaintenanceApplications puts applications in maintenance mode, in addition to the applications
+// from the `maintenanceApplications` feature flag, and overrides their status codes. Call this
+// method before `AddGRPCApplications()`.
+func (b *SnapshotBuilder) WithMaintenanceApplications(maintenanceApplications map[string]string) *SnapshotBuilder {
+	b.maintenanceApplications = maintenanceApplications
+	return b
+}
+
+// WithPlaceholders sets the names of Clusters and ClusterLoadAssignments that xDS clients requested,
+// but that are not in the snapshot. If the `synthesizeUnknownResources` feature flag is enabled,
+// `Build()` adds placeholder resources without endpoints for these names, so that clients fail
+// requests with `UNAVAILABLE` instead of waiting for the resources.
+func (b *SnapshotBuilder) WithPlaceholders(clusterNames []string, clusterLoadAssignmentNames []string) *SnapshotBuilder {
+	b.placeholderClusterNames = clusterNames
+	b.placeholderClusterLoadAssignmentNames = clusterLoadAssignmentNames
+	return b
+}
+
+// WithAdditionalAuthorities adds authority names of this control plane, e.g., of additional
+// authority server ports. With xDS federation enabled, applications without an authority name
+// get `xdstp://` resource names for each of these authorities, in addition to the authority
+// name of the control plane. Call this method before `AddGRPCApplications()`.
+func (b *SnapshotBuilder) WithAdditionalAuthorities(authorities ...string) *SnapshotBuilder {
+	b.additionalAuthorities = authorities
+	return b
+}
+
+// withRequestedNaming restricts the snapshot to the naming styles requested by the xDS clients
+// of the node hash. Resources with plain names of applications, external backends, and routes
+// are only included if clients requested plain names, and resources with `xdstp://` names are
+// only included for the authorities that clients requested. Server Listeners, and resources
+// for Envoy proxies, are always included. Call this method before `AddGRPCApplications()`.
+func (b *SnapshotBuilder) withRequestedNaming(naming requestedNaming) *SnapshotBuilder {
+	b.naming = naming
+	return b
+}
+
+// withResourceMemo sets the memo that reuses Listeners and Clusters created with the same
+// inputs for other snapshots. Call this method before `AddGRPCApplications()`.
+func (b *SnapshotBuilder) withResourceMemo(memo *resourceMemo) *SnapshotBuilder {
+	b.memo = memo
+	return b
+}
+
+// WithResourceGenerators adds generators that run after the default and registered generators,
+// for this builder only.
+func (b *SnapshotBuilder) WithResourceGenerators(generators ...ResourceGenerator) *SnapshotBuilder {
+	b.resourceGenerators = append(b.resourceGenerators, generators...)
+	return b
+}
+
 // AddGRPCApplications adds the provided application configurations to the xDS resource snapshot.
+// With xDS federation enabled, the `xdstp://` resource names of each application use the
+// authority name of the application, or the authority names of the control plane if not set.
+//
+// The Listeners, RouteConfigurations, Clusters, and ClusterLoadAssignments of the applications
+// are created in `Build()`, see `applicationGenerator`. Applications with the same name share
+// these resources, and the endpoints of applications with the same name and serving port are
+// merged, e.g., from the informers of multiple Kubernetes clusters.
 func (b *SnapshotBuilder) AddGRPCApplications(apps []applications.Application) (*SnapshotBuilder, error) {
 	for _, app := range apps {
-		if b.listeners[app.Name] == nil {
-			apiListener, err := lds.CreateAPIListener(app.Name, app.Name)
-			if err != nil {
-				return nil, fmt.Errorf("could not create LDS API listener for gRPC application %+v: %w", app, err)
-			}
-			b.listeners[apiListener.Name] = apiListener
-			if b.features.EnableFederation {
-				xdstpListenerName := xdstpListener(b.authority, app.Name)
-				xdstpRouteConfigurationName := xdstpRouteConfiguration(b.authority, app.Name)
-				xdstpListener, err := lds.CreateAPIListener(xdstpListenerName, xdstpRouteConfigurationName)
-				if err != nil {
-					return nil, fmt.Errorf("could not create federation LDS API listener for authority=%s and gRPC application %+v: %w", b.authority, app, err)
-				}
-				b.listeners[xdstpListener.Name] = xdstpListener
-			}
+		b.apps = append(b.apps, app)
+		b.addAuthorities(app.Name, b.appAuthorities(app))
+		ref := applicationRef{namespace: app.Namespace, servingPort: app.ServingPort}
+		if !slices.Contains(b.applicationsByCluster[app.Name], ref) {
+			b.applicationsByCluster[app.Name] = append(b.applicationsByCluster[app.Name], ref)
 		}
-		if b.routeConfigurations[app.Name] == nil {
-			routeConfiguration := rds.CreateRouteConfigurationForAPIListener(app.Name, app.Name, app.PathPrefix, app.Name)
-			b.routeConfigurations[routeConfiguration.Name] = routeConfiguration
-			if b.features.EnableFederation {
-				xdstpRouteConfigurationName := xdstpRouteConfiguration(b.authority, app.Name)
-				xdstpClusterName := xdstpCluster(b.authority, app.Name)
-				xdstpRouteConfiguration := rds.CreateRouteConfigurationForAPIListener(xdstpRouteConfigurationName, app.Name, app.PathPrefix, xdstpClusterName)
-				b.routeConfigurations[xdstpRouteConfiguration.Name] = xdstpRouteConfiguration
+		endpointsByClusterKey := appEndpointsKey(app)
+		b.endpointsByCluster[endpointsByClusterKey] = append(b.endpointsByCluster[endpointsByClusterKey], app.Endpoints...)
+		if _, exists := applications.FQDNHostname(b.endpointsByCluster[endpointsByClusterKey]); !exists {
+			if subsets := applications.SubsetLabelValues(b.endpointsByCluster[endpointsByClusterKey]); len(subsets) > 0 {
+				// The RouteConfiguration for Envoy proxies uses the subsets to route requests with subset headers.
+				b.subsetsByCluster[app.Name] = subsets
 			}
 		}
-		if b.clusters[app.Name] == nil {
-			cluster, err := cds.CreateCluster(
-				app.Name,
-				app.Name,
-				app.Namespace,
-				app.ServiceAccountName,
-				app.HealthCheckPort,
-				app.HealthCheckProtocol,
-				"",
-				b.features.EnableDataPlaneTLS,
-				b.features.RequireDataPlaneClientCerts)
+	}
+	return b, nil
+}
+
+// appEndpointsKey returns the key of the merged endpoints of applications with the same name
+// and serving port in `endpointsByCluster`.
+func appEndpointsKey(app applications.Application) string {
+	return fmt.Sprintf("%s-%d", app.Name, app.ServingPort)
+}
+
+// hasCluster returns true if the snapshot has a Cluster with the name, or will have one after
+// `Build()` creates the Clusters of the applications.
+func (b *SnapshotBuilder) hasCluster(name string) bool {
+	return b.clusters[name] != nil || len(b.applicationsByCluster[name]) > 0
+}
+
+// createAPIListener creates an LDS API Listener, or reuses the memoized Listener with the same inputs.
+func (b *SnapshotBuilder) createAPIListener(name string, routeConfigurationName string) (*listenerv3.Listener, error) {
+	inputs := apiListenerInputs{
+		Name:                   name,
+		RouteConfigurationName: routeConfigurationName,
+	}
+	return memoize(b.memo, "apiListener", inputs, func() (*listenerv3.Listener, error) {
+		return lds.CreateAPIListener(name, routeConfigurationName)
+	})
+}
+
+// createCluster creates a CDS Cluster for the application, or reuses the memoized Cluster with
+// the same inputs.
+func (b *SnapshotBuilder) createCluster(name string, edsServiceName string, app applications.Application) (*clusterv3.Cluster, error) {
+	inputs := clusterInputs{
+		Name:                         name,
+		EDSServiceName:               edsServiceName,
+		Namespace:                    app.Namespace,
+		ServiceAccountName:           app.ServiceAccountName,
+		SubjectAltNames:              app.SubjectAltNames,
+		HealthCheckPort:              app.HealthCheckPort,
+		HealthCheckProtocol:          app.HealthCheckProtocol,
+		HealthCheckPathOrGRPCService: app.HealthCheckPathOrGRPCService(),
+		EnableTLS:                    b.features.EnableDataPlaneTLS,
+		CertificateProvider:          b.features.CertificateProvider(),
+		RequireClientCerts:           b.features.RequireDataPlaneClientCerts,
+		LoadBalancingPolicy:          b.features.ClusterLoadBalancingPolicy(),
+		TrustDomains:                 b.features.TrustDomains,
+	}
+	return memoize(b.memo, "cluster", inputs, func() (*clusterv3.Cluster, error) {
+		return cds.CreateCluster(
+			inputs.Name,
+			inputs.EDSServiceName,
+			inputs.Namespace,
+			inputs.ServiceAccountName,
+			inputs.SubjectAltNames,
+			inputs.HealthCheckPort,
+			inputs.HealthCheckProtocol,
+			inputs.HealthCheckPathOrGRPCService,
+			inputs.EnableTLS,
+			inputs.CertificateProvider,
+			inputs.RequireClientCerts,
+			inputs.LoadBalancingPolicy,
+			inputs.TrustDomains)
+	})
+}
+
+// addLocalityWeightConfig adds the locality weighted LB config to the Cluster if a locality weight
+// policy is set, and the load reporting server if the policy uses load reports.
+func (b *SnapshotBuilder) addLocalityWeightConfig(cluster *clusterv3.Cluster) {
+	if b.features.LocalityWeightPolicy == "" {
+		return
+	}
+	cds.AddLocalityWeightedLbConfig(cluster)
+	if b.features.UsesLoadReports() {
+		cds.AddLoadReportingServer(cluster)
+	}
+}
+
+// appAuthorities returns the authority names for the `xdstp://` resource names of the application.
+func (b *SnapshotBuilder) appAuthorities(app applications.Application) []string {
+	if app.Authority != "" {
+		return []string{app.Authority}
+	}
+	return b.controlPlaneAuthorities()
+}
+
+// controlPlaneAuthorities returns the authority name of the control plane, followed by the
+// additional authority names.
+func (b *SnapshotBuilder) controlPlaneAuthorities() []string {
+	return append([]string{b.authority}, b.additionalAuthorities...)
+}
+
+// federationAuthorities returns the authorities requested by xDS clients if xDS federation is
+// enabled, and nil otherwise.
+func (b *SnapshotBuilder) federationAuthorities(authorities []string) []string {
+	if !b.features.EnableFederation {
+		return nil
+	}
+	var requestedAuthorities []string
+	for _, authority := range authorities {
+		if b.naming.includesAuthority(authority) {
+			requestedAuthorities = append(requestedAuthorities, authority)
+		}
+	}
+	return requestedAuthorities
+}
+
+// AddExternalBackends adds a Listener, RouteConfiguration, and DNS Cluster for each of the
+// provided external backends to the xDS resource snapshot. Backends with the same name as an
+// application are skipped.
+//
+// Call this method before `AddGRPCRoutes()`, so that routing rules can use the backends.
+func (b *SnapshotBuilder) AddExternalBackends(backends []cds.ExternalBackend) (*SnapshotBuilder, error) {
+	for _, backend := range backends {
+		if b.hasCluster(backend.Name) {
+			continue
+		}
+		b.addAuthorities(backend.Name, b.controlPlaneAuthorities())
+		apiListener, err := b.createAPIListener(backend.Name, backend.Name)
+		if err != nil {
+			return nil, fmt.Errorf("could not create LDS API listener for external backend %+v: %w", backend, err)
+		}
+		b.listeners[apiListener.Name] = apiListener
+		routeConfiguration := rds.CreateRouteConfigurationForAPIListener(backend.Name, backend.Name, backend.PathPrefix, backend.Name)
+		b.routeConfigurations[routeConfiguration.Name] = routeConfiguration
+		cluster, err := cds.CreateDNSCluster(backend.Name, backend, b.features.ClusterLoadBalancingPolicy())
+		if err != nil {
+			return nil, fmt.Errorf("could not create CDS DNS Cluster for external backend %+v: %w", backend, err)
+		}
+		b.clusters[cluster.Name] = cluster
+	}
+	return b, nil
+}
+
+// AddGRPCRoutes adds the provided routing rules to the xDS resource snapshot, replacing the
+// route configurations for listeners named by the route hostnames. Routes without hostnames
+// use the route name as the listener name.
+//
+// Call this method after `AddGRPCApplications()`, since backends without clusters in the
+// snapshot are removed, and so are rules without any remaining backends.
+func (b *SnapshotBuilder) AddGRPCRoutes(routes []applications.Route) (*SnapshotBuilder, error) {
+	rulesByHostname := map[string][]applications.RouteRule{}
+	for _, route := range routes {
+		rules := b.routeRulesWithClusters(route.Rules)
+		hostnames := route.Hostnames
+		if len(hostnames) == 0 {
+			hostnames = []string{route.Name}
+		}
+		for _, hostname := range hostnames {
+			rulesByHostname[hostname] = append(rulesByHostname[hostname], rules...)
+		}
+	}
+	for hostname, rules := range rulesByHostname {
+		if len(rules) == 0 {
+			continue
+		}
+		b.addAuthorities(hostname, b.controlPlaneAuthorities())
+		if b.listeners[hostname] == nil {
+			apiListener, err := b.createAPIListener(hostname, hostname)
 			if err != nil {
-				return nil, fmt.Errorf("could not create CDS Cluster for gRPC application %+v: %w", app, err)
-			}
-			b.clusters[cluster.Name] = cluster
-			if b.features.EnableFederation {
-				xdstpClusterName := xdstpCluster(b.authority, app.Name)
-				xdstpEDSServiceName := xdstpEdsService(b.authority, app.Name)
-				xdstpCluster, err := cds.CreateCluster(
-					xdstpClusterName,
-					xdstpEDSServiceName,
-					app.Namespace,
-					app.ServiceAccountName,
-					app.HealthCheckPort,
-					app.HealthCheckProtocol,
-					"",
-					b.features.EnableDataPlaneTLS,
-					b.features.RequireDataPlaneClientCerts)
-				if err != nil {
-					return nil, fmt.Errorf("could not create federation CDS Cluster for authority=%s and gRPC application %+v: %w", b.authority, app, err)
-				}
-				b.clusters[xdstpCluster.Name] = xdstpCluster
+				return nil, fmt.Errorf("could not create LDS API listener for route hostname=%s: %w", hostname, err)
 			}
+			b.listeners[apiListener.Name] = apiListener
 		}
-		// Merge endpoints from multiple informers for the same app:
-		endpointsByClusterKey := fmt.Sprintf("%s-%d", app.Name, app.ServingPort)
-		b.endpointsByCluster[endpointsByClusterKey] = append(b.endpointsByCluster[endpointsByClusterKey], app.Endpoints...)
-		clusterLoadAssignment := eds.CreateClusterLoadAssignment(app.Name, app.ServingPort, b.nodeHash, b.localityPriorityMapper, b.endpointsByCluster[endpointsByClusterKey])
-		b.clusterLoadAssignments[clusterLoadAssignment.ClusterName] = clusterLoadAssignment
-		if b.features.EnableFederation {
-			xdstpEDSServiceName := xdstpEdsService(b.authority, app.Name)
-			xdstpClusterLoadAssignment := eds.CreateClusterLoadAssignment(xdstpEDSServiceName, app.ServingPort, b.nodeHash, b.localityPriorityMapper, b.endpointsByCluster[endpointsByClusterKey])
-			b.clusterLoadAssignments[xdstpClusterLoadAssignment.ClusterName] = xdstpClusterLoadAssignment
-		}
+		routeConfiguration := rds.CreateRouteConfigurationForRouteRules(hostname, hostname, rules, func(appName string) string {
+			return appName
+		})
+		b.routeConfigurations[routeConfiguration.Name] = routeConfiguration
 	}
 	return b, nil
 }
 
-func xdstpListener(authority string, listenerName string) string {
-	return fmt.Sprintf("xdstp://%s/envoy.config.listener.v3.Listener/%s", authority, listenerName)
+// setMaintenanceMode replaces the routes of the virtual hosts with a direct response route, if the
+// application is in maintenance mode, see `rds.SetMaintenanceMode()`.
+func (b *SnapshotBuilder) setMaintenanceMode(appName string, virtualHosts ...*routev3.VirtualHost) error {
+	statusCodeName, exists := b.maintenanceApplications[appName]
+	if !exists {
+		statusCodeName, exists = b.features.MaintenanceApplications[appName]
+	}
+	if !exists {
+		return nil
+	}
+	code, err := MaintenanceStatusCode(statusCodeName)
+	if err != nil {
+		return fmt.Errorf("could not set maintenance mode for application %s: %w", appName, err)
+	}
+	for _, virtualHost := range virtualHosts {
+		rds.SetMaintenanceMode(virtualHost, code)
+	}
+	return nil
 }
 
-func xdstpRouteConfiguration(authority string, routeConfigurationName string) string {
-	return fmt.Sprintf("xdstp://%s/envoy.config.route.v3.RouteConfiguration/%s", authority, routeConfigurationName)
+// mirrorsWithClusters returns the mirror policies of each cluster, without the mirror policies
+// for clusters that are not in the snapshot.
+func (b *SnapshotBuilder) mirrorsWithClusters() map[string][]rds.MirrorPolicy {
+	mirrorsByCluster := map[string][]rds.MirrorPolicy{}
+	for clusterName, policy := range b.routePolicies {
+		for _, mirror := range policy.Mirrors {
+			if b.hasCluster(mirror.Cluster) {
+				mirrorsByCluster[clusterName] = append(mirrorsByCluster[clusterName], mirror)
+			}
+		}
+	}
+	return mirrorsByCluster
 }
 
-func xdstpCluster(authority string, clusterName string) string {
-	return fmt.Sprintf("xdstp://%s/envoy.config.cluster.v3.Cluster/%s", authority, clusterName)
+// routeRulesWithClusters returns copies of the rules with only the backends that have
+// clusters in the snapshot, a matching application, see `matchesApplication()`, and a non-zero
+// weight, and without rules that have no backends.
+func (b *SnapshotBuilder) routeRulesWithClusters(rules []applications.RouteRule) []applications.RouteRule {
+	var rulesWithClusters []applications.RouteRule
+	for _, rule := range rules {
+		var backends []applications.RouteBackend
+		for _, backend := range rule.Backends {
+			if b.hasCluster(backend.Name) && backend.Weight > 0 && b.matchesApplication(backend) {
+				backends = append(backends, backend)
+			}
+		}
+		if len(backends) > 0 {
+			rulesWithClusters = append(rulesWithClusters, applications.RouteRule{
+				Matches:  rule.Matches,
+				Backends: backends,
+			})
+		}
+	}
+	return rulesWithClusters
+}
+
+// matchesApplication returns true if the Namespace and port of the backend match one of the
+// applications of the Cluster with the backend name. Clusters without applications, e.g., for
+// external backends, match by name only.
+func (b *SnapshotBuilder) matchesApplication(backend applications.RouteBackend) bool {
+	refs := b.applicationsByCluster[backend.Name]
+	if len(refs) == 0 {
+		return true
+	}
+	return slices.ContainsFunc(refs, func(ref applicationRef) bool {
+		return (backend.Namespace == "" || backend.Namespace == ref.namespace) &&
+			(backend.Port == 0 || backend.Port == ref.servingPort)
+	})
+}
+
+// addAuthorities adds authority names for the `xdstp://` names of the resources with the plain name.
+func (b *SnapshotBuilder) addAuthorities(name string, authorities []string) {
+	for _, authority := range authorities {
+		if !slices.Contains(b.authoritiesByName[name], authority) {
+			b.authoritiesByName[name] = append(b.authoritiesByName[name], authority)
+		}
+	}
 }
 
-func xdstpEdsService(authority string, serviceName string) string {
-	return fmt.Sprintf("xdstp://%s/envoy.config.endpoint.v3.ClusterLoadAssignment/%s", authority, serviceName)
+// federationNamers returns the namers for the `xdstp://` names of the resources with the plain
+// name, for each requested authority, see `federationAuthorities()`.
+func (b *SnapshotBuilder) federationNamers(name string) []xdstpResourceNamer {
+	var namers []xdstpResourceNamer
+	for _, authority := range b.federationAuthorities(b.authoritiesByName[name]) {
+		namers = append(namers, xdstpResourceNamer{authority: authority})
+	}
+	return namers
 }
 
 // AddGRPCServerListenerAddresses adds server listeners and associated route
@@ -162,44 +488,53 @@ func (b *SnapshotBuilder) AddGRPCServerListenerAddresses(addresses []EndpointAdd
 	return b
 }
 
-// Build adds the server listeners and route configuration for the node hash, and then builds the snapshot.
+// AddRBACPolicies sets the RBAC policies of the route configuration for server listeners.
+// The policies are only used if RBAC is enabled in the xDS feature flags.
+func (b *SnapshotBuilder) AddRBACPolicies(policies []rds.RBACPolicy) *SnapshotBuilder {
+	b.rbacPolicies = append(b.rbacPolicies, policies...)
+	return b
+}
+
+// AddJWTProviders sets the JWT providers of the JWT authentication HTTP filter for server listeners.
+// The providers are only used if JWT authentication is enabled in the xDS feature flags.
+func (b *SnapshotBuilder) AddJWTProviders(providers []lds.JWTProvider) *SnapshotBuilder {
+	b.jwtProviders = append(b.jwtProviders, providers...)
+	return b
+}
+
+// Build adds the federation resources, runs the resource generators for the node hash, and then
+// builds the snapshot.
 func (b *SnapshotBuilder) Build() (cachev3.ResourceSnapshot, error) {
-	for address := range b.grpcServerListenerAddresses {
-		serverListener, err := lds.CreateGRPCServerListener(address.Host, address.Port, b.features.EnableDataPlaneTLS, b.features.RequireDataPlaneClientCerts, b.features.EnableRBAC)
-		if err != nil {
-			return nil, fmt.Errorf("could not create LDS server Listener for address %s:%d: %w", address.Host, address.Port, err)
-		}
-		b.listeners[serverListener.Name] = serverListener
+	generators := b.defaultResourceGenerators()
+	generators = append(generators, resourceGenerators()...)
+	generators = append(generators, b.resourceGenerators...)
+	input := GeneratorInput{
+		NodeHash: b.nodeHash,
+		Features: b.features,
 	}
-	if len(b.grpcServerListenerAddresses) > 0 {
-		routeConfigurationForGRPCServerListener, err := rds.CreateRouteConfigurationForGRPCServerListener(b.features.EnableRBAC)
-		if err != nil {
-			return nil, fmt.Errorf("could not create RDS RouteConfiguration for LDS server Listener: %w", err)
-		}
-		b.routeConfigurations[routeConfigurationForGRPCServerListener.Name] = routeConfigurationForGRPCServerListener
-	}
-
-	// Envoy proxies will not accept the gRPC server Listeners, because all the routes in their RouteConfigurations
-	// specify `NonForwardingAction` as the action.
-	// Envoy proxies will also not accept the API Listeners created for gRPC clients, because Envoy proxies can only
-	// have at most one API Listener defined, and that API Listener must be a static resource (not fetched via xDS).
-	// TODO: Add gRPC-JSON transcoding and gRPC HTTP/1.1 bridge.
-	// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/grpc_json_transcoder_filter
-	// https://www.envoyproxy.io/docs/envoy/latest/configuration/http/http_filters/grpc_http1_bridge_filter
-	envoyGRPCListener, err := lds.CreateEnvoyGRPCListener(50051, true)
-	if err != nil {
-		return nil, fmt.Errorf("could not create LDS Listener for Envoy proxy receiving gRPC requests: %w", err)
+	resources := &Resources{
+		Listeners:              b.listeners,
+		RouteConfigurations:    b.routeConfigurations,
+		Clusters:               b.clusters,
+		ClusterLoadAssignments: b.clusterLoadAssignments,
 	}
-	b.listeners[envoyGRPCListener.Name] = envoyGRPCListener
-	var clusterNames []string
-	for clusterName := range b.clusters {
-		clusterNames = append(clusterNames, clusterName)
+	for _, phase := range generatorPhases {
+		for _, generator := range generators {
+			if err := phase.generate(generator, input, resources); err != nil {
+				return nil, fmt.Errorf("could not generate %s using %T: %w", phase.name, generator, err)
+			}
+		}
 	}
-	routeConfigurationForEnvoyGRPCListener, err := rds.CreateRouteConfigurationForEnvoyGRPCListener(clusterNames)
-	if err != nil {
-		return nil, fmt.Errorf("could not create RDS RouteConfiguration for Envoy proxy gRPC LDS Listener: %w", err)
+	if !b.naming.includesPlain() {
+		// Only clients that use `xdstp://` names, e.g., gRPC clients with federation, use this
+		// node hash, so the resources with plain names would only increase the snapshot size.
+		for name := range b.authoritiesByName {
+			delete(b.listeners, name)
+			delete(b.routeConfigurations, name)
+			delete(b.clusters, name)
+			delete(b.clusterLoadAssignments, name)
+		}
 	}
-	b.routeConfigurations[routeConfigurationForEnvoyGRPCListener.Name] = routeConfigurationForEnvoyGRPCListener
 
 	listenerResources := make([]types.Resource, len(b.listeners))
 	i := 0
@@ -227,10 +562,19 @@ func (b *SnapshotBuilder) Build() (cachev3.ResourceSnapshot, error) {
 	}
 
 	version := strconv.FormatInt(time.Now().UnixNano(), 10)
-	return cachev3.NewSnapshot(version, map[resource.Type][]types.Resource{
+	snapshot, err := cachev3.NewSnapshot(version, map[resource.Type][]types.Resource{
 		resource.ListenerType: listenerResources,
 		resource.RouteType:    routeConfigurationResources,
 		resource.ClusterType:  clusterResources,
 		resource.EndpointType: clusterLoadAssignmentResources,
 	})
+	if err != nil {
+		return nil, err
+	}
+	if b.features.ResourceTTL > 0 {
+		// Only Envoy proxies use this RouteConfiguration. gRPC clients do not implement resource
+		// TTLs, and do not accept the heartbeat responses for resources with a TTL.
+		setResourceTTL(snapshot, resource.RouteType, lds.EnvoyGRPCListenerRouteConfigurationName, b.features.ResourceTTL)
+	}
+	return snapshot, nil
 }
@@ -0,0 +1,680 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	routev3 "github.com/envoyproxy/go-control-plane/envoy/config/route/v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/tls"
+)
+
+// update rewrites the golden files with the snapshots from the current code:
+//
+//	go test ./pkg/xds/... -update
+var update = flag.Bool("update", false, "update the golden files in testdata/")
+
+const (
+	goldenAuthority = "control-plane.xds.svc.cluster.local"
+	goldenZone      = "us-central1-a"
+	goldenCluster   = "grpc-xds-2"
+)
+
+// TestSnapshotBuilderGolden builds snapshots of fixture applications with different xDS features,
+// and compares the JSON representation to the golden files in testdata/.
+func TestSnapshotBuilderGolden(t *testing.T) {
+	tests := []struct {
+		name             string
+		features         Features
+		nodeHash         string
+		externalBackends []cds.ExternalBackend
+		routes           []applications.Route
+		// extraApplications are added to the fixture applications. Their endpoints must be sorted,
+		// as done by `applications.NewApplication()`.
+		extraApplications []applications.Application
+		loadReports       *eds.LoadReports
+		routePolicies     []rds.RoutePolicy
+		// placeholderClusterNames are Cluster names requested by xDS clients, see `WithPlaceholders()`.
+		placeholderClusterNames []string
+		// grpcServerListenerAddresses default to `10.0.0.20:50051`.
+		grpcServerListenerAddresses []EndpointAddress
+		// resourceGenerators run after the default generators, see `WithResourceGenerators()`.
+		resourceGenerators []ResourceGenerator
+		// additionalAuthorities are authority names of additional authority server ports,
+		// see `WithAdditionalAuthorities()`.
+		additionalAuthorities []string
+		// requestedNames are resource names requested by xDS clients, see `withRequestedNaming()`.
+		requestedNames []string
+	}{
+		{
+			name:     "plaintext",
+			features: Features{},
+		},
+		{
+			name: "data_plane_tls_rbac",
+			features: Features{
+				EnableDataPlaneTLS:          true,
+				RequireDataPlaneClientCerts: true,
+				EnableRBAC:                  true,
+				ServerListenerUsesRDS:       true,
+			},
+		},
+		{
+			name: "permissive_source_prefixes",
+			features: Features{
+				EnableDataPlaneTLS:          true,
+				RequireDataPlaneClientCerts: true,
+				EnableRBAC:                  true,
+				PermissiveSourcePrefixes:    []string{"35.191.0.0/16", "130.211.0.0/22"},
+			},
+		},
+		{
+			name: "rbac_audit_only_trust_domains",
+			features: Features{
+				EnableDataPlaneTLS:          true,
+				RequireDataPlaneClientCerts: true,
+				EnableRBAC:                  true,
+				RBACAuditOnly:               true,
+				TrustDomains: []tls.TrustDomain{
+					{Name: "example.svc.id.goog"},
+				},
+			},
+		},
+		{
+			name: "federation",
+			features: Features{
+				EnableFederation:      true,
+				ServerListenerUsesRDS: true,
+			},
+		},
+		{
+			name: "federation_namespace_authority",
+			features: Features{
+				EnableFederation: true,
+			},
+			extraApplications: []applications.Application{
+				{
+					Namespace:           "team-a",
+					Authority:           "team-a.xds.example.com",
+					ServiceAccountName:  "greeter-team-a",
+					Name:                "greeter-team-a",
+					ServingPort:         50051,
+					ServingProtocol:     "grpc",
+					HealthCheckPort:     50051,
+					HealthCheckProtocol: "grpc",
+					Endpoints: []applications.ApplicationEndpoints{
+						applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.50"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+					},
+				},
+			},
+		},
+		{
+			name: "federation_additional_authorities",
+			features: Features{
+				EnableFederation: true,
+			},
+			externalBackends: []cds.ExternalBackend{
+				{Name: "external-greeter", Hostname: "greeter.example.com", Port: 50051},
+			},
+			// The application with a namespace authority only uses its own authority name.
+			extraApplications: []applications.Application{
+				{
+					Namespace:           "team-a",
+					Authority:           "team-a.xds.example.com",
+					ServiceAccountName:  "greeter-team-a",
+					Name:                "greeter-team-a",
+					ServingPort:         50051,
+					ServingProtocol:     "grpc",
+					HealthCheckPort:     50051,
+					HealthCheckProtocol: "grpc",
+					Endpoints: []applications.ApplicationEndpoints{
+						applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.50"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+					},
+				},
+			},
+			additionalAuthorities: []string{"control-plane-west.xds.svc.cluster.local"},
+		},
+		{
+			name: "federation_requested_naming",
+			features: Features{
+				EnableFederation: true,
+			},
+			externalBackends: []cds.ExternalBackend{
+				{Name: "external-greeter", Hostname: "greeter.example.com", Port: 50051},
+			},
+			additionalAuthorities: []string{"control-plane-west.xds.svc.cluster.local"},
+			// Clients only requested `xdstp://` names of the additional authority, so the
+			// snapshot contains neither plain names nor names of the default authority.
+			requestedNames: []string{
+				"xdstp://control-plane-west.xds.svc.cluster.local/envoy.config.listener.v3.Listener/greeter-leaf",
+				"grpc/server?xds.resource.listening_address=10.0.0.20:50051",
+			},
+		},
+		{
+			name: "weighted_round_robin",
+			features: Features{
+				EnableWeightedRoundRobin: true,
+			},
+		},
+		{
+			name: "pick_first",
+			features: Features{
+				LoadBalancingPolicy: cds.LoadBalancingPolicyPickFirst,
+			},
+		},
+		{
+			name: "least_request",
+			features: Features{
+				LoadBalancingPolicy: cds.LoadBalancingPolicyLeastRequest,
+			},
+		},
+		{
+			name:     "external_backend",
+			features: Features{},
+			externalBackends: []cds.ExternalBackend{
+				{
+					Name:     "greeter-external",
+					Hostname: "greeter.example.com",
+					Port:     50051,
+				},
+			},
+		},
+		{
+			name: "tcp_proxy",
+			features: Features{
+				EnableTCPProxy: true,
+			},
+			extraApplications: []applications.Application{
+				applications.NewApplication("xds", "redis", 6379, "tcp", 6379, "tcp", []applications.ApplicationEndpoints{
+					applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.30"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+				}),
+			},
+		},
+		{
+			name: "dynamic_forward_proxy",
+			features: Features{
+				EnableDynamicForwardProxy: true,
+			},
+		},
+		{
+			name: "jwt_authn",
+			features: Features{
+				EnableJWTAuthn: true,
+			},
+		},
+		{
+			// The JWT provider uses the external backend Cluster as its JWKS Cluster.
+			name: "jwt_authn_external_backend_jwks_cluster",
+			features: Features{
+				EnableJWTAuthn: true,
+			},
+			externalBackends: []cds.ExternalBackend{
+				{Name: "jwks-google", Hostname: "www.googleapis.com", Port: 443},
+			},
+		},
+		{
+			name: "access_log_grpc",
+			features: Features{
+				AccessLog: lds.AccessLogGRPC,
+			},
+		},
+		{
+			name: "cluster_and_zone",
+			features: Features{
+				LocalityPriorityPolicy: LocalityPriorityPolicyClusterAndZone,
+			},
+			nodeHash: goldenZone + eds.NodeHashSeparator + goldenCluster,
+		},
+		{
+			name: "locality_priority_node_and_zone",
+			features: Features{
+				LocalityPriorityPolicy: LocalityPriorityPolicyNodeAndZone,
+			},
+			nodeHash: goldenZone + eds.NodeHashSeparator + "node-a",
+		},
+		{
+			name: "subset_load_balancing",
+			extraApplications: []applications.Application{
+				applications.NewApplication("xds", "greeter-canary", 50051, "grpc", 50051, "grpc", []applications.ApplicationEndpoints{
+					applications.NewApplicationEndpoints("greeter-canary-5c8d7f9b6-klmno", "node-a", goldenZone, "", []string{"10.0.0.40"}, applications.AddressTypeIPv4, applications.Healthy, map[string]string{"version": "v1"}),
+					applications.NewApplicationEndpoints("greeter-canary-6b9c4d8f7-pqrst", "node-b", "us-central1-b", "", []string{"10.0.1.40"}, applications.AddressTypeIPv4, applications.Healthy, map[string]string{"version": "v2"}),
+				}),
+			},
+		},
+		{
+			name: "nack_simulation",
+			features: Features{
+				NACKSimulationApplications: []string{"greeter-leaf"},
+			},
+		},
+		{
+			name: "maintenance_mode",
+			features: Features{
+				MaintenanceApplications: map[string]string{"greeter-leaf": "RESOURCE_EXHAUSTED"},
+			},
+		},
+		{
+			name: "feature_overrides",
+			features: Features{
+				FeatureOverrides: []FeatureOverride{
+					{
+						Zone:         goldenZone,
+						NodeMetadata: map[string]string{"K8S_NAMESPACE": "xds"},
+						Features:     map[string]interface{}{"loadBalancingPolicy": "pick_first"},
+					},
+					{
+						// Does not match the node hash.
+						NodeMetadata: map[string]string{"K8S_NAMESPACE": "other"},
+						Features:     map[string]interface{}{"enableDynamicForwardProxy": true},
+					},
+				},
+			},
+			nodeHash: goldenZone + ";K8S_NAMESPACE=xds",
+		},
+		{
+			name: "resource_ttl",
+			features: Features{
+				ResourceTTL: 30 * time.Second,
+			},
+		},
+		{
+			name: "unknown_resource_placeholders",
+			features: Features{
+				SynthesizeUnknownResources: true,
+			},
+			// greeter-leaf is in the snapshot, so it does not get a placeholder.
+			placeholderClusterNames: []string{"greeter-leaf", "greeter-missing"},
+		},
+		{
+			name: "locality_priority_disabled",
+			features: Features{
+				DisableLocalityPriority: true,
+				LocalityWeightPolicy:    LocalityWeightPolicyStatic,
+				LocalityWeights:         map[string]uint32{"us-central1-b": 5},
+			},
+		},
+		{
+			name: "locality_weight_static",
+			features: Features{
+				LocalityWeightPolicy: LocalityWeightPolicyStatic,
+				LocalityWeights:      map[string]uint32{"us-central1-b": 5},
+			},
+		},
+		{
+			name: "locality_weight_load_report",
+			features: Features{
+				LocalityWeightPolicy: LocalityWeightPolicyLoadReport,
+			},
+			loadReports: fixtureLoadReports(),
+		},
+		{
+			name:          "route_policies",
+			routePolicies: fixtureRoutePolicies(),
+		},
+		{
+			name: "request_mirroring",
+			extraApplications: []applications.Application{
+				applications.NewApplication("xds", "greeter-canary", 50051, "grpc", 50051, "grpc", []applications.ApplicationEndpoints{
+					applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.40"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+				}),
+			},
+			routePolicies: []rds.RoutePolicy{
+				{
+					Application: "greeter-leaf",
+					Mirrors: []rds.MirrorPolicy{
+						{Cluster: "greeter-canary", Percent: 25},
+						// Mirror policies for clusters that are not in the snapshot are removed.
+						{Cluster: "greeter-missing", Percent: 100},
+					},
+				},
+			},
+		},
+		{
+			name: "path_prefix",
+			extraApplications: []applications.Application{
+				{
+					Namespace:           "xds",
+					ServiceAccountName:  "greeter-prefix",
+					Name:                "greeter-prefix",
+					PathPrefix:          "/helloworld.Greeter/",
+					ServingPort:         50051,
+					ServingProtocol:     "grpc",
+					HealthCheckPort:     50052,
+					HealthCheckProtocol: "grpc",
+					Endpoints: []applications.ApplicationEndpoints{
+						applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.70"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+					},
+				},
+			},
+		},
+		{
+			name: "subject_alt_names",
+			features: Features{
+				EnableDataPlaneTLS: true,
+			},
+			extraApplications: []applications.Application{
+				{
+					Namespace:          "xds",
+					ServiceAccountName: "greeter-external-ca",
+					Name:               "greeter-external-ca",
+					SubjectAltNames: []tls.SubjectAltNameMatcher{
+						{DNS: "greeter-external-ca.xds.svc.cluster.local"},
+						{Regex: `spiffe://example\.com/ns/xds/sa/greeter-.+`},
+					},
+					ServingPort:         50051,
+					ServingProtocol:     "grpc",
+					HealthCheckPort:     50051,
+					HealthCheckProtocol: "grpc",
+					Endpoints: []applications.ApplicationEndpoints{
+						applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.75"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+					},
+				},
+			},
+		},
+		{
+			name: "health_check_grpc_service",
+			extraApplications: []applications.Application{
+				{
+					Namespace:              "xds",
+					ServiceAccountName:     "echo",
+					Name:                   "echo",
+					ServingPort:            50051,
+					ServingProtocol:        "grpc",
+					HealthCheckPort:        50052,
+					HealthCheckProtocol:    "grpc",
+					HealthCheckGRPCService: "echo.Echo",
+					Endpoints: []applications.ApplicationEndpoints{
+						applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.60"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+					},
+				},
+			},
+		},
+		{
+			name: "endpoint_weights",
+			extraApplications: []applications.Application{
+				{
+					Namespace:           "xds",
+					ServiceAccountName:  "greeter-weights",
+					Name:                "greeter-weights",
+					ServingPort:         50051,
+					ServingProtocol:     "grpc",
+					HealthCheckPort:     50051,
+					HealthCheckProtocol: "grpc",
+					Endpoints: []applications.ApplicationEndpoints{
+						// Terminating endpoint with a decaying weight, as set by the `endpointWeightTransition` feature flag.
+						{
+							Pod:            "greeter-weights-6c7b8a9d4-fghij",
+							Node:           "node-b",
+							Zone:           "us-central1-b",
+							Addresses:      []string{"10.0.1.90"},
+							AddressType:    applications.AddressTypeIPv4,
+							EndpointStatus: applications.Healthy,
+							Weight:         60,
+						},
+						// New endpoint that is ramping up.
+						{
+							Pod:            "greeter-weights-7f8d9c6b5-abcde",
+							Node:           "node-a",
+							Zone:           goldenZone,
+							Addresses:      []string{"10.0.0.90"},
+							AddressType:    applications.AddressTypeIPv4,
+							EndpointStatus: applications.Healthy,
+							Weight:         25,
+						},
+					},
+				},
+			},
+		},
+		{
+			name: "address_types",
+			extraApplications: []applications.Application{
+				{
+					Namespace:           "xds",
+					ServiceAccountName:  "greeter-ipv6",
+					Name:                "greeter-ipv6",
+					ServingPort:         50051,
+					ServingProtocol:     "grpc",
+					HealthCheckPort:     50051,
+					HealthCheckProtocol: "grpc",
+					Endpoints: []applications.ApplicationEndpoints{
+						// IPv4-mapped IPv6 address.
+						applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"::ffff:10.0.0.81", "fd00:10::80"}, applications.AddressTypeIPv6, applications.Healthy, nil),
+					},
+				},
+				{
+					Namespace:           "xds",
+					ServiceAccountName:  "greeter-fqdn",
+					Name:                "greeter-fqdn",
+					ServingPort:         50051,
+					ServingProtocol:     "grpc",
+					HealthCheckPort:     50051,
+					HealthCheckProtocol: "grpc",
+					Endpoints: []applications.ApplicationEndpoints{
+						applications.NewApplicationEndpoints("", "", "", "", []string{"greeter.example.com"}, applications.AddressTypeFQDN, applications.Healthy, nil),
+					},
+				},
+			},
+		},
+		{
+			name: "listener_ip_family_ipv6",
+			features: Features{
+				ListenerIPFamily: lds.IPFamilyIPv6,
+			},
+			// The server Listener name keeps the requested host, and the socket address is canonical.
+			grpcServerListenerAddresses: []EndpointAddress{{Host: "0:0:0:0:0:0:0:0", Port: 50051}},
+		},
+		{
+			name: "listener_ip_family_dual_stack",
+			features: Features{
+				ListenerIPFamily: lds.IPFamilyDualStack,
+			},
+		},
+		{
+			name:               "resource_generator",
+			resourceGenerators: []ResourceGenerator{&requestHeaderGenerator{name: "x-workshop-extension", value: "golden"}},
+		},
+		{
+			// Only the backends with a matching Namespace and serving port remain in the routes.
+			name: "grpc_route_backend_refs",
+			routes: []applications.Route{
+				{
+					Namespace: "xds",
+					Name:      "greeter",
+					Hostnames: []string{"greeter"},
+					Rules: []applications.RouteRule{
+						{
+							Matches: []applications.RouteMatch{{Service: "helloworld.Greeter", Method: "SayHello"}},
+							Backends: []applications.RouteBackend{
+								{Name: "greeter-leaf", Namespace: "xds", Port: 50051, Weight: 80},
+								{Name: "greeter-leaf", Namespace: "team-a", Port: 50051, Weight: 20},
+							},
+						},
+						{
+							Backends: []applications.RouteBackend{
+								{Name: "greeter-intermediary", Namespace: "xds", Port: 8080, Weight: 1},
+								{Name: "greeter-leaf", Namespace: "xds", Weight: 1},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	// The tests share a resource memo, to check that memoized Listeners and Clusters are only
+	// reused for the same inputs, and that changes to them do not affect other snapshots.
+	memo := newResourceMemo()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			nodeHash := test.nodeHash
+			if nodeHash == "" {
+				nodeHash = goldenZone
+			}
+			_, localityPriorityMapper := test.features.NodeHash()
+			naming := newRequestedResourceNaming()
+			naming.add(nodeHash, test.requestedNames, false)
+			snapshotBuilder, err := NewSnapshotBuilder(nodeHash, localityPriorityMapper, &test.features, goldenAuthority).
+				WithLoadReports(test.loadReports).
+				WithRoutePolicies(test.routePolicies).
+				WithPlaceholders(test.placeholderClusterNames, nil).
+				WithResourceGenerators(test.resourceGenerators...).
+				WithAdditionalAuthorities(test.additionalAuthorities...).
+				withRequestedNaming(naming.get(nodeHash)).
+				withResourceMemo(memo).
+				AddGRPCApplications(append(fixtureApplications(), test.extraApplications...))
+			if err != nil {
+				t.Fatalf("could not add applications to snapshot builder: %v", err)
+			}
+			snapshotBuilder, err = snapshotBuilder.AddExternalBackends(test.externalBackends)
+			if err != nil {
+				t.Fatalf("could not add external backends to snapshot builder: %v", err)
+			}
+			snapshotBuilder, err = snapshotBuilder.AddGRPCRoutes(test.routes)
+			if err != nil {
+				t.Fatalf("could not add routes to snapshot builder: %v", err)
+			}
+			grpcServerListenerAddresses := test.grpcServerListenerAddresses
+			if grpcServerListenerAddresses == nil {
+				grpcServerListenerAddresses = []EndpointAddress{{Host: "10.0.0.20", Port: 50051}}
+			}
+			snapshot, err := snapshotBuilder.
+				AddGRPCServerListenerAddresses(grpcServerListenerAddresses).
+				AddRBACPolicies(rds.DefaultRBACPolicies()).
+				AddJWTProviders(fixtureJWTProviders()).
+				Build()
+			if err != nil {
+				t.Fatalf("could not build snapshot: %v", err)
+			}
+			got, err := MarshalSnapshotJSON(snapshot)
+			if err != nil {
+				t.Fatalf("could not marshal snapshot: %v", err)
+			}
+			goldenFile := filepath.Join("testdata", test.name+".golden.json")
+			if *update {
+				if err := os.WriteFile(goldenFile, got, 0o644); err != nil {
+					t.Fatalf("could not write golden file %s: %v", goldenFile, err)
+				}
+			}
+			want, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("could not read golden file %s, run with -update to create it: %v", goldenFile, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("snapshot does not match golden file %s, run with -update if the change is intended\ngot:\n%s", goldenFile, got)
+			}
+		})
+	}
+}
+
+// fixtureApplications returns two applications with endpoints in multiple zones and clusters.
+func fixtureApplications() []applications.Application {
+	return []applications.Application{
+		applications.NewApplication("xds", "greeter-intermediary", 50051, "grpc", 50052, "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("greeter-intermediary-7d9f8b6c4-abcde", "node-a", goldenZone, "", []string{"10.0.0.10"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+			applications.NewApplicationEndpoints("greeter-intermediary-7d9f8b6c4-fghij", "node-b", "us-central1-b", "", []string{"10.0.1.10"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+		}),
+		applications.NewApplication("xds", "greeter-leaf", 50051, "grpc", 50052, "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("", "node-a", goldenZone, "", []string{"10.0.0.20", "10.0.0.21"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+			applications.NewApplicationEndpoints("", "node-c", "us-central1-c", "", []string{"10.0.2.20"}, applications.AddressTypeIPv4, applications.Draining, nil),
+			applications.NewApplicationEndpoints("", "node-d", goldenZone, goldenCluster, []string{"10.1.0.20"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+		}),
+	}
+}
+
+// fixtureLoadReports returns load reports with failing requests to the greeter-leaf endpoints
+// in one of the zones.
+func fixtureLoadReports() *eds.LoadReports {
+	loadReports := eds.NewLoadReports()
+	loadReports.Add("greeter-leaf", eds.Locality{Zone: goldenZone}, 90, 10)
+	loadReports.Add("greeter-leaf", eds.Locality{Zone: "us-central1-c"}, 50, 0)
+	return loadReports
+}
+
+// fixtureRoutePolicies returns route policies with a timeout, retries, and fault injection for
+// one method of the greeter-leaf application.
+func fixtureRoutePolicies() []rds.RoutePolicy {
+	return []rds.RoutePolicy{
+		{
+			Application: "greeter-leaf",
+			Routes: []rds.MethodRoute{
+				{
+					Service: "helloworld.Greeter",
+					Method:  "SayHello",
+					Timeout: 2 * time.Second,
+					Retry: &rds.RetryPolicy{
+						NumRetries:   2,
+						RetryOn:      []string{"unavailable", "resource-exhausted"},
+						BaseInterval: 50 * time.Millisecond,
+						MaxInterval:  500 * time.Millisecond,
+					},
+					Fault: &rds.FaultPolicy{
+						Delay:        500 * time.Millisecond,
+						DelayPercent: 10,
+						AbortCode:    "UNAVAILABLE",
+						AbortPercent: 5,
+					},
+				},
+			},
+		},
+	}
+}
+
+func fixtureJWTProviders() []lds.JWTProvider {
+	return []lds.JWTProvider{
+		{
+			Name:        "google",
+			Issuer:      "https://accounts.google.com",
+			Audiences:   []string{"greeter"},
+			JWKSURI:     "https://www.googleapis.com/oauth2/v3/certs",
+			JWKSCluster: "jwks-google",
+		},
+	}
+}
+
+// requestHeaderGenerator is an example extension that adds a request header to all
+// RouteConfigurations in the snapshot.
+type requestHeaderGenerator struct {
+	BaseResourceGenerator
+	name  string
+	value string
+}
+
+func (g *requestHeaderGenerator) GenerateRoutes(_ GeneratorInput, resources *Resources) error {
+	for name, resource := range resources.RouteConfigurations {
+		routeConfiguration, ok := resource.(*routev3.RouteConfiguration)
+		if !ok {
+			return fmt.Errorf("unexpected type %T for RouteConfiguration %s", resource, name)
+		}
+		routeConfiguration.RequestHeadersToAdd = append(routeConfiguration.RequestHeadersToAdd, &corev3.HeaderValueOption{
+			Header: &corev3.HeaderValue{
+				Key:   g.name,
+				Value: g.value,
+			},
+		})
+	}
+	return nil
+}
@@ -18,30 +18,42 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"maps"
 	"net"
+	"slices"
 	"strconv"
 	"strings"
+	"sync"
+	"time"
 
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	streamv3 "github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/metric"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/discovery"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/cds"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/lds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/rds"
 )
 
 // Server listener resource names typically follow the template `grpc/server?xds.resource.listening_address=%s`.
 // serverListenerNamePrefix is the part up to and including the `=` sign.
 var serverListenerNamePrefix = strings.SplitAfter(lds.GRPCServerListenerResourceNameTemplate, "=")[0]
 
+var errNoServerListenerHost = errors.New("server Listener name has no host")
+
 // SnapshotCache stores snapshots of xDS resources in a delegate cache.
 //
 // It handles server listener requests by intercepting Listener stream creation, see `CreateWatch()`.
 // Server listeners addresses from these requests are kept in a map, keyed by the node hash,
-// and with a set of addresses per node hash.
+// and with a set of addresses per node hash. Addresses are removed when all the streams that
+// requested them have closed, see `StreamListenerClosed()`.
 //
 // It also handles propagating snapshots to all node hashes in the cache.
 type SnapshotCache struct {
@@ -53,37 +65,104 @@ type SnapshotCache struct {
 	hash cachev3.NodeHash
 	// localityPriorityMapper constructs a priority map for localities, to be used in EDS ClusterLoadAssignment resources.
 	localityPriorityMapper eds.LocalityPriorityMapper
+	// loadReports stores load reports from gRPC clients, for the `loadReport` locality weight policy.
+	loadReports *eds.LoadReports
 	// appsCache stores the most recent gRPC application configuration information from k8s cluster EndpointSlices.
 	// The appsCache is used to populate new entries (previously unseen `nodeHash`es) in the xDS resource snapshot cache,
 	// so that the new subscribers don't have to wait for an EndpointSlice update before they can receive xDS resources.
 	appsCache *applications.ApplicationCache
+	// routesCache stores the most recent routing rules, e.g., from Kubernetes Gateway API GRPCRoutes.
+	routesCache *applications.RouteCache
 	// grpcServerListenerCache stores known server listener names for each snapshot cache key (`nodeHash`).
-	// These names are captured when new Listener streams are created, see `CreateWatch()`.
+	// These names are captured when new Listener streams are created, see `CreateWatch()`, and removed
+	// when the streams that requested them close, see `StreamListenerClosed()`.
 	// The server listener names are added to xDS resource snapshots, to be included in LDS responded for xDS-enabled gRPC servers.
 	grpcServerListenerCache *GRPCServerListenerCache
+	// nodeHashStreams counts open xDS streams per node hash, to evict idle node hashes, see `EvictIdleNodeHashes()`.
+	nodeHashStreams *nodeHashStreams
+	// featuresMu guards features, rbacPolicies, routePolicies, jwtProviders, externalBackends, fallbackApps,
+	// and maintenanceApplications, which can be replaced at runtime, see `UpdateFeatures()`, `UpdateRBACPolicies()`,
+	// `UpdateRoutePolicies()`, `UpdateJWTProviders()`, `UpdateExternalBackends()`, `UpdateFallbackApplications()`,
+	// and `SetMaintenanceMode()`.
+	featuresMu sync.RWMutex
 	// features contains flags to enable and disable xDS features, e.g., mTLS.
 	features *Features
+	// rbacPolicies are used in the route configuration for server listeners if RBAC is enabled.
+	rbacPolicies []rds.RBACPolicy
+	// routePolicies add per-method routes to the route configurations of applications.
+	routePolicies []rds.RoutePolicy
+	// jwtProviders are used in the JWT authentication HTTP filter for server listeners if JWT authentication is enabled.
+	jwtProviders []lds.JWTProvider
+	// externalBackends are gRPC backends outside the Kubernetes clusters, reached using DNS clusters.
+	externalBackends []cds.ExternalBackend
+	// fallbackApps are used instead of the applications in the appsCache when the appsCache is empty,
+	// e.g., when the informers have not found any EndpointSlices, or when there are no informers.
+	fallbackApps []applications.Application
+	// maintenanceApplications are the applications put in maintenance mode at runtime, in addition to the
+	// applications from the `maintenanceApplications` feature flag, see `SetMaintenanceMode()`.
+	maintenanceApplications map[string]string
+	// blueGreenMu serializes blue/green switchovers, and guards blueGreenTargets.
+	blueGreenMu sync.Mutex
+	// blueGreenTargets are the target applications of blue/green switchovers, by application name,
+	// see `SwitchBlueGreen()`.
+	blueGreenTargets map[string]string
 	// authority is the authority name of this control plane for xDS federation.
 	authority string
+	// additionalAuthorities are the authority names of the additional authority server ports.
+	additionalAuthorities []string
+	// unknownResourceNames stores Cluster and ClusterLoadAssignment names requested by xDS clients
+	// that were not in their snapshots, to add placeholder resources, see `CreateWatch()`.
+	unknownResourceNames *unknownResourceNames
+	// requestedResourceNaming stores the resource naming styles requested by xDS clients, so that
+	// snapshots only contain plain and `xdstp://` names if requested, see `CreateWatch()`.
+	requestedResourceNaming *requestedResourceNaming
+	// unknownResourceRequests counts resource names in CDS and EDS requests that are not in the snapshot.
+	unknownResourceRequests metric.Int64Counter
+	// endpointTransitions tracks new and draining endpoints, for the `endpointWeightTransition` feature flag.
+	endpointTransitions *endpointTransitions
+	// resourceMemo reuses Listeners and Clusters across snapshots and node hashes.
+	resourceMemo *resourceMemo
 }
 
-var _ cachev3.Cache = &SnapshotCache{}
+var (
+	_ cachev3.Cache  = &SnapshotCache{}
+	_ discovery.Sink = &SnapshotCache{}
+)
 
 // NewSnapshotCache creates an xDS resource cache for the provided node hash function.
 //
-// If `allowPartialRequests` is true, the DiscoveryServer will respond to requests for a resource
-// type even if some resources in the snapshot are not named in the request.
-func NewSnapshotCache(ctx context.Context, allowPartialRequests bool, hash cachev3.NodeHash, localityPriorityMapper eds.LocalityPriorityMapper, features *Features, authority string) *SnapshotCache {
+// If `features.AllowPartialRequests()` is true, the DiscoveryServer will respond to requests for a
+// resource type even if some resources in the snapshot are not named in the request.
+//
+// With xDS federation enabled, snapshots contain `xdstp://` resource names for the authority name
+// of the control plane, and for the additional authority names, if any.
+func NewSnapshotCache(ctx context.Context, hash cachev3.NodeHash, localityPriorityMapper eds.LocalityPriorityMapper, features *Features, authority string, additionalAuthorities ...string) *SnapshotCache {
+	logger := logging.FromContext(ctx)
+	unknownResourceRequests, err := newUnknownResourceRequestsCounter()
+	if err != nil {
+		logger.Error(err, "Could not create counter for unknown resource names in xDS requests")
+	}
 	return &SnapshotCache{
 		ctx:                     ctx,
-		logger:                  logging.FromContext(ctx),
-		delegate:                cachev3.NewSnapshotCache(!allowPartialRequests, hash, logging.SnapshotCacheLogger(ctx)),
+		logger:                  logger,
+		delegate:                cachev3.NewSnapshotCacheWithHeartbeating(ctx, !features.AllowPartialRequests(), hash, logging.SnapshotCacheLogger(ctx), resourceTTLHeartbeatInterval),
 		hash:                    hash,
 		localityPriorityMapper:  localityPriorityMapper,
+		loadReports:             eds.NewLoadReports(),
 		appsCache:               applications.NewApplicationCache(),
+		routesCache:             applications.NewRouteCache(),
 		grpcServerListenerCache: NewGRPCServerListenerCache(),
+		nodeHashStreams:         newNodeHashStreams(),
 		features:                features,
+		maintenanceApplications: map[string]string{},
+		blueGreenTargets:        map[string]string{},
 		authority:               authority,
+		additionalAuthorities:   additionalAuthorities,
+		unknownResourceNames:    newUnknownResourceNames(),
+		requestedResourceNaming: newRequestedResourceNaming(),
+		unknownResourceRequests: unknownResourceRequests,
+		endpointTransitions:     newEndpointTransitions(),
+		resourceMemo:            newResourceMemo(),
 	}
 }
 
@@ -98,7 +177,17 @@ func NewSnapshotCache(ctx context.Context, allowPartialRequests bool, hash cache
 //
 // This solves bootstrapping of xDS resources snapshots for xDS-enabled gRPC servers and
 // Envoy proxy instances that fetch configuration dynamically using ADS.
+//
+// For Cluster (CDS) and ClusterLoadAssignment (EDS) requests, CreateWatch logs and counts
+// requested resource names that are not in the snapshot, and optionally adds placeholder
+// resources for these names, see `checkUnknownResourceNames()`.
+//
+// For all requests, CreateWatch records whether the requested resource names are plain names,
+// or `xdstp://` names and their authorities. With xDS federation enabled, snapshots only
+// contain the naming styles requested by the xDS clients of the node hash, and CreateWatch
+// creates a new snapshot when a client requests a naming style for the first time.
 func (c *SnapshotCache) CreateWatch(request *cachev3.Request, state streamv3.StreamState, responses chan cachev3.Response) (cancel func()) {
+	namingChanges := c.addRequestedNaming(request.GetNode(), request.ResourceNames, isWildcardRequest(request.GetTypeUrl(), request.ResourceNames))
 	if isListenerRequest(request) {
 		c.logger.Info("CreateWatch",
 			"typeUrl", request.TypeUrl,
@@ -106,37 +195,151 @@ func (c *SnapshotCache) CreateWatch(request *cachev3.Request, state streamv3.Str
 			"node.cluster", request.Node.Cluster,
 			"node.user_agent_name", request.Node.UserAgentName,
 			"node.id", request.Node.Id)
-		nodeHash := c.hash.ID(request.GetNode())
-		addressesFromRequest, err := findServerListenerAddresses(request.ResourceNames)
-		if err != nil {
-			c.logger.Error(err, "Problem encountered when looking for server listener addresses in new Listener stream request", "nodeHash", nodeHash)
-			return func() {}
-		}
-		changes := c.grpcServerListenerCache.Add(nodeHash, addressesFromRequest)
-		existingSnapshot, err := c.delegate.GetSnapshot(nodeHash)
-		if err != nil || existingSnapshot == nil || changes {
-			apps := c.appsCache.GetAll()
-			if err := c.createNewSnapshot(nodeHash, apps); err != nil {
-				c.logger.Error(err, "Could not set new xDS resource snapshot", "nodeHash", nodeHash, "apps", apps)
-				return func() {}
-			}
-		}
+		c.addServerListeners(request.GetNode(), request.ResourceNames, namingChanges)
+	} else if namingChanges {
+		c.createNewSnapshotForNode(request.GetNode())
+	}
+	if isClusterOrEndpointType(request.GetTypeUrl()) {
+		c.checkUnknownResourceNames(request.GetNode(), request.GetTypeUrl(), request.GetResourceNames())
 	}
+	// Always delegate, even if a new snapshot could not be created, so that the watch responds
+	// from the existing snapshot, or from the next snapshot for the node hash.
 	return c.delegate.CreateWatch(request, state, responses)
 }
 
-// UpdateResources creates a new snapshot for each node hash in the cache,
-// based on the provided gRPC application configuration,
+// addRequestedNaming records the naming styles of the requested resource names for the node
+// hash, and returns true if xDS federation is enabled and any of the naming styles are new.
+func (c *SnapshotCache) addRequestedNaming(node *corev3.Node, resourceNames []string, wildcard bool) bool {
+	nodeHash := c.hash.ID(node)
+	changes := c.requestedResourceNaming.add(nodeHash, resourceNames, wildcard)
+	c.featuresMu.RLock()
+	enableFederation := c.features.ForNodeHash(nodeHash).EnableFederation
+	c.featuresMu.RUnlock()
+	if changes && enableFederation {
+		c.logger.V(2).Info("New resource naming style requested", "nodeHash", nodeHash, "resourceNames", resourceNames, "wildcard", wildcard)
+		return true
+	}
+	return false
+}
+
+// createNewSnapshotForNode creates a new snapshot for the node hash of the node, and logs the
+// error if the snapshot could not be created.
+func (c *SnapshotCache) createNewSnapshotForNode(node *corev3.Node) {
+	nodeHash := c.hash.ID(node)
+	apps := c.apps()
+	if err := c.createNewSnapshot(nodeHash, apps); err != nil {
+		c.logger.Error(err, "Could not set new xDS resource snapshot", "nodeHash", nodeHash, "apps", apps)
+	}
+}
+
+// addServerListeners adds the server listener addresses from the requested Listener names to the
+// cache for the node hash, and creates a new snapshot if there is no snapshot for the node hash,
+// if any addresses are new, or if `force` is true. Invalid server Listener names are logged and
+// skipped, and the Listeners for the other names are still added.
+func (c *SnapshotCache) addServerListeners(node *corev3.Node, resourceNames []string, force bool) {
+	nodeHash := c.hash.ID(node)
+	addressesFromRequest, err := findServerListenerAddresses(resourceNames)
+	if err != nil {
+		c.logger.Info("Warning: skipping invalid server Listener names in Listener request", "nodeHash", nodeHash, "error", err.Error())
+	}
+	changes := c.grpcServerListenerCache.Add(nodeHash, addressesFromRequest)
+	existingSnapshot, err := c.delegate.GetSnapshot(nodeHash)
+	if err != nil || existingSnapshot == nil || changes || force {
+		c.createNewSnapshotForNode(node)
+	}
+}
+
+// StreamListenerRequest records the server listener addresses requested on the stream, so that
+// the addresses can be removed when the stream closes, see `StreamListenerClosed()`.
+// Call this method from the `OnStreamRequest` and `OnStreamDeltaRequest` callbacks of the xDS
+// server, with the resource names to subscribe to and unsubscribe from. For state-of-the-world
+// streams, the subscribed names are the resource names of the request.
+//
+// Clients only send the node in the first request on a stream, so the node hash of the stream is
+// recorded on the first request, regardless of the resource type.
+func (c *SnapshotCache) StreamListenerRequest(stream StreamKey, node *corev3.Node, typeURL string, subscribe []string, unsubscribe []string) {
+	nodeHash, exists := c.grpcServerListenerCache.NodeHash(stream)
+	if node != nil {
+		nodeHash = c.hash.ID(node)
+		exists = true
+	}
+	if !exists {
+		return
+	}
+	if typeURL != resourcev3.ListenerType {
+		c.grpcServerListenerCache.SubscribeStream(stream, nodeHash, nil, false)
+		return
+	}
+	// Invalid server Listener names are skipped, and logged by `CreateWatch()` and `CreateDeltaWatch()`.
+	subscribeAddresses, _ := findServerListenerAddresses(subscribe)
+	c.grpcServerListenerCache.SubscribeStream(stream, nodeHash, subscribeAddresses, !stream.Delta)
+	unsubscribeAddresses, _ := findServerListenerAddresses(unsubscribe)
+	c.grpcServerListenerCache.UnsubscribeStream(stream, unsubscribeAddresses)
+}
+
+// StreamListenerClosed removes the server listener addresses requested on the stream, unless
+// other open streams for the same node hash requested the same addresses, and creates a new
+// snapshot for the node hash if any addresses were removed. Delta (incremental) xDS streams
+// then receive the removed Listeners in `removed_resources`.
+// Call this method from the `OnStreamClosed` and `OnDeltaStreamClosed` callbacks of the xDS server.
+func (c *SnapshotCache) StreamListenerClosed(stream StreamKey) {
+	nodeHash, removed := c.grpcServerListenerCache.RemoveStream(stream)
+	if !removed {
+		return
+	}
+	c.logger.V(2).Info("Removing server listener addresses of closed xDS stream", "nodeHash", nodeHash, "streamID", stream.ID, "delta", stream.Delta)
+	apps := c.apps()
+	if err := c.createNewSnapshot(nodeHash, apps); err != nil {
+		c.logger.Error(err, "Could not set new xDS resource snapshot after removing server listener addresses", "nodeHash", nodeHash)
+	}
+}
+
+// UpdateResources replaces the applications of the discovery source in the namespace, and creates
+// a new snapshot for each node hash in the cache, based on the applications of all sources,
 // with the addition of server listeners and their associated route configurations.
-func (c *SnapshotCache) UpdateResources(_ context.Context, logger logr.Logger, kubecontextName string, namespace string, updatedApps []applications.Application) error {
-	var errs []error
-	changed := c.appsCache.Put(kubecontextName, namespace, updatedApps)
+func (c *SnapshotCache) UpdateResources(_ context.Context, logger logr.Logger, source string, namespace string, updatedApps []applications.Application) error {
+	changed := c.appsCache.Put(source, namespace, updatedApps)
 	if !changed {
 		logger.V(2).Info("No application updates, so not generating new xDS resource snapshots")
 		return nil
 	}
-	apps := c.appsCache.GetAll()
+	apps := c.apps()
 	logger.V(2).Info("Application updates, generating new xDS resource snapshots", "apps", apps)
+	return c.createNewSnapshots(apps)
+}
+
+// EvictKubecontext removes the applications and routes of the kubecontext, e.g., because the
+// Kubernetes cluster is unreachable, and creates a new snapshot for each node hash in the cache.
+func (c *SnapshotCache) EvictKubecontext(_ context.Context, logger logr.Logger, kubecontextName string) error {
+	appsChanged := c.appsCache.DeleteKubecontext(kubecontextName)
+	routesChanged := c.routesCache.DeleteKubecontext(kubecontextName)
+	if !appsChanged && !routesChanged {
+		logger.V(2).Info("No applications or routes to evict, so not generating new xDS resource snapshots")
+		return nil
+	}
+	apps := c.apps()
+	logger.V(2).Info("Evicted applications and routes, generating new xDS resource snapshots", "apps", apps)
+	return c.createNewSnapshots(apps)
+}
+
+// DeleteNamespace removes the applications and routes of the namespace in the kubecontext, e.g.,
+// because the informer for the namespace was stopped, or because the namespace was deleted, and
+// creates a new snapshot for each node hash in the cache.
+func (c *SnapshotCache) DeleteNamespace(_ context.Context, logger logr.Logger, kubecontextName string, namespace string) error {
+	appsChanged := c.appsCache.Delete(kubecontextName, namespace)
+	routesChanged := c.routesCache.Delete(kubecontextName, namespace)
+	if !appsChanged && !routesChanged {
+		logger.V(2).Info("No applications or routes in the namespace, so not generating new xDS resource snapshots", "kubecontext", kubecontextName, "namespace", namespace)
+		return nil
+	}
+	apps := c.apps()
+	logger.V(2).Info("Deleted applications and routes of namespace, generating new xDS resource snapshots", "kubecontext", kubecontextName, "namespace", namespace, "apps", apps)
+	return c.createNewSnapshots(apps)
+}
+
+// createNewSnapshots sets a new snapshot for each node hash in the cache.
+func (c *SnapshotCache) createNewSnapshots(apps []applications.Application) error {
+	var errs []error
 	for _, nodeHash := range c.delegate.GetStatusKeys() {
 		if err := c.createNewSnapshot(nodeHash, apps); err != nil {
 			errs = append(errs, err)
@@ -148,15 +351,127 @@ func (c *SnapshotCache) UpdateResources(_ context.Context, logger logr.Logger, k
 	return nil
 }
 
+// UpdateRoutes creates a new snapshot for each node hash in the cache,
+// based on the provided routing rules and the most recent gRPC application configuration.
+func (c *SnapshotCache) UpdateRoutes(_ context.Context, logger logr.Logger, kubecontextName string, namespace string, updatedRoutes []applications.Route) error {
+	changed := c.routesCache.Put(kubecontextName, namespace, updatedRoutes)
+	if !changed {
+		logger.V(2).Info("No route updates, so not generating new xDS resource snapshots")
+		return nil
+	}
+	apps := c.apps()
+	logger.V(2).Info("Route updates, generating new xDS resource snapshots", "routes", updatedRoutes)
+	return c.createNewSnapshots(apps)
+}
+
+// UpdateFeatures replaces the xDS feature flags, and creates a new snapshot for each
+// node hash in the cache, using the most recent gRPC application configuration.
+func (c *SnapshotCache) UpdateFeatures(_ context.Context, logger logr.Logger, features *Features) error {
+	c.featuresMu.Lock()
+	c.features = features
+	c.featuresMu.Unlock()
+	apps := c.apps()
+	logger.V(2).Info("xDS feature flags updated, generating new xDS resource snapshots", "features", features)
+	return c.createNewSnapshots(apps)
+}
+
+// UpdateRBACPolicies replaces the RBAC policies, and generates new snapshots for all node hashes.
+func (c *SnapshotCache) UpdateRBACPolicies(_ context.Context, logger logr.Logger, policies []rds.RBACPolicy) error {
+	c.featuresMu.Lock()
+	c.rbacPolicies = policies
+	c.featuresMu.Unlock()
+	apps := c.apps()
+	logger.V(2).Info("RBAC policies updated, generating new xDS resource snapshots", "policies", policies)
+	return c.createNewSnapshots(apps)
+}
+
+// UpdateRoutePolicies replaces the route policies, and generates new snapshots for all node hashes.
+func (c *SnapshotCache) UpdateRoutePolicies(_ context.Context, logger logr.Logger, policies []rds.RoutePolicy) error {
+	c.featuresMu.Lock()
+	c.routePolicies = policies
+	c.featuresMu.Unlock()
+	apps := c.apps()
+	logger.V(2).Info("Route policies updated, generating new xDS resource snapshots", "policies", policies)
+	return c.createNewSnapshots(apps)
+}
+
+// UpdateExternalBackends replaces the external backends, and generates new snapshots for all node hashes.
+func (c *SnapshotCache) UpdateExternalBackends(_ context.Context, logger logr.Logger, backends []cds.ExternalBackend) error {
+	c.featuresMu.Lock()
+	c.externalBackends = backends
+	c.featuresMu.Unlock()
+	apps := c.apps()
+	logger.V(2).Info("External backends updated, generating new xDS resource snapshots", "backends", backends)
+	return c.createNewSnapshots(apps)
+}
+
+// UpdateFallbackApplications replaces the fallback applications, and generates new snapshots for all node hashes.
+func (c *SnapshotCache) UpdateFallbackApplications(_ context.Context, logger logr.Logger, apps []applications.Application) error {
+	c.featuresMu.Lock()
+	c.fallbackApps = apps
+	c.featuresMu.Unlock()
+	logger.V(2).Info("Fallback applications updated, generating new xDS resource snapshots", "apps", apps)
+	return c.createNewSnapshots(c.apps())
+}
+
+// apps returns the applications from the informers, or the fallback applications if the informers
+// have not found any applications.
+func (c *SnapshotCache) apps() []applications.Application {
+	if apps := c.appsCache.GetAll(); len(apps) > 0 {
+		return apps
+	}
+	c.featuresMu.RLock()
+	defer c.featuresMu.RUnlock()
+	return c.fallbackApps
+}
+
+// UpdateJWTProviders replaces the JWT providers, and generates new snapshots for all node hashes.
+func (c *SnapshotCache) UpdateJWTProviders(_ context.Context, logger logr.Logger, providers []lds.JWTProvider) error {
+	c.featuresMu.Lock()
+	c.jwtProviders = providers
+	c.featuresMu.Unlock()
+	apps := c.apps()
+	logger.V(2).Info("JWT providers updated, generating new xDS resource snapshots", "providers", providers)
+	return c.createNewSnapshots(apps)
+}
+
 // createNewSnapshot sets a new snapshot for the provided `nodeHash` and gRPC application configuration.
 func (c *SnapshotCache) createNewSnapshot(nodeHash string, apps []applications.Application) error {
 	c.logger.Info("Creating a new snapshot", "nodeHash", nodeHash, "apps", apps)
-	snapshotBuilder, err := NewSnapshotBuilder(nodeHash, c.localityPriorityMapper, c.features, c.authority).AddGRPCApplications(apps)
+	c.featuresMu.RLock()
+	features := c.features
+	rbacPolicies := c.rbacPolicies
+	routePolicies := c.routePolicies
+	jwtProviders := c.jwtProviders
+	externalBackends := c.externalBackends
+	maintenanceApplications := maps.Clone(c.maintenanceApplications)
+	c.featuresMu.RUnlock()
+	placeholderClusterNames, placeholderClusterLoadAssignmentNames := c.unknownResourceNames.get(nodeHash)
+	apps = c.endpointTransitions.apply(apps, time.Now(), features.EndpointWeightTransition)
+	snapshotBuilder, err := NewSnapshotBuilder(nodeHash, c.localityPriorityMapper, features, c.authority).
+		WithLoadReports(c.loadReports).
+		WithRoutePolicies(routePolicies).
+		WithMaintenanceApplications(maintenanceApplications).
+		WithPlaceholders(placeholderClusterNames, placeholderClusterLoadAssignmentNames).
+		WithAdditionalAuthorities(c.additionalAuthorities...).
+		withRequestedNaming(c.requestedResourceNaming.get(nodeHash)).
+		withResourceMemo(c.resourceMemo).
+		AddGRPCApplications(apps)
 	if err != nil {
 		return fmt.Errorf("could not create xDS resource snapshot builder for nodeHash=%s: %w", nodeHash, err)
 	}
+	snapshotBuilder, err = snapshotBuilder.AddExternalBackends(externalBackends)
+	if err != nil {
+		return fmt.Errorf("could not add external backends to xDS resource snapshot builder for nodeHash=%s: %w", nodeHash, err)
+	}
+	snapshotBuilder, err = snapshotBuilder.AddGRPCRoutes(blueGreenPrecedence(c.routesCache.GetAll()))
+	if err != nil {
+		return fmt.Errorf("could not add routes to xDS resource snapshot builder for nodeHash=%s: %w", nodeHash, err)
+	}
 	snapshot, err := snapshotBuilder.
 		AddGRPCServerListenerAddresses(c.grpcServerListenerCache.Get(nodeHash)).
+		AddRBACPolicies(rbacPolicies).
+		AddJWTProviders(jwtProviders).
 		Build()
 	if err != nil {
 		return fmt.Errorf("could not create new xDS resource snapshot for nodeHash=%s: %w", nodeHash, err)
@@ -176,33 +491,99 @@ func isListenerRequest(request *cachev3.Request) bool {
 
 // findServerListenerAddresses looks for server Listener names in the provided
 // slice and extracts the address and port for each server Listener found.
+// Invalid server Listener names are skipped, and the returned error joins the
+// errors of all the invalid names.
+//
+// The hosts are returned as requested, e.g., `0:0:0:0:0:0:0:0` from gRPC Java servers,
+// since the server Listener names must match the requested names. The socket addresses of the
+// server Listeners use the canonical IPv6 representation, see `lds.CreateGRPCServerListener()`.
+// IPv6 hosts must be in brackets, and zones in IPv6 hosts are kept, e.g., `[fe80::1%eth0]:50051`.
 // TODO: Handle xDS federation server Listener names using `xdstp://` names,
 // e.g., "xdstp://xds-authority.example.com/envoy.config.listener.v3.Listener/grpc/server/%s"
 func findServerListenerAddresses(names []string) ([]EndpointAddress, error) {
 	var addresses []EndpointAddress
+	var errs []error
 	for _, name := range names {
-		if strings.HasPrefix(name, serverListenerNamePrefix) && len(name) > len(serverListenerNamePrefix) {
-			hostPort := strings.SplitAfter(name, serverListenerNamePrefix)[1]
-			host, portStr, err := net.SplitHostPort(hostPort)
-			if err != nil {
-				return nil, fmt.Errorf("could not extract host and port from server Listener name=%s: %w", name, err)
-			}
-			port, err := strconv.ParseUint(portStr, 10, 32)
-			if err != nil {
-				return nil, fmt.Errorf("could not extract port from server Listener name: %w", err)
-			}
-			addresses = append(addresses, EndpointAddress{
-				Host: host,
-				Port: uint32(port),
-			})
+		if !strings.HasPrefix(name, serverListenerNamePrefix) || len(name) == len(serverListenerNamePrefix) {
+			continue
+		}
+		address, err := parseServerListenerAddress(name)
+		if err != nil {
+			errs = append(errs, err)
+			continue
 		}
+		addresses = append(addresses, address)
+	}
+	return addresses, errors.Join(errs...)
+}
+
+// parseServerListenerAddress extracts the address and port from a server Listener name.
+func parseServerListenerAddress(name string) (EndpointAddress, error) {
+	hostPort := strings.SplitAfter(name, serverListenerNamePrefix)[1]
+	host, portStr, err := net.SplitHostPort(hostPort)
+	if err != nil {
+		return EndpointAddress{}, fmt.Errorf("could not extract host and port from server Listener name=%s: %w", name, err)
+	}
+	if host == "" {
+		return EndpointAddress{}, fmt.Errorf("%w: name=%s", errNoServerListenerHost, name)
 	}
-	return addresses, nil
+	port, err := strconv.ParseUint(portStr, 10, 32)
+	if err != nil {
+		return EndpointAddress{}, fmt.Errorf("could not extract port from server Listener name=%s: %w", name, err)
+	}
+	return EndpointAddress{
+		Host: host,
+		Port: uint32(port),
+	}, nil
 }
 
-// CreateDeltaWatch just delegates, since gRPC does not support delta/incremental xDS currently.
-// TODO: Handle request for gRPC server Listeners once gRPC implementation support delta/incremental xDS.
+// NodeHashes returns the node hashes that have snapshots in the cache, sorted.
+func (c *SnapshotCache) NodeHashes() []string {
+	nodeHashes := c.delegate.GetStatusKeys()
+	slices.Sort(nodeHashes)
+	return nodeHashes
+}
+
+// Snapshot returns the current snapshot for the node hash.
+func (c *SnapshotCache) Snapshot(nodeHash string) (cachev3.ResourceSnapshot, error) {
+	return c.delegate.GetSnapshot(nodeHash)
+}
+
+// Features returns a copy of the current xDS feature flags.
+func (c *SnapshotCache) Features() Features {
+	c.featuresMu.RLock()
+	defer c.featuresMu.RUnlock()
+	return *c.features
+}
+
+// RegenerateSnapshots generates new snapshots for all node hashes, e.g., to recover from a
+// snapshot that clients rejected.
+func (c *SnapshotCache) RegenerateSnapshots(_ context.Context, logger logr.Logger) error {
+	apps := c.apps()
+	logger.V(2).Info("Regenerating xDS resource snapshots")
+	return c.createNewSnapshots(apps)
+}
+
+// CreateDeltaWatch intercepts delta (incremental) Listener requests, and checks the resource names
+// of Cluster and ClusterLoadAssignment requests, in the same way as `CreateWatch()`, using the
+// subscribed resource names of the stream, before delegating. The delegate cache
+// computes `removed_resources` by comparing the resource versions of the stream to the snapshot,
+// so Listeners removed from the snapshot, e.g., by `StreamListenerClosed()`, are sent as removed.
 func (c *SnapshotCache) CreateDeltaWatch(request *cachev3.DeltaRequest, state streamv3.StreamState, responses chan cachev3.DeltaResponse) (cancel func()) {
+	resourceNames := slices.Sorted(maps.Keys(state.GetSubscribedResourceNames()))
+	namingChanges := c.addRequestedNaming(request.GetNode(), resourceNames, state.IsWildcard())
+	if request.GetTypeUrl() == resourcev3.ListenerType {
+		if len(resourceNames) > 0 || request.GetNode().GetUserAgentName() == "envoy" {
+			c.addServerListeners(request.GetNode(), resourceNames, namingChanges)
+			namingChanges = false
+		}
+	}
+	if namingChanges {
+		c.createNewSnapshotForNode(request.GetNode())
+	}
+	if isClusterOrEndpointType(request.GetTypeUrl()) {
+		c.checkUnknownResourceNames(request.GetNode(), request.GetTypeUrl(), resourceNames)
+	}
 	return c.delegate.CreateDeltaWatch(request, state, responses)
 }
 
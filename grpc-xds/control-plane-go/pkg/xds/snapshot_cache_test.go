@@ -0,0 +1,127 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strconv"
+	"testing"
+	"time"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	resourcev3 "github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	streamv3 "github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/go-logr/logr"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+func TestFindServerListenerAddresses(t *testing.T) {
+	tests := []struct {
+		name    string
+		names   []string
+		want    []EndpointAddress
+		wantErr error
+		// wantAnyErr is true if the error is not a sentinel error, e.g., from `strconv`.
+		wantAnyErr bool
+	}{
+		{
+			name:  "IPv4 and bracketed IPv6 hosts",
+			names: []string{serverListenerNamePrefix + "0.0.0.0:50051", serverListenerNamePrefix + "[::]:50052"},
+			want:  []EndpointAddress{{Host: "0.0.0.0", Port: 50051}, {Host: "::", Port: 50052}},
+		},
+		{
+			name:  "ignores names that are not server Listener names",
+			names: []string{"greeter-leaf", serverListenerNamePrefix},
+		},
+		{
+			name:    "missing host",
+			names:   []string{serverListenerNamePrefix + ":50051"},
+			wantErr: errNoServerListenerHost,
+		},
+		{
+			name:    "invalid port",
+			names:   []string{serverListenerNamePrefix + "0.0.0.0:http"},
+			wantErr: strconv.ErrSyntax,
+		},
+		{
+			name:       "missing port",
+			names:      []string{serverListenerNamePrefix + "0.0.0.0"},
+			wantAnyErr: true,
+		},
+		{
+			name: "skips invalid names and keeps the valid names",
+			names: []string{
+				serverListenerNamePrefix + ":50051",
+				serverListenerNamePrefix + "0.0.0.0:50051",
+				serverListenerNamePrefix + "0.0.0.0:http",
+			},
+			want:    []EndpointAddress{{Host: "0.0.0.0", Port: 50051}},
+			wantErr: errNoServerListenerHost,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := findServerListenerAddresses(test.names)
+			if test.wantErr == nil && !test.wantAnyErr && err != nil {
+				t.Fatalf("findServerListenerAddresses() unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Fatalf("findServerListenerAddresses() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantAnyErr && err == nil {
+				t.Fatalf("findServerListenerAddresses() error = nil, want an error")
+			}
+			if !slices.Equal(got, test.want) {
+				t.Errorf("findServerListenerAddresses() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+// TestCreateWatchInvalidServerListenerName verifies that a Listener request with an invalid
+// server Listener name still creates a watch, and that the watch responds with the Listeners
+// for the valid names.
+func TestCreateWatchInvalidServerListenerName(t *testing.T) {
+	ctx := logging.NewContext(context.Background(), logr.Discard())
+	c := NewSnapshotCache(ctx, ZoneHash{}, eds.LocalityPriorityByZone{}, &Features{}, goldenAuthority)
+	validName := serverListenerNamePrefix + "0.0.0.0:50051"
+	request := &cachev3.Request{
+		Node:          &corev3.Node{Id: "node-a", Locality: &corev3.Locality{Zone: goldenZone}},
+		TypeUrl:       resourcev3.ListenerType,
+		ResourceNames: []string{serverListenerNamePrefix + ":50051", validName},
+	}
+	responses := make(chan cachev3.Response, 1)
+	cancel := c.CreateWatch(request, streamv3.NewStreamState(false, nil), responses)
+	if cancel != nil {
+		defer cancel()
+	}
+	select {
+	case response := <-responses:
+		discoveryResponse, err := response.GetDiscoveryResponse()
+		if err != nil {
+			t.Fatalf("could not get discovery response: %v", err)
+		}
+		if len(discoveryResponse.GetResources()) != 1 {
+			t.Errorf("got %d Listeners, want 1 Listener for name=%s", len(discoveryResponse.GetResources()), validName)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatalf("no response from the watch for the Listener request")
+	}
+}
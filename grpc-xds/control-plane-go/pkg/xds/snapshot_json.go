@@ -0,0 +1,98 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"slices"
+
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+// snapshotResourceTypes are the xDS resource types included in snapshots, in the order of `MarshalSnapshotJSON()`.
+var snapshotResourceTypes = []struct {
+	key     string
+	typeURL string
+}{
+	{key: "listeners", typeURL: resource.ListenerType},
+	{key: "routeConfigurations", typeURL: resource.RouteType},
+	{key: "clusters", typeURL: resource.ClusterType},
+	{key: "clusterLoadAssignments", typeURL: resource.EndpointType},
+}
+
+// MarshalSnapshotJSON returns the resources of the snapshot as indented JSON, grouped by
+// resource type, and sorted by resource name, so that the output is stable. If any resources
+// have a TTL, the output also includes the TTLs, by resource type and name, as `resourceTtls`.
+func MarshalSnapshotJSON(snapshot cachev3.ResourceSnapshot) ([]byte, error) {
+	resourceTTLs := snapshotResourceTTLs(snapshot)
+	var buf bytes.Buffer
+	buf.WriteString("{\n")
+	for i, resourceType := range snapshotResourceTypes {
+		resources := snapshot.GetResources(resourceType.typeURL)
+		names := make([]string, 0, len(resources))
+		for name := range resources {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		jsonResources := make([]json.RawMessage, 0, len(names))
+		for _, name := range names {
+			jsonResource, err := protojson.Marshal(resources[name])
+			if err != nil {
+				return nil, fmt.Errorf("could not marshal xDS resource type=%s name=%s to JSON: %w", resourceType.typeURL, name, err)
+			}
+			jsonResources = append(jsonResources, jsonResource)
+		}
+		jsonResourceList, err := json.MarshalIndent(jsonResources, "  ", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal xDS resources type=%s to JSON: %w", resourceType.typeURL, err)
+		}
+		fmt.Fprintf(&buf, "  %q: %s", resourceType.key, jsonResourceList)
+		if i < len(snapshotResourceTypes)-1 || len(resourceTTLs) > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString("\n")
+	}
+	if len(resourceTTLs) > 0 {
+		jsonResourceTTLs, err := json.MarshalIndent(resourceTTLs, "  ", "  ")
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal xDS resource TTLs to JSON: %w", err)
+		}
+		fmt.Fprintf(&buf, "  %q: %s\n", "resourceTtls", jsonResourceTTLs)
+	}
+	buf.WriteString("}\n")
+	return buf.Bytes(), nil
+}
+
+// snapshotResourceTTLs returns the TTLs of the resources in the snapshot that have a TTL, by
+// resource type key and resource name.
+func snapshotResourceTTLs(snapshot cachev3.ResourceSnapshot) map[string]map[string]string {
+	resourceTTLs := map[string]map[string]string{}
+	for _, resourceType := range snapshotResourceTypes {
+		for name, resourceWithTTL := range snapshot.GetResourcesAndTTL(resourceType.typeURL) {
+			if resourceWithTTL.TTL == nil {
+				continue
+			}
+			if resourceTTLs[resourceType.key] == nil {
+				resourceTTLs[resourceType.key] = map[string]string{}
+			}
+			resourceTTLs[resourceType.key][name] = resourceWithTTL.TTL.String()
+		}
+	}
+	return resourceTTLs
+}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+)
+
+const (
+	// DefaultCertificateProviderInstanceName is used in the `[Down|Up]streamTlsContext`s, and must match
+	// the `certificate_providers` key in the gRPC xDS bootstrap configuration.
+	// Using the same name as the `traffic-director-grpc-bootstrap` tool, but this is not important.
+	// https://github.com/GoogleCloudPlatform/traffic-director-grpc-bootstrap/blob/2a9cf4614b56ec085c391a12f4cc53defaa575ac/main.go#L276
+	DefaultCertificateProviderInstanceName = "google_cloud_private_spiffe"
+	// DefaultIdentityCertificateName is the same certificate name value as Traffic Director uses,
+	// but the certificate name is ignored by gRPC according to gRFC A29.
+	DefaultIdentityCertificateName = "DEFAULT"
+	// DefaultRootCertificateName is the same certificate name value as Traffic Director uses,
+	// but the certificate name is ignored by gRPC according to gRFC A29.
+	DefaultRootCertificateName = "ROOTCA"
+)
+
+// CertificateProvider identifies the certificate provider plugin instance that gRPC clients and
+// servers use for TLS certificates and CA certificates, see
+// [gRFC A29: xDS-Based Security for gRPC Clients and Servers]: https://github.com/grpc/proposal/blob/master/A29-xds-tls-security.md
+type CertificateProvider struct {
+	// InstanceName must match a `certificate_providers` key in the gRPC xDS bootstrap configuration.
+	InstanceName string
+	// IdentityCertificateName is passed to the provider when requesting the workload certificate.
+	IdentityCertificateName string
+	// RootCertificateName is passed to the provider when requesting the CA certificates.
+	RootCertificateName string
+}
+
+// NewCertificateProvider returns a CertificateProvider, using the default values for blank names.
+func NewCertificateProvider(instanceName string, identityCertificateName string, rootCertificateName string) CertificateProvider {
+	provider := CertificateProvider{
+		InstanceName:            instanceName,
+		IdentityCertificateName: identityCertificateName,
+		RootCertificateName:     rootCertificateName,
+	}
+	if provider.InstanceName == "" {
+		provider.InstanceName = DefaultCertificateProviderInstanceName
+	}
+	if provider.IdentityCertificateName == "" {
+		provider.IdentityCertificateName = DefaultIdentityCertificateName
+	}
+	if provider.RootCertificateName == "" {
+		provider.RootCertificateName = DefaultRootCertificateName
+	}
+	return provider
+}
+
+func (p CertificateProvider) identityCertificateInstance() *tlsv3.CertificateProviderPluginInstance {
+	return &tlsv3.CertificateProviderPluginInstance{
+		InstanceName:    p.InstanceName,
+		CertificateName: p.IdentityCertificateName,
+	}
+}
+
+func (p CertificateProvider) rootCertificateInstance() *tlsv3.CertificateProviderPluginInstance {
+	return &tlsv3.CertificateProviderPluginInstance{
+		InstanceName:    p.InstanceName,
+		CertificateName: p.RootCertificateName,
+	}
+}
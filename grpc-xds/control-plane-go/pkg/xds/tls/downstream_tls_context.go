@@ -23,22 +23,20 @@ import (
 // 1. gRPC server TLS certificate provider
 // 2. Envoy static secret name for TLS certificates and private keys
 // 3. Certificate authorities (CAs) to validate gRPC client certificates.
-func CreateDownstreamTLSContext(requireClientCerts bool) *tlsv3.DownstreamTlsContext {
+//
+// If all the `spiffeTrustDomains` have trust bundles, client certificates are required and validated
+// using the SPIFFE certificate validator instead. Only Envoy proxies support this validator.
+func CreateDownstreamTLSContext(certificateProvider CertificateProvider, requireClientCerts bool, spiffeTrustDomains []TrustDomain) (*tlsv3.DownstreamTlsContext, error) {
 	downstreamTLSContext := tlsv3.DownstreamTlsContext{
 		CommonTlsContext: &tlsv3.CommonTlsContext{
 			// AlpnProtocols is ignored by gRPC xDS according to gRFC A29, but Envoy wants it.
 			AlpnProtocols: []string{"h2"},
 			// Set server certificate for gRPC servers:
-			TlsCertificateProviderInstance: &tlsv3.CertificateProviderPluginInstance{
-				InstanceName: certificateProviderInstanceName,
-				// Using the same certificate name value as Traffic Director, but the
-				// certificate name is ignored by gRPC according to gRFC A29.
-				CertificateName: "DEFAULT",
-			},
+			TlsCertificateProviderInstance: certificateProvider.identityCertificateInstance(),
 			// Set server certificate for Envoy:
 			TlsCertificateSdsSecretConfigs: []*tlsv3.SdsSecretConfig{
 				{
-					Name: "downstream_cert", // Match the name in Envoy static_resources.secrets
+					Name: DownstreamCertificateSecretName,
 				},
 			},
 		},
@@ -54,21 +52,25 @@ func CreateDownstreamTLSContext(requireClientCerts bool) *tlsv3.DownstreamTlsCon
 			CombinedValidationContext: &tlsv3.CommonTlsContext_CombinedCertificateValidationContext{
 				// gRPC client config using xDS certificate provider framework:
 				DefaultValidationContext: &tlsv3.CertificateValidationContext{
-					CaCertificateProviderInstance: &tlsv3.CertificateProviderPluginInstance{
-						InstanceName: certificateProviderInstanceName,
-						// Using the same certificate name value as Traffic Director,
-						// but the certificate name is ignored by gRPC, see gRFC A29.
-						CertificateName: "ROOTCA",
-					},
+					CaCertificateProviderInstance: certificateProvider.rootCertificateInstance(),
 				},
 				// Envoy config using static resources, see:
 				// https://www.envoyproxy.io/docs/envoy/latest/configuration/security/secret
 				ValidationContextSdsSecretConfig: &tlsv3.SdsSecretConfig{
-					Name: "downstream_validation", // Match the name in Envoy static_resources.secrets
+					Name: DownstreamValidationSecretName,
 				},
 			},
 		}
 	}
 
-	return &downstreamTLSContext
+	if hasTrustBundles(spiffeTrustDomains) {
+		spiffeValidationContext, err := createSPIFFEValidationContext(spiffeTrustDomains)
+		if err != nil {
+			return nil, err
+		}
+		downstreamTLSContext.RequireClientCertificate = wrapperspb.Bool(true)
+		downstreamTLSContext.CommonTlsContext.ValidationContextType = spiffeValidationContext
+	}
+
+	return &downstreamTLSContext, nil
 }
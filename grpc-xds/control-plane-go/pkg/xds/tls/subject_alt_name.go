@@ -0,0 +1,95 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
+)
+
+var (
+	errSubjectAltNameMatcherFields = errors.New("subject alt name matcher must set exactly one of spiffeId, dns, or regex")
+	errSubjectAltNameSPIFFEID      = errors.New("subject alt name matcher spiffeId must start with spiffe://")
+	errSubjectAltNameRegex         = errors.New("subject alt name matcher regex is invalid")
+)
+
+// SubjectAltNameMatcher matches a subject alternative name (SAN) of the server certificates of an
+// application, for deployments where the default SPIFFE ID pattern, based on the namespace and
+// Kubernetes ServiceAccount name of the application, does not apply. Exactly one field must be set.
+type SubjectAltNameMatcher struct {
+	// SPIFFEID matches a URI SAN exactly, e.g., `spiffe://example.org/ns/xds/sa/greeter`.
+	SPIFFEID string `yaml:"spiffeId"`
+	// DNS matches a DNS SAN exactly, e.g., `greeter-leaf.xds.svc.cluster.local`.
+	DNS string `yaml:"dns"`
+	// Regex matches any SAN using an RE2 regular expression.
+	Regex string `yaml:"regex"`
+}
+
+// Compare orders matchers by SPIFFE ID, DNS name, and regular expression.
+func (m SubjectAltNameMatcher) Compare(n SubjectAltNameMatcher) int {
+	return cmp.Or(
+		strings.Compare(m.SPIFFEID, n.SPIFFEID),
+		strings.Compare(m.DNS, n.DNS),
+		strings.Compare(m.Regex, n.Regex))
+}
+
+// Validate returns an error if the matcher does not set exactly one field, or if the field value
+// is invalid.
+func (m SubjectAltNameMatcher) Validate() error {
+	fields := 0
+	for _, value := range []string{m.SPIFFEID, m.DNS, m.Regex} {
+		if value != "" {
+			fields++
+		}
+	}
+	if fields != 1 {
+		return fmt.Errorf("%w: matcher=%+v", errSubjectAltNameMatcherFields, m)
+	}
+	if m.SPIFFEID != "" && !strings.HasPrefix(m.SPIFFEID, "spiffe://") {
+		return fmt.Errorf("%w: spiffeId=%s", errSubjectAltNameSPIFFEID, m.SPIFFEID)
+	}
+	if m.Regex != "" {
+		if _, err := regexp.Compile(m.Regex); err != nil {
+			return fmt.Errorf("%w: regex=%s: %w", errSubjectAltNameRegex, m.Regex, err)
+		}
+	}
+	return nil
+}
+
+// stringMatcher returns the matcher for the `match_subject_alt_names` field of the validation
+// context. This field matches all SAN types, so DNS matchers can also match URI SANs.
+func (m SubjectAltNameMatcher) stringMatcher() *matcherv3.StringMatcher {
+	switch {
+	case m.SPIFFEID != "":
+		return &matcherv3.StringMatcher{
+			MatchPattern: &matcherv3.StringMatcher_Exact{Exact: m.SPIFFEID},
+		}
+	case m.DNS != "":
+		return &matcherv3.StringMatcher{
+			MatchPattern: &matcherv3.StringMatcher_Exact{Exact: m.DNS},
+		}
+	default:
+		return &matcherv3.StringMatcher{
+			MatchPattern: &matcherv3.StringMatcher_SafeRegex{
+				SafeRegex: &matcherv3.RegexMatcher{Regex: m.Regex},
+			},
+		}
+	}
+}
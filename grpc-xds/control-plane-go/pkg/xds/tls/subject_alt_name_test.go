@@ -0,0 +1,72 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSubjectAltNameMatcherValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		matcher SubjectAltNameMatcher
+		wantErr error
+	}{
+		{
+			name:    "SPIFFE ID",
+			matcher: SubjectAltNameMatcher{SPIFFEID: "spiffe://example.org/ns/xds/sa/greeter"},
+		},
+		{
+			name:    "DNS",
+			matcher: SubjectAltNameMatcher{DNS: "greeter-leaf.xds.svc.cluster.local"},
+		},
+		{
+			name:    "regex",
+			matcher: SubjectAltNameMatcher{Regex: `spiffe://example\.com/ns/xds/sa/greeter-.+`},
+		},
+		{
+			name:    "no fields",
+			matcher: SubjectAltNameMatcher{},
+			wantErr: errSubjectAltNameMatcherFields,
+		},
+		{
+			name:    "multiple fields",
+			matcher: SubjectAltNameMatcher{SPIFFEID: "spiffe://example.org/ns/xds/sa/greeter", DNS: "greeter-leaf.xds.svc.cluster.local"},
+			wantErr: errSubjectAltNameMatcherFields,
+		},
+		{
+			name:    "SPIFFE ID without spiffe scheme",
+			matcher: SubjectAltNameMatcher{SPIFFEID: "https://example.org/ns/xds/sa/greeter"},
+			wantErr: errSubjectAltNameSPIFFEID,
+		},
+		{
+			name:    "invalid regex",
+			matcher: SubjectAltNameMatcher{Regex: "greeter-(.+"},
+			wantErr: errSubjectAltNameRegex,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.matcher.Validate()
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			if !errors.Is(err, test.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
@@ -25,10 +25,17 @@ import (
 
 const (
 	envoyTransportSocketsTLSName = "envoy.transport_sockets.tls"
-	// certificateProviderInstanceName is used in the `[Down|Up]streamTlsContext`s.
-	// Using the same name as the `traffic-director-grpc-bootstrap` tool, but this is not important.
-	// https://github.com/GoogleCloudPlatform/traffic-director-grpc-bootstrap/blob/2a9cf4614b56ec085c391a12f4cc53defaa575ac/main.go#L276
-	certificateProviderInstanceName = "google_cloud_private_spiffe"
+
+	// Names of the secrets in Envoy `static_resources.secrets`, see `bootstrap.RenderEnvoy()`.
+
+	// UpstreamCertificateSecretName is the client certificate of Envoy proxies.
+	UpstreamCertificateSecretName = "upstream_cert"
+	// UpstreamValidationSecretName is the CA certificates to validate server certificates.
+	UpstreamValidationSecretName = "upstream_validation"
+	// DownstreamCertificateSecretName is the server certificate of Envoy proxies.
+	DownstreamCertificateSecretName = "downstream_cert"
+	// DownstreamValidationSecretName is the CA certificates to validate client certificates.
+	DownstreamValidationSecretName = "downstream_validation"
 )
 
 // CreateTransportSocket creates a TLS transport socket for LDS Listeners and CDS Clusters.
@@ -0,0 +1,103 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tls
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
+	"google.golang.org/protobuf/types/known/anypb"
+)
+
+const (
+	envoyTLSCertValidatorSPIFFEName = "envoy.tls.cert_validator.spiffe"
+	// anyTrustDomainRegex matches the trust domain of any SPIFFE ID.
+	anyTrustDomainRegex = "[^/]+"
+)
+
+// TrustDomain is a SPIFFE trust domain that workloads accept peer certificates from.
+type TrustDomain struct {
+	// Name of the trust domain, e.g., `example.svc.id.goog`.
+	Name string `yaml:"name"`
+	// TrustBundleFile is the path to a file containing the root CA certificates of the trust domain,
+	// in PEM format, on the Envoy proxy instances. Optional, and only used by Envoy proxies.
+	TrustBundleFile string `yaml:"trustBundleFile"`
+}
+
+// SPIFFEIDRegex returns a regular expression that matches SPIFFE IDs of Kubernetes workloads in one of
+// the trust domains, or in any trust domain if there are none. `namespace` and `serviceAccount` are
+// regular expressions.
+func SPIFFEIDRegex(trustDomains []TrustDomain, namespace string, serviceAccount string) string {
+	return fmt.Sprintf("spiffe://%s/ns/%s/sa/%s", trustDomainsRegex(trustDomains), namespace, serviceAccount)
+}
+
+func trustDomainsRegex(trustDomains []TrustDomain) string {
+	if len(trustDomains) == 0 {
+		return anyTrustDomainRegex
+	}
+	quoted := make([]string, 0, len(trustDomains))
+	for _, trustDomain := range trustDomains {
+		quoted = append(quoted, regexp.QuoteMeta(trustDomain.Name))
+	}
+	return "(" + strings.Join(quoted, "|") + ")"
+}
+
+// hasTrustBundles returns true iff all the trust domains specify a trust bundle file.
+func hasTrustBundles(trustDomains []TrustDomain) bool {
+	if len(trustDomains) == 0 {
+		return false
+	}
+	for _, trustDomain := range trustDomains {
+		if trustDomain.TrustBundleFile == "" {
+			return false
+		}
+	}
+	return true
+}
+
+// createSPIFFEValidationContext returns a validation context that uses the SPIFFE certificate validator
+// to validate peer certificates using the trust bundle of the trust domain in the peer's SPIFFE ID.
+// This enables mTLS between workloads in different trust domains, e.g., in different clusters.
+//
+// gRPC does not support `custom_validator_config`, so only use this validation context for Envoy proxies. See
+// [SPIFFE certificate validator]: https://www.envoyproxy.io/docs/envoy/latest/api-v3/extensions/transport_sockets/tls/v3/tls_spiffe_validator_config.proto
+func createSPIFFEValidationContext(trustDomains []TrustDomain) (*tlsv3.CommonTlsContext_ValidationContext, error) {
+	spiffeValidatorConfig := tlsv3.SPIFFECertValidatorConfig{}
+	for _, trustDomain := range trustDomains {
+		spiffeValidatorConfig.TrustDomains = append(spiffeValidatorConfig.TrustDomains, &tlsv3.SPIFFECertValidatorConfig_TrustDomain{
+			Name: trustDomain.Name,
+			TrustBundle: &corev3.DataSource{
+				Specifier: &corev3.DataSource_Filename{
+					Filename: trustDomain.TrustBundleFile,
+				},
+			},
+		})
+	}
+	spiffeValidatorTypedConfig, err := anypb.New(&spiffeValidatorConfig)
+	if err != nil {
+		return nil, fmt.Errorf("could not marshall SPIFFE certificate validator config into Any instance: %w", err)
+	}
+	return &tlsv3.CommonTlsContext_ValidationContext{
+		ValidationContext: &tlsv3.CertificateValidationContext{
+			CustomValidatorConfig: &corev3.TypedExtensionConfig{
+				Name:        envoyTLSCertValidatorSPIFFEName,
+				TypedConfig: spiffeValidatorTypedConfig,
+			},
+		},
+	}, nil
+}
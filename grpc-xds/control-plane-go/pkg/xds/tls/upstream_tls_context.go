@@ -15,8 +15,6 @@
 package tls
 
 import (
-	"fmt"
-
 	tlsv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	matcherv3 "github.com/envoyproxy/go-control-plane/envoy/type/matcher/v3"
 )
@@ -25,8 +23,10 @@ import (
 // 1. gRPC client TLS certificate provider
 // 2. Envoy static secret name for TLS certificates and private keys
 // 3. Certificate authorities (CAs) to validate gRPC server certificates, including server authorization.
-// Important: Assumes that the client application k8s Service account name matches the application name!
-func CreateUpstreamTLSContext(namespace string, serviceAccountName string, requireClientCerts bool) *tlsv3.UpstreamTlsContext {
+// If `subjectAltNames` is empty, server certificates must have the SPIFFE ID of the namespace and
+// Kubernetes ServiceAccount name, in one of the trust domains if `trustDomains` is not empty.
+// Otherwise, server certificates must have a SAN that matches one of the `subjectAltNames`.
+func CreateUpstreamTLSContext(certificateProvider CertificateProvider, namespace string, serviceAccountName string, subjectAltNames []SubjectAltNameMatcher, requireClientCerts bool, trustDomains []TrustDomain) *tlsv3.UpstreamTlsContext {
 	//goland:noinspection ALL
 	upstreamTLSContext := tlsv3.UpstreamTlsContext{
 		CommonTlsContext: &tlsv3.CommonTlsContext{
@@ -37,29 +37,16 @@ func CreateUpstreamTLSContext(namespace string, serviceAccountName string, requi
 				CombinedValidationContext: &tlsv3.CommonTlsContext_CombinedCertificateValidationContext{
 					// Validate gRPC server certificates for gRPC clients:
 					DefaultValidationContext: &tlsv3.CertificateValidationContext{
-						CaCertificateProviderInstance: &tlsv3.CertificateProviderPluginInstance{
-							InstanceName: certificateProviderInstanceName,
-							// Using the same certificate name value as Traffic Director,
-							// but the certificate name is ignored by gRPC, see gRFC A29.
-							CertificateName: "ROOTCA",
-						},
+						CaCertificateProviderInstance: certificateProvider.rootCertificateInstance(),
 						// Server authorization (SAN checks):
 						// gRPC-Java as of v1.64.0 does not work correctly with
 						// `match_typed_subject_alt_names`, using deprecated
 						// `match_subject_alt_names` instead, for now.
-						MatchSubjectAltNames: []*matcherv3.StringMatcher{
-							{
-								MatchPattern: &matcherv3.StringMatcher_SafeRegex{
-									SafeRegex: &matcherv3.RegexMatcher{
-										Regex: fmt.Sprintf("spiffe://[^/]+/ns/%s/sa/%s", namespace, serviceAccountName),
-									},
-								},
-							},
-						},
+						MatchSubjectAltNames: subjectAltNameMatchers(namespace, serviceAccountName, subjectAltNames, trustDomains),
 					},
 					// Validate server certificates for Envoy proxy clients:
 					ValidationContextSdsSecretConfig: &tlsv3.SdsSecretConfig{
-						Name: "upstream_validation", // Match the name in Envoy static_resources.secrets
+						Name: UpstreamValidationSecretName,
 					},
 				},
 			},
@@ -68,19 +55,35 @@ func CreateUpstreamTLSContext(namespace string, serviceAccountName string, requi
 
 	if requireClientCerts {
 		// Send client certificate in TLS handshake for gRPC clients:
-		upstreamTLSContext.CommonTlsContext.TlsCertificateProviderInstance = &tlsv3.CertificateProviderPluginInstance{
-			InstanceName: certificateProviderInstanceName,
-			// Using the same certificate name value as Traffic Director, but the
-			// certificate name is ignored by gRPC according to gRFC A29.
-			CertificateName: "DEFAULT",
-		}
+		upstreamTLSContext.CommonTlsContext.TlsCertificateProviderInstance = certificateProvider.identityCertificateInstance()
 		// Send client certificate in TLS handshake for Envoy proxy clients:
 		upstreamTLSContext.CommonTlsContext.TlsCertificateSdsSecretConfigs = []*tlsv3.SdsSecretConfig{
 			{
-				Name: "upstream_cert", // Match the name in Envoy static_resources.secrets
+				Name: UpstreamCertificateSecretName,
 			},
 		}
 	}
 
 	return &upstreamTLSContext
 }
+
+// subjectAltNameMatchers returns the matchers for the SANs of server certificates, defaulting to
+// the SPIFFE ID of the namespace and Kubernetes ServiceAccount name.
+func subjectAltNameMatchers(namespace string, serviceAccountName string, subjectAltNames []SubjectAltNameMatcher, trustDomains []TrustDomain) []*matcherv3.StringMatcher {
+	if len(subjectAltNames) == 0 {
+		return []*matcherv3.StringMatcher{
+			{
+				MatchPattern: &matcherv3.StringMatcher_SafeRegex{
+					SafeRegex: &matcherv3.RegexMatcher{
+						Regex: SPIFFEIDRegex(trustDomains, namespace, serviceAccountName),
+					},
+				},
+			},
+		}
+	}
+	matchers := make([]*matcherv3.StringMatcher, 0, len(subjectAltNames))
+	for _, subjectAltName := range subjectAltNames {
+		matchers = append(matchers, subjectAltName.stringMatcher())
+	}
+	return matchers
+}
@@ -0,0 +1,231 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"slices"
+	"strings"
+	"sync"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+)
+
+const meterName = "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+
+// maxUnknownResourceNamesPerNodeHash limits the number of unknown resource names stored for each
+// node hash, so that xDS clients cannot grow the cache and the snapshots without bounds by
+// requesting arbitrary names.
+const maxUnknownResourceNamesPerNodeHash = 1000
+
+// unknownResourceNames stores the Cluster and ClusterLoadAssignment names that xDS clients
+// requested, but that were not in the snapshot for their node hash. Each name is only reported
+// (logged and counted) once per node hash. Reported names are added to new snapshots as
+// placeholder resources, if the `synthesizeUnknownResources` feature flag is enabled.
+type unknownResourceNames struct {
+	mu sync.Mutex
+	// reportedByNodeHash contains the reported names by node hash and resource type URL.
+	reportedByNodeHash map[string]map[resource.Type]map[string]bool
+	// placeholdersByNodeHash contains the names for placeholder resources by node hash and
+	// resource type URL.
+	placeholdersByNodeHash map[string]map[resource.Type]map[string]bool
+}
+
+func newUnknownResourceNames() *unknownResourceNames {
+	return &unknownResourceNames{
+		reportedByNodeHash:     map[string]map[resource.Type]map[string]bool{},
+		placeholdersByNodeHash: map[string]map[resource.Type]map[string]bool{},
+	}
+}
+
+// report stores the names for the node hash and type URL, up to
+// `maxUnknownResourceNamesPerNodeHash` names for each node hash. It returns the names that were
+// not reported before, and the number of names that were dropped because of the limit.
+func (u *unknownResourceNames) report(nodeHash string, typeURL resource.Type, names []string) ([]string, int) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	namesByTypeURL, exists := u.reportedByNodeHash[nodeHash]
+	if !exists {
+		namesByTypeURL = map[resource.Type]map[string]bool{}
+		u.reportedByNodeHash[nodeHash] = namesByTypeURL
+	}
+	if namesByTypeURL[typeURL] == nil {
+		namesByTypeURL[typeURL] = map[string]bool{}
+	}
+	count := 0
+	for _, namesForTypeURL := range namesByTypeURL {
+		count += len(namesForTypeURL)
+	}
+	var newNames []string
+	dropped := 0
+	for _, name := range names {
+		if namesByTypeURL[typeURL][name] {
+			continue
+		}
+		if count >= maxUnknownResourceNamesPerNodeHash {
+			dropped++
+			continue
+		}
+		namesByTypeURL[typeURL][name] = true
+		newNames = append(newNames, name)
+		count++
+	}
+	return newNames, dropped
+}
+
+// addPlaceholders stores the names for placeholder resources for the node hash and type URL.
+// Only reported names are stored, see `report()`. Returns true if any of the names are new.
+func (u *unknownResourceNames) addPlaceholders(nodeHash string, typeURL resource.Type, names []string) bool {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	namesByTypeURL, exists := u.placeholdersByNodeHash[nodeHash]
+	if !exists {
+		namesByTypeURL = map[resource.Type]map[string]bool{}
+		u.placeholdersByNodeHash[nodeHash] = namesByTypeURL
+	}
+	if namesByTypeURL[typeURL] == nil {
+		namesByTypeURL[typeURL] = map[string]bool{}
+	}
+	changes := false
+	for _, name := range names {
+		if !u.reportedByNodeHash[nodeHash][typeURL][name] || namesByTypeURL[typeURL][name] {
+			continue
+		}
+		namesByTypeURL[typeURL][name] = true
+		changes = true
+	}
+	return changes
+}
+
+// get returns the sorted Cluster and ClusterLoadAssignment names for placeholder resources for
+// the node hash.
+func (u *unknownResourceNames) get(nodeHash string) ([]string, []string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	namesByTypeURL := u.placeholdersByNodeHash[nodeHash]
+	return sortedKeys(namesByTypeURL[resource.ClusterType]), sortedKeys(namesByTypeURL[resource.EndpointType])
+}
+
+// remove deletes the names for the node hash, e.g., when the node hash is evicted.
+func (u *unknownResourceNames) remove(nodeHash string) {
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	delete(u.reportedByNodeHash, nodeHash)
+	delete(u.placeholdersByNodeHash, nodeHash)
+}
+
+func sortedKeys(m map[string]bool) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+// newUnknownResourceRequestsCounter creates the counter of unknown resource names in CDS and EDS
+// requests, counting each name once per node hash, using the global MeterProvider. If the counter cannot be created, it returns a no-op
+// counter, so that the control plane can serve xDS resources without the metric.
+func newUnknownResourceRequestsCounter() (metric.Int64Counter, error) {
+	counter, err := otel.Meter(meterName).Int64Counter("xds.requests.unknown_resources",
+		metric.WithDescription("Number of distinct resource names in CDS and EDS requests that are not in the snapshot of the node hash, by resource type URL."),
+		metric.WithUnit("{resource}"))
+	if err != nil {
+		return noop.Int64Counter{}, err
+	}
+	return counter, nil
+}
+
+// isClusterOrEndpointType determines if the type URL is the type URL of Cluster (CDS) or
+// ClusterLoadAssignment (EDS) resources.
+func isClusterOrEndpointType(typeURL string) bool {
+	return typeURL == resource.ClusterType || typeURL == resource.EndpointType
+}
+
+// findUnknownResourceNames returns the requested resource names that are not in the snapshot
+// for the node hash. Returns nil if there is no snapshot for the node hash yet, or if the
+// request is a wildcard request.
+func (c *SnapshotCache) findUnknownResourceNames(nodeHash string, typeURL string, resourceNames []string) []string {
+	snapshot, err := c.delegate.GetSnapshot(nodeHash)
+	if err != nil || snapshot == nil {
+		return nil
+	}
+	resources := snapshot.GetResources(typeURL)
+	var unknownNames []string
+	for _, name := range resourceNames {
+		if name == "" || name == "*" {
+			continue
+		}
+		if _, exists := resources[name]; !exists {
+			unknownNames = append(unknownNames, name)
+		}
+	}
+	return unknownNames
+}
+
+// checkUnknownResourceNames logs and counts the resource names in CDS and EDS requests that are
+// not in the snapshot for the node hash, e.g., because of configuration skew between the xDS
+// clients and the control plane. Each name is logged and counted once per node hash. If the
+// `synthesizeUnknownResources` feature flag is enabled, it also creates a new snapshot with
+// placeholder resources for the unknown names, so that clients fail fast instead of waiting for
+// resources that do not exist.
+//
+// For state-of-the-world requests, the resource names are the names of the request. For delta
+// requests, the resource names are the subscribed names of the stream.
+func (c *SnapshotCache) checkUnknownResourceNames(node *corev3.Node, typeURL string, resourceNames []string) {
+	nodeHash := c.hash.ID(node)
+	unknownNames := c.findUnknownResourceNames(nodeHash, typeURL, resourceNames)
+	if len(unknownNames) == 0 {
+		return
+	}
+	newNames, dropped := c.unknownResourceNames.report(nodeHash, typeURL, unknownNames)
+	if dropped > 0 {
+		c.logger.Info("Warning: too many unknown resource names requested for node hash, ignoring the remaining names",
+			"nodeHash", nodeHash,
+			"typeUrl", typeURL,
+			"limit", maxUnknownResourceNamesPerNodeHash,
+			"ignored", dropped)
+	}
+	if len(newNames) > 0 {
+		c.logger.V(1).Info("xDS client requested resources that are not in the snapshot",
+			"nodeHash", nodeHash,
+			"typeUrl", typeURL,
+			"resourceNames", newNames,
+			"node.id", node.GetId())
+		c.unknownResourceRequests.Add(c.ctx, int64(len(newNames)), metric.WithAttributes(attribute.String("type_url", typeURL)))
+	}
+	c.featuresMu.RLock()
+	synthesize := c.features.ForNodeHash(nodeHash).SynthesizeUnknownResources
+	c.featuresMu.RUnlock()
+	if !synthesize {
+		return
+	}
+	// Placeholders with `xdstp://` names would need the authority and resource name templates of
+	// the application, so only unknown names without an authority get placeholders.
+	placeholderNames := slices.DeleteFunc(unknownNames, func(name string) bool {
+		return strings.HasPrefix(name, "xdstp://")
+	})
+	if !c.unknownResourceNames.addPlaceholders(nodeHash, typeURL, placeholderNames) {
+		return
+	}
+	apps := c.apps()
+	if err := c.createNewSnapshot(nodeHash, apps); err != nil {
+		c.logger.Error(err, "Could not set new xDS resource snapshot with placeholder resources", "nodeHash", nodeHash, "resourceNames", placeholderNames)
+	}
+}
@@ -0,0 +1,163 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"testing"
+
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	streamv3 "github.com/envoyproxy/go-control-plane/pkg/server/stream/v3"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+// fakeCounter sums the values added to the counter.
+type fakeCounter struct {
+	noop.Int64Counter
+	sum int64
+}
+
+func (f *fakeCounter) Add(_ context.Context, incr int64, _ ...metric.AddOption) {
+	f.sum += incr
+}
+
+// newUnknownResourcesTestCache returns a cache with an empty snapshot for the node hash
+// `goldenZone`, and a fake counter of unknown resource names.
+func newUnknownResourcesTestCache(t *testing.T, features *Features) (*SnapshotCache, *fakeCounter) {
+	t.Helper()
+	ctx := logging.NewContext(context.Background(), logr.Discard())
+	c := NewSnapshotCache(ctx, ZoneHash{}, eds.LocalityPriorityByZone{}, features, goldenAuthority)
+	counter := &fakeCounter{}
+	c.unknownResourceRequests = counter
+	snapshot, err := cachev3.NewSnapshot("1", nil)
+	if err != nil {
+		t.Fatalf("could not create snapshot: %v", err)
+	}
+	if err := c.delegate.SetSnapshot(ctx, goldenZone, snapshot); err != nil {
+		t.Fatalf("could not set snapshot: %v", err)
+	}
+	return c, counter
+}
+
+func TestUnknownResourceNamesReport(t *testing.T) {
+	u := newUnknownResourceNames()
+	newNames, dropped := u.report(goldenZone, resource.ClusterType, []string{"cluster-a", "cluster-b"})
+	if !slices.Equal(newNames, []string{"cluster-a", "cluster-b"}) || dropped != 0 {
+		t.Errorf("first report() = %v, %d, want [cluster-a cluster-b], 0", newNames, dropped)
+	}
+	newNames, dropped = u.report(goldenZone, resource.ClusterType, []string{"cluster-a", "cluster-c"})
+	if !slices.Equal(newNames, []string{"cluster-c"}) || dropped != 0 {
+		t.Errorf("second report() = %v, %d, want [cluster-c], 0", newNames, dropped)
+	}
+	if newNames, _ := u.report("other-zone", resource.ClusterType, []string{"cluster-a"}); len(newNames) != 1 {
+		t.Errorf("report() for other node hash = %v, want [cluster-a]", newNames)
+	}
+	var many []string
+	for i := range maxUnknownResourceNamesPerNodeHash {
+		many = append(many, fmt.Sprintf("endpoints-%d", i))
+	}
+	newNames, dropped = u.report(goldenZone, resource.EndpointType, many)
+	if wantNew := maxUnknownResourceNamesPerNodeHash - 3; len(newNames) != wantNew || dropped != 3 {
+		t.Errorf("report() over the limit = %d names, %d dropped, want %d names, 3 dropped", len(newNames), dropped, wantNew)
+	}
+	if u.addPlaceholders(goldenZone, resource.EndpointType, []string{"not-reported"}) {
+		t.Errorf("addPlaceholders() for name that was not reported = true, want false")
+	}
+	if !u.addPlaceholders(goldenZone, resource.ClusterType, []string{"cluster-a"}) {
+		t.Errorf("addPlaceholders() for reported name = false, want true")
+	}
+	if clusterNames, _ := u.get(goldenZone); !slices.Equal(clusterNames, []string{"cluster-a"}) {
+		t.Errorf("get() cluster names = %v, want [cluster-a]", clusterNames)
+	}
+	u.remove(goldenZone)
+	if newNames, _ := u.report(goldenZone, resource.ClusterType, []string{"cluster-a"}); len(newNames) != 1 {
+		t.Errorf("report() after remove() = %v, want [cluster-a]", newNames)
+	}
+}
+
+func TestCheckUnknownResourceNames(t *testing.T) {
+	node := &corev3.Node{Id: "node-a", Locality: &corev3.Locality{Zone: goldenZone}}
+	tests := []struct {
+		name string
+		// watch creates and cancels a watch for the resource names. The delegate cache responds
+		// immediately, without a watch to cancel, if it can respond from the snapshot.
+		watch func(c *SnapshotCache, resourceNames []string)
+	}{
+		{
+			name: "state of the world",
+			watch: func(c *SnapshotCache, resourceNames []string) {
+				cancel := c.CreateWatch(&cachev3.Request{
+					Node:          node,
+					TypeUrl:       resource.ClusterType,
+					ResourceNames: resourceNames,
+				}, streamv3.NewStreamState(false, nil), make(chan cachev3.Response, 1))
+				if cancel != nil {
+					cancel()
+				}
+			},
+		},
+		{
+			name: "delta",
+			watch: func(c *SnapshotCache, resourceNames []string) {
+				state := streamv3.NewStreamState(false, nil)
+				subscribed := map[string]struct{}{}
+				for _, name := range resourceNames {
+					subscribed[name] = struct{}{}
+				}
+				state.SetSubscribedResourceNames(subscribed)
+				cancel := c.CreateDeltaWatch(&cachev3.DeltaRequest{
+					Node:                   node,
+					TypeUrl:                resource.ClusterType,
+					ResourceNamesSubscribe: resourceNames,
+				}, state, make(chan cachev3.DeltaResponse, 1))
+				if cancel != nil {
+					cancel()
+				}
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name+"/counts each name once", func(t *testing.T) {
+			c, counter := newUnknownResourcesTestCache(t, &Features{})
+			test.watch(c, []string{"cluster-a"})
+			test.watch(c, []string{"cluster-a", "cluster-b"})
+			if counter.sum != 2 {
+				t.Errorf("unknown resource names counter = %d, want 2", counter.sum)
+			}
+			if clusterNames, _ := c.unknownResourceNames.get(goldenZone); len(clusterNames) != 0 {
+				t.Errorf("placeholder names without synthesizeUnknownResources = %v, want none", clusterNames)
+			}
+		})
+		t.Run(test.name+"/synthesizes placeholders", func(t *testing.T) {
+			c, counter := newUnknownResourcesTestCache(t, &Features{SynthesizeUnknownResources: true})
+			test.watch(c, []string{"cluster-a", "xdstp://" + goldenAuthority + "/envoy.config.cluster.v3.Cluster/cluster-b"})
+			if counter.sum != 2 {
+				t.Errorf("unknown resource names counter = %d, want 2", counter.sum)
+			}
+			if clusterNames, _ := c.unknownResourceNames.get(goldenZone); !slices.Equal(clusterNames, []string{"cluster-a"}) {
+				t.Errorf("placeholder names = %v, want [cluster-a]", clusterNames)
+			}
+		})
+	}
+}
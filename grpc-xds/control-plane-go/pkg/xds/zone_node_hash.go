@@ -0,0 +1,40 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xds
+
+import (
+	corev3 "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
+	cachev3 "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+// NodeMetadataNodeName is the key of the node metadata field in the gRPC xDS
+// bootstrap configuration that contains the name of the Kubernetes node of the client.
+const NodeMetadataNodeName = "K8S_NODE_NAME"
+
+// ZoneNodeHash uses `[locality.zone]/[metadata.K8S_NODE_NAME]` as the node hash,
+// so all xDS clients on the same Kubernetes node access the same cache snapshot.
+type ZoneNodeHash struct{}
+
+var _ cachev3.NodeHash = &ZoneNodeHash{}
+
+func (ZoneNodeHash) ID(node *corev3.Node) string {
+	if node == nil {
+		return eds.NodeHashSeparator
+	}
+	nodeName := node.GetMetadata().GetFields()[NodeMetadataNodeName].GetStringValue()
+	return node.GetLocality().GetZone() + eds.NodeHashSeparator + nodeName
+}
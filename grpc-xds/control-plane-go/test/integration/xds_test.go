@@ -0,0 +1,303 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package integration runs the xDS control plane, xDS-enabled gRPC clients, and greeter
+// servers in-process, with fake Kubernetes EndpointSlices and fallback applications, to check that the clients
+// accept the xDS resources and send RPCs to the expected servers.
+package integration
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"strconv"
+	"testing"
+	"time"
+
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	serverv3 "github.com/envoyproxy/go-control-plane/pkg/server/v3"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/xds"
+	corev1 "k8s.io/api/core/v1"
+	discoveryv1 "k8s.io/api/discovery/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/applications"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/informers"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/logging"
+	xdscontrolplane "github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/bootstrap"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/control-plane-go/pkg/xds/eds"
+)
+
+const (
+	authority   = "control-plane.xds.svc.cluster.local"
+	namespace   = "xds"
+	serviceName = "greeter-leaf"
+	zone        = "us-central1-a"
+	// timeout is how long to wait for RPCs to reach the expected servers.
+	timeout = 20 * time.Second
+)
+
+// TestSayHelloReachesEndpoints serves fake EndpointSlices for two greeter servers, and checks that
+// an xDS-enabled gRPC client sends RPCs to both servers, and then only to the remaining server
+// after one server is removed from the EndpointSlice.
+func TestSayHelloReachesEndpoints(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+
+	features := &xdscontrolplane.Features{}
+	xdsCache := xdscontrolplane.NewSnapshotCache(ctx, xdscontrolplane.ZoneHash{}, eds.LocalityPriorityByZone{}, features, authority)
+	controlPlaneAddr := startControlPlane(t, ctx, xdsCache)
+
+	port := startGreeter(t, "127.0.0.1", 0, "greeter-1")
+	startGreeter(t, "127.0.0.2", port, "greeter-2")
+
+	clientset := fake.NewSimpleClientset()
+	manager := informers.NewManagerForClients("", clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if err := manager.Start(ctx, logr.Discard(), xdsCache); err != nil {
+		t.Fatalf("could not start informer manager: %v", err)
+	}
+	if err := manager.AddEndpointSliceInformer(ctx, logr.Discard(), informers.Config{
+		Namespace: namespace,
+		Services:  []string{serviceName},
+	}); err != nil {
+		t.Fatalf("could not add EndpointSlice informer: %v", err)
+	}
+	endpointSlices := clientset.DiscoveryV1().EndpointSlices(namespace)
+	endpointSlice := newEndpointSlice(port, "127.0.0.1", "127.0.0.2")
+	if _, err := endpointSlices.Create(ctx, endpointSlice, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("could not create EndpointSlice: %v", err)
+	}
+
+	client := newGreeterClient(t, controlPlaneAddr, features)
+	waitForGreeters(ctx, t, client, map[string]bool{"greeter-1": true, "greeter-2": true})
+
+	endpointSlice = newEndpointSlice(port, "127.0.0.2")
+	if _, err := endpointSlices.Update(ctx, endpointSlice, metav1.UpdateOptions{}); err != nil {
+		t.Fatalf("could not update EndpointSlice: %v", err)
+	}
+	waitForGreeters(ctx, t, client, map[string]bool{"greeter-2": true})
+}
+
+// TestSayHelloReachesFallbackEndpoints serves a fallback application for one greeter server, and
+// checks that an xDS-enabled gRPC client sends RPCs to that server until an EndpointSlice for the
+// other server replaces the fallback application.
+func TestSayHelloReachesFallbackEndpoints(t *testing.T) {
+	if testing.Short() {
+		t.Skip("skipping integration test in short mode")
+	}
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+
+	features := &xdscontrolplane.Features{}
+	xdsCache := xdscontrolplane.NewSnapshotCache(ctx, xdscontrolplane.ZoneHash{}, eds.LocalityPriorityByZone{}, features, authority)
+	controlPlaneAddr := startControlPlane(t, ctx, xdsCache)
+
+	port := startGreeter(t, "127.0.0.1", 0, "greeter-1")
+	startGreeter(t, "127.0.0.2", port, "greeter-2")
+
+	fallbackApps := []applications.Application{
+		applications.NewApplication(namespace, serviceName, uint32(port), "grpc", uint32(port), "grpc", []applications.ApplicationEndpoints{
+			applications.NewApplicationEndpoints("", "", zone, "", []string{"127.0.0.1"}, applications.AddressTypeIPv4, applications.Healthy, nil),
+		}),
+	}
+	if err := xdsCache.UpdateFallbackApplications(ctx, logr.Discard(), fallbackApps); err != nil {
+		t.Fatalf("could not set fallback applications: %v", err)
+	}
+
+	client := newGreeterClient(t, controlPlaneAddr, features)
+	waitForGreeters(ctx, t, client, map[string]bool{"greeter-1": true})
+
+	clientset := fake.NewSimpleClientset()
+	manager := informers.NewManagerForClients("", clientset, dynamicfake.NewSimpleDynamicClient(runtime.NewScheme()))
+	if err := manager.Start(ctx, logr.Discard(), xdsCache); err != nil {
+		t.Fatalf("could not start informer manager: %v", err)
+	}
+	if err := manager.AddEndpointSliceInformer(ctx, logr.Discard(), informers.Config{
+		Namespace: namespace,
+		Services:  []string{serviceName},
+	}); err != nil {
+		t.Fatalf("could not add EndpointSlice informer: %v", err)
+	}
+	endpointSlice := newEndpointSlice(port, "127.0.0.2")
+	if _, err := clientset.DiscoveryV1().EndpointSlices(namespace).Create(ctx, endpointSlice, metav1.CreateOptions{}); err != nil {
+		t.Fatalf("could not create EndpointSlice: %v", err)
+	}
+	waitForGreeters(ctx, t, client, map[string]bool{"greeter-2": true})
+}
+
+// startControlPlane serves the xDS management services for the snapshot cache on an ephemeral port.
+func startControlPlane(t *testing.T, ctx context.Context, xdsCache *xdscontrolplane.SnapshotCache) string {
+	t.Helper()
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("could not create control plane TCP listener: %v", err)
+	}
+	server := grpc.NewServer()
+	discoveryv3.RegisterAggregatedDiscoveryServiceServer(server, serverv3.NewServer(ctx, xdsCache, nil))
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return listener.Addr().String()
+}
+
+// startGreeter serves a greeter that includes its name in replies. Port 0 means an ephemeral port.
+func startGreeter(t *testing.T, host string, port int, name string) int {
+	t.Helper()
+	listener, err := net.Listen("tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	if err != nil {
+		t.Skipf("could not create greeter TCP listener on %s:%d, the test requires multiple loopback addresses: %v", host, port, err)
+	}
+	server := grpc.NewServer()
+	helloworldpb.RegisterGreeterServer(server, &greeter{name: name})
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return listener.Addr().(*net.TCPAddr).Port
+}
+
+type greeter struct {
+	helloworldpb.UnimplementedGreeterServer
+	name string
+}
+
+func (g *greeter) SayHello(_ context.Context, request *helloworldpb.HelloRequest) (*helloworldpb.HelloReply, error) {
+	return &helloworldpb.HelloReply{
+		Message: g.name,
+	}, nil
+}
+
+// newGreeterClient creates an xDS-enabled gRPC client for the greeter service, using a gRPC xDS
+// bootstrap configuration rendered in-process for the control plane address.
+func newGreeterClient(t *testing.T, controlPlaneAddr string, features *xdscontrolplane.Features) helloworldpb.GreeterClient {
+	t.Helper()
+	bootstrapConfig, err := bootstrap.Render(bootstrap.Options{
+		Authority:   authority,
+		ServerURI:   "passthrough:///" + controlPlaneAddr,
+		NodeID:      "integration-test-client",
+		NodeCluster: "integration-test",
+		Zone:        zone,
+		XDSFeatures: features,
+	})
+	if err != nil {
+		t.Fatalf("could not render gRPC xDS bootstrap configuration: %v", err)
+	}
+	resolverBuilder, err := xds.NewXDSResolverWithConfigForTesting(bootstrapConfig)
+	if err != nil {
+		t.Fatalf("could not create xDS resolver: %v", err)
+	}
+	clientConn, err := grpc.NewClient("xds:///"+serviceName,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithResolvers(resolverBuilder))
+	if err != nil {
+		t.Fatalf("could not create gRPC client: %v", err)
+	}
+	t.Cleanup(func() {
+		_ = clientConn.Close()
+	})
+	return helloworldpb.NewGreeterClient(clientConn)
+}
+
+func newEndpointSlice(port int, addresses ...string) *discoveryv1.EndpointSlice {
+	portName := "grpc"
+	portNumber := int32(port)
+	appProtocol := "grpc"
+	protocol := corev1.ProtocolTCP
+	ready := true
+	endpointZone := zone
+	endpointSlice := &discoveryv1.EndpointSlice{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      serviceName + "-abcde",
+			Namespace: namespace,
+			Labels: map[string]string{
+				discoveryv1.LabelServiceName: serviceName,
+			},
+		},
+		AddressType: discoveryv1.AddressTypeIPv4,
+		Ports: []discoveryv1.EndpointPort{
+			{
+				Name:        &portName,
+				Port:        &portNumber,
+				Protocol:    &protocol,
+				AppProtocol: &appProtocol,
+			},
+		},
+	}
+	for _, address := range addresses {
+		endpointSlice.Endpoints = append(endpointSlice.Endpoints, discoveryv1.Endpoint{
+			Addresses: []string{address},
+			Conditions: discoveryv1.EndpointConditions{
+				Ready:   &ready,
+				Serving: &ready,
+			},
+			Zone: &endpointZone,
+		})
+	}
+	return endpointSlice
+}
+
+// waitForGreeters sends RPCs until a batch of RPCs reaches exactly the expected greeters.
+func waitForGreeters(ctx context.Context, t *testing.T, client helloworldpb.GreeterClient, want map[string]bool) {
+	t.Helper()
+	deadline := time.Now().Add(timeout)
+	var got map[string]bool
+	var lastErr error
+	for time.Now().Before(deadline) {
+		got, lastErr = sayHelloBatch(ctx, client, 10*len(want))
+		if lastErr == nil && equalSets(got, want) {
+			return
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for RPCs to reach greeters %v, got %v, last error: %v", want, got, lastErr)
+}
+
+// sayHelloBatch sends RPCs and returns the set of greeters that replied.
+func sayHelloBatch(ctx context.Context, client helloworldpb.GreeterClient, count int) (map[string]bool, error) {
+	greeters := map[string]bool{}
+	for range count {
+		rpcCtx, cancel := context.WithTimeout(ctx, time.Second)
+		reply, err := client.SayHello(rpcCtx, &helloworldpb.HelloRequest{Name: "integration-test"})
+		cancel()
+		if err != nil {
+			return greeters, fmt.Errorf("SayHello RPC failed: %w", err)
+		}
+		greeters[reply.GetMessage()] = true
+	}
+	return greeters, nil
+}
+
+func equalSets(a map[string]bool, b map[string]bool) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for key := range a {
+		if !b[key] {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,34 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command channelz-summary connects to the admin services of a greeter application,
+// and summarizes the Channelz channels, subchannels, and sockets per target,
+// including the number of RPCs sent to each address.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/channelzsummary"
+)
+
+func main() {
+	if err := channelzsummary.Run(context.Background(), flag.CommandLine, os.Args[1:]); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
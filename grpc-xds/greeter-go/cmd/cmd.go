@@ -18,9 +18,13 @@ import (
 	"context"
 	"flag"
 	"fmt"
+	"os"
+	"time"
 
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greeter"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/preflight"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/server"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/signals"
 )
@@ -28,6 +32,8 @@ import (
 func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
 	ctx = signals.SetupSignalHandler(ctx)
 	logging.InitFlags(flagset)
+	runPreflight := flagset.Bool("preflight", false, "check the xDS bootstrap configuration, certificate files, and control plane connectivity, print a report, and exit")
+	preflightTimeout := flagset.Duration("preflight-timeout", 10*time.Second, "deadline of each network check in preflight mode")
 	if err := flagset.Parse(args); err != nil {
 		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
 	}
@@ -38,6 +44,12 @@ func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
 	if err != nil {
 		return fmt.Errorf("could not configure greeter server listening port: %w", err)
 	}
+	if *runPreflight {
+		return preflight.Run(ctx, os.Stdout, preflight.Options{
+			ServingPort: servingPort,
+			Timeout:     *preflightTimeout,
+		})
+	}
 	healthPort, err := config.HealthPort()
 	if err != nil {
 		return fmt.Errorf("could not configure greeter server gRPC health check port: %w", err)
@@ -46,13 +58,96 @@ func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
 	if err != nil {
 		return fmt.Errorf("could not configure greeter server HTTP health check port: %w", err)
 	}
+	httpGatewayPort, err := config.HTTPGatewayPort()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter server HTTP gateway port: %w", err)
+	}
+	nextHopTimeout, err := config.NextHopTimeout()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter client timeout: %w", err)
+	}
+	nextHopHedgingMaxAttempts, err := config.NextHopHedgingMaxAttempts()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter client hedging max attempts: %w", err)
+	}
+	nextHopHedgingDelay, err := config.NextHopHedgingDelay()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter client hedging delay: %w", err)
+	}
+	nextHopRetryMaxAttempts, err := config.NextHopRetryMaxAttempts()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter client retry max attempts: %w", err)
+	}
+	nextHopRetryInitialBackoff, err := config.NextHopRetryInitialBackoff()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter client retry initial backoff: %w", err)
+	}
+	nextHopRetryMaxBackoff, err := config.NextHopRetryMaxBackoff()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter client retry max backoff: %w", err)
+	}
+	nextHopPickPolicy, err := greeter.ParsePickPolicy(config.NextHopPolicy())
+	if err != nil {
+		return fmt.Errorf("could not configure greeter client next hop policy: %w", err)
+	}
+	applicationUtilization, err := config.ApplicationUtilization()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter server ORCA application utilization: %w", err)
+	}
+	gracefulShutdownTimeout, err := config.GracefulShutdownTimeout()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter server graceful shutdown timeout: %w", err)
+	}
+	drainInterval, err := config.DrainInterval()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter server drain interval: %w", err)
+	}
+	faultInjection, err := config.FaultInjection()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter server fault injection: %w", err)
+	}
+	certificateExpiryWarningThreshold, err := config.CertificateExpiryWarningThreshold()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter server certificate expiry warning threshold: %w", err)
+	}
+	grpcServer, err := config.GRPCServerConfig()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter gRPC server parameters: %w", err)
+	}
+	useXDSCredentials, err := config.UseXDSCredentials()
+	if err != nil {
+		return fmt.Errorf("could not configure greeter server xDS credentials: %w", err)
+	}
+	zone := config.Zone(ctx)
 	serverConfig := server.Config{
-		ServingPort:    servingPort,
-		HealthPort:     healthPort,
-		HTTPHealthPort: httpHealthPort,
-		GreeterName:    config.GreeterName(ctx),
-		NextHop:        config.NextHop(),
-		UseXDS:         config.UseXDS(),
+		ServingPort:     servingPort,
+		HealthPort:      healthPort,
+		HTTPHealthPort:  httpHealthPort,
+		HTTPGatewayPort: httpGatewayPort,
+		GreeterName:     config.GreeterName(ctx, zone),
+		PodName:         config.PodName(ctx),
+		Zone:            zone,
+		NextHops:        config.NextHops(),
+		NextHopClientConfig: greeter.ClientConfig{
+			Timeout:             nextHopTimeout,
+			HedgingMaxAttempts:  nextHopHedgingMaxAttempts,
+			HedgingDelay:        nextHopHedgingDelay,
+			RetryMaxAttempts:    nextHopRetryMaxAttempts,
+			RetryInitialBackoff: nextHopRetryInitialBackoff,
+			RetryMaxBackoff:     nextHopRetryMaxBackoff,
+			PickPolicy:          nextHopPickPolicy,
+		},
+		UseXDS:                            config.UseXDS(),
+		UseXDSCredentials:                 useXDSCredentials,
+		Application:                       config.Application(),
+		FaultInjection:                    faultInjection,
+		ApplicationUtilization:            applicationUtilization,
+		GracefulShutdownTimeout:           gracefulShutdownTimeout,
+		DrainInterval:                     drainInterval,
+		CertificateFile:                   config.CertificateFile(),
+		CACertificateFile:                 config.CACertificateFile(),
+		CertificateExpiryWarningThreshold: certificateExpiryWarningThreshold,
+		GRPCServer:                        grpcServer,
 	}
 	return server.Run(ctx, serverConfig)
 }
@@ -0,0 +1,37 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command csds-dump connects to the admin services of a greeter application, fetches
+// the xDS client configuration using the Client Status Discovery Service (CSDS), and
+// prints the LDS, RDS, CDS, and EDS resources with their ACK/NACK status.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	// blank import to register the xDS resource types, for printing resources as JSON.
+	_ "google.golang.org/grpc/xds"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/csdsdump"
+)
+
+func main() {
+	if err := csdsdump.Run(context.Background(), flag.CommandLine, os.Args[1:]); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,37 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Command greeter-cli sends individual SayHello RPCs to greeter services, using
+// any target and request metadata headers, and prints the peer address and
+// zone of the greeter server that responded.
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+
+	// blank import to register the xds resolver and load balancing policies.
+	_ "google.golang.org/grpc/xds"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greetercli"
+)
+
+func main() {
+	if err := greetercli.Run(context.Background(), flag.CommandLine, os.Args[1:]); err != nil {
+		_, _ = fmt.Fprintln(os.Stderr, err.Error())
+		os.Exit(1)
+	}
+}
@@ -0,0 +1,315 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.35.2
+// 	protoc        (unknown)
+// source: connection/connection.proto
+
+package connectionpb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// The request message. It has no fields.
+type ConnectionInfoRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+}
+
+func (x *ConnectionInfoRequest) Reset() {
+	*x = ConnectionInfoRequest{}
+	mi := &file_connection_connection_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectionInfoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectionInfoRequest) ProtoMessage() {}
+
+func (x *ConnectionInfoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_connection_connection_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectionInfoRequest.ProtoReflect.Descriptor instead.
+func (*ConnectionInfoRequest) Descriptor() ([]byte, []int) {
+	return file_connection_connection_proto_rawDescGZIP(), []int{0}
+}
+
+// The response message containing the connection details.
+type ConnectionInfoReply struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	// Address of the caller, e.g., `10.0.0.20:43210`.
+	PeerAddress string `protobuf:"bytes,1,opt,name=peer_address,json=peerAddress,proto3" json:"peer_address,omitempty"`
+	// Authentication type of the connection, e.g., `tls` or `insecure`.
+	AuthType string `protobuf:"bytes,2,opt,name=auth_type,json=authType,proto3" json:"auth_type,omitempty"`
+	// TLS version, e.g., `TLS 1.3`. Empty if the connection does not use TLS.
+	TlsVersion string `protobuf:"bytes,3,opt,name=tls_version,json=tlsVersion,proto3" json:"tls_version,omitempty"`
+	// Negotiated cipher suite, e.g., `TLS_AES_128_GCM_SHA256`.
+	CipherSuite string `protobuf:"bytes,4,opt,name=cipher_suite,json=cipherSuite,proto3" json:"cipher_suite,omitempty"`
+	// Negotiated application protocol (ALPN), e.g., `h2`.
+	NegotiatedProtocol string `protobuf:"bytes,5,opt,name=negotiated_protocol,json=negotiatedProtocol,proto3" json:"negotiated_protocol,omitempty"`
+	// SPIFFE ID of the caller, from the URI SAN of the peer certificate.
+	PeerSpiffeId string `protobuf:"bytes,6,opt,name=peer_spiffe_id,json=peerSpiffeId,proto3" json:"peer_spiffe_id,omitempty"`
+	// Subject alternative names of the peer certificate, with type prefixes,
+	// e.g., `URI:spiffe://example.com/ns/xds/sa/greeter` or `DNS:greeter`.
+	// Empty if the caller did not send a client certificate.
+	PeerSubjectAltNames []string `protobuf:"bytes,7,rep,name=peer_subject_alt_names,json=peerSubjectAltNames,proto3" json:"peer_subject_alt_names,omitempty"`
+	// Expiry time of the peer certificate.
+	PeerCertificateExpiry *timestamppb.Timestamp `protobuf:"bytes,8,opt,name=peer_certificate_expiry,json=peerCertificateExpiry,proto3" json:"peer_certificate_expiry,omitempty"`
+	// SPIFFE ID of the server, from the local workload certificate file.
+	LocalSpiffeId string `protobuf:"bytes,9,opt,name=local_spiffe_id,json=localSpiffeId,proto3" json:"local_spiffe_id,omitempty"`
+	// Expiry time of the local workload certificate.
+	LocalCertificateExpiry *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=local_certificate_expiry,json=localCertificateExpiry,proto3" json:"local_certificate_expiry,omitempty"`
+}
+
+func (x *ConnectionInfoReply) Reset() {
+	*x = ConnectionInfoReply{}
+	mi := &file_connection_connection_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ConnectionInfoReply) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ConnectionInfoReply) ProtoMessage() {}
+
+func (x *ConnectionInfoReply) ProtoReflect() protoreflect.Message {
+	mi := &file_connection_connection_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ConnectionInfoReply.ProtoReflect.Descriptor instead.
+func (*ConnectionInfoReply) Descriptor() ([]byte, []int) {
+	return file_connection_connection_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *ConnectionInfoReply) GetPeerAddress() string {
+	if x != nil {
+		return x.PeerAddress
+	}
+	return ""
+}
+
+func (x *ConnectionInfoReply) GetAuthType() string {
+	if x != nil {
+		return x.AuthType
+	}
+	return ""
+}
+
+func (x *ConnectionInfoReply) GetTlsVersion() string {
+	if x != nil {
+		return x.TlsVersion
+	}
+	return ""
+}
+
+func (x *ConnectionInfoReply) GetCipherSuite() string {
+	if x != nil {
+		return x.CipherSuite
+	}
+	return ""
+}
+
+func (x *ConnectionInfoReply) GetNegotiatedProtocol() string {
+	if x != nil {
+		return x.NegotiatedProtocol
+	}
+	return ""
+}
+
+func (x *ConnectionInfoReply) GetPeerSpiffeId() string {
+	if x != nil {
+		return x.PeerSpiffeId
+	}
+	return ""
+}
+
+func (x *ConnectionInfoReply) GetPeerSubjectAltNames() []string {
+	if x != nil {
+		return x.PeerSubjectAltNames
+	}
+	return nil
+}
+
+func (x *ConnectionInfoReply) GetPeerCertificateExpiry() *timestamppb.Timestamp {
+	if x != nil {
+		return x.PeerCertificateExpiry
+	}
+	return nil
+}
+
+func (x *ConnectionInfoReply) GetLocalSpiffeId() string {
+	if x != nil {
+		return x.LocalSpiffeId
+	}
+	return ""
+}
+
+func (x *ConnectionInfoReply) GetLocalCertificateExpiry() *timestamppb.Timestamp {
+	if x != nil {
+		return x.LocalCertificateExpiry
+	}
+	return nil
+}
+
+var File_connection_connection_proto protoreflect.FileDescriptor
+
+var file_connection_connection_proto_rawDesc = []byte{
+	0x0a, 0x1b, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x2f, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0a, 0x63,
+	0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c,
+	0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73,
+	0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0x17, 0x0a, 0x15, 0x43, 0x6f,
+	0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x71, 0x75,
+	0x65, 0x73, 0x74, 0x22, 0xf7, 0x03, 0x0a, 0x13, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x12, 0x21, 0x0a, 0x0c, 0x70,
+	0x65, 0x65, 0x72, 0x5f, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0b, 0x70, 0x65, 0x65, 0x72, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x1b,
+	0x0a, 0x09, 0x61, 0x75, 0x74, 0x68, 0x5f, 0x74, 0x79, 0x70, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x61, 0x75, 0x74, 0x68, 0x54, 0x79, 0x70, 0x65, 0x12, 0x1f, 0x0a, 0x0b, 0x74,
+	0x6c, 0x73, 0x5f, 0x76, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x0a, 0x74, 0x6c, 0x73, 0x56, 0x65, 0x72, 0x73, 0x69, 0x6f, 0x6e, 0x12, 0x21, 0x0a, 0x0c,
+	0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x5f, 0x73, 0x75, 0x69, 0x74, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x63, 0x69, 0x70, 0x68, 0x65, 0x72, 0x53, 0x75, 0x69, 0x74, 0x65, 0x12,
+	0x2f, 0x0a, 0x13, 0x6e, 0x65, 0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x5f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x12, 0x6e, 0x65,
+	0x67, 0x6f, 0x74, 0x69, 0x61, 0x74, 0x65, 0x64, 0x50, 0x72, 0x6f, 0x74, 0x6f, 0x63, 0x6f, 0x6c,
+	0x12, 0x24, 0x0a, 0x0e, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x73, 0x70, 0x69, 0x66, 0x66, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x06, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0c, 0x70, 0x65, 0x65, 0x72, 0x53, 0x70,
+	0x69, 0x66, 0x66, 0x65, 0x49, 0x64, 0x12, 0x33, 0x0a, 0x16, 0x70, 0x65, 0x65, 0x72, 0x5f, 0x73,
+	0x75, 0x62, 0x6a, 0x65, 0x63, 0x74, 0x5f, 0x61, 0x6c, 0x74, 0x5f, 0x6e, 0x61, 0x6d, 0x65, 0x73,
+	0x18, 0x07, 0x20, 0x03, 0x28, 0x09, 0x52, 0x13, 0x70, 0x65, 0x65, 0x72, 0x53, 0x75, 0x62, 0x6a,
+	0x65, 0x63, 0x74, 0x41, 0x6c, 0x74, 0x4e, 0x61, 0x6d, 0x65, 0x73, 0x12, 0x52, 0x0a, 0x17, 0x70,
+	0x65, 0x65, 0x72, 0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f,
+	0x65, 0x78, 0x70, 0x69, 0x72, 0x79, 0x18, 0x08, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x15, 0x70, 0x65, 0x65, 0x72, 0x43, 0x65,
+	0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x12,
+	0x26, 0x0a, 0x0f, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x5f, 0x73, 0x70, 0x69, 0x66, 0x66, 0x65, 0x5f,
+	0x69, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0d, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x53,
+	0x70, 0x69, 0x66, 0x66, 0x65, 0x49, 0x64, 0x12, 0x54, 0x0a, 0x18, 0x6c, 0x6f, 0x63, 0x61, 0x6c,
+	0x5f, 0x63, 0x65, 0x72, 0x74, 0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x5f, 0x65, 0x78, 0x70,
+	0x69, 0x72, 0x79, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67,
+	0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65,
+	0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x16, 0x6c, 0x6f, 0x63, 0x61, 0x6c, 0x43, 0x65, 0x72, 0x74,
+	0x69, 0x66, 0x69, 0x63, 0x61, 0x74, 0x65, 0x45, 0x78, 0x70, 0x69, 0x72, 0x79, 0x32, 0x6b, 0x0a,
+	0x0e, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f, 0x12,
+	0x59, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e,
+	0x49, 0x6e, 0x66, 0x6f, 0x12, 0x21, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f,
+	0x6e, 0x2e, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49, 0x6e, 0x66, 0x6f,
+	0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x1f, 0x2e, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63,
+	0x74, 0x69, 0x6f, 0x6e, 0x2e, 0x43, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x49,
+	0x6e, 0x66, 0x6f, 0x52, 0x65, 0x70, 0x6c, 0x79, 0x22, 0x00, 0x42, 0x68, 0x5a, 0x66, 0x67, 0x69,
+	0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x63,
+	0x6c, 0x6f, 0x75, 0x64, 0x70, 0x6c, 0x61, 0x74, 0x66, 0x6f, 0x72, 0x6d, 0x2f, 0x73, 0x6f, 0x6c,
+	0x75, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2d, 0x77, 0x6f, 0x72, 0x6b, 0x73, 0x68, 0x6f, 0x70, 0x73,
+	0x2f, 0x67, 0x72, 0x70, 0x63, 0x2d, 0x78, 0x64, 0x73, 0x2f, 0x67, 0x72, 0x65, 0x65, 0x74, 0x65,
+	0x72, 0x2d, 0x67, 0x6f, 0x2f, 0x70, 0x6b, 0x67, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x63, 0x6f, 0x6e,
+	0x6e, 0x65, 0x63, 0x74, 0x69, 0x6f, 0x6e, 0x3b, 0x63, 0x6f, 0x6e, 0x6e, 0x65, 0x63, 0x74, 0x69,
+	0x6f, 0x6e, 0x70, 0x62, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_connection_connection_proto_rawDescOnce sync.Once
+	file_connection_connection_proto_rawDescData = file_connection_connection_proto_rawDesc
+)
+
+func file_connection_connection_proto_rawDescGZIP() []byte {
+	file_connection_connection_proto_rawDescOnce.Do(func() {
+		file_connection_connection_proto_rawDescData = protoimpl.X.CompressGZIP(file_connection_connection_proto_rawDescData)
+	})
+	return file_connection_connection_proto_rawDescData
+}
+
+var file_connection_connection_proto_msgTypes = make([]protoimpl.MessageInfo, 2)
+var file_connection_connection_proto_goTypes = []any{
+	(*ConnectionInfoRequest)(nil), // 0: connection.ConnectionInfoRequest
+	(*ConnectionInfoReply)(nil),   // 1: connection.ConnectionInfoReply
+	(*timestamppb.Timestamp)(nil), // 2: google.protobuf.Timestamp
+}
+var file_connection_connection_proto_depIdxs = []int32{
+	2, // 0: connection.ConnectionInfoReply.peer_certificate_expiry:type_name -> google.protobuf.Timestamp
+	2, // 1: connection.ConnectionInfoReply.local_certificate_expiry:type_name -> google.protobuf.Timestamp
+	0, // 2: connection.ConnectionInfo.GetConnectionInfo:input_type -> connection.ConnectionInfoRequest
+	1, // 3: connection.ConnectionInfo.GetConnectionInfo:output_type -> connection.ConnectionInfoReply
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_connection_connection_proto_init() }
+func file_connection_connection_proto_init() {
+	if File_connection_connection_proto != nil {
+		return
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_connection_connection_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   2,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_connection_connection_proto_goTypes,
+		DependencyIndexes: file_connection_connection_proto_depIdxs,
+		MessageInfos:      file_connection_connection_proto_msgTypes,
+	}.Build()
+	File_connection_connection_proto = out.File
+	file_connection_connection_proto_rawDesc = nil
+	file_connection_connection_proto_goTypes = nil
+	file_connection_connection_proto_depIdxs = nil
+}
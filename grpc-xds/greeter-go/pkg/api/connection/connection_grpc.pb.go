@@ -0,0 +1,147 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: connection/connection.proto
+
+package connectionpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	ConnectionInfo_GetConnectionInfo_FullMethodName = "/connection.ConnectionInfo/GetConnectionInfo"
+)
+
+// ConnectionInfoClient is the client API for ConnectionInfo service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The connection info service definition.
+//
+// Returns the identity of the caller as seen by the server, to verify mTLS
+// configuration without inspecting server logs.
+type ConnectionInfoClient interface {
+	// Returns the transport security details of the connection of the caller.
+	GetConnectionInfo(ctx context.Context, in *ConnectionInfoRequest, opts ...grpc.CallOption) (*ConnectionInfoReply, error)
+}
+
+type connectionInfoClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewConnectionInfoClient(cc grpc.ClientConnInterface) ConnectionInfoClient {
+	return &connectionInfoClient{cc}
+}
+
+func (c *connectionInfoClient) GetConnectionInfo(ctx context.Context, in *ConnectionInfoRequest, opts ...grpc.CallOption) (*ConnectionInfoReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ConnectionInfoReply)
+	err := c.cc.Invoke(ctx, ConnectionInfo_GetConnectionInfo_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// ConnectionInfoServer is the server API for ConnectionInfo service.
+// All implementations must embed UnimplementedConnectionInfoServer
+// for forward compatibility.
+//
+// The connection info service definition.
+//
+// Returns the identity of the caller as seen by the server, to verify mTLS
+// configuration without inspecting server logs.
+type ConnectionInfoServer interface {
+	// Returns the transport security details of the connection of the caller.
+	GetConnectionInfo(context.Context, *ConnectionInfoRequest) (*ConnectionInfoReply, error)
+	mustEmbedUnimplementedConnectionInfoServer()
+}
+
+// UnimplementedConnectionInfoServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedConnectionInfoServer struct{}
+
+func (UnimplementedConnectionInfoServer) GetConnectionInfo(context.Context, *ConnectionInfoRequest) (*ConnectionInfoReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetConnectionInfo not implemented")
+}
+func (UnimplementedConnectionInfoServer) mustEmbedUnimplementedConnectionInfoServer() {}
+func (UnimplementedConnectionInfoServer) testEmbeddedByValue()                        {}
+
+// UnsafeConnectionInfoServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to ConnectionInfoServer will
+// result in compilation errors.
+type UnsafeConnectionInfoServer interface {
+	mustEmbedUnimplementedConnectionInfoServer()
+}
+
+func RegisterConnectionInfoServer(s grpc.ServiceRegistrar, srv ConnectionInfoServer) {
+	// If the following call pancis, it indicates UnimplementedConnectionInfoServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&ConnectionInfo_ServiceDesc, srv)
+}
+
+func _ConnectionInfo_GetConnectionInfo_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ConnectionInfoRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ConnectionInfoServer).GetConnectionInfo(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ConnectionInfo_GetConnectionInfo_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ConnectionInfoServer).GetConnectionInfo(ctx, req.(*ConnectionInfoRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// ConnectionInfo_ServiceDesc is the grpc.ServiceDesc for ConnectionInfo service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var ConnectionInfo_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "connection.ConnectionInfo",
+	HandlerType: (*ConnectionInfoServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetConnectionInfo",
+			Handler:    _ConnectionInfo_GetConnectionInfo_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "connection/connection.proto",
+}
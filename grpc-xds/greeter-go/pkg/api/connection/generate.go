@@ -0,0 +1,18 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package connectionpb contains the generated code for the connection.ConnectionInfo gRPC service.
+package connectionpb
+
+//go:generate protoc --proto_path=../../../proto --go_out=../../.. --go_opt=module=github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go --go-grpc_out=../../.. --go-grpc_opt=module=github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go connection/connection.proto
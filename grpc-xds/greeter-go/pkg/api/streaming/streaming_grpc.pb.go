@@ -0,0 +1,152 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: helloworld/streaming.proto
+
+package streamingpb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	StreamingGreeter_SayHelloStream_FullMethodName = "/helloworld.StreamingGreeter/SayHelloStream"
+)
+
+// StreamingGreeterClient is the client API for StreamingGreeter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The streaming greeting service definition.
+//
+// Long-lived streams show how in-flight RPCs behave across endpoint drains,
+// listener updates, and connection re-balancing.
+type StreamingGreeterClient interface {
+	// Sends a stream of greetings, one per interval, until the requested number
+	// of greetings have been sent, or the client cancels the RPC.
+	SayHelloStream(ctx context.Context, in *HelloStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HelloStreamReply], error)
+}
+
+type streamingGreeterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewStreamingGreeterClient(cc grpc.ClientConnInterface) StreamingGreeterClient {
+	return &streamingGreeterClient{cc}
+}
+
+func (c *streamingGreeterClient) SayHelloStream(ctx context.Context, in *HelloStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[HelloStreamReply], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &StreamingGreeter_ServiceDesc.Streams[0], StreamingGreeter_SayHelloStream_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[HelloStreamRequest, HelloStreamReply]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StreamingGreeter_SayHelloStreamClient = grpc.ServerStreamingClient[HelloStreamReply]
+
+// StreamingGreeterServer is the server API for StreamingGreeter service.
+// All implementations must embed UnimplementedStreamingGreeterServer
+// for forward compatibility.
+//
+// The streaming greeting service definition.
+//
+// Long-lived streams show how in-flight RPCs behave across endpoint drains,
+// listener updates, and connection re-balancing.
+type StreamingGreeterServer interface {
+	// Sends a stream of greetings, one per interval, until the requested number
+	// of greetings have been sent, or the client cancels the RPC.
+	SayHelloStream(*HelloStreamRequest, grpc.ServerStreamingServer[HelloStreamReply]) error
+	mustEmbedUnimplementedStreamingGreeterServer()
+}
+
+// UnimplementedStreamingGreeterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedStreamingGreeterServer struct{}
+
+func (UnimplementedStreamingGreeterServer) SayHelloStream(*HelloStreamRequest, grpc.ServerStreamingServer[HelloStreamReply]) error {
+	return status.Errorf(codes.Unimplemented, "method SayHelloStream not implemented")
+}
+func (UnimplementedStreamingGreeterServer) mustEmbedUnimplementedStreamingGreeterServer() {}
+func (UnimplementedStreamingGreeterServer) testEmbeddedByValue()                          {}
+
+// UnsafeStreamingGreeterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to StreamingGreeterServer will
+// result in compilation errors.
+type UnsafeStreamingGreeterServer interface {
+	mustEmbedUnimplementedStreamingGreeterServer()
+}
+
+func RegisterStreamingGreeterServer(s grpc.ServiceRegistrar, srv StreamingGreeterServer) {
+	// If the following call pancis, it indicates UnimplementedStreamingGreeterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&StreamingGreeter_ServiceDesc, srv)
+}
+
+func _StreamingGreeter_SayHelloStream_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(HelloStreamRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StreamingGreeterServer).SayHelloStream(m, &grpc.GenericServerStream[HelloStreamRequest, HelloStreamReply]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type StreamingGreeter_SayHelloStreamServer = grpc.ServerStreamingServer[HelloStreamReply]
+
+// StreamingGreeter_ServiceDesc is the grpc.ServiceDesc for StreamingGreeter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var StreamingGreeter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helloworld.StreamingGreeter",
+	HandlerType: (*StreamingGreeterServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "SayHelloStream",
+			Handler:       _StreamingGreeter_SayHelloStream_Handler,
+			ServerStreams: true,
+		},
+	},
+	Metadata: "helloworld/streaming.proto",
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: helloworld/trace.proto
+
+package tracepb
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	TracingGreeter_TraceHello_FullMethodName = "/helloworld.TracingGreeter/TraceHello"
+)
+
+// TracingGreeterClient is the client API for TracingGreeter service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// The tracing greeting service definition.
+//
+// Traced greetings show the path of a request through the chain of greeter
+// services, e.g., to visualize locality routing across zones.
+type TracingGreeterClient interface {
+	// Sends a greeting through the chain of greeter services. Each greeter
+	// server adds a hop with information about itself to the reply.
+	TraceHello(ctx context.Context, in *TraceHelloRequest, opts ...grpc.CallOption) (*TraceHelloReply, error)
+}
+
+type tracingGreeterClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewTracingGreeterClient(cc grpc.ClientConnInterface) TracingGreeterClient {
+	return &tracingGreeterClient{cc}
+}
+
+func (c *tracingGreeterClient) TraceHello(ctx context.Context, in *TraceHelloRequest, opts ...grpc.CallOption) (*TraceHelloReply, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(TraceHelloReply)
+	err := c.cc.Invoke(ctx, TracingGreeter_TraceHello_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// TracingGreeterServer is the server API for TracingGreeter service.
+// All implementations must embed UnimplementedTracingGreeterServer
+// for forward compatibility.
+//
+// The tracing greeting service definition.
+//
+// Traced greetings show the path of a request through the chain of greeter
+// services, e.g., to visualize locality routing across zones.
+type TracingGreeterServer interface {
+	// Sends a greeting through the chain of greeter services. Each greeter
+	// server adds a hop with information about itself to the reply.
+	TraceHello(context.Context, *TraceHelloRequest) (*TraceHelloReply, error)
+	mustEmbedUnimplementedTracingGreeterServer()
+}
+
+// UnimplementedTracingGreeterServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedTracingGreeterServer struct{}
+
+func (UnimplementedTracingGreeterServer) TraceHello(context.Context, *TraceHelloRequest) (*TraceHelloReply, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method TraceHello not implemented")
+}
+func (UnimplementedTracingGreeterServer) mustEmbedUnimplementedTracingGreeterServer() {}
+func (UnimplementedTracingGreeterServer) testEmbeddedByValue()                        {}
+
+// UnsafeTracingGreeterServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to TracingGreeterServer will
+// result in compilation errors.
+type UnsafeTracingGreeterServer interface {
+	mustEmbedUnimplementedTracingGreeterServer()
+}
+
+func RegisterTracingGreeterServer(s grpc.ServiceRegistrar, srv TracingGreeterServer) {
+	// If the following call pancis, it indicates UnimplementedTracingGreeterServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&TracingGreeter_ServiceDesc, srv)
+}
+
+func _TracingGreeter_TraceHello_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(TraceHelloRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(TracingGreeterServer).TraceHello(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: TracingGreeter_TraceHello_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(TracingGreeterServer).TraceHello(ctx, req.(*TraceHelloRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// TracingGreeter_ServiceDesc is the grpc.ServiceDesc for TracingGreeter service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var TracingGreeter_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "helloworld.TracingGreeter",
+	HandlerType: (*TracingGreeterServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "TraceHello",
+			Handler:    _TracingGreeter_TraceHello_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "helloworld/trace.proto",
+}
@@ -0,0 +1,169 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bootstrapgen generates gRPC xDS bootstrap configuration files, so that
+// the greeter applications can switch between a self-managed xDS control plane
+// and Traffic Director without editing bootstrap files by hand.
+package bootstrapgen
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"strings"
+
+	"cloud.google.com/go/compute/metadata"
+	"github.com/google/uuid"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/xdsclient/bootstrap"
+)
+
+const (
+	serviceAccountNamespaceFile = "/var/run/secrets/kubernetes.io/serviceaccount/namespace"
+	// clusterNameEnvVar is the default of the `-cluster-name` flag.
+	clusterNameEnvVar = "K8S_CLUSTER_NAME"
+	// clusterNameMetadataKey must match `xds.NodeMetadataClusterName` of the Go control plane.
+	clusterNameMetadataKey = "K8S_CLUSTER_NAME"
+)
+
+// Run parses the command line flags, and writes the bootstrap configuration to the output file,
+// or to stdout if the output file flag is empty.
+func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
+	opts := bootstrap.GenerateOptions{
+		NodeMetadata: map[string]string{},
+	}
+	var authorities, clusterName, output string
+	flagset.StringVar(&opts.ControlPlane, "control-plane", bootstrap.ControlPlaneDIY, "xDS control plane, either diy or traffic-director")
+	flagset.StringVar(&opts.ServerURI, "server-uri", "dns:///control-plane.xds:50051", "URI of the self-managed xDS management server (diy only)")
+	flagset.StringVar(&authorities, "authorities", "", "comma-separated list of federation authorities of self-managed xDS management servers, e.g., control-plane.xds.svc.cluster2.example.com (diy only)")
+	flagset.IntVar(&opts.AuthorityPort, "authority-port", 50051, "port of the xDS management servers of the federation authorities (diy only)")
+	flagset.StringVar(&opts.ProjectNumber, "project-number", "", "Google Cloud project number of the mesh, defaults to the project of the GCP metadata server (traffic-director only)")
+	flagset.StringVar(&opts.MeshName, "mesh-name", "grpc-xds", "name of the Traffic Director mesh (traffic-director only)")
+	flagset.BoolVar(&opts.Federation, "federation", false, "use xdstp:// listener resource names with the Traffic Director authority (traffic-director only)")
+	flagset.StringVar(&opts.NodeCluster, "node-cluster", "", "xDS node cluster, e.g., the app name")
+	flagset.StringVar(&opts.Zone, "zone", "", "xDS node locality zone, defaults to the zone of the GCP metadata server")
+	flagset.StringVar(&clusterName, "cluster-name", os.Getenv(clusterNameEnvVar), "kubeconfig context name of this cluster in the informer configuration of the control plane, for the clusterAndZone locality priority policy, defaults to the "+clusterNameEnvVar+" environment variable (diy only)")
+	flagset.Var(metadataFlag(opts.NodeMetadata), "node-metadata", "additional xDS node metadata as `key=value`, can be repeated")
+	flagset.StringVar(&opts.CertificateFile, "certificate-file", "", "path of the workload certificate chain file, defaults to /var/run/secrets/workload-spiffe-credentials/certificates.pem")
+	flagset.StringVar(&opts.PrivateKeyFile, "private-key-file", "", "path of the workload private key file, defaults to /var/run/secrets/workload-spiffe-credentials/private_key.pem")
+	flagset.StringVar(&opts.CACertificateFile, "ca-certificate-file", "", "path of the CA certificates file, defaults to /var/run/secrets/workload-spiffe-credentials/ca_certificates.pem")
+	flagset.DurationVar(&opts.CertificateRefreshInterval, "certificate-refresh-interval", 0, "how often gRPC reloads the certificate files, defaults to 10m")
+	flagset.StringVar(&output, "output", "", "path of the bootstrap configuration file to write, defaults to stdout")
+	if err := flagset.Parse(args); err != nil {
+		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
+	}
+	if authorities != "" {
+		opts.Authorities = strings.Split(authorities, ",")
+	}
+	if clusterName != "" {
+		setIfAbsent(opts.NodeMetadata, clusterNameMetadataKey, clusterName)
+	}
+	if err := setDefaults(ctx, &opts); err != nil {
+		return err
+	}
+	data, err := bootstrap.Generate(opts)
+	if err != nil {
+		return fmt.Errorf("could not generate gRPC xDS bootstrap configuration: %w", err)
+	}
+	if output == "" {
+		_, err = os.Stdout.Write(data)
+		return err
+	}
+	if err := os.WriteFile(output, data, 0o644); err != nil {
+		return fmt.Errorf("could not write gRPC xDS bootstrap configuration to file %s: %w", output, err)
+	}
+	return nil
+}
+
+// setDefaults populates node information from the Pod environment and the GCP metadata server,
+// in the same way as the init containers in the `k8s/greeter/components/bootstrap-*` Kustomize components.
+func setDefaults(ctx context.Context, opts *bootstrap.GenerateOptions) error {
+	instanceIP := podIP()
+	id, err := uuid.NewRandom()
+	if err != nil {
+		return fmt.Errorf("could not generate node ID: %w", err)
+	}
+	nodeID := id.String()
+	if opts.ControlPlane == bootstrap.ControlPlaneDIY {
+		nodeID = nodeID + "~" + instanceIP
+	}
+	opts.NodeID = nodeID
+	setIfAbsent(opts.NodeMetadata, "INSTANCE_IP", instanceIP)
+	if hostname, err := os.Hostname(); err == nil {
+		setIfAbsent(opts.NodeMetadata, "K8S_POD", hostname)
+	}
+	if namespace, err := os.ReadFile(serviceAccountNamespaceFile); err == nil {
+		setIfAbsent(opts.NodeMetadata, "K8S_NAMESPACE", strings.TrimSpace(string(namespace)))
+	}
+	if !metadata.OnGCE() {
+		return nil
+	}
+	if opts.Zone == "" {
+		zone, err := metadata.ZoneWithContext(ctx)
+		if err != nil {
+			return fmt.Errorf("could not look up the zone from the GCP metadata server: %w", err)
+		}
+		opts.Zone = zone
+	}
+	if opts.ControlPlane == bootstrap.ControlPlaneTrafficDirector && opts.ProjectNumber == "" {
+		projectNumber, err := metadata.NumericProjectIDWithContext(ctx)
+		if err != nil {
+			return fmt.Errorf("could not look up the project number from the GCP metadata server: %w", err)
+		}
+		opts.ProjectNumber = projectNumber
+	}
+	return nil
+}
+
+// podIP returns the first non-loopback IPv4 address of this host, or an empty string.
+func podIP() string {
+	addrs, err := net.InterfaceAddrs()
+	if err != nil {
+		return ""
+	}
+	for _, addr := range addrs {
+		if ipNet, ok := addr.(*net.IPNet); ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return ""
+}
+
+func setIfAbsent(m map[string]string, key string, value string) {
+	if _, exists := m[key]; !exists && value != "" {
+		m[key] = value
+	}
+}
+
+// metadataFlag collects xDS node metadata from repeated command line flags.
+type metadataFlag map[string]string
+
+func (m metadataFlag) String() string {
+	pairs := make([]string, 0, len(m))
+	for key, value := range m {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (m metadataFlag) Set(keyValue string) error {
+	key, value, found := strings.Cut(keyValue, "=")
+	if !found || key == "" {
+		return fmt.Errorf("invalid node metadata %q, expected key=value", keyValue)
+	}
+	m[key] = value
+	return nil
+}
@@ -0,0 +1,193 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package channelzsummary queries the Channelz service of a greeter application, and
+// summarizes the channels, subchannels, and sockets per target, to show how the
+// load balancing policy distributes connections and RPCs across addresses.
+package channelzsummary
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	channelzpb "google.golang.org/grpc/channelz/grpc_channelz_v1"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/signals"
+)
+
+// Run parses the command line flags, fetches the channels from the Channelz service
+// at the address, and prints a summary to stdout.
+func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
+	ctx = signals.SetupSignalHandler(ctx)
+	var addr, target string
+	var timeout time.Duration
+	flagset.StringVar(&addr, "addr", "localhost:50052", "address of the admin services, e.g., the health port of a greeter Pod")
+	flagset.StringVar(&target, "target", "", "only summarize channels with targets that contain this string, e.g., greeter-leaf")
+	flagset.DurationVar(&timeout, "timeout", 10*time.Second, "deadline of all the Channelz requests")
+	if err := flagset.Parse(args); err != nil {
+		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
+	}
+
+	clientConn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("could not create gRPC client for address=%s: %w", addr, err)
+	}
+	defer clientConn.Close()
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	s := &summarizer{
+		client: channelzpb.NewChannelzClient(clientConn),
+		out:    os.Stdout,
+	}
+	return s.summarizeTopChannels(requestCtx, target)
+}
+
+// summarizer prints Channelz channels, subchannels, and sockets as an indented tree.
+type summarizer struct {
+	client channelzpb.ChannelzClient
+	out    io.Writer
+}
+
+// summarizeTopChannels pages through the top channels, and summarizes the channels with
+// targets that contain the target filter.
+func (s *summarizer) summarizeTopChannels(ctx context.Context, target string) error {
+	var startChannelID int64
+	found := false
+	for {
+		resp, err := s.client.GetTopChannels(ctx, &channelzpb.GetTopChannelsRequest{StartChannelId: startChannelID})
+		if err != nil {
+			return fmt.Errorf("could not get Channelz top channels: %w", err)
+		}
+		for _, channel := range resp.GetChannel() {
+			startChannelID = channel.GetRef().GetChannelId() + 1
+			if !strings.Contains(channel.GetData().GetTarget(), target) {
+				continue
+			}
+			found = true
+			if err := s.summarizeChannel(ctx, channel, 0); err != nil {
+				return err
+			}
+		}
+		if resp.GetEnd() || len(resp.GetChannel()) == 0 {
+			break
+		}
+	}
+	if !found {
+		s.printf(0, "No channels found for target filter %q.\n", target)
+	}
+	return nil
+}
+
+func (s *summarizer) summarizeChannel(ctx context.Context, channel *channelzpb.Channel, depth int) error {
+	data := channel.GetData()
+	s.printf(depth, "Channel %d target=%s state=%s %s\n",
+		channel.GetRef().GetChannelId(), data.GetTarget(), state(data), calls(data))
+	for _, channelRef := range channel.GetChannelRef() {
+		resp, err := s.client.GetChannel(ctx, &channelzpb.GetChannelRequest{ChannelId: channelRef.GetChannelId()})
+		if err != nil {
+			return fmt.Errorf("could not get Channelz channel id=%d: %w", channelRef.GetChannelId(), err)
+		}
+		if err := s.summarizeChannel(ctx, resp.GetChannel(), depth+1); err != nil {
+			return err
+		}
+	}
+	for _, subchannelRef := range channel.GetSubchannelRef() {
+		if err := s.summarizeSubchannel(ctx, subchannelRef.GetSubchannelId(), data.GetCallsStarted(), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// summarizeSubchannel prints the subchannel with its share of the calls started on the parent
+// channel, followed by its sockets.
+func (s *summarizer) summarizeSubchannel(ctx context.Context, subchannelID int64, parentCallsStarted int64, depth int) error {
+	resp, err := s.client.GetSubchannel(ctx, &channelzpb.GetSubchannelRequest{SubchannelId: subchannelID})
+	if err != nil {
+		return fmt.Errorf("could not get Channelz subchannel id=%d: %w", subchannelID, err)
+	}
+	subchannel := resp.GetSubchannel()
+	data := subchannel.GetData()
+	s.printf(depth, "Subchannel %d state=%s %s share=%s\n",
+		subchannelID, state(data), calls(data), share(data.GetCallsStarted(), parentCallsStarted))
+	for _, socketRef := range subchannel.GetSocketRef() {
+		if err := s.summarizeSocket(ctx, socketRef.GetSocketId(), depth+1); err != nil {
+			return err
+		}
+	}
+	for _, subchannelRef := range subchannel.GetSubchannelRef() {
+		if err := s.summarizeSubchannel(ctx, subchannelRef.GetSubchannelId(), data.GetCallsStarted(), depth+1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *summarizer) summarizeSocket(ctx context.Context, socketID int64, depth int) error {
+	resp, err := s.client.GetSocket(ctx, &channelzpb.GetSocketRequest{SocketId: socketID})
+	if err != nil {
+		return fmt.Errorf("could not get Channelz socket id=%d: %w", socketID, err)
+	}
+	socket := resp.GetSocket()
+	data := socket.GetData()
+	s.printf(depth, "Socket %d remote=%s local=%s streams started=%d succeeded=%d failed=%d\n",
+		socketID, address(socket.GetRemote()), address(socket.GetLocal()),
+		data.GetStreamsStarted(), data.GetStreamsSucceeded(), data.GetStreamsFailed())
+	return nil
+}
+
+func (s *summarizer) printf(depth int, format string, a ...any) {
+	_, _ = fmt.Fprintf(s.out, strings.Repeat("  ", depth)+format, a...)
+}
+
+func state(data *channelzpb.ChannelData) string {
+	return data.GetState().GetState().String()
+}
+
+func calls(data *channelzpb.ChannelData) string {
+	return fmt.Sprintf("calls started=%d succeeded=%d failed=%d",
+		data.GetCallsStarted(), data.GetCallsSucceeded(), data.GetCallsFailed())
+}
+
+// share returns the percentage of calls started on the parent channel, or `-` if the
+// parent channel has not started any calls.
+func share(callsStarted int64, parentCallsStarted int64) string {
+	if parentCallsStarted == 0 {
+		return "-"
+	}
+	return strconv.FormatFloat(float64(callsStarted)*100/float64(parentCallsStarted), 'f', 1, 64) + "%"
+}
+
+// address returns the TCP/IP address as `host:port`, or the other address types as strings.
+func address(addr *channelzpb.Address) string {
+	switch a := addr.GetAddress().(type) {
+	case *channelzpb.Address_TcpipAddress:
+		return net.JoinHostPort(net.IP(a.TcpipAddress.GetIpAddress()).String(), strconv.Itoa(int(a.TcpipAddress.GetPort())))
+	case *channelzpb.Address_UdsAddress_:
+		return "unix:" + a.UdsAddress.GetFilename()
+	case *channelzpb.Address_OtherAddress_:
+		return a.OtherAddress.GetName()
+	default:
+		return "-"
+	}
+}
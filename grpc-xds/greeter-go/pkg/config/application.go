@@ -0,0 +1,33 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+)
+
+const (
+	defaultApplication = "greeter"
+	applicationEnvVar  = "APPLICATION"
+)
+
+// Application returns the name of the sample application that the server serves,
+// either `greeter` (the default), or `echo`.
+func Application() string {
+	if application, exists := os.LookupEnv(applicationEnvVar); exists && application != "" {
+		return application
+	}
+	return defaultApplication
+}
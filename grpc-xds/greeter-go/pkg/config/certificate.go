@@ -0,0 +1,54 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"os"
+	"time"
+)
+
+const (
+	defaultCertificateFile                   = "/var/run/secrets/workload-spiffe-credentials/certificates.pem"
+	defaultCACertificateFile                 = "/var/run/secrets/workload-spiffe-credentials/ca_certificates.pem"
+	defaultCertificateExpiryWarningThreshold = 6 * time.Hour
+	certificateFileEnvVar                    = "TLS_CERT_FILE"
+	caCertificateFileEnvVar                  = "TLS_CA_FILE"
+	certificateExpiryWarningThresholdEnvVar  = "TLS_EXPIRY_WARNING_THRESHOLD"
+)
+
+// CertificateFile returns the path of the workload certificate chain file of the server, as used
+// in the certificate provider configuration of the gRPC xDS bootstrap file. The default is the
+// file that GKE provisions in `/var/run/secrets/workload-spiffe-credentials`.
+func CertificateFile() string {
+	if certificateFile, exists := os.LookupEnv(certificateFileEnvVar); exists && certificateFile != "" {
+		return certificateFile
+	}
+	return defaultCertificateFile
+}
+
+// CACertificateFile returns the path of the CA certificates file, as used in the certificate
+// provider configuration of the gRPC xDS bootstrap file.
+func CACertificateFile() string {
+	if caCertificateFile, exists := os.LookupEnv(caCertificateFileEnvVar); exists && caCertificateFile != "" {
+		return caCertificateFile
+	}
+	return defaultCACertificateFile
+}
+
+// CertificateExpiryWarningThreshold returns how long before expiry of a workload or CA
+// certificate the server starts logging warnings. A value of 0 disables the warnings.
+func CertificateExpiryWarningThreshold() (time.Duration, error) {
+	return nonNegativeDurationFromEnv(certificateExpiryWarningThresholdEnvVar, defaultCertificateExpiryWarningThreshold)
+}
@@ -0,0 +1,113 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+const (
+	faultDelayEnvVar        = "FAULT_DELAY"
+	faultDelayJitterEnvVar  = "FAULT_DELAY_JITTER"
+	faultDelayPercentEnvVar = "FAULT_DELAY_PERCENT"
+	faultErrorPercentEnvVar = "FAULT_ERROR_PERCENT"
+	faultErrorCodeEnvVar    = "FAULT_ERROR_CODE"
+)
+
+// Fault configures artificial latency and errors that the greeter server injects into
+// requests. Unlike Envoy fault injection, which proxyless gRPC clients apply client-side, these
+// faults happen in the server, so they show the value of outlier detection, retries, and hedging
+// for all clients.
+type Fault struct {
+	// DelayPercent is the percentage of requests to delay, from 0 to 100.
+	DelayPercent float64
+	// Delay is the minimum delay of delayed requests.
+	Delay time.Duration
+	// DelayJitter is the maximum additional delay of delayed requests. The additional delay
+	// is uniformly distributed between zero and DelayJitter.
+	DelayJitter time.Duration
+	// ErrorPercent is the percentage of requests to fail with ErrorCode, from 0 to 100.
+	ErrorPercent float64
+	// ErrorCode is the status code of failed requests.
+	ErrorCode codes.Code
+}
+
+// Enabled returns true iff the configuration injects delays or errors.
+func (c Fault) Enabled() bool {
+	return (c.DelayPercent > 0 && c.Delay+c.DelayJitter > 0) || c.ErrorPercent > 0
+}
+
+// FaultInjection returns the configuration of the artificial latency and errors that the
+// greeter server injects into requests. By default, no faults are injected.
+//
+// If `FAULT_DELAY` or `FAULT_DELAY_JITTER` is set, `FAULT_DELAY_PERCENT` of the requests
+// (default 100) are delayed by `FAULT_DELAY`, plus a random delay of up to
+// `FAULT_DELAY_JITTER`. `FAULT_ERROR_PERCENT` of the requests fail with the status code
+// `FAULT_ERROR_CODE`, e.g., `UNAVAILABLE` (the default) or `INTERNAL`.
+func FaultInjection() (Fault, error) {
+	delay, err := nonNegativeDurationFromEnv(faultDelayEnvVar, 0)
+	if err != nil {
+		return Fault{}, err
+	}
+	delayJitter, err := nonNegativeDurationFromEnv(faultDelayJitterEnvVar, 0)
+	if err != nil {
+		return Fault{}, err
+	}
+	delayPercent, err := percentFromEnv(faultDelayPercentEnvVar, 100)
+	if err != nil {
+		return Fault{}, err
+	}
+	errorPercent, err := percentFromEnv(faultErrorPercentEnvVar, 0)
+	if err != nil {
+		return Fault{}, err
+	}
+	errorCode := codes.Unavailable
+	if errorCodeEnv, exists := os.LookupEnv(faultErrorCodeEnvVar); exists {
+		if err := errorCode.UnmarshalJSON([]byte(strconv.Quote(strings.ToUpper(errorCodeEnv)))); err != nil {
+			return Fault{}, fmt.Errorf("could not convert environment variable value %s=%s to status code: %w", faultErrorCodeEnvVar, errorCodeEnv, err)
+		}
+		if errorCode == codes.OK {
+			return Fault{}, fmt.Errorf("environment variable value %s=%s must not be OK", faultErrorCodeEnvVar, errorCodeEnv)
+		}
+	}
+	return Fault{
+		DelayPercent: delayPercent,
+		Delay:        delay,
+		DelayJitter:  delayJitter,
+		ErrorPercent: errorPercent,
+		ErrorCode:    errorCode,
+	}, nil
+}
+
+func percentFromEnv(envVar string, defaultPercent float64) (float64, error) {
+	percent := defaultPercent
+	if percentEnv, exists := os.LookupEnv(envVar); exists {
+		var err error
+		percent, err = strconv.ParseFloat(percentEnv, 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not convert environment variable value %s=%s to float: %w", envVar, percentEnv, err)
+		}
+		if percent < 0 || percent > 100 {
+			return 0, fmt.Errorf("environment variable value %s=%s must be between 0 and 100", envVar, percentEnv)
+		}
+	}
+	return percent, nil
+}
@@ -0,0 +1,149 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+
+	"google.golang.org/grpc/codes"
+)
+
+func TestFaultInjection(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    Fault
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: Fault{DelayPercent: 100, ErrorCode: codes.Unavailable},
+		},
+		{
+			name: "delay and errors",
+			env: map[string]string{
+				faultDelayEnvVar:        "100ms",
+				faultDelayJitterEnvVar:  "50ms",
+				faultDelayPercentEnvVar: "25",
+				faultErrorPercentEnvVar: "12.5",
+				faultErrorCodeEnvVar:    "internal",
+			},
+			want: Fault{
+				DelayPercent: 25,
+				Delay:        100 * time.Millisecond,
+				DelayJitter:  50 * time.Millisecond,
+				ErrorPercent: 12.5,
+				ErrorCode:    codes.Internal,
+			},
+		},
+		{
+			name:    "negative delay",
+			env:     map[string]string{faultDelayEnvVar: "-1s"},
+			wantErr: true,
+		},
+		{
+			name:    "delay is not a duration",
+			env:     map[string]string{faultDelayJitterEnvVar: "1"},
+			wantErr: true,
+		},
+		{
+			name:    "percent above 100",
+			env:     map[string]string{faultDelayPercentEnvVar: "100.1"},
+			wantErr: true,
+		},
+		{
+			name:    "negative percent",
+			env:     map[string]string{faultErrorPercentEnvVar: "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "percent is not a number",
+			env:     map[string]string{faultErrorPercentEnvVar: "half"},
+			wantErr: true,
+		},
+		{
+			name:    "unknown status code",
+			env:     map[string]string{faultErrorCodeEnvVar: "BROKEN"},
+			wantErr: true,
+		},
+		{
+			name:    "status code OK",
+			env:     map[string]string{faultErrorCodeEnvVar: "OK"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			got, err := FaultInjection()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("FaultInjection() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("FaultInjection() unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("FaultInjection() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
+
+func TestFaultEnabled(t *testing.T) {
+	tests := []struct {
+		name  string
+		fault Fault
+		want  bool
+	}{
+		{
+			name:  "defaults",
+			fault: Fault{DelayPercent: 100, ErrorCode: codes.Unavailable},
+			want:  false,
+		},
+		{
+			name:  "delay",
+			fault: Fault{DelayPercent: 100, Delay: time.Second},
+			want:  true,
+		},
+		{
+			name:  "jitter only",
+			fault: Fault{DelayPercent: 100, DelayJitter: time.Second},
+			want:  true,
+		},
+		{
+			name:  "delay of zero percent of requests",
+			fault: Fault{DelayPercent: 0, Delay: time.Second},
+			want:  false,
+		},
+		{
+			name:  "errors",
+			fault: Fault{ErrorPercent: 1, ErrorCode: codes.Internal},
+			want:  true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.fault.Enabled(); got != test.want {
+				t.Errorf("Enabled() = %t, want %t", got, test.want)
+			}
+		})
+	}
+}
@@ -33,8 +33,19 @@ import (
 var errNoLocality = errors.New("no locality information in the gRPC xDS bootstrap configuration")
 
 // GreeterName is constructed from the host name and the zone name.
-// The zone name is looked up from the.
-func GreeterName(ctx context.Context) string {
+func GreeterName(ctx context.Context, zone string) string {
+	return fmt.Sprintf("%s(%s)", PodName(ctx), zone)
+}
+
+// PodName returns the host name, which is the name of the Kubernetes Pod.
+func PodName(ctx context.Context) string {
+	return hostname(logging.FromContext(ctx))
+}
+
+// Zone returns the zone name of the Kubernetes cluster node where this Pod is
+// scheduled. The zone name is looked up from the gRPC xDS bootstrap
+// configuration, and if not found there, from the GCP metadata server.
+func Zone(ctx context.Context) string {
 	logger := logging.FromContext(ctx)
 	zone, err := zoneFromGRPCXDSBootstrapFile()
 	if err != nil || zone == "" {
@@ -43,7 +54,7 @@ func GreeterName(ctx context.Context) string {
 			logger.Error(err, "Could not determine the zone from the GCP metadata server")
 		}
 	}
-	return fmt.Sprintf("%s(%s)", hostname(logger), zone)
+	return zone
 }
 
 // hostname returns the host name, or a generated name if there is a problem looking up the host name.
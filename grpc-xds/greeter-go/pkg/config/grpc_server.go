@@ -0,0 +1,111 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"strconv"
+	"time"
+)
+
+// gRPC configuration defaults based on https://github.com/envoyproxy/go-control-plane/blob/v0.11.1/internal/example/server.go
+// The message size defaults are the defaults of grpc-go.
+const (
+	defaultKeepaliveTime        = 30 * time.Second
+	defaultKeepaliveTimeout     = 5 * time.Second
+	defaultKeepaliveMinTime     = 30 * time.Second
+	defaultMaxConcurrentStreams = 1000000
+	defaultMaxRecvMsgSize       = 4 * 1024 * 1024
+	defaultMaxSendMsgSize       = math.MaxInt32
+	keepaliveTimeEnvVar         = "GRPC_KEEPALIVE_TIME"
+	keepaliveTimeoutEnvVar      = "GRPC_KEEPALIVE_TIMEOUT"
+	keepaliveMinTimeEnvVar      = "GRPC_KEEPALIVE_MIN_TIME"
+	maxConcurrentStreamsEnvVar  = "GRPC_MAX_CONCURRENT_STREAMS"
+	maxRecvMsgSizeEnvVar        = "GRPC_MAX_RECV_MSG_SIZE"
+	maxSendMsgSizeEnvVar        = "GRPC_MAX_SEND_MSG_SIZE"
+)
+
+// GRPCServer contains the keepalive parameters and limits of the greeter gRPC server.
+type GRPCServer struct {
+	// KeepaliveTime is the interval of server keepalive pings.
+	KeepaliveTime time.Duration
+	// KeepaliveTimeout is how long the server waits for a keepalive ping response before closing the connection.
+	KeepaliveTimeout time.Duration
+	// KeepaliveMinTime is the minimum interval between client keepalive pings.
+	KeepaliveMinTime     time.Duration
+	MaxConcurrentStreams uint32
+	// MaxRecvMsgSize and MaxSendMsgSize are the maximum sizes of received and sent messages, in bytes.
+	MaxRecvMsgSize int
+	MaxSendMsgSize int
+}
+
+// GRPCServerConfig returns the keepalive parameters and limits of the gRPC server from
+// environment variables, with the same names as in the xDS control plane, e.g., `GRPC_KEEPALIVE_TIME`.
+func GRPCServerConfig() (GRPCServer, error) {
+	var c GRPCServer
+	var err error
+	if c.KeepaliveTime, err = positiveDurationFromEnv(keepaliveTimeEnvVar, defaultKeepaliveTime); err != nil {
+		return GRPCServer{}, err
+	}
+	if c.KeepaliveTimeout, err = positiveDurationFromEnv(keepaliveTimeoutEnvVar, defaultKeepaliveTimeout); err != nil {
+		return GRPCServer{}, err
+	}
+	if c.KeepaliveMinTime, err = positiveDurationFromEnv(keepaliveMinTimeEnvVar, defaultKeepaliveMinTime); err != nil {
+		return GRPCServer{}, err
+	}
+	maxConcurrentStreams, err := positiveIntFromEnv(maxConcurrentStreamsEnvVar, defaultMaxConcurrentStreams)
+	if err != nil {
+		return GRPCServer{}, err
+	}
+	if int64(maxConcurrentStreams) > math.MaxUint32 {
+		return GRPCServer{}, fmt.Errorf("environment variable value %s=%d must not exceed %d", maxConcurrentStreamsEnvVar, maxConcurrentStreams, uint32(math.MaxUint32))
+	}
+	c.MaxConcurrentStreams = uint32(maxConcurrentStreams)
+	if c.MaxRecvMsgSize, err = positiveIntFromEnv(maxRecvMsgSizeEnvVar, defaultMaxRecvMsgSize); err != nil {
+		return GRPCServer{}, err
+	}
+	if c.MaxSendMsgSize, err = positiveIntFromEnv(maxSendMsgSizeEnvVar, defaultMaxSendMsgSize); err != nil {
+		return GRPCServer{}, err
+	}
+	return c, nil
+}
+
+func positiveDurationFromEnv(envVar string, defaultDuration time.Duration) (time.Duration, error) {
+	duration, err := durationFromEnv(envVar, defaultDuration)
+	if err != nil {
+		return 0, err
+	}
+	if duration <= 0 {
+		return 0, fmt.Errorf("environment variable value %s=%s must be positive", envVar, duration)
+	}
+	return duration, nil
+}
+
+func positiveIntFromEnv(envVar string, defaultValue int) (int, error) {
+	value := defaultValue
+	if valueEnv, exists := os.LookupEnv(envVar); exists {
+		var err error
+		value, err = strconv.Atoi(valueEnv)
+		if err != nil {
+			return 0, fmt.Errorf("could not convert environment variable value %s=%s to integer: %w", envVar, valueEnv, err)
+		}
+	}
+	if value <= 0 {
+		return 0, fmt.Errorf("environment variable value %s=%d must be positive", envVar, value)
+	}
+	return value, nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestGRPCServerConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		env     map[string]string
+		want    GRPCServer
+		wantErr bool
+	}{
+		{
+			name: "defaults",
+			want: GRPCServer{
+				KeepaliveTime:        defaultKeepaliveTime,
+				KeepaliveTimeout:     defaultKeepaliveTimeout,
+				KeepaliveMinTime:     defaultKeepaliveMinTime,
+				MaxConcurrentStreams: defaultMaxConcurrentStreams,
+				MaxRecvMsgSize:       defaultMaxRecvMsgSize,
+				MaxSendMsgSize:       defaultMaxSendMsgSize,
+			},
+		},
+		{
+			name: "environment variables",
+			env: map[string]string{
+				keepaliveTimeEnvVar:        "1m",
+				keepaliveTimeoutEnvVar:     "10s",
+				keepaliveMinTimeEnvVar:     "15s",
+				maxConcurrentStreamsEnvVar: "4294967295",
+				maxRecvMsgSizeEnvVar:       "1024",
+				maxSendMsgSizeEnvVar:       "2048",
+			},
+			want: GRPCServer{
+				KeepaliveTime:        time.Minute,
+				KeepaliveTimeout:     10 * time.Second,
+				KeepaliveMinTime:     15 * time.Second,
+				MaxConcurrentStreams: 4294967295,
+				MaxRecvMsgSize:       1024,
+				MaxSendMsgSize:       2048,
+			},
+		},
+		{
+			name:    "zero keepalive time",
+			env:     map[string]string{keepaliveTimeEnvVar: "0s"},
+			wantErr: true,
+		},
+		{
+			name:    "negative keepalive timeout",
+			env:     map[string]string{keepaliveTimeoutEnvVar: "-5s"},
+			wantErr: true,
+		},
+		{
+			name:    "keepalive min time is not a duration",
+			env:     map[string]string{keepaliveMinTimeEnvVar: "30"},
+			wantErr: true,
+		},
+		{
+			name:    "zero max concurrent streams",
+			env:     map[string]string{maxConcurrentStreamsEnvVar: "0"},
+			wantErr: true,
+		},
+		{
+			name:    "max concurrent streams above max uint32",
+			env:     map[string]string{maxConcurrentStreamsEnvVar: "4294967296"},
+			wantErr: true,
+		},
+		{
+			name:    "negative max receive message size",
+			env:     map[string]string{maxRecvMsgSizeEnvVar: "-1"},
+			wantErr: true,
+		},
+		{
+			name:    "max send message size is not an integer",
+			env:     map[string]string{maxSendMsgSizeEnvVar: "4MiB"},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for key, value := range test.env {
+				t.Setenv(key, value)
+			}
+			got, err := GRPCServerConfig()
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("GRPCServerConfig() = %+v, want error", got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("GRPCServerConfig() unexpected error: %v", err)
+			}
+			if got != test.want {
+				t.Errorf("GRPCServerConfig() = %+v, want %+v", got, test.want)
+			}
+		})
+	}
+}
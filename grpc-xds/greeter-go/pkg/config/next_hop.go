@@ -15,9 +15,102 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 )
 
-func NextHop() string {
-	return os.Getenv("NEXT_HOP")
+const (
+	defaultNextHopHedgingDelay        = 100 * time.Millisecond
+	defaultNextHopRetryInitialBackoff = 100 * time.Millisecond
+	defaultNextHopRetryMaxBackoff     = 1 * time.Second
+	nextHopTimeoutEnvVar              = "NEXT_HOP_TIMEOUT"
+	nextHopHedgingMaxAttemptsEnvVar   = "NEXT_HOP_HEDGING_MAX_ATTEMPTS"
+	nextHopHedgingDelayEnvVar         = "NEXT_HOP_HEDGING_DELAY"
+	nextHopRetryMaxAttemptsEnvVar     = "NEXT_HOP_RETRY_MAX_ATTEMPTS"
+	nextHopRetryInitialBackoffEnvVar  = "NEXT_HOP_RETRY_INITIAL_BACKOFF"
+	nextHopRetryMaxBackoffEnvVar      = "NEXT_HOP_RETRY_MAX_BACKOFF"
+	nextHopPolicyEnvVar               = "NEXT_HOP_POLICY"
+)
+
+// NextHops returns the comma-separated targets of the `NEXT_HOP` environment
+// variable. No targets means that the greeter is a leaf.
+func NextHops() []string {
+	var nextHops []string
+	for _, nextHop := range strings.Split(os.Getenv("NEXT_HOP"), ",") {
+		if nextHop = strings.TrimSpace(nextHop); nextHop != "" {
+			nextHops = append(nextHops, nextHop)
+		}
+	}
+	return nextHops
+}
+
+// NextHopPolicy returns how requests choose among multiple next hops, one of
+// `round_robin` (the default), `random`, or `fan_out`.
+func NextHopPolicy() string {
+	return os.Getenv(nextHopPolicyEnvVar)
+}
+
+// NextHopTimeout returns the deadline of requests to the next hop. Zero means
+// that requests to the next hop only use the deadline of the incoming request.
+func NextHopTimeout() (time.Duration, error) {
+	return durationFromEnv(nextHopTimeoutEnvVar, 0)
+}
+
+// NextHopHedgingMaxAttempts returns the maximum number of hedged attempts of each
+// request to the next hop, including the original request. Values less than 2
+// disable hedging, and the default is 0.
+func NextHopHedgingMaxAttempts() (int, error) {
+	return maxAttemptsFromEnv(nextHopHedgingMaxAttemptsEnvVar)
+}
+
+// NextHopHedgingDelay returns how long to wait for a response from the next hop
+// before sending a hedged attempt.
+func NextHopHedgingDelay() (time.Duration, error) {
+	return durationFromEnv(nextHopHedgingDelayEnvVar, defaultNextHopHedgingDelay)
+}
+
+// NextHopRetryMaxAttempts returns the maximum number of attempts of each request
+// to the next hop, including the original request. Values less than 2 disable
+// retries, and the default is 0.
+func NextHopRetryMaxAttempts() (int, error) {
+	return maxAttemptsFromEnv(nextHopRetryMaxAttemptsEnvVar)
+}
+
+// NextHopRetryInitialBackoff returns the upper bound of the randomized delay before
+// the first retry of a request to the next hop.
+func NextHopRetryInitialBackoff() (time.Duration, error) {
+	return durationFromEnv(nextHopRetryInitialBackoffEnvVar, defaultNextHopRetryInitialBackoff)
+}
+
+// NextHopRetryMaxBackoff returns the cap of the randomized delay before each retry
+// of a request to the next hop.
+func NextHopRetryMaxBackoff() (time.Duration, error) {
+	return durationFromEnv(nextHopRetryMaxBackoffEnvVar, defaultNextHopRetryMaxBackoff)
+}
+
+func maxAttemptsFromEnv(envVar string) (int, error) {
+	maxAttempts := 0
+	if maxAttemptsEnv, exists := os.LookupEnv(envVar); exists {
+		var err error
+		maxAttempts, err = strconv.Atoi(maxAttemptsEnv)
+		if err != nil {
+			return 0, fmt.Errorf("could not convert environment variable value %s=%s to integer: %w", envVar, maxAttemptsEnv, err)
+		}
+	}
+	return maxAttempts, nil
+}
+
+func durationFromEnv(envVar string, defaultDuration time.Duration) (time.Duration, error) {
+	duration := defaultDuration
+	if durationEnv, exists := os.LookupEnv(envVar); exists {
+		var err error
+		duration, err = time.ParseDuration(durationEnv)
+		if err != nil {
+			return 0, fmt.Errorf("could not convert environment variable value %s=%s to duration: %w", envVar, durationEnv, err)
+		}
+	}
+	return duration, nil
 }
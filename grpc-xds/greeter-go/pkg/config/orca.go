@@ -0,0 +1,46 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+const (
+	applicationUtilizationEnvVar = "ORCA_APPLICATION_UTILIZATION"
+)
+
+// ApplicationUtilization returns the custom application utilization that this
+// greeter server reports to clients as an ORCA backend metric.
+//
+// Setting different values for different deployments makes it possible to
+// demonstrate weighted round-robin load balancing based on custom load.
+// Clients use CPU utilization instead if this value is 0, which is the default.
+func ApplicationUtilization() (float64, error) {
+	utilization := 0.0
+	if utilizationEnv, exists := os.LookupEnv(applicationUtilizationEnvVar); exists {
+		var err error
+		utilization, err = strconv.ParseFloat(utilizationEnv, 64)
+		if err != nil {
+			return 0, fmt.Errorf("could not convert environment variable value %s=%s to float: %w", applicationUtilizationEnvVar, utilizationEnv, err)
+		}
+		if utilization < 0 {
+			return 0, fmt.Errorf("environment variable value %s=%s must not be negative", applicationUtilizationEnvVar, utilizationEnv)
+		}
+	}
+	return utilization, nil
+}
@@ -27,6 +27,7 @@ const (
 	servingPortEnvVar     = "PORT"
 	healthPortEnvVar      = "HEALTH_PORT"
 	httpHealthPortEnvVar  = "HTTP_HEALTH_PORT"
+	httpGatewayPortEnvVar = "HTTP_GATEWAY_PORT"
 )
 
 func ServingPort() (int, error) {
@@ -64,3 +65,17 @@ func HTTPHealthPort() (int, error) {
 	}
 	return port, nil
 }
+
+// HTTPGatewayPort returns the port of the optional HTTP/REST gateway to the
+// Greeter service. The gateway is disabled if the port is 0, which is the default.
+func HTTPGatewayPort() (int, error) {
+	port := 0
+	if portEnv, exists := os.LookupEnv(httpGatewayPortEnvVar); exists {
+		var err error
+		port, err = strconv.Atoi(portEnv)
+		if err != nil {
+			return 0, fmt.Errorf("could not convert environment variable value %s=%s to integer: %w", httpGatewayPortEnvVar, portEnv, err)
+		}
+	}
+	return port, nil
+}
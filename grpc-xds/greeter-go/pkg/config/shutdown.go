@@ -0,0 +1,51 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package config
+
+import (
+	"fmt"
+	"time"
+)
+
+const (
+	defaultGracefulShutdownTimeout = 5 * time.Second
+	gracefulShutdownTimeoutEnvVar  = "GRACEFUL_SHUTDOWN_TIMEOUT"
+	drainIntervalEnvVar            = "DRAIN_INTERVAL"
+)
+
+// GracefulShutdownTimeout returns how long the greeter server waits for open
+// requests to finish on shutdown, before stopping immediately. The default is 5s.
+func GracefulShutdownTimeout() (time.Duration, error) {
+	return nonNegativeDurationFromEnv(gracefulShutdownTimeoutEnvVar, defaultGracefulShutdownTimeout)
+}
+
+// DrainInterval returns how long the greeter server reports NOT_SERVING health
+// status on shutdown before it starts the graceful shutdown. This gives
+// readiness probes and xDS clients time to switch to other replicas. The
+// default is 0, which starts the graceful shutdown immediately.
+func DrainInterval() (time.Duration, error) {
+	return nonNegativeDurationFromEnv(drainIntervalEnvVar, 0)
+}
+
+func nonNegativeDurationFromEnv(envVar string, defaultDuration time.Duration) (time.Duration, error) {
+	duration, err := durationFromEnv(envVar, defaultDuration)
+	if err != nil {
+		return 0, err
+	}
+	if duration < 0 {
+		return 0, fmt.Errorf("environment variable value %s=%s must not be negative", envVar, duration)
+	}
+	return duration, nil
+}
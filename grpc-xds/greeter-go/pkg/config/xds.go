@@ -15,7 +15,11 @@
 package config
 
 import (
+	"fmt"
 	"os"
+	"slices"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/xdsclient/bootstrap"
 )
 
 // UseXDS determines if the gRPC server should connect with an xDS control
@@ -31,3 +35,32 @@ func UseXDS() bool {
 	}
 	return false
 }
+
+// UseXDSCredentials determines if the gRPC xDS bootstrap configuration contains
+// certificate providers, so that the xDS server-side credentials can use mTLS.
+//
+// It returns an error if a `file_watcher` certificate provider references a
+// file that does not exist, since gRPC would otherwise only fail when the
+// control plane sends a Listener with a TLS configuration.
+func UseXDSCredentials() (bool, error) {
+	if !UseXDS() {
+		return false, nil
+	}
+	bootstrapConfig, err := bootstrap.NewConfigPartial()
+	if err != nil {
+		return false, fmt.Errorf("could not parse the gRPC xDS bootstrap configuration: %w", err)
+	}
+	instances := make([]string, 0, len(bootstrapConfig.FileWatcherConfigs))
+	for instance := range bootstrapConfig.FileWatcherConfigs {
+		instances = append(instances, instance)
+	}
+	slices.Sort(instances)
+	for _, instance := range instances {
+		for _, file := range bootstrapConfig.FileWatcherConfigs[instance].Files() {
+			if _, err := os.Stat(file); err != nil {
+				return false, fmt.Errorf("certificate provider instance %s in the gRPC xDS bootstrap configuration references file %s, check the workload certificate volume mount of the Pod, or remove the certificate provider: %w", instance, file, err)
+			}
+		}
+	}
+	return len(bootstrapConfig.CertProviderConfigs) > 0, nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package connectioninfo implements the connection.ConnectionInfo gRPC service, which returns the
+// identity of the caller as seen by the server, for verifying mTLS configuration.
+package connectioninfo
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	connectionpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/connection"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+var errNoCertificate = errors.New("no PEM-encoded certificate found")
+
+// service implements connection.ConnectionInfo.
+type service struct {
+	connectionpb.UnimplementedConnectionInfoServer
+	logger logr.Logger
+	// certificateFile is the path of the workload certificate chain file of the server.
+	certificateFile string
+}
+
+func NewService(ctx context.Context, certificateFile string) connectionpb.ConnectionInfoServer {
+	return &service{
+		logger:          logging.FromContext(ctx),
+		certificateFile: certificateFile,
+	}
+}
+
+// GetConnectionInfo returns the transport security details of the connection of the caller, and
+// the expiry of the local workload certificate. The TLS fields are empty for plaintext connections,
+// e.g., on the health port, or on the serving port without xDS server-side TLS.
+func (s *service) GetConnectionInfo(ctx context.Context, _ *connectionpb.ConnectionInfoRequest) (*connectionpb.ConnectionInfoReply, error) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil, status.Error(codes.Internal, "no peer information in the request context")
+	}
+	reply := &connectionpb.ConnectionInfoReply{
+		PeerAddress: p.Addr.String(),
+	}
+	if p.AuthInfo != nil {
+		reply.AuthType = p.AuthInfo.AuthType()
+	}
+	if tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo); ok {
+		setTLSInfo(reply, tlsInfo)
+	}
+	localCertificate, err := readCertificate(s.certificateFile)
+	if err != nil {
+		s.logger.V(4).Info("Could not read the local workload certificate", "certificateFile", s.certificateFile, "error", err.Error())
+	} else {
+		reply.LocalSpiffeId = spiffeID(localCertificate)
+		reply.LocalCertificateExpiry = timestamppb.New(localCertificate.NotAfter)
+	}
+	s.logger.V(2).Info("Returning connection info", "peerAddress", reply.GetPeerAddress(), "authType", reply.GetAuthType(), "peerSpiffeId", reply.GetPeerSpiffeId())
+	return reply, nil
+}
+
+func setTLSInfo(reply *connectionpb.ConnectionInfoReply, tlsInfo credentials.TLSInfo) {
+	reply.TlsVersion = tls.VersionName(tlsInfo.State.Version)
+	reply.CipherSuite = tls.CipherSuiteName(tlsInfo.State.CipherSuite)
+	reply.NegotiatedProtocol = tlsInfo.State.NegotiatedProtocol
+	if tlsInfo.SPIFFEID != nil {
+		reply.PeerSpiffeId = tlsInfo.SPIFFEID.String()
+	}
+	if len(tlsInfo.State.PeerCertificates) == 0 {
+		return
+	}
+	peerCertificate := tlsInfo.State.PeerCertificates[0]
+	reply.PeerSubjectAltNames = subjectAltNames(peerCertificate)
+	reply.PeerCertificateExpiry = timestamppb.New(peerCertificate.NotAfter)
+	if reply.PeerSpiffeId == "" {
+		reply.PeerSpiffeId = spiffeID(peerCertificate)
+	}
+}
+
+// subjectAltNames returns the SANs of the certificate, with the same type prefixes as OpenSSL.
+func subjectAltNames(certificate *x509.Certificate) []string {
+	var names []string
+	for _, uri := range certificate.URIs {
+		names = append(names, "URI:"+uri.String())
+	}
+	for _, dnsName := range certificate.DNSNames {
+		names = append(names, "DNS:"+dnsName)
+	}
+	for _, ipAddress := range certificate.IPAddresses {
+		names = append(names, "IP:"+ipAddress.String())
+	}
+	for _, emailAddress := range certificate.EmailAddresses {
+		names = append(names, "email:"+emailAddress)
+	}
+	return names
+}
+
+// spiffeID returns the first URI SAN with the `spiffe` scheme, or an empty string.
+func spiffeID(certificate *x509.Certificate) string {
+	for _, uri := range certificate.URIs {
+		if uri.Scheme == "spiffe" {
+			return uri.String()
+		}
+	}
+	return ""
+}
+
+// readCertificate returns the first certificate in the PEM-encoded certificate chain file.
+// The file is read on every request, since the certificates are rotated.
+func readCertificate(certificateFile string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(certificateFile)
+	if err != nil {
+		return nil, fmt.Errorf("could not read certificate file %s: %w", certificateFile, err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, fmt.Errorf("%w in file %s", errNoCertificate, certificateFile)
+	}
+	certificate, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse certificate in file %s: %w", certificateFile, err)
+	}
+	return certificate, nil
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package csdsdump fetches the xDS client configuration of a greeter application
+// using the Client Status Discovery Service (CSDS), and prints the resources with
+// their status in a human-readable format.
+package csdsdump
+
+import (
+	"cmp"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	adminv3 "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	statusv3 "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/signals"
+)
+
+// typeURLPrefix is the common prefix of the xDS resource type URLs.
+const typeURLPrefix = "type.googleapis.com/envoy.config."
+
+// resourceTypes maps xDS resource type URLs to the short names of the discovery services,
+// in the order that clients request them.
+var resourceTypes = []struct {
+	typeURL string
+	name    string
+}{
+	{typeURL: typeURLPrefix + "listener.v3.Listener", name: "LDS"},
+	{typeURL: typeURLPrefix + "route.v3.RouteConfiguration", name: "RDS"},
+	{typeURL: typeURLPrefix + "cluster.v3.Cluster", name: "CDS"},
+	{typeURL: typeURLPrefix + "endpoint.v3.ClusterLoadAssignment", name: "EDS"},
+}
+
+// Run parses the command line flags, fetches the xDS client configuration from the
+// CSDS service at the address, and prints it to stdout.
+func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
+	ctx = signals.SetupSignalHandler(ctx)
+	var addr string
+	var showResources bool
+	var timeout time.Duration
+	flagset.StringVar(&addr, "addr", "localhost:50052", "address of the admin services, e.g., the health port of a greeter Pod")
+	flagset.BoolVar(&showResources, "resources", false, "print the contents of each resource as JSON")
+	flagset.DurationVar(&timeout, "timeout", 10*time.Second, "deadline of the CSDS request")
+	if err := flagset.Parse(args); err != nil {
+		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
+	}
+
+	clientConn, err := grpc.NewClient(addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		return fmt.Errorf("could not create gRPC client for address=%s: %w", addr, err)
+	}
+	defer clientConn.Close()
+	requestCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := statusv3.NewClientStatusDiscoveryServiceClient(clientConn).FetchClientStatus(requestCtx, &statusv3.ClientStatusRequest{
+		ExcludeResourceContents: !showResources,
+	})
+	if err != nil {
+		return fmt.Errorf("could not fetch client status from address=%s: %w", addr, err)
+	}
+	return printClientStatus(os.Stdout, resp, showResources)
+}
+
+// printClientStatus writes the resources of each xDS client in the response, grouped by resource type.
+func printClientStatus(w io.Writer, resp *statusv3.ClientStatusResponse, showResources bool) error {
+	if len(resp.GetConfig()) == 0 {
+		_, err := fmt.Fprintln(w, "No xDS clients, the application may not have created any xDS channels or servers yet.")
+		return err
+	}
+	for _, clientConfig := range resp.GetConfig() {
+		if err := printClientConfig(w, clientConfig, showResources); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func printClientConfig(w io.Writer, clientConfig *statusv3.ClientConfig, showResources bool) error {
+	_, _ = fmt.Fprintf(w, "xDS client: scope=%s node=%s zone=%s\n",
+		cmp.Or(clientConfig.GetClientScope(), "(default)"),
+		clientConfig.GetNode().GetId(),
+		clientConfig.GetNode().GetLocality().GetZone())
+	configs := slices.Clone(clientConfig.GetGenericXdsConfigs())
+	slices.SortStableFunc(configs, func(a, b *statusv3.ClientConfig_GenericXdsConfig) int {
+		return cmp.Or(cmp.Compare(typeOrder(a.GetTypeUrl()), typeOrder(b.GetTypeUrl())), strings.Compare(a.GetName(), b.GetName()))
+	})
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "  TYPE\tSTATUS\tVERSION\tLAST UPDATED\tNAME")
+	for _, config := range configs {
+		_, _ = fmt.Fprintf(tw, "  %s\t%s\t%s\t%s\t%s\n",
+			typeName(config.GetTypeUrl()),
+			clientStatus(config.GetClientStatus()),
+			cmp.Or(config.GetVersionInfo(), "-"),
+			lastUpdated(config),
+			config.GetName())
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("could not write resources: %w", err)
+	}
+	for _, config := range configs {
+		if errorState := config.GetErrorState(); errorState != nil {
+			_, _ = fmt.Fprintf(w, "  %s %s rejected version=%s: %s\n",
+				typeName(config.GetTypeUrl()), config.GetName(), errorState.GetVersionInfo(), errorState.GetDetails())
+		}
+	}
+	if showResources {
+		for _, config := range configs {
+			if config.GetXdsConfig() == nil {
+				continue
+			}
+			resourceJSON, err := protojson.MarshalOptions{Multiline: true, Indent: "  "}.Marshal(config.GetXdsConfig())
+			if err != nil {
+				return fmt.Errorf("could not marshal %s resource %s to JSON: %w", typeName(config.GetTypeUrl()), config.GetName(), err)
+			}
+			_, _ = fmt.Fprintf(w, "\n%s %s:\n%s\n", typeName(config.GetTypeUrl()), config.GetName(), resourceJSON)
+		}
+	}
+	_, err := fmt.Fprintln(w)
+	return err
+}
+
+// typeOrder returns the position of the resource type in `resourceTypes`, or
+// `len(resourceTypes)` for other resource types.
+func typeOrder(typeURL string) int {
+	for i, resourceType := range resourceTypes {
+		if resourceType.typeURL == typeURL {
+			return i
+		}
+	}
+	return len(resourceTypes)
+}
+
+// typeName returns the short name of the discovery service for the resource type, e.g., `LDS`.
+func typeName(typeURL string) string {
+	for _, resourceType := range resourceTypes {
+		if resourceType.typeURL == typeURL {
+			return resourceType.name
+		}
+	}
+	return typeURL[strings.LastIndex(typeURL, "/")+1:]
+}
+
+// clientStatus returns the client status, e.g., `ACKED`, `NACKED`, `REQUESTED`, or `DOES_NOT_EXIST`.
+func clientStatus(status adminv3.ClientResourceStatus) string {
+	if status == adminv3.ClientResourceStatus_UNKNOWN {
+		return "-"
+	}
+	return status.String()
+}
+
+func lastUpdated(config *statusv3.ClientConfig_GenericXdsConfig) string {
+	if config.GetLastUpdated() == nil {
+		return "-"
+	}
+	return config.GetLastUpdated().AsTime().Local().Format(time.DateTime)
+}
@@ -0,0 +1,66 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package echo implements the echo.Echo gRPC service, a sample application with
+// configurable response sizes and latencies.
+package echo
+
+import (
+	"context"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	echopb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/echo"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+// MaxResponseSize is the largest payload that the echo service returns, in bytes.
+// Larger replies would exceed the default maximum receive message size of gRPC clients.
+const MaxResponseSize = 1 << 20
+
+// service implements echo.Echo.
+type service struct {
+	echopb.UnimplementedEchoServer
+	logger logr.Logger
+}
+
+func NewService(ctx context.Context) echopb.EchoServer {
+	return &service{
+		logger: logging.FromContext(ctx),
+	}
+}
+
+func (s *service) Echo(ctx context.Context, request *echopb.EchoRequest) (*echopb.EchoReply, error) {
+	s.logger.V(2).Info("Received echo request", "responseSize", request.GetResponseSize(), "latency", request.GetLatency().AsDuration(), "requestID", interceptors.RequestIDFromContext(ctx))
+	if request.GetResponseSize() > MaxResponseSize {
+		return nil, status.Errorf(codes.InvalidArgument, "response size %d exceeds the maximum of %d bytes", request.GetResponseSize(), MaxResponseSize)
+	}
+	if latency := request.GetLatency().AsDuration(); latency > 0 {
+		timer := time.NewTimer(latency)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return nil, status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+	}
+	return &echopb.EchoReply{
+		Message: request.GetMessage(),
+		Payload: make([]byte, request.GetResponseSize()),
+	}, nil
+}
@@ -21,73 +21,149 @@ import (
 	"time"
 
 	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	xdscredentials "google.golang.org/grpc/credentials/xds"
 	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
 	"google.golang.org/grpc/keepalive"
 
+	streamingpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/streaming"
+	tracepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/trace"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/telemetry"
 )
 
 const (
-	grpcClientDialTimeout      = 10 * time.Second
 	grpcClientKeepaliveTime    = 30 * time.Second
 	grpcClientKeepaliveTimeout = 5 * time.Second
 	grpcClientIdleTimeout      = math.MaxInt64 // good idea?
+	meterName                  = "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greeter"
 )
 
 type Client struct {
-	logger  logr.Logger
-	nextHop string
-	client  helloworldpb.GreeterClient
+	logger          logr.Logger
+	nextHop         string
+	client          helloworldpb.GreeterClient
+	streamingClient streamingpb.StreamingGreeterClient
+	tracingClient   tracepb.TracingGreeterClient
 }
 
-func NewClient(ctx context.Context, nextHop string) (*Client, error) {
+// NewClient creates a greeter client for the target `nextHop`. The client connection
+// does not block on name resolution or connection establishment. Instead, transient
+// failures of the connection are logged and counted in the
+// `greeter.client.connection.failures` metric. The client connection is closed when
+// the context is done.
+func NewClient(ctx context.Context, nextHop string, opts ...ClientOption) (*Client, error) {
 	logger := logging.FromContext(ctx)
-	dialOpts, err := dialOptions(logger)
+	var clientOpts clientOptions
+	for _, opt := range opts {
+		opt(&clientOpts)
+	}
+	dialOpts, err := dialOptions(logger, clientOpts)
 	if err != nil {
 		return nil, fmt.Errorf("could not configure greeter client connection dial options: %w", err)
 	}
+	failuresCounter, err := otel.Meter(meterName).Int64Counter("greeter.client.connection.failures",
+		metric.WithDescription("Number of times the greeter client connection entered the TRANSIENT_FAILURE state, e.g., due to name resolution errors."),
+		metric.WithUnit("{failure}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create greeter client connection failures counter: %w", err)
+	}
 	clientConn, err := grpc.NewClient(nextHop, dialOpts...)
 	if err != nil {
 		return nil, fmt.Errorf("could not create a virtual connection to target=%s: %w", nextHop, err)
 	}
+	go watchConnectivityState(ctx, logger, nextHop, clientConn, failuresCounter)
 	addClientConnectionCloseBehavior(ctx, logger, clientConn)
 	return &Client{
-		client:  helloworldpb.NewGreeterClient(clientConn),
-		logger:  logger,
-		nextHop: nextHop,
+		client:          helloworldpb.NewGreeterClient(clientConn),
+		streamingClient: streamingpb.NewStreamingGreeterClient(clientConn),
+		tracingClient:   tracepb.NewTracingGreeterClient(clientConn),
+		logger:          logger,
+		nextHop:         nextHop,
 	}, nil
 }
 
-func (c *Client) SayHello(requestCtx context.Context, name string) (string, error) {
-	resp, err := c.client.SayHello(requestCtx, &helloworldpb.HelloRequest{Name: name}, grpc.WaitForReady(true))
+// SayHello sends a greeting request. Requests wait for the connection to be ready,
+// unless the provided call options override this.
+func (c *Client) SayHello(requestCtx context.Context, name string, opts ...grpc.CallOption) (string, error) {
+	resp, err := c.client.SayHello(requestCtx, &helloworldpb.HelloRequest{Name: name}, append([]grpc.CallOption{grpc.WaitForReady(true)}, opts...)...)
 	if err != nil {
 		return "", fmt.Errorf("could not greet name=%s at target=%s: %w", name, c.nextHop, err)
 	}
 	return resp.GetMessage(), nil
 }
 
-// dialOptions sets parameters for client connection establishment.
-func dialOptions(logger logr.Logger) ([]grpc.DialOption, error) {
-	logger.V(1).Info("Using xDS client-side credentials, with insecure as fallback")
-	clientCredentials, err := xdscredentials.NewClientCredentials(xdscredentials.ClientOptions{FallbackCreds: insecure.NewCredentials()})
+// SayHelloStream starts a greeting stream. The stream waits for the connection to be ready,
+// unless the provided call options override this.
+func (c *Client) SayHelloStream(requestCtx context.Context, request *streamingpb.HelloStreamRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[streamingpb.HelloStreamReply], error) {
+	stream, err := c.streamingClient.SayHelloStream(requestCtx, request, append([]grpc.CallOption{grpc.WaitForReady(true)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not start greeting stream for name=%s at target=%s: %w", request.GetName(), c.nextHop, err)
+	}
+	return stream, nil
+}
+
+// TraceHello sends a tracing greeting request. Requests wait for the connection to be ready,
+// unless the provided call options override this.
+func (c *Client) TraceHello(requestCtx context.Context, request *tracepb.TraceHelloRequest, opts ...grpc.CallOption) (*tracepb.TraceHelloReply, error) {
+	reply, err := c.tracingClient.TraceHello(requestCtx, request, append([]grpc.CallOption{grpc.WaitForReady(true)}, opts...)...)
+	if err != nil {
+		return nil, fmt.Errorf("could not trace greeting for name=%s at target=%s: %w", request.GetName(), c.nextHop, err)
+	}
+	return reply, nil
+}
+
+// dialOptions sets parameters for client connection establishment. The dial options
+// from the client options are applied after the default dial options.
+func dialOptions(logger logr.Logger, clientOpts clientOptions) ([]grpc.DialOption, error) {
+	clientCredentials := clientOpts.transportCredentials
+	if clientCredentials == nil {
+		logger.V(1).Info("Using xDS client-side credentials, with insecure as fallback")
+		var err error
+		clientCredentials, err = xdscredentials.NewClientCredentials(xdscredentials.ClientOptions{FallbackCreds: insecure.NewCredentials()})
+		if err != nil {
+			return nil, fmt.Errorf("could not create client-side transport credentials for xDS: %w", err)
+		}
+	}
+	peerLocalityStatsHandler, err := telemetry.ClientPeerLocalityStatsHandler()
 	if err != nil {
-		return nil, fmt.Errorf("could not create client-side transport credentials for xDS: %w", err)
+		return nil, fmt.Errorf("could not create greeter client peer locality stats handler: %w", err)
 	}
-	return []grpc.DialOption{
-		grpc.WithChainStreamInterceptor(interceptors.StreamClientLogging(logger)),
-		grpc.WithChainUnaryInterceptor(interceptors.UnaryClientLogging(logger)),
+	dialOpts := []grpc.DialOption{
+		grpc.WithChainStreamInterceptor(append([]grpc.StreamClientInterceptor{interceptors.StreamClientRequestID(), interceptors.StreamClientLogging(logger)}, clientOpts.streamInterceptors...)...),
+		grpc.WithChainUnaryInterceptor(append([]grpc.UnaryClientInterceptor{interceptors.UnaryClientRequestID(), interceptors.UnaryClientLogging(logger)}, clientOpts.unaryInterceptors...)...),
 		grpc.WithIdleTimeout(time.Duration(grpcClientIdleTimeout)),
 		grpc.WithKeepaliveParams(keepalive.ClientParameters{
 			Time:                grpcClientKeepaliveTime,
 			Timeout:             grpcClientKeepaliveTimeout,
 			PermitWithoutStream: true,
 		}),
+		grpc.WithStatsHandler(telemetry.ClientStatsHandler()),
+		grpc.WithStatsHandler(peerLocalityStatsHandler),
 		grpc.WithTransportCredentials(clientCredentials),
-	}, nil
+	}
+	return append(dialOpts, clientOpts.dialOpts...), nil
+}
+
+// watchConnectivityState logs and counts transient failures of the client connection,
+// until the context is done. With the non-blocking client connection, requests that
+// wait for the connection to be ready would otherwise hide name resolution errors.
+func watchConnectivityState(ctx context.Context, logger logr.Logger, target string, cc *grpc.ClientConn, failuresCounter metric.Int64Counter) {
+	state := cc.GetState()
+	for cc.WaitForStateChange(ctx, state) {
+		state = cc.GetState()
+		logger.V(2).Info("Greeter client connection state changed", "target", target, "state", state.String())
+		if state == connectivity.TransientFailure {
+			logger.Error(nil, "Greeter client connection failed, check name resolution and the next hop", "target", target)
+			failuresCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("target", target)))
+		}
+	}
 }
 
 func addClientConnectionCloseBehavior(ctx context.Context, logger logr.Logger, clientConn *grpc.ClientConn) {
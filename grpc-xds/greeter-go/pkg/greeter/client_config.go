@@ -0,0 +1,110 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+)
+
+// ClientConfig provides the per-call deadline, retry, and hedging parameters of requests to the
+// next hop, and how requests choose among multiple next hops.
+type ClientConfig struct {
+	// Timeout is the deadline of SayHello requests. The deadline of the incoming request
+	// still applies if it is earlier. Zero means no additional deadline.
+	Timeout time.Duration
+	// HedgingMaxAttempts is the maximum number of attempts of each SayHello request,
+	// including the original request. Values less than 2 disable hedging.
+	HedgingMaxAttempts int
+	// HedgingDelay is how long to wait for a response before sending a hedged attempt.
+	HedgingDelay time.Duration
+	// RetryMaxAttempts is the maximum number of attempts of each SayHello request,
+	// including the original request. Values less than 2 disable retries.
+	RetryMaxAttempts int
+	// RetryInitialBackoff is the upper bound of the randomized delay before the first retry.
+	RetryInitialBackoff time.Duration
+	// RetryMaxBackoff caps the upper bound of the randomized delay before each retry.
+	RetryMaxBackoff time.Duration
+	// PickPolicy determines how requests choose among multiple next hops. The empty
+	// string means round-robin.
+	PickPolicy PickPolicy
+}
+
+type serviceConfig struct {
+	MethodConfig []methodConfig `json:"methodConfig"`
+}
+
+type methodConfig struct {
+	Name    []methodName `json:"name"`
+	Timeout string       `json:"timeout,omitempty"`
+}
+
+type methodName struct {
+	Service string `json:"service"`
+}
+
+// DialOptions returns the dial options that apply the configuration when the target does
+// not use the `xds` scheme. With xDS, the control plane provides this configuration.
+//
+// The deadline is set in the default service config, which is only used if the name
+// resolver does not provide a service config. gRPC-Go does not implement the `hedgingPolicy`
+// of the service config, so hedging uses a client interceptor instead. Retries also use a
+// client interceptor, with a retry budget shared by all requests to the target.
+func (c ClientConfig) DialOptions(logger logr.Logger, target string) ([]grpc.DialOption, error) {
+	if strings.HasPrefix(target, "xds:") {
+		return nil, nil
+	}
+	var opts []grpc.DialOption
+	if c.Timeout > 0 {
+		serviceConfigJSON, err := json.Marshal(serviceConfig{
+			MethodConfig: []methodConfig{{
+				Name:    []methodName{{Service: helloworldpb.Greeter_ServiceDesc.ServiceName}},
+				Timeout: fmt.Sprintf("%.9fs", c.Timeout.Seconds()),
+			}},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not marshal default service config: %w", err)
+		}
+		logger.V(1).Info("Using default service config for the greeter client", "serviceConfig", string(serviceConfigJSON))
+		opts = append(opts, grpc.WithDefaultServiceConfig(string(serviceConfigJSON)))
+	}
+	if c.RetryMaxAttempts > 1 {
+		logger.V(1).Info("Retrying greeter client requests", "maxAttempts", c.RetryMaxAttempts, "initialBackoff", c.RetryInitialBackoff, "maxBackoff", c.RetryMaxBackoff)
+		retryInterceptor, err := interceptors.UnaryClientRetry(logger, interceptors.RetryConfig{
+			MaxAttempts:    c.RetryMaxAttempts,
+			InitialBackoff: c.RetryInitialBackoff,
+			MaxBackoff:     c.RetryMaxBackoff,
+			RetryableCodes: []codes.Code{codes.Unavailable},
+		})
+		if err != nil {
+			return nil, fmt.Errorf("could not create greeter client retry interceptor: %w", err)
+		}
+		opts = append(opts, grpc.WithChainUnaryInterceptor(retryInterceptor))
+	}
+	if c.HedgingMaxAttempts > 1 {
+		logger.V(1).Info("Hedging greeter client requests", "maxAttempts", c.HedgingMaxAttempts, "hedgingDelay", c.HedgingDelay)
+		opts = append(opts, grpc.WithChainUnaryInterceptor(interceptors.UnaryClientHedging(logger, c.HedgingMaxAttempts, c.HedgingDelay, codes.Unavailable)))
+	}
+	return opts, nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+// ClientOption customizes the client connection created by NewClient.
+type ClientOption func(*clientOptions)
+
+type clientOptions struct {
+	dialOpts             []grpc.DialOption
+	transportCredentials credentials.TransportCredentials
+	unaryInterceptors    []grpc.UnaryClientInterceptor
+	streamInterceptors   []grpc.StreamClientInterceptor
+}
+
+// WithDialOptions adds dial options that are applied after the default dial options.
+func WithDialOptions(opts ...grpc.DialOption) ClientOption {
+	return func(o *clientOptions) {
+		o.dialOpts = append(o.dialOpts, opts...)
+	}
+}
+
+// WithTransportCredentials replaces the default xDS client-side credentials, which
+// use insecure credentials as the fallback.
+func WithTransportCredentials(creds credentials.TransportCredentials) ClientOption {
+	return func(o *clientOptions) {
+		o.transportCredentials = creds
+	}
+}
+
+// WithUnaryInterceptors adds unary client interceptors that run after the default
+// request ID and logging interceptors.
+func WithUnaryInterceptors(interceptors ...grpc.UnaryClientInterceptor) ClientOption {
+	return func(o *clientOptions) {
+		o.unaryInterceptors = append(o.unaryInterceptors, interceptors...)
+	}
+}
+
+// WithStreamInterceptors adds stream client interceptors that run after the default
+// request ID and logging interceptors.
+func WithStreamInterceptors(interceptors ...grpc.StreamClientInterceptor) ClientOption {
+	return func(o *clientOptions) {
+		o.streamInterceptors = append(o.streamInterceptors, interceptors...)
+	}
+}
@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+// PickPolicy determines how a ClientPool chooses next hops for each request.
+type PickPolicy string
+
+const (
+	// PickRoundRobin sends each request to the next target in turn.
+	PickRoundRobin PickPolicy = "round_robin"
+	// PickRandom sends each request to a randomly chosen target.
+	PickRandom PickPolicy = "random"
+	// PickFanOut sends SayHello requests to all targets. Streaming and tracing
+	// requests fall back to round-robin, as their replies cannot be combined.
+	PickFanOut PickPolicy = "fan_out"
+)
+
+var (
+	errNoNextHops        = errors.New("no next hop targets")
+	errUnknownPickPolicy = errors.New("unknown next hop pick policy")
+)
+
+// ParsePickPolicy returns the pick policy with the provided name. The empty string
+// means round-robin.
+func ParsePickPolicy(name string) (PickPolicy, error) {
+	switch policy := PickPolicy(name); policy {
+	case "":
+		return PickRoundRobin, nil
+	case PickRoundRobin, PickRandom, PickFanOut:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("%w: %s", errUnknownPickPolicy, name)
+	}
+}
+
+// ClientPool holds greeter clients for one or more next hops, and picks the
+// target(s) of each request according to its policy. Client connections are
+// created on first use and cached per target, and they are closed when the
+// context passed to NewClientPool is done.
+type ClientPool struct {
+	ctx     context.Context
+	logger  logr.Logger
+	targets []string
+	policy  PickPolicy
+	// dialOptions returns the dial options of the client for each target, see
+	// `ClientConfig.DialOptions()`.
+	dialOptions func(logger logr.Logger, target string) ([]grpc.DialOption, error)
+	next        atomic.Uint64
+	mu          sync.Mutex
+	clients     map[string]*Client
+}
+
+// NewClientPool creates a client pool for the provided next hop targets.
+func NewClientPool(ctx context.Context, targets []string, clientConfig ClientConfig) (*ClientPool, error) {
+	if len(targets) == 0 {
+		return nil, errNoNextHops
+	}
+	policy := clientConfig.PickPolicy
+	if policy == "" {
+		policy = PickRoundRobin
+	}
+	return &ClientPool{
+		ctx:         ctx,
+		logger:      logging.FromContext(ctx),
+		targets:     targets,
+		policy:      policy,
+		dialOptions: clientConfig.DialOptions,
+		clients:     map[string]*Client{},
+	}, nil
+}
+
+// Pick returns the client for the next target according to the policy of the pool.
+func (p *ClientPool) Pick() (*Client, error) {
+	var target string
+	switch {
+	case len(p.targets) == 1:
+		target = p.targets[0]
+	case p.policy == PickRandom:
+		target = p.targets[rand.Intn(len(p.targets))]
+	default:
+		target = p.targets[(p.next.Add(1)-1)%uint64(len(p.targets))]
+	}
+	return p.client(target)
+}
+
+// SayHello sends a greeting request to the picked target, or to all targets concurrently
+// with the fan-out policy. Fan-out replies are joined in the order of the targets, and
+// the request fails if any of the targets fails.
+func (p *ClientPool) SayHello(requestCtx context.Context, name string, opts ...grpc.CallOption) (string, error) {
+	if p.policy != PickFanOut || len(p.targets) == 1 {
+		client, err := p.Pick()
+		if err != nil {
+			return "", err
+		}
+		return client.SayHello(requestCtx, name, opts...)
+	}
+	messages := make([]string, len(p.targets))
+	errs := make([]error, len(p.targets))
+	var wg sync.WaitGroup
+	for i, target := range p.targets {
+		wg.Add(1)
+		go func(i int, target string) {
+			defer wg.Done()
+			client, err := p.client(target)
+			if err != nil {
+				errs[i] = err
+				return
+			}
+			messages[i], errs[i] = client.SayHello(requestCtx, name, opts...)
+		}(i, target)
+	}
+	wg.Wait()
+	if err := errors.Join(errs...); err != nil {
+		return "", err
+	}
+	return strings.Join(messages, "; "), nil
+}
+
+// client returns the cached client for the target, and creates it if necessary.
+func (p *ClientPool) client(target string) (*Client, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if client, exists := p.clients[target]; exists {
+		return client, nil
+	}
+	dialOpts, err := p.dialOptions(p.logger, target)
+	if err != nil {
+		return nil, fmt.Errorf("could not configure greeter client for target=%s: %w", target, err)
+	}
+	client, err := NewClient(p.ctx, target, WithDialOptions(dialOpts...))
+	if err != nil {
+		return nil, err
+	}
+	p.logger.V(2).Info("Created greeter client", "target", target, "policy", p.policy)
+	p.clients[target] = client
+	return client, nil
+}
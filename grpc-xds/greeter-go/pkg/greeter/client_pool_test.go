@@ -0,0 +1,220 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/status"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+var errFakeDialOptions = errors.New("fake dial options error")
+
+// fakeClientConfig provides the dial options of the clients in a ClientPool, instead of
+// `ClientConfig.DialOptions()`, and counts the clients created for each target.
+type fakeClientConfig struct {
+	// dialers connect to the greeter server of each target. Targets without dialers fail.
+	dialers map[string]grpc.DialOption
+	calls   map[string]int
+}
+
+func (f *fakeClientConfig) DialOptions(_ logr.Logger, target string) ([]grpc.DialOption, error) {
+	f.calls[target]++
+	dialer, exists := f.dialers[target]
+	if !exists {
+		return nil, errFakeDialOptions
+	}
+	return []grpc.DialOption{dialer, grpc.WithTransportCredentials(insecure.NewCredentials())}, nil
+}
+
+// unavailableGreeter fails all requests.
+type unavailableGreeter struct {
+	helloworldpb.UnimplementedGreeterServer
+}
+
+func (unavailableGreeter) SayHello(context.Context, *helloworldpb.HelloRequest) (*helloworldpb.HelloReply, error) {
+	return nil, status.Error(codes.Unavailable, "unavailable")
+}
+
+// newTestClientPool creates a client pool that uses the fake client config.
+func newTestClientPool(ctx context.Context, t *testing.T, targets []string, policy PickPolicy, fake *fakeClientConfig) *ClientPool {
+	t.Helper()
+	pool, err := NewClientPool(ctx, targets, ClientConfig{PickPolicy: policy})
+	if err != nil {
+		t.Fatalf("NewClientPool() unexpected error: %v", err)
+	}
+	pool.dialOptions = fake.DialOptions
+	return pool
+}
+
+// pickTargets returns the targets of `n` picks from the pool.
+func pickTargets(t *testing.T, pool *ClientPool, n int) []string {
+	t.Helper()
+	var targets []string
+	for range n {
+		client, err := pool.Pick()
+		if err != nil {
+			t.Fatalf("Pick() unexpected error: %v", err)
+		}
+		targets = append(targets, client.nextHop)
+	}
+	return targets
+}
+
+func TestClientPoolPick(t *testing.T) {
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+	targets := []string{"passthrough:///a", "passthrough:///b", "passthrough:///c"}
+	dialers := map[string]grpc.DialOption{}
+	for _, target := range targets {
+		dialers[target] = startGreeterServer(t, NewLeafService(ctx, target))
+	}
+
+	t.Run("round-robin", func(t *testing.T) {
+		fake := &fakeClientConfig{dialers: dialers, calls: map[string]int{}}
+		pool := newTestClientPool(ctx, t, targets, PickRoundRobin, fake)
+		got := pickTargets(t, pool, 5)
+		want := []string{targets[0], targets[1], targets[2], targets[0], targets[1]}
+		if !slices.Equal(got, want) {
+			t.Errorf("picked targets = %v, want %v", got, want)
+		}
+	})
+
+	t.Run("random", func(t *testing.T) {
+		fake := &fakeClientConfig{dialers: dialers, calls: map[string]int{}}
+		pool := newTestClientPool(ctx, t, targets, PickRandom, fake)
+		picked := map[string]bool{}
+		for _, target := range pickTargets(t, pool, 100) {
+			if !slices.Contains(targets, target) {
+				t.Fatalf("picked target=%s, want one of %v", target, targets)
+			}
+			picked[target] = true
+		}
+		if len(picked) < 2 {
+			t.Errorf("picked targets = %v in 100 picks, want more than one target", picked)
+		}
+	})
+
+	t.Run("caches clients per target", func(t *testing.T) {
+		fake := &fakeClientConfig{dialers: dialers, calls: map[string]int{}}
+		pool := newTestClientPool(ctx, t, targets, PickRoundRobin, fake)
+		first, err := pool.Pick()
+		if err != nil {
+			t.Fatalf("Pick() unexpected error: %v", err)
+		}
+		pickTargets(t, pool, 2*len(targets)-1)
+		again, err := pool.Pick()
+		if err != nil {
+			t.Fatalf("Pick() unexpected error: %v", err)
+		}
+		if first != again {
+			t.Errorf("Pick() created a new client for target=%s, want the cached client", first.nextHop)
+		}
+		for _, target := range targets {
+			if fake.calls[target] != 1 {
+				t.Errorf("created %d clients for target=%s, want 1", fake.calls[target], target)
+			}
+		}
+	})
+
+	t.Run("fails for targets without dial options", func(t *testing.T) {
+		fake := &fakeClientConfig{calls: map[string]int{}}
+		pool := newTestClientPool(ctx, t, targets, PickRoundRobin, fake)
+		if _, err := pool.Pick(); !errors.Is(err, errFakeDialOptions) {
+			t.Errorf("Pick() error = %v, want %v", err, errFakeDialOptions)
+		}
+	})
+}
+
+func TestClientPoolSayHelloFanOut(t *testing.T) {
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+	dialers := map[string]grpc.DialOption{
+		"passthrough:///a":           startGreeterServer(t, NewLeafService(ctx, "a")),
+		"passthrough:///b":           startGreeterServer(t, NewLeafService(ctx, "b")),
+		"passthrough:///unavailable": startGreeterServer(t, unavailableGreeter{}),
+	}
+	tests := []struct {
+		name      string
+		targets   []string
+		want      string
+		wantErrs  []error
+		wantCodes []codes.Code
+	}{
+		{
+			name:    "joins replies in the order of the targets",
+			targets: []string{"passthrough:///b", "passthrough:///a"},
+			want:    "Hello test, from b; Hello test, from a",
+		},
+		{
+			name:      "joins errors of all failed targets",
+			targets:   []string{"passthrough:///a", "passthrough:///unavailable", "passthrough:///no-dialer"},
+			wantErrs:  []error{errFakeDialOptions},
+			wantCodes: []codes.Code{codes.Unavailable},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			fake := &fakeClientConfig{dialers: dialers, calls: map[string]int{}}
+			pool := newTestClientPool(ctx, t, test.targets, PickFanOut, fake)
+			got, err := pool.SayHello(ctx, "test")
+			if len(test.wantErrs) == 0 && len(test.wantCodes) == 0 && err != nil {
+				t.Fatalf("SayHello() unexpected error: %v", err)
+			}
+			for _, wantErr := range test.wantErrs {
+				if !errors.Is(err, wantErr) {
+					t.Errorf("SayHello() error = %v, want %v", err, wantErr)
+				}
+			}
+			for _, wantCode := range test.wantCodes {
+				if !hasStatusCode(err, wantCode) {
+					t.Errorf("SayHello() error = %v, want code %s", err, wantCode)
+				}
+			}
+			if got != test.want {
+				t.Errorf("SayHello() = %q, want %q", got, test.want)
+			}
+			for _, target := range test.targets {
+				if fake.calls[target] != 1 {
+					t.Errorf("created %d clients for target=%s, want 1", fake.calls[target], target)
+				}
+			}
+		})
+	}
+}
+
+// hasStatusCode determines if any of the joined errors has the gRPC status code.
+func hasStatusCode(err error, code codes.Code) bool {
+	joined, ok := err.(interface{ Unwrap() []error })
+	if !ok {
+		return status.Code(err) == code
+	}
+	for _, err := range joined.Unwrap() {
+		if status.Code(err) == code {
+			return true
+		}
+	}
+	return false
+}
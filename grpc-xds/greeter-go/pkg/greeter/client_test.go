@@ -0,0 +1,74 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+// startGreeterServer starts a greeter server on an in-memory listener, and returns a dial
+// option that connects to the server.
+func startGreeterServer(t *testing.T, service helloworldpb.GreeterServer) grpc.DialOption {
+	t.Helper()
+	listener := bufconn.Listen(1024 * 1024)
+	server := grpc.NewServer()
+	helloworldpb.RegisterGreeterServer(server, service)
+	go func() {
+		_ = server.Serve(listener)
+	}()
+	t.Cleanup(server.Stop)
+	return grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+		return listener.DialContext(ctx)
+	})
+}
+
+func TestNewClientWithOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(logging.NewContext(context.Background(), logr.Discard()))
+	defer cancel()
+	dialer := startGreeterServer(t, NewLeafService(ctx, "leaf"))
+	var methods []string
+	recordMethod := func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		methods = append(methods, method)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+	client, err := NewClient(ctx, "passthrough:///leaf",
+		WithTransportCredentials(insecure.NewCredentials()),
+		WithUnaryInterceptors(recordMethod),
+		WithDialOptions(dialer))
+	if err != nil {
+		t.Fatalf("NewClient() unexpected error: %v", err)
+	}
+	greeting, err := client.SayHello(ctx, "test")
+	if err != nil {
+		t.Fatalf("SayHello() unexpected error: %v", err)
+	}
+	if !strings.Contains(greeting, "test") {
+		t.Errorf("SayHello() = %q, want a greeting for name=test", greeting)
+	}
+	if len(methods) != 1 || methods[0] != helloworldpb.Greeter_SayHello_FullMethodName {
+		t.Errorf("unary interceptor methods = %v, want [%s]", methods, helloworldpb.Greeter_SayHello_FullMethodName)
+	}
+}
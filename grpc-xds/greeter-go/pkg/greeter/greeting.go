@@ -0,0 +1,45 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"strings"
+)
+
+const (
+	// UnknownZone is returned by ParseGreeting if the greeting does not contain a zone.
+	UnknownZone = "unknown"
+	fromPrefix  = ", from "
+	viaPrefix   = ", via "
+)
+
+// ParseGreeting returns the name and zone of the greeter server that returned the greeting.
+//
+// Greetings from greeter-leaf have the format `Hello [name], from [hostname]([zone])`.
+// Greetings from greeter-intermediary have `, via [hostname]([zone])` appended,
+// and the greeter server is the intermediary.
+func ParseGreeting(greeting string) (string, string) {
+	greeterName := greeting
+	if i := strings.LastIndex(greeting, viaPrefix); i >= 0 {
+		greeterName = greeting[i+len(viaPrefix):]
+	} else if i := strings.LastIndex(greeting, fromPrefix); i >= 0 {
+		greeterName = greeting[i+len(fromPrefix):]
+	}
+	zone := UnknownZone
+	if start, end := strings.LastIndex(greeterName, "("), strings.LastIndex(greeterName, ")"); start >= 0 && end > start+1 {
+		zone = greeterName[start+1 : end]
+	}
+	return greeterName, zone
+}
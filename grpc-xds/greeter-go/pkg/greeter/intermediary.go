@@ -17,6 +17,7 @@ package greeter
 import (
 	"context"
 	"fmt"
+	"time"
 
 	"github.com/go-logr/logr"
 	"google.golang.org/genproto/googleapis/rpc/errdetails"
@@ -26,28 +27,29 @@ import (
 	"google.golang.org/protobuf/encoding/protojson"
 	"google.golang.org/protobuf/proto"
 
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
 )
 
 // intermediaryService implements helloworld.Greeter.
 type intermediaryService struct {
 	helloworldpb.UnimplementedGreeterServer
-	logger        logr.Logger
-	name          string
-	greeterClient *Client
+	logger         logr.Logger
+	name           string
+	greeterClients *ClientPool
 }
 
-func NewIntermediaryService(ctx context.Context, name string, greeterClient *Client) helloworldpb.GreeterServer {
+func NewIntermediaryService(ctx context.Context, name string, greeterClients *ClientPool) helloworldpb.GreeterServer {
 	return &intermediaryService{
-		logger:        logging.FromContext(ctx),
-		name:          name,
-		greeterClient: greeterClient,
+		logger:         logging.FromContext(ctx),
+		name:           name,
+		greeterClients: greeterClients,
 	}
 }
 
 func (s *intermediaryService) SayHello(ctx context.Context, request *helloworldpb.HelloRequest) (*helloworldpb.HelloReply, error) {
-	s.logger.V(2).Info("Received request, forwarding to the next hop", "name", request.Name)
-	intermediaryMessage, err := s.greeterClient.SayHello(ctx, request.GetName())
+	s.logger.V(2).Info("Received request, forwarding to the next hop", "name", request.Name, "deadline", remainingDeadline(ctx), "requestID", interceptors.RequestIDFromContext(ctx))
+	intermediaryMessage, err := s.greeterClients.SayHello(ctx, request.GetName())
 	if err != nil {
 		logGreeterError(s.logger, err, "Greeting request failed, returning error code internal")
 		st, errSt := createStatus(codes.Internal, "greeter request failed")
@@ -82,6 +84,16 @@ func logGreeterError(logger logr.Logger, err error, message string, keysAndValue
 	logger.Error(err, message, keysAndValues...)
 }
 
+// remainingDeadline returns the time remaining until the deadline of the request, to show
+// how deadlines propagate through the chain of greeter services.
+func remainingDeadline(ctx context.Context) string {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		return "none"
+	}
+	return time.Until(deadline).Round(time.Millisecond).String()
+}
+
 func createStatus(code codes.Code, message string) (*status.Status, error) {
 	st := status.New(code, message)
 	return st.WithDetails(&errdetails.ErrorInfo{
@@ -0,0 +1,85 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+
+	streamingpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/streaming"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+// intermediaryStreamingService implements helloworld.StreamingGreeter.
+type intermediaryStreamingService struct {
+	streamingpb.UnimplementedStreamingGreeterServer
+	logger         logr.Logger
+	name           string
+	greeterClients *ClientPool
+}
+
+func NewIntermediaryStreamingService(ctx context.Context, name string, greeterClients *ClientPool) streamingpb.StreamingGreeterServer {
+	return &intermediaryStreamingService{
+		logger:         logging.FromContext(ctx),
+		name:           name,
+		greeterClients: greeterClients,
+	}
+}
+
+// SayHelloStream relays the greetings streamed by the next hop. The stream to the
+// next hop is canceled when the client cancels the stream to this server.
+func (s *intermediaryStreamingService) SayHelloStream(request *streamingpb.HelloStreamRequest, stream grpc.ServerStreamingServer[streamingpb.HelloStreamReply]) error {
+	s.logger.V(2).Info("Received streaming request, forwarding to the next hop", "name", request.GetName(), "count", request.GetCount(), "requestID", interceptors.RequestIDFromContext(stream.Context()))
+	greeterClient, err := s.greeterClients.Pick()
+	if err != nil {
+		return s.streamError(err)
+	}
+	nextHopStream, err := greeterClient.SayHelloStream(stream.Context(), request)
+	if err != nil {
+		return s.streamError(err)
+	}
+	for {
+		reply, err := nextHopStream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return s.streamError(err)
+		}
+		if err := stream.Send(&streamingpb.HelloStreamReply{
+			Message:  fmt.Sprintf("%s, via %s", reply.GetMessage(), s.name),
+			Sequence: reply.GetSequence(),
+		}); err != nil {
+			return err
+		}
+	}
+}
+
+func (s *intermediaryStreamingService) streamError(err error) error {
+	logGreeterError(s.logger, err, "Greeting stream failed, returning error code internal")
+	st, errSt := createStatus(codes.Internal, "greeter stream failed")
+	if errSt != nil {
+		// Should not happen
+		s.logger.Error(errSt, "Could not append ErrorInfo to Status")
+	}
+	return st.Err()
+}
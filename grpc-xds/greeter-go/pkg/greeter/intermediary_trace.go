@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+
+	tracepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/trace"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+// intermediaryTracingService implements helloworld.TracingGreeter.
+type intermediaryTracingService struct {
+	tracepb.UnimplementedTracingGreeterServer
+	logger         logr.Logger
+	name           string
+	podName        string
+	zone           string
+	greeterClients *ClientPool
+}
+
+func NewIntermediaryTracingService(ctx context.Context, name string, podName string, zone string, greeterClients *ClientPool) tracepb.TracingGreeterServer {
+	return &intermediaryTracingService{
+		logger:         logging.FromContext(ctx),
+		name:           name,
+		podName:        podName,
+		zone:           zone,
+		greeterClients: greeterClients,
+	}
+}
+
+// TraceHello forwards the request to the next hop, and adds the hop of this server
+// in front of the hops returned by the next hop.
+func (s *intermediaryTracingService) TraceHello(ctx context.Context, request *tracepb.TraceHelloRequest) (*tracepb.TraceHelloReply, error) {
+	start := time.Now()
+	s.logger.V(2).Info("Received tracing request, forwarding to the next hop", "name", request.GetName(), "deadline", remainingDeadline(ctx), "requestID", interceptors.RequestIDFromContext(ctx))
+	greeterClient, err := s.greeterClients.Pick()
+	if err != nil {
+		return nil, s.traceError(err)
+	}
+	nextHopReply, err := greeterClient.TraceHello(ctx, request)
+	if err != nil {
+		return nil, s.traceError(err)
+	}
+	hop := newTraceHop(ctx, start, s.name, s.podName, s.zone, greeterClient.nextHop)
+	return &tracepb.TraceHelloReply{
+		Message: fmt.Sprintf("%s, via %s", nextHopReply.GetMessage(), s.name),
+		Hops:    append([]*tracepb.TraceHop{hop}, nextHopReply.GetHops()...),
+	}, nil
+}
+
+func (s *intermediaryTracingService) traceError(err error) error {
+	logGreeterError(s.logger, err, "Tracing request failed, returning error code internal")
+	st, errSt := createStatus(codes.Internal, "greeter tracing request failed")
+	if errSt != nil {
+		// Should not happen
+		s.logger.Error(errSt, "Could not append ErrorInfo to Status")
+	}
+	return st.Err()
+}
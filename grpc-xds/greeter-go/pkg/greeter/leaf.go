@@ -21,6 +21,7 @@ import (
 	"github.com/go-logr/logr"
 	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
 
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
 )
 
@@ -38,7 +39,7 @@ func NewLeafService(ctx context.Context, name string) helloworldpb.GreeterServer
 	}
 }
 
-func (s *leafService) SayHello(_ context.Context, request *helloworldpb.HelloRequest) (*helloworldpb.HelloReply, error) {
-	s.logger.V(2).Info("Received request, returning greeting", "name", request.Name)
+func (s *leafService) SayHello(ctx context.Context, request *helloworldpb.HelloRequest) (*helloworldpb.HelloReply, error) {
+	s.logger.V(2).Info("Received request, returning greeting", "name", request.Name, "deadline", remainingDeadline(ctx), "requestID", interceptors.RequestIDFromContext(ctx))
 	return &helloworldpb.HelloReply{Message: fmt.Sprintf("Hello %s, from %s", request.Name, s.name)}, nil
 }
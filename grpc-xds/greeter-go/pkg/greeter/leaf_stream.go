@@ -0,0 +1,77 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	streamingpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/streaming"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+const defaultStreamInterval = 1 * time.Second
+
+// leafStreamingService implements helloworld.StreamingGreeter.
+type leafStreamingService struct {
+	streamingpb.UnimplementedStreamingGreeterServer
+	logger logr.Logger
+	name   string
+}
+
+func NewLeafStreamingService(ctx context.Context, name string) streamingpb.StreamingGreeterServer {
+	return &leafStreamingService{
+		logger: logging.FromContext(ctx),
+		name:   name,
+	}
+}
+
+func (s *leafStreamingService) SayHelloStream(request *streamingpb.HelloStreamRequest, stream grpc.ServerStreamingServer[streamingpb.HelloStreamReply]) error {
+	s.logger.V(2).Info("Received streaming request, returning greetings", "name", request.GetName(), "count", request.GetCount(), "requestID", interceptors.RequestIDFromContext(stream.Context()))
+	interval := defaultStreamInterval
+	if request.GetInterval() != nil {
+		interval = request.GetInterval().AsDuration()
+		if interval <= 0 {
+			return status.Errorf(codes.InvalidArgument, "interval must be positive, got %s", interval)
+		}
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for sequence := uint32(1); request.GetCount() == 0 || sequence <= request.GetCount(); sequence++ {
+		if err := stream.Send(&streamingpb.HelloStreamReply{
+			Message:  fmt.Sprintf("Hello %s, from %s", request.GetName(), s.name),
+			Sequence: sequence,
+		}); err != nil {
+			return err
+		}
+		if sequence == request.GetCount() {
+			break
+		}
+		select {
+		case <-stream.Context().Done():
+			s.logger.V(2).Info("Greeting stream ended by the client", "name", request.GetName(), "sent", sequence)
+			return stream.Context().Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	streamingpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/streaming"
+)
+
+// fakeGreetingStream records the replies sent on a server stream.
+type fakeGreetingStream struct {
+	grpc.ServerStream
+	ctx     context.Context
+	replies []*streamingpb.HelloStreamReply
+}
+
+func (f *fakeGreetingStream) Context() context.Context {
+	return f.ctx
+}
+
+func (f *fakeGreetingStream) Send(reply *streamingpb.HelloStreamReply) error {
+	f.replies = append(f.replies, reply)
+	return nil
+}
+
+func TestSayHelloStreamRejectsNonPositiveInterval(t *testing.T) {
+	service := NewLeafStreamingService(context.Background(), "leaf")
+	for _, interval := range []time.Duration{0, -time.Second} {
+		stream := &fakeGreetingStream{ctx: context.Background()}
+		err := service.SayHelloStream(&streamingpb.HelloStreamRequest{
+			Name:     "test",
+			Count:    3,
+			Interval: durationpb.New(interval),
+		}, stream)
+		if status.Code(err) != codes.InvalidArgument {
+			t.Errorf("interval=%s: got error %v, want code %s", interval, err, codes.InvalidArgument)
+		}
+		if len(stream.replies) != 0 {
+			t.Errorf("interval=%s: got %d replies, want none", interval, len(stream.replies))
+		}
+	}
+}
+
+func TestSayHelloStreamSendsCount(t *testing.T) {
+	service := NewLeafStreamingService(context.Background(), "leaf")
+	stream := &fakeGreetingStream{ctx: context.Background()}
+	err := service.SayHelloStream(&streamingpb.HelloStreamRequest{
+		Name:     "test",
+		Count:    3,
+		Interval: durationpb.New(time.Millisecond),
+	}, stream)
+	if err != nil {
+		t.Fatalf("SayHelloStream: %v", err)
+	}
+	if len(stream.replies) != 3 {
+		t.Fatalf("got %d replies, want 3", len(stream.replies))
+	}
+	for i, reply := range stream.replies {
+		if reply.GetSequence() != uint32(i+1) {
+			t.Errorf("reply %d: got sequence %d, want %d", i, reply.GetSequence(), i+1)
+		}
+	}
+}
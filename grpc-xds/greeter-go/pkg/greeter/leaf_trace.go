@@ -0,0 +1,73 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greeter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	tracepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/trace"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+)
+
+// leafTracingService implements helloworld.TracingGreeter.
+type leafTracingService struct {
+	tracepb.UnimplementedTracingGreeterServer
+	logger  logr.Logger
+	name    string
+	podName string
+	zone    string
+}
+
+func NewLeafTracingService(ctx context.Context, name string, podName string, zone string) tracepb.TracingGreeterServer {
+	return &leafTracingService{
+		logger:  logging.FromContext(ctx),
+		name:    name,
+		podName: podName,
+		zone:    zone,
+	}
+}
+
+func (s *leafTracingService) TraceHello(ctx context.Context, request *tracepb.TraceHelloRequest) (*tracepb.TraceHelloReply, error) {
+	start := time.Now()
+	s.logger.V(2).Info("Received tracing request, returning greeting", "name", request.GetName(), "requestID", interceptors.RequestIDFromContext(ctx))
+	return &tracepb.TraceHelloReply{
+		Message: fmt.Sprintf("Hello %s, from %s", request.GetName(), s.name),
+		Hops: []*tracepb.TraceHop{
+			newTraceHop(ctx, start, s.name, s.podName, s.zone, ""),
+		},
+	}, nil
+}
+
+// newTraceHop returns the hop of this greeter server, with the latency since the start time.
+func newTraceHop(ctx context.Context, start time.Time, name string, podName string, zone string, nextHop string) *tracepb.TraceHop {
+	hop := &tracepb.TraceHop{
+		GreeterName: name,
+		PodName:     podName,
+		Zone:        zone,
+		Latency:     durationpb.New(time.Since(start)),
+		NextHop:     nextHop,
+	}
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		hop.PeerAddress = p.Addr.String()
+	}
+	return hop
+}
@@ -21,22 +21,34 @@ import (
 	"github.com/go-logr/logr"
 	"google.golang.org/grpc"
 	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+
+	streamingpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/streaming"
+	tracepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/trace"
 )
 
-// RegisterServer registers the Greeter gRPC service to a server.
-func RegisterServer(ctx context.Context, logger logr.Logger, greeterName string, nextHop string, server grpc.ServiceRegistrar) error {
+// RegisterServer registers the Greeter, StreamingGreeter, and TracingGreeter gRPC services to a server,
+// and returns the Greeter and TracingGreeter service implementations.
+func RegisterServer(ctx context.Context, logger logr.Logger, greeterName string, podName string, zone string, nextHops []string, clientConfig ClientConfig, server grpc.ServiceRegistrar) (helloworldpb.GreeterServer, tracepb.TracingGreeterServer, error) {
 	var greeterService helloworldpb.GreeterServer
-	if nextHop == "" {
+	var streamingGreeterService streamingpb.StreamingGreeterServer
+	var tracingGreeterService tracepb.TracingGreeterServer
+	if len(nextHops) == 0 {
 		logger.V(1).Info("Adding leaf Greeter service, as NEXT_HOP is not provided")
 		greeterService = NewLeafService(ctx, greeterName)
+		streamingGreeterService = NewLeafStreamingService(ctx, greeterName)
+		tracingGreeterService = NewLeafTracingService(ctx, greeterName, podName, zone)
 	} else {
-		logger.V(1).Info("Adding intermediary Greeter service", "NEXT_HOP", nextHop)
-		greeterClient, err := NewClient(ctx, nextHop)
+		logger.V(1).Info("Adding intermediary Greeter service", "NEXT_HOP", nextHops, "pickPolicy", clientConfig.PickPolicy)
+		greeterClients, err := NewClientPool(ctx, nextHops, clientConfig)
 		if err != nil {
-			return fmt.Errorf("could not create greeter client %w", err)
+			return nil, nil, fmt.Errorf("could not create greeter client pool: %w", err)
 		}
-		greeterService = NewIntermediaryService(ctx, greeterName, greeterClient)
+		greeterService = NewIntermediaryService(ctx, greeterName, greeterClients)
+		streamingGreeterService = NewIntermediaryStreamingService(ctx, greeterName, greeterClients)
+		tracingGreeterService = NewIntermediaryTracingService(ctx, greeterName, podName, zone, greeterClients)
 	}
 	helloworldpb.RegisterGreeterServer(server, greeterService)
-	return nil
+	streamingpb.RegisterStreamingGreeterServer(server, streamingGreeterService)
+	tracepb.RegisterTracingGreeterServer(server, tracingGreeterService)
+	return greeterService, tracingGreeterService, nil
 }
@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package greetercli sends individual SayHello RPCs to greeter services, and
+// prints the peer address and zone of the greeter server that responded.
+package greetercli
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/signals"
+)
+
+const usage = `Enter a name to send a greeting request, or one of these commands:
+  /stream <count> <name>    receive a stream of greetings, a count of 0 streams until interrupted
+  /target <uri>             switch to another target, e.g., xds:///greeter-leaf, dns:///greeter-leaf:50051,
+                            or xdstp://xds-authority.example.com/envoy.config.listener.v3.Listener/greeter-leaf
+  /authority [authority]    set or clear the authority (:authority header) of requests
+  /header <key>=<value>     add a request metadata header, or remove it if the value is empty
+  /headers                  show the request metadata headers
+  /wait-for-ready <on|off>  toggle waiting for the connection to be ready
+  /help                     show this message
+  /quit                     exit
+`
+
+// Run parses the command line flags, and sends a greeting request for each remaining
+// command line argument. If there are no remaining arguments, Run reads names and
+// commands interactively from stdin.
+func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
+	ctx = signals.SetupSignalHandler(ctx)
+	logging.InitFlags(flagset)
+	headers := headerFlag{}
+	var target, authority string
+	var waitForReady bool
+	var timeout time.Duration
+	flagset.StringVar(&target, "target", "xds:///greeter-leaf", "gRPC channel target URI, using the dns, xds, or xdstp scheme")
+	flagset.StringVar(&authority, "authority", "", "authority (:authority header) of requests, defaults to the target")
+	flagset.Var(headers, "H", "request metadata header as `key=value`, can be repeated")
+	flagset.BoolVar(&waitForReady, "wait-for-ready", true, "wait for the connection to be ready instead of failing fast")
+	flagset.DurationVar(&timeout, "timeout", 10*time.Second, "deadline of each request")
+	if err := flagset.Parse(args); err != nil {
+		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
+	}
+	logger := logging.NewLogger()
+	logging.SetGRPCLogger(logger)
+	ctx = logging.NewContext(ctx, logger)
+
+	s := newSession(ctx, os.Stdout, target, authority, headers, waitForReady, timeout)
+	defer s.close()
+	if flagset.NArg() > 0 {
+		for _, name := range flagset.Args() {
+			if err := s.greet(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	return interactive(ctx, os.Stdin, s)
+}
+
+func interactive(ctx context.Context, in io.Reader, s *session) error {
+	s.printf("%s\n", usage)
+	scanner := bufio.NewScanner(in)
+	for {
+		s.printf("%s> ", s.target)
+		if !scanner.Scan() {
+			s.printf("\n")
+			return scanner.Err()
+		}
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if line == "/quit" || line == "/exit" {
+			return nil
+		}
+		if err := s.handle(line); err != nil {
+			s.printf("%v\n", err)
+		}
+		if ctx.Err() != nil {
+			return nil
+		}
+	}
+}
+
+// headerFlag collects request metadata headers from repeated command line flags.
+type headerFlag map[string]string
+
+func (h headerFlag) String() string {
+	pairs := make([]string, 0, len(h))
+	for key, value := range h {
+		pairs = append(pairs, key+"="+value)
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (h headerFlag) Set(header string) error {
+	key, value, found := strings.Cut(header, "=")
+	if !found || key == "" {
+		return fmt.Errorf("invalid header %q, expected key=value", header)
+	}
+	h[strings.ToLower(key)] = value
+	return nil
+}
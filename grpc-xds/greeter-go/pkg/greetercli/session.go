@@ -0,0 +1,228 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package greetercli
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+
+	streamingpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/streaming"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greeter"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+)
+
+var errUnknownCommand = errors.New("unknown command, enter /help to see the available commands")
+
+// session holds the greeter client and the request settings, which can be changed between requests.
+type session struct {
+	ctx          context.Context
+	out          io.Writer
+	target       string
+	authority    string
+	headers      map[string]string
+	waitForReady bool
+	timeout      time.Duration
+	client       *greeter.Client
+	closeClient  context.CancelFunc
+}
+
+func newSession(ctx context.Context, out io.Writer, target string, authority string, headers map[string]string, waitForReady bool, timeout time.Duration) *session {
+	return &session{
+		ctx:          ctx,
+		out:          out,
+		target:       target,
+		authority:    authority,
+		headers:      headers,
+		waitForReady: waitForReady,
+		timeout:      timeout,
+	}
+}
+
+// handle runs a command, or sends a greeting request if the line is not a command.
+func (s *session) handle(line string) error {
+	if !strings.HasPrefix(line, "/") {
+		return s.greet(line)
+	}
+	command, arg, _ := strings.Cut(line, " ")
+	arg = strings.TrimSpace(arg)
+	switch command {
+	case "/stream":
+		countArg, name, _ := strings.Cut(arg, " ")
+		count, err := strconv.ParseUint(countArg, 10, 32)
+		if err != nil {
+			return fmt.Errorf("invalid count %q: %w", countArg, err)
+		}
+		return s.greetStream(strings.TrimSpace(name), uint32(count))
+	case "/target":
+		if arg == "" {
+			return errors.New("missing target URI")
+		}
+		s.target = arg
+		s.close()
+	case "/authority":
+		s.authority = arg
+		s.close()
+	case "/header":
+		key, value, found := strings.Cut(arg, "=")
+		if !found || key == "" {
+			return fmt.Errorf("invalid header %q, expected key=value", arg)
+		}
+		if value == "" {
+			delete(s.headers, strings.ToLower(key))
+		} else {
+			s.headers[strings.ToLower(key)] = value
+		}
+	case "/headers":
+		for _, key := range slices.Sorted(maps.Keys(s.headers)) {
+			s.printf("%s: %s\n", key, s.headers[key])
+		}
+	case "/wait-for-ready":
+		switch arg {
+		case "on":
+			s.waitForReady = true
+		case "off":
+			s.waitForReady = false
+		default:
+			return fmt.Errorf("invalid value %q, expected on or off", arg)
+		}
+	case "/help":
+		s.printf("%s", usage)
+	default:
+		return errUnknownCommand
+	}
+	return nil
+}
+
+// greet sends a greeting request and prints the response.
+func (s *session) greet(name string) error {
+	client, err := s.greeterClient()
+	if err != nil {
+		return err
+	}
+	requestCtx, cancel := context.WithTimeout(s.ctx, s.timeout)
+	defer cancel()
+	for key, value := range s.headers {
+		requestCtx = metadata.AppendToOutgoingContext(requestCtx, key, value)
+	}
+	var p peer.Peer
+	var header, trailer metadata.MD
+	start := time.Now()
+	greeting, err := client.SayHello(requestCtx, name, grpc.Peer(&p), grpc.Header(&header), grpc.Trailer(&trailer), grpc.WaitForReady(s.waitForReady))
+	latency := time.Since(start)
+	if err != nil {
+		return err
+	}
+	greeterName, zone := greeter.ParseGreeting(greeting)
+	requestID := strings.Join(header.Get(interceptors.RequestIDHeader), ",")
+	s.printf("greeting: %s\npeer:     %s\ngreeter:  %s\nzone:     %s\nrequest:  %s\nlatency:  %s\n", greeting, p.Addr, greeterName, zone, requestID, latency.Round(time.Microsecond))
+	s.printBackendInfo(trailer)
+	return nil
+}
+
+// greetStream receives a stream of greetings and prints each greeting as it arrives.
+// Streams with a count of 0 end when the session context is done, e.g., on Ctrl+C.
+func (s *session) greetStream(name string, count uint32) error {
+	client, err := s.greeterClient()
+	if err != nil {
+		return err
+	}
+	streamCtx := s.ctx
+	if count > 0 {
+		var cancel context.CancelFunc
+		streamCtx, cancel = context.WithTimeout(s.ctx, s.timeout+time.Duration(count)*time.Second)
+		defer cancel()
+	}
+	for key, value := range s.headers {
+		streamCtx = metadata.AppendToOutgoingContext(streamCtx, key, value)
+	}
+	var p peer.Peer
+	stream, err := client.SayHelloStream(streamCtx, &streamingpb.HelloStreamRequest{Name: name, Count: count}, grpc.Peer(&p), grpc.WaitForReady(s.waitForReady))
+	if err != nil {
+		return err
+	}
+	start := time.Now()
+	for {
+		reply, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			s.printf("stream ended, peer: %s\n", p.Addr)
+			s.printBackendInfo(stream.Trailer())
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("greeting stream failed after %s: %w", time.Since(start).Round(time.Millisecond), err)
+		}
+		greeterName, zone := greeter.ParseGreeting(reply.GetMessage())
+		s.printf("%4d  %s  greeter: %s  zone: %s\n", reply.GetSequence(), reply.GetMessage(), greeterName, zone)
+	}
+}
+
+// printBackendInfo prints the trailing metadata about the greeter server that handled the request,
+// see `interceptors.UnaryServerBackendInfo()`. With greeter-intermediary, the trailers describe
+// the intermediary server, and its connection from this client.
+func (s *session) printBackendInfo(trailer metadata.MD) {
+	if len(trailer.Get(interceptors.PodTrailer)) == 0 {
+		return
+	}
+	s.printf("backend:  pod=%s zone=%s tls=%s client=%s\n",
+		strings.Join(trailer.Get(interceptors.PodTrailer), ","),
+		strings.Join(trailer.Get(interceptors.ZoneTrailer), ","),
+		strings.Join(trailer.Get(interceptors.TLSModeTrailer), ","),
+		cmp.Or(strings.Join(trailer.Get(interceptors.ClientSPIFFEIDTrailer), ","), "-"))
+}
+
+// greeterClient returns the client for the current target and authority, creating it if necessary.
+func (s *session) greeterClient() (*greeter.Client, error) {
+	if s.client != nil {
+		return s.client, nil
+	}
+	var opts []grpc.DialOption
+	if s.authority != "" {
+		opts = append(opts, grpc.WithAuthority(s.authority))
+	}
+	clientCtx, cancel := context.WithCancel(s.ctx)
+	client, err := greeter.NewClient(clientCtx, s.target, greeter.WithDialOptions(opts...))
+	if err != nil {
+		cancel()
+		return nil, fmt.Errorf("could not create greeter client for target=%s: %w", s.target, err)
+	}
+	s.client = client
+	s.closeClient = cancel
+	return client, nil
+}
+
+// close closes the current greeter client, if any.
+func (s *session) close() {
+	if s.closeClient != nil {
+		s.closeClient()
+	}
+	s.client = nil
+	s.closeClient = nil
+}
+
+func (s *session) printf(format string, a ...any) {
+	_, _ = fmt.Fprintf(s.out, format, a...)
+}
@@ -0,0 +1,97 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"strings"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Trailing metadata keys with information about the greeter server that handled the request.
+const (
+	PodTrailer            = "x-greeter-pod"
+	ZoneTrailer           = "x-greeter-zone"
+	TLSModeTrailer        = "x-greeter-tls-mode"
+	ClientSPIFFEIDTrailer = "x-greeter-client-spiffe-id"
+)
+
+// TLS modes of the connection from the client, as reported in the `x-greeter-tls-mode` trailer.
+const (
+	TLSModeMTLS      = "mtls"
+	TLSModeTLS       = "tls"
+	TLSModePlaintext = "plaintext"
+)
+
+// UnaryServerBackendInfo adds trailing metadata with the Pod name and zone of the server, the TLS
+// mode of the connection, and the SPIFFE ID of the client, if the client presented a certificate.
+// This allows clients to verify locality routing and mTLS without looking at server logs.
+func UnaryServerBackendInfo(podName string, zone string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		// Ignore the error, the trailers are only informational.
+		_ = grpc.SetTrailer(ctx, backendInfo(ctx, podName, zone))
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerBackendInfo is the streaming equivalent of `UnaryServerBackendInfo`.
+func StreamServerBackendInfo(podName string, zone string) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		stream.SetTrailer(backendInfo(stream.Context(), podName, zone))
+		return handler(srv, stream)
+	}
+}
+
+func backendInfo(ctx context.Context, podName string, zone string) metadata.MD {
+	tlsMode, clientSPIFFEID := peerTLSInfo(ctx)
+	md := metadata.Pairs(
+		PodTrailer, podName,
+		ZoneTrailer, zone,
+		TLSModeTrailer, tlsMode,
+	)
+	if clientSPIFFEID != "" {
+		md.Set(ClientSPIFFEIDTrailer, clientSPIFFEID)
+	}
+	return md
+}
+
+// peerTLSInfo returns the TLS mode of the connection, and the SPIFFE ID from the URI SAN of the
+// client certificate, if any.
+func peerTLSInfo(ctx context.Context) (string, string) {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return TLSModePlaintext, ""
+	}
+	tlsInfo, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok {
+		return TLSModePlaintext, ""
+	}
+	if len(tlsInfo.State.PeerCertificates) == 0 {
+		return TLSModeTLS, ""
+	}
+	if tlsInfo.SPIFFEID != nil {
+		return TLSModeMTLS, tlsInfo.SPIFFEID.String()
+	}
+	for _, uri := range tlsInfo.State.PeerCertificates[0].URIs {
+		if strings.EqualFold(uri.Scheme, "spiffe") {
+			return TLSModeMTLS, uri.String()
+		}
+	}
+	return TLSModeMTLS, ""
+}
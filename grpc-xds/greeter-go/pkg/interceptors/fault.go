@@ -0,0 +1,85 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"math/rand"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/config"
+)
+
+// faultExcludedServicePrefixes are the prefixes of infrastructure services that faults are never
+// injected into, such as health checking, reflection, Channelz, CSDS, and ORCA.
+var faultExcludedServicePrefixes = []string{"/grpc.", "/envoy.", "/xds."}
+
+// UnaryServerFault injects delays and errors into requests according to the configuration.
+func UnaryServerFault(fault config.Fault) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := injectFault(ctx, fault, info.FullMethod); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerFault is the streaming equivalent of `UnaryServerFault`. Faults are injected before
+// the stream handler runs.
+func StreamServerFault(fault config.Fault) grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := injectFault(stream.Context(), fault, info.FullMethod); err != nil {
+			return err
+		}
+		return handler(srv, stream)
+	}
+}
+
+// injectFault delays the request and returns an error, if the configuration selects the request.
+func injectFault(ctx context.Context, fault config.Fault, fullMethod string) error {
+	if !fault.Enabled() || faultExcluded(fullMethod) {
+		return nil
+	}
+	if fault.DelayPercent > 0 && rand.Float64()*100 < fault.DelayPercent {
+		delay := fault.Delay
+		if fault.DelayJitter > 0 {
+			delay += time.Duration(rand.Int63n(int64(fault.DelayJitter)))
+		}
+		timer := time.NewTimer(delay)
+		defer timer.Stop()
+		select {
+		case <-ctx.Done():
+			return status.FromContextError(ctx.Err()).Err()
+		case <-timer.C:
+		}
+	}
+	if fault.ErrorPercent > 0 && rand.Float64()*100 < fault.ErrorPercent {
+		return status.Errorf(fault.ErrorCode, "injected fault for %s", fullMethod)
+	}
+	return nil
+}
+
+func faultExcluded(fullMethod string) bool {
+	for _, prefix := range faultExcludedServicePrefixes {
+		if strings.HasPrefix(fullMethod, prefix) {
+			return true
+		}
+	}
+	return false
+}
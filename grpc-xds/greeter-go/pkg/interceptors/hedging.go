@@ -0,0 +1,155 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+// UnaryClientHedging sends up to `maxAttempts` attempts of each unary RPC, starting a new
+// attempt if no response has been received after `hedgingDelay`, or immediately if an attempt
+// fails with one of the `nonFatalCodes`. The first successful response is returned, and the
+// remaining attempts are canceled.
+//
+// The semantics follow the `hedgingPolicy` of the gRPC service config, see
+// https://github.com/grpc/proposal/blob/master/A6-client-retries.md#hedging-policy
+// gRPC-Go does not implement the hedging policy of the service config, so this interceptor
+// provides the equivalent behavior.
+//
+// The header, trailer, and peer call options of the caller are only populated from the attempt
+// whose response or error is returned, as concurrent attempts would otherwise race on them.
+func UnaryClientHedging(logger logr.Logger, maxAttempts int, hedgingDelay time.Duration, nonFatalCodes ...codes.Code) grpc.UnaryClientInterceptor {
+	isNonFatal := func(err error) bool {
+		code := status.Code(err)
+		for _, nonFatalCode := range nonFatalCodes {
+			if code == nonFatalCode {
+				return true
+			}
+		}
+		return false
+	}
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		replyMessage, ok := reply.(proto.Message)
+		if !ok || maxAttempts < 2 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		defer cancel() // cancel outstanding attempts
+		inputOpts, outputOpts := splitOutputCallOptions(opts)
+		type result struct {
+			reply   proto.Message
+			outputs *attemptOutputs
+			err     error
+		}
+		results := make(chan result, maxAttempts)
+		attempts, pending := 0, 0
+		startAttempt := func() {
+			attempts++
+			pending++
+			if attempts > 1 {
+				logger.V(2).Info("Sending hedged request", "method", method, "attempt", attempts)
+			}
+			attemptReply := proto.Clone(replyMessage)
+			outputs := &attemptOutputs{}
+			attemptOpts := append(slices.Clone(inputOpts), outputs.callOptions()...)
+			go func() {
+				err := invoker(ctx, method, req, attemptReply, cc, attemptOpts...)
+				results <- result{reply: attemptReply, outputs: outputs, err: err}
+			}()
+		}
+		startAttempt()
+		timer := time.NewTimer(hedgingDelay)
+		defer timer.Stop()
+		var last result
+		for {
+			select {
+			case r := <-results:
+				pending--
+				if r.err == nil {
+					proto.Reset(replyMessage)
+					proto.Merge(replyMessage, r.reply)
+					r.outputs.apply(outputOpts)
+					return nil
+				}
+				if !isNonFatal(r.err) {
+					r.outputs.apply(outputOpts)
+					return r.err
+				}
+				last = r
+				if attempts < maxAttempts {
+					startAttempt()
+					timer.Reset(hedgingDelay)
+				} else if pending == 0 {
+					last.outputs.apply(outputOpts)
+					return last.err
+				}
+			case <-timer.C:
+				if attempts < maxAttempts {
+					startAttempt()
+					timer.Reset(hedgingDelay)
+				}
+			}
+		}
+	}
+}
+
+// attemptOutputs holds the response header, trailer, and peer of one hedged attempt.
+type attemptOutputs struct {
+	header  metadata.MD
+	trailer metadata.MD
+	peer    peer.Peer
+}
+
+func (a *attemptOutputs) callOptions() []grpc.CallOption {
+	return []grpc.CallOption{grpc.Header(&a.header), grpc.Trailer(&a.trailer), grpc.Peer(&a.peer)}
+}
+
+// apply copies the outputs of the attempt to the header, trailer, and peer call options of the caller.
+func (a *attemptOutputs) apply(outputOpts []grpc.CallOption) {
+	for _, opt := range outputOpts {
+		switch o := opt.(type) {
+		case grpc.HeaderCallOption:
+			*o.HeaderAddr = a.header
+		case grpc.TrailerCallOption:
+			*o.TrailerAddr = a.trailer
+		case grpc.PeerCallOption:
+			*o.PeerAddr = a.peer
+		}
+	}
+}
+
+// splitOutputCallOptions separates the call options that write into caller-owned values, i.e.,
+// the header, trailer, and peer options, from the other call options.
+func splitOutputCallOptions(opts []grpc.CallOption) (inputOpts []grpc.CallOption, outputOpts []grpc.CallOption) {
+	for _, opt := range opts {
+		switch opt.(type) {
+		case grpc.HeaderCallOption, grpc.TrailerCallOption, grpc.PeerCallOption:
+			outputOpts = append(outputOpts, opt)
+		default:
+			inputOpts = append(inputOpts, opt)
+		}
+	}
+	return inputOpts, outputOpts
+}
@@ -0,0 +1,144 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const (
+	testHedgingDelay = 20 * time.Millisecond
+	testMethod       = "/helloworld.Greeter/SayHello"
+)
+
+// attemptFunc handles one attempt of a fake hedged RPC, numbered from 1.
+type attemptFunc func(ctx context.Context, attempt int32, reply *helloworldpb.HelloReply) error
+
+// fakeInvoker returns an invoker that calls handle for each attempt, and sets the response
+// header `attempt` through the header call option, like the gRPC client does.
+func fakeInvoker(attempts *atomic.Int32, handle attemptFunc) grpc.UnaryInvoker {
+	return func(ctx context.Context, _ string, _, reply any, _ *grpc.ClientConn, opts ...grpc.CallOption) error {
+		attempt := attempts.Add(1)
+		err := handle(ctx, attempt, reply.(*helloworldpb.HelloReply))
+		for _, opt := range opts {
+			if o, ok := opt.(grpc.HeaderCallOption); ok {
+				*o.HeaderAddr = metadata.Pairs("attempt", fmt.Sprint(attempt))
+			}
+		}
+		return err
+	}
+}
+
+func invokeHedging(t *testing.T, invoker grpc.UnaryInvoker) (*helloworldpb.HelloReply, metadata.MD, error) {
+	t.Helper()
+	interceptor := UnaryClientHedging(logr.Discard(), 3, testHedgingDelay, codes.Unavailable)
+	reply := &helloworldpb.HelloReply{}
+	var header metadata.MD
+	err := interceptor(context.Background(), testMethod, &helloworldpb.HelloRequest{Name: "test"}, reply, nil, invoker, grpc.Header(&header))
+	return reply, header, err
+}
+
+func TestUnaryClientHedgingFirstAttemptWins(t *testing.T) {
+	var attempts atomic.Int32
+	reply, header, err := invokeHedging(t, fakeInvoker(&attempts, func(_ context.Context, attempt int32, reply *helloworldpb.HelloReply) error {
+		reply.Message = fmt.Sprintf("attempt %d", attempt)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := reply.GetMessage(); got != "attempt 1" {
+		t.Errorf("got reply %q, want %q", got, "attempt 1")
+	}
+	if got := header.Get("attempt"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("got header attempt=%v, want [1]", got)
+	}
+	// Wait longer than the hedging delay, to verify that no hedged attempts start after the response.
+	time.Sleep(2 * testHedgingDelay)
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+func TestUnaryClientHedgingHedgeWins(t *testing.T) {
+	var attempts atomic.Int32
+	firstAttemptCanceled := make(chan struct{})
+	reply, header, err := invokeHedging(t, fakeInvoker(&attempts, func(ctx context.Context, attempt int32, reply *helloworldpb.HelloReply) error {
+		if attempt == 1 {
+			// The first attempt does not respond until the interceptor cancels it.
+			<-ctx.Done()
+			close(firstAttemptCanceled)
+			return status.FromContextError(ctx.Err()).Err()
+		}
+		reply.Message = fmt.Sprintf("attempt %d", attempt)
+		return nil
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got := reply.GetMessage(); got != "attempt 2" {
+		t.Errorf("got reply %q, want %q", got, "attempt 2")
+	}
+	select {
+	case <-firstAttemptCanceled:
+	case <-time.After(time.Second):
+		t.Fatal("first attempt was not canceled after the hedged attempt succeeded")
+	}
+	// The header of the canceled first attempt must not overwrite the header of the winner.
+	if got := header.Get("attempt"); len(got) != 1 || got[0] != "2" {
+		t.Errorf("got header attempt=%v, want [2]", got)
+	}
+}
+
+func TestUnaryClientHedgingNonRetryableErrorStopsHedging(t *testing.T) {
+	var attempts atomic.Int32
+	_, header, err := invokeHedging(t, fakeInvoker(&attempts, func(_ context.Context, _ int32, _ *helloworldpb.HelloReply) error {
+		return status.Error(codes.InvalidArgument, "bad request")
+	}))
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("got error %v, want code %s", err, codes.InvalidArgument)
+	}
+	if got := header.Get("attempt"); len(got) != 1 || got[0] != "1" {
+		t.Errorf("got header attempt=%v, want [1]", got)
+	}
+	time.Sleep(2 * testHedgingDelay)
+	if got := attempts.Load(); got != 1 {
+		t.Errorf("got %d attempts, want 1", got)
+	}
+}
+
+func TestUnaryClientHedgingNonFatalErrorsExhaustAttempts(t *testing.T) {
+	var attempts atomic.Int32
+	_, _, err := invokeHedging(t, fakeInvoker(&attempts, func(_ context.Context, _ int32, _ *helloworldpb.HelloReply) error {
+		return status.Error(codes.Unavailable, "no healthy upstream")
+	}))
+	if status.Code(err) != codes.Unavailable {
+		t.Fatalf("got error %v, want code %s", err, codes.Unavailable)
+	}
+	if got := attempts.Load(); got != 3 {
+		t.Errorf("got %d attempts, want 3", got)
+	}
+}
@@ -56,6 +56,7 @@ var (
 		logging.WithLogOnEvents(
 			logging.PayloadReceived,
 			logging.PayloadSent),
+		logging.WithFieldsFromContext(requestIDFields),
 	}
 )
 
@@ -0,0 +1,116 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	middleware "github.com/grpc-ecosystem/go-grpc-middleware/v2"
+	"github.com/grpc-ecosystem/go-grpc-middleware/v2/interceptors/logging"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// RequestIDHeader is the request metadata header that correlates requests across the chain of
+// greeter services. Envoy proxies use the same header name.
+//
+// The request ID is returned in the response header, and not in the `HelloReply` message, since
+// the message comes from the gRPC-Go examples module, and other greeter implementations and
+// clients, e.g., gRPCurl with server reflection, use the same message definition.
+const RequestIDHeader = "x-request-id"
+
+type requestIDKey struct{}
+
+// RequestIDFromContext returns the request ID of the incoming request, or an empty string if
+// the context does not contain a request ID.
+func RequestIDFromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
+
+// UnaryServerRequestID extracts the request ID from the incoming request metadata, or creates a
+// new request ID, adds it to the context, and returns it to the caller as a response header.
+func UnaryServerRequestID() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		ctx = withRequestID(ctx)
+		return handler(ctx, req)
+	}
+}
+
+// StreamServerRequestID is the streaming equivalent of `UnaryServerRequestID`.
+func StreamServerRequestID() grpc.StreamServerInterceptor {
+	return func(srv any, stream grpc.ServerStream, _ *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		wrappedStream := middleware.WrapServerStream(stream)
+		wrappedStream.WrappedContext = withRequestID(stream.Context())
+		return handler(srv, wrappedStream)
+	}
+}
+
+// UnaryClientRequestID propagates the request ID from the context to outgoing requests, unless
+// the outgoing request metadata already contains a request ID.
+func UnaryClientRequestID() grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		return invoker(propagateRequestID(ctx), method, req, reply, cc, opts...)
+	}
+}
+
+// StreamClientRequestID is the streaming equivalent of `UnaryClientRequestID`.
+func StreamClientRequestID() grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		return streamer(propagateRequestID(ctx), desc, cc, method, opts...)
+	}
+}
+
+// withRequestID returns a context with the request ID from the incoming request metadata, or a
+// new request ID, and sets the response header.
+func withRequestID(ctx context.Context) context.Context {
+	var requestID string
+	if values := metadata.ValueFromIncomingContext(ctx, RequestIDHeader); len(values) > 0 && values[0] != "" {
+		requestID = values[0]
+	} else {
+		requestID = newRequestID()
+	}
+	// Ignore the error, the response header is only informational.
+	_ = grpc.SetHeader(ctx, metadata.Pairs(RequestIDHeader, requestID))
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+func propagateRequestID(ctx context.Context) context.Context {
+	requestID := RequestIDFromContext(ctx)
+	if requestID == "" {
+		return ctx
+	}
+	if md, ok := metadata.FromOutgoingContext(ctx); ok && len(md.Get(RequestIDHeader)) > 0 {
+		return ctx
+	}
+	return metadata.AppendToOutgoingContext(ctx, RequestIDHeader, requestID)
+}
+
+// newRequestID returns a random 128-bit request ID, hex-encoded.
+func newRequestID() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// requestIDFields adds the request ID to the log entries of the logging interceptors.
+func requestIDFields(ctx context.Context) logging.Fields {
+	if requestID := RequestIDFromContext(ctx); requestID != "" {
+		return logging.Fields{"requestID", requestID}
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"encoding/hex"
+	"testing"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+// serveUnary calls the unary server request ID interceptor with the incoming metadata, and
+// returns the request ID that the handler sees.
+func serveUnary(t *testing.T, incoming metadata.MD, handle func(ctx context.Context)) string {
+	t.Helper()
+	ctx := metadata.NewIncomingContext(context.Background(), incoming)
+	var requestID string
+	_, err := UnaryServerRequestID()(ctx, nil, &grpc.UnaryServerInfo{FullMethod: testMethod}, func(ctx context.Context, _ any) (any, error) {
+		requestID = RequestIDFromContext(ctx)
+		if handle != nil {
+			handle(ctx)
+		}
+		return nil, nil
+	})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return requestID
+}
+
+// outgoingRequestID calls the unary client request ID interceptor, and returns the request IDs
+// in the outgoing metadata of the next hop.
+func outgoingRequestID(ctx context.Context, t *testing.T) []string {
+	t.Helper()
+	var got []string
+	invoker := func(ctx context.Context, _ string, _, _ any, _ *grpc.ClientConn, _ ...grpc.CallOption) error {
+		md, _ := metadata.FromOutgoingContext(ctx)
+		got = md.Get(RequestIDHeader)
+		return nil
+	}
+	if err := UnaryClientRequestID()(ctx, testMethod, nil, nil, nil, invoker); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	return got
+}
+
+func TestUnaryServerRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming metadata.MD
+		want     string
+	}{
+		{
+			name:     "uses the incoming request ID",
+			incoming: metadata.Pairs(RequestIDHeader, "my-request-1"),
+			want:     "my-request-1",
+		},
+		{
+			name:     "creates a request ID if the header is missing",
+			incoming: metadata.MD{},
+		},
+		{
+			name:     "creates a request ID if the header is empty",
+			incoming: metadata.Pairs(RequestIDHeader, ""),
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := serveUnary(t, test.incoming, nil)
+			if test.want != "" && got != test.want {
+				t.Errorf("request ID = %q, want %q", got, test.want)
+			}
+			if test.want == "" {
+				if decoded, err := hex.DecodeString(got); err != nil || len(decoded) != 16 {
+					t.Errorf("request ID = %q, want 128 bits, hex-encoded", got)
+				}
+			}
+		})
+	}
+}
+
+func TestRequestIDPropagatesToNextHop(t *testing.T) {
+	tests := []struct {
+		name     string
+		incoming metadata.MD
+		// outgoing is metadata that the handler sets for the next hop.
+		outgoing metadata.MD
+		want     string
+	}{
+		{
+			name:     "propagates the incoming request ID",
+			incoming: metadata.Pairs(RequestIDHeader, "my-request-1"),
+			want:     "my-request-1",
+		},
+		{
+			name:     "keeps the request ID of the outgoing metadata",
+			incoming: metadata.Pairs(RequestIDHeader, "my-request-1"),
+			outgoing: metadata.Pairs(RequestIDHeader, "my-request-2"),
+			want:     "my-request-2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var got []string
+			serveUnary(t, test.incoming, func(ctx context.Context) {
+				if test.outgoing != nil {
+					ctx = metadata.NewOutgoingContext(ctx, test.outgoing)
+				}
+				got = outgoingRequestID(ctx, t)
+			})
+			if len(got) != 1 || got[0] != test.want {
+				t.Errorf("outgoing request IDs = %v, want [%s]", got, test.want)
+			}
+		})
+	}
+}
+
+func TestRequestIDPropagatesNewRequestID(t *testing.T) {
+	var got []string
+	requestID := serveUnary(t, metadata.MD{}, func(ctx context.Context) {
+		got = outgoingRequestID(ctx, t)
+	})
+	if len(got) != 1 || got[0] != requestID {
+		t.Errorf("outgoing request IDs = %v, want [%s]", got, requestID)
+	}
+}
+
+func TestUnaryClientRequestIDWithoutRequestID(t *testing.T) {
+	if got := outgoingRequestID(context.Background(), t); len(got) != 0 {
+		t.Errorf("outgoing request IDs = %v, want none", got)
+	}
+}
@@ -0,0 +1,178 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	meterName                      = "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+	defaultRetryBackoffMultiplier  = 2.0
+	defaultRetryBudgetMaxTokens    = 10.0
+	defaultRetryBudgetTokenRatio   = 0.1
+	retryBudgetThresholdMultiplier = 0.5
+)
+
+// RetryConfig provides the parameters of UnaryClientRetry.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts of each RPC, including the original attempt.
+	MaxAttempts int
+	// InitialBackoff is the upper bound of the randomized delay before the first retry.
+	InitialBackoff time.Duration
+	// MaxBackoff caps the upper bound of the randomized delay before each retry.
+	MaxBackoff time.Duration
+	// BackoffMultiplier grows the upper bound of the delay after each retry. Zero means 2.
+	BackoffMultiplier float64
+	// BudgetMaxTokens is the size of the retry budget, which is shared by all RPCs of
+	// the interceptor. Zero means 10.
+	BudgetMaxTokens float64
+	// BudgetTokenRatio is the number of tokens that each successful RPC returns to the
+	// retry budget. Zero means 0.1.
+	BudgetTokenRatio float64
+	// RetryableCodes are the status codes of failed attempts that can be retried.
+	RetryableCodes []codes.Code
+}
+
+// UnaryClientRetry retries unary RPCs that fail with one of the retryable codes, with
+// exponential backoff, up to `MaxAttempts` attempts.
+//
+// Retries are throttled by a retry budget, following the `retryThrottling` policy
+// of the gRPC service config, see
+// https://github.com/grpc/proposal/blob/master/A6-client-retries.md#throttling-retry-attempts-and-hedged-rpcs
+// Each failed attempt takes one token from the budget, each successful RPC returns
+// `BudgetTokenRatio` tokens, and no retries are sent while the budget is at or below
+// half of `BudgetMaxTokens`.
+//
+// The interceptor cannot see retry policies provided by the name resolver, such as the
+// retry policies of xDS routes. gRPC applies those to each attempt of this interceptor,
+// so enable only one of them, e.g., to compare in-application retries with retry
+// policies configured by the xDS control plane. The `greeter.client.retries` and
+// `greeter.client.retries.throttled` metrics count the retries sent and the retries
+// prevented by the budget.
+func UnaryClientRetry(logger logr.Logger, config RetryConfig) (grpc.UnaryClientInterceptor, error) {
+	retriesCounter, err := otel.Meter(meterName).Int64Counter("greeter.client.retries",
+		metric.WithDescription("Number of retries of outbound RPCs sent by the retry interceptor, by method and status code of the failed attempt."),
+		metric.WithUnit("{retry}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create client retries counter: %w", err)
+	}
+	throttledCounter, err := otel.Meter(meterName).Int64Counter("greeter.client.retries.throttled",
+		metric.WithDescription("Number of retries of outbound RPCs prevented by the retry budget, by method."),
+		metric.WithUnit("{retry}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create client throttled retries counter: %w", err)
+	}
+	if config.BackoffMultiplier == 0 {
+		config.BackoffMultiplier = defaultRetryBackoffMultiplier
+	}
+	if config.BudgetMaxTokens == 0 {
+		config.BudgetMaxTokens = defaultRetryBudgetMaxTokens
+	}
+	if config.BudgetTokenRatio == 0 {
+		config.BudgetTokenRatio = defaultRetryBudgetTokenRatio
+	}
+	budget := &retryBudget{
+		tokens:    config.BudgetMaxTokens,
+		maxTokens: config.BudgetMaxTokens,
+		ratio:     config.BudgetTokenRatio,
+	}
+	isRetryable := func(err error) bool {
+		code := status.Code(err)
+		for _, retryableCode := range config.RetryableCodes {
+			if code == retryableCode {
+				return true
+			}
+		}
+		return false
+	}
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		replyMessage, ok := reply.(proto.Message)
+		if !ok || config.MaxAttempts < 2 {
+			return invoker(ctx, method, req, reply, cc, opts...)
+		}
+		backoff := float64(config.InitialBackoff)
+		for attempt := 1; ; attempt++ {
+			err := invoker(ctx, method, req, reply, cc, opts...)
+			if err == nil {
+				budget.onSuccess()
+				return nil
+			}
+			if !isRetryable(err) {
+				return err
+			}
+			retryAllowed := budget.onFailure()
+			if attempt >= config.MaxAttempts {
+				return err
+			}
+			if !retryAllowed {
+				logger.V(2).Info("Retry budget exhausted, not retrying request", "method", method, "attempt", attempt)
+				throttledCounter.Add(ctx, 1, metric.WithAttributes(attribute.String("method", method)))
+				return err
+			}
+			delay := time.Duration(rand.Float64() * math.Min(backoff, float64(config.MaxBackoff)))
+			backoff *= config.BackoffMultiplier
+			logger.V(2).Info("Retrying request", "method", method, "attempt", attempt+1, "delay", delay, "code", status.Code(err).String())
+			timer := time.NewTimer(delay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return err
+			case <-timer.C:
+			}
+			retriesCounter.Add(ctx, 1, metric.WithAttributes(
+				attribute.String("method", method),
+				attribute.String("code", status.Code(err).String())))
+			proto.Reset(replyMessage)
+		}
+	}, nil
+}
+
+// retryBudget implements the token bucket of the gRPC retry throttling policy.
+type retryBudget struct {
+	mu        sync.Mutex
+	tokens    float64
+	maxTokens float64
+	ratio     float64
+}
+
+// onFailure takes a token for a failed attempt, and returns true if a retry is allowed.
+func (b *retryBudget) onFailure() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Max(b.tokens-1, 0)
+	return b.tokens > b.maxTokens*retryBudgetThresholdMultiplier
+}
+
+// onSuccess returns a fraction of a token for a successful RPC.
+func (b *retryBudget) onSuccess() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.tokens = math.Min(b.tokens+b.ratio, b.maxTokens)
+}
@@ -0,0 +1,126 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package interceptors
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/status"
+)
+
+func TestRetryBudgetThrottleThreshold(t *testing.T) {
+	budget := &retryBudget{tokens: 10, maxTokens: 10, ratio: 0.1}
+	// Retries are allowed while more than half of the tokens remain.
+	for failure := 1; failure <= 4; failure++ {
+		if !budget.onFailure() {
+			t.Fatalf("failure %d: retry throttled with tokens=%v, want allowed", failure, budget.tokens)
+		}
+	}
+	if budget.onFailure() {
+		t.Errorf("failure 5: retry allowed with tokens=%v, want throttled", budget.tokens)
+	}
+	for range 10 {
+		budget.onFailure()
+	}
+	if budget.tokens != 0 {
+		t.Errorf("got tokens=%v after more failures than tokens, want 0", budget.tokens)
+	}
+}
+
+func TestRetryBudgetSuccessRefills(t *testing.T) {
+	budget := &retryBudget{tokens: 5, maxTokens: 10, ratio: 0.1}
+	if budget.onFailure() {
+		t.Fatalf("retry allowed at the threshold with tokens=%v, want throttled", budget.tokens)
+	}
+	// The throttled failure still took a token, leaving 4. Each success returns 0.1 tokens,
+	// so 21 successes lift the budget above the threshold again, even after the next
+	// failure takes a token.
+	for range 21 {
+		budget.onSuccess()
+	}
+	if !budget.onFailure() {
+		t.Errorf("retry throttled after successes with tokens=%v, want allowed", budget.tokens)
+	}
+	for range 1000 {
+		budget.onSuccess()
+	}
+	if budget.tokens != budget.maxTokens {
+		t.Errorf("got tokens=%v after many successes, want maxTokens=%v", budget.tokens, budget.maxTokens)
+	}
+}
+
+func TestUnaryClientRetryThrottled(t *testing.T) {
+	interceptor, err := UnaryClientRetry(logr.Discard(), RetryConfig{
+		MaxAttempts:     3,
+		BudgetMaxTokens: 4,
+		RetryableCodes:  []codes.Code{codes.Unavailable},
+	})
+	if err != nil {
+		t.Fatalf("could not create retry interceptor: %v", err)
+	}
+	var attempts atomic.Int32
+	var fail atomic.Bool
+	invoker := func(context.Context, string, any, any, *grpc.ClientConn, ...grpc.CallOption) error {
+		attempts.Add(1)
+		if fail.Load() {
+			return status.Error(codes.Unavailable, "unavailable")
+		}
+		return nil
+	}
+	invoke := func() (int32, error) {
+		attempts.Store(0)
+		err := interceptor(context.Background(), testMethod, &helloworldpb.HelloRequest{Name: "test"}, &helloworldpb.HelloReply{}, nil, invoker)
+		return attempts.Load(), err
+	}
+
+	fail.Store(true)
+	tests := []struct {
+		name         string
+		wantAttempts int32
+	}{
+		// 4 tokens: the first failure leaves 3, above the threshold of 2, so one retry is sent,
+		// and the second failure leaves 2, which stops further retries.
+		{name: "retries until the budget reaches the threshold", wantAttempts: 2},
+		{name: "does not retry below the threshold", wantAttempts: 1},
+	}
+	for _, test := range tests {
+		got, err := invoke()
+		if status.Code(err) != codes.Unavailable {
+			t.Errorf("%s: got err=%v, want code %s", test.name, err, codes.Unavailable)
+		}
+		if got != test.wantAttempts {
+			t.Errorf("%s: got %d attempts, want %d", test.name, got, test.wantAttempts)
+		}
+	}
+
+	// The budget is at 1 token, so 21 successful RPCs return 2.1 tokens, and the next
+	// failure leaves 2.1 tokens, which allows a retry again.
+	fail.Store(false)
+	for range 21 {
+		if _, err := invoke(); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+	fail.Store(true)
+	if got, _ := invoke(); got != 2 {
+		t.Errorf("after successes: got %d attempts, want 2", got)
+	}
+}
@@ -0,0 +1,160 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package loadtester sends SayHello RPCs at a fixed rate to a greeter service,
+// and reports how the requests were distributed across backends and localities.
+package loadtester
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greeter"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/signals"
+)
+
+// maxRate is the highest rate with a positive interval between requests, i.e., one nanosecond.
+const maxRate = float64(time.Second)
+
+var (
+	errInvalidRate           = errors.New("rate must be positive and at most 1e9 requests per second")
+	errInvalidDuration       = errors.New("duration must be positive")
+	errInvalidRequestTimeout = errors.New("request timeout must be positive")
+	errInvalidMaxInFlight    = errors.New("maximum number of requests in flight must be positive")
+)
+
+// Config provides load test parameters read from command line flags.
+type Config struct {
+	Target         string
+	Name           string
+	Rate           float64
+	Duration       time.Duration
+	RequestTimeout time.Duration
+	MaxInFlight    int
+	// ClientOptions customize the greeter client, e.g., to inject interceptors or
+	// transport credentials. There is no command line flag for this field.
+	ClientOptions []greeter.ClientOption
+}
+
+// Run parses the command line flags, runs the load test, and prints the report to stdout.
+func Run(ctx context.Context, flagset *flag.FlagSet, args []string) error {
+	logging.InitFlags(flagset)
+	var c Config
+	flagset.StringVar(&c.Target, "target", "xds:///greeter-leaf", "gRPC channel target URI of the greeter service")
+	flagset.StringVar(&c.Name, "name", "loadtester", "name to send in each HelloRequest")
+	flagset.Float64Var(&c.Rate, "rate", 10, "requests per second")
+	flagset.DurationVar(&c.Duration, "duration", 30*time.Second, "duration of the load test")
+	flagset.DurationVar(&c.RequestTimeout, "request-timeout", 5*time.Second, "deadline of each request")
+	flagset.IntVar(&c.MaxInFlight, "max-in-flight", 100, "maximum number of concurrent requests, additional requests are counted as dropped")
+	if err := flagset.Parse(args); err != nil {
+		return fmt.Errorf("could not parse command line flags args=%+v: %w", args, err)
+	}
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	ctx = signals.SetupSignalHandler(ctx)
+	logger := logging.NewLogger()
+	logging.SetGRPCLogger(logger)
+	ctx = logging.NewContext(ctx, logger)
+	report, err := runLoadTest(ctx, c)
+	if err != nil {
+		return err
+	}
+	return report.Print(os.Stdout)
+}
+
+// Validate checks that the rate, durations, and the maximum number of requests in flight are
+// positive, and that the rate is at most one request per nanosecond.
+func (c *Config) Validate() error {
+	if _, err := requestInterval(c.Rate); err != nil {
+		return err
+	}
+	if c.Duration <= 0 {
+		return fmt.Errorf("%w: duration=%s", errInvalidDuration, c.Duration)
+	}
+	if c.RequestTimeout <= 0 {
+		return fmt.Errorf("%w: requestTimeout=%s", errInvalidRequestTimeout, c.RequestTimeout)
+	}
+	if c.MaxInFlight <= 0 {
+		return fmt.Errorf("%w: maxInFlight=%d", errInvalidMaxInFlight, c.MaxInFlight)
+	}
+	return nil
+}
+
+// requestInterval returns the interval between requests for the rate in requests per second.
+func requestInterval(rate float64) (time.Duration, error) {
+	// The negated comparison also rejects NaN.
+	if !(rate > 0 && rate <= maxRate) {
+		return 0, fmt.Errorf("%w: rate=%v", errInvalidRate, rate)
+	}
+	return time.Duration(float64(time.Second) / rate), nil
+}
+
+func runLoadTest(ctx context.Context, c Config) (*Report, error) {
+	logger := logging.FromContext(ctx)
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	interval, err := requestInterval(c.Rate)
+	if err != nil {
+		return nil, err
+	}
+	// The client connection closes when ctx is done, after the requests in flight have completed.
+	client, err := greeter.NewClient(ctx, c.Target, c.ClientOptions...)
+	if err != nil {
+		return nil, fmt.Errorf("could not create greeter client for target=%s: %w", c.Target, err)
+	}
+	loadCtx, cancel := context.WithTimeout(ctx, c.Duration)
+	defer cancel()
+	logger.Info("Starting load test", "target", c.Target, "rate", c.Rate, "duration", c.Duration)
+	report := NewReport(c.Target)
+	inFlight := make(chan struct{}, c.MaxInFlight)
+	var wg sync.WaitGroup
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-loadCtx.Done():
+			wg.Wait()
+			report.Finish()
+			return report, nil
+		case <-ticker.C:
+			select {
+			case inFlight <- struct{}{}:
+			default:
+				report.RecordDropped()
+				continue
+			}
+			wg.Add(1)
+			go func() {
+				defer func() {
+					<-inFlight
+					wg.Done()
+				}()
+				// Requests in flight when the load test ends are allowed to complete.
+				requestCtx, requestCancel := context.WithTimeout(ctx, c.RequestTimeout)
+				defer requestCancel()
+				start := time.Now()
+				greeting, err := client.SayHello(requestCtx, c.Name)
+				report.Record(greeting, time.Since(start), err)
+			}()
+		}
+	}
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtester
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"math"
+	"testing"
+	"time"
+)
+
+func TestRequestInterval(t *testing.T) {
+	tests := []struct {
+		rate    float64
+		want    time.Duration
+		wantErr bool
+	}{
+		{rate: 10, want: 100 * time.Millisecond},
+		{rate: 0.5, want: 2 * time.Second},
+		// The highest rate, with an interval of one nanosecond.
+		{rate: maxRate, want: time.Nanosecond},
+		// Higher rates truncate the interval to zero, which would make the ticker panic.
+		{rate: math.Nextafter(maxRate, math.Inf(1)), wantErr: true},
+		{rate: 2 * maxRate, wantErr: true},
+		{rate: math.Inf(1), wantErr: true},
+		{rate: 0, wantErr: true},
+		{rate: -1, wantErr: true},
+		{rate: math.NaN(), wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := requestInterval(tt.rate)
+		if tt.wantErr {
+			if !errors.Is(err, errInvalidRate) {
+				t.Errorf("requestInterval(%v): got error %v, want %v", tt.rate, err, errInvalidRate)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("requestInterval(%v): unexpected error: %v", tt.rate, err)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("requestInterval(%v) = %s, want %s", tt.rate, got, tt.want)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Rate: 10, Duration: time.Second, RequestTimeout: time.Second, MaxInFlight: 1}
+	tests := []struct {
+		name    string
+		modify  func(c *Config)
+		wantErr error
+	}{
+		{name: "valid", modify: func(*Config) {}},
+		{name: "invalid rate", modify: func(c *Config) { c.Rate = 0 }, wantErr: errInvalidRate},
+		{name: "zero duration", modify: func(c *Config) { c.Duration = 0 }, wantErr: errInvalidDuration},
+		{name: "negative duration", modify: func(c *Config) { c.Duration = -time.Second }, wantErr: errInvalidDuration},
+		{name: "zero request timeout", modify: func(c *Config) { c.RequestTimeout = 0 }, wantErr: errInvalidRequestTimeout},
+		{name: "negative request timeout", modify: func(c *Config) { c.RequestTimeout = -time.Second }, wantErr: errInvalidRequestTimeout},
+		// A buffered channel with zero capacity would drop every request.
+		{name: "zero max in flight", modify: func(c *Config) { c.MaxInFlight = 0 }, wantErr: errInvalidMaxInFlight},
+		// A negative channel capacity would panic.
+		{name: "negative max in flight", modify: func(c *Config) { c.MaxInFlight = -1 }, wantErr: errInvalidMaxInFlight},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := valid
+			tt.modify(&c)
+			err := c.Validate()
+			if tt.wantErr == nil && err != nil {
+				t.Errorf("unexpected error: %v", err)
+			}
+			if tt.wantErr != nil && !errors.Is(err, tt.wantErr) {
+				t.Errorf("got error %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestRunRejectsInvalidFlags(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr error
+	}{
+		{args: []string{"-max-in-flight=-1"}, wantErr: errInvalidMaxInFlight},
+		{args: []string{"-max-in-flight=0"}, wantErr: errInvalidMaxInFlight},
+		{args: []string{"-duration=0s"}, wantErr: errInvalidDuration},
+		{args: []string{"-request-timeout=-1s"}, wantErr: errInvalidRequestTimeout},
+		{args: []string{"-rate=2e9"}, wantErr: errInvalidRate},
+	}
+	for _, tt := range tests {
+		err := Run(context.Background(), flag.NewFlagSet("loadtester", flag.ContinueOnError), tt.args)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("Run(%v): got error %v, want %v", tt.args, err, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package loadtester
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"slices"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"google.golang.org/grpc/status"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greeter"
+)
+
+// Report aggregates the results of a load test.
+type Report struct {
+	mu                sync.Mutex
+	target            string
+	start             time.Time
+	elapsed           time.Duration
+	requestsByBackend map[string]int
+	requestsByZone    map[string]int
+	errorsByCode      map[string]int
+	latencies         []time.Duration
+	dropped           int
+}
+
+func NewReport(target string) *Report {
+	return &Report{
+		target:            target,
+		start:             time.Now(),
+		requestsByBackend: map[string]int{},
+		requestsByZone:    map[string]int{},
+		errorsByCode:      map[string]int{},
+	}
+}
+
+// Record adds the result of one request to the report.
+func (r *Report) Record(greeting string, latency time.Duration, err error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err != nil {
+		r.errorsByCode[status.Code(err).String()]++
+		return
+	}
+	backend, zone := greeter.ParseGreeting(greeting)
+	r.requestsByBackend[backend]++
+	r.requestsByZone[zone]++
+	r.latencies = append(r.latencies, latency)
+}
+
+// RecordDropped counts a request that was not sent because too many requests were in flight.
+func (r *Report) RecordDropped() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.dropped++
+}
+
+// Finish records the elapsed time of the load test.
+func (r *Report) Finish() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.elapsed = time.Since(r.start)
+}
+
+// Print writes the report as aligned text columns.
+func (r *Report) Print(out io.Writer) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	succeeded := len(r.latencies)
+	failed := 0
+	for _, count := range r.errorsByCode {
+		failed += count
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(w, "Target:\t%s\n", r.target)
+	_, _ = fmt.Fprintf(w, "Duration:\t%s\n", r.elapsed.Round(time.Millisecond))
+	_, _ = fmt.Fprintf(w, "Requests:\t%d succeeded, %d failed, %d dropped\n", succeeded, failed, r.dropped)
+	if r.elapsed > 0 {
+		_, _ = fmt.Fprintf(w, "Throughput:\t%.1f successful requests/s\n", float64(succeeded)/r.elapsed.Seconds())
+	}
+	if succeeded > 0 {
+		slices.Sort(r.latencies)
+		_, _ = fmt.Fprintf(w, "Latency:\tp50=%s p90=%s p99=%s max=%s\n",
+			percentile(r.latencies, 50), percentile(r.latencies, 90), percentile(r.latencies, 99), r.latencies[succeeded-1].Round(time.Microsecond))
+	}
+	printDistribution(w, "Backend", r.requestsByBackend, succeeded)
+	printDistribution(w, "Zone", r.requestsByZone, succeeded)
+	printDistribution(w, "Error code", r.errorsByCode, failed)
+	return w.Flush()
+}
+
+func printDistribution(w io.Writer, heading string, counts map[string]int, total int) {
+	if len(counts) == 0 {
+		return
+	}
+	_, _ = fmt.Fprintf(w, "\n%s\tRequests\tPercent\n", heading)
+	for _, key := range slices.Sorted(maps.Keys(counts)) {
+		_, _ = fmt.Fprintf(w, "%s\t%d\t%.1f%%\n", key, counts[key], 100*float64(counts[key])/float64(total))
+	}
+}
+
+// percentile returns the p-th percentile of the sorted latencies, using the nearest-rank method.
+func percentile(sortedLatencies []time.Duration, p int) time.Duration {
+	rank := (p*len(sortedLatencies) + 99) / 100
+	return sortedLatencies[max(rank, 1)-1].Round(time.Microsecond)
+}
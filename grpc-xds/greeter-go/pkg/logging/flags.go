@@ -16,11 +16,36 @@ package logging
 
 import (
 	"flag"
+	"fmt"
 
 	"k8s.io/klog/v2"
 )
 
+const (
+	// FormatText is the default klog text format.
+	FormatText = "text"
+	// FormatJSON writes JSON log entries with a Cloud Logging compatible `severity` field.
+	FormatJSON = "json"
+)
+
+var (
+	// format is set by the `-log-format` flag.
+	format = FormatText
+	// klogVerbosity is the `-v` flag registered by klog, used to change the verbosity at runtime.
+	klogVerbosity flag.Value
+)
+
 // InitFlags initializes logging-related flags.
 func InitFlags(flagset *flag.FlagSet) {
 	klog.InitFlags(flagset)
+	klogVerbosity = flagset.Lookup("v").Value
+	flagset.Func("log-format", "log format, either text (default) or json", func(value string) error {
+		switch value {
+		case FormatText, FormatJSON:
+			format = value
+			return nil
+		default:
+			return fmt.Errorf("unknown log format %q, must be one of %s or %s", value, FormatText, FormatJSON)
+		}
+	})
 }
@@ -0,0 +1,84 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"log/slog"
+	"os"
+	"sync"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	// jsonLevel is shared by all JSON loggers, so that changes to the verbosity apply to all of them.
+	jsonLevel      slog.LevelVar
+	jsonHandler    slog.Handler
+	jsonHandlerMux sync.Mutex
+)
+
+// newJSONLogger returns a logger that writes JSON log entries to stderr, with the
+// fields expected by Cloud Logging, see
+// https://cloud.google.com/logging/docs/structured-logging#special-payload-fields
+//
+// The logr verbosity level `V(n)` maps to the slog level `-n`.
+func newJSONLogger() logr.Logger {
+	jsonHandlerMux.Lock()
+	defer jsonHandlerMux.Unlock()
+	if jsonHandler == nil {
+		jsonLevel.Set(slog.Level(-klogVerbosityLevel()))
+		jsonHandler = slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{
+			AddSource:   true,
+			Level:       &jsonLevel,
+			ReplaceAttr: replaceCloudLoggingAttr,
+		})
+	}
+	return logr.FromSlogHandler(jsonHandler)
+}
+
+// replaceCloudLoggingAttr renames the top-level slog attributes to the Cloud Logging field
+// names, and converts the level to a Cloud Logging severity.
+func replaceCloudLoggingAttr(groups []string, attr slog.Attr) slog.Attr {
+	if len(groups) > 0 {
+		return attr
+	}
+	switch attr.Key {
+	case slog.LevelKey:
+		level, _ := attr.Value.Any().(slog.Level)
+		return slog.String("severity", severity(level))
+	case slog.MessageKey:
+		return slog.Attr{Key: "message", Value: attr.Value}
+	case slog.SourceKey:
+		return slog.Attr{Key: "logging.googleapis.com/sourceLocation", Value: attr.Value}
+	case slog.TimeKey:
+		return slog.Attr{Key: "time", Value: attr.Value}
+	}
+	return attr
+}
+
+// severity returns the Cloud Logging severity of the slog level. Verbose logr levels,
+// that is, `V(1)` and higher, map to `DEBUG`.
+func severity(level slog.Level) string {
+	switch {
+	case level >= slog.LevelError:
+		return "ERROR"
+	case level >= slog.LevelWarn:
+		return "WARNING"
+	case level >= slog.LevelInfo:
+		return "INFO"
+	default:
+		return "DEBUG"
+	}
+}
@@ -21,8 +21,12 @@ import (
 
 // Ref: https://github.com/kubernetes/community/blob/1a09f121536ddb84c1429c88fbb3978d6c5e2dd0/contributors/devel/sig-instrumentation/logging.md
 
+// NewLogger returns a logger that uses the format from the `-log-format` flag.
 func NewLogger() logr.Logger {
 	logger := klog.NewKlogr()
+	if format == FormatJSON {
+		logger = newJSONLogger()
+	}
 	logger.WithCallDepth(2).V(1).Info("Creating new logger")
 	return logger
 }
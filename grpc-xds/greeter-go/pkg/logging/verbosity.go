@@ -0,0 +1,93 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package logging
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"github.com/go-logr/logr"
+)
+
+var (
+	errFlagsNotInitialized = errors.New("logging flags are not initialized, see InitFlags()")
+	errNegativeVerbosity   = errors.New("verbosity cannot be negative")
+)
+
+// Verbosity returns the current verbosity, initially set by the `-v` flag.
+func Verbosity() int {
+	if format == FormatJSON {
+		return -int(jsonLevel.Level())
+	}
+	return klogVerbosityLevel()
+}
+
+// SetVerbosity changes the verbosity of all loggers at runtime.
+func SetVerbosity(verbosity int) error {
+	if klogVerbosity == nil {
+		return errFlagsNotInitialized
+	}
+	if verbosity < 0 {
+		return fmt.Errorf("%w: %d", errNegativeVerbosity, verbosity)
+	}
+	if err := klogVerbosity.Set(strconv.Itoa(verbosity)); err != nil {
+		return fmt.Errorf("could not set klog verbosity to %d: %w", verbosity, err)
+	}
+	jsonLevel.Set(slog.Level(-verbosity))
+	return nil
+}
+
+// VerbosityHandler returns an HTTP handler that responds with the current verbosity to GET
+// requests, and changes the verbosity on PUT and POST requests with the query parameter `v`,
+// e.g., `curl -X PUT 'localhost:50053/debug/verbosity?v=4'`.
+func VerbosityHandler(logger logr.Logger) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+		case http.MethodPut, http.MethodPost:
+			verbosity, err := strconv.Atoi(r.URL.Query().Get("v"))
+			if err != nil {
+				http.Error(w, "query parameter v must be an integer", http.StatusBadRequest)
+				return
+			}
+			previous := Verbosity()
+			if err := SetVerbosity(verbosity); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			logger.Info("Changed log verbosity", "previous", previous, "verbosity", verbosity, "remoteAddr", r.RemoteAddr)
+		default:
+			w.Header().Set("Allow", "GET, PUT, POST")
+			http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+			return
+		}
+		_, _ = fmt.Fprintf(w, "%d\n", Verbosity())
+	})
+}
+
+// klogVerbosityLevel returns the value of the klog `-v` flag, or 0 if the flags are not initialized.
+func klogVerbosityLevel() int {
+	if klogVerbosity == nil {
+		return 0
+	}
+	verbosity, err := strconv.Atoi(klogVerbosity.String())
+	if err != nil {
+		return 0
+	}
+	return verbosity
+}
@@ -0,0 +1,278 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package preflight runs startup self-checks of the xDS configuration of a greeter
+// server, and prints a diagnostic report. Run it as an init container, or with
+// `kubectl exec` when debugging Pods that do not become ready.
+package preflight
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	discoveryv3 "github.com/envoyproxy/go-control-plane/envoy/service/discovery/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/google"
+	"google.golang.org/grpc/credentials/insecure"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/xdsclient/bootstrap"
+)
+
+var (
+	errChecksFailed       = errors.New("preflight checks failed")
+	errNoCertificates     = errors.New("no certificates found in PEM file")
+	errExpiredCertificate = errors.New("certificate has expired")
+	errUnsupportedCreds   = errors.New("unsupported channel_creds type")
+	errNoListener         = errors.New("control plane did not return the server Listener")
+)
+
+// Options are the inputs to `Run()`.
+type Options struct {
+	// ServingPort is the port of the greeter server, used for the server Listener resource name.
+	ServingPort int
+	// Timeout is the deadline of each network check.
+	Timeout time.Duration
+}
+
+// result is the outcome of a check. Checks are skipped if an earlier check that they depend on failed.
+type result struct {
+	check   string
+	err     error
+	skipped bool
+	details string
+}
+
+func (r result) status() string {
+	switch {
+	case r.skipped:
+		return "SKIP"
+	case r.err != nil:
+		return "FAIL"
+	default:
+		return "PASS"
+	}
+}
+
+// Run checks the gRPC xDS bootstrap configuration, the certificate files of the `file_watcher`
+// certificate providers, the DNS resolution of the xDS management server address, and opens an
+// ADS stream to request the server Listener. It writes a report to w, and returns an error if
+// any check failed.
+func Run(ctx context.Context, w io.Writer, opts Options) error {
+	var results []result
+	bootstrapConfig, err := bootstrap.NewConfigPartial()
+	if err == nil {
+		err = bootstrapConfig.Validate()
+	}
+	if err != nil {
+		results = append(results,
+			result{check: "bootstrap", err: err},
+			result{check: "certificates", skipped: true},
+			result{check: "resolve", skipped: true},
+			result{check: "ads", skipped: true})
+		return writeReport(w, results)
+	}
+	results = append(results, result{check: "bootstrap", details: fmt.Sprintf("node=%s serverUri=%s", bootstrapConfig.NodeProto.GetId(), bootstrapConfig.ServerURI)})
+	results = append(results, checkCertificates(bootstrapConfig))
+	xdsServer := bootstrapConfig.XDSServers[0]
+	resolveResult := checkResolve(ctx, xdsServer.ServerURI, opts.Timeout)
+	results = append(results, resolveResult)
+	if resolveResult.err != nil {
+		results = append(results, result{check: "ads", skipped: true})
+	} else {
+		listenerName := strings.ReplaceAll(bootstrapConfig.ServerListenerResourceNameTemplate, "%s", fmt.Sprintf("0.0.0.0:%d", opts.ServingPort))
+		results = append(results, checkADS(ctx, bootstrapConfig, xdsServer, listenerName, opts.Timeout))
+	}
+	return writeReport(w, results)
+}
+
+// checkCertificates verifies that the files of the `file_watcher` certificate providers exist,
+// and that the certificate and CA certificate files contain certificates that have not expired.
+func checkCertificates(bootstrapConfig *bootstrap.Config) result {
+	if len(bootstrapConfig.FileWatcherConfigs) == 0 {
+		return result{check: "certificates", skipped: true, details: "no file_watcher certificate providers, mTLS is not available"}
+	}
+	instances := make([]string, 0, len(bootstrapConfig.FileWatcherConfigs))
+	for instance := range bootstrapConfig.FileWatcherConfigs {
+		instances = append(instances, instance)
+	}
+	slices.Sort(instances)
+	var details []string
+	for _, instance := range instances {
+		fileWatcherConfig := bootstrapConfig.FileWatcherConfigs[instance]
+		if fileWatcherConfig.PrivateKeyFile != "" {
+			if _, err := os.Stat(fileWatcherConfig.PrivateKeyFile); err != nil {
+				return result{check: "certificates", err: fmt.Errorf("certificate provider instance %s: %w", instance, err)}
+			}
+		}
+		for _, file := range []string{fileWatcherConfig.CertificateFile, fileWatcherConfig.CACertificateFile} {
+			if file == "" {
+				continue
+			}
+			notAfter, err := earliestExpiry(file)
+			if err != nil {
+				return result{check: "certificates", err: fmt.Errorf("certificate provider instance %s: %w", instance, err)}
+			}
+			details = append(details, fmt.Sprintf("%s expires %s", file, notAfter.Format(time.RFC3339)))
+		}
+	}
+	return result{check: "certificates", details: strings.Join(details, ", ")}
+}
+
+// earliestExpiry returns the earliest expiry time of the certificates in the PEM file.
+func earliestExpiry(file string) (time.Time, error) {
+	pemBytes, err := os.ReadFile(file)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not read certificate file %s: %w", file, err)
+	}
+	var earliest time.Time
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("could not parse certificate in file %s: %w", file, err)
+		}
+		if earliest.IsZero() || cert.NotAfter.Before(earliest) {
+			earliest = cert.NotAfter
+		}
+	}
+	if earliest.IsZero() {
+		return time.Time{}, fmt.Errorf("%w: %s", errNoCertificates, file)
+	}
+	if time.Now().After(earliest) {
+		return time.Time{}, fmt.Errorf("%w: file=%s notAfter=%s", errExpiredCertificate, file, earliest.Format(time.RFC3339))
+	}
+	return earliest, nil
+}
+
+// checkResolve looks up the host of the xDS management server URI, e.g., `dns:///control-plane.xds:50051`.
+func checkResolve(ctx context.Context, serverURI string, timeout time.Duration) result {
+	host, err := serverHost(serverURI)
+	if err != nil {
+		return result{check: "resolve", err: err}
+	}
+	resolveCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	addrs, err := net.DefaultResolver.LookupHost(resolveCtx, host)
+	if err != nil {
+		return result{check: "resolve", err: fmt.Errorf("could not resolve xDS management server host %s: %w", host, err)}
+	}
+	return result{check: "resolve", details: fmt.Sprintf("%s resolved to %s", host, strings.Join(addrs, ","))}
+}
+
+// serverHost returns the host of a gRPC target URI, with or without the `dns` scheme.
+func serverHost(serverURI string) (string, error) {
+	endpoint := serverURI
+	if u, err := url.Parse(serverURI); err == nil && u.Scheme == "dns" {
+		endpoint = strings.TrimPrefix(u.Path, "/")
+	}
+	host, _, err := net.SplitHostPort(endpoint)
+	if err != nil {
+		return "", fmt.Errorf("could not parse host and port of xDS management server URI %s: %w", serverURI, err)
+	}
+	return host, nil
+}
+
+// checkADS opens an Aggregated Discovery Service (ADS) stream to the xDS management server, using
+// the node from the bootstrap configuration, and requests the server Listener for the serving port.
+func checkADS(ctx context.Context, bootstrapConfig *bootstrap.Config, xdsServer bootstrap.XDSServer, listenerName string, timeout time.Duration) result {
+	transportCredentials, err := channelCredentials(xdsServer.ChannelCredsTypes)
+	if err != nil {
+		return result{check: "ads", skipped: true, details: err.Error()}
+	}
+	clientConn, err := grpc.NewClient(xdsServer.ServerURI, grpc.WithTransportCredentials(transportCredentials))
+	if err != nil {
+		return result{check: "ads", err: fmt.Errorf("could not create gRPC client for xDS management server %s: %w", xdsServer.ServerURI, err)}
+	}
+	defer clientConn.Close()
+	streamCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stream, err := discoveryv3.NewAggregatedDiscoveryServiceClient(clientConn).StreamAggregatedResources(streamCtx)
+	if err != nil {
+		return result{check: "ads", err: fmt.Errorf("could not open ADS stream to %s: %w", xdsServer.ServerURI, err)}
+	}
+	if err := stream.Send(&discoveryv3.DiscoveryRequest{
+		Node:          bootstrapConfig.NodeProto,
+		TypeUrl:       resource.ListenerType,
+		ResourceNames: []string{listenerName},
+	}); err != nil {
+		return result{check: "ads", err: fmt.Errorf("could not send Listener request on ADS stream to %s: %w", xdsServer.ServerURI, err)}
+	}
+	resp, err := stream.Recv()
+	if err != nil {
+		return result{check: "ads", err: fmt.Errorf("no response to Listener request for %s on ADS stream to %s: %w", listenerName, xdsServer.ServerURI, err)}
+	}
+	for _, anyResource := range resp.GetResources() {
+		var listener listenerv3.Listener
+		if err := anyResource.UnmarshalTo(&listener); err == nil && listener.GetName() == listenerName {
+			return result{check: "ads", details: fmt.Sprintf("received Listener %s version=%s", listenerName, resp.GetVersionInfo())}
+		}
+	}
+	return result{check: "ads", err: fmt.Errorf("%w: %s, version=%s", errNoListener, listenerName, resp.GetVersionInfo())}
+}
+
+// channelCredentials returns the transport credentials for the first supported `channel_creds` type.
+func channelCredentials(types []string) (credentials.TransportCredentials, error) {
+	for _, credsType := range types {
+		switch credsType {
+		case "insecure":
+			return insecure.NewCredentials(), nil
+		case "google_default":
+			return google.NewDefaultCredentials().TransportCredentials(), nil
+		}
+	}
+	return nil, fmt.Errorf("%w: %v", errUnsupportedCreds, types)
+}
+
+// writeReport prints the results as a table, and returns an error if any check failed.
+func writeReport(w io.Writer, results []result) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "CHECK\tRESULT\tDETAILS")
+	failed := false
+	for _, r := range results {
+		details := r.details
+		if r.err != nil {
+			failed = true
+			details = r.err.Error()
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%s\t%s\n", r.check, r.status(), details)
+	}
+	if err := tw.Flush(); err != nil {
+		return fmt.Errorf("could not write preflight report: %w", err)
+	}
+	if failed {
+		return errChecksFailed
+	}
+	return nil
+}
@@ -0,0 +1,221 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package preflight
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestServerHost(t *testing.T) {
+	tests := []struct {
+		name      string
+		serverURI string
+		want      string
+		wantErr   bool
+	}{
+		{
+			name:      "dns scheme",
+			serverURI: "dns:///control-plane.xds:50051",
+			want:      "control-plane.xds",
+		},
+		{
+			name:      "dns scheme with IPv6 address",
+			serverURI: "dns:///[::1]:50051",
+			want:      "::1",
+		},
+		{
+			name:      "no scheme",
+			serverURI: "control-plane.xds.svc.cluster.local:50051",
+			want:      "control-plane.xds.svc.cluster.local",
+		},
+		{
+			name:      "dns scheme without port",
+			serverURI: "dns:///control-plane.xds",
+			wantErr:   true,
+		},
+		{
+			name:      "unsupported scheme",
+			serverURI: "xds:///control-plane.xds:50051",
+			wantErr:   true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := serverHost(test.serverURI)
+			if test.wantErr {
+				if err == nil {
+					t.Fatalf("serverHost(%q) = %q, want error", test.serverURI, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("serverHost(%q) unexpected error: %v", test.serverURI, err)
+			}
+			if got != test.want {
+				t.Errorf("serverHost(%q) = %q, want %q", test.serverURI, got, test.want)
+			}
+		})
+	}
+}
+
+func TestChannelCredentials(t *testing.T) {
+	tests := []struct {
+		name         string
+		types        []string
+		wantProtocol string
+		wantErr      error
+	}{
+		{
+			name:         "insecure",
+			types:        []string{"insecure"},
+			wantProtocol: "insecure",
+		},
+		{
+			name:         "first supported type",
+			types:        []string{"tls", "insecure"},
+			wantProtocol: "insecure",
+		},
+		{
+			name:    "no supported type",
+			types:   []string{"tls"},
+			wantErr: errUnsupportedCreds,
+		},
+		{
+			name:    "no types",
+			wantErr: errUnsupportedCreds,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := channelCredentials(test.types)
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("channelCredentials(%v) unexpected error: %v", test.types, err)
+			}
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("channelCredentials(%v) error = %v, want %v", test.types, err, test.wantErr)
+				}
+				return
+			}
+			if protocol := got.Info().SecurityProtocol; protocol != test.wantProtocol {
+				t.Errorf("channelCredentials(%v) security protocol = %s, want %s", test.types, protocol, test.wantProtocol)
+			}
+		})
+	}
+}
+
+func TestEarliestExpiry(t *testing.T) {
+	now := time.Now().Truncate(time.Second)
+	tests := []struct {
+		name      string
+		notAfters []time.Time
+		// withPrivateKey adds a private key PEM block, which is not a certificate.
+		withPrivateKey bool
+		want           time.Time
+		wantErr        error
+	}{
+		{
+			name:      "one certificate",
+			notAfters: []time.Time{now.Add(time.Hour)},
+			want:      now.Add(time.Hour),
+		},
+		{
+			name:           "certificate chain and private key",
+			notAfters:      []time.Time{now.Add(24 * time.Hour), now.Add(time.Hour), now.Add(48 * time.Hour)},
+			withPrivateKey: true,
+			want:           now.Add(time.Hour),
+		},
+		{
+			name:           "no certificates",
+			withPrivateKey: true,
+			wantErr:        errNoCertificates,
+		},
+		{
+			name:      "expired certificate in chain",
+			notAfters: []time.Time{now.Add(time.Hour), now.Add(-time.Hour)},
+			wantErr:   errExpiredCertificate,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			file := writePEMFile(t, test.notAfters, test.withPrivateKey)
+			got, err := earliestExpiry(file)
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("earliestExpiry() unexpected error: %v", err)
+			}
+			if test.wantErr != nil {
+				if !errors.Is(err, test.wantErr) {
+					t.Fatalf("earliestExpiry() error = %v, want %v", err, test.wantErr)
+				}
+				return
+			}
+			if !got.Equal(test.want) {
+				t.Errorf("earliestExpiry() = %s, want %s", got, test.want)
+			}
+		})
+	}
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := earliestExpiry(filepath.Join(t.TempDir(), "missing.pem")); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("earliestExpiry() error = %v, want %v", err, os.ErrNotExist)
+		}
+	})
+}
+
+// writePEMFile writes self-signed certificates with the expiry times, and optionally a
+// private key, to a PEM file in a temporary directory, and returns the file path.
+func writePEMFile(t *testing.T, notAfters []time.Time, withPrivateKey bool) string {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("could not generate private key: %v", err)
+	}
+	var pemBytes []byte
+	for i, notAfter := range notAfters {
+		template := &x509.Certificate{
+			SerialNumber: big.NewInt(int64(i + 1)),
+			Subject:      pkix.Name{CommonName: "greeter"},
+			NotBefore:    notAfter.Add(-48 * time.Hour),
+			NotAfter:     notAfter,
+		}
+		der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+		if err != nil {
+			t.Fatalf("could not create certificate: %v", err)
+		}
+		pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	}
+	if withPrivateKey {
+		der, err := x509.MarshalECPrivateKey(key)
+		if err != nil {
+			t.Fatalf("could not marshal private key: %v", err)
+		}
+		pemBytes = append(pemBytes, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: der})...)
+	}
+	file := filepath.Join(t.TempDir(), "certificates.pem")
+	if err := os.WriteFile(file, pemBytes, 0o600); err != nil {
+		t.Fatalf("could not write PEM file: %v", err)
+	}
+	return file
+}
@@ -0,0 +1,106 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+
+	echopb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/echo"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/echo"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greeter"
+)
+
+const (
+	// GreeterApplication serves the helloworld.Greeter, helloworld.StreamingGreeter, and
+	// helloworld.TracingGreeter services. This is the default application.
+	GreeterApplication = "greeter"
+	// EchoApplication serves the echo.Echo service, with configurable response sizes and latencies.
+	EchoApplication = "echo"
+)
+
+// readinessServiceName is the service name of the serving status for Kubernetes readiness probes.
+// The readiness probes use the same service name for all applications.
+var readinessServiceName = helloworldpb.Greeter_ServiceDesc.ServiceName
+
+// Application is a sample application that the server serves.
+type Application interface {
+	// ServiceName is the gRPC service name of the application, used for ORCA backend metrics.
+	ServiceName() string
+	// Register registers the gRPC services of the application to the serving server, and returns
+	// the handler of the HTTP/REST gateway. The handler is nil if the application has no gateway.
+	Register(ctx context.Context, logger logr.Logger, c Config, server grpc.ServiceRegistrar) (http.Handler, error)
+}
+
+var applications = map[string]Application{
+	GreeterApplication: greeterApplication{},
+	EchoApplication:    echoApplication{},
+}
+
+// RegisterApplication makes an application available to select by name in the server
+// configuration. Call this function from `init()` functions, before `Run()`.
+func RegisterApplication(name string, application Application) {
+	applications[name] = application
+}
+
+func lookupApplication(name string) (Application, error) {
+	application, exists := applications[name]
+	if !exists {
+		names := make([]string, 0, len(applications))
+		for name := range applications {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown application %q, must be one of %v", name, names)
+	}
+	return application, nil
+}
+
+// greeterApplication is the chain of greeter services, either a leaf or an intermediary.
+type greeterApplication struct{}
+
+func (greeterApplication) ServiceName() string {
+	return helloworldpb.Greeter_ServiceDesc.ServiceName
+}
+
+func (greeterApplication) Register(ctx context.Context, logger logr.Logger, c Config, server grpc.ServiceRegistrar) (http.Handler, error) {
+	greeterService, tracingGreeterService, err := greeter.RegisterServer(ctx, logger, c.GreeterName, c.PodName, c.Zone, c.NextHops, c.NextHopClientConfig, server)
+	if err != nil {
+		return nil, fmt.Errorf("could not register Greeter server: %w", err)
+	}
+	return newGreeterHTTPGatewayHandler(logger, greeterService, tracingGreeterService), nil
+}
+
+// echoApplication is the echo service. The echo service is always a leaf, and ignores NEXT_HOP.
+type echoApplication struct{}
+
+func (echoApplication) ServiceName() string {
+	return echopb.Echo_ServiceDesc.ServiceName
+}
+
+func (echoApplication) Register(ctx context.Context, logger logr.Logger, c Config, server grpc.ServiceRegistrar) (http.Handler, error) {
+	if len(c.NextHops) > 0 {
+		logger.Info("The echo application does not forward requests, ignoring NEXT_HOP", "NEXT_HOP", c.NextHops)
+	}
+	echoService := echo.NewService(ctx)
+	echopb.RegisterEchoServer(server, echoService)
+	return newEchoHTTPGatewayHandler(logger, echoService), nil
+}
@@ -0,0 +1,158 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+)
+
+// certificateExpiryPollInterval is how often to parse the workload and CA certificate files.
+const certificateExpiryPollInterval = time.Minute
+
+var errNoCertificates = errors.New("no certificates found in PEM file")
+
+// certificateExpiryMonitor periodically parses the PEM files used by the xDS certificate providers,
+// and exports the expiry time of the earliest expiring certificate in each file as a gauge.
+// It logs a warning when a certificate expires within the warning threshold, which usually means
+// that certificate rotation is broken, e.g., because of a misconfigured CA pool.
+//
+// The Go modules in this repository don't share packages, so `control-plane-go/pkg/server/certificate_expiry.go`
+// is a copy of this file, apart from the gauge name. Keep the copies, and their tests, in sync.
+type certificateExpiryMonitor struct {
+	logger    logr.Logger
+	files     []string
+	threshold time.Duration
+	mu        sync.Mutex
+	// expiries by file path.
+	expiries map[string]certificateExpiry
+}
+
+// certificateExpiry is the earliest expiring certificate in a PEM file.
+type certificateExpiry struct {
+	Subject  string
+	NotAfter time.Time
+}
+
+func newCertificateExpiryMonitor(logger logr.Logger, files []string, threshold time.Duration) (*certificateExpiryMonitor, error) {
+	m := &certificateExpiryMonitor{
+		logger:    logger,
+		files:     files,
+		threshold: threshold,
+		expiries:  map[string]certificateExpiry{},
+	}
+	expiryGauge, err := otel.Meter(meterName).Int64ObservableGauge("greeter.tls.certificate.expiry",
+		metric.WithDescription("Expiry time of the earliest expiring certificate in each PEM file, as seconds since the Unix epoch."),
+		metric.WithUnit("s"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create certificate expiry gauge: %w", err)
+	}
+	_, err = otel.Meter(meterName).RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		for file, expiry := range m.snapshot() {
+			observer.ObserveInt64(expiryGauge, expiry.NotAfter.Unix(), metric.WithAttributes(
+				attribute.String("file", file),
+				attribute.String("subject", expiry.Subject)))
+		}
+		return nil
+	}, expiryGauge)
+	if err != nil {
+		return nil, fmt.Errorf("could not register callback for certificate expiry gauge: %w", err)
+	}
+	return m, nil
+}
+
+// run parses the certificate files at the provided interval until the context is done.
+func (m *certificateExpiryMonitor) run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		m.poll()
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (m *certificateExpiryMonitor) poll() {
+	current := map[string]certificateExpiry{}
+	for _, file := range m.files {
+		expiry, err := earliestCertificateExpiry(file)
+		if err != nil {
+			m.logger.V(1).Info("Could not check certificate expiry", "file", file, "error", err.Error())
+			continue
+		}
+		current[file] = expiry
+		remaining := time.Until(expiry.NotAfter)
+		if m.threshold > 0 && remaining < m.threshold {
+			m.logger.Info("Warning: certificate expires soon, check certificate rotation and the CA configuration",
+				"file", file, "subject", expiry.Subject, "notAfter", expiry.NotAfter, "remaining", remaining.Round(time.Second).String())
+		}
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.expiries = current
+}
+
+func (m *certificateExpiryMonitor) snapshot() map[string]certificateExpiry {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.expiries
+}
+
+// earliestCertificateExpiry parses all certificates in the PEM file and returns the one that expires first.
+func earliestCertificateExpiry(file string) (certificateExpiry, error) {
+	pemBytes, err := os.ReadFile(file)
+	if err != nil {
+		return certificateExpiry{}, fmt.Errorf("could not read certificate file %s: %w", file, err)
+	}
+	var earliest *x509.Certificate
+	for {
+		var block *pem.Block
+		block, pemBytes = pem.Decode(pemBytes)
+		if block == nil {
+			break
+		}
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return certificateExpiry{}, fmt.Errorf("could not parse certificate in file %s: %w", file, err)
+		}
+		if earliest == nil || cert.NotAfter.Before(earliest.NotAfter) {
+			earliest = cert
+		}
+	}
+	if earliest == nil {
+		return certificateExpiry{}, fmt.Errorf("%w: %s", errNoCertificates, file)
+	}
+	return certificateExpiry{
+		Subject:  earliest.Subject.String(),
+		NotAfter: earliest.NotAfter,
+	}, nil
+}
@@ -0,0 +1,179 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/codes"
+	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+
+	echopb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/echo"
+	tracepb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/trace"
+)
+
+const (
+	httpGatewayRequestTimeout = 10 * time.Second
+)
+
+// listenHTTPGateway serves the HTTP/REST gateway of the application.
+//
+// The gateway calls the services in-process. Requests to the gateway are plaintext, and they
+// bypass the gRPC server interceptors, the xDS transport credentials, and the RBAC policies of
+// the serving port. For this reason, the gateway is opt-in, and only intended for development.
+func listenHTTPGateway(listener net.Listener, handler http.Handler) error {
+	httpGatewayServer := &http.Server{Handler: handler}
+	return httpGatewayServer.Serve(listener)
+}
+
+// newGreeterHTTPGatewayHandler serves `GET /hello?name=[name]` by calling the Greeter service
+// in-process, and returns the HelloReply as JSON. For the intermediary, this means
+// that the request to the next hop uses the xDS-enabled gRPC client.
+//
+// `GET /trace?name=[name]` calls the TracingGreeter service in-process, and returns
+// the TraceHelloReply with the hops of the request path as JSON.
+func newGreeterHTTPGatewayHandler(logger logr.Logger, greeterService helloworldpb.GreeterServer, tracingGreeterService tracepb.TracingGreeterServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /hello", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		logger.V(2).Info("Received HTTP gateway request", "url", r.URL.String(), "remoteAddr", r.RemoteAddr)
+		ctx, cancel := context.WithTimeout(r.Context(), httpGatewayRequestTimeout)
+		defer cancel()
+		resp, err := greeterService.SayHello(ctx, &helloworldpb.HelloRequest{Name: name})
+		if err != nil {
+			st := status.Convert(err)
+			logger.Error(err, "Greeting request from HTTP gateway failed", "name", name)
+			writeJSON(logger, w, httpStatusFromCode(st.Code()), st.Proto())
+			return
+		}
+		writeJSON(logger, w, http.StatusOK, resp)
+	})
+	mux.HandleFunc("GET /trace", func(w http.ResponseWriter, r *http.Request) {
+		name := r.URL.Query().Get("name")
+		logger.V(2).Info("Received HTTP gateway tracing request", "url", r.URL.String(), "remoteAddr", r.RemoteAddr)
+		ctx, cancel := context.WithTimeout(r.Context(), httpGatewayRequestTimeout)
+		defer cancel()
+		resp, err := tracingGreeterService.TraceHello(ctx, &tracepb.TraceHelloRequest{Name: name})
+		if err != nil {
+			st := status.Convert(err)
+			logger.Error(err, "Tracing request from HTTP gateway failed", "name", name)
+			writeJSON(logger, w, httpStatusFromCode(st.Code()), st.Proto())
+			return
+		}
+		writeJSON(logger, w, http.StatusOK, resp)
+	})
+	return mux
+}
+
+// newEchoHTTPGatewayHandler serves `GET /echo?message=[message]&responseSize=[bytes]&latency=[duration]`
+// by calling the Echo service in-process, and returns the EchoReply as JSON.
+func newEchoHTTPGatewayHandler(logger logr.Logger, echoService echopb.EchoServer) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /echo", func(w http.ResponseWriter, r *http.Request) {
+		logger.V(2).Info("Received HTTP gateway echo request", "url", r.URL.String(), "remoteAddr", r.RemoteAddr)
+		request, err := echoRequestFromQuery(r.URL.Query())
+		if err != nil {
+			st := status.New(codes.InvalidArgument, err.Error())
+			writeJSON(logger, w, httpStatusFromCode(st.Code()), st.Proto())
+			return
+		}
+		ctx, cancel := context.WithTimeout(r.Context(), httpGatewayRequestTimeout)
+		defer cancel()
+		resp, err := echoService.Echo(ctx, request)
+		if err != nil {
+			st := status.Convert(err)
+			logger.Error(err, "Echo request from HTTP gateway failed")
+			writeJSON(logger, w, httpStatusFromCode(st.Code()), st.Proto())
+			return
+		}
+		writeJSON(logger, w, http.StatusOK, resp)
+	})
+	return mux
+}
+
+func echoRequestFromQuery(query url.Values) (*echopb.EchoRequest, error) {
+	request := &echopb.EchoRequest{
+		Message: query.Get("message"),
+	}
+	if responseSize := query.Get("responseSize"); responseSize != "" {
+		size, err := strconv.ParseUint(responseSize, 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("invalid responseSize=%s: %w", responseSize, err)
+		}
+		request.ResponseSize = uint32(size)
+	}
+	if latency := query.Get("latency"); latency != "" {
+		duration, err := time.ParseDuration(latency)
+		if err != nil {
+			return nil, fmt.Errorf("invalid latency=%s: %w", latency, err)
+		}
+		request.Latency = durationpb.New(duration)
+	}
+	return request, nil
+}
+
+func writeJSON(logger logr.Logger, w http.ResponseWriter, httpStatus int, message proto.Message) {
+	body, err := protojson.Marshal(message)
+	if err != nil {
+		logger.Error(err, "Could not marshal HTTP gateway response body to JSON")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	w.Write(body)
+}
+
+// httpStatusFromCode maps gRPC status codes to HTTP status codes, see
+// https://github.com/googleapis/googleapis/blob/master/google/rpc/code.proto
+func httpStatusFromCode(code codes.Code) int {
+	switch code {
+	case codes.OK:
+		return http.StatusOK
+	case codes.Canceled:
+		return 499 // Client Closed Request
+	case codes.InvalidArgument, codes.FailedPrecondition, codes.OutOfRange:
+		return http.StatusBadRequest
+	case codes.DeadlineExceeded:
+		return http.StatusGatewayTimeout
+	case codes.NotFound:
+		return http.StatusNotFound
+	case codes.AlreadyExists, codes.Aborted:
+		return http.StatusConflict
+	case codes.PermissionDenied:
+		return http.StatusForbidden
+	case codes.Unauthenticated:
+		return http.StatusUnauthorized
+	case codes.ResourceExhausted:
+		return http.StatusTooManyRequests
+	case codes.Unimplemented:
+		return http.StatusNotImplemented
+	case codes.Unavailable:
+		return http.StatusServiceUnavailable
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -15,6 +15,7 @@
 package server
 
 import (
+	"encoding/json"
 	"net"
 	"net/http"
 
@@ -23,9 +24,20 @@ import (
 	"golang.org/x/net/http2/h2c"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/telemetry"
 )
 
-func listenHTTPHealth(logger logr.Logger, listener net.Listener, healthServer *health.Server) error {
+// healthzJSON is the response body of the `/livez` and `/readyz` paths.
+type healthzJSON struct {
+	Service string `json:"service"`
+	Status  string `json:"status"`
+	// ServingMode is the serving mode of the xDS-enabled gRPC server, only set on the `/readyz` path.
+	ServingMode string `json:"servingMode,omitempty"`
+}
+
+func listenHTTPHealth(logger logr.Logger, listener net.Listener, healthServer *health.Server, status *serverStatus) error {
 	mux := http.NewServeMux()
 	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
 		service := r.URL.Query().Get("service") // optional
@@ -49,6 +61,59 @@ func listenHTTPHealth(logger logr.Logger, listener net.Listener, healthServer *h
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(healthStatusName))
 	})
+	// `/livez` reports the overall health status, for Kubernetes liveness probes.
+	mux.HandleFunc("/livez", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthJSON(logger, w, r, healthServer, "", "")
+	})
+	// `/readyz` reports the health status of the Greeter service, for Kubernetes readiness probes.
+	mux.HandleFunc("/readyz", func(w http.ResponseWriter, r *http.Request) {
+		writeHealthJSON(logger, w, r, healthServer, readinessServiceName, status.xdsServingMode())
+	})
+	mux.HandleFunc("/configz", func(w http.ResponseWriter, r *http.Request) {
+		configz, err := status.configz(r.Context())
+		if err != nil {
+			logger.Error(err, "could not get server configuration state")
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		writeStatusJSON(logger, w, http.StatusOK, configz)
+	})
+	mux.Handle("/metrics", telemetry.MetricsHandler())
+	mux.Handle("/debug/verbosity", logging.VerbosityHandler(logger))
 	httpHealthServer := &http.Server{Handler: h2c.NewHandler(mux, &http2.Server{})}
 	return httpHealthServer.Serve(listener)
 }
+
+// writeHealthJSON writes the health status of the service as JSON, with status code 200 if
+// the service is SERVING, and 503 otherwise.
+func writeHealthJSON(logger logr.Logger, w http.ResponseWriter, r *http.Request, healthServer *health.Server, service string, servingMode string) {
+	healthResp, err := healthServer.Check(r.Context(), &healthpb.HealthCheckRequest{
+		Service: service,
+	})
+	if err != nil {
+		logger.Error(err, "could not access health status", "service", service)
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	statusCode := http.StatusServiceUnavailable
+	if healthResp.GetStatus() == healthpb.HealthCheckResponse_SERVING {
+		statusCode = http.StatusOK
+	}
+	writeStatusJSON(logger, w, statusCode, healthzJSON{
+		Service:     service,
+		Status:      healthResp.GetStatus().String(),
+		ServingMode: servingMode,
+	})
+}
+
+func writeStatusJSON(logger logr.Logger, w http.ResponseWriter, httpStatus int, value interface{}) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		logger.Error(err, "Could not marshal HTTP health response body to JSON")
+		w.WriteHeader(http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(httpStatus)
+	w.Write(body)
+}
@@ -18,6 +18,7 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"net/http"
 	"time"
 
 	"github.com/go-logr/logr"
@@ -27,34 +28,64 @@ import (
 	"google.golang.org/grpc/connectivity"
 	"google.golang.org/grpc/credentials/insecure"
 	xdscredentials "google.golang.org/grpc/credentials/xds"
-	helloworldpb "google.golang.org/grpc/examples/helloworld/helloworld"
 	"google.golang.org/grpc/health"
 	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/orca"
 	"google.golang.org/grpc/reflection"
 	"google.golang.org/grpc/xds"
 
+	connectionpb "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/api/connection"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/config"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/connectioninfo"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/greeter"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
 	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/logging"
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/telemetry"
 )
 
-// gRPC configuration based on https://github.com/envoyproxy/go-control-plane/blob/v0.11.1/internal/example/server.go
 const (
-	grpcKeepaliveTime        = 30 * time.Second
-	grpcKeepaliveTimeout     = 5 * time.Second
-	grpcKeepaliveMinTime     = 30 * time.Second
-	grpcMaxConcurrentStreams = 1000000
+	// orcaMinReportingInterval is the shortest interval that clients can request for out-of-band backend metric reports.
+	orcaMinReportingInterval = 5 * time.Second
+	// backendMetricsRecordInterval is how often CPU utilization, QPS, and EPS backend metrics are updated.
+	backendMetricsRecordInterval = 5 * time.Second
 )
 
 // Config provides server parameters read from the environment.
 type Config struct {
-	ServingPort    int
-	HealthPort     int
-	HTTPHealthPort int
-	GreeterName    string
-	NextHop        string
-	UseXDS         bool
+	ServingPort         int
+	HealthPort          int
+	HTTPHealthPort      int
+	HTTPGatewayPort     int
+	GreeterName         string
+	PodName             string
+	Zone                string
+	NextHops            []string
+	NextHopClientConfig greeter.ClientConfig
+	UseXDS              bool
+	// UseXDSCredentials is true if the gRPC xDS bootstrap configuration contains certificate providers.
+	UseXDSCredentials bool
+	// Application is the name of the application to serve, see `GreeterApplication` and `EchoApplication`.
+	Application            string
+	ApplicationUtilization float64
+	// GracefulShutdownTimeout is how long the server waits for open requests to finish on
+	// shutdown, before stopping immediately.
+	GracefulShutdownTimeout time.Duration
+	// FaultInjection configures artificial latency and errors injected into requests.
+	FaultInjection config.Fault
+	// DrainInterval is how long the server reports NOT_SERVING health status on shutdown,
+	// before it starts the graceful shutdown.
+	DrainInterval time.Duration
+	// CertificateFile is the workload certificate chain file, for the local certificate
+	// details returned by the connection info service.
+	CertificateFile string
+	// CACertificateFile is the CA certificates file used to verify peer certificates.
+	CACertificateFile string
+	// CertificateExpiryWarningThreshold is how long before certificate expiry the server starts
+	// logging warnings. A value of 0 disables the warnings.
+	CertificateExpiryWarningThreshold time.Duration
+	// GRPCServer contains the keepalive parameters and message size limits of the gRPC server.
+	GRPCServer config.GRPCServer
 }
 
 // grpcserver is implemented by both grpc.Server and xds.GRPCServer.
@@ -68,8 +99,41 @@ type grpcserver interface {
 
 func Run(ctx context.Context, c Config) error {
 	logger := logging.FromContext(ctx)
+	shutdownMeterProvider, err := telemetry.InitMeterProvider(c.Zone)
+	if err != nil {
+		return fmt.Errorf("could not initialize OpenTelemetry metrics: %w", err)
+	}
+	defer func() {
+		if err := shutdownMeterProvider(context.Background()); err != nil {
+			logger.Error(err, "Could not shut down the OpenTelemetry MeterProvider")
+		}
+	}()
+	application, err := lookupApplication(c.Application)
+	if err != nil {
+		return err
+	}
 	healthServer := health.NewServer()
-	serverOptions, err := configureServerOptions(logger, healthServer)
+	backendMetricsRecorder := telemetry.NewBackendMetricsRecorder(application.ServiceName(), c.ApplicationUtilization)
+	go backendMetricsRecorder.Run(ctx, backendMetricsRecordInterval)
+	if c.UseXDS && len(c.NextHops) > 0 {
+		endpointsObserver, err := newXDSEndpointsObserver(logger)
+		if err != nil {
+			return err
+		}
+		go endpointsObserver.run(ctx)
+	}
+	if c.UseXDS {
+		certificateExpiry, err := newCertificateExpiryMonitor(logger, []string{c.CertificateFile, c.CACertificateFile}, c.CertificateExpiryWarningThreshold)
+		if err != nil {
+			return err
+		}
+		go certificateExpiry.run(ctx, certificateExpiryPollInterval)
+	}
+	status, err := newServerStatus(logger, c)
+	if err != nil {
+		return err
+	}
+	serverOptions, err := configureServerOptions(logger, c, healthServer, backendMetricsRecorder, status)
 	if err != nil {
 		return fmt.Errorf("could not set gRPC server options: %w", err)
 	}
@@ -79,20 +143,35 @@ func Run(ctx context.Context, c Config) error {
 		return fmt.Errorf("could not create the serving gRPC server: %w", err)
 	}
 	healthGRPCServer := grpc.NewServer() // naming is hard :-(
-	addServerStopBehavior(ctx, logger, servingGRPCServer, healthGRPCServer, healthServer)
+	addServerStopBehavior(ctx, logger, c.DrainInterval, c.GracefulShutdownTimeout, servingGRPCServer, healthGRPCServer, healthServer)
+
+	httpGatewayHandler, err := application.Register(ctx, logger, c, servingGRPCServer)
+	if err != nil {
+		return fmt.Errorf("could not register the %s application: %w", c.Application, err)
+	}
 
-	if err := greeter.RegisterServer(ctx, logger, c.GreeterName, c.NextHop, servingGRPCServer); err != nil {
-		return fmt.Errorf("could not register Greeter server: %w", err)
+	// Report backend metrics out-of-band on the serving port, for weighted round-robin load balancing
+	if err := orca.Register(servingGRPCServer, orca.ServiceOptions{
+		ServerMetricsProvider: backendMetricsRecorder,
+		MinReportingInterval:  orcaMinReportingInterval,
+	}); err != nil {
+		return fmt.Errorf("could not register ORCA service: %w", err)
 	}
 
 	// Register health server on both serving and health ports
 	// Set serving status for k8s startup and liveness probes:
 	healthServer.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
 	// Set serving status for k8s readiness probes:
-	healthServer.SetServingStatus(helloworldpb.Greeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	healthServer.SetServingStatus(readinessServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
 	healthpb.RegisterHealthServer(servingGRPCServer, healthServer)
 	healthpb.RegisterHealthServer(healthGRPCServer, healthServer)
 
+	// Register connection info service on both serving and health ports, to verify the
+	// mTLS configuration on the serving port, and the local certificate on both ports.
+	connectionInfoService := connectioninfo.NewService(ctx, c.CertificateFile)
+	connectionpb.RegisterConnectionInfoServer(servingGRPCServer, connectionInfoService)
+	connectionpb.RegisterConnectionInfoServer(healthGRPCServer, connectionInfoService)
+
 	// Register admin services on both serving and health ports
 	cleanupAdminServers, err := registerAdminServers(c.UseXDS, servingGRPCServer, healthGRPCServer)
 	if err != nil {
@@ -107,41 +186,58 @@ func Run(ctx context.Context, c Config) error {
 	reflection.Register(servingGRPCServer)
 	reflection.Register(healthGRPCServer)
 
-	return serve(logger, c, servingGRPCServer, healthServer, healthGRPCServer)
+	return serve(logger, c, servingGRPCServer, healthServer, healthGRPCServer, httpGatewayHandler, status)
 }
 
-func configureServerOptions(logger logr.Logger, healthServer *health.Server) ([]grpc.ServerOption, error) {
-	logger.V(1).Info("Using xDS server-side credentials, with insecure as fallback")
+func configureServerOptions(logger logr.Logger, c Config, healthServer *health.Server, backendMetricsRecorder *telemetry.BackendMetricsRecorder, status *serverStatus) ([]grpc.ServerOption, error) {
+	readinessGate, err := newXDSReadinessGate(logger, healthServer)
+	if err != nil {
+		return nil, err
+	}
+	if c.FaultInjection.Enabled() {
+		logger.Info("Injecting faults into requests", "faultInjection", c.FaultInjection)
+	}
+	if c.UseXDSCredentials {
+		logger.V(1).Info("Using xDS server-side credentials, with insecure as fallback")
+	} else {
+		logger.V(1).Info("Using xDS server-side credentials without certificate providers, only plaintext connections will work")
+	}
 	serverCredentials, err := xdscredentials.NewServerCredentials(xdscredentials.ServerOptions{FallbackCreds: insecure.NewCredentials()})
 	if err != nil {
 		return nil, fmt.Errorf("could not create server-side transport credentials for xDS: %w", err)
 	}
 	return []grpc.ServerOption{
-		grpc.ChainStreamInterceptor(interceptors.StreamServerLogging(logger)),
-		grpc.ChainUnaryInterceptor(interceptors.UnaryServerLogging(logger)),
+		// The request ID interceptors must run before the logging interceptors, to include the request IDs in logs.
+		// The fault interceptors run last, so that injected errors are logged and recorded as backend metrics.
+		grpc.ChainStreamInterceptor(interceptors.StreamServerRequestID(), interceptors.StreamServerLogging(logger), interceptors.StreamServerBackendInfo(c.PodName, c.Zone), interceptors.StreamServerFault(c.FaultInjection)),
+		grpc.ChainUnaryInterceptor(interceptors.UnaryServerRequestID(), interceptors.UnaryServerLogging(logger), interceptors.UnaryServerBackendInfo(c.PodName, c.Zone), backendMetricsRecorder.UnaryServerInterceptor(), interceptors.UnaryServerFault(c.FaultInjection)),
 		grpc.Creds(serverCredentials),
 		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
-			MinTime:             grpcKeepaliveMinTime,
+			MinTime:             c.GRPCServer.KeepaliveMinTime,
 			PermitWithoutStream: true,
 		}),
 		grpc.KeepaliveParams(keepalive.ServerParameters{
-			Time:    grpcKeepaliveTime,
-			Timeout: grpcKeepaliveTimeout,
+			Time:    c.GRPCServer.KeepaliveTime,
+			Timeout: c.GRPCServer.KeepaliveTimeout,
 		}),
-		grpc.MaxConcurrentStreams(grpcMaxConcurrentStreams),
+		grpc.MaxConcurrentStreams(c.GRPCServer.MaxConcurrentStreams),
+		grpc.MaxRecvMsgSize(c.GRPCServer.MaxRecvMsgSize),
+		grpc.MaxSendMsgSize(c.GRPCServer.MaxSendMsgSize),
+		grpc.StatsHandler(telemetry.ServerStatsHandler()),
+		// Report backend metrics per-request in trailers
+		orca.CallMetricsServerOption(backendMetricsRecorder),
 		xds.ServingModeCallback(func(addr net.Addr, args xds.ServingModeChangeArgs) {
+			status.setServingMode(args.Mode)
 			switch args.Mode {
 			case connectivity.ServingModeStarting:
 				logger.Info("Attempting to connect to the xDS control plane management server")
 			case connectivity.ServingModeServing:
-				// Make k8s readiness probes pass.
-				// TODO: Enhance this Listener so readiness probes only pass after the server Listener and RouteConfiguration resources have been ACKed.
+				// Make k8s readiness probes pass, after the server Listener and RouteConfiguration resources have been ACKed.
 				logger.Info("Connected to the xDS control plane management server")
-				healthServer.SetServingStatus(helloworldpb.Greeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+				readinessGate.serving(addr)
 			case connectivity.ServingModeNotServing:
-				// Do _not_ make k8s readiness probes fail, because the greeter server can continue using the last ACKed configuration.
 				logger.Error(args.Err, "Lost connection to the xDS control plane management server, using cached configuration", "xdsControlPlaneServingMode", args.Mode.String())
-				healthServer.SetServingStatus(helloworldpb.Greeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+				readinessGate.notServing()
 			}
 		}),
 	}, nil
@@ -163,18 +259,26 @@ func newGRPCServer(logger logr.Logger, useXDS bool, opts ...grpc.ServerOption) (
 	return server, nil
 }
 
-func addServerStopBehavior(ctx context.Context, logger logr.Logger, servingGRPCServer grpcserver, healthGRPCServer grpcserver, healthServer *health.Server) {
+// addServerStopBehavior stops the servers when the context is done. The health status changes
+// to NOT_SERVING first, and the server keeps serving for the drain interval, so that clients can
+// switch to other replicas. GracefulStop then sends HTTP/2 GOAWAY frames to all clients and waits
+// for open requests to finish, until the graceful shutdown timeout expires.
+func addServerStopBehavior(ctx context.Context, logger logr.Logger, drainInterval time.Duration, gracefulShutdownTimeout time.Duration, servingGRPCServer grpcserver, healthGRPCServer grpcserver, healthServer *health.Server) {
 	go func() {
 		<-ctx.Done()
 		healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
-		healthServer.SetServingStatus(helloworldpb.Greeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+		healthServer.SetServingStatus(readinessServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+		if drainInterval > 0 {
+			logger.Info("Draining the gRPC server before stopping", "drainInterval", drainInterval)
+			time.Sleep(drainInterval)
+		}
 		stopped := make(chan struct{})
 		go func() {
 			logger.Info("Attempting to gracefully stop the gRPC server")
 			servingGRPCServer.GracefulStop()
 			close(stopped)
 		}()
-		timer := time.NewTimer(5 * time.Second)
+		timer := time.NewTimer(gracefulShutdownTimeout)
 		select {
 		case <-timer.C:
 			logger.Info("Stopping the gRPC server immediately")
@@ -208,7 +312,7 @@ func registerAdminServers(useXDS bool, servingGRPCServer grpcserver, healthGRPCS
 	}, nil
 }
 
-func serve(logger logr.Logger, c Config, servingGRPCServer grpcserver, healthServer *health.Server, healthGRPCServer *grpc.Server) error {
+func serve(logger logr.Logger, c Config, servingGRPCServer grpcserver, healthServer *health.Server, healthGRPCServer *grpc.Server, httpGatewayHandler http.Handler, status *serverStatus) error {
 	servingListener, err := net.Listen("tcp4", fmt.Sprintf(":%d", c.ServingPort))
 	if err != nil {
 		return fmt.Errorf("could not create TCP listener on gRPC serving port=%d: %w", c.ServingPort, err)
@@ -221,16 +325,31 @@ func serve(logger logr.Logger, c Config, servingGRPCServer grpcserver, healthSer
 	if err != nil {
 		return fmt.Errorf("could not create TCP listener on HTTP health port=%d: %w", c.HealthPort, err)
 	}
-	logger.V(1).Info("Greeter service listening", "port", c.ServingPort, "healthPort", c.HealthPort, "httpHealthPort", c.HTTPHealthPort, "nextHop", c.NextHop)
+	var httpGatewayListener net.Listener
+	if c.HTTPGatewayPort != 0 && httpGatewayHandler != nil {
+		httpGatewayListener, err = net.Listen("tcp4", fmt.Sprintf(":%d", c.HTTPGatewayPort))
+		if err != nil {
+			return fmt.Errorf("could not create TCP listener on HTTP gateway port=%d: %w", c.HTTPGatewayPort, err)
+		}
+		logger.Info("The HTTP gateway accepts plaintext requests without the interceptors, transport credentials, and RBAC policies of the serving port, only enable it in development environments", "httpGatewayPort", c.HTTPGatewayPort)
+	}
+	logger.V(1).Info("Greeter service listening", "application", c.Application, "port", c.ServingPort, "healthPort", c.HealthPort, "httpHealthPort", c.HTTPHealthPort, "httpGatewayPort", c.HTTPGatewayPort, "nextHops", c.NextHops)
 	go func() {
 		err := servingGRPCServer.Serve(servingListener)
 		if err != nil {
 			healthServer.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
-			healthServer.SetServingStatus(helloworldpb.Greeter_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+			healthServer.SetServingStatus(readinessServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
 		}
 	}()
 	go func() {
-		listenHTTPHealth(logger, httpHealthListener, healthServer)
+		listenHTTPHealth(logger, httpHealthListener, healthServer, status)
 	}()
+	if httpGatewayListener != nil {
+		go func() {
+			if err := listenHTTPGateway(httpGatewayListener, httpGatewayHandler); err != nil {
+				logger.Error(err, "HTTP gateway stopped")
+			}
+		}()
+	}
 	return healthGRPCServer.Serve(healthListener)
 }
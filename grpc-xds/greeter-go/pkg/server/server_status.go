@@ -0,0 +1,164 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	statusv3 "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/connectivity"
+	"google.golang.org/grpc/xds/csds"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/xdsclient/bootstrap"
+)
+
+// serverStatus records the state of the greeter server that the HTTP health server
+// returns from the `/readyz` and `/configz` paths, for debugging.
+type serverStatus struct {
+	config Config
+	// bootstrapServerURI, certificateProviders, and listenerNameTemplate are from the
+	// gRPC xDS bootstrap configuration, if the server uses xDS.
+	bootstrapServerURI   string
+	certificateProviders []string
+	listenerNameTemplate string
+	// csdsServer provides the time of the last xDS resource update. Nil if the server does not use xDS.
+	csdsServer         *csds.ClientStatusDiscoveryServer
+	mu                 sync.Mutex
+	servingMode        connectivity.ServingMode
+	servingModeChanged time.Time
+}
+
+// configzJSON is the response body of the `/configz` path.
+type configzJSON struct {
+	GreeterName                        string     `json:"greeterName"`
+	PodName                            string     `json:"podName,omitempty"`
+	Zone                               string     `json:"zone,omitempty"`
+	NextHop                            string     `json:"nextHop,omitempty"`
+	UseXDS                             bool       `json:"useXds"`
+	ServingMode                        string     `json:"servingMode,omitempty"`
+	ServingModeChanged                 *time.Time `json:"servingModeChanged,omitempty"`
+	LastXDSUpdate                      *time.Time `json:"lastXdsUpdate,omitempty"`
+	BootstrapServerURI                 string     `json:"bootstrapServerUri,omitempty"`
+	ServerListenerResourceNameTemplate string     `json:"serverListenerResourceNameTemplate,omitempty"`
+	CertificateProviderPresent         bool       `json:"certificateProviderPresent"`
+	CertificateProviders               []string   `json:"certificateProviders,omitempty"`
+}
+
+func newServerStatus(logger logr.Logger, c Config) (*serverStatus, error) {
+	status := &serverStatus{
+		config: c,
+	}
+	if !c.UseXDS {
+		return status, nil
+	}
+	csdsServer, err := csds.NewClientStatusDiscoveryServer()
+	if err != nil {
+		return nil, fmt.Errorf("could not create CSDS server for the server status: %w", err)
+	}
+	status.csdsServer = csdsServer
+	bootstrapConfig, err := bootstrap.NewConfigPartial()
+	if err != nil {
+		logger.V(2).Info("Could not read the gRPC xDS bootstrap configuration for the server status", "error", err.Error())
+		return status, nil
+	}
+	if err := bootstrapConfig.Validate(); err != nil {
+		logger.Error(err, "Invalid gRPC xDS bootstrap configuration, check the bootstrap file or the init container that creates it")
+	}
+	status.bootstrapServerURI = bootstrapConfig.ServerURI
+	status.listenerNameTemplate = bootstrapConfig.ServerListenerResourceNameTemplate
+	for name := range bootstrapConfig.CertProviderConfigs {
+		status.certificateProviders = append(status.certificateProviders, name)
+	}
+	sort.Strings(status.certificateProviders)
+	return status, nil
+}
+
+// setServingMode records the serving mode reported by the xDS-enabled gRPC server.
+func (s *serverStatus) setServingMode(mode connectivity.ServingMode) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.servingMode = mode
+	s.servingModeChanged = time.Now()
+}
+
+// xdsServingMode returns the serving mode reported by the xDS-enabled gRPC server,
+// or an empty string if the server does not use xDS.
+func (s *serverStatus) xdsServingMode() string {
+	if !s.config.UseXDS {
+		return ""
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.servingMode.String()
+}
+
+func (s *serverStatus) configz(ctx context.Context) (configzJSON, error) {
+	configz := configzJSON{
+		GreeterName:                        s.config.GreeterName,
+		PodName:                            s.config.PodName,
+		Zone:                               s.config.Zone,
+		NextHop:                            strings.Join(s.config.NextHops, ","),
+		UseXDS:                             s.config.UseXDS,
+		BootstrapServerURI:                 s.bootstrapServerURI,
+		ServerListenerResourceNameTemplate: s.listenerNameTemplate,
+		CertificateProviderPresent:         len(s.certificateProviders) > 0,
+		CertificateProviders:               s.certificateProviders,
+	}
+	if !s.config.UseXDS {
+		return configz, nil
+	}
+	s.mu.Lock()
+	configz.ServingMode = s.servingMode.String()
+	if !s.servingModeChanged.IsZero() {
+		servingModeChanged := s.servingModeChanged
+		configz.ServingModeChanged = &servingModeChanged
+	}
+	s.mu.Unlock()
+	lastXDSUpdate, err := s.lastXDSUpdate(ctx)
+	if err != nil {
+		return configzJSON{}, err
+	}
+	if !lastXDSUpdate.IsZero() {
+		configz.LastXDSUpdate = &lastXDSUpdate
+	}
+	return configz, nil
+}
+
+// lastXDSUpdate returns the most recent update time of the xDS resources in the xDS client,
+// or the zero time if the xDS client has not received any resources.
+func (s *serverStatus) lastXDSUpdate(ctx context.Context) (time.Time, error) {
+	clientStatus, err := s.csdsServer.FetchClientStatus(ctx, &statusv3.ClientStatusRequest{})
+	if err != nil {
+		return time.Time{}, fmt.Errorf("could not fetch xDS client status: %w", err)
+	}
+	var lastUpdated time.Time
+	for _, clientConfig := range clientStatus.GetConfig() {
+		for _, genericConfig := range clientConfig.GetGenericXdsConfigs() {
+			if genericConfig.GetLastUpdated() == nil {
+				continue
+			}
+			if updated := genericConfig.GetLastUpdated().AsTime(); updated.After(lastUpdated) {
+				lastUpdated = updated
+			}
+		}
+	}
+	return lastUpdated, nil
+}
@@ -0,0 +1,213 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	adminv3 "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	endpointv3 "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
+	statusv3 "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/go-logr/logr"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/xds/csds"
+)
+
+const (
+	meterName = "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/server"
+	// xdsEndpointsPollInterval is how often to check the client status of the ClusterLoadAssignments.
+	xdsEndpointsPollInterval = 2 * time.Second
+)
+
+// xdsEndpointsObserver logs the endpoints of the next hop, with their localities and weights,
+// whenever the xDS client receives a change. This makes load balancing decisions visible in
+// real time, without access to the control plane.
+//
+// The observer uses the Client Status Discovery Service (CSDS) to read the ClusterLoadAssignments
+// that the xDS client has ACKed. It also exports the number of endpoints by ClusterLoadAssignment,
+// zone, and priority as a gauge.
+type xdsEndpointsObserver struct {
+	logger     logr.Logger
+	csdsServer *csds.ClientStatusDiscoveryServer
+	mu         sync.Mutex
+	// endpoints by ClusterLoadAssignment name.
+	endpoints map[string][]xdsEndpoint
+}
+
+// xdsEndpoint is an endpoint of a ClusterLoadAssignment, with its locality and weights.
+type xdsEndpoint struct {
+	Address        string `json:"address"`
+	Zone           string `json:"zone"`
+	Priority       uint32 `json:"priority"`
+	LocalityWeight uint32 `json:"localityWeight"`
+	Weight         uint32 `json:"weight"`
+	HealthStatus   string `json:"healthStatus"`
+}
+
+func newXDSEndpointsObserver(logger logr.Logger) (*xdsEndpointsObserver, error) {
+	csdsServer, err := csds.NewClientStatusDiscoveryServer()
+	if err != nil {
+		return nil, fmt.Errorf("could not create CSDS server for the xDS endpoints observer: %w", err)
+	}
+	o := &xdsEndpointsObserver{
+		logger:     logger,
+		csdsServer: csdsServer,
+		endpoints:  map[string][]xdsEndpoint{},
+	}
+	endpointsGauge, err := otel.Meter(meterName).Int64ObservableGauge("greeter.xds.endpoints",
+		metric.WithDescription("Number of next hop endpoints in the xDS client, by ClusterLoadAssignment, zone, and priority."),
+		metric.WithUnit("{endpoint}"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create xDS endpoints gauge: %w", err)
+	}
+	_, err = otel.Meter(meterName).RegisterCallback(func(_ context.Context, observer metric.Observer) error {
+		counts := map[[3]string]int64{}
+		for name, endpoints := range o.snapshot() {
+			for _, endpoint := range endpoints {
+				counts[[3]string{name, endpoint.Zone, strconv.FormatUint(uint64(endpoint.Priority), 10)}]++
+			}
+		}
+		for key, count := range counts {
+			observer.ObserveInt64(endpointsGauge, count, metric.WithAttributes(
+				attribute.String("cluster_load_assignment", key[0]),
+				attribute.String("zone", key[1]),
+				attribute.String("priority", key[2])))
+		}
+		return nil
+	}, endpointsGauge)
+	if err != nil {
+		return nil, fmt.Errorf("could not register callback for xDS endpoints gauge: %w", err)
+	}
+	return o, nil
+}
+
+// run polls the client status of the ClusterLoadAssignments until the context is done.
+func (o *xdsEndpointsObserver) run(ctx context.Context) {
+	ticker := time.NewTicker(xdsEndpointsPollInterval)
+	defer ticker.Stop()
+	for {
+		if err := o.poll(ctx); err != nil {
+			o.logger.V(2).Info("Could not check the client status of the ClusterLoadAssignments", "error", err.Error())
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (o *xdsEndpointsObserver) poll(ctx context.Context) error {
+	clientStatus, err := o.csdsServer.FetchClientStatus(ctx, &statusv3.ClientStatusRequest{})
+	if err != nil {
+		return fmt.Errorf("could not fetch xDS client status: %w", err)
+	}
+	current := map[string][]xdsEndpoint{}
+	for _, clientConfig := range clientStatus.GetConfig() {
+		for _, genericConfig := range clientConfig.GetGenericXdsConfigs() {
+			if genericConfig.GetTypeUrl() != resource.EndpointType ||
+				genericConfig.GetClientStatus() != adminv3.ClientResourceStatus_ACKED {
+				continue
+			}
+			var cla endpointv3.ClusterLoadAssignment
+			if err := genericConfig.GetXdsConfig().UnmarshalTo(&cla); err != nil {
+				return fmt.Errorf("could not unmarshal ClusterLoadAssignment %s: %w", genericConfig.GetName(), err)
+			}
+			current[genericConfig.GetName()] = xdsEndpoints(&cla)
+		}
+	}
+	o.mu.Lock()
+	previous := o.endpoints
+	o.endpoints = current
+	o.mu.Unlock()
+	for name, endpoints := range current {
+		if previousEndpoints, exists := previous[name]; !exists || !slices.Equal(previousEndpoints, endpoints) {
+			added, removed := diffAddresses(previousEndpoints, endpoints)
+			o.logger.Info("Next hop endpoints changed", "clusterLoadAssignment", name, "endpoints", endpoints, "added", added, "removed", removed)
+		}
+	}
+	for name := range previous {
+		if _, exists := current[name]; !exists {
+			o.logger.Info("Next hop endpoints removed", "clusterLoadAssignment", name)
+		}
+	}
+	return nil
+}
+
+func (o *xdsEndpointsObserver) snapshot() map[string][]xdsEndpoint {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	return o.endpoints
+}
+
+// xdsEndpoints returns the endpoints of the ClusterLoadAssignment, sorted by priority, zone, and address.
+func xdsEndpoints(cla *endpointv3.ClusterLoadAssignment) []xdsEndpoint {
+	var endpoints []xdsEndpoint
+	for _, localityEndpoints := range cla.GetEndpoints() {
+		for _, lbEndpoint := range localityEndpoints.GetLbEndpoints() {
+			socketAddress := lbEndpoint.GetEndpoint().GetAddress().GetSocketAddress()
+			endpoints = append(endpoints, xdsEndpoint{
+				Address:        fmt.Sprintf("%s:%d", socketAddress.GetAddress(), socketAddress.GetPortValue()),
+				Zone:           localityEndpoints.GetLocality().GetZone(),
+				Priority:       localityEndpoints.GetPriority(),
+				LocalityWeight: localityEndpoints.GetLoadBalancingWeight().GetValue(),
+				Weight:         lbEndpoint.GetLoadBalancingWeight().GetValue(),
+				HealthStatus:   lbEndpoint.GetHealthStatus().String(),
+			})
+		}
+	}
+	sort.Slice(endpoints, func(i, j int) bool {
+		if endpoints[i].Priority != endpoints[j].Priority {
+			return endpoints[i].Priority < endpoints[j].Priority
+		}
+		if endpoints[i].Zone != endpoints[j].Zone {
+			return endpoints[i].Zone < endpoints[j].Zone
+		}
+		return endpoints[i].Address < endpoints[j].Address
+	})
+	return endpoints
+}
+
+// diffAddresses returns the addresses of the endpoints that were added and removed.
+func diffAddresses(previous []xdsEndpoint, current []xdsEndpoint) ([]string, []string) {
+	previousAddresses := map[string]bool{}
+	for _, endpoint := range previous {
+		previousAddresses[endpoint.Address] = true
+	}
+	currentAddresses := map[string]bool{}
+	var added []string
+	for _, endpoint := range current {
+		currentAddresses[endpoint.Address] = true
+		if !previousAddresses[endpoint.Address] {
+			added = append(added, endpoint.Address)
+		}
+	}
+	var removed []string
+	for _, endpoint := range previous {
+		if !currentAddresses[endpoint.Address] {
+			removed = append(removed, endpoint.Address)
+		}
+	}
+	return added, removed
+}
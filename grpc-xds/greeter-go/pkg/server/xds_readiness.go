@@ -0,0 +1,224 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	adminv3 "github.com/envoyproxy/go-control-plane/envoy/admin/v3"
+	listenerv3 "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
+	http_connection_managerv3 "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/http_connection_manager/v3"
+	statusv3 "github.com/envoyproxy/go-control-plane/envoy/service/status/v3"
+	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/xds/csds"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/xdsclient/bootstrap"
+)
+
+const (
+	// xdsReadinessPollInterval is how often to check the client status of the xDS resources.
+	xdsReadinessPollInterval = 500 * time.Millisecond
+	// xdsReadinessTimeout is how long to wait for the ACKs, before falling back to the serving mode
+	// callback of the xDS-enabled gRPC server, e.g., if the resource names in the client status do
+	// not match the server Listener name.
+	xdsReadinessTimeout = 60 * time.Second
+)
+
+// xdsReadinessGate sets the serving status of the Greeter service for Kubernetes readiness probes
+// to SERVING only after the xDS client has ACKed the server Listener and its RouteConfiguration.
+//
+// The serving mode callback of the xDS-enabled gRPC server reports SERVING when it receives the
+// server Listener, which can be before the RouteConfiguration is ACKed. The gate uses the Client
+// Status Discovery Service (CSDS) to check the status of both resources. If the resources are not
+// ACKed within `xdsReadinessTimeout`, the gate logs a warning and sets the serving status to SERVING.
+type xdsReadinessGate struct {
+	logger       logr.Logger
+	healthServer *health.Server
+	// ackedFunc checks the client status of the server Listener and its RouteConfiguration.
+	ackedFunc func(ctx context.Context, listenerName string) (bool, error)
+	// listenerNameTemplate is `server_listener_resource_name_template` from the gRPC xDS bootstrap configuration.
+	listenerNameTemplate string
+	pollInterval         time.Duration
+	timeout              time.Duration
+	// mu guards cancel and the serving status of the Greeter service. The context of the running
+	// `waitForACK()` is canceled whenever the serving mode changes, while holding mu.
+	mu     sync.Mutex
+	cancel context.CancelFunc
+}
+
+func newXDSReadinessGate(logger logr.Logger, healthServer *health.Server) (*xdsReadinessGate, error) {
+	csdsServer, err := csds.NewClientStatusDiscoveryServer()
+	if err != nil {
+		return nil, fmt.Errorf("could not create CSDS server for the xDS readiness gate: %w", err)
+	}
+	gate := &xdsReadinessGate{
+		logger:       logger,
+		healthServer: healthServer,
+		pollInterval: xdsReadinessPollInterval,
+		timeout:      xdsReadinessTimeout,
+	}
+	gate.ackedFunc = func(ctx context.Context, listenerName string) (bool, error) {
+		return acked(ctx, csdsServer, listenerName)
+	}
+	if bootstrapConfig, err := bootstrap.NewConfigPartial(); err == nil {
+		gate.listenerNameTemplate = bootstrapConfig.ServerListenerResourceNameTemplate
+	} else {
+		logger.V(2).Info("Could not read the server Listener resource name template from the gRPC xDS bootstrap configuration, readiness will not wait for ACKs", "error", err.Error())
+	}
+	return gate, nil
+}
+
+// serving waits in the background until the server Listener for the address and its RouteConfiguration
+// are ACKed, and then sets the serving status of the Greeter service to SERVING.
+func (g *xdsReadinessGate) serving(addr net.Addr) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		g.cancel()
+	}
+	if g.listenerNameTemplate == "" {
+		g.healthServer.SetServingStatus(readinessServiceName, healthpb.HealthCheckResponse_SERVING)
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	g.cancel = cancel
+	listenerName := fmt.Sprintf(g.listenerNameTemplate, addr.String())
+	go g.waitForACK(ctx, listenerName)
+}
+
+// notServing stops waiting for ACKs, and sets the serving status of the Greeter service to NOT_SERVING.
+func (g *xdsReadinessGate) notServing() {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if g.cancel != nil {
+		g.cancel()
+		g.cancel = nil
+	}
+	g.healthServer.SetServingStatus(readinessServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+}
+
+func (g *xdsReadinessGate) waitForACK(ctx context.Context, listenerName string) {
+	logger := g.logger.WithValues("listener", listenerName)
+	ticker := time.NewTicker(g.pollInterval)
+	defer ticker.Stop()
+	timeout := time.NewTimer(g.timeout)
+	defer timeout.Stop()
+	for {
+		acked, err := g.ackedFunc(ctx, listenerName)
+		if err != nil {
+			logger.V(2).Info("Could not check the client status of the xDS resources", "error", err.Error())
+		}
+		if acked {
+			if g.setServing(ctx) {
+				logger.Info("Server Listener and RouteConfiguration ACKed, ready to serve")
+			}
+			return
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-timeout.C:
+			if g.setServing(ctx) {
+				logger.Info("Warning: Server Listener and RouteConfiguration not ACKed, using the serving mode of the xDS-enabled gRPC server for readiness instead", "timeout", g.timeout)
+			}
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// setServing sets the serving status of the Greeter service to SERVING, unless the serving mode
+// changed since `waitForACK()` started, i.e., unless ctx is done. Returns true if the status was set.
+func (g *xdsReadinessGate) setServing(ctx context.Context) bool {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if ctx.Err() != nil {
+		return false
+	}
+	g.healthServer.SetServingStatus(readinessServiceName, healthpb.HealthCheckResponse_SERVING)
+	return true
+}
+
+// acked returns true iff the server Listener is ACKed, and either includes its RouteConfiguration
+// inline, or refers to a RouteConfiguration that is also ACKed.
+func acked(ctx context.Context, csdsServer *csds.ClientStatusDiscoveryServer, listenerName string) (bool, error) {
+	clientStatus, err := csdsServer.FetchClientStatus(ctx, &statusv3.ClientStatusRequest{})
+	if err != nil {
+		return false, fmt.Errorf("could not fetch xDS client status: %w", err)
+	}
+	listenerConfig := findACKedConfig(clientStatus, resource.ListenerType, listenerName)
+	if listenerConfig == nil {
+		return false, nil
+	}
+	var listener listenerv3.Listener
+	if err := listenerConfig.GetXdsConfig().UnmarshalTo(&listener); err != nil {
+		return false, fmt.Errorf("could not unmarshal server Listener %s: %w", listenerName, err)
+	}
+	routeConfigurationName, err := rdsRouteConfigurationName(&listener)
+	if err != nil {
+		return false, err
+	}
+	if routeConfigurationName == "" {
+		return true, nil
+	}
+	return findACKedConfig(clientStatus, resource.RouteType, routeConfigurationName) != nil, nil
+}
+
+func findACKedConfig(clientStatus *statusv3.ClientStatusResponse, typeURL string, name string) *statusv3.ClientConfig_GenericXdsConfig {
+	for _, clientConfig := range clientStatus.GetConfig() {
+		for _, genericConfig := range clientConfig.GetGenericXdsConfigs() {
+			if genericConfig.GetTypeUrl() == typeURL &&
+				genericConfig.GetName() == name &&
+				genericConfig.GetClientStatus() == adminv3.ClientResourceStatus_ACKED {
+				return genericConfig
+			}
+		}
+	}
+	return nil
+}
+
+// rdsRouteConfigurationName returns the name of the RouteConfiguration that the server Listener
+// fetches using RDS, or an empty string if the RouteConfiguration is inline.
+func rdsRouteConfigurationName(listener *listenerv3.Listener) (string, error) {
+	filterChains := listener.GetFilterChains()
+	if listener.GetDefaultFilterChain() != nil {
+		filterChains = append(filterChains, listener.GetDefaultFilterChain())
+	}
+	for _, filterChain := range filterChains {
+		for _, filter := range filterChain.GetFilters() {
+			if filter.GetTypedConfig() == nil {
+				continue
+			}
+			var httpConnectionManager http_connection_managerv3.HttpConnectionManager
+			if !filter.GetTypedConfig().MessageIs(&httpConnectionManager) {
+				continue
+			}
+			if err := filter.GetTypedConfig().UnmarshalTo(&httpConnectionManager); err != nil {
+				return "", fmt.Errorf("could not unmarshal HttpConnectionManager of server Listener %s: %w", listener.GetName(), err)
+			}
+			if rds := httpConnectionManager.GetRds(); rds != nil {
+				return rds.GetRouteConfigName(), nil
+			}
+		}
+	}
+	return "", nil
+}
@@ -0,0 +1,112 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package server
+
+import (
+	"context"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/go-logr/logr"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+var testServingAddr = &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 50051}
+
+func newTestReadinessGate(ackedFunc func(ctx context.Context, listenerName string) (bool, error)) (*xdsReadinessGate, *health.Server) {
+	healthServer := health.NewServer()
+	healthServer.SetServingStatus(readinessServiceName, healthpb.HealthCheckResponse_NOT_SERVING)
+	return &xdsReadinessGate{
+		logger:               logr.Discard(),
+		healthServer:         healthServer,
+		ackedFunc:            ackedFunc,
+		listenerNameTemplate: "grpc/server?xds.resource.listening_address=%s",
+		pollInterval:         time.Millisecond,
+		timeout:              time.Minute,
+	}, healthServer
+}
+
+func readinessStatus(t *testing.T, healthServer *health.Server) healthpb.HealthCheckResponse_ServingStatus {
+	t.Helper()
+	response, err := healthServer.Check(context.Background(), &healthpb.HealthCheckRequest{Service: readinessServiceName})
+	if err != nil {
+		t.Fatalf("health check: %v", err)
+	}
+	return response.GetStatus()
+}
+
+func waitForReadinessStatus(t *testing.T, healthServer *health.Server, want healthpb.HealthCheckResponse_ServingStatus) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for time.Now().Before(deadline) {
+		if readinessStatus(t, healthServer) == want {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("timed out waiting for readiness status %s, got %s", want, readinessStatus(t, healthServer))
+}
+
+func TestXDSReadinessGateServingAfterACK(t *testing.T) {
+	listenerNames := make(chan string, 1)
+	gate, healthServer := newTestReadinessGate(func(_ context.Context, listenerName string) (bool, error) {
+		select {
+		case listenerNames <- listenerName:
+		default:
+		}
+		return true, nil
+	})
+	gate.serving(testServingAddr)
+	waitForReadinessStatus(t, healthServer, healthpb.HealthCheckResponse_SERVING)
+	want := "grpc/server?xds.resource.listening_address=10.0.0.1:50051"
+	if got := <-listenerNames; got != want {
+		t.Errorf("got listener name %q, want %q", got, want)
+	}
+}
+
+// TestXDSReadinessGateNotServingAfterServing checks that a pending ACK check that completes
+// after the serving mode changed to NOT_SERVING does not set the status to SERVING.
+func TestXDSReadinessGateNotServingAfterServing(t *testing.T) {
+	checking := make(chan struct{})
+	release := make(chan struct{})
+	checked := make(chan struct{})
+	gate, healthServer := newTestReadinessGate(func(_ context.Context, _ string) (bool, error) {
+		close(checking)
+		<-release
+		defer close(checked)
+		return true, nil
+	})
+	gate.serving(testServingAddr)
+	<-checking
+	gate.notServing()
+	close(release)
+	<-checked
+	// Give the gate time to act on the result of the ACK check.
+	time.Sleep(20 * time.Millisecond)
+	if got := readinessStatus(t, healthServer); got != healthpb.HealthCheckResponse_NOT_SERVING {
+		t.Errorf("got readiness status %s after SERVING then NOT_SERVING, want %s", got, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+}
+
+func TestXDSReadinessGateTimeout(t *testing.T) {
+	gate, healthServer := newTestReadinessGate(func(_ context.Context, _ string) (bool, error) {
+		return false, nil
+	})
+	gate.timeout = 20 * time.Millisecond
+	gate.serving(testServingAddr)
+	waitForReadinessStatus(t, healthServer, healthpb.HealthCheckResponse_SERVING)
+}
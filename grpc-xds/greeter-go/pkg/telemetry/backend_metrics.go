@@ -0,0 +1,123 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"runtime/metrics"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/orca"
+)
+
+const (
+	cpuTotalMetricName = "/cpu/classes/total:cpu-seconds"
+	cpuIdleMetricName  = "/cpu/classes/idle:cpu-seconds"
+)
+
+// BackendMetricsRecorder records the backend metrics that a server reports to
+// clients using [ORCA], both per-request in trailers, and out-of-band on the
+// ORCA service.
+//
+// Clients can use these metrics for weighted round-robin load balancing.
+//
+// [ORCA]: https://github.com/grpc/proposal/blob/master/A51-custom-backend-metrics.md
+type BackendMetricsRecorder struct {
+	orca.ServerMetricsRecorder
+	servicePrefix  string
+	requests       atomic.Int64
+	errors         atomic.Int64
+	cpuSamples     []metrics.Sample
+	lastCPUTotal   float64
+	lastCPUIdle    float64
+	lastRecordTime time.Time
+}
+
+// NewBackendMetricsRecorder creates a recorder that counts requests and errors for
+// the gRPC service with the provided fully qualified name.
+//
+// A positive `applicationUtilization` is reported as the custom application
+// utilization, which clients prefer over CPU utilization.
+func NewBackendMetricsRecorder(serviceName string, applicationUtilization float64) *BackendMetricsRecorder {
+	recorder := &BackendMetricsRecorder{
+		ServerMetricsRecorder: orca.NewServerMetricsRecorder(),
+		servicePrefix:         "/" + serviceName + "/",
+		cpuSamples: []metrics.Sample{
+			{Name: cpuTotalMetricName},
+			{Name: cpuIdleMetricName},
+		},
+		lastRecordTime: time.Now(),
+	}
+	if applicationUtilization > 0 {
+		recorder.SetApplicationUtilization(applicationUtilization)
+	}
+	recorder.lastCPUTotal, recorder.lastCPUIdle = recorder.readCPU()
+	return recorder
+}
+
+// Run updates the CPU utilization, queries per second (QPS), and errors per
+// second (EPS) metrics at the provided interval, until the context is done.
+func (r *BackendMetricsRecorder) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-ticker.C:
+			r.record(now)
+		}
+	}
+}
+
+func (r *BackendMetricsRecorder) record(now time.Time) {
+	elapsedSeconds := now.Sub(r.lastRecordTime).Seconds()
+	if elapsedSeconds <= 0 {
+		return
+	}
+	r.SetQPS(float64(r.requests.Swap(0)) / elapsedSeconds)
+	r.SetEPS(float64(r.errors.Swap(0)) / elapsedSeconds)
+	cpuTotal, cpuIdle := r.readCPU()
+	if cpuTotalDelta := cpuTotal - r.lastCPUTotal; cpuTotalDelta > 0 {
+		cpuUtilization := 1 - (cpuIdle-r.lastCPUIdle)/cpuTotalDelta
+		r.SetCPUUtilization(min(max(cpuUtilization, 0), 1))
+	}
+	r.lastCPUTotal, r.lastCPUIdle = cpuTotal, cpuIdle
+	r.lastRecordTime = now
+}
+
+// readCPU returns the total available CPU time and the idle CPU time of the Go
+// runtime, in seconds. The total is based on GOMAXPROCS.
+func (r *BackendMetricsRecorder) readCPU() (float64, float64) {
+	metrics.Read(r.cpuSamples)
+	return r.cpuSamples[0].Value.Float64(), r.cpuSamples[1].Value.Float64()
+}
+
+// UnaryServerInterceptor counts requests and errors for the QPS and EPS metrics.
+func (r *BackendMetricsRecorder) UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		resp, err := handler(ctx, req)
+		if strings.HasPrefix(info.FullMethod, r.servicePrefix) {
+			r.requests.Add(1)
+			if err != nil {
+				r.errors.Add(1)
+			}
+		}
+		return resp, err
+	}
+}
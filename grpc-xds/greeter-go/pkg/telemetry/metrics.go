@@ -0,0 +1,70 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc/filters"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	otelprometheus "go.opentelemetry.io/otel/exporters/prometheus"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/resource"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"google.golang.org/grpc/stats"
+)
+
+// InitMeterProvider creates an OpenTelemetry MeterProvider that exports metrics
+// in the Prometheus exposition format, and sets it as the global MeterProvider.
+//
+// The zone of this greeter server is added as a label to all metrics, so that
+// RPC counts and latencies can be aggregated by locality.
+//
+// The returned function shuts down the MeterProvider.
+func InitMeterProvider(zone string) (func(context.Context) error, error) {
+	exporter, err := otelprometheus.New(
+		otelprometheus.WithResourceAsConstantLabels(attribute.NewAllowKeysFilter(semconv.CloudAvailabilityZoneKey)),
+	)
+	if err != nil {
+		return nil, fmt.Errorf("could not create OpenTelemetry Prometheus exporter: %w", err)
+	}
+	meterProvider := sdkmetric.NewMeterProvider(
+		sdkmetric.WithReader(exporter),
+		sdkmetric.WithResource(resource.NewSchemaless(semconv.CloudAvailabilityZone(zone))),
+	)
+	otel.SetMeterProvider(meterProvider)
+	return meterProvider.Shutdown, nil
+}
+
+// MetricsHandler serves the metrics in the Prometheus exposition format.
+func MetricsHandler() http.Handler {
+	return promhttp.Handler()
+}
+
+// ServerStatsHandler records per-RPC metrics for gRPC servers, using the global MeterProvider.
+// Health check RPCs are not recorded.
+func ServerStatsHandler() stats.Handler {
+	return otelgrpc.NewServerHandler(otelgrpc.WithFilter(filters.Not(filters.HealthCheck())))
+}
+
+// ClientStatsHandler records per-RPC metrics for gRPC clients, using the global MeterProvider.
+func ClientStatsHandler() stats.Handler {
+	return otelgrpc.NewClientHandler()
+}
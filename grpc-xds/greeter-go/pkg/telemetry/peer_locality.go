@@ -0,0 +1,96 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+)
+
+const (
+	meterName = "github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/telemetry"
+	// unknownPeerZone is the peer zone label of RPCs without a zone trailer, e.g., RPCs that
+	// failed before reaching a greeter server.
+	unknownPeerZone = "unknown"
+)
+
+type peerLocalityKey struct{}
+
+// peerLocality holds the zone of the server that handled an RPC, from the trailing metadata.
+type peerLocality struct {
+	method string
+	zone   string
+}
+
+// peerLocalityStatsHandler records the latency of client RPCs by the zone of the server that
+// handled the RPC. Greeter servers report their zone, i.e., the zone of their xDS locality,
+// in the `x-greeter-zone` trailer.
+type peerLocalityStatsHandler struct {
+	duration metric.Float64Histogram
+}
+
+// ClientPeerLocalityStatsHandler records the `greeter.client.rpc.duration` metric for gRPC
+// clients, with the `greeter.peer.zone` label set to the zone of the server that handled the
+// RPC, using the global MeterProvider. The count of the histogram is the number of RPCs.
+func ClientPeerLocalityStatsHandler() (stats.Handler, error) {
+	duration, err := otel.Meter(meterName).Float64Histogram("greeter.client.rpc.duration",
+		metric.WithDescription("Duration of client RPCs by the zone of the server that handled the RPC."),
+		metric.WithUnit("ms"))
+	if err != nil {
+		return nil, fmt.Errorf("could not create greeter client RPC duration histogram: %w", err)
+	}
+	return &peerLocalityStatsHandler{duration: duration}, nil
+}
+
+func (h *peerLocalityStatsHandler) TagRPC(ctx context.Context, info *stats.RPCTagInfo) context.Context {
+	return context.WithValue(ctx, peerLocalityKey{}, &peerLocality{
+		method: info.FullMethodName,
+		zone:   unknownPeerZone,
+	})
+}
+
+func (h *peerLocalityStatsHandler) HandleRPC(ctx context.Context, rpcStats stats.RPCStats) {
+	locality, ok := ctx.Value(peerLocalityKey{}).(*peerLocality)
+	if !ok {
+		return
+	}
+	switch s := rpcStats.(type) {
+	case *stats.InTrailer:
+		if zones := s.Trailer.Get(interceptors.ZoneTrailer); len(zones) > 0 && zones[0] != "" {
+			locality.zone = zones[0]
+		}
+	case *stats.End:
+		h.duration.Record(ctx, float64(s.EndTime.Sub(s.BeginTime).Microseconds())/1000,
+			metric.WithAttributes(
+				attribute.String("rpc.method", locality.method),
+				attribute.String("rpc.grpc.status_code", status.Code(s.Error).String()),
+				attribute.String("greeter.peer.zone", locality.zone),
+			))
+	}
+}
+
+func (h *peerLocalityStatsHandler) TagConn(ctx context.Context, _ *stats.ConnTagInfo) context.Context {
+	return ctx
+}
+
+func (h *peerLocalityStatsHandler) HandleConn(context.Context, stats.ConnStats) {}
@@ -0,0 +1,78 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package telemetry
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	sdkmetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/stats"
+	"google.golang.org/grpc/status"
+
+	"github.com/googlecloudplatform/solutions-workshops/grpc-xds/greeter-go/pkg/interceptors"
+)
+
+func TestClientPeerLocalityStatsHandler(t *testing.T) {
+	reader := sdkmetric.NewManualReader()
+	otel.SetMeterProvider(sdkmetric.NewMeterProvider(sdkmetric.WithReader(reader)))
+	handler, err := ClientPeerLocalityStatsHandler()
+	if err != nil {
+		t.Fatalf("ClientPeerLocalityStatsHandler: %v", err)
+	}
+	begin := time.Now()
+
+	// The server in zone-a handled the first RPC.
+	ctx := handler.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/helloworld.Greeter/SayHello"})
+	handler.HandleRPC(ctx, &stats.InTrailer{Client: true, Trailer: metadata.Pairs(interceptors.ZoneTrailer, "zone-a")})
+	handler.HandleRPC(ctx, &stats.End{Client: true, BeginTime: begin, EndTime: begin.Add(5 * time.Millisecond)})
+
+	// The second RPC failed without reaching a server.
+	ctx = handler.TagRPC(context.Background(), &stats.RPCTagInfo{FullMethodName: "/helloworld.Greeter/SayHello"})
+	handler.HandleRPC(ctx, &stats.End{Client: true, BeginTime: begin, EndTime: begin, Error: status.Error(codes.Unavailable, "no healthy upstream")})
+
+	var rm metricdata.ResourceMetrics
+	if err := reader.Collect(context.Background(), &rm); err != nil {
+		t.Fatalf("Collect: %v", err)
+	}
+	counts := map[string]uint64{}
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != "greeter.client.rpc.duration" {
+				continue
+			}
+			for _, dp := range m.Data.(metricdata.Histogram[float64]).DataPoints {
+				zone, _ := dp.Attributes.Value(attribute.Key("greeter.peer.zone"))
+				code, _ := dp.Attributes.Value(attribute.Key("rpc.grpc.status_code"))
+				counts[zone.AsString()+"/"+code.AsString()] += dp.Count
+			}
+		}
+	}
+	want := map[string]uint64{
+		"zone-a/OK":                      1,
+		unknownPeerZone + "/Unavailable": 1,
+	}
+	for key, count := range want {
+		if counts[key] != count {
+			t.Errorf("got count %d for %s, want %d (all counts: %v)", counts[key], key, count, counts)
+		}
+	}
+}
@@ -24,12 +24,16 @@ package bootstrap
 
 import (
 	"encoding/json"
+	"errors"
 	"fmt"
 	"os"
+	"strings"
+	"time"
 
 	v3corepb "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	"google.golang.org/grpc/credentials/tls/certprovider"
 	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/types/known/durationpb"
 )
 
 const (
@@ -45,6 +49,13 @@ const (
 	//
 	// When both bootstrap FileName and FileContent are set, FileName is used.
 	XDSBootstrapFileContentEnv = "GRPC_XDS_BOOTSTRAP_CONFIG"
+	// FileWatcherPluginName is the name of the certificate provider plugin that
+	// reads certificates and private keys from PEM files.
+	FileWatcherPluginName = "file_watcher"
+	// DefaultServerListenerResourceNameTemplate is the server Listener resource
+	// name template that the control planes in this repository expect. It must
+	// match `lds.GRPCServerListenerResourceNameTemplate` in the control plane.
+	DefaultServerListenerResourceNameTemplate = "grpc/server?xds.resource.listening_address=%s"
 )
 
 var (
@@ -68,6 +79,14 @@ var (
 
 	errNoBootstrapEnvVar = fmt.Errorf("none of the bootstrap environment variables (%q or %q) defined",
 		XDSBootstrapFileNameEnv, XDSBootstrapFileContentEnv)
+	errFileWatcherKeyPair         = errors.New("file_watcher certificate_file and private_key_file must either both be set or both be empty")
+	errFileWatcherNoFiles         = errors.New("file_watcher requires at least one of certificate_file or ca_certificate_file")
+	errFileWatcherRefreshInterval = errors.New("file_watcher refresh_interval must be positive")
+	errNoXDSServers               = errors.New("xds_servers must contain at least one xDS management server")
+	errNoXDSServerURI             = errors.New("server_uri of xDS management server must not be empty")
+	errNoChannelCreds             = errors.New("channel_creds of xDS management server must not be empty")
+	errNoServerListenerTemplate   = errors.New("server_listener_resource_name_template is missing, the xDS-enabled gRPC server cannot request its Listener")
+	errServerListenerTemplate     = errors.New("server_listener_resource_name_template does not match the control plane, the control plane will not send the server Listener")
 )
 
 // Config provides the xDS client with several key bits of information that it
@@ -77,9 +96,51 @@ type Config struct {
 	// CertProviderConfigs contains a mapping from certificate provider plugin
 	// instance names to parsed buildable configs.
 	CertProviderConfigs map[string]*certprovider.BuildableConfig
+	// FileWatcherConfigs contains the parsed configs of the certificate
+	// provider plugin instances that use the `file_watcher` plugin, by
+	// instance name.
+	FileWatcherConfigs map[string]*FileWatcherConfig
 	// NodeProto contains the Node proto to be used in xDS requests. This will be
 	// of type *v3corepb.Node.
 	NodeProto *v3corepb.Node
+	// ServerListenerResourceNameTemplate is the template for the name of the
+	// Listener resource to subscribe to for a gRPC server.
+	ServerListenerResourceNameTemplate string
+	// ServerURI is the `server_uri` of the first xDS management server in
+	// the `xds_servers` section.
+	ServerURI string
+	// XDSServers are the xDS management servers in the `xds_servers` section.
+	XDSServers []XDSServer
+}
+
+// XDSServer is an xDS management server from the `xds_servers` section.
+type XDSServer struct {
+	ServerURI string
+	// ChannelCredsTypes are the `type` values of the `channel_creds` list,
+	// e.g., `insecure` or `google_default`. gRPC uses the first supported type.
+	ChannelCredsTypes []string
+	ServerFeatures    []string
+}
+
+// FileWatcherConfig is the config of a `file_watcher` certificate provider
+// plugin instance. Empty file paths are not used by the plugin instance.
+type FileWatcherConfig struct {
+	CertificateFile   string
+	PrivateKeyFile    string
+	CACertificateFile string
+	// RefreshInterval is how often the plugin instance reloads the files.
+	RefreshInterval time.Duration
+}
+
+// Files returns the non-empty file paths of the plugin instance config.
+func (c *FileWatcherConfig) Files() []string {
+	var files []string
+	for _, file := range []string{c.CertificateFile, c.PrivateKeyFile, c.CACertificateFile} {
+		if file != "" {
+			files = append(files, file)
+		}
+	}
+	return files
 }
 
 // NewConfigPartial returns a new instance of Config initialized by reading the
@@ -90,8 +151,8 @@ type Config struct {
 // Compared to the original `NewConfig()` function in the package
 // `google.golang.org/grpc/xds/internal/xdsclient/bootstrap`,
 // ([Source]: https://github.com/grpc/grpc-go/blob/v1.57.0/xds/internal/xdsclient/bootstrap/bootstrap.go#L414)
-// this partial implementation only reads the `node` and `certificate_provider`
-// sections.
+// this partial implementation only reads the `node`, `certificate_provider`,
+// `server_listener_resource_name_template`, and `xds_servers` sections.
 //
 // We support a credential registration mechanism and only credentials
 // registered through that mechanism will be accepted here. See package
@@ -153,11 +214,34 @@ func newConfigFromContents(data []byte) (*Config, error) {
 			if err := json.Unmarshal(v, &providerInstances); err != nil {
 				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %w", string(v), k, err)
 			}
-			configs, err := parseCertificateProviders(providerInstances)
+			configs, fileWatcherConfigs, err := parseCertificateProviders(providerInstances)
 			if err != nil {
 				return nil, err
 			}
 			config.CertProviderConfigs = configs
+			config.FileWatcherConfigs = fileWatcherConfigs
+		case "server_listener_resource_name_template":
+			if err := json.Unmarshal(v, &config.ServerListenerResourceNameTemplate); err != nil {
+				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %w", string(v), k, err)
+			}
+		case "xds_servers":
+			var servers []xdsServerJSON
+			if err := json.Unmarshal(v, &servers); err != nil {
+				return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %w", string(v), k, err)
+			}
+			for _, server := range servers {
+				xdsServer := XDSServer{
+					ServerURI:      server.ServerURI,
+					ServerFeatures: server.ServerFeatures,
+				}
+				for _, channelCreds := range server.ChannelCreds {
+					xdsServer.ChannelCredsTypes = append(xdsServer.ChannelCredsTypes, channelCreds.Type)
+				}
+				config.XDSServers = append(config.XDSServers, xdsServer)
+			}
+			if len(servers) > 0 {
+				config.ServerURI = servers[0].ServerURI
+			}
 		}
 	}
 
@@ -169,15 +253,48 @@ func newConfigFromContents(data []byte) (*Config, error) {
 	return config, nil
 }
 
-func parseCertificateProviders(providerInstances map[string]json.RawMessage) (map[string]*certprovider.BuildableConfig, error) {
+// Validate checks the `xds_servers` section, and checks that the
+// `server_listener_resource_name_template` matches the server Listener
+// resource names of the control plane. The template can have an `xdstp://`
+// prefix, as used with xDS federation.
+func (c *Config) Validate() error {
+	if len(c.XDSServers) == 0 {
+		return errNoXDSServers
+	}
+	for i, server := range c.XDSServers {
+		if server.ServerURI == "" {
+			return fmt.Errorf("%w: xds_servers[%d]", errNoXDSServerURI, i)
+		}
+		if len(server.ChannelCredsTypes) == 0 {
+			return fmt.Errorf("%w: server_uri=%s", errNoChannelCreds, server.ServerURI)
+		}
+	}
+	if c.ServerListenerResourceNameTemplate == "" {
+		return errNoServerListenerTemplate
+	}
+	if !strings.HasSuffix(c.ServerListenerResourceNameTemplate, DefaultServerListenerResourceNameTemplate) {
+		return fmt.Errorf("%w: got %q, want %q", errServerListenerTemplate, c.ServerListenerResourceNameTemplate, DefaultServerListenerResourceNameTemplate)
+	}
+	return nil
+}
+
+func parseCertificateProviders(providerInstances map[string]json.RawMessage) (map[string]*certprovider.BuildableConfig, map[string]*FileWatcherConfig, error) {
 	configs := make(map[string]*certprovider.BuildableConfig)
+	fileWatcherConfigs := make(map[string]*FileWatcherConfig)
 	for instance, data := range providerInstances {
 		var nameAndConfig struct {
 			PluginName string          `json:"plugin_name"`
 			Config     json.RawMessage `json:"config"`
 		}
 		if err := json.Unmarshal(data, &nameAndConfig); err != nil {
-			return nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %w", string(data), instance, err)
+			return nil, nil, fmt.Errorf("xds: json.Unmarshal(%v) for field %q failed during bootstrap: %w", string(data), instance, err)
+		}
+		if nameAndConfig.PluginName == FileWatcherPluginName {
+			fileWatcherConfig, err := parseFileWatcherConfig(nameAndConfig.Config)
+			if err != nil {
+				return nil, nil, fmt.Errorf("xds: invalid config for certificate provider instance %q: %w", instance, err)
+			}
+			fileWatcherConfigs[instance] = fileWatcherConfig
 		}
 		bc := certprovider.NewBuildableConfig(
 			nameAndConfig.PluginName,
@@ -185,5 +302,46 @@ func parseCertificateProviders(providerInstances map[string]json.RawMessage) (ma
 			func(options certprovider.BuildOptions) certprovider.Provider { return nil })
 		configs[instance] = bc
 	}
-	return configs, nil
+	return configs, fileWatcherConfigs, nil
+}
+
+// parseFileWatcherConfig parses and validates the config of a `file_watcher` certificate
+// provider plugin instance, using the same rules as the `pemfile` package in gRPC-Go.
+// The refresh interval defaults to 10 minutes.
+//
+// [Source]: https://github.com/grpc/grpc-go/blob/v1.57.0/credentials/tls/certprovider/pemfile/builder.go
+func parseFileWatcherConfig(data json.RawMessage) (*FileWatcherConfig, error) {
+	var configJSON struct {
+		CertificateFile   string          `json:"certificate_file"`
+		PrivateKeyFile    string          `json:"private_key_file"`
+		CACertificateFile string          `json:"ca_certificate_file"`
+		RefreshInterval   json.RawMessage `json:"refresh_interval"`
+	}
+	if err := json.Unmarshal(data, &configJSON); err != nil {
+		return nil, fmt.Errorf("json.Unmarshal(%v) failed: %w", string(data), err)
+	}
+	config := &FileWatcherConfig{
+		CertificateFile:   configJSON.CertificateFile,
+		PrivateKeyFile:    configJSON.PrivateKeyFile,
+		CACertificateFile: configJSON.CACertificateFile,
+		RefreshInterval:   defaultCertificateRefreshInterval,
+	}
+	if (config.CertificateFile == "") != (config.PrivateKeyFile == "") {
+		return nil, errFileWatcherKeyPair
+	}
+	if config.CertificateFile == "" && config.CACertificateFile == "" {
+		return nil, errFileWatcherNoFiles
+	}
+	if len(configJSON.RefreshInterval) > 0 {
+		// JSON representation of a protobuf Duration, e.g., `600s`.
+		refreshInterval := &durationpb.Duration{}
+		if err := protojson.Unmarshal(configJSON.RefreshInterval, refreshInterval); err != nil {
+			return nil, fmt.Errorf("could not parse refresh_interval %s: %w", string(configJSON.RefreshInterval), err)
+		}
+		config.RefreshInterval = refreshInterval.AsDuration()
+	}
+	if config.RefreshInterval <= 0 {
+		return nil, fmt.Errorf("%w: %s", errFileWatcherRefreshInterval, config.RefreshInterval)
+	}
+	return config, nil
 }
@@ -0,0 +1,198 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseFileWatcherConfig(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  string
+		want    FileWatcherConfig
+		wantErr error
+		// wantAnyErr is for parse errors without a sentinel error.
+		wantAnyErr bool
+	}{
+		{
+			name:   "certificate, private key, and CA certificate with default refresh interval",
+			config: `{"certificate_file": "/certs/tls.crt", "private_key_file": "/certs/tls.key", "ca_certificate_file": "/certs/ca.crt"}`,
+			want: FileWatcherConfig{
+				CertificateFile:   "/certs/tls.crt",
+				PrivateKeyFile:    "/certs/tls.key",
+				CACertificateFile: "/certs/ca.crt",
+				RefreshInterval:   defaultCertificateRefreshInterval,
+			},
+		},
+		{
+			name:   "CA certificate only with refresh interval",
+			config: `{"ca_certificate_file": "/certs/ca.crt", "refresh_interval": "30s"}`,
+			want: FileWatcherConfig{
+				CACertificateFile: "/certs/ca.crt",
+				RefreshInterval:   30 * time.Second,
+			},
+		},
+		{
+			name:   "certificate and private key only",
+			config: `{"certificate_file": "/certs/tls.crt", "private_key_file": "/certs/tls.key"}`,
+			want: FileWatcherConfig{
+				CertificateFile: "/certs/tls.crt",
+				PrivateKeyFile:  "/certs/tls.key",
+				RefreshInterval: defaultCertificateRefreshInterval,
+			},
+		},
+		{
+			name:    "certificate without private key",
+			config:  `{"certificate_file": "/certs/tls.crt", "ca_certificate_file": "/certs/ca.crt"}`,
+			wantErr: errFileWatcherKeyPair,
+		},
+		{
+			name:    "private key without certificate",
+			config:  `{"private_key_file": "/certs/tls.key", "ca_certificate_file": "/certs/ca.crt"}`,
+			wantErr: errFileWatcherKeyPair,
+		},
+		{
+			name:    "no files",
+			config:  `{"refresh_interval": "30s"}`,
+			wantErr: errFileWatcherNoFiles,
+		},
+		{
+			name:    "zero refresh interval",
+			config:  `{"ca_certificate_file": "/certs/ca.crt", "refresh_interval": "0s"}`,
+			wantErr: errFileWatcherRefreshInterval,
+		},
+		{
+			name:    "negative refresh interval",
+			config:  `{"ca_certificate_file": "/certs/ca.crt", "refresh_interval": "-30s"}`,
+			wantErr: errFileWatcherRefreshInterval,
+		},
+		{
+			name:       "refresh interval is not a duration",
+			config:     `{"ca_certificate_file": "/certs/ca.crt", "refresh_interval": "30"}`,
+			wantAnyErr: true,
+		},
+		{
+			name:       "invalid JSON",
+			config:     `{"ca_certificate_file": `,
+			wantAnyErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := parseFileWatcherConfig(json.RawMessage(test.config))
+			if test.wantErr == nil && !test.wantAnyErr && err != nil {
+				t.Fatalf("parseFileWatcherConfig() unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Fatalf("parseFileWatcherConfig() error = %v, want %v", err, test.wantErr)
+			}
+			if test.wantAnyErr && err == nil {
+				t.Fatalf("parseFileWatcherConfig() = %+v, want error", got)
+			}
+			if err != nil {
+				return
+			}
+			if *got != test.want {
+				t.Errorf("parseFileWatcherConfig() = %+v, want %+v", *got, test.want)
+			}
+		})
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	validServers := []XDSServer{
+		{
+			ServerURI:         "dns:///control-plane.xds:50051",
+			ChannelCredsTypes: []string{"insecure"},
+		},
+	}
+	tests := []struct {
+		name    string
+		config  Config
+		wantErr error
+	}{
+		{
+			name: "valid",
+			config: Config{
+				XDSServers:                         validServers,
+				ServerListenerResourceNameTemplate: DefaultServerListenerResourceNameTemplate,
+			},
+		},
+		{
+			name: "valid with xdstp server listener resource name template",
+			config: Config{
+				XDSServers:                         validServers,
+				ServerListenerResourceNameTemplate: "xdstp://control-plane.xds.svc.cluster.example.com/envoy.config.listener.v3.Listener/" + DefaultServerListenerResourceNameTemplate,
+			},
+		},
+		{
+			name: "no xDS servers",
+			config: Config{
+				ServerListenerResourceNameTemplate: DefaultServerListenerResourceNameTemplate,
+			},
+			wantErr: errNoXDSServers,
+		},
+		{
+			name: "empty server URI of second xDS server",
+			config: Config{
+				XDSServers: append(validServers, XDSServer{
+					ChannelCredsTypes: []string{"insecure"},
+				}),
+				ServerListenerResourceNameTemplate: DefaultServerListenerResourceNameTemplate,
+			},
+			wantErr: errNoXDSServerURI,
+		},
+		{
+			name: "no channel credentials",
+			config: Config{
+				XDSServers: []XDSServer{
+					{ServerURI: "dns:///control-plane.xds:50051"},
+				},
+				ServerListenerResourceNameTemplate: DefaultServerListenerResourceNameTemplate,
+			},
+			wantErr: errNoChannelCreds,
+		},
+		{
+			name: "no server listener resource name template",
+			config: Config{
+				XDSServers: validServers,
+			},
+			wantErr: errNoServerListenerTemplate,
+		},
+		{
+			name: "server listener resource name template of another control plane",
+			config: Config{
+				XDSServers:                         validServers,
+				ServerListenerResourceNameTemplate: "grpc/server?udpa.resource.listening_address=%s",
+			},
+			wantErr: errServerListenerTemplate,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := test.config.Validate()
+			if test.wantErr == nil && err != nil {
+				t.Fatalf("Validate() unexpected error: %v", err)
+			}
+			if test.wantErr != nil && !errors.Is(err, test.wantErr) {
+				t.Fatalf("Validate() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}
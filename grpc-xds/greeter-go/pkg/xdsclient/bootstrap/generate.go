@@ -0,0 +1,261 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"maps"
+	"strconv"
+	"time"
+)
+
+const (
+	// ControlPlaneDIY uses a self-managed xDS control plane management server,
+	// such as the control planes in this repository.
+	ControlPlaneDIY = "diy"
+	// ControlPlaneTrafficDirector uses Traffic Director as the xDS control plane.
+	ControlPlaneTrafficDirector = "traffic-director"
+
+	trafficDirectorServerURI = "trafficdirector.googleapis.com:443"
+	trafficDirectorAuthority = "traffic-director-global.xds.googleapis.com"
+
+	certificateProviderInstanceName   = "google_cloud_private_spiffe"
+	workloadCredentialsDir            = "/var/run/secrets/workload-spiffe-credentials"
+	defaultCertificateRefreshInterval = 600 * time.Second
+)
+
+var (
+	errUnknownControlPlane = errors.New("control plane must be one of diy or traffic-director")
+	errNoServerURI         = errors.New("server URI is required for the diy control plane")
+	errNoProjectNumber     = errors.New("project number is required for the traffic-director control plane")
+	errNoMeshName          = errors.New("mesh name is required for the traffic-director control plane")
+)
+
+// GenerateOptions are the inputs to `Generate()`.
+type GenerateOptions struct {
+	// ControlPlane is either `ControlPlaneDIY` or `ControlPlaneTrafficDirector`.
+	ControlPlane string
+	// ServerURI of the default xDS management server, e.g., `dns:///control-plane.xds:50051`.
+	// Only used with `ControlPlaneDIY`.
+	ServerURI string
+	// Authorities are the federation authorities of other self-managed xDS management servers,
+	// e.g., `control-plane.xds.svc.cluster2.example.com`. Clients connect to each authority on
+	// the AuthorityPort. Only used with `ControlPlaneDIY`.
+	Authorities   []string
+	AuthorityPort int
+	// ProjectNumber and MeshName identify the Traffic Director mesh.
+	// Only used with `ControlPlaneTrafficDirector`.
+	ProjectNumber string
+	MeshName      string
+	// Federation adds the Traffic Director xDS authority and uses `xdstp://` listener
+	// resource names. Only used with `ControlPlaneTrafficDirector`.
+	Federation   bool
+	NodeID       string
+	NodeCluster  string
+	Zone         string
+	NodeMetadata map[string]string
+	// CertificateFile, PrivateKeyFile, and CACertificateFile are the paths of the workload
+	// certificate files, e.g., files mounted by the cert-manager CSI driver or written by a
+	// SPIRE agent. Default to the files in `/var/run/secrets/workload-spiffe-credentials`.
+	CertificateFile   string
+	PrivateKeyFile    string
+	CACertificateFile string
+	// CertificateRefreshInterval is how often gRPC reloads the certificate files.
+	// Defaults to 10 minutes.
+	CertificateRefreshInterval time.Duration
+}
+
+// Generate creates a gRPC xDS bootstrap configuration in JSON format.
+//
+// The output is equivalent to the bootstrap configuration files created by the init containers
+// in the `k8s/greeter/components/bootstrap-*` Kustomize components.
+func Generate(opts GenerateOptions) ([]byte, error) {
+	var config *bootstrapJSON
+	var err error
+	switch opts.ControlPlane {
+	case ControlPlaneDIY:
+		config, err = generateDIY(opts)
+	case ControlPlaneTrafficDirector:
+		config, err = generateTrafficDirector(opts)
+	default:
+		err = fmt.Errorf("%w: controlPlane=%s", errUnknownControlPlane, opts.ControlPlane)
+	}
+	if err != nil {
+		return nil, err
+	}
+	config.CertificateProviders = map[string]certificateProviderJSON{
+		certificateProviderInstanceName: {
+			PluginName: FileWatcherPluginName,
+			Config:     newFileWatcherConfig(opts),
+		},
+	}
+	config.ServerListenerResourceNameTemplate = DefaultServerListenerResourceNameTemplate
+	data, err := json.MarshalIndent(config, "", "  ")
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal gRPC xDS bootstrap configuration: %w", err)
+	}
+	return append(data, '\n'), nil
+}
+
+// newFileWatcherConfig returns the certificate file paths and refresh interval, with defaults
+// for the workload certificates provisioned by GKE.
+func newFileWatcherConfig(opts GenerateOptions) fileWatcherConfigJSON {
+	config := fileWatcherConfigJSON{
+		CACertificateFile: workloadCredentialsDir + "/ca_certificates.pem",
+		CertificateFile:   workloadCredentialsDir + "/certificates.pem",
+		PrivateKeyFile:    workloadCredentialsDir + "/private_key.pem",
+	}
+	if opts.CACertificateFile != "" {
+		config.CACertificateFile = opts.CACertificateFile
+	}
+	if opts.CertificateFile != "" {
+		config.CertificateFile = opts.CertificateFile
+	}
+	if opts.PrivateKeyFile != "" {
+		config.PrivateKeyFile = opts.PrivateKeyFile
+	}
+	refreshInterval := defaultCertificateRefreshInterval
+	if opts.CertificateRefreshInterval > 0 {
+		refreshInterval = opts.CertificateRefreshInterval
+	}
+	// JSON representation of a protobuf Duration, e.g., `600s`.
+	config.RefreshInterval = strconv.FormatFloat(refreshInterval.Seconds(), 'f', -1, 64) + "s"
+	return config
+}
+
+func generateDIY(opts GenerateOptions) (*bootstrapJSON, error) {
+	if opts.ServerURI == "" {
+		return nil, errNoServerURI
+	}
+	metadata := map[string]string{
+		"XDS_STREAM_TYPE": "ADS",
+	}
+	maps.Copy(metadata, opts.NodeMetadata)
+	config := &bootstrapJSON{
+		XDSServers: []xdsServerJSON{newXDSServer(opts.ServerURI, "insecure")},
+		Node:       newNode(opts.NodeID, opts.NodeCluster, opts.Zone, metadata),
+	}
+	if len(opts.Authorities) > 0 {
+		config.Authorities = map[string]authorityJSON{}
+		for _, authority := range opts.Authorities {
+			serverURI := fmt.Sprintf("dns:///%s:%d", authority, opts.AuthorityPort)
+			config.Authorities[authority] = authorityJSON{
+				XDSServers:                         []xdsServerJSON{newXDSServer(serverURI, "insecure")},
+				ClientListenerResourceNameTemplate: fmt.Sprintf("xdstp://%s/envoy.config.listener.v3.Listener/%%s", authority),
+			}
+		}
+		config.ClientDefaultListenerResourceNameTemplate = "%s"
+	}
+	return config, nil
+}
+
+func generateTrafficDirector(opts GenerateOptions) (*bootstrapJSON, error) {
+	if opts.ProjectNumber == "" {
+		return nil, errNoProjectNumber
+	}
+	if opts.MeshName == "" {
+		return nil, errNoMeshName
+	}
+	metadata := map[string]string{
+		"TRAFFICDIRECTOR_GCP_PROJECT_NUMBER": opts.ProjectNumber,
+		"TRAFFICDIRECTOR_MESH_SCOPE_NAME":    opts.MeshName,
+	}
+	maps.Copy(metadata, opts.NodeMetadata)
+	nodeID := fmt.Sprintf("projects/%s/networks/mesh:%s/nodes/%s", opts.ProjectNumber, opts.MeshName, opts.NodeID)
+	config := &bootstrapJSON{
+		XDSServers: []xdsServerJSON{newXDSServer(trafficDirectorServerURI, "google_default")},
+		Node:       newNode(nodeID, opts.NodeCluster, opts.Zone, metadata),
+	}
+	if opts.Federation {
+		listenerTemplate := fmt.Sprintf("xdstp://%s/envoy.config.listener.v3.Listener/%s/mesh:%s/%%s", trafficDirectorAuthority, opts.ProjectNumber, opts.MeshName)
+		config.Authorities = map[string]authorityJSON{
+			trafficDirectorAuthority: {
+				ClientListenerResourceNameTemplate: listenerTemplate,
+			},
+		}
+		config.ClientDefaultListenerResourceNameTemplate = listenerTemplate
+	}
+	return config, nil
+}
+
+func newXDSServer(serverURI string, channelCredsType string) xdsServerJSON {
+	return xdsServerJSON{
+		ServerURI:      serverURI,
+		ChannelCreds:   []channelCredsJSON{{Type: channelCredsType}},
+		ServerFeatures: []string{"xds_v3", "ignore_resource_deletion"},
+	}
+}
+
+func newNode(id string, cluster string, zone string, metadata map[string]string) nodeJSON {
+	node := nodeJSON{
+		ID:       id,
+		Cluster:  cluster,
+		Metadata: metadata,
+	}
+	if zone != "" {
+		node.Locality = &localityJSON{Zone: zone}
+	}
+	return node
+}
+
+// bootstrapJSON is the subset of the gRPC xDS bootstrap configuration format used by the greeter applications.
+type bootstrapJSON struct {
+	XDSServers                                []xdsServerJSON                    `json:"xds_servers"`
+	Authorities                               map[string]authorityJSON           `json:"authorities,omitempty"`
+	Node                                      nodeJSON                           `json:"node"`
+	CertificateProviders                      map[string]certificateProviderJSON `json:"certificate_providers,omitempty"`
+	ServerListenerResourceNameTemplate        string                             `json:"server_listener_resource_name_template,omitempty"`
+	ClientDefaultListenerResourceNameTemplate string                             `json:"client_default_listener_resource_name_template,omitempty"`
+}
+
+type xdsServerJSON struct {
+	ServerURI      string             `json:"server_uri"`
+	ChannelCreds   []channelCredsJSON `json:"channel_creds"`
+	ServerFeatures []string           `json:"server_features"`
+}
+
+type channelCredsJSON struct {
+	Type string `json:"type"`
+}
+
+type authorityJSON struct {
+	XDSServers                         []xdsServerJSON `json:"xds_servers,omitempty"`
+	ClientListenerResourceNameTemplate string          `json:"client_listener_resource_name_template"`
+}
+
+type nodeJSON struct {
+	ID       string            `json:"id"`
+	Cluster  string            `json:"cluster,omitempty"`
+	Metadata map[string]string `json:"metadata,omitempty"`
+	Locality *localityJSON     `json:"locality,omitempty"`
+}
+
+type localityJSON struct {
+	Zone string `json:"zone"`
+}
+
+type certificateProviderJSON struct {
+	PluginName string                `json:"plugin_name"`
+	Config     fileWatcherConfigJSON `json:"config"`
+}
+
+type fileWatcherConfigJSON struct {
+	CACertificateFile string `json:"ca_certificate_file"`
+	CertificateFile   string `json:"certificate_file"`
+	PrivateKeyFile    string `json:"private_key_file"`
+	RefreshInterval   string `json:"refresh_interval"`
+}
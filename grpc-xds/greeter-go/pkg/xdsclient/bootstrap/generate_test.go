@@ -0,0 +1,154 @@
+// Copyright 2024 Google LLC
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//      http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bootstrap
+
+import (
+	"bytes"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "update the golden files in testdata/")
+
+func TestGenerateGolden(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GenerateOptions
+	}{
+		{
+			name: "diy",
+			opts: GenerateOptions{
+				ControlPlane: ControlPlaneDIY,
+				ServerURI:    "dns:///control-plane.xds:50051",
+				NodeID:       "b7f9c818-fb46-43ca-8662-d3bdbcf7ec18",
+				NodeCluster:  "greeter-leaf",
+				Zone:         "us-central1-a",
+				NodeMetadata: map[string]string{"POD_NAME": "greeter-leaf-abcde"},
+			},
+		},
+		{
+			name: "diy_federation",
+			opts: GenerateOptions{
+				ControlPlane:  ControlPlaneDIY,
+				ServerURI:     "dns:///control-plane.xds:50051",
+				Authorities:   []string{"control-plane.xds.svc.cluster2.example.com"},
+				AuthorityPort: 50051,
+				NodeID:        "b7f9c818-fb46-43ca-8662-d3bdbcf7ec18",
+			},
+		},
+		{
+			name: "diy_certificate_files",
+			opts: GenerateOptions{
+				ControlPlane:               ControlPlaneDIY,
+				ServerURI:                  "dns:///control-plane.xds:50051",
+				NodeID:                     "b7f9c818-fb46-43ca-8662-d3bdbcf7ec18",
+				CertificateFile:            "/var/run/secrets/workload-certs/tls.crt",
+				PrivateKeyFile:             "/var/run/secrets/workload-certs/tls.key",
+				CACertificateFile:          "/var/run/secrets/workload-certs/ca.crt",
+				CertificateRefreshInterval: 90 * time.Second,
+			},
+		},
+		{
+			name: "traffic_director",
+			opts: GenerateOptions{
+				ControlPlane:  ControlPlaneTrafficDirector,
+				ProjectNumber: "123456789012",
+				MeshName:      "grpc-mesh",
+				NodeID:        "b7f9c818-fb46-43ca-8662-d3bdbcf7ec18",
+				Zone:          "us-central1-a",
+			},
+		},
+		{
+			name: "traffic_director_federation",
+			opts: GenerateOptions{
+				ControlPlane:  ControlPlaneTrafficDirector,
+				ProjectNumber: "123456789012",
+				MeshName:      "grpc-mesh",
+				Federation:    true,
+				NodeID:        "b7f9c818-fb46-43ca-8662-d3bdbcf7ec18",
+				Zone:          "us-central1-a",
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := Generate(test.opts)
+			if err != nil {
+				t.Fatalf("Generate() unexpected error: %v", err)
+			}
+			// The greeter applications must accept the generated configuration.
+			config, err := newConfigFromContents(got)
+			if err != nil {
+				t.Fatalf("could not parse generated bootstrap configuration: %v", err)
+			}
+			if err := config.Validate(); err != nil {
+				t.Errorf("generated bootstrap configuration is invalid: %v", err)
+			}
+			goldenFile := filepath.Join("testdata", test.name+".golden.json")
+			if *update {
+				if err := os.WriteFile(goldenFile, got, 0o644); err != nil {
+					t.Fatalf("could not update golden file %s: %v", goldenFile, err)
+				}
+			}
+			want, err := os.ReadFile(goldenFile)
+			if err != nil {
+				t.Fatalf("could not read golden file %s, run with -update to create it: %v", goldenFile, err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("bootstrap configuration does not match golden file %s, run with -update if the change is intended\ngot:\n%s", goldenFile, got)
+			}
+		})
+	}
+}
+
+func TestGenerateErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		opts    GenerateOptions
+		wantErr error
+	}{
+		{
+			name:    "unknown control plane",
+			opts:    GenerateOptions{ControlPlane: "istio"},
+			wantErr: errUnknownControlPlane,
+		},
+		{
+			name:    "diy without server URI",
+			opts:    GenerateOptions{ControlPlane: ControlPlaneDIY},
+			wantErr: errNoServerURI,
+		},
+		{
+			name:    "traffic-director without project number",
+			opts:    GenerateOptions{ControlPlane: ControlPlaneTrafficDirector, MeshName: "grpc-mesh"},
+			wantErr: errNoProjectNumber,
+		},
+		{
+			name:    "traffic-director without mesh name",
+			opts:    GenerateOptions{ControlPlane: ControlPlaneTrafficDirector, ProjectNumber: "123456789012"},
+			wantErr: errNoMeshName,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if _, err := Generate(test.opts); !errors.Is(err, test.wantErr) {
+				t.Errorf("Generate() error = %v, want %v", err, test.wantErr)
+			}
+		})
+	}
+}